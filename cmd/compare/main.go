@@ -0,0 +1,239 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/wildfunctions/genetic_series/pkg/expr"
+	"github.com/wildfunctions/genetic_series/pkg/series"
+)
+
+// minEquivDigits is the agreement, in digits, below which two sums are
+// never called equivalent, however little either has converged.
+const minEquivDigits = 10
+
+func main() {
+	var (
+		a, b     string
+		fileA    string
+		fileB    string
+		maxTerms int64
+		prec     uint
+		digits   int
+		terms    int
+		warn     bool
+		werror   bool
+	)
+
+	flag.StringVar(&a, "a", "", "first LaTeX formula")
+	flag.StringVar(&b, "b", "", "second LaTeX formula")
+	flag.StringVar(&fileA, "file-a", "", "file containing the first formula")
+	flag.StringVar(&fileB, "file-b", "", "file containing the second formula")
+	flag.Int64Var(&maxTerms, "maxterms", 4096, "terms to sum of each")
+	flag.UintVar(&prec, "precision", 512, "precision in bits, the same for both")
+	flag.IntVar(&digits, "digits", 50, "significant digits to print")
+	flag.IntVar(&terms, "terms", 8, "term ratios to print")
+	flag.BoolVar(&warn, "W", false, "print warnings to stderr for input the parser or simplifier normalized in ways that can change the value (implicit products by numbers, unbraced exponents, rounded constants, cancelled subtrees)")
+	flag.BoolVar(&werror, "Werror", false, "as -W, then exit with status 1 if there were any warnings")
+	flag.Parse()
+
+	a, b = readFormula(a, fileA), readFormula(b, fileB)
+	if a == "" || b == "" {
+		fmt.Fprintln(os.Stderr, "usage: compare -a '\\sum ...' -b '\\sum ...' [-maxterms 4096] [-precision 512] [-digits 50] [-terms 8]")
+		os.Exit(1)
+	}
+	ca, wa, err := series.ParseCandidateLatexWarnings(a)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "parse error in -a: %v\n", err)
+		os.Exit(1)
+	}
+	cb, wb, err := series.ParseCandidateLatexWarnings(b)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "parse error in -b: %v\n", err)
+		os.Exit(1)
+	}
+	if warn || werror {
+		wa = append(wa, ca.SimplifyWarnings(prec)...)
+		wb = append(wb, cb.SimplifyWarnings(prec)...)
+		expr.WriteWarnings(os.Stderr, "A: ", wa)
+		expr.WriteWarnings(os.Stderr, "B: ", wb)
+		if werror && len(wa)+len(wb) > 0 {
+			fmt.Fprintf(os.Stderr, "%d warnings, failing for -Werror\n", len(wa)+len(wb))
+			os.Exit(1)
+		}
+	}
+
+	ka, kb := series.Canonical(ca), series.Canonical(cb)
+	fmt.Printf("A: %s\n   canonical %s\n", ca.LaTeX(), ka)
+	fmt.Printf("B: %s\n   canonical %s\n", cb.LaTeX(), kb)
+	same := ka.String() == kb.String()
+	fmt.Println("\nStructural diff of the canonical forms, A → B:")
+	writeDiff(ka, kb)
+
+	fmt.Printf("\nSums of %d terms at %d bits:\n", maxTerms, prec)
+	sa, da := sumOf(ca, maxTerms, prec)
+	sb, db := sumOf(cb, maxTerms, prec)
+	printSum("A", sa, da, digits)
+	printSum("B", sb, db, digits)
+	agree := 0.0
+	if sa != nil && sb != nil {
+		agree = agreeDigits(sa, sb)
+		fmt.Printf("  agree to %.1f digits\n", agree)
+	}
+
+	fmt.Println("\nTerm ratios a_A(k)/a_B(k), k-th term from each start:")
+	ratios := termRatios(ca, cb, terms, prec)
+	for k, r := range ratios {
+		if r == nil {
+			fmt.Printf("  k=%d: undefined\n", k)
+			continue
+		}
+		fmt.Printf("  k=%d: %s\n", k, r.Text('g', min(digits, 20)))
+	}
+
+	fmt.Print("\nVerdict: ")
+	switch {
+	case same:
+		fmt.Println("identical (same canonical form)")
+	case sa != nil && sb != nil && agree >= math.Min(da, db)-1:
+		if agree >= minEquivDigits {
+			fmt.Printf("numerically equivalent (sums agree to %.1f digits, as far as both have converged)\n", agree)
+		} else {
+			fmt.Printf("undecided (sums agree to %.1f digits, as far as both have converged; raise -maxterms)\n", agree)
+		}
+	case constantRatio(ratios):
+		fmt.Printf("termwise proportional (every ratio is %s) but the sums differ\n", ratios[0].Text('g', 20))
+	default:
+		fmt.Println("different")
+	}
+}
+
+// readFormula returns formula, or the contents of file if formula is empty.
+func readFormula(formula, file string) string {
+	if formula != "" || file == "" {
+		return formula
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error reading %s: %v\n", file, err)
+		os.Exit(1)
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// sumOf returns c's partial sum to maxTerms and the digits it shares with
+// the sum to half as many, a measure of how far it has converged; or nil
+// if it fails to evaluate.
+func sumOf(c *series.Candidate, maxTerms int64, prec uint) (*big.Float, float64) {
+	opts := series.EvalOptions{MaxTerms: maxTerms, Prec: prec}
+	r := series.BigFloatEvaluator{}.Evaluate(c, opts)
+	if !r.OK {
+		return nil, 0
+	}
+	opts.MaxTerms = max(maxTerms/2, 1)
+	half := series.BigFloatEvaluator{}.Evaluate(c, opts)
+	if !half.OK {
+		return r.PartialSum, 0
+	}
+	return r.PartialSum, agreeDigits(r.PartialSum, half.PartialSum)
+}
+
+func printSum(name string, s *big.Float, converged float64, digits int) {
+	if s == nil {
+		fmt.Printf("  %s: fails to evaluate\n", name)
+		return
+	}
+	fmt.Printf("  %s = %s  (%.1f digits stable from half the terms)\n", name, s.Text('g', digits), converged)
+}
+
+// agreeDigits returns the decimal digits x and y share, relative to y; the
+// agreement of exactly equal values is the precision in digits.
+func agreeDigits(x, y *big.Float) float64 {
+	d := new(big.Float).SetPrec(x.Prec()).Sub(x, y)
+	if d.Sign() == 0 {
+		return float64(x.Prec()) * math.Log10(2)
+	}
+	ref := new(big.Float).Abs(y)
+	if ref.Sign() == 0 {
+		ref.SetInt64(1)
+	}
+	d.Quo(d.Abs(d), ref)
+	mant := new(big.Float)
+	exp := d.MantExp(mant)
+	m, _ := mant.Float64()
+	return math.Max(0, -(math.Log10(m) + float64(exp)*math.Log10(2)))
+}
+
+// termRatios returns a_A(k)/a_B(k) for the first k terms of each series,
+// nil where either term is undefined or a_B(k) is 0.
+func termRatios(a, b *series.Candidate, k int, prec uint) []*big.Float {
+	out := make([]*big.Float, k)
+	for i := range out {
+		ta, tb := term(a, a.Start+int64(i), prec), term(b, b.Start+int64(i), prec)
+		if ta != nil && tb != nil && tb.Sign() != 0 {
+			out[i] = ta.Quo(ta, tb)
+		}
+	}
+	return out
+}
+
+func term(c *series.Candidate, n int64, prec uint) *big.Float {
+	x := new(big.Float).SetPrec(prec).SetInt64(n)
+	num, ok1 := c.Numerator.Eval(x, prec)
+	den, ok2 := c.Denominator.Eval(x, prec)
+	if !ok1 || !ok2 || den.Sign() == 0 {
+		return nil
+	}
+	return new(big.Float).SetPrec(prec).Quo(num, den)
+}
+
+// constantRatio reports whether every ratio is defined and equal to the
+// first to 20 digits.
+func constantRatio(rs []*big.Float) bool {
+	if len(rs) < 2 || rs[0] == nil {
+		return false
+	}
+	for _, r := range rs[1:] {
+		if r == nil || agreeDigits(r, rs[0]) < 20 {
+			return false
+		}
+	}
+	return true
+}
+
+// writeDiff prints the start, offset and subtree changes from a to b.
+func writeDiff(a, b *series.Candidate) {
+	same := true
+	if a.Start != b.Start {
+		fmt.Printf("  start: %d → %d\n", a.Start, b.Start)
+		same = false
+	}
+	if x, y := offsetString(a), offsetString(b); x != y {
+		fmt.Printf("  offset: %s → %s\n", x, y)
+		same = false
+	}
+	for _, part := range []struct {
+		name string
+		a, b expr.ExprNode
+	}{{"numerator", a.Numerator, b.Numerator}, {"denominator", a.Denominator, b.Denominator}} {
+		for _, c := range expr.Diff(part.a, part.b) {
+			fmt.Printf("  %s node %d:\n    - %s\n    + %s\n", part.name, c.Index, c.Old, c.New)
+			same = false
+		}
+	}
+	if same {
+		fmt.Println("  none")
+	}
+}
+
+// offsetString is c's offset for writeDiff, or "none".
+func offsetString(c *series.Candidate) string {
+	if c.Offset == nil {
+		return "none"
+	}
+	return c.Offset.String()
+}
@@ -1,14 +1,17 @@
 package main
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
 	"math"
 	"math/big"
 	"os"
+	"strconv"
 	"strings"
 
 	"github.com/wildfunctions/genetic_series/pkg/constants"
+	"github.com/wildfunctions/genetic_series/pkg/expr"
 	"github.com/wildfunctions/genetic_series/pkg/series"
 )
 
@@ -20,16 +23,59 @@ func main() {
 		targetV  string
 		maxTerms int64
 		prec     uint
+		binSplit bool
+		backend  string
+		evalName string
+		digits   int
+		dot      bool
+		tree     bool
+		features bool
+		cache    string
+		explain  string
+		curve    string
+		sens     int64
+		consist  bool
+		identTol float64
+		split    int64
+		xform    string
+		bfile    string
+		bterms   int
+		warn     bool
+		werror   bool
 	)
 
 	flag.StringVar(&formula, "formula", "", "LaTeX formula to evaluate")
 	flag.StringVar(&file, "file", "", "file containing LaTeX formula")
-	flag.StringVar(&target, "target", "", "named constant to compare ("+strings.Join(constants.Names(), ", ")+")")
-	flag.StringVar(&targetV, "target-value", "", "explicit target value (decimal string)")
+	flag.StringVar(&target, "target", "", "target to compare: a constant ("+strings.Join(constants.Names(), ", ")+"), an expression such as pi^2/6, digits, or file:path")
+	flag.StringVar(&targetV, "target-value", "", "explicit target value (decimal string); same as -target with digits")
 	flag.Int64Var(&maxTerms, "maxterms", 4096, "max terms to sum")
 	flag.UintVar(&prec, "precision", 512, "precision in bits")
+	flag.BoolVar(&binSplit, "binsplit", false, "sum to full precision by binary splitting (hypergeometric series only)")
+	flag.StringVar(&backend, "backend", "", "numeric backend for plain summation ("+strings.Join(series.SumBackends(), ", ")+"); default is the search evaluator")
+	flag.StringVar(&evalName, "evaluator", "big", "search evaluator ("+strings.Join(series.EvaluatorNames(), ", ")+")")
+	flag.IntVar(&digits, "digits", 50, "significant digits to print")
+	flag.BoolVar(&dot, "dot", false, "print the term numerator/denominator as a Graphviz DOT graph and exit (pipe to dot -Tsvg)")
+	flag.StringVar(&explain, "explain", "", "print an annotated per-term trace (text, json) and exit")
+	flag.StringVar(&curve, "curve", "", "print the digits agreeing with the target after 1, 2, 4, ... terms up to -maxterms (text, json) and exit")
+	flag.Int64Var(&sens, "sensitivity", 0, "shift each integer constant by ±1..±k, report the digits left against the target, and exit")
+	flag.BoolVar(&consist, "consistency", false, "evaluate from start and start+1 and at twice the terms, report whether they agree with each other and the target, and exit")
+	flag.Float64Var(&identTol, "identify-tol", 1e-12, "relative tolerance for suggesting closed forms (p/q·√k or p/q·constant) of the sum")
+	flag.Int64Var(&split, "split", 0, "split the first k terms off the sum (k < 0: absorb -k earlier terms), print both parts, and exit")
+	flag.StringVar(&xform, "transform", "", "print an equivalent form of the sum and exit: pair (consecutive terms added), parity (even plus odd terms) or reverse:k (the first k terms in reverse order)")
+	flag.StringVar(&bfile, "bfile", "", "write OEIS b-files of the reduced term numerators and denominators to <base>.num.txt and <base>.den.txt, and exit")
+	flag.IntVar(&bterms, "bfile-terms", 1000, "terms to export with -bfile (fewer if a term is not an exact rational or has over 1000 digits)")
+	flag.BoolVar(&tree, "tree", false, "print the term numerator/denominator as an ASCII tree and exit")
+	flag.BoolVar(&features, "features", false, "print the candidate's feature vector (series.FeatureNames) as name<TAB>value lines and exit")
+	flag.StringVar(&cache, "constcache", "", "directory caching constants computed past their stored digits between runs (empty = disabled)")
+	flag.BoolVar(&warn, "W", false, "print warnings to stderr for input the parser or simplifier normalized in ways that can change the value (implicit products by numbers, unbraced exponents, rounded constants, cancelled subtrees)")
+	flag.BoolVar(&werror, "Werror", false, "as -W, then exit with status 1 if there were any warnings")
 	flag.Parse()
 
+	if err := constants.SetCacheDir(cache); err != nil {
+		fmt.Fprintf(os.Stderr, "constant cache: %v\n", err)
+		os.Exit(1)
+	}
+
 	// Read formula from flag or file.
 	if formula == "" && file != "" {
 		data, err := os.ReadFile(file)
@@ -40,54 +86,246 @@ func main() {
 		formula = strings.TrimSpace(string(data))
 	}
 	if formula == "" {
-		fmt.Fprintln(os.Stderr, "usage: eval -formula '\\sum ...' [-target pi] [-maxterms 4096] [-precision 512]")
+		fmt.Fprintln(os.Stderr, "usage: eval -formula '\\sum ...' [-target pi] [-maxterms 4096] [-precision 512] [-binsplit | -backend big] [-digits 50] [-dot | -tree | -features | -split 2 | -transform pair | -explain text | -curve text | -sensitivity 3 | -consistency | -bfile base]")
 		fmt.Fprintln(os.Stderr, "       eval -file formula.txt [-target-value 3.14159...]")
 		os.Exit(1)
 	}
 
 	// Parse the formula.
-	cand, err := series.ParseCandidateLatex(formula)
+	cand, warnings, err := series.ParseCandidateLatexWarnings(formula)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "parse error: %v\n", err)
 		os.Exit(1)
 	}
-
-	fmt.Fprintf(os.Stderr, "Parsed: %s\n", cand.String())
-	fmt.Fprintf(os.Stderr, "Evaluating up to %d terms at %d-bit precision...\n", maxTerms, prec)
-
-	// Evaluate.
-	result := series.EvaluateCandidate(cand, maxTerms, prec)
-	if !result.OK {
-		fmt.Fprintln(os.Stderr, "evaluation failed (not enough terms or timeout)")
-		os.Exit(1)
+	if warn || werror {
+		warnings = append(warnings, cand.SimplifyWarnings(prec)...)
+		expr.WriteWarnings(os.Stderr, "", warnings)
+		if werror && len(warnings) > 0 {
+			fmt.Fprintf(os.Stderr, "%d warnings, failing for -Werror\n", len(warnings))
+			os.Exit(1)
+		}
 	}
 
-	fmt.Printf("Terms computed: %d\n", result.TermsComputed)
-	fmt.Printf("Converged:     %v\n", result.Converged)
-	fmt.Printf("Partial sum:   %s\n", result.PartialSum.Text('g', 50))
-
-	// Compare against target if provided.
 	var tv *big.Float
+	if target == "" {
+		target = targetV
+	}
 	if target != "" {
-		c := constants.Get(target)
-		if c == nil {
-			fmt.Fprintf(os.Stderr, "unknown target: %s\n", target)
+		tg, err := constants.ParseTarget(target)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid target: %v\n", err)
+			os.Exit(1)
+		}
+		tv = tg.At(prec)
+	}
+
+	fmt.Fprintf(os.Stderr, "Parsed: %s\n", cand.String())
+	if cand.Over != nil && (split != 0 || xform != "" || bfile != "" || dot || tree) {
+		fmt.Fprintln(os.Stderr, "-split, -transform, -bfile, -dot and -tree take a single series, not a ratio")
+		os.Exit(1)
+	}
+	if features {
+		f := series.Features(cand)
+		for i, name := range series.FeatureNames {
+			fmt.Printf("%s\t%g\n", name, f[i])
+		}
+		return
+	}
+	if sens > 0 {
+		if tv == nil {
+			fmt.Fprintln(os.Stderr, "-sensitivity needs -target or -target-value")
+			os.Exit(1)
+		}
+		series.Sensitivity(cand, maxTerms, prec, tv, sens).WriteText(os.Stdout)
+		return
+	}
+	if consist {
+		if tv == nil {
+			fmt.Fprintln(os.Stderr, "-consistency needs -target or -target-value")
+			os.Exit(1)
+		}
+		ev, err := series.GetEvaluator(evalName)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+		opts := series.SearchOptions(maxTerms, prec)
+		opts.Timeout = 0
+		r := series.CheckConsistency(cand, ev, opts, tv)
+		r.WriteText(os.Stdout)
+		if !r.Consistent {
+			os.Exit(2)
+		}
+		return
+	}
+	if explain != "" {
+		trace := series.Explain(cand, maxTerms, prec, tv)
+		switch explain {
+		case "json":
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(trace); err != nil {
+				fmt.Fprintf(os.Stderr, "error writing JSON: %v\n", err)
+				os.Exit(1)
+			}
+		case "text":
+			trace.WriteText(os.Stdout)
+		default:
+			fmt.Fprintf(os.Stderr, "unknown -explain format: %s (text, json)\n", explain)
+			os.Exit(1)
+		}
+		return
+	}
+	if curve != "" {
+		if tv == nil {
+			fmt.Fprintln(os.Stderr, "-curve needs -target or -target-value")
+			os.Exit(1)
+		}
+		cv := series.DigitCurve(cand, maxTerms, prec, tv)
+		switch curve {
+		case "json":
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(cv); err != nil {
+				fmt.Fprintf(os.Stderr, "error writing JSON: %v\n", err)
+				os.Exit(1)
+			}
+		case "text":
+			cv.WriteText(os.Stdout)
+		default:
+			fmt.Fprintf(os.Stderr, "unknown -curve format: %s (text, json)\n", curve)
 			os.Exit(1)
 		}
-		tv = c.Value
-		fmt.Printf("Target (%s):   %s\n", target, tv.Text('g', 50))
-	} else if targetV != "" {
+		return
+	}
+	if split > 0 {
+		terms, rest, ok := series.SplitLeading(cand, split, prec)
+		if !ok {
+			fmt.Fprintf(os.Stderr, "a term among the first %d fails to evaluate\n", split)
+			os.Exit(1)
+		}
+		for i, t := range terms {
+			fmt.Printf("  t(%d) = %s\n", cand.Start+int64(i), t.Text('g', digits))
+		}
+		fmt.Printf("+ %s\n  %s\n", rest, rest.LaTeX())
+		return
+	}
+	if split < 0 {
+		head, wider, ok := series.AbsorbLeading(cand, -split, prec)
+		if !ok {
+			fmt.Fprintf(os.Stderr, "cannot absorb %d terms (start index would go below 0, or a term fails to evaluate)\n", -split)
+			os.Exit(1)
+		}
+		fmt.Printf("%s\n  %s\n- %s\n", wider, wider.LaTeX(), head.Text('g', digits))
+		return
+	}
+	if xform != "" {
+		switch k, ok := strings.CutPrefix(xform, "reverse:"); {
+		case xform == "pair":
+			p := series.PairTerms(cand)
+			fmt.Printf("%s\n  %s\n", p, p.LaTeX())
+		case xform == "parity":
+			even, odd := series.SplitParity(cand)
+			fmt.Printf("  %s\n  %s\n+ %s\n  %s\n", even, even.LaTeX(), odd, odd.LaTeX())
+		case ok:
+			n, err := strconv.ParseInt(k, 10, 64)
+			if err != nil || n < 1 {
+				fmt.Fprintf(os.Stderr, "-transform reverse:k needs k >= 1, got %q\n", k)
+				os.Exit(1)
+			}
+			r := series.ReverseRange(cand, n)
+			fmt.Printf("%s\n  %s\n(the first %d terms only)\n", r, r.LaTeX(), n)
+		default:
+			fmt.Fprintf(os.Stderr, "unknown -transform: %s (pair, parity, reverse:k)\n", xform)
+			os.Exit(1)
+		}
+		return
+	}
+	if bfile != "" {
+		n, err := series.ExportBFiles(cand, bterms, bfile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("wrote %d terms to %s.num.txt and %s.den.txt\n", n, bfile, bfile)
+		if n < bterms {
+			fmt.Fprintf(os.Stderr, "t(%d) is not an exact rational or has over %d digits; stopped there\n", cand.Start+int64(n), series.MaxBFileDigits)
+		}
+		return
+	}
+	if dot || tree {
+		// Draw the term as one tree, numerator / denominator.
+		term := &expr.BinaryNode{Op: expr.OpDiv, Left: cand.Numerator, Right: cand.Denominator}
+		if cand.Offset != nil {
+			fmt.Printf("// offset %s, plus\n", cand.Offset)
+		}
+		if dot {
+			fmt.Printf("// term of the sum from n = %d\n%s", cand.Start, expr.ToDOT(term))
+		} else {
+			fmt.Printf("sum from n = %d of\n%s", cand.Start, expr.ToASCII(term))
+		}
+		return
+	}
+	var sum *big.Float
+	if binSplit {
+		fmt.Fprintf(os.Stderr, "Summing by binary splitting at %d-bit precision...\n", prec)
+		var terms int64
 		var ok bool
-		tv, ok = new(big.Float).SetPrec(prec).SetString(targetV)
+		sum, terms, ok = series.SumBinarySplit(cand, prec)
 		if !ok {
-			fmt.Fprintf(os.Stderr, "invalid target value: %s\n", targetV)
+			fmt.Fprintln(os.Stderr, "binary splitting not applicable (term ratio is not a rational function of n, or convergence is sub-geometric)")
+			os.Exit(1)
+		}
+		fmt.Printf("Terms computed: %d\n", terms)
+	} else if backend != "" {
+		fmt.Fprintf(os.Stderr, "Summing %d terms at %d-bit precision with the %s backend...\n", maxTerms, prec, backend)
+		var terms int64
+		sum, terms, err = series.PartialSumWith(backend, cand, maxTerms, prec)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "evaluation failed: %v\n", err)
 			os.Exit(1)
 		}
-		fmt.Printf("Target:        %s\n", tv.Text('g', 50))
+		fmt.Printf("Terms computed: %d\n", terms)
+	} else {
+		ev, err := series.GetEvaluator(evalName)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "Evaluating up to %d terms at %d-bit precision with the %s evaluator...\n", maxTerms, prec, ev.Name())
+
+		opts := series.SearchOptions(maxTerms, prec)
+		result := ev.Evaluate(cand, opts)
+		if result.Overflow {
+			fmt.Fprintf(os.Stderr, "evaluation failed: term %d overflowed 2^%d\n", result.TermsComputed+1, opts.MaxExponent)
+			os.Exit(1)
+		}
+		if !result.OK {
+			fmt.Fprintln(os.Stderr, "evaluation failed (not enough terms or timeout)")
+			os.Exit(1)
+		}
+		sum = result.PartialSum
+
+		fmt.Printf("Terms computed: %d\n", result.TermsComputed)
+		fmt.Printf("Converged:     %v\n", result.Converged)
+	}
+	fmt.Printf("Partial sum:   %s\n", sum.Text('g', digits))
+	for i, id := range constants.Identify(sum, identTol, 1000) {
+		if i == 3 {
+			break
+		}
+		fmt.Printf("Looks like:    %s (rel. error %.1e)\n", id, id.RelErr)
+	}
+
+	// Compare against target if provided.
+	if target != "" && target != targetV {
+		fmt.Printf("Target (%s):   %s\n", target, tv.Text('g', digits))
+	} else if tv != nil {
+		fmt.Printf("Target:        %s\n", tv.Text('g', digits))
 	}
 
 	if tv != nil {
-		diff := new(big.Float).SetPrec(prec).Sub(result.PartialSum, tv)
+		diff := new(big.Float).SetPrec(prec).Sub(sum, tv)
 		diff.Abs(diff)
 		fmt.Printf("Error:         %s\n", diff.Text('e', 15))
 
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/wildfunctions/genetic_series/pkg/experiment"
+)
+
+func main() {
+	var (
+		specFile  string
+		out       string
+		parallel  int
+		plan      bool
+		aggregate bool
+		format    string
+	)
+
+	flag.StringVar(&specFile, "spec", "", "experiment spec: a run spec with a [sweep] section of setting = [values] and optional [experiment] trials")
+	flag.StringVar(&out, "out", "experiment", "directory for each run's spec and report; rerunning resumes")
+	flag.IntVar(&parallel, "parallel", 1, "runs at a time (each uses the spec's eval.workers)")
+	flag.BoolVar(&plan, "plan", false, "write each run's spec and print the commands to run them elsewhere, without running")
+	flag.BoolVar(&aggregate, "aggregate", false, "summarize the reports already in -out, without running")
+	flag.StringVar(&format, "format", "text", "summary format (text, json)")
+	flag.Parse()
+
+	if specFile == "" {
+		fmt.Fprintln(os.Stderr, "usage: experiment -spec sweep.toml [-out experiment] [-parallel 2] [-plan | -aggregate] [-format json]")
+		os.Exit(1)
+	}
+	spec, err := experiment.LoadSpec(specFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error reading spec: %v\n", err)
+		os.Exit(1)
+	}
+	runs := spec.Runs()
+
+	var results []experiment.Result
+	switch {
+	case plan:
+		if err := experiment.Plan(runs, out); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		for _, r := range runs {
+			fmt.Printf("%s  # %s\n", experiment.Command(out, r), r.Label())
+		}
+		return
+	case aggregate:
+		results = experiment.Load(runs, out)
+	default:
+		if results, err = experiment.Execute(runs, out, parallel, os.Stderr); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	summary := experiment.Summarize(spec, results)
+	switch format {
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(summary); err != nil {
+			fmt.Fprintf(os.Stderr, "error writing JSON: %v\n", err)
+			os.Exit(1)
+		}
+	default:
+		experiment.WriteSummary(os.Stdout, summary)
+	}
+}
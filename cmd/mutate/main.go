@@ -0,0 +1,144 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/wildfunctions/genetic_series/pkg/expr"
+	"github.com/wildfunctions/genetic_series/pkg/pool"
+	"github.com/wildfunctions/genetic_series/pkg/random"
+	"github.com/wildfunctions/genetic_series/pkg/series"
+	"github.com/wildfunctions/genetic_series/pkg/strategy"
+)
+
+func main() {
+	var (
+		formula  string
+		file     string
+		op       string
+		k        int
+		poolName string
+		seed     int64
+		rngKind  string
+	)
+
+	flag.StringVar(&formula, "formula", "", "LaTeX formula to mutate")
+	flag.StringVar(&file, "file", "", "file containing LaTeX formula")
+	flag.StringVar(&op, "op", "any", "mutation operator ("+strings.Join(strategy.MutationNames(), ", ")+")")
+	flag.IntVar(&k, "k", 10, "offspring to print")
+	flag.StringVar(&poolName, "pool", "conservative", "gene pool new nodes are drawn from ("+strings.Join(pool.Names(), ", ")+")")
+	flag.Int64Var(&seed, "seed", 0, "random seed (0 = random)")
+	flag.StringVar(&rngKind, "rng", "go", "random number generator ("+strings.Join(random.Kinds, ", ")+")")
+	flag.Parse()
+
+	if formula == "" && file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error reading %s: %v\n", file, err)
+			os.Exit(1)
+		}
+		formula = strings.TrimSpace(string(data))
+	}
+	if formula == "" {
+		fmt.Fprintln(os.Stderr, "usage: mutate -formula '\\sum ...' [-op subtree] [-k 10] [-pool moderate] [-seed 1]")
+		os.Exit(1)
+	}
+
+	parent, err := series.ParseCandidateLatex(formula)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "parse error: %v\n", err)
+		os.Exit(1)
+	}
+	m, err := strategy.ParseMutation(op)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+	p, err := pool.Get(poolName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	rng, err := random.New(rngKind, seed)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("parent: %s\n        %s\n", parent.LaTeX(), parent)
+	fmt.Printf("%d offspring by %s from pool %s, seed %d\n", k, m, poolName, seed)
+	for i := 1; i <= k; i++ {
+		raw, child, ok := strategy.Preview(parent, m, p, rng)
+		fmt.Printf("\n#%d ", i)
+		writeMarked(fmt.Sprintf("#%d ", i), parent, raw)
+		writeDiff(parent, raw)
+		if child.String() != raw.String() {
+			fmt.Printf("  simplified: %s\n", child.LaTeX())
+		}
+		if !ok {
+			fmt.Println("  rejected: over the size limits, a run would breed a random candidate instead")
+		}
+	}
+}
+
+// writeMarked prints child's LaTeX with the subtrees that differ from
+// parent underlined by a line of ^, indented by len(prefix), the text
+// already printed before it.
+func writeMarked(prefix string, parent, child *series.Candidate) {
+	latex, num, den := child.LaTeXMap()
+	marks := []byte(strings.Repeat(" ", len(latex)))
+	for _, part := range []struct {
+		a, b  expr.ExprNode
+		spans []expr.Span
+	}{{parent.Numerator, child.Numerator, num}, {parent.Denominator, child.Denominator, den}} {
+		for _, c := range expr.Diff(part.a, part.b) {
+			sp := part.spans[c.NewIndex]
+			for j := sp.Start; j < sp.End; j++ {
+				marks[j] = '^'
+			}
+		}
+	}
+	fmt.Println(latex)
+	if m := strings.TrimRight(string(marks), " "); m != "" {
+		fmt.Printf("%s%s\n", strings.Repeat(" ", len(prefix)), m)
+	}
+}
+
+// writeDiff prints each changed subtree of child against parent.
+func writeDiff(parent, child *series.Candidate) {
+	same := true
+	if parent.Start != child.Start {
+		fmt.Printf("  start: %d → %d\n", parent.Start, child.Start)
+		same = false
+	}
+	if a, b := offsetString(parent), offsetString(child); a != b {
+		fmt.Printf("  offset: %s → %s\n", a, b)
+		same = false
+	}
+	for _, part := range []struct {
+		name string
+		a, b expr.ExprNode
+	}{{"numerator", parent.Numerator, child.Numerator}, {"denominator", parent.Denominator, child.Denominator}} {
+		for _, c := range expr.Diff(part.a, part.b) {
+			fmt.Printf("  %s node %d:\n    - %s\n    + %s\n", part.name, c.Index, c.Old, c.New)
+			same = false
+		}
+	}
+	if same {
+		fmt.Println("  unchanged")
+	}
+}
+
+// offsetString is c's offset for writeDiff, or "none".
+func offsetString(c *series.Candidate) string {
+	if c.Offset == nil {
+		return "none"
+	}
+	return c.Offset.String()
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/wildfunctions/genetic_series/pkg/constants"
+	"github.com/wildfunctions/genetic_series/pkg/engine"
+	"github.com/wildfunctions/genetic_series/pkg/series"
+)
+
+func main() {
+	cfg := engine.DefaultConfig()
+	var (
+		in, out    string
+		configPath string
+	)
+
+	flag.StringVar(&in, "in", "", "leaderboard JSON file of a run (-leaderboard) to re-score")
+	flag.StringVar(&out, "out", "", "file to write the re-scored leaderboard to, with a .tex snippet beside it (empty = report only)")
+	flag.StringVar(&configPath, "config", "", "run spec file (TOML) for the new settings, fitness weights included; flags given explicitly override it")
+	flag.StringVar(&cfg.Target, "target", "", "new target (default: the board's, whatever the -config file says): a constant ("+strings.Join(constants.Names(), ", ")+"), an expression such as pi^2/6, digits, file:path, or seq:A000045")
+	flag.UintVar(&cfg.Precision, "precision", cfg.Precision, "precision in bits")
+	flag.Int64Var(&cfg.MaxTerms, "maxterms", cfg.MaxTerms, "max terms to evaluate per series")
+	flag.IntVar(&cfg.MaxExponent, "maxexp", cfg.MaxExponent, "binary exponent past which a term fails its candidate as an overflow (0 = no bound)")
+	flag.BoolVar(&cfg.DynamicPrecision, "dynprec", cfg.DynamicPrecision, "choose each candidate's precision from its constants, term growth and convergence instead of using -precision for all")
+	flag.StringVar(&cfg.Evaluator, "evaluator", cfg.Evaluator, "evaluator ("+strings.Join(series.EvaluatorNames(), ", ")+")")
+	flag.StringVar(&cfg.FitnessScript, "fitness-script", cfg.FitnessScript, "expression over digits, nodes, cost, ... that replaces each candidate's fitness (e.g. 'combined - nodes')")
+	flag.IntVar(&cfg.Workers, "workers", cfg.Workers, "number of parallel workers")
+	flag.StringVar(&cfg.ConstantCache, "constcache", cfg.ConstantCache, "directory caching constants computed past their stored digits between runs (empty = disabled)")
+	flag.StringVar(&cfg.Format, "format", "text", "report format (text, json)")
+	flag.Parse()
+
+	if in == "" {
+		fmt.Fprintln(os.Stderr, "usage: rescore -in board.json [-out rescored.json] [-config run.toml] [-target pi] [-precision 512] [-maxterms 1024] [-fitness-script ...] [-format text|json]")
+		os.Exit(1)
+	}
+	if configPath != "" {
+		// As in the search: the file in place of the defaults, then the
+		// flags given on the command line again on top.
+		explicit := map[string]string{}
+		flag.Visit(func(f *flag.Flag) { explicit[f.Name] = f.Value.String() })
+		fileCfg, err := engine.LoadConfigFile(configPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error reading config: %v\n", err)
+			os.Exit(1)
+		}
+		cfg = fileCfg
+		for name, value := range explicit {
+			flag.Set(name, value)
+		}
+	}
+
+	board, err := engine.LoadLeaderboard(in)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error reading leaderboard: %v\n", err)
+		os.Exit(1)
+	}
+	from := board.Target
+	if !isSet("target") {
+		cfg.Target = from
+	}
+
+	// Only evaluation is wanted: no background verification, no files.
+	cfg.DiscoveryDigits = 0
+	cfg.Leaderboard, cfg.EventLog, cfg.SeenFile, cfg.InboxDir = "", "", "", ""
+	e, err := engine.New(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	rescored, entries, err := e.Rescore(board)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error re-scoring %s: %v\n", in, err)
+		os.Exit(1)
+	}
+
+	if out != "" {
+		if err := engine.WriteLeaderboard(out, rescored); err != nil {
+			fmt.Fprintf(os.Stderr, "error writing %s: %v\n", out, err)
+			os.Exit(1)
+		}
+	}
+	if cfg.Format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(entries); err != nil {
+			fmt.Fprintf(os.Stderr, "error writing JSON: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	engine.WriteRescoreReport(os.Stdout, from, cfg.Target, entries)
+}
+
+// isSet reports whether the flag name was given on the command line.
+func isSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) { set = set || f.Name == name })
+	return set
+}
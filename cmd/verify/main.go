@@ -0,0 +1,271 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"math/big"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/wildfunctions/genetic_series/pkg/constants"
+	"github.com/wildfunctions/genetic_series/pkg/expr"
+	"github.com/wildfunctions/genetic_series/pkg/series"
+)
+
+func main() {
+	var (
+		formula    string
+		file       string
+		target     string
+		targetV    string
+		targetFile string
+		maxTerms   int64
+		prec       uint
+		ckpt       string
+		every      time.Duration
+		digits     int
+		constCache string
+		warn       bool
+		werror     bool
+		far        string
+		farSamples int64
+		orderName  string
+		workers    int
+		binSplit   bool
+	)
+
+	flag.StringVar(&formula, "formula", "", "LaTeX formula to verify")
+	flag.StringVar(&file, "file", "", "file containing LaTeX formula")
+	flag.StringVar(&target, "target", "", "target to compare, generated at -precision: a constant ("+strings.Join(constants.Names(), ", ")+"), an expression such as pi^2/6, digits, or file:path")
+	flag.StringVar(&targetV, "target-value", "", "explicit target value (decimal string); same as -target with digits")
+	flag.StringVar(&targetFile, "target-file", "", "file containing the target value (decimal string); same as -target file:path")
+	flag.Int64Var(&maxTerms, "maxterms", 10_000_000, "terms to sum")
+	flag.UintVar(&prec, "precision", 340_000, "precision in bits (~3.32 bits per decimal digit)")
+	flag.StringVar(&ckpt, "checkpoint", "verify.ckpt", "checkpoint file; resumed from if it exists")
+	flag.DurationVar(&every, "every", time.Minute, "how often to write the checkpoint")
+	flag.IntVar(&digits, "digits", 50, "significant digits to print")
+	flag.StringVar(&constCache, "constcache", "", "directory caching constants computed past their stored digits between runs (empty = disabled)")
+	flag.BoolVar(&warn, "W", false, "print warnings to stderr for input the parser or simplifier normalized in ways that can change the value (implicit products by numbers, unbraced exponents, rounded constants, cancelled subtrees)")
+	flag.StringVar(&far, "far", "", "terms to extend the sum to past -maxterms, such as 1e12 (may exceed int64), estimated from -far-samples terms at each end of doubling ranges (see series.ExtendSum)")
+	flag.Int64Var(&farSamples, "far-samples", 1024, "terms -far sums at each end of a range")
+	flag.StringVar(&orderName, "order", "sequential", "summation order: "+strings.Join(series.SumOrderNames(), ", ")+"; chunked sums fixed chunks of terms on -workers goroutines, bit-identical for any worker count")
+	flag.IntVar(&workers, "workers", 0, "goroutines for -order chunked (0 = one per CPU)")
+	flag.BoolVar(&binSplit, "binsplit", true, "sum hypergeometric series by binary splitting to -precision instead of term by term (see series.SumBinarySplit)")
+	flag.BoolVar(&werror, "Werror", false, "as -W, then exit with status 1 if there were any warnings")
+	flag.Parse()
+
+	if err := constants.SetCacheDir(constCache); err != nil {
+		fmt.Fprintf(os.Stderr, "constant cache: %v\n", err)
+		os.Exit(1)
+	}
+
+	if formula == "" && file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error reading %s: %v\n", file, err)
+			os.Exit(1)
+		}
+		formula = strings.TrimSpace(string(data))
+	}
+	if formula == "" {
+		fmt.Fprintln(os.Stderr, "usage: verify -formula '\\sum ...' [-maxterms 10000000] [-precision 340000] [-checkpoint verify.ckpt] [-every 1m]")
+		fmt.Fprintln(os.Stderr, "              [-target pi | -target-value 3.14159... | -target-file pi.txt] [-digits 50] [-constcache dir]")
+		os.Exit(1)
+	}
+
+	cand, warnings, err := series.ParseCandidateLatexWarnings(formula)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "parse error: %v\n", err)
+		os.Exit(1)
+	}
+	if warn || werror {
+		warnings = append(warnings, cand.SimplifyWarnings(prec)...)
+		expr.WriteWarnings(os.Stderr, "", warnings)
+		if werror && len(warnings) > 0 {
+			fmt.Fprintf(os.Stderr, "%d warnings, failing for -Werror\n", len(warnings))
+			os.Exit(1)
+		}
+	}
+	fmt.Fprintf(os.Stderr, "Parsed: %s\n", cand.String())
+	order, err := series.ParseSumOrder(orderName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid -order: %v\n", err)
+		os.Exit(1)
+	}
+
+	// A hypergeometric series reaches the full precision by binary
+	// splitting in far fewer terms than -maxterms; a checkpoint left by a
+	// term-by-term run is resumed instead.
+	var (
+		value *big.Float
+		sum   *series.ResumableSum
+	)
+	if _, err := os.Stat(ckpt); binSplit && err != nil {
+		var terms int64
+		var ok bool
+		if value, terms, ok = series.SumBinarySplit(cand, prec); ok {
+			fmt.Fprintf(os.Stderr, "Summed by binary splitting at %d-bit precision\n", prec)
+			fmt.Printf("Terms computed: %d\n", terms)
+			fmt.Printf("Partial sum:   %s\n", value.Text('g', digits))
+		}
+	}
+	if value == nil {
+		sum, value = sumTerms(cand, ckpt, prec, order, workers, maxTerms, every, digits)
+	}
+
+	var farSum *series.FarSum
+	if far != "" && sum == nil {
+		fmt.Fprintln(os.Stderr, "-far ignored: the sum is already at full precision")
+	} else if far != "" && !sum.Failed {
+		total, err := parseTerms(far)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid -far: %v\n", err)
+			os.Exit(1)
+		}
+		var ok bool
+		if farSum, ok = series.ExtendSum(cand, value, sum.Terms, total, farSamples, prec); !ok {
+			fmt.Fprintf(os.Stderr, "the sum could not be extended: a sampled term failed, or the samples do not fit a power law\n")
+			os.Exit(1)
+		}
+		fmt.Printf("Extended to:   %s terms (%d evaluated in %d ranges)\n", farSum.Terms, farSum.Evaluated, len(farSum.Chunks))
+		fmt.Printf("Extended sum:  %s\n", farSum.Sum.Text('g', digits))
+		if farSum.Accelerated != nil {
+			fmt.Printf("Extrapolated:  %s\n", farSum.Accelerated.Text('g', digits))
+		}
+	}
+
+	switch {
+	case target != "":
+	case targetV != "":
+		target = targetV
+	case targetFile != "":
+		target = "file:" + targetFile
+	default:
+		return
+	}
+	tg, err := constants.ParseTarget(target)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid target: %v\n", err)
+		os.Exit(1)
+	}
+	if bits := tg.Precision(); bits != 0 && bits < prec {
+		fmt.Fprintf(os.Stderr, "warning: target is known to %d bits, less than -precision %d; digits past it are not checked\n", bits, prec)
+	}
+	tv := tg.At(prec)
+	fmt.Printf("Target:        %s\n", tv.Text('g', digits))
+	printError("", value, tv, prec)
+	if farSum != nil {
+		printError("Extended", farSum.Sum, tv, prec)
+		if farSum.Accelerated != nil {
+			printError("Extrapolated", farSum.Accelerated, tv, prec)
+		}
+	}
+}
+
+// sumTerms sums cand term by term to maxTerms, resuming from and writing
+// the checkpoint ckpt, and prints the partial sum. It exits on an error or
+// an interrupt.
+func sumTerms(cand *series.Candidate, ckpt string, prec uint, order series.SumOrder, workers int, maxTerms int64, every time.Duration, digits int) (*series.ResumableSum, *big.Float) {
+	// Resume from the checkpoint if one exists.
+	var sum *series.ResumableSum
+	if _, err := os.Stat(ckpt); err == nil {
+		sum, err = series.LoadResumableSum(ckpt)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error loading checkpoint: %v\n", err)
+			os.Exit(1)
+		}
+		if sum.Precision != prec {
+			fmt.Fprintf(os.Stderr, "checkpoint %s is at %d-bit precision, not %d\n", ckpt, sum.Precision, prec)
+			os.Exit(1)
+		}
+		if sum.Order != order {
+			fmt.Fprintf(os.Stderr, "checkpoint %s is summed in %s order, not %s\n", ckpt, sum.Order, order)
+			os.Exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "Resuming from %s: %d terms done, next n = %d\n", ckpt, sum.Terms, sum.Next)
+	} else {
+		sum = series.NewResumableSum(cand, prec)
+		sum.Order = order
+	}
+	sum.Workers = workers
+
+	// Ctrl+C stops at the next block (or round of chunks) and writes a final checkpoint.
+	stop := make(chan struct{})
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	go func() {
+		<-interrupt
+		fmt.Fprintln(os.Stderr, "\nInterrupted, writing checkpoint...")
+		signal.Stop(interrupt)
+		close(stop)
+	}()
+
+	fmt.Fprintf(os.Stderr, "Summing to %d terms at %d-bit precision, checkpointing to %s every %v...\n", maxTerms, prec, ckpt, every)
+	// The rate counts only this session's terms, not those of the sessions
+	// the checkpoint resumed from.
+	start, startTerms := time.Now(), sum.Terms
+	save := func(s *series.ResumableSum) error {
+		fmt.Fprintf(os.Stderr, "  checkpoint: %d terms (%.1f terms/s)\n", s.Terms, float64(s.Terms-startTerms)/time.Since(start).Seconds())
+		return s.Save(ckpt)
+	}
+	if err := sum.Run(cand, maxTerms, every, save, stop); err != nil {
+		fmt.Fprintf(os.Stderr, "verification failed: %v\n", err)
+		os.Exit(1)
+	}
+
+	select {
+	case <-stop:
+		fmt.Fprintf(os.Stderr, "Stopped after %d terms; rerun the same command to resume.\n", sum.Terms)
+		os.Exit(2)
+	default:
+	}
+
+	if sum.Failed {
+		fmt.Fprintf(os.Stderr, "term n = %d failed to evaluate; the sum ends there\n", sum.Next)
+	}
+	value, err := sum.Value()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Terms computed: %d\n", sum.Terms)
+	fmt.Printf("Partial sum:   %s\n", value.Text('g', digits))
+	return sum, value
+}
+
+// printError prints the error of value against tv and its correct digits,
+// in lines named for what value is ("" for the partial sum).
+func printError(name string, value, tv *big.Float, prec uint) {
+	errLabel, digitsLabel := "Error:        ", "Correct digits:"
+	if name != "" {
+		errLabel, digitsLabel = name+" error:", name+" digits:"
+	}
+	diff := new(big.Float).SetPrec(prec).Sub(value, tv)
+	diff.Abs(diff)
+	fmt.Printf("%s %s\n", errLabel, diff.Text('e', 15))
+	if diff.Sign() == 0 {
+		fmt.Printf("%s all (exact at this precision)\n", digitsLabel)
+		return
+	}
+	// Digits from the binary exponents, since the error can be far below
+	// float64 range at these precisions.
+	rel := diff.MantExp(nil) - tv.MantExp(nil)
+	fmt.Printf("%s %.0f\n", digitsLabel, math.Max(0, -float64(rel)*math.Log10(2)))
+}
+
+// parseTerms parses a term count such as 1000000 or 1e12, which may exceed
+// int64.
+func parseTerms(s string) (*big.Int, error) {
+	f, _, err := big.ParseFloat(s, 10, 256, big.ToNearestEven)
+	if err != nil {
+		return nil, err
+	}
+	if !f.IsInt() || f.Sign() <= 0 {
+		return nil, fmt.Errorf("%s is not a positive integer", s)
+	}
+	n, _ := f.Int(nil)
+	return n, nil
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
 	"os"
@@ -9,31 +10,119 @@ import (
 	"github.com/wildfunctions/genetic_series/pkg/constants"
 	"github.com/wildfunctions/genetic_series/pkg/engine"
 	"github.com/wildfunctions/genetic_series/pkg/pool"
+	"github.com/wildfunctions/genetic_series/pkg/random"
+	"github.com/wildfunctions/genetic_series/pkg/series"
 	"github.com/wildfunctions/genetic_series/pkg/strategy"
 )
 
 func main() {
 	cfg := engine.DefaultConfig()
 	outdir := "."
+	reports := ""
+	var configPath string
+	var estimate int
 
-	flag.StringVar(&cfg.Target, "target", cfg.Target, "target constant ("+strings.Join(constants.Names(), ", ")+")")
+	flag.StringVar(&cfg.Target, "target", cfg.Target, "target: a constant ("+strings.Join(constants.Names(), ", ")+"), an expression such as pi^2/6, digits, file:path, or seq:A000045 / seq:b-file for an integer sequence")
 	flag.UintVar(&cfg.Precision, "precision", cfg.Precision, "precision in bits")
+	flag.StringVar(&cfg.Evaluator, "evaluator", cfg.Evaluator, "evaluator for candidates that pass the float64 prescreen ("+strings.Join(series.EvaluatorNames(), ", ")+")")
 	flag.StringVar(&cfg.Pool, "pool", cfg.Pool, "gene pool ("+strings.Join(pool.Names(), ", ")+")")
 	flag.StringVar(&cfg.Strategy, "strategy", cfg.Strategy, "evolution strategy ("+strings.Join(strategy.Names(), ", ")+")")
 	flag.IntVar(&cfg.Population, "population", cfg.Population, "population size")
 	flag.IntVar(&cfg.Generations, "generations", cfg.Generations, "number of generations")
+	flag.StringVar(&cfg.Stop, "stop", cfg.Stop, "stop condition over generations, attempts, time, digits, stagnation, archive, e.g. \"time >= 2h or digits >= 30\" (replaces -generations)")
 	flag.Int64Var(&cfg.MaxTerms, "maxterms", cfg.MaxTerms, "max terms to evaluate per series")
+	flag.IntVar(&cfg.MaxExponent, "maxexp", cfg.MaxExponent, "binary exponent past which a term fails its candidate as an overflow (0 = no bound)")
+	flag.BoolVar(&cfg.DynamicPrecision, "dynprec", cfg.DynamicPrecision, "choose each candidate's precision from its constants, term growth and convergence instead of using -precision for all")
+	flag.BoolVar(&cfg.TrigProbe, "trigprobe", cfg.TrigProbe, "fail candidates whose sin/cos/tan arguments sit at rational multiples of pi, making the terms zero or constant, before evaluating them")
 	flag.Int64Var(&cfg.Seed, "seed", cfg.Seed, "random seed (0 = random)")
+	flag.StringVar(&cfg.RNG, "rng", cfg.RNG, "random number generator the seed drives ("+strings.Join(random.Kinds, ", ")+")")
+	flag.IntVar(&cfg.RNGStream, "rng-stream", cfg.RNGStream, "independent stream of the seed to draw from (0 = the master stream)")
 	flag.StringVar(&cfg.Format, "format", cfg.Format, "output format (text, json)")
 	flag.BoolVar(&cfg.Verbose, "verbose", cfg.Verbose, "verbose output per generation")
 	flag.IntVar(&cfg.MaxDepth, "maxdepth", cfg.MaxDepth, "max tree depth")
 	flag.IntVar(&cfg.Workers, "workers", cfg.Workers, "number of parallel workers")
+	flag.StringVar(&cfg.FitnessScript, "fitness-script", "", "expression over digits, nodes, cost, ... that replaces each candidate's fitness (e.g. 'combined - nodes')")
 	flag.IntVar(&cfg.StagnationLimit, "stagnation", cfg.StagnationLimit, "generations without improvement before restart")
 	flag.Float64Var(&cfg.F64PromotionThreshold, "f64threshold", cfg.F64PromotionThreshold, "min float64 digits to promote to big.Float (0 = disabled)")
+	flag.Float64Var(&cfg.SurrogateFraction, "surrogate", cfg.SurrogateFraction, "fraction of each generation a learned surrogate lets through to evaluation once trained (0 = disabled)")
 	flag.StringVar(&cfg.SeedFormula, "seed-formula", "", "LaTeX seed formula for constant-tuning strategy")
+	flag.StringVar(&cfg.ReplayFile, "replay", cfg.ReplayFile, "replay: file of LaTeX series to evaluate in turn, one per line")
+	flag.BoolVar(&cfg.Guided, "guided", cfg.Guided, "consttune: step constants toward the target by the sign of each parent's error")
+	flag.Float64Var(&cfg.SkeletonRate, "skeleton-rate", cfg.SkeletonRate, "consttune: fraction of perturbations on exponents, factorial arguments and the start index rather than coefficients (0 = any constant)")
+	flag.Float64Var(&cfg.RepairRate, "repair-rate", cfg.RepairRate, "hillclimb, tournament: probability a child dividing by zero or taking a negative factorial in its first terms is repaired (shifted start, offset or abs) rather than bred as is (0 = disabled)")
+	flag.Float64Var(&cfg.RestartRate, "restart-rate", cfg.RestartRate, "hillclimb, tournament: fraction of mutations that replace one tree of the child with a new random one and keep the other (0 = disabled)")
+	flag.StringVar(&cfg.RestartSide, "restart-side", cfg.RestartSide, "tree -restart-rate regenerates: den (default), num or either")
+	flag.Float64Var(&cfg.SizeFairRate, "sizefair-rate", cfg.SizeFairRate, "hillclimb, tournament: fraction of the other mutations that replace a random subtree with a new one of about its size (0 = disabled)")
+	flag.BoolVar(&cfg.Ratio, "ratio", cfg.Ratio, "hillclimb, tournament, random: search for the target as a ratio of two series, each evolved")
+	flag.IntVar(&cfg.ArchiveSize, "archive", cfg.ArchiveSize, "max candidates kept in the shared archive (0 = disabled)")
+	flag.IntVar(&cfg.FailTabuSize, "failtabu", cfg.FailTabuSize, "max failed structures (divergent, domain error) strategies avoid re-breeding (0 = disabled)")
+	flag.IntVar(&cfg.FailTabuTTL, "failtabu-ttl", cfg.FailTabuTTL, "generations a failed structure stays tabu")
+	flag.StringVar(&cfg.InboxDir, "inbox", cfg.InboxDir, "directory polled each generation for .tex/.txt files of seed formulas to inject (one per line)")
+	flag.StringVar(&cfg.SeenFile, "seen", cfg.SeenFile, "bloom filter file of candidates explored by earlier runs: skipped, then extended with this run's and saved (created if missing)")
+	flag.StringVar(&cfg.ConstantCache, "constcache", cfg.ConstantCache, "directory caching constants computed past their stored digits between runs (empty = disabled)")
+	flag.IntVar(&cfg.SeenCapacity, "seen-capacity", cfg.SeenCapacity, "candidates a new -seen filter is sized for, at 1% false positives")
+	flag.IntVar(&cfg.MemoryLimit, "memlimit", cfg.MemoryLimit, "approximate MiB of population, archive, tabu and simplify cache past which caches are evicted and the archive shrunk (0 = unlimited)")
+	flag.IntVar(&cfg.StreamBatch, "stream", cfg.StreamBatch, "hold the population as compact genomes, evaluating this many at a time (0 = disabled)")
+	flag.Float64Var(&cfg.DiscoveryDigits, "discovery", cfg.DiscoveryDigits, "digits at which a candidate is re-verified at 2x and 4x precision/terms before counting as a discovery (0 = off)")
+	flag.DurationVar(&cfg.VerifyInterval, "verify-interval", cfg.VerifyInterval, "minimum time between background re-verifications of discoveries, e.g. 10s (0 = no limit)")
+	flag.DurationVar(&cfg.GenerationBudget, "genbudget", cfg.GenerationBudget, "wall-clock evaluation budget per generation, e.g. 30s (0 = unlimited)")
 	flag.StringVar(&outdir, "outdir", outdir, "output directory for generated files")
+	flag.IntVar(&cfg.Appendix, "appendix", cfg.Appendix, "top verified discoveries to write to -outdir as a LaTeX appendix section with numbered equations (0 = none)")
+	flag.StringVar(&reports, "reports", reports, "hall-of-fame documents to write beside the .tex, comma-separated (typst, markdown)")
+	flag.StringVar(&cfg.Leaderboard, "leaderboard", cfg.Leaderboard, "JSON file of the best candidates so far, rewritten each generation, with a .tex snippet beside it")
+	flag.IntVar(&cfg.LeaderboardSize, "leaderboard-k", cfg.LeaderboardSize, "candidates kept on the -leaderboard")
+	flag.StringVar(&cfg.EventLog, "events", cfg.EventLog, "JSONL file the run's events (generations, new bests, attempts, discoveries) are appended to")
+	flag.StringVar(&configPath, "config", "", "run spec file (TOML); flags given explicitly override it")
+	flag.IntVar(&estimate, "estimate", 0, "dry run: time this many sample candidates and project time per generation and memory, without searching")
 	flag.Parse()
 
+	if configPath != "" {
+		// Remember the flags given on the command line, load the file in
+		// their place, then apply them again on top.
+		explicit := map[string]string{}
+		flag.Visit(func(f *flag.Flag) { explicit[f.Name] = f.Value.String() })
+		fileCfg, err := engine.LoadConfigFile(configPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error reading config: %v\n", err)
+			os.Exit(1)
+		}
+		cfg = fileCfg
+		if fileCfg.OutDir != "" {
+			outdir = fileCfg.OutDir
+		}
+		reports = strings.Join(fileCfg.Reports, ",")
+		for name, value := range explicit {
+			flag.Set(name, value)
+		}
+	}
+
+	cfg.Reports = nil
+	for _, r := range strings.Split(reports, ",") {
+		if r = strings.TrimSpace(r); r != "" {
+			cfg.Reports = append(cfg.Reports, r)
+		}
+	}
+
+	if estimate > 0 {
+		e, err := engine.New(cfg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		r := e.Estimate(estimate)
+		if cfg.Format == "json" {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(r); err != nil {
+				fmt.Fprintf(os.Stderr, "error writing JSON: %v\n", err)
+				os.Exit(1)
+			}
+		} else {
+			engine.WriteEstimate(os.Stdout, cfg, r)
+		}
+		return
+	}
+
 	// Create output directory and wire it into config so the engine can write during the run
 	if err := os.MkdirAll(outdir, 0o755); err != nil {
 		fmt.Fprintf(os.Stderr, "error creating output dir: %v\n", err)
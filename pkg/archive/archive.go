@@ -0,0 +1,255 @@
+// Package archive keeps a bounded, deduplicated collection of the best
+// candidates seen during a run. It is safe for concurrent use by
+// evaluation workers.
+package archive
+
+import (
+	"hash/fnv"
+	"sort"
+	"sync"
+
+	"github.com/wildfunctions/genetic_series/pkg/random"
+	"github.com/wildfunctions/genetic_series/pkg/series"
+)
+
+// DefaultShards is the shard count used by New. Workers hash to shards by
+// candidate key, so with 32 shards and 16 workers lock contention is rare.
+const DefaultShards = 32
+
+// Entry is an archived candidate with the fitness it was archived at.
+type Entry struct {
+	Key       string // series.CanonicalKey(Candidate), used for deduplication
+	Candidate *series.Candidate
+	Fitness   series.Fitness
+}
+
+// Archive is a concurrent-safe bounded set of candidates. Each shard keeps
+// its own share of the capacity and evicts its lowest-fitness entry when a
+// better candidate arrives.
+type Archive struct {
+	shards []shard
+}
+
+type shard struct {
+	mu      sync.Mutex
+	cap     int
+	entries []Entry
+	index   map[string]int // key -> position in entries
+	worst   int            // position of the lowest Combined fitness
+	_       [32]byte       // keep neighbouring shard locks off one cache line
+}
+
+// New returns an archive holding up to capacity candidates.
+func New(capacity int) *Archive {
+	return NewSharded(capacity, DefaultShards)
+}
+
+// NewSharded returns an archive with an explicit shard count. A single
+// shard gives one global mutex, which is mainly useful as a benchmark
+// baseline.
+func NewSharded(capacity, shards int) *Archive {
+	if shards < 1 {
+		shards = 1
+	}
+	if capacity < shards {
+		shards = capacity
+		if shards < 1 {
+			shards = 1
+		}
+	}
+	a := &Archive{shards: make([]shard, shards)}
+	for i := range a.shards {
+		per := capacity / shards
+		if i < capacity%shards {
+			per++
+		}
+		a.shards[i].cap = per
+		a.shards[i].index = make(map[string]int, per)
+	}
+	return a
+}
+
+func (a *Archive) shardFor(key string) *shard {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return &a.shards[h.Sum32()%uint32(len(a.shards))]
+}
+
+// Add offers a candidate to the archive and reports whether it was stored.
+// A candidate already present keeps the better of the two fitnesses. The
+// candidate is cloned on insert, so callers may keep reassigning its fields.
+func (a *Archive) Add(c *series.Candidate, f series.Fitness) bool {
+	return a.AddKeyed(series.CanonicalKey(c), c, f)
+}
+
+// AddKeyed is Add with a precomputed series.CanonicalKey.
+func (a *Archive) AddKeyed(key string, c *series.Candidate, f series.Fitness) bool {
+	s := a.shardFor(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if i, ok := s.index[key]; ok {
+		if f.Combined <= s.entries[i].Fitness.Combined {
+			return false
+		}
+		s.entries[i].Fitness = f
+		s.updateWorst()
+		return true
+	}
+	if s.cap == 0 {
+		return false
+	}
+	if len(s.entries) < s.cap {
+		s.index[key] = len(s.entries)
+		s.entries = append(s.entries, Entry{Key: key, Candidate: c.Clone(), Fitness: f})
+		s.updateWorst()
+		return true
+	}
+	w := s.worst
+	if f.Combined <= s.entries[w].Fitness.Combined {
+		return false
+	}
+	delete(s.index, s.entries[w].Key)
+	s.index[key] = w
+	s.entries[w] = Entry{Key: key, Candidate: c.Clone(), Fitness: f}
+	s.updateWorst()
+	return true
+}
+
+// Lookup returns the archived entry for key (a series.CanonicalKey), if any.
+func (a *Archive) Lookup(key string) (Entry, bool) {
+	s := a.shardFor(key)
+	s.mu.Lock()
+	i, ok := s.index[key]
+	var e Entry
+	if ok {
+		e = s.entries[i]
+	}
+	s.mu.Unlock()
+	if ok {
+		e.Candidate = e.Candidate.Clone()
+	}
+	return e, ok
+}
+
+func (s *shard) updateWorst() {
+	s.worst = 0
+	for i := range s.entries {
+		if s.entries[i].Fitness.Combined < s.entries[s.worst].Fitness.Combined {
+			s.worst = i
+		}
+	}
+}
+
+// Sample returns a random entry: a random shard, then a random entry within
+// it, which is close to uniform since keys hash evenly. rng must not be
+// shared between goroutines; each worker should pass its own.
+func (a *Archive) Sample(rng random.Rand) (Entry, bool) {
+	start := rng.Intn(len(a.shards))
+	for k := 0; k < len(a.shards); k++ {
+		s := &a.shards[(start+k)%len(a.shards)]
+		s.mu.Lock()
+		if n := len(s.entries); n > 0 {
+			e := s.entries[rng.Intn(n)]
+			s.mu.Unlock()
+			e.Candidate = e.Candidate.Clone()
+			return e, true
+		}
+		s.mu.Unlock()
+	}
+	return Entry{}, false
+}
+
+// Len returns the number of archived candidates.
+func (a *Archive) Len() int {
+	n := 0
+	for i := range a.shards {
+		s := &a.shards[i]
+		s.mu.Lock()
+		n += len(s.entries)
+		s.mu.Unlock()
+	}
+	return n
+}
+
+// Best returns up to k entries in descending fitness order (all if k <= 0).
+func (a *Archive) Best(k int) []Entry {
+	var all []Entry
+	for i := range a.shards {
+		s := &a.shards[i]
+		s.mu.Lock()
+		all = append(all, s.entries...)
+		s.mu.Unlock()
+	}
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].Fitness.Combined != all[j].Fitness.Combined {
+			return all[i].Fitness.Combined > all[j].Fitness.Combined
+		}
+		return all[i].Key < all[j].Key
+	})
+	if k > 0 && len(all) > k {
+		all = all[:k]
+	}
+	for i := range all {
+		all[i].Candidate = all[i].Candidate.Clone()
+	}
+	return all
+}
+
+// Shrink lowers the archive's capacity to capacity, dropping the
+// lowest-fitness entries of each shard past its new share, and returns how
+// many were dropped. It never raises the capacity.
+func (a *Archive) Shrink(capacity int) int {
+	dropped := 0
+	for i := range a.shards {
+		per := capacity / len(a.shards)
+		if i < capacity%len(a.shards) {
+			per++
+		}
+		s := &a.shards[i]
+		s.mu.Lock()
+		if per < s.cap {
+			s.cap = per
+		}
+		if n := len(s.entries); n > s.cap {
+			sort.Slice(s.entries, func(i, j int) bool {
+				return s.entries[i].Fitness.Combined > s.entries[j].Fitness.Combined
+			})
+			s.entries = append([]Entry(nil), s.entries[:s.cap]...) // a new array, freeing the old
+			s.index = make(map[string]int, s.cap)
+			for j, e := range s.entries {
+				s.index[e.Key] = j
+			}
+			s.updateWorst()
+			dropped += n - s.cap
+		}
+		s.mu.Unlock()
+	}
+	return dropped
+}
+
+// Cap returns the number of candidates the archive can hold.
+func (a *Archive) Cap() int {
+	n := 0
+	for i := range a.shards {
+		s := &a.shards[i]
+		s.mu.Lock()
+		n += s.cap
+		s.mu.Unlock()
+	}
+	return n
+}
+
+// NodeCount returns the total node count of the archived candidates.
+func (a *Archive) NodeCount() int {
+	n := 0
+	for i := range a.shards {
+		s := &a.shards[i]
+		s.mu.Lock()
+		for _, e := range s.entries {
+			n += e.Candidate.NodeCount()
+		}
+		s.mu.Unlock()
+	}
+	return n
+}
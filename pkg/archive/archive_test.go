@@ -0,0 +1,164 @@
+package archive
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+	"testing"
+
+	"github.com/wildfunctions/genetic_series/pkg/expr"
+	"github.com/wildfunctions/genetic_series/pkg/series"
+)
+
+// cand returns the distinct candidate 1/(n + k).
+func cand(k int64) *series.Candidate {
+	return &series.Candidate{
+		Numerator:   &expr.ConstNode{Val: 1},
+		Denominator: &expr.BinaryNode{Op: expr.OpAdd, Left: &expr.VarNode{}, Right: &expr.ConstNode{Val: k}},
+	}
+}
+
+func fit(v float64) series.Fitness { return series.Fitness{Combined: v} }
+
+func TestAddDedupKeepsBetter(t *testing.T) {
+	a := New(16)
+	if !a.Add(cand(1), fit(1)) {
+		t.Fatal("first Add rejected")
+	}
+	if a.Add(cand(1), fit(0.5)) {
+		t.Error("worse duplicate accepted")
+	}
+	if !a.Add(cand(1), fit(2)) {
+		t.Error("better duplicate rejected")
+	}
+	if a.Len() != 1 {
+		t.Errorf("Len = %d, want 1", a.Len())
+	}
+	if got := a.Best(1)[0].Fitness.Combined; got != 2 {
+		t.Errorf("fitness = %v, want 2", got)
+	}
+}
+
+func TestEvictionKeepsBest(t *testing.T) {
+	for _, shards := range []int{1, 4} {
+		t.Run(fmt.Sprintf("shards=%d", shards), func(t *testing.T) {
+			a := NewSharded(8, shards)
+			for k := int64(0); k < 200; k++ {
+				a.Add(cand(k), fit(float64(k)))
+			}
+			if a.Len() > 8 {
+				t.Fatalf("Len = %d, exceeds capacity 8", a.Len())
+			}
+			best := a.Best(0)
+			for i := 1; i < len(best); i++ {
+				if best[i].Fitness.Combined > best[i-1].Fitness.Combined {
+					t.Fatal("Best not in descending order")
+				}
+			}
+			if best[0].Fitness.Combined != 199 {
+				t.Errorf("best = %v, want 199", best[0].Fitness.Combined)
+			}
+			if shards == 1 && best[len(best)-1].Fitness.Combined != 192 {
+				t.Errorf("single shard should keep exactly the top 8, worst = %v", best[len(best)-1].Fitness.Combined)
+			}
+		})
+	}
+}
+
+func TestAddClonesCandidate(t *testing.T) {
+	a := New(4)
+	c := cand(3)
+	a.Add(c, fit(1))
+	c.Numerator = &expr.ConstNode{Val: 99}
+	c.Start = 7
+	e, ok := a.Sample(rand.New(rand.NewSource(1)))
+	if !ok {
+		t.Fatal("Sample on non-empty archive failed")
+	}
+	if e.Candidate.Numerator.(*expr.ConstNode).Val != 1 || e.Candidate.Start == 7 {
+		t.Error("archived candidate aliased the caller's candidate")
+	}
+}
+
+func TestLookup(t *testing.T) {
+	a := New(4)
+	c := cand(5)
+	a.Add(c, fit(3))
+	key := series.CanonicalKey(c)
+	e, ok := a.Lookup(key)
+	if !ok || e.Fitness.Combined != 3 || e.Candidate.String() != c.String() {
+		t.Errorf("Lookup(%q) = %v, %v", key, e, ok)
+	}
+	if _, ok := a.Lookup(series.CanonicalKey(cand(6))); ok {
+		t.Error("Lookup of absent key returned ok")
+	}
+}
+
+func TestAddDedupsEquivalent(t *testing.T) {
+	a := New(16)
+	a.Add(cand(1), fit(1))
+	// 1/(n+1) from 0 is 1/n from 1, and -1/-n is 1/n.
+	shifted := &series.Candidate{
+		Numerator:   &expr.UnaryNode{Op: expr.OpNeg, Child: &expr.ConstNode{Val: 1}},
+		Denominator: &expr.UnaryNode{Op: expr.OpNeg, Child: &expr.VarNode{}},
+		Start:       1,
+	}
+	if a.Add(shifted, fit(0.5)) {
+		t.Error("worse equivalent candidate accepted")
+	}
+	if a.Len() != 1 {
+		t.Errorf("Len = %d, want 1", a.Len())
+	}
+}
+
+func TestSampleEmpty(t *testing.T) {
+	if _, ok := New(4).Sample(rand.New(rand.NewSource(1))); ok {
+		t.Error("Sample on empty archive returned ok")
+	}
+}
+
+func TestConcurrentAddSample(t *testing.T) {
+	a := New(256)
+	var wg sync.WaitGroup
+	for w := 0; w < 16; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			rng := rand.New(rand.NewSource(int64(w)))
+			for i := 0; i < 500; i++ {
+				k := rng.Int63n(1000)
+				a.Add(cand(k), fit(float64(k)))
+				a.Sample(rng)
+			}
+		}(w)
+	}
+	wg.Wait()
+	if n := a.Len(); n == 0 || n > 256 {
+		t.Errorf("Len = %d, want 1..256", n)
+	}
+}
+
+func TestShrinkKeepsBest(t *testing.T) {
+	a := NewSharded(8, 1)
+	for k := int64(0); k < 8; k++ {
+		a.Add(cand(k), fit(float64(k)))
+	}
+	if got := a.Shrink(3); got != 5 {
+		t.Errorf("Shrink dropped %d, want 5", got)
+	}
+	if a.Len() != 3 || a.Cap() != 3 {
+		t.Fatalf("Len = %d, Cap = %d after Shrink(3)", a.Len(), a.Cap())
+	}
+	if best := a.Best(0); best[2].Fitness.Combined != 5 {
+		t.Errorf("worst kept = %v, want 5", best[2].Fitness.Combined)
+	}
+	if _, ok := a.Lookup(series.CanonicalKey(cand(6))); !ok {
+		t.Error("kept entry not found after Shrink")
+	}
+	if a.Add(cand(100), fit(1)) {
+		t.Error("Add past the new capacity accepted a worse candidate")
+	}
+	if a.Shrink(10) != 0 || a.Cap() != 3 {
+		t.Errorf("Shrink raised the capacity to %d", a.Cap())
+	}
+}
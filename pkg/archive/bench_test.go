@@ -0,0 +1,52 @@
+package archive
+
+import (
+	"fmt"
+	"math/rand"
+	"sync/atomic"
+	"testing"
+
+	"github.com/wildfunctions/genetic_series/pkg/series"
+)
+
+// benchmarkParallel runs a mixed Add/Sample workload from 16×GOMAXPROCS
+// goroutines, the shape of the engine's evaluation workers.
+func benchmarkParallel(b *testing.B, shards int, addFrac float64) {
+	const keys = 4096
+	cands := make([]*series.Candidate, keys)
+	strs := make([]string, keys)
+	for i := range cands {
+		cands[i] = cand(int64(i))
+		strs[i] = cands[i].String()
+	}
+	a := NewSharded(1024, shards)
+	for i := 0; i < 1024; i++ {
+		a.AddKeyed(strs[i], cands[i], fit(float64(i)))
+	}
+
+	var seed int64
+	b.SetParallelism(16)
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		rng := rand.New(rand.NewSource(atomic.AddInt64(&seed, 1)))
+		for pb.Next() {
+			if rng.Float64() < addFrac {
+				k := rng.Intn(keys)
+				a.AddKeyed(strs[k], cands[k], fit(rng.Float64()*keys))
+			} else {
+				a.Sample(rng)
+			}
+		}
+	})
+}
+
+func BenchmarkArchiveParallel(b *testing.B) {
+	for _, shards := range []int{1, DefaultShards} {
+		for _, addFrac := range []float64{0.1, 0.9} {
+			b.Run(fmt.Sprintf("shards%d/add%.0f%%", shards, addFrac*100), func(b *testing.B) {
+				benchmarkParallel(b, shards, addFrac)
+			})
+		}
+	}
+}
@@ -0,0 +1,202 @@
+// Package bloom is a Bloom filter of candidate hashes (see tabu.KeyOf),
+// compact enough to carry everything earlier runs explored into a new one
+// without loading their candidates. Contains may report a key that was
+// never added, at about the rate FalsePositiveRate estimates, but never
+// misses one that was.
+package bloom
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+)
+
+// magic starts a saved filter; the byte after it is the format version.
+const (
+	magic   = "GSBF"
+	version = 1
+)
+
+// Filter is a Bloom filter over 64-bit keys. A nil *Filter is empty and
+// ignores Add. It is not safe for concurrent Add; concurrent Contains is
+// fine.
+type Filter struct {
+	bits []uint64
+	k    uint32 // probes per key
+	n    uint64 // keys added
+}
+
+// New returns a filter sized for capacity keys at false positive rate fp.
+func New(capacity int, fp float64) *Filter {
+	capacity = max(capacity, 1)
+	fp = min(max(fp, 1e-9), 0.5)
+	// The standard optimum: m = -n·ln p / ln²2 bits and k = m/n·ln 2.
+	m := math.Ceil(-float64(capacity) * math.Log(fp) / (math.Ln2 * math.Ln2))
+	words := int(math.Ceil(m / 64))
+	k := uint32(math.Round(float64(words*64) / float64(capacity) * math.Ln2))
+	return &Filter{bits: make([]uint64, words), k: max(k, 1)}
+}
+
+// probes calls f with the k bit positions of key, by double hashing: the
+// key itself and a mix of it, odd so it steps through every position.
+func (f *Filter) probes(key uint64, visit func(bit uint64) bool) {
+	m := uint64(len(f.bits)) * 64
+	h2 := mix(key) | 1
+	for i := uint64(0); i < uint64(f.k); i++ {
+		if !visit((key + i*h2) % m) {
+			return
+		}
+	}
+}
+
+// mix is the SplitMix64 finalizer.
+func mix(x uint64) uint64 {
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	return x ^ (x >> 31)
+}
+
+// Add records key.
+func (f *Filter) Add(key uint64) {
+	if f == nil {
+		return
+	}
+	f.probes(key, func(bit uint64) bool {
+		f.bits[bit/64] |= 1 << (bit % 64)
+		return true
+	})
+	f.n++
+}
+
+// Contains reports whether key may have been added.
+func (f *Filter) Contains(key uint64) bool {
+	if f == nil {
+		return false
+	}
+	found := true
+	f.probes(key, func(bit uint64) bool {
+		found = f.bits[bit/64]&(1<<(bit%64)) != 0
+		return found
+	})
+	return found
+}
+
+// Len returns the number of keys added, counting repeats.
+func (f *Filter) Len() uint64 {
+	if f == nil {
+		return 0
+	}
+	return f.n
+}
+
+// FalsePositiveRate estimates the chance that Contains reports a key that
+// was not added, from the number of keys added so far.
+func (f *Filter) FalsePositiveRate() float64 {
+	if f == nil || f.n == 0 {
+		return 0
+	}
+	m, k := float64(len(f.bits)*64), float64(f.k)
+	return math.Pow(1-math.Exp(-k*float64(f.n)/m), k)
+}
+
+// Clone returns an independent copy of f.
+func (f *Filter) Clone() *Filter {
+	if f == nil {
+		return nil
+	}
+	return &Filter{bits: append([]uint64(nil), f.bits...), k: f.k, n: f.n}
+}
+
+// WriteTo writes f in the format Read reads: the magic, version, probe
+// count, key count and bit words, little-endian.
+func (f *Filter) WriteTo(w io.Writer) (int64, error) {
+	bw := bufio.NewWriter(w)
+	bw.WriteString(magic)
+	bw.WriteByte(version)
+	var head [20]byte
+	binary.LittleEndian.PutUint32(head[0:], f.k)
+	binary.LittleEndian.PutUint64(head[4:], f.n)
+	binary.LittleEndian.PutUint64(head[12:], uint64(len(f.bits)))
+	bw.Write(head[:])
+	var word [8]byte
+	for _, b := range f.bits {
+		binary.LittleEndian.PutUint64(word[:], b)
+		bw.Write(word[:])
+	}
+	return int64(len(magic) + 1 + len(head) + 8*len(f.bits)), bw.Flush()
+}
+
+// Read reads a filter written by WriteTo.
+func Read(r io.Reader) (*Filter, error) {
+	return read(bufio.NewReader(r))
+}
+
+// read reads one filter from br, leaving anything after it unread.
+func read(br *bufio.Reader) (*Filter, error) {
+	var lead [len(magic) + 1]byte
+	if _, err := io.ReadFull(br, lead[:]); err != nil || string(lead[:len(magic)]) != magic {
+		return nil, errors.New("not a bloom filter file")
+	}
+	if lead[len(magic)] != version {
+		return nil, fmt.Errorf("bloom filter format version %d, want %d", lead[len(magic)], version)
+	}
+	var head [20]byte
+	if _, err := io.ReadFull(br, head[:]); err != nil {
+		return nil, fmt.Errorf("reading bloom filter header: %w", err)
+	}
+	f := &Filter{k: binary.LittleEndian.Uint32(head[0:]), n: binary.LittleEndian.Uint64(head[4:])}
+	words := binary.LittleEndian.Uint64(head[12:])
+	if f.k == 0 || words == 0 || words > 1<<32 {
+		return nil, fmt.Errorf("bad bloom filter header: %d probes, %d words", f.k, words)
+	}
+	f.bits = make([]uint64, words)
+	var word [8]byte
+	for i := range f.bits {
+		if _, err := io.ReadFull(br, word[:]); err != nil {
+			return nil, fmt.Errorf("reading bloom filter: %w", err)
+		}
+		f.bits[i] = binary.LittleEndian.Uint64(word[:])
+	}
+	return f, nil
+}
+
+// Load reads the filter saved at path.
+func Load(path string) (*Filter, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	f, err := Read(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return f, nil
+}
+
+// Save writes f to path, through a temporary file renamed into place so an
+// interrupted save leaves the old filter intact.
+func (f *Filter) Save(path string) error { return save(path, f) }
+
+func save(path string, f io.WriterTo) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	_, err = f.WriteTo(tmp)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
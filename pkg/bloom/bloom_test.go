@@ -0,0 +1,123 @@
+package bloom
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFilter(t *testing.T) {
+	f := New(10000, 0.01)
+	for k := uint64(0); k < 10000; k++ {
+		f.Add(k * 7919)
+	}
+	for k := uint64(0); k < 10000; k++ {
+		if !f.Contains(k * 7919) {
+			t.Fatalf("added key %d missing", k*7919)
+		}
+	}
+	fp := 0
+	for k := uint64(0); k < 100000; k++ {
+		if f.Contains(k*7919 + 1) {
+			fp++
+		}
+	}
+	if rate := float64(fp) / 100000; rate > 0.02 {
+		t.Errorf("false positive rate %.4f, sized for 0.01", rate)
+	}
+	if est := f.FalsePositiveRate(); est < 0.005 || est > 0.02 {
+		t.Errorf("estimated false positive rate %.4f", est)
+	}
+
+	var nilFilter *Filter
+	nilFilter.Add(1)
+	if nilFilter.Contains(1) || nilFilter.Len() != 0 {
+		t.Error("nil filter not empty")
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	f := New(100, 0.01)
+	f.Add(42)
+	path := filepath.Join(t.TempDir(), "seen.bloom")
+	if err := f.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	g, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !g.Contains(42) || g.Len() != 1 || g.k != f.k || len(g.bits) != len(f.bits) {
+		t.Errorf("loaded %+v, saved %+v", g, f)
+	}
+
+	var b bytes.Buffer
+	f.WriteTo(&b)
+	data := b.Bytes()
+	for name, bad := range map[string][]byte{
+		"not a filter": []byte("hello world"),
+		"version":      append([]byte("GSBF\x09"), data[5:]...),
+		"truncated":    data[:len(data)-3],
+	} {
+		if _, err := Read(bytes.NewReader(bad)); err == nil {
+			t.Errorf("%s: read succeeded", name)
+		}
+	}
+	if _, err := Load(filepath.Join(t.TempDir(), "missing")); !os.IsNotExist(err) {
+		t.Errorf("missing file: %v", err)
+	}
+}
+
+func TestScalable(t *testing.T) {
+	// Ten times the first filter's capacity: a single Filter would be at
+	// ~100% false positives, the growing one stays near its design rate.
+	s := NewScalable(1000, 0.01)
+	for k := uint64(0); k < 10000; k++ {
+		s.Add(k * 7919)
+	}
+	for k := uint64(0); k < 10000; k++ {
+		if !s.Contains(k * 7919) {
+			t.Fatalf("added key %d missing", k*7919)
+		}
+	}
+	fp := 0
+	for k := uint64(0); k < 100000; k++ {
+		if s.Contains(k*7919 + 1) {
+			fp++
+		}
+	}
+	if rate := float64(fp) / 100000; rate > 0.02 {
+		t.Errorf("false positive rate %.4f over %d filters, want under 0.02", rate, s.Filters())
+	}
+	if est := s.FalsePositiveRate(); s.Filters() < 3 || est > 0.02 {
+		t.Errorf("%d filters, estimated false positive rate %.4f", s.Filters(), est)
+	}
+
+	path := filepath.Join(t.TempDir(), "seen.bloom")
+	if err := s.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	g, err := LoadScalable(path, 0.01)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Filters() != s.Filters() || g.Len() != s.Len() || !g.Contains(7919*9999) {
+		t.Errorf("loaded %d filters, %d keys; saved %d, %d", g.Filters(), g.Len(), s.Filters(), s.Len())
+	}
+
+	// A file of one Filter loads as a Scalable of it.
+	f := New(100, 0.01)
+	f.Add(42)
+	if err := f.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	if g, err = LoadScalable(path, 0.01); err != nil || g.Filters() != 1 || !g.Contains(42) {
+		t.Errorf("single filter loaded as %v, %v", g, err)
+	}
+	var b bytes.Buffer
+	f.WriteTo(&b)
+	if _, err := ReadScalable(bytes.NewReader(b.Bytes()[:b.Len()-3]), 0.01); err == nil {
+		t.Error("truncated filter read")
+	}
+}
@@ -0,0 +1,149 @@
+package bloom
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"os"
+)
+
+// Scalable is a Bloom filter that grows with the keys added to it, after
+// Almeida et al.'s scalable Bloom filters: keys go into the newest of a
+// list of Filters, and once that one's estimated false positive rate
+// passes its design rate a new one is started at twice the keys and half
+// the rate. The rate over all of them stays below twice the first one's
+// however many keys are added, where a single Filter past its capacity
+// only gets worse. A nil *Scalable is empty and ignores Add.
+type Scalable struct {
+	filters []*Filter
+	fp      float64 // design rate of filters[0]; filters[i] gets fp/2^i
+}
+
+// NewScalable returns a filter whose first Filter is sized for capacity
+// keys at false positive rate fp.
+func NewScalable(capacity int, fp float64) *Scalable {
+	return &Scalable{filters: []*Filter{New(capacity, fp)}, fp: fp}
+}
+
+// rate is the design false positive rate of the i-th filter.
+func (s *Scalable) rate(i int) float64 { return s.fp / math.Exp2(float64(i)) }
+
+// Add records key.
+func (s *Scalable) Add(key uint64) {
+	if s == nil {
+		return
+	}
+	i := len(s.filters) - 1
+	last := s.filters[i]
+	last.Add(key)
+	if last.FalsePositiveRate() > s.rate(i) {
+		s.filters = append(s.filters, New(int(min(2*last.Len(), math.MaxInt32)), s.rate(i+1)))
+	}
+}
+
+// Contains reports whether key may have been added.
+func (s *Scalable) Contains(key uint64) bool {
+	if s == nil {
+		return false
+	}
+	for _, f := range s.filters {
+		if f.Contains(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// Len returns the number of keys added, counting repeats.
+func (s *Scalable) Len() uint64 {
+	if s == nil {
+		return 0
+	}
+	var n uint64
+	for _, f := range s.filters {
+		n += f.Len()
+	}
+	return n
+}
+
+// Filters returns the number of Filters s has grown to.
+func (s *Scalable) Filters() int {
+	if s == nil {
+		return 0
+	}
+	return len(s.filters)
+}
+
+// FalsePositiveRate estimates the chance that Contains reports a key that
+// was not added: that of any of its Filters doing so.
+func (s *Scalable) FalsePositiveRate() float64 {
+	if s == nil {
+		return 0
+	}
+	miss := 1.0
+	for _, f := range s.filters {
+		miss *= 1 - f.FalsePositiveRate()
+	}
+	return 1 - miss
+}
+
+// Clone returns an independent copy of s.
+func (s *Scalable) Clone() *Scalable {
+	if s == nil {
+		return nil
+	}
+	c := &Scalable{filters: make([]*Filter, len(s.filters)), fp: s.fp}
+	for i, f := range s.filters {
+		c.filters[i] = f.Clone()
+	}
+	return c
+}
+
+// WriteTo writes the Filters of s one after another, oldest first, each as
+// Filter.WriteTo writes it; a file of one Filter reads as a Scalable of it.
+func (s *Scalable) WriteTo(w io.Writer) (int64, error) {
+	var total int64
+	for _, f := range s.filters {
+		n, err := f.WriteTo(w)
+		total += n
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+// ReadScalable reads a filter written by Scalable.WriteTo, or a single
+// Filter's, with fp the design rate of the first Filter.
+func ReadScalable(r io.Reader, fp float64) (*Scalable, error) {
+	br := bufio.NewReader(r)
+	s := &Scalable{fp: fp}
+	for {
+		if _, err := br.Peek(1); err == io.EOF && len(s.filters) > 0 {
+			return s, nil
+		}
+		f, err := read(br)
+		if err != nil {
+			return nil, err
+		}
+		s.filters = append(s.filters, f)
+	}
+}
+
+// LoadScalable reads the Scalable saved at path.
+func LoadScalable(path string, fp float64) (*Scalable, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	s, err := ReadScalable(file, fp)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return s, nil
+}
+
+// Save writes s to path as Filter.Save does.
+func (s *Scalable) Save(path string) error { return save(path, s) }
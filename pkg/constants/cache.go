@@ -0,0 +1,109 @@
+package constants
+
+import (
+	"math/big"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// cacheDir is where computed constants are kept between runs (see
+// SetCacheDir); empty keeps them in memory only.
+var cacheDir struct {
+	sync.Mutex
+	path string
+}
+
+// SetCacheDir makes At keep the constants it computes past their stored
+// digits in dir, one file per constant and precision, and look there
+// before computing, so a run at 100k digits starts as fast as the last
+// one left off. A cached value at a higher precision serves any lower one.
+// The cache is best-effort: unreadable files are recomputed and failed
+// writes only cost the next run the computation. Empty disables it.
+func SetCacheDir(dir string) error {
+	if dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+	}
+	cacheDir.Lock()
+	cacheDir.path = dir
+	cacheDir.Unlock()
+	return nil
+}
+
+func cachePath() string {
+	cacheDir.Lock()
+	defer cacheDir.Unlock()
+	return cacheDir.path
+}
+
+// cacheExt names cached constants <name>.<prec>.bigf; the contents are
+// the value's big.Float GobEncode form, which is exact.
+const cacheExt = ".bigf"
+
+// loadCached returns c at prec from the smallest cached precision that
+// covers it.
+func (c *Constant) loadCached(prec uint) (*big.Float, bool) {
+	dir := cachePath()
+	if dir == "" {
+		return nil, false
+	}
+	matches, _ := filepath.Glob(filepath.Join(dir, c.Name+".*"+cacheExt))
+	best := uint(0)
+	for _, m := range matches {
+		p, err := strconv.ParseUint(strings.TrimSuffix(strings.TrimPrefix(filepath.Base(m), c.Name+"."), cacheExt), 10, 32)
+		if err == nil && uint(p) >= prec && (best == 0 || uint(p) < best) {
+			best = uint(p)
+		}
+	}
+	if best == 0 {
+		return nil, false
+	}
+	data, err := os.ReadFile(filepath.Join(dir, c.Name+"."+strconv.FormatUint(uint64(best), 10)+cacheExt))
+	if err != nil {
+		return nil, false
+	}
+	v := new(big.Float)
+	if v.GobDecode(data) != nil || v.Prec() < prec || !c.agrees(v) {
+		return nil, false
+	}
+	return v.SetPrec(prec), true
+}
+
+// agrees reports whether v matches the registered digits to all but the
+// last few bits of DefaultPrecision, guarding against a stale or foreign
+// file.
+func (c *Constant) agrees(v *big.Float) bool {
+	d := new(big.Float).SetPrec(DefaultPrecision).Sub(v, c.Value)
+	return d.Sign() == 0 || d.MantExp(nil) <= c.Value.MantExp(nil)-DefaultPrecision+8
+}
+
+// storeCached writes v, c at prec bits, to the cache, through a temporary
+// file so a concurrent reader never sees part of it.
+func (c *Constant) storeCached(prec uint, v *big.Float) {
+	dir := cachePath()
+	if dir == "" {
+		return
+	}
+	data, err := v.GobEncode()
+	if err != nil {
+		return
+	}
+	tmp, err := os.CreateTemp(dir, c.Name+".tmp*")
+	if err != nil {
+		return
+	}
+	_, err = tmp.Write(data)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), filepath.Join(dir, c.Name+"."+strconv.FormatUint(uint64(prec), 10)+cacheExt))
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+}
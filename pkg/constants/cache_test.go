@@ -0,0 +1,51 @@
+package constants
+
+import (
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCacheDir(t *testing.T) {
+	dir := t.TempDir()
+	if err := SetCacheDir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer SetCacheDir("")
+	forget := func() {
+		computed.Lock()
+		computed.m = nil
+		computed.Unlock()
+	}
+	forget()
+	defer forget()
+
+	pi := Get("pi")
+	want := pi.At(3001)
+	if _, err := os.Stat(filepath.Join(dir, "pi.3001"+cacheExt)); err != nil {
+		t.Fatalf("not cached: %v", err)
+	}
+
+	// A later run reads it back, at that precision or any lower one,
+	// without computing.
+	forget()
+	pi.compute = func(uint) *big.Float { t.Fatal("computed despite the cache"); return nil }
+	if got := pi.At(3001); got.Cmp(want) != 0 {
+		t.Errorf("At(3001) from cache = %s, want %s", got.Text('g', 20), want.Text('g', 20))
+	}
+	if got, w := pi.At(2000), new(big.Float).SetPrec(2000).Set(want); got.Cmp(w) != 0 || got.Prec() != 2000 {
+		t.Errorf("At(2000) from cache differs")
+	}
+
+	// A file that disagrees with the stored digits is recomputed.
+	forget()
+	e := Get("e")
+	data, _ := Get("pi").At(4000).GobEncode()
+	if err := os.WriteFile(filepath.Join(dir, "e.4000"+cacheExt), data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got := e.At(4000); agreeBits(got, e.Value) < DefaultPrecision-8 {
+		t.Errorf("e read from a foreign cache file")
+	}
+}
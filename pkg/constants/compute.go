@@ -0,0 +1,184 @@
+package constants
+
+import (
+	"math/big"
+	"math/bits"
+)
+
+// guardBits are computed beyond the requested precision so that the
+// truncation errors of the fixed-point series stay below its last bit.
+const guardBits = 64
+
+// The generators below compute a constant to any number of bits in fixed
+// point: a big.Int v stands for v / 2^b. Every series is summed with
+// exact small-integer multiplications and divisions, so the only error is
+// truncation, a few units of the last place per term.
+
+// fixedToFloat rounds the fixed-point value v / 2^b to prec bits.
+func fixedToFloat(v *big.Int, b, prec uint) *big.Float {
+	f := new(big.Float).SetPrec(prec).SetInt(v)
+	return f.SetMantExp(f, -int(b))
+}
+
+// one returns 1 in fixed point with b fractional bits.
+func one(b uint) *big.Int {
+	return new(big.Int).Lsh(big.NewInt(1), b)
+}
+
+// arctanInv returns arctan(1/x), or atanh(1/x) if hyperbolic, to b bits.
+func arctanInv(x int64, b uint, hyperbolic bool) *big.Int {
+	sum := new(big.Int)
+	power := new(big.Int).Quo(one(b), big.NewInt(x)) // x^-(2k+1)
+	x2 := big.NewInt(x * x)
+	term := new(big.Int)
+	for k := int64(0); power.Sign() != 0; k++ {
+		term.Quo(power, big.NewInt(2*k+1))
+		if k%2 == 1 && !hyperbolic {
+			sum.Sub(sum, term)
+		} else {
+			sum.Add(sum, term)
+		}
+		power.Quo(power, x2)
+	}
+	return sum
+}
+
+// fixedPi is Machin's formula, π = 16 arctan(1/5) − 4 arctan(1/239).
+func fixedPi(b uint) *big.Int {
+	a := arctanInv(5, b, false)
+	a.Lsh(a, 4)
+	c := arctanInv(239, b, false)
+	c.Lsh(c, 2)
+	return a.Sub(a, c)
+}
+
+// fixedLn2 is ln 2 = 18 atanh(1/26) − 2 atanh(1/4801) + 8 atanh(1/8749).
+func fixedLn2(b uint) *big.Int {
+	s := new(big.Int).Mul(arctanInv(26, b, true), big.NewInt(18))
+	s.Sub(s, new(big.Int).Mul(arctanInv(4801, b, true), big.NewInt(2)))
+	return s.Add(s, new(big.Int).Mul(arctanInv(8749, b, true), big.NewInt(8)))
+}
+
+func computePi(prec uint) *big.Float {
+	b := prec + guardBits
+	return fixedToFloat(fixedPi(b), b, prec)
+}
+
+func computeOneOverPi(prec uint) *big.Float {
+	b := prec + guardBits
+	inv := new(big.Int).Quo(one(2*b), fixedPi(b))
+	return fixedToFloat(inv, b, prec)
+}
+
+// computeE sums 1/k!.
+func computeE(prec uint) *big.Float {
+	b := prec + guardBits
+	sum := new(big.Int)
+	term := one(b)
+	for k := int64(1); term.Sign() != 0; k++ {
+		sum.Add(sum, term)
+		term.Quo(term, big.NewInt(k))
+	}
+	return fixedToFloat(sum, b, prec)
+}
+
+func computeLn2(prec uint) *big.Float {
+	b := prec + guardBits
+	return fixedToFloat(fixedLn2(b), b, prec)
+}
+
+// computePhi is the golden ratio, (1 + √5) / 2.
+func computePhi(prec uint) *big.Float {
+	b := prec + guardBits
+	s := new(big.Int).Sqrt(new(big.Int).Lsh(big.NewInt(5), 2*b))
+	s.Add(s, one(b))
+	return fixedToFloat(s.Rsh(s, 1), b, prec)
+}
+
+// computeCatalan uses Ramanujan's
+//
+//	G = π/8 · ln(2+√3) + 3/8 · Σ_{k≥0} (k!)² / ((2k)! (2k+1)²)
+//
+// with ln(2+√3) = 2 atanh(1/√3) = 2/√3 · Σ_{k≥0} 1 / (3^k (2k+1)).
+func computeCatalan(prec uint) *big.Float {
+	b := prec + guardBits
+
+	s := new(big.Int)
+	t := one(b) // (k!)² / (2k)!
+	term := new(big.Int)
+	for k := int64(0); t.Sign() != 0; {
+		term.Quo(t, big.NewInt((2*k+1)*(2*k+1)))
+		s.Add(s, term)
+		k++
+		t.Mul(t, big.NewInt(k))
+		t.Quo(t, big.NewInt(2*(2*k-1)))
+	}
+	s.Mul(s, big.NewInt(3))
+
+	l := new(big.Int)
+	p := one(b) // 3^-k
+	for k := int64(0); p.Sign() != 0; k++ {
+		l.Add(l, term.Quo(p, big.NewInt(2*k+1)))
+		p.Quo(p, big.NewInt(3))
+	}
+	sqrt3 := new(big.Int).Sqrt(new(big.Int).Lsh(big.NewInt(3), 2*b))
+	l.Lsh(l, b+1)
+	l.Quo(l, sqrt3) // ln(2+√3)
+
+	g := new(big.Int).Mul(fixedPi(b), l)
+	g.Rsh(g, b)
+	g.Add(g, s)
+	g.Rsh(g, 3)
+	return fixedToFloat(g, b, prec)
+}
+
+// computeApery uses ζ(3) = 5/2 · Σ_{k≥1} (−1)^(k+1) / (k³ C(2k,k)).
+func computeApery(prec uint) *big.Float {
+	b := prec + guardBits
+	sum := new(big.Int)
+	t := new(big.Int).Rsh(one(b), 1) // 1 / C(2k,k)
+	term := new(big.Int)
+	for k := int64(1); t.Sign() != 0; k++ {
+		term.Quo(t, big.NewInt(k*k*k))
+		if k%2 == 1 {
+			sum.Add(sum, term)
+		} else {
+			sum.Sub(sum, term)
+		}
+		t.Mul(t, big.NewInt(k+1))
+		t.Quo(t, big.NewInt(2*(2*k+1)))
+	}
+	sum.Mul(sum, big.NewInt(5))
+	sum.Rsh(sum, 1)
+	return fixedToFloat(sum, b, prec)
+}
+
+// computeEulerGamma is Brent and McMillan's algorithm B1: with n = 2^m,
+//
+//	γ ≈ U/V,  U = Σ_{k≥0} (n^k/k!)² (H_k − ln n),  V = Σ_{k≥0} (n^k/k!)²
+//
+// with error below π e^(−4n), so n is chosen with 4n ≥ b ln 2.
+func computeEulerGamma(prec uint) *big.Float {
+	b := prec + guardBits
+	m := uint(bits.Len(uint(float64(b)*0.6931471805599453/4) + 1))
+
+	a := fixedLn2(b)
+	a.Mul(a, big.NewInt(int64(m)))
+	a.Neg(a) // A_0 = −ln n
+	bk := one(b)
+	u := new(big.Int).Set(a)
+	v := new(big.Int).Set(bk)
+	for k := int64(1); a.Sign() != 0 || bk.Sign() != 0; k++ {
+		kk := big.NewInt(k * k)
+		bk.Lsh(bk, 2*m)
+		bk.Quo(bk, kk) // B_k = B_{k−1} n²/k²
+		a.Lsh(a, 2*m)
+		a.Quo(a, big.NewInt(k))
+		a.Add(a, bk)
+		a.Quo(a, big.NewInt(k)) // A_k = (A_{k−1} n²/k + B_k)/k
+		u.Add(u, a)
+		v.Add(v, bk)
+	}
+	u.Lsh(u, b)
+	return fixedToFloat(u.Quo(u, v), b, prec)
+}
@@ -1,15 +1,25 @@
+// Package constants holds the target constants, computed to any precision
+// and cached, and the target expressions built from them (ParseTarget).
 package constants
 
-import "math/big"
+import (
+	"math/big"
+	"sort"
+)
 
 // DefaultPrecision is the default precision in bits (~154 decimal digits).
 const DefaultPrecision = 512
 
 // Constant represents a named mathematical constant with a high-precision value.
+// Value holds the stored digits at DefaultPrecision; At computes the
+// constant to any precision.
 type Constant struct {
 	Name         string
 	Value        *big.Float
 	Float64Value float64
+
+	digits  string // as registered
+	compute func(prec uint) *big.Float
 }
 
 var registry = map[string]Constant{}
@@ -20,9 +30,10 @@ func init() {
 		"0.5772156649015328606065120900824024310421"+
 			"5933593992359880576723488486772677766467"+
 			"0936947063291746749514631447249807082480"+
-			"9600265022796782354781636009341721641219"+
-			"9245863150302861829745557067498385054945"+
-			"8858692699569092721079750930295532116534")
+			"9605040144865428362241739976449235362535"+
+			"0033374293733773767394279259525824709491"+
+			"6008735203948165670853233151776611528621",
+		computeEulerGamma)
 
 	// pi
 	register("pi",
@@ -31,16 +42,18 @@ func init() {
 			"8628034825342117067982148086513282306647"+
 			"0938446095505822317253594081284811174502"+
 			"8410270193852110555964462294895493038196"+
-			"4428810975665933446128475648233786783165")
+			"4428810975665933446128475648233786783165",
+		computePi)
 
 	// 1/pi
 	register("one_over_pi",
 		"0.3183098861837906715377675267450287240689"+
 			"1929148091289749533468811779359526845307"+
-			"0180227605532506171912145685453515916767"+
-			"3089137555998655553977456851355543069437"+
-			"4838801885528043515624860753515250535751"+
-			"6461625897666954163289939900838308356625")
+			"0180227605532506171912145685453515916073"+
+			"7858236922291573057559348214633996784584"+
+			"7993387481815514615549279385061537743478"+
+			"5792434795323386724780483447258023664760",
+		computeOneOverPi)
 
 	// e (Euler's number)
 	register("e",
@@ -49,7 +62,8 @@ func init() {
 			"4571382178525166427427466391932003059921"+
 			"8174135966290435729003342952605956307381"+
 			"3232862794349076323382988075319525101901"+
-			"1573834187930702154089149934884167509244")
+			"1573834187930702154089149934884167509244",
+		computeE)
 
 	// natural log of 2
 	register("ln2",
@@ -58,7 +72,8 @@ func init() {
 			"5605863326996418687542001481020570685733"+
 			"6855202357581305570326707516350759619307"+
 			"2757082837143519030703862389167347112335"+
-			"0115364497955239120475174897943149409978")
+			"0115364497955239120475172681574932065155",
+		computeLn2)
 
 	// Catalan's constant
 	register("catalan",
@@ -67,25 +82,37 @@ func init() {
 			"9479356512926115106248574422619196199579"+
 			"0358988033258590594315947374811584069953"+
 			"3202877331946051903872747816408786590902"+
-			"4706484152163000228727640942388259957741")
+			"4706484152163000228727640942388259957741",
+		computeCatalan)
 
 	// Apery's constant (zeta(3))
 	register("apery",
 		"1.2020569031595942853997381615114499907649"+
 			"8629234049888179227155534183820578631309"+
 			"0186455873609335258146199157795260719418"+
-			"4925066265788304366421542293787082365828"+
-			"1175663006524234158764758002549537812485"+
-			"0654059421297375648142983530828308522096")
+			"4919959986732832137763968372079001614539"+
+			"4178294936006671919157552224249424396156"+
+			"3909664103291159095780965514651279918405",
+		computeApery)
+
+	// golden ratio
+	register("phi",
+		"1.6180339887498948482045868343656381177203"+
+			"0917980576286213544862270526046281890244"+
+			"9707207204189391137484754088075386891752"+
+			"1266338622235369317931800607667263544333"+
+			"8908659593958290563832266131992829026788"+
+			"0675208766892501711696207032221043216269",
+		computePhi)
 }
 
-func register(name, value string) {
+func register(name, value string, compute func(prec uint) *big.Float) {
 	f, _, err := big.ParseFloat(value, 10, DefaultPrecision, big.ToNearestEven)
 	if err != nil {
 		panic("bad constant " + name + ": " + err.Error())
 	}
 	f64, _ := f.Float64()
-	registry[name] = Constant{Name: name, Value: f, Float64Value: f64}
+	registry[name] = Constant{Name: name, Value: f, Float64Value: f64, digits: value, compute: compute}
 }
 
 // Get returns the constant with the given name, or nil if not found.
@@ -97,11 +124,12 @@ func Get(name string) *Constant {
 	return &c
 }
 
-// Names returns all registered constant names.
+// Names returns all registered constant names, sorted.
 func Names() []string {
 	names := make([]string, 0, len(registry))
 	for k := range registry {
 		names = append(names, k)
 	}
+	sort.Strings(names)
 	return names
 }
@@ -0,0 +1,153 @@
+package constants
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"strconv"
+)
+
+// Identity is a closed form P/Q · Base that a value is close to.
+type Identity struct {
+	P, Q   int64
+	Base   string  // registry name, "sqrtK", or "" for a plain rational
+	RelErr float64 // |value - P/Q·Base| / |value|
+}
+
+// symbols are the display forms of the bases Identify tries.
+var symbols = map[string]string{
+	"pi":          "π",
+	"e":           "e",
+	"euler_gamma": "γ",
+	"ln2":         "ln2",
+	"catalan":     "G",
+	"apery":       "ζ(3)",
+}
+
+// sqrtBases are the square roots Identify tries besides the registry.
+var sqrtBases = []int64{2, 3, 5, 6, 7}
+
+// String renders the identity compactly, e.g. "5√2", "π/4", "-3/7".
+func (m Identity) String() string {
+	sym := symbols[m.Base]
+	if sym == "" && m.Base != "" {
+		sym = "√" + m.Base[len("sqrt"):]
+	}
+	sign := ""
+	p := m.P
+	if p < 0 {
+		sign, p = "-", -p
+	}
+	num := strconv.FormatInt(p, 10)
+	if sym != "" {
+		num = sym
+		if p != 1 {
+			num = strconv.FormatInt(p, 10) + sym
+		}
+	}
+	if m.Q == 1 {
+		return sign + num
+	}
+	return fmt.Sprintf("%s%s/%d", sign, num, m.Q)
+}
+
+// cost ranks identities by how much there is to write down.
+func (m Identity) cost() int {
+	c := len(strconv.FormatInt(m.P, 10)) + len(strconv.FormatInt(m.Q, 10))
+	if m.Base != "" {
+		c++
+	}
+	return c
+}
+
+// Identify looks for P/Q·K within relative tolerance tol of x, with
+// Q ≤ maxDen and K one of 1, √2, √3, √5, √6, √7 or a registered constant
+// (1/π is covered by Q). For each K it takes the first continued-fraction
+// convergent of x/K within tolerance, which is the simplest rational there
+// is at that accuracy. Results are ordered simplest first.
+//
+// It is applied to sums only: eval's partial sum and each attempt's best
+// one. Coefficients need none, since the search's constants are integers
+// and constant subtrees fold to exact rationals (or are rounded, with a
+// warning, when irrational).
+func Identify(x *big.Float, tol float64, maxDen int64) []Identity {
+	if x.Sign() == 0 {
+		return nil
+	}
+	type base struct {
+		name string
+		val  *big.Float
+	}
+	bases := []base{{"", big.NewFloat(1)}}
+	for _, k := range sqrtBases {
+		v := new(big.Float).SetPrec(DefaultPrecision).SetInt64(k)
+		bases = append(bases, base{fmt.Sprintf("sqrt%d", k), v.Sqrt(v)})
+	}
+	for name := range symbols {
+		bases = append(bases, base{name, registry[name].Value})
+	}
+
+	var out []Identity
+	for _, b := range bases {
+		r := new(big.Float).SetPrec(DefaultPrecision).Quo(x, b.val)
+		p, q, ok := convergent(r, tol, maxDen)
+		if !ok || p == 0 {
+			continue
+		}
+		approx := new(big.Float).SetPrec(DefaultPrecision).SetInt64(p)
+		approx.Quo(approx, new(big.Float).SetInt64(q))
+		approx.Mul(approx, b.val)
+		diff := approx.Sub(approx, x)
+		rel, _ := diff.Quo(diff.Abs(diff), new(big.Float).Abs(x)).Float64()
+		out = append(out, Identity{P: p, Q: q, Base: b.name, RelErr: rel})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if ci, cj := out[i].cost(), out[j].cost(); ci != cj {
+			return ci < cj
+		}
+		return out[i].RelErr < out[j].RelErr
+	})
+	return out
+}
+
+// convergent returns the first continued-fraction convergent p/q of r
+// within relative tolerance tol, or false if none has q ≤ maxDen.
+func convergent(r *big.Float, tol float64, maxDen int64) (int64, int64, bool) {
+	bound := new(big.Float).Abs(r)
+	bound.Mul(bound, big.NewFloat(tol))
+	x := new(big.Float).SetPrec(DefaultPrecision).Set(r)
+	var p0, q0, p1, q1 int64 = 0, 1, 1, 0 // p_{k-2}/q_{k-2}, p_{k-1}/q_{k-1}
+	for i := 0; i < 64; i++ {
+		fl := new(big.Float).SetPrec(DefaultPrecision)
+		ai, _ := x.Int(nil)
+		if x.Sign() < 0 && !x.IsInt() {
+			ai.Sub(ai, big.NewInt(1)) // floor, not truncation
+		}
+		if !ai.IsInt64() || math.Abs(float64(ai.Int64())) > 1<<31 {
+			return 0, 0, false
+		}
+		a := ai.Int64()
+		p, q := a*p1+p0, a*q1+q0
+		if q > maxDen || q <= 0 {
+			return 0, 0, false
+		}
+		p0, q0, p1, q1 = p1, q1, p, q
+
+		// |r - p/q| ≤ tol·|r| ?
+		diff := new(big.Float).SetPrec(DefaultPrecision).SetInt64(p)
+		diff.Quo(diff, new(big.Float).SetInt64(q))
+		diff.Sub(diff, r)
+		if diff.Abs(diff).Cmp(bound) <= 0 {
+			return p, q, true
+		}
+
+		fl.SetInt64(a)
+		x.Sub(x, fl)
+		if x.Sign() == 0 {
+			return 0, 0, false
+		}
+		x.Quo(big.NewFloat(1).SetPrec(DefaultPrecision), x)
+	}
+	return 0, 0, false
+}
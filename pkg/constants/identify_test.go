@@ -0,0 +1,32 @@
+package constants
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestIdentify(t *testing.T) {
+	sqrt2 := new(big.Float).SetPrec(DefaultPrecision).SetInt64(2)
+	sqrt2.Sqrt(sqrt2)
+	quarterPi := new(big.Float).Quo(Get("pi").Value, big.NewFloat(4))
+	cases := []struct {
+		x    *big.Float
+		want string
+	}{
+		{new(big.Float).Mul(sqrt2, big.NewFloat(5)), "5√2"},
+		{quarterPi, "π/4"},
+		{big.NewFloat(-0.375), "-3/8"},
+		{new(big.Float).Mul(Get("e").Value, big.NewFloat(3)), "3e"},
+	}
+	for _, tc := range cases {
+		ids := Identify(tc.x, 1e-12, 1000)
+		if len(ids) == 0 || ids[0].String() != tc.want {
+			t.Errorf("Identify(%s) = %v, want %s first", tc.x.Text('g', 15), ids, tc.want)
+		}
+	}
+
+	// A value only known to a few digits matches loosely.
+	if ids := Identify(big.NewFloat(7.0711), 1e-5, 1000); len(ids) == 0 || ids[0].String() != "5√2" {
+		t.Errorf("Identify(7.0711) = %v, want 5√2 first", ids)
+	}
+}
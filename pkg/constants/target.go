@@ -0,0 +1,503 @@
+package constants
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"os"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Target is a value series are compared against. At gives it at whatever
+// precision a comparison runs at, so that fitness at the search precision
+// and every rung of a verification ladder see a target as accurate as the
+// sums they are compared with.
+type Target interface {
+	// String is the spec the target was parsed from.
+	String() string
+	// At returns the value rounded to prec bits. Past Precision bits the
+	// result is only as good as the digits the target was given.
+	At(prec uint) *big.Float
+	// Precision is how many bits of the value are known, or 0 if it can
+	// be generated to any precision.
+	Precision() uint
+}
+
+// ParseTarget parses a target spec, which is one of
+//
+//	pi                 a registered constant (see Names)
+//	3.14159265358979   literal digits, known only as far as they go
+//	file:pi.txt        literal digits read from a file
+//	pi^2/6 - sqrt(2)   an expression over constants and exact numbers
+//	[1.2820, 1.2830]   an interval with bounds of either of the forms above
+//
+// Expressions take + - * /, integer powers with ^, sqrt(...) and
+// parentheses; numbers in them are exact, so an expression over the
+// registry can be generated to any precision. An interval is a Bounded
+// target.
+func ParseTarget(spec string) (Target, error) {
+	spec = strings.TrimSpace(spec)
+	if inner, ok := strings.CutPrefix(spec, "["); ok {
+		return parseInterval(spec, inner)
+	}
+	if path, ok := strings.CutPrefix(spec, "file:"); ok {
+		return FileTarget(path)
+	}
+	if c := Get(spec); c != nil {
+		return c, nil
+	}
+	if t, err := newLiteral(spec, spec); err == nil {
+		return t, nil
+	}
+	p := &targetParser{src: spec}
+	root, err := p.parse()
+	if err != nil {
+		return nil, fmt.Errorf("target %q: %w", spec, err)
+	}
+	t := &exprTarget{spec: spec, root: root}
+	if err := t.check(); err != nil {
+		return nil, fmt.Errorf("target %q: %w", spec, err)
+	}
+	return t, nil
+}
+
+// FileTarget reads literal digits from path, for targets known beyond what
+// the registry can generate. Whitespace is ignored, so the digits may be
+// wrapped over many lines; lines starting with # or % are comments.
+func FileTarget(path string) (Target, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var b strings.Builder
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "#") || strings.HasPrefix(line, "%") {
+			continue
+		}
+		for _, r := range line {
+			if !unicode.IsSpace(r) {
+				b.WriteRune(r)
+			}
+		}
+	}
+	t, err := newLiteral("file:"+path, b.String())
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return t, nil
+}
+
+// Bounded is a target known only to lie in an interval, such as a
+// measured constant with error bars. At is the interval's midpoint, and
+// Precision the bits its width leaves known.
+type Bounded interface {
+	Target
+	// Bounds returns the interval's ends, lo < hi, at prec bits.
+	Bounds(prec uint) (lo, hi *big.Float)
+}
+
+// interval is a Bounded target between two other targets.
+type interval struct {
+	spec   string
+	lo, hi Target
+}
+
+// parseInterval parses spec, "[lo, hi]", given inner, the text after "[".
+func parseInterval(spec, inner string) (Target, error) {
+	inner, ok := strings.CutSuffix(inner, "]")
+	lo, hi, comma := strings.Cut(inner, ",")
+	if !ok || !comma {
+		return nil, fmt.Errorf("target %q: want an interval [lo, hi]", spec)
+	}
+	t := &interval{spec: spec}
+	var err error
+	if t.lo, err = ParseTarget(lo); err != nil {
+		return nil, err
+	}
+	if t.hi, err = ParseTarget(hi); err != nil {
+		return nil, err
+	}
+	if _, ok := t.lo.(Bounded); ok {
+		return nil, fmt.Errorf("target %q: nested interval", spec)
+	}
+	if _, ok := t.hi.(Bounded); ok {
+		return nil, fmt.Errorf("target %q: nested interval", spec)
+	}
+	if l, h := t.Bounds(64); l.Cmp(h) >= 0 {
+		return nil, fmt.Errorf("target %q: empty interval", spec)
+	}
+	return t, nil
+}
+
+func (t *interval) String() string { return t.spec }
+
+func (t *interval) Bounds(prec uint) (lo, hi *big.Float) {
+	return t.lo.At(prec), t.hi.At(prec)
+}
+
+func (t *interval) At(prec uint) *big.Float {
+	lo, hi := t.Bounds(prec + guardBits)
+	mid := new(big.Float).SetPrec(prec+guardBits).Add(lo, hi)
+	mid.Quo(mid, big.NewFloat(2))
+	return new(big.Float).SetPrec(prec).Set(mid)
+}
+
+// Precision is log2(|mid| / half-width), at least 1: the bits the
+// interval pins down.
+func (t *interval) Precision() uint {
+	lo, hi := t.Bounds(64)
+	mid, _ := t.At(64).Float64()
+	w, _ := new(big.Float).Sub(hi, lo).Float64()
+	bits := math.Log2(math.Abs(mid) / (w / 2))
+	if math.IsNaN(bits) || math.IsInf(bits, 0) || bits < 1 {
+		return 1
+	}
+	return uint(math.Ceil(bits))
+}
+
+// computed caches generated constants by name and precision: the search
+// asks for one precision, and each verification ladder a few more.
+var computed struct {
+	sync.Mutex
+	m map[string]map[uint]*big.Float
+}
+
+func (c *Constant) String() string { return c.Name }
+
+// At returns the constant to prec bits, computing it if the stored digits
+// are not enough (or reading it from the cache; see SetCacheDir).
+func (c *Constant) At(prec uint) *big.Float {
+	if c.compute == nil || prec <= c.Value.Prec() {
+		return new(big.Float).SetPrec(prec).Set(c.Value)
+	}
+	computed.Lock()
+	defer computed.Unlock()
+	if computed.m == nil {
+		computed.m = map[string]map[uint]*big.Float{}
+	}
+	byPrec := computed.m[c.Name]
+	if byPrec == nil {
+		byPrec = map[uint]*big.Float{}
+		computed.m[c.Name] = byPrec
+	}
+	v, ok := byPrec[prec]
+	if !ok {
+		if v, ok = c.loadCached(prec); !ok {
+			v = c.compute(prec)
+			c.storeCached(prec, v)
+		}
+		byPrec[prec] = v
+	}
+	return new(big.Float).Copy(v)
+}
+
+// Precision is 0 for registered constants, which have generators, and the
+// stored precision for any that do not.
+func (c *Constant) Precision() uint {
+	if c.compute != nil {
+		return 0
+	}
+	return c.Value.Prec()
+}
+
+// literal is a target given as decimal digits.
+type literal struct {
+	name   string
+	digits string
+	bits   uint // known precision, from the significant digits
+}
+
+func newLiteral(name, digits string) (*literal, error) {
+	f, _, err := big.ParseFloat(digits, 10, 64, big.ToNearestEven)
+	if err != nil {
+		return nil, fmt.Errorf("invalid digits: %w", err)
+	}
+	if f.IsInf() {
+		return nil, fmt.Errorf("invalid digits: %s", digits)
+	}
+	return &literal{name: name, digits: digits, bits: uint(math.Ceil(float64(significantDigits(digits)) * math.Log2(10)))}, nil
+}
+
+// significantDigits counts the mantissa digits of a decimal, leading zeros
+// excluded.
+func significantDigits(s string) int {
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		s = s[:i]
+	}
+	n := 0
+	for _, r := range s {
+		if r >= '0' && r <= '9' && (n > 0 || r != '0') {
+			n++
+		}
+	}
+	return n
+}
+
+func (l *literal) String() string { return l.name }
+
+func (l *literal) At(prec uint) *big.Float {
+	f, _, _ := big.ParseFloat(l.digits, 10, prec, big.ToNearestEven)
+	return f
+}
+
+func (l *literal) Precision() uint { return l.bits }
+
+// exprTarget is an expression over constants.
+type exprTarget struct {
+	spec string
+	root targetNode
+}
+
+func (t *exprTarget) String() string { return t.spec }
+
+func (t *exprTarget) At(prec uint) *big.Float {
+	v := t.root.eval(prec + guardBits)
+	return new(big.Float).SetPrec(prec).Set(v)
+}
+
+// Precision is 0: every leaf is exact or a registered constant.
+func (t *exprTarget) Precision() uint { return 0 }
+
+// check evaluates the expression once, rejecting ones that are undefined
+// (a square root of a negative, a division by zero).
+func (t *exprTarget) check() (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("undefined: %v", r)
+		}
+	}()
+	if v := t.At(64); v.IsInf() {
+		return fmt.Errorf("undefined: division by zero")
+	}
+	return nil
+}
+
+// targetNode is a node of a target expression, evaluated at a working
+// precision.
+type targetNode interface {
+	eval(prec uint) *big.Float
+}
+
+type numNode struct{ r *big.Rat }
+
+func (n numNode) eval(prec uint) *big.Float { return new(big.Float).SetPrec(prec).SetRat(n.r) }
+
+type constNode struct{ c *Constant }
+
+func (n constNode) eval(prec uint) *big.Float { return n.c.At(prec) }
+
+type negNode struct{ x targetNode }
+
+func (n negNode) eval(prec uint) *big.Float { x := n.x.eval(prec); return x.Neg(x) }
+
+type sqrtNode struct{ x targetNode }
+
+func (n sqrtNode) eval(prec uint) *big.Float { x := n.x.eval(prec); return x.Sqrt(x) }
+
+type binNode struct {
+	op   byte
+	l, r targetNode
+}
+
+func (n binNode) eval(prec uint) *big.Float {
+	l, r := n.l.eval(prec), n.r.eval(prec)
+	switch n.op {
+	case '+':
+		return l.Add(l, r)
+	case '-':
+		return l.Sub(l, r)
+	case '*':
+		return l.Mul(l, r)
+	}
+	return l.Quo(l, r)
+}
+
+type powNode struct {
+	x targetNode
+	n int64
+}
+
+func (n powNode) eval(prec uint) *big.Float {
+	base := n.x.eval(prec)
+	result := new(big.Float).SetPrec(prec).SetInt64(1)
+	for k := max(n.n, -n.n); k > 0; k >>= 1 {
+		if k&1 == 1 {
+			result.Mul(result, base)
+		}
+		base.Mul(base, base)
+	}
+	if n.n < 0 {
+		result.Quo(new(big.Float).SetPrec(prec).SetInt64(1), result)
+	}
+	return result
+}
+
+// targetParser is a recursive-descent parser for target expressions:
+//
+//	expr   = term {("+" | "-") term}
+//	term   = unary {("*" | "/") unary}
+//	unary  = "-" unary | power
+//	power  = atom ["^" ["-"] integer]
+//	atom   = number | name | "sqrt" "(" expr ")" | "(" expr ")"
+type targetParser struct {
+	src string
+	pos int
+}
+
+func (p *targetParser) parse() (targetNode, error) {
+	n, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+	if p.skipSpace(); p.pos < len(p.src) {
+		return nil, fmt.Errorf("unexpected %q", p.src[p.pos:])
+	}
+	return n, nil
+}
+
+func (p *targetParser) skipSpace() {
+	for p.pos < len(p.src) && p.src[p.pos] == ' ' {
+		p.pos++
+	}
+}
+
+// accept consumes c if it is next.
+func (p *targetParser) accept(c byte) bool {
+	p.skipSpace()
+	if p.pos < len(p.src) && p.src[p.pos] == c {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *targetParser) expr() (targetNode, error) {
+	n, err := p.term()
+	for err == nil {
+		var op byte
+		switch {
+		case p.accept('+'):
+			op = '+'
+		case p.accept('-'):
+			op = '-'
+		default:
+			return n, nil
+		}
+		var r targetNode
+		if r, err = p.term(); err == nil {
+			n = binNode{op: op, l: n, r: r}
+		}
+	}
+	return nil, err
+}
+
+func (p *targetParser) term() (targetNode, error) {
+	n, err := p.unary()
+	for err == nil {
+		var op byte
+		switch {
+		case p.accept('*'):
+			op = '*'
+		case p.accept('/'):
+			op = '/'
+		default:
+			return n, nil
+		}
+		var r targetNode
+		if r, err = p.unary(); err == nil {
+			n = binNode{op: op, l: n, r: r}
+		}
+	}
+	return nil, err
+}
+
+func (p *targetParser) unary() (targetNode, error) {
+	if p.accept('-') {
+		x, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return negNode{x}, nil
+	}
+	return p.power()
+}
+
+func (p *targetParser) power() (targetNode, error) {
+	x, err := p.atom()
+	if err != nil || !p.accept('^') {
+		return x, err
+	}
+	neg := p.accept('-')
+	word := p.word()
+	e, ok := new(big.Int).SetString(word, 10)
+	if !ok || !e.IsInt64() {
+		return nil, fmt.Errorf("exponent must be an integer, got %q", word)
+	}
+	n := e.Int64()
+	if neg {
+		n = -n
+	}
+	return powNode{x: x, n: n}, nil
+}
+
+func (p *targetParser) atom() (targetNode, error) {
+	if p.accept('(') {
+		return p.closeParen(p.expr())
+	}
+	word := p.word()
+	switch {
+	case word == "":
+		if p.pos == len(p.src) {
+			return nil, fmt.Errorf("unexpected end of expression")
+		}
+		return nil, fmt.Errorf("unexpected %q", p.src[p.pos:])
+	case word == "sqrt":
+		if !p.accept('(') {
+			return nil, fmt.Errorf("expected ( after sqrt")
+		}
+		x, err := p.closeParen(p.expr())
+		if err != nil {
+			return nil, err
+		}
+		return sqrtNode{x}, nil
+	case word[0] >= '0' && word[0] <= '9' || word[0] == '.':
+		r, ok := new(big.Rat).SetString(word)
+		if !ok {
+			return nil, fmt.Errorf("invalid number %q", word)
+		}
+		return numNode{r}, nil
+	}
+	c := Get(word)
+	if c == nil {
+		return nil, fmt.Errorf("unknown constant %q (available: %v)", word, Names())
+	}
+	return constNode{c}, nil
+}
+
+func (p *targetParser) closeParen(n targetNode, err error) (targetNode, error) {
+	if err != nil {
+		return nil, err
+	}
+	if !p.accept(')') {
+		return nil, fmt.Errorf("expected )")
+	}
+	return n, nil
+}
+
+// word consumes a run of letters, digits, underscores and dots: a name or
+// a number.
+func (p *targetParser) word() string {
+	p.skipSpace()
+	start := p.pos
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		if c != '_' && c != '.' && !(c >= '0' && c <= '9') && !(c >= 'a' && c <= 'z') && !(c >= 'A' && c <= 'Z') {
+			break
+		}
+		p.pos++
+	}
+	return p.src[start:p.pos]
+}
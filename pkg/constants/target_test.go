@@ -0,0 +1,152 @@
+package constants
+
+import (
+	"math"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// agreeBits returns how many leading bits a and b share, relative to b.
+func agreeBits(a, b *big.Float) int {
+	d := new(big.Float).Sub(a, b)
+	if d.Sign() == 0 {
+		return int(min(a.Prec(), b.Prec()))
+	}
+	return b.MantExp(nil) - d.MantExp(nil)
+}
+
+func TestComputedConstantsMatchStoredDigits(t *testing.T) {
+	for _, name := range Names() {
+		c := Get(name)
+		if got := c.At(DefaultPrecision); got.Cmp(c.Value) != 0 {
+			t.Errorf("%s: At(%d) = %s, want stored %s", name, DefaultPrecision, got.Text('g', 40), c.Value.Text('g', 40))
+		}
+		// The stored digits are good to ~790 bits; the generator must
+		// agree with all of them.
+		const prec = 1024
+		stored, _, _ := big.ParseFloat(c.digits, 10, prec, big.ToNearestEven)
+		if bits := agreeBits(c.compute(prec), stored); bits < 780 {
+			t.Errorf("%s: generator agrees with stored digits to %d bits, want ≥ 780", name, bits)
+		}
+	}
+}
+
+func TestConstantAtHigherPrecision(t *testing.T) {
+	pi := Get("pi")
+	lo, hi := pi.At(2000), pi.At(4000)
+	if bits := agreeBits(hi, lo); bits < 1990 {
+		t.Errorf("pi at 2000 and 4000 bits agree to %d bits", bits)
+	}
+	if hi.Prec() != 4000 {
+		t.Errorf("At(4000) has precision %d", hi.Prec())
+	}
+	// The cache must hand out copies.
+	hi.SetInt64(0)
+	if pi.At(4000).Sign() == 0 {
+		t.Error("At returned the cached value itself")
+	}
+}
+
+func TestParseTarget(t *testing.T) {
+	const prec = 1500
+	pi := Get("pi").At(prec + 64)
+	zeta2 := new(big.Float).Mul(pi, pi)
+	zeta2.Quo(zeta2, big.NewFloat(6))
+	two := new(big.Float).SetPrec(prec).SetInt64(2)
+
+	cases := []struct {
+		spec string
+		want *big.Float
+	}{
+		{"pi", pi},
+		{"pi^2/6", zeta2},
+		{"  pi ^ 2 / 6 ", zeta2},
+		{"sqrt(2)^2", two},
+		{"-(-2)", two},
+		{"2*catalan - catalan/0.5 + 2", two},
+		{"4^-1 * 8", two},
+		{"e - e + 2", two},
+	}
+	for _, tc := range cases {
+		tg, err := ParseTarget(tc.spec)
+		if err != nil {
+			t.Errorf("ParseTarget(%q): %v", tc.spec, err)
+			continue
+		}
+		if tg.Precision() != 0 {
+			t.Errorf("%q: Precision() = %d, want 0", tc.spec, tg.Precision())
+		}
+		if bits := agreeBits(tg.At(prec), tc.want); bits < prec-2 {
+			t.Errorf("%q: agrees with expected value to %d bits, want ≥ %d", tc.spec, bits, prec-2)
+		}
+	}
+
+	lit, err := ParseTarget("3.14159")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lit.Precision() != 20 {
+		t.Errorf("literal Precision() = %d, want 20", lit.Precision())
+	}
+	if f, _ := lit.At(64).Float64(); f != 3.14159 {
+		t.Errorf("literal At = %v", f)
+	}
+
+	for _, bad := range []string{"", "tau", "1/0", "sqrt(-1)", "pi^e", "(pi", "pi pi", "inf"} {
+		if _, err := ParseTarget(bad); err == nil {
+			t.Errorf("ParseTarget(%q) succeeded, want error", bad)
+		}
+	}
+}
+
+func TestIntervalTarget(t *testing.T) {
+	tg, err := ParseTarget("[1.2820, pi - 1.8586]")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, ok := tg.(Bounded)
+	if !ok {
+		t.Fatalf("%T is not Bounded", tg)
+	}
+	lo, hi := b.Bounds(64)
+	if l, _ := lo.Float64(); l != 1.282 {
+		t.Errorf("lo = %v", l)
+	}
+	want := (1.282 + math.Pi - 1.8586) / 2
+	if mid, _ := tg.At(64).Float64(); math.Abs(mid-want) > 1e-15 {
+		t.Errorf("At = %v, want the midpoint %v", mid, want)
+	}
+	// |mid| / half-width ≈ 1.2825 / 0.00049 ≈ 2600, about 11.3 bits.
+	if tg.Precision() != 12 || hi.Cmp(lo) <= 0 {
+		t.Errorf("Precision() = %d, want 12", tg.Precision())
+	}
+
+	for _, bad := range []string{"[1, 1]", "[2, 1]", "[1 2]", "[1, 2", "[1, x]", "[[1, 2], 3]"} {
+		if _, err := ParseTarget(bad); err == nil {
+			t.Errorf("ParseTarget(%q) succeeded, want error", bad)
+		}
+	}
+}
+
+func TestFileTarget(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pi.txt")
+	data := "# pi to 31 digits\n3.14159 26535\n89793 23846 26433 83279\n"
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tg, err := ParseTarget("file:" + path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tg.String() != "file:"+path {
+		t.Errorf("String() = %q", tg.String())
+	}
+	if bits := agreeBits(tg.At(200), Get("pi").At(200)); bits < 95 || bits > 110 {
+		t.Errorf("31 digits of pi agree with pi to %d bits", bits)
+	}
+	if tg.Precision() != 103 {
+		t.Errorf("Precision() = %d, want 103", tg.Precision())
+	}
+}
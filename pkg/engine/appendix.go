@@ -0,0 +1,74 @@
+package engine
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/wildfunctions/genetic_series/pkg/series"
+)
+
+// appendixDiscoveries returns the verified discoveries, most digits on the
+// top rung first and the simpler of a tie first, at most k of them.
+func appendixDiscoveries(discoveries []Discovery, k int) []Discovery {
+	var out []Discovery
+	for _, d := range discoveries {
+		if d.Verified && len(d.Ladder) > 0 {
+			out = append(out, d)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		di, dj := topRung(out[i]).Digits, topRung(out[j]).Digits
+		if di != dj {
+			return di > dj
+		}
+		return out[i].Complexity < out[j].Complexity
+	})
+	if k > 0 && len(out) > k {
+		out = out[:k]
+	}
+	return out
+}
+
+// topRung is the highest-precision rung of d's ladder.
+func topRung(d Discovery) series.LadderRung {
+	return d.Ladder[len(d.Ladder)-1]
+}
+
+// WriteAppendixLatex writes the top k verified discoveries (all of them
+// for k <= 0) as a LaTeX section for \input into a paper that loads
+// amsmath: one numbered, labelled equation per discovery, with the digits
+// it was verified to, the terms that took and its complexity.
+func WriteAppendixLatex(w io.Writer, discoveries []Discovery, k int, cfg Config, prov series.Provenance) {
+	top := appendixDiscoveries(discoveries, k)
+
+	writeLatexProvenance(w, prov)
+	fmt.Fprintf(w, "\\section{Discovered series for \\texttt{%s}}\n", latexEscape(cfg.Target))
+	fmt.Fprintf(w, "\\label{sec:discoveries-%s}\n\n", fileSafe(cfg.Target))
+	if len(top) == 0 {
+		fmt.Fprintln(w, `No discoveries were verified.`)
+		return
+	}
+	fmt.Fprintf(w, "The %d series below were found by a genetic search (pool \\texttt{%s}, strategy \\texttt{%s}, seed %d) ",
+		len(top), latexEscape(cfg.Pool), latexEscape(cfg.Strategy), prov.Seed)
+	fmt.Fprintf(w, "and re-summed at %d and %d times the search's precision and terms; ", 1<<(series.LadderSteps-1), 1<<series.LadderSteps)
+	fmt.Fprintln(w, "each kept or gained digits at every step. Digits and terms are those of the last re-summation.")
+
+	for i, d := range top {
+		target := cfg.Target
+		if d.Constant != "" {
+			target = d.Constant
+		}
+		r := topRung(d)
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, `\begin{equation}`)
+		fmt.Fprintf(w, "  \\label{eq:%s-%d}\n", fileSafe(cfg.Target), i+1)
+		fmt.Fprintf(w, "  %s\n", d.LaTeX)
+		fmt.Fprintln(w, `\end{equation}`)
+		fmt.Fprintf(w, "\\noindent Agrees with \\texttt{%s} to %.1f digits", latexEscape(target), r.Digits)
+		if d.Accelerated > 0 {
+			fmt.Fprintf(w, " (%.1f accelerated)", d.Accelerated)
+		}
+		fmt.Fprintf(w, "; terms needed: %d at %d bits; complexity: %.1f.\n", r.Terms, r.Precision, d.Complexity)
+	}
+}
@@ -0,0 +1,55 @@
+package engine
+
+import (
+	"sort"
+
+	"github.com/wildfunctions/genetic_series/pkg/series"
+)
+
+// carried is the evaluation of an elite, kept for when it comes back.
+type carried struct {
+	fitness series.Fitness
+	result  series.EvalResult
+}
+
+// keepElites replaces the carry-over set with the e.elites fittest members
+// of g, keyed by canonical key, so that evaluatePopulation can reuse their
+// evaluation next generation instead of repeating it. Deferred and failed
+// members are not kept: the former were never fully evaluated.
+func (e *Engine) keepElites(g generation, fitnesses []series.Fitness, results []series.EvalResult) {
+	if e.elites == 0 {
+		return
+	}
+	idx := make([]int, 0, len(fitnesses))
+	worst := series.WorstFitness().Combined
+	for i, f := range fitnesses {
+		if !f.Deferred && f.Combined > worst && results[i].Err == nil {
+			idx = append(idx, i)
+		}
+	}
+	sort.SliceStable(idx, func(a, b int) bool { return fitnesses[idx[a]].Combined > fitnesses[idx[b]].Combined })
+	e.carry = make(map[string]carried, e.elites)
+	for _, i := range idx[:min(e.elites, len(idx))] {
+		e.carry[series.CanonicalKey(g.at(i))] = carried{fitnesses[i], results[i]}
+	}
+}
+
+// carryOver fills in the fitness and result of every member of pop kept by
+// keepElites, skipping ones on the restart tabu, and reports which it
+// filled in, or nil if none. strs are the members' canonical keys.
+func (e *Engine) carryOver(strs []string, fitnesses []series.Fitness, results []series.EvalResult, tabuSet map[string]bool) []bool {
+	var done []bool
+	for i, key := range strs {
+		c, ok := e.carry[key]
+		if !ok || tabuSet[key] {
+			continue
+		}
+		if done == nil {
+			done = make([]bool, len(strs))
+		}
+		fitnesses[i], results[i] = c.fitness, c.result
+		done[i] = true
+		e.carried++
+	}
+	return done
+}
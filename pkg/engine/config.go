@@ -2,6 +2,7 @@ package engine
 
 import (
 	"runtime"
+	"time"
 
 	"github.com/wildfunctions/genetic_series/pkg/constants"
 	"github.com/wildfunctions/genetic_series/pkg/series"
@@ -9,42 +10,86 @@ import (
 
 // Config holds all parameters for an evolutionary run.
 type Config struct {
-	Target      string
-	Pool        string
-	Strategy    string
-	Population  int
-	Generations int
-	MaxTerms    int64
-	MaxDepth    int
-	Precision   uint
-	Seed        int64
-	Format      string // "text" or "json"
-	Verbose     bool
-	Workers     int
+	Target                string
+	Pool                  string
+	Strategy              string
+	Population            int
+	Generations           int
+	MaxTerms              int64
+	MaxDepth              int
+	Precision             uint
+	Seed                  int64
+	RNG                   string // generator the seed drives (see random.Kinds)
+	RNGStream             int    // stream of the seed's generator to draw from (0 = the master stream)
+	Format                string // "text" or "json"
+	Verbose               bool
+	Workers               int
 	Weights               series.FitnessWeights
+	FitnessScript         string // expression replacing each evaluated candidate's Combined (see series.ParseFitnessScript; empty = none)
 	StagnationLimit       int
 	OutDir                string
-	F64PromotionThreshold float64 // min float64 digits to promote to big.Float (0 = disabled)
-	SeedFormula           string  // LaTeX formula for constant-tuning (empty = normal init)
+	F64PromotionThreshold float64       // min float64 digits to promote to big.Float (0 = disabled)
+	SurrogateFraction     float64       // fraction of each generation a nearest-neighbour surrogate lets through to evaluation once trained (0 = disabled)
+	SeedFormula           string        // LaTeX formula for constant-tuning (empty = normal init)
+	ReplayFile            string        // replay: file of LaTeX series to evaluate in turn, one per line
+	SkeletonRate          float64       // consttune: fraction of perturbations on exponents, factorial arguments and the start rather than coefficients (0 = any constant)
+	Guided                bool          // consttune: step constants toward the target by the sign of each parent's error
+	RepairRate            float64       // hillclimb, tournament: probability a child with a domain fault (zero divisor, negative factorial argument) is repaired rather than bred as is (0 = disabled)
+	RestartRate           float64       // hillclimb, tournament: fraction of mutations that regenerate one tree of the child and keep the other (0 = disabled)
+	RestartSide           string        // tree RestartRate regenerates: "den" (empty), "num" or "either"
+	SizeFairRate          float64       // hillclimb, tournament: fraction of the other mutations that replace a subtree with a new one of about its size (0 = disabled)
+	Ratio                 bool          // hillclimb, tournament, random: breed ratios of two series (see series.Candidate.Over), scored by their quotient
+	ArchiveSize           int           // max candidates kept in the shared archive (0 = disabled)
+	StreamBatch           int           // hold the population as genomes, decoding this many at a time (0 = disabled)
+	GenerationBudget      time.Duration // wall-clock evaluation budget per generation (0 = unlimited)
+	MemoryLimit           int           // MiB of population, archive, tabu and simplify cache past which caches are evicted and the archive shrunk (see MemoryUsage; 0 = unlimited)
+	Ops                   []string      // op whitelist applied to the pool (see expr.OpIDs; empty = all)
+	DiscoveryDigits       float64       // digits at which a candidate is re-verified at 2×/4× precision and terms (0 = disabled)
+	VerifyInterval        time.Duration // minimum time between background re-verifications (0 = no limit)
+	FailTabuSize          int           // max failed structures strategies avoid re-breeding (0 = disabled)
+	FailTabuTTL           int           // generations a failed structure stays tabu
+	InboxDir              string        // directory polled each generation for seed formulas to inject (empty = disabled)
+	SeenFile              string        // bloom filter of candidates explored by earlier runs, skipped and extended with this run's (empty = disabled)
+	SeenCapacity          int           // keys a new SeenFile is sized for
+	ConstantCache         string        // directory of constants computed past their stored digits, reused by later runs (empty = disabled)
+	Stop                  string        // stop condition, e.g. "time >= 2h or digits >= 30" (empty = Generations budget)
+	Evaluator             string        // series.Evaluator for the full-precision phase (see series.EvaluatorNames)
+	MaxExponent           int           // binary exponent past which a term fails its candidate as an overflow (0 = no bound)
+	DynamicPrecision      bool          // sum each candidate at series.CandidatePrecision rather than Precision
+	TrigProbe             bool          // fail candidates series.ProbeTrig finds degenerate (trig at rational angles) without evaluating them
+	Leaderboard           string        // JSON file of the top LeaderboardSize candidates, rewritten each generation, with a .tex snippet beside it (empty = disabled)
+	LeaderboardSize       int           // candidates kept on the leaderboard
+	EventLog              string        // JSONL file the run's events are appended to, for dashboards to tail (see Event; empty = disabled)
+	Reports               []string      // hall-of-fame documents written beside the .tex in OutDir: "typst", "markdown"
+	Appendix              int           // top verified discoveries written to OutDir as a LaTeX appendix section (see WriteAppendixLatex; 0 = none)
 }
 
 // DefaultConfig returns a config with sensible defaults.
 func DefaultConfig() Config {
 	return Config{
-		Target:      "e",
-		Pool:        "conservative",
-		Strategy:    "hillclimb",
-		Population:  200,
-		Generations: 1000,
-		MaxTerms:    1024,
-		MaxDepth:    4,
-		Precision:   constants.DefaultPrecision,
-		Seed:        0, // 0 = random
-		Format:      "text",
-		Verbose:     false,
-		Workers:     runtime.NumCPU(),
+		Target:                "e",
+		Pool:                  "conservative",
+		Strategy:              "hillclimb",
+		Population:            200,
+		Generations:           1000,
+		MaxTerms:              1024,
+		MaxDepth:              4,
+		Precision:             constants.DefaultPrecision,
+		Evaluator:             "big",
+		Seed:                  0, // 0 = random
+		RNG:                   "go",
+		Format:                "text",
+		Verbose:               false,
+		Workers:               runtime.NumCPU(),
 		Weights:               series.DefaultWeights(),
 		StagnationLimit:       200,
 		F64PromotionThreshold: 4.0,
+		ArchiveSize:           1024,
+		DiscoveryDigits:       10,
+		FailTabuSize:          65536,
+		FailTabuTTL:           50,
+		SeenCapacity:          1 << 22,
+		MaxExponent:           series.DefaultMaxExponent,
+		LeaderboardSize:       10,
 	}
 }
@@ -0,0 +1,327 @@
+package engine
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Run specs are written in a small subset of TOML: [section] headers,
+// key = value lines and # comments. Values are strings ("..." with Go/TOML
+// escapes, or '...' literal, handy for LaTeX), integers, floats, booleans
+// and single-line arrays of strings. Durations are strings such as "30s".
+// Keys not listed in configKeys are an error, so typos don't silently fall
+// back to defaults.
+
+// configKeys maps each "section.key" of a run spec to the Config field it
+// sets, in the order FormatConfig writes them.
+var configKeys = []struct {
+	name  string
+	field func(*Config) any
+}{
+	{"target", func(c *Config) any { return &c.Target }},
+	{"seed", func(c *Config) any { return &c.Seed }},
+	{"rng", func(c *Config) any { return &c.RNG }},
+	{"rng_stream", func(c *Config) any { return &c.RNGStream }},
+
+	{"strategy.name", func(c *Config) any { return &c.Strategy }},
+	{"strategy.population", func(c *Config) any { return &c.Population }},
+	{"strategy.generations", func(c *Config) any { return &c.Generations }},
+	{"strategy.stagnation", func(c *Config) any { return &c.StagnationLimit }},
+	{"strategy.stop", func(c *Config) any { return &c.Stop }},
+	{"strategy.max_depth", func(c *Config) any { return &c.MaxDepth }},
+	{"strategy.seed_formula", func(c *Config) any { return &c.SeedFormula }},
+	{"strategy.replay_file", func(c *Config) any { return &c.ReplayFile }},
+	{"strategy.skeleton_rate", func(c *Config) any { return &c.SkeletonRate }},
+	{"strategy.guided", func(c *Config) any { return &c.Guided }},
+	{"strategy.repair_rate", func(c *Config) any { return &c.RepairRate }},
+	{"strategy.restart_rate", func(c *Config) any { return &c.RestartRate }},
+	{"strategy.restart_side", func(c *Config) any { return &c.RestartSide }},
+	{"strategy.sizefair_rate", func(c *Config) any { return &c.SizeFairRate }},
+	{"strategy.ratio", func(c *Config) any { return &c.Ratio }},
+
+	{"fitness.accuracy", func(c *Config) any { return &c.Weights.Accuracy }},
+	{"fitness.complexity", func(c *Config) any { return &c.Weights.Complexity }},
+	{"fitness.convergence", func(c *Config) any { return &c.Weights.Convergence }},
+	{"fitness.cost", func(c *Config) any { return &c.Weights.Cost }},
+	{"fitness.script", func(c *Config) any { return &c.FitnessScript }},
+
+	{"pool.name", func(c *Config) any { return &c.Pool }},
+	{"pool.ops", func(c *Config) any { return &c.Ops }},
+
+	{"eval.max_terms", func(c *Config) any { return &c.MaxTerms }},
+	{"eval.precision", func(c *Config) any { return &c.Precision }},
+	{"eval.evaluator", func(c *Config) any { return &c.Evaluator }},
+	{"eval.max_exponent", func(c *Config) any { return &c.MaxExponent }},
+	{"eval.dynamic_precision", func(c *Config) any { return &c.DynamicPrecision }},
+	{"eval.trig_probe", func(c *Config) any { return &c.TrigProbe }},
+	{"eval.workers", func(c *Config) any { return &c.Workers }},
+	{"eval.f64_threshold", func(c *Config) any { return &c.F64PromotionThreshold }},
+	{"eval.surrogate", func(c *Config) any { return &c.SurrogateFraction }},
+	{"eval.generation_budget", func(c *Config) any { return &c.GenerationBudget }},
+	{"eval.stream", func(c *Config) any { return &c.StreamBatch }},
+	{"eval.memory_limit", func(c *Config) any { return &c.MemoryLimit }},
+	{"eval.discovery_digits", func(c *Config) any { return &c.DiscoveryDigits }},
+	{"eval.verify_interval", func(c *Config) any { return &c.VerifyInterval }},
+
+	{"archive.size", func(c *Config) any { return &c.ArchiveSize }},
+
+	{"tabu.size", func(c *Config) any { return &c.FailTabuSize }},
+	{"tabu.ttl", func(c *Config) any { return &c.FailTabuTTL }},
+
+	{"inbox.dir", func(c *Config) any { return &c.InboxDir }},
+
+	{"seen.file", func(c *Config) any { return &c.SeenFile }},
+	{"seen.capacity", func(c *Config) any { return &c.SeenCapacity }},
+
+	{"constants.cache", func(c *Config) any { return &c.ConstantCache }},
+
+	{"output.format", func(c *Config) any { return &c.Format }},
+	{"output.verbose", func(c *Config) any { return &c.Verbose }},
+	{"output.outdir", func(c *Config) any { return &c.OutDir }},
+	{"output.leaderboard", func(c *Config) any { return &c.Leaderboard }},
+	{"output.leaderboard_size", func(c *Config) any { return &c.LeaderboardSize }},
+	{"output.events", func(c *Config) any { return &c.EventLog }},
+	{"output.reports", func(c *Config) any { return &c.Reports }},
+	{"output.appendix", func(c *Config) any { return &c.Appendix }},
+}
+
+// LoadConfigFile reads a run spec from path. Settings it omits keep their
+// DefaultConfig values.
+func LoadConfigFile(path string) (Config, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Config{}, err
+	}
+	defer f.Close()
+	cfg, err := ParseConfig(f)
+	if err != nil {
+		return Config{}, fmt.Errorf("%s:%w", path, err)
+	}
+	return cfg, nil
+}
+
+// ParseConfig reads a run spec from r on top of DefaultConfig. Errors are
+// prefixed with the line number.
+func ParseConfig(r io.Reader) (Config, error) {
+	return ParseConfigSections(r, nil)
+}
+
+// ParseConfigSections is ParseConfig for files that embed a run spec
+// alongside sections of their own: key = value lines in a section named in
+// extra go to its handler, with the value as written, instead of to Config.
+func ParseConfigSections(r io.Reader, extra map[string]func(key, val string) error) (Config, error) {
+	cfg := DefaultConfig()
+	fields := make(map[string]any, len(configKeys))
+	for _, k := range configKeys {
+		fields[k.name] = k.field(&cfg)
+	}
+
+	sc := bufio.NewScanner(r)
+	section := ""
+	for line := 1; sc.Scan(); line++ {
+		text := strings.TrimSpace(stripComment(sc.Text()))
+		if text == "" {
+			continue
+		}
+		if strings.HasPrefix(text, "[") {
+			if !strings.HasSuffix(text, "]") {
+				return Config{}, fmt.Errorf("%d: malformed section header %q", line, text)
+			}
+			section = strings.TrimSpace(text[1 : len(text)-1])
+			continue
+		}
+		key, val, ok := strings.Cut(text, "=")
+		if !ok {
+			return Config{}, fmt.Errorf("%d: expected key = value, got %q", line, text)
+		}
+		name := strings.TrimSpace(key)
+		if handle, ok := extra[section]; ok {
+			if err := handle(name, strings.TrimSpace(val)); err != nil {
+				return Config{}, fmt.Errorf("%d: %s.%s: %w", line, section, name, err)
+			}
+			continue
+		}
+		if section != "" {
+			name = section + "." + name
+		}
+		field, ok := fields[name]
+		if !ok {
+			return Config{}, fmt.Errorf("%d: unknown setting %q", line, name)
+		}
+		if err := setConfigField(field, strings.TrimSpace(val)); err != nil {
+			return Config{}, fmt.Errorf("%d: %s: %w", line, name, err)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+// SetConfigValue sets the run spec setting name ("section.key") of cfg
+// from a value as written in a run spec, e.g. "400" or '"tournament"'.
+func SetConfigValue(cfg *Config, name, val string) error {
+	for _, k := range configKeys {
+		if k.name == name {
+			if err := setConfigField(k.field(cfg), strings.TrimSpace(val)); err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown setting %q", name)
+}
+
+// SplitArray splits a single-line run spec array into its items as written,
+// quotes included, for values that are set one at a time.
+func SplitArray(val string) ([]string, error) {
+	val = strings.TrimSpace(val)
+	if !strings.HasPrefix(val, "[") || !strings.HasSuffix(val, "]") {
+		return nil, fmt.Errorf("expected an array, got %s", val)
+	}
+	var out []string
+	for _, item := range strings.Split(val[1:len(val)-1], ",") {
+		if item = strings.TrimSpace(item); item != "" { // skip a trailing comma
+			out = append(out, item)
+		}
+	}
+	return out, nil
+}
+
+// stripComment removes a trailing # comment that is not inside a string.
+func stripComment(s string) string {
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#':
+			return s[:i]
+		}
+	}
+	return s
+}
+
+func setConfigField(field any, val string) error {
+	switch p := field.(type) {
+	case *string:
+		s, err := parseString(val)
+		*p = s
+		return err
+	case *time.Duration:
+		s, err := parseString(val)
+		if err != nil {
+			return err
+		}
+		*p, err = time.ParseDuration(s)
+		return err
+	case *[]string:
+		v, err := parseStringArray(val)
+		*p = v
+		return err
+	case *bool:
+		v, err := strconv.ParseBool(val)
+		*p = v
+		return err
+	case *int:
+		v, err := strconv.Atoi(val)
+		*p = v
+		return err
+	case *int64:
+		v, err := strconv.ParseInt(val, 10, 64)
+		*p = v
+		return err
+	case *uint:
+		v, err := strconv.ParseUint(val, 10, 0)
+		*p = uint(v)
+		return err
+	case *float64:
+		v, err := strconv.ParseFloat(val, 64)
+		*p = v
+		return err
+	}
+	panic(fmt.Sprintf("config field of unsupported type %T", field))
+}
+
+func parseString(val string) (string, error) {
+	if len(val) >= 2 && val[0] == '\'' && val[len(val)-1] == '\'' {
+		return val[1 : len(val)-1], nil
+	}
+	if len(val) >= 2 && val[0] == '"' {
+		return strconv.Unquote(val)
+	}
+	return "", fmt.Errorf("expected a quoted string, got %s", val)
+}
+
+func parseStringArray(val string) ([]string, error) {
+	items, err := SplitArray(val)
+	if err != nil {
+		return nil, err
+	}
+	var out []string
+	for _, item := range items {
+		s, err := parseString(item)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, s)
+	}
+	return out, nil
+}
+
+// FormatConfig renders cfg as a complete run spec that ParseConfig reads
+// back to the same Config. It is embedded in every final report, so any
+// result can be reproduced with -config.
+func FormatConfig(cfg Config) string {
+	var b strings.Builder
+	section := ""
+	for _, k := range configKeys {
+		sec, key, ok := strings.Cut(k.name, ".")
+		if !ok {
+			sec, key = "", k.name
+		}
+		if sec != section {
+			fmt.Fprintf(&b, "\n[%s]\n", sec)
+			section = sec
+		}
+		fmt.Fprintf(&b, "%s = %s\n", key, formatConfigValue(k.field(&cfg)))
+	}
+	return b.String()
+}
+
+func formatConfigValue(field any) string {
+	switch p := field.(type) {
+	case *string:
+		return strconv.Quote(*p)
+	case *time.Duration:
+		return strconv.Quote(p.String())
+	case *[]string:
+		q := make([]string, len(*p))
+		for i, s := range *p {
+			q[i] = strconv.Quote(s)
+		}
+		return "[" + strings.Join(q, ", ") + "]"
+	case *float64:
+		return strconv.FormatFloat(*p, 'g', -1, 64)
+	case *int:
+		return strconv.Itoa(*p)
+	case *int64:
+		return strconv.FormatInt(*p, 10)
+	case *uint:
+		return strconv.FormatUint(uint64(*p), 10)
+	case *bool:
+		return strconv.FormatBool(*p)
+	}
+	panic(fmt.Sprintf("config field of unsupported type %T", field))
+}
@@ -0,0 +1,78 @@
+package engine
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseConfig(t *testing.T) {
+	spec := `
+# Ramanujan-style search for pi
+target = "pi"
+seed = 7
+
+[strategy]
+name = "consttune"
+population = 500
+seed_formula = '\frac{1}{n!}' # literal string, no escapes
+
+[pool]
+name = "moderate"
+ops = ["factorial", "pow", "mul", "div",]
+
+[eval]
+generation_budget = "1m30s"
+f64_threshold = 3.5
+`
+	cfg, err := ParseConfig(strings.NewReader(spec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := DefaultConfig()
+	want.Target = "pi"
+	want.Seed = 7
+	want.Strategy = "consttune"
+	want.Population = 500
+	want.SeedFormula = `\frac{1}{n!}`
+	want.Pool = "moderate"
+	want.Ops = []string{"factorial", "pow", "mul", "div"}
+	want.GenerationBudget = 90 * time.Second
+	want.F64PromotionThreshold = 3.5
+	if !reflect.DeepEqual(cfg, want) {
+		t.Errorf("ParseConfig =\n%+v\nwant\n%+v", cfg, want)
+	}
+}
+
+func TestFormatConfigRoundTrip(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Seed = 99
+	cfg.SeedFormula = `\frac{(-1)^n}{2n+1} # not a comment`
+	cfg.Ops = []string{"neg", "add"}
+	cfg.GenerationBudget = 250 * time.Millisecond
+	cfg.Weights.Complexity = 0.125
+	cfg.OutDir = "runs/pi"
+
+	got, err := ParseConfig(strings.NewReader(FormatConfig(cfg)))
+	if err != nil {
+		t.Fatalf("%v\nspec:\n%s", err, FormatConfig(cfg))
+	}
+	if !reflect.DeepEqual(got, cfg) {
+		t.Errorf("round trip =\n%+v\nwant\n%+v", got, cfg)
+	}
+}
+
+func TestParseConfigErrors(t *testing.T) {
+	for _, spec := range []string{
+		"populaton = 10",
+		"[strategy]\npopulation = many",
+		"[eval]\ngeneration_budget = 30",
+		"target = pi",
+		"[pool\nname = \"moderate\"",
+	} {
+		if _, err := ParseConfig(strings.NewReader(spec)); err == nil {
+			t.Errorf("ParseConfig(%q) succeeded, want error", spec)
+		}
+	}
+}
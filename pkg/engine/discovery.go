@@ -0,0 +1,240 @@
+package engine
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/wildfunctions/genetic_series/pkg/constants"
+	"github.com/wildfunctions/genetic_series/pkg/series"
+)
+
+// Discovery is a candidate that reached Config.DiscoveryDigits, with the
+// precision ladder it was re-verified on (see series.VerifyLadder). Only
+// verified ones are discoveries; the rest were precision or cutoff artifacts.
+type Discovery struct {
+	Candidate   string              `json:"candidate"`
+	LaTeX       string              `json:"latex"`
+	Digits      float64             `json:"digits"` // at the search's precision and term count
+	Ladder      []series.LadderRung `json:"ladder"`
+	Accelerated float64             `json:"accelerated_digits,omitempty"` // of the top rung's sum extrapolated by series.AcceleratedSum
+	Verified    bool                `json:"verified"`
+	Complexity  float64             `json:"complexity"`            // of the candidate (see series.Candidate.Complexity)
+	Constant    string              `json:"constant,omitempty"`    // exploration runs: the constant it matched
+	Consistency *series.Consistency `json:"consistency,omitempty"` // of a candidate that passed the ladder
+}
+
+// maxPendingVerifications bounds the verifier's queue. Candidates offered
+// while it is full are skipped, so verification never stalls the search.
+const maxPendingVerifications = 64
+
+type verifyJob struct {
+	key       string
+	candidate *series.Candidate
+	digits    float64
+	target    constants.Target // nil for the verifier's
+}
+
+// verifier re-evaluates candidates on a precision ladder in a background
+// goroutine, at most one every interval, checks those that pass for
+// consistency with the search's evaluator, and hands each result to
+// publish as it lands. Each canonical key is verified once per run.
+type verifier struct {
+	maxTerms  int64
+	prec      uint
+	evaluator series.Evaluator
+	opts      series.EvalOptions // the search's, without the timeout
+	target    constants.Target
+	interval  time.Duration
+	publish   func(Discovery)
+	jobs      chan verifyJob
+	flush     chan struct{} // closed by finish to lift the rate limit
+	done      chan struct{}
+
+	mu      sync.Mutex
+	seen    map[string]bool
+	order   []string
+	results map[string]Discovery
+	skipped int
+}
+
+func newVerifier(ev series.Evaluator, opts series.EvalOptions, target constants.Target, interval time.Duration, publish func(Discovery)) *verifier {
+	opts.Timeout = 0
+	v := &verifier{
+		maxTerms:  opts.MaxTerms,
+		prec:      opts.Prec,
+		evaluator: ev,
+		opts:      opts,
+		target:    target,
+		interval:  interval,
+		publish:   publish,
+		jobs:      make(chan verifyJob, maxPendingVerifications),
+		flush:     make(chan struct{}),
+		done:      make(chan struct{}),
+		seen:      map[string]bool{},
+		results:   map[string]Discovery{},
+	}
+	go v.run()
+	return v
+}
+
+// submit queues c for verification against target, or the verifier's
+// target if nil, unless its key was seen before or the queue is full.
+func (v *verifier) submit(key string, c *series.Candidate, digits float64, target constants.Target) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.seen[key] {
+		return
+	}
+	select {
+	case v.jobs <- verifyJob{key: key, candidate: c.Clone(), digits: digits, target: target}:
+		v.seen[key] = true
+		v.order = append(v.order, key)
+	default:
+		v.skipped++
+	}
+}
+
+func (v *verifier) run() {
+	defer close(v.done)
+	var last time.Time
+	for j := range v.jobs {
+		if wait := time.Until(last.Add(v.interval)); wait > 0 {
+			select {
+			case <-time.After(wait):
+			case <-v.flush:
+			}
+		}
+		last = time.Now()
+
+		target := v.target
+		if j.target != nil {
+			target = j.target
+		}
+		ladder, ok := series.VerifyLadder(j.candidate, v.maxTerms, v.prec, target)
+		d := Discovery{
+			Candidate:  j.candidate.String(),
+			LaTeX:      j.candidate.LaTeX(),
+			Digits:     j.digits,
+			Ladder:     ladder,
+			Verified:   ok,
+			Complexity: j.candidate.Complexity(),
+		}
+		if j.target != nil {
+			d.Constant = j.target.String()
+		}
+		verdict, problem := "discovery", ""
+		if ok {
+			c := series.CheckConsistency(j.candidate, v.evaluator, v.opts, target.At(v.prec))
+			d.Consistency = &c
+			if !c.Consistent {
+				d.Verified = false
+				verdict, problem = "inconsistent", ": "+c.Problem
+			}
+		}
+		if d.Verified {
+			// Extrapolate from the top rung; an artifact isn't worth it.
+			top := ladder[len(ladder)-1]
+			d.Accelerated = series.AcceleratedDigits(j.candidate, top.Terms, top.Precision, target)
+		} else if !ok {
+			verdict = "rejected"
+		}
+		fmt.Fprintf(os.Stderr, "[verify] %s (%s digits%s) | %s%s%s\n", verdict, ladderDigits(ladder), acceleratedNote(d), d.Candidate, constantNote(d), problem)
+		v.mu.Lock()
+		v.results[j.key] = d
+		v.mu.Unlock()
+		if v.publish != nil {
+			v.publish(d)
+		}
+	}
+}
+
+// acceleratedNote renders d's accelerated digits for a log line.
+func acceleratedNote(d Discovery) string {
+	if d.Accelerated == 0 {
+		return ""
+	}
+	return fmt.Sprintf(", %.1f accelerated", d.Accelerated)
+}
+
+// constantNote renders the constant an exploration run's d matched.
+func constantNote(d Discovery) string {
+	if d.Constant == "" {
+		return ""
+	}
+	return " = " + d.Constant
+}
+
+// finish waits for queued verifications, no longer rate limited, and
+// returns the results in the order candidates were submitted.
+func (v *verifier) finish() []Discovery {
+	close(v.flush)
+	close(v.jobs)
+	<-v.done
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.skipped > 0 {
+		fmt.Fprintf(os.Stderr, "[verify] queue full, %d candidates not verified\n", v.skipped)
+	}
+	out := make([]Discovery, 0, len(v.order))
+	for _, k := range v.order {
+		out = append(out, v.results[k])
+	}
+	return out
+}
+
+// verified reports whether the candidate with canonical key passed.
+func (v *verifier) verified(key string) bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.results[key].Verified
+}
+
+// ladderDigits renders a ladder's digits as "12.3 → 24.0 → 48.1".
+func ladderDigits(ladder []series.LadderRung) string {
+	parts := make([]string, len(ladder))
+	for i, r := range ladder {
+		parts[i] = fmt.Sprintf("%.1f", r.Digits)
+	}
+	return strings.Join(parts, " → ")
+}
+
+// WriteDiscoveries writes the verified discoveries of a run.
+func WriteDiscoveries(w io.Writer, discoveries []Discovery) {
+	if len(discoveries) == 0 {
+		return
+	}
+	n := 0
+	for _, d := range discoveries {
+		if d.Verified {
+			n++
+		}
+	}
+	fmt.Fprintf(w, "\n--- Discoveries (%d of %d verified) ---\n", n, len(discoveries))
+	// An exploration run's discoveries are bucketed by constant, in order
+	// of each constant's first discovery; the rest have none.
+	var order []string
+	byConstant := map[string][]Discovery{}
+	for _, d := range discoveries {
+		if !d.Verified {
+			continue
+		}
+		if _, ok := byConstant[d.Constant]; !ok {
+			order = append(order, d.Constant)
+		}
+		byConstant[d.Constant] = append(byConstant[d.Constant], d)
+	}
+	for _, c := range order {
+		indent := "  "
+		if c != "" {
+			fmt.Fprintf(w, "  %s (%d):\n", c, len(byConstant[c]))
+			indent = "    "
+		}
+		for _, d := range byConstant[c] {
+			fmt.Fprintf(w, "%s%s digits%s | %s\n", indent, ladderDigits(d.Ladder), acceleratedNote(d), d.Candidate)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/wildfunctions/genetic_series/pkg/constants"
+	"github.com/wildfunctions/genetic_series/pkg/series"
+)
+
+func TestVerifierRateLimitAndPublish(t *testing.T) {
+	published := make(chan Discovery, 2)
+	v := newVerifier(series.BigFloatEvaluator{}, series.EvalOptions{MaxTerms: 64, Prec: 256}, constants.Get("ln2"), time.Hour, func(d Discovery) { published <- d })
+	for _, f := range []string{
+		`\sum_{n=0}^{\infty} \frac{(-1)^{n}}{n + 1}`,
+		`\sum_{n=1}^{\infty} \frac{1}{n \cdot 2^{n}}`,
+	} {
+		c, err := series.ParseCandidateLatex(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		v.submit(series.CanonicalKey(c), c, 10, nil)
+	}
+
+	// The first job runs at once and is published before the run ends;
+	// the second waits out the interval.
+	select {
+	case d := <-published:
+		if !d.Verified || d.Accelerated <= d.Ladder[len(d.Ladder)-1].Digits {
+			t.Errorf("alternating ln 2: %+v, want verified and improved by acceleration", d)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("first verification not published")
+	}
+	select {
+	case d := <-published:
+		t.Fatalf("%s verified within the rate limit", d.Candidate)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// finish lifts the limit rather than waiting an hour.
+	ds := v.finish()
+	if len(ds) != 2 || !ds[1].Verified {
+		t.Errorf("discoveries = %+v", ds)
+	}
+	if len(published) != 1 {
+		t.Errorf("%d late publications, want 1", len(published))
+	}
+}
+
+func TestWriteAppendixLatex(t *testing.T) {
+	rungs := func(digits float64) []series.LadderRung {
+		return []series.LadderRung{{Precision: 256, Terms: 64, Digits: digits}, {Precision: 1024, Terms: 256, Digits: digits + 1}}
+	}
+	ds := []Discovery{
+		{LaTeX: `\sum_{n=0}^{\infty} \frac{1}{n!}`, Ladder: rungs(20), Verified: true, Complexity: 6},
+		{LaTeX: `\sum_{n=0}^{\infty} \frac{2}{n!}`, Ladder: rungs(40), Verified: false, Complexity: 6},
+		{LaTeX: `\sum_{n=1}^{\infty} \frac{n}{n!}`, Ladder: rungs(30), Verified: true, Complexity: 7, Accelerated: 35},
+		{LaTeX: `\sum_{n=0}^{\infty} \frac{n + 1}{(n + 1)!}`, Ladder: rungs(30), Verified: true, Complexity: 9},
+	}
+	cfg := DefaultConfig()
+	cfg.Target = "e"
+
+	var b strings.Builder
+	WriteAppendixLatex(&b, ds, 2, cfg, series.Provenance{RunID: "r1", Seed: 7})
+	out := b.String()
+	for _, want := range []string{
+		`\section{Discovered series for \texttt{e}}`,
+		`\label{eq:e-1}` + "\n  " + `\sum_{n=1}^{\infty} \frac{n}{n!}`,
+		`\label{eq:e-2}` + "\n  " + `\sum_{n=0}^{\infty} \frac{n + 1}{(n + 1)!}`,
+		"to 31.0 digits (35.0 accelerated); terms needed: 256 at 1024 bits; complexity: 7.0.",
+		"seed 7",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("appendix lacks %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, `\frac{2}{n!}`) || strings.Contains(out, `\frac{1}{n!}`) || strings.Contains(out, "eq:e-3") {
+		t.Errorf("appendix has an unverified or past-k discovery:\n%s", out)
+	}
+
+	b.Reset()
+	WriteAppendixLatex(&b, ds[1:2], 5, cfg, series.Provenance{})
+	if !strings.Contains(b.String(), "No discoveries were verified.") {
+		t.Errorf("appendix without verified discoveries:\n%s", b.String())
+	}
+
+	cfg.Appendix = -1
+	if _, err := New(cfg); err == nil {
+		t.Error("New accepted a negative appendix size")
+	}
+}
@@ -1,3 +1,8 @@
+// Package engine runs the evolutionary search: New builds an Engine from a
+// Config (DefaultConfig, or a run spec file read by LoadConfigFile), and
+// Run searches until the stop condition and returns a FinalReport. Hooks
+// set before Run (OnEvent, OnDiscovery, AdjustFitness) let a program that
+// embeds the engine watch and steer it; progress is written to stderr.
 package engine
 
 import (
@@ -7,13 +12,20 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"runtime/debug"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/wildfunctions/genetic_series/pkg/archive"
 	"github.com/wildfunctions/genetic_series/pkg/constants"
+	"github.com/wildfunctions/genetic_series/pkg/expr"
 	"github.com/wildfunctions/genetic_series/pkg/pool"
+	"github.com/wildfunctions/genetic_series/pkg/random"
 	"github.com/wildfunctions/genetic_series/pkg/series"
 	"github.com/wildfunctions/genetic_series/pkg/strategy"
+	"github.com/wildfunctions/genetic_series/pkg/tabu"
 )
 
 // Engine runs the evolutionary search.
@@ -21,9 +33,39 @@ type Engine struct {
 	cfg       Config
 	pool      pool.Pool
 	strategy  strategy.Strategy
-	target    *big.Float
+	evaluator series.Evaluator
+	evalOpts  series.EvalOptions
+	tgt       constants.Target // generated per precision for verification; nil for a sequence
+	target    *big.Float       // tgt at Config.Precision
 	targetF64 float64
-	rng       *rand.Rand
+	seq       *series.Sequence // sequence target ("seq:..."), scored by series.SequenceFitness
+	explore   *exploration     // exploration target ("any"), scored against its nearest constant
+	bounds    *targetBounds    // interval of a constants.Bounded target; nil for a point
+	rng       *random.Stream
+	archive   *archive.Archive // nil when disabled
+	failed    *tabu.List       // structures that failed evaluation; nil when disabled
+	inbox     *inbox           // nil when InboxDir is empty
+	seen      *seenFilter      // nil when SeenFile is empty
+	stop      stopCond         // when Run ends; see stopConditionOf
+	verifier  *verifier        // nil when DiscoveryDigits is 0; set per Run
+	board     *leaderboard     // nil when Leaderboard is empty; set per Run
+
+	elites  int                // elites carried per generation (see strategy.Elitist)
+	carry   map[string]carried // the last generation's elites, by canonical key
+	carried int                // evaluations skipped by carrying elites over, this attempt
+
+	degenerate int // candidates failed by the trig probe, this attempt
+
+	origins   []strategy.Origin         // of the generation being evaluated; nil if the strategy does not report them
+	operators map[string]*OperatorStats // by operator, this run
+
+	surrogate *surrogate // nil when SurrogateFraction is 0
+
+	onDiscovery func(Discovery)    // see OnDiscovery
+	eventHooks  []func(Event)      // see OnEvent
+	events      *events            // set per Run
+	adjust      series.FitnessHook // FitnessScript and AdjustFitness, or nil
+	prov        series.Provenance
 }
 
 // New creates a new engine from the given config.
@@ -32,6 +74,12 @@ func New(cfg Config) (*Engine, error) {
 	if err != nil {
 		return nil, err
 	}
+	p = pool.WithCustomOps(p)
+	if len(cfg.Ops) > 0 {
+		if p, err = pool.Restrict(p, cfg.Ops); err != nil {
+			return nil, err
+		}
+	}
 	s, err := strategy.Get(cfg.Strategy)
 	if err != nil {
 		return nil, err
@@ -39,10 +87,7 @@ func New(cfg Config) (*Engine, error) {
 
 	// If a seed formula was provided, pass it to the strategy.
 	if cfg.SeedFormula != "" {
-		type seedable interface {
-			SetSeedFormula(string) error
-		}
-		if ss, ok := s.(seedable); ok {
+		if ss, ok := s.(strategy.Seedable); ok {
 			if err := ss.SetSeedFormula(cfg.SeedFormula); err != nil {
 				return nil, fmt.Errorf("invalid seed formula: %w", err)
 			}
@@ -51,34 +96,289 @@ func New(cfg Config) (*Engine, error) {
 		}
 	}
 
-	c := constants.Get(cfg.Target)
-	if c == nil {
-		return nil, fmt.Errorf("unknown target constant: %s (available: %v)", cfg.Target, constants.Names())
+	if cfg.ReplayFile != "" {
+		rs, ok := s.(strategy.Replayable)
+		if !ok {
+			return nil, fmt.Errorf("strategy %q does not support -replay", cfg.Strategy)
+		}
+		if err := rs.SetReplayFile(cfg.ReplayFile); err != nil {
+			return nil, fmt.Errorf("invalid replay file: %w", err)
+		}
+	} else if _, ok := s.(*strategy.ReplayStrategy); ok {
+		return nil, fmt.Errorf("strategy %q needs -replay", cfg.Strategy)
+	}
+
+	if cfg.SkeletonRate != 0 {
+		if cfg.SkeletonRate < 0 || cfg.SkeletonRate > 1 {
+			return nil, fmt.Errorf("skeleton rate must be in [0, 1], got %v", cfg.SkeletonRate)
+		}
+		sr, ok := s.(strategy.SkeletonRated)
+		if !ok {
+			return nil, fmt.Errorf("strategy %q does not support -skeleton-rate", cfg.Strategy)
+		}
+		sr.SetSkeletonRate(cfg.SkeletonRate)
+	}
+
+	if cfg.Appendix < 0 {
+		return nil, fmt.Errorf("appendix size must be non-negative, got %d", cfg.Appendix)
+	}
+	for _, r := range cfg.Reports {
+		if _, ok := hallOfFameReports[r]; !ok {
+			return nil, fmt.Errorf("unknown report format %q (want typst or markdown)", r)
+		}
+	}
+
+	if cfg.RepairRate != 0 {
+		if cfg.RepairRate < 0 || cfg.RepairRate > 1 {
+			return nil, fmt.Errorf("repair rate must be in [0, 1], got %v", cfg.RepairRate)
+		}
+		rr, ok := s.(strategy.Repairing)
+		if !ok {
+			return nil, fmt.Errorf("strategy %q does not support -repair-rate", cfg.Strategy)
+		}
+		rr.SetRepairRate(cfg.RepairRate)
+	}
+
+	if cfg.RestartRate != 0 {
+		if cfg.RestartRate < 0 || cfg.RestartRate > 1 {
+			return nil, fmt.Errorf("restart rate must be in [0, 1], got %v", cfg.RestartRate)
+		}
+		side := strategy.RestartDenominator
+		if cfg.RestartSide != "" {
+			if side, err = strategy.ParseRestartSide(cfg.RestartSide); err != nil {
+				return nil, err
+			}
+		}
+		rs, ok := s.(strategy.Restartable)
+		if !ok {
+			return nil, fmt.Errorf("strategy %q does not support -restart-rate", cfg.Strategy)
+		}
+		rs.SetRestart(cfg.RestartRate, side)
+	}
+
+	if cfg.SizeFairRate != 0 {
+		if cfg.SizeFairRate < 0 || cfg.SizeFairRate > 1 {
+			return nil, fmt.Errorf("size-fair rate must be in [0, 1], got %v", cfg.SizeFairRate)
+		}
+		sf, ok := s.(strategy.SizeFairRated)
+		if !ok {
+			return nil, fmt.Errorf("strategy %q does not support -sizefair-rate", cfg.Strategy)
+		}
+		sf.SetSizeFairRate(cfg.SizeFairRate)
+	}
+
+	if cfg.Guided {
+		g, ok := s.(strategy.Guidable)
+		if !ok {
+			return nil, fmt.Errorf("strategy %q does not support -guided", cfg.Strategy)
+		}
+		g.SetGuided(true)
+	}
+
+	var adjust series.FitnessHook
+	if cfg.FitnessScript != "" {
+		if adjust, err = series.ParseFitnessScript(cfg.FitnessScript); err != nil {
+			return nil, err
+		}
+	}
+
+	ev, err := series.GetEvaluator(cfg.Evaluator)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		tgt       constants.Target
+		target    *big.Float
+		targetF64 float64
+		seq       *series.Sequence
+		explore   *exploration
+		bounds    *targetBounds
+		maxDigits = series.MaxDigits
+	)
+	if err := constants.SetCacheDir(cfg.ConstantCache); err != nil {
+		return nil, fmt.Errorf("constant cache: %w", err)
+	}
+	if explore, err = parseExploration(cfg.Target, cfg.Precision); err != nil {
+		return nil, err
+	}
+	if spec, ok := strings.CutPrefix(cfg.Target, "seq:"); ok {
+		if seq, err = series.LoadSequence(spec); err != nil {
+			return nil, fmt.Errorf("invalid target: %w", err)
+		}
+		// Sums play no part, so neither does the float64 prescreen of
+		// them or re-verification at higher precision.
+		cfg.F64PromotionThreshold = 0
+		cfg.DiscoveryDigits = 0
+		maxDigits = seq.Len(cfg.MaxTerms)
+	} else if explore == nil {
+		if tgt, err = constants.ParseTarget(cfg.Target); err != nil {
+			return nil, fmt.Errorf("invalid target: %w", err)
+		}
+		target = tgt.At(cfg.Precision)
+		targetF64, _ = target.Float64()
+		if b, ok := tgt.(constants.Bounded); ok {
+			bounds = newTargetBounds(b, cfg.Precision)
+			// Digits are capped by the interval's width, and the ladder
+			// would compare against its midpoint, so nothing to verify.
+			cfg.DiscoveryDigits = 0
+		}
+	}
+
+	if cfg.StreamBatch > 0 {
+		if _, ok := s.(strategy.GenomeStrategy); !ok {
+			return nil, fmt.Errorf("strategy %q does not support streaming mode", cfg.Strategy)
+		}
+	}
+
+	if cfg.Ratio {
+		// Genomes hold one series, and a sequence is matched term by term.
+		switch {
+		case cfg.StreamBatch > 0:
+			return nil, fmt.Errorf("-ratio does not support streaming mode")
+		case seq != nil:
+			return nil, fmt.Errorf("-ratio does not support sequence targets")
+		}
+		pr, ok := s.(strategy.Pairing)
+		if !ok {
+			return nil, fmt.Errorf("strategy %q does not support -ratio", cfg.Strategy)
+		}
+		pr.SetRatio(true)
+	}
+
+	// Record the seed actually used, so reports and run specs can replay it.
+	if cfg.Seed == 0 {
+		cfg.Seed = rand.Int63()
+	}
+	if cfg.RNGStream < 0 {
+		return nil, fmt.Errorf("rng stream must be non-negative, got %d", cfg.RNGStream)
+	}
+	rng, err := random.New(cfg.RNG, cfg.Seed)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.RNGStream > 0 {
+		rng = rng.Split(uint64(cfg.RNGStream))
 	}
 
-	seed := cfg.Seed
-	if seed == 0 {
-		seed = rand.Int63()
+	var arch *archive.Archive
+	if cfg.ArchiveSize > 0 {
+		arch = archive.New(cfg.ArchiveSize)
 	}
 
+	var failed *tabu.List
+	if cfg.FailTabuSize > 0 && cfg.FailTabuTTL > 0 {
+		failed = tabu.New(cfg.FailTabuSize, cfg.FailTabuTTL)
+		if ta, ok := s.(strategy.TabuAware); ok {
+			ta.SetTabu(failed)
+		}
+	}
+
+	stop, err := stopConditionOf(cfg, maxDigits)
+	if err != nil {
+		return nil, err
+	}
+
+	var ib *inbox
+	if cfg.InboxDir != "" {
+		if ib, err = newInbox(cfg.InboxDir); err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg.MemoryLimit < 0 {
+		return nil, fmt.Errorf("memory limit must be non-negative, got %d MiB", cfg.MemoryLimit)
+	}
+
+	var sur *surrogate
+	if cfg.SurrogateFraction != 0 {
+		if cfg.SurrogateFraction < 0 || cfg.SurrogateFraction > 1 {
+			return nil, fmt.Errorf("surrogate fraction must be in (0, 1], got %v", cfg.SurrogateFraction)
+		}
+		sur = newSurrogate(cfg.SurrogateFraction)
+	}
+
+	elites := 0
+	if el, ok := s.(strategy.Elitist); ok {
+		elites = el.Elites(cfg.Population)
+	}
+
+	var seen *seenFilter
+	if cfg.SeenFile != "" {
+		if seen, err = loadSeen(cfg.SeenFile, cfg.SeenCapacity); err != nil {
+			return nil, err
+		}
+	}
+
+	evalOpts := series.SearchOptions(cfg.MaxTerms, cfg.Precision)
+	evalOpts.MaxExponent = cfg.MaxExponent
+
 	return &Engine{
 		cfg:       cfg,
 		pool:      p,
 		strategy:  s,
-		target:    c.Value,
-		targetF64: c.Float64Value,
-		rng:       rand.New(rand.NewSource(seed)),
+		evaluator: ev,
+		evalOpts:  evalOpts,
+		tgt:       tgt,
+		target:    target,
+		targetF64: targetF64,
+		seq:       seq,
+		explore:   explore,
+		bounds:    bounds,
+		rng:       rng,
+		archive:   arch,
+		failed:    failed,
+		inbox:     ib,
+		seen:      seen,
+		stop:      stop,
+		elites:    elites,
+		surrogate: sur,
+		adjust:    adjust,
 	}, nil
 }
 
+// OnDiscovery sets f to be called with each background verification
+// result, verified or not, as it lands. f runs on the verifier's goroutine,
+// concurrently with the search, and should return quickly. Set it before Run.
+func (e *Engine) OnDiscovery(f func(Discovery)) {
+	e.onDiscovery = f
+}
+
+// AdjustFitness sets h to rescore every evaluated candidate, after the
+// standard fitness and Config.FitnessScript. Failed and deferred
+// candidates are not passed to it. h runs on the evaluation workers,
+// concurrently and in no fixed order, so it must be safe for that, and a
+// seeded run reproduces with any worker count only if h depends on its
+// arguments alone. Set it before Run.
+func (e *Engine) AdjustFitness(h series.FitnessHook) {
+	if prev := e.adjust; prev != nil {
+		e.adjust = func(c *series.Candidate, f series.Fitness) series.Fitness { return h(c, prev(c, f)) }
+		return
+	}
+	e.adjust = h
+}
+
+// Provenance returns the provenance of the current or most recent Run.
+func (e *Engine) Provenance() series.Provenance {
+	return e.prov
+}
+
+// Archive returns the run's shared candidate archive, or nil if disabled.
+// Every candidate that passes big.Float evaluation is offered to it.
+func (e *Engine) Archive() *archive.Archive {
+	return e.archive
+}
+
 // Run executes the evolutionary loop and returns the final report.
 func (e *Engine) Run() FinalReport {
-	runTimestamp := fmt.Sprintf("%d", time.Now().Unix())
+	e.prov = newProvenance(e.cfg)
+	runTimestamp := fmt.Sprintf("%d", e.prov.Timestamp.Unix())
 	var hallOfFame []AttemptResult
 	var genReports []GenerationReport
 	totalGensUsed := 0
 	attempt := 0
 	tabuSet := map[string]bool{}
+	e.operators = nil
 
 	// Track best across all attempts
 	var globalBest *series.Candidate
@@ -86,30 +386,128 @@ func (e *Engine) Run() FinalReport {
 	var globalBestResult series.EvalResult
 	globalBestFitness.Combined = -1e18
 
-	genBudget := "unlimited"
-	if e.cfg.Generations > 0 {
-		genBudget = fmt.Sprintf("%d", e.cfg.Generations)
+	fmt.Fprintf(os.Stderr, "Run %s (config %s, version %s)\n", e.prov.RunID, e.prov.ConfigHash, e.prov.Version)
+	fmt.Fprintf(os.Stderr, "Timestamp: [%s] Starting target %s, pool %s, strategy %s, population %d, stop when %s, stagnation %d, workers %d, seed %d\n",
+		runTimestamp, e.cfg.Target, e.cfg.Pool, e.cfg.Strategy, e.cfg.Population, e.stop, e.cfg.StagnationLimit, e.cfg.Workers, e.cfg.Seed)
+
+	if e.seq != nil {
+		fmt.Fprintf(os.Stderr, "Sequence %s: %d terms from n = %d; digits count matched terms\n", e.seq.Name, e.seq.Len(e.cfg.MaxTerms), e.seq.Offset)
+	} else if e.explore != nil {
+		fmt.Fprintf(os.Stderr, "Exploring %d constants: %s\n", len(e.explore.targets), e.explore)
+	} else if bits := e.tgt.Precision(); bits != 0 && bits < e.cfg.Precision {
+		fmt.Fprintf(os.Stderr, "Warning: target %s is known to %d bits, less than the %d-bit precision\n", e.tgt, bits, e.cfg.Precision)
 	}
-	fmt.Fprintf(os.Stderr, "Timestamp: [%s] Starting target %s, pool %s, strategy %s, population %d, %s gen budget, stagnation %d, workers %d, seed %d\n",
-		runTimestamp, e.cfg.Target, e.cfg.Pool, e.cfg.Strategy, e.cfg.Population, genBudget, e.cfg.StagnationLimit, e.cfg.Workers, e.cfg.Seed)
 
-	unlimited := e.cfg.Generations <= 0
-	for unlimited || totalGensUsed < e.cfg.Generations {
+	e.events = newEvents(os.Stderr, e.cfg.Verbose, e.cfg.EventLog, e.prov.RunID, e.eventHooks)
+	defer e.events.close()
+	prov := e.prov
+	e.events.emit(Event{Type: EventRunStart, Provenance: &prov, RunSpec: FormatConfig(e.cfg)})
+
+	e.verifier = nil
+	if e.cfg.DiscoveryDigits > 0 {
+		e.verifier = newVerifier(e.evaluator, e.evalOpts, e.tgt, e.cfg.VerifyInterval, func(d Discovery) {
+			e.events.emit(Event{Type: EventDiscovery, Discovery: &d})
+			if e.onDiscovery != nil {
+				e.onDiscovery(d)
+			}
+		})
+	}
+
+	if e.cfg.MemoryLimit > 0 {
+		// Have the GC work harder as the whole heap nears the limit too,
+		// restoring the previous limit after the run.
+		defer debug.SetMemoryLimit(debug.SetMemoryLimit(int64(e.cfg.MemoryLimit) << 20))
+	}
+
+	e.board = nil
+	if e.cfg.Leaderboard != "" {
+		e.board = newLeaderboard(e.cfg.Leaderboard, e.cfg.LeaderboardSize, e.prov, e.cfg.Target)
+	}
+
+	// Run-wide best, for the digits and stagnation stop criteria.
+	start := time.Now()
+	runBestDigits, runBestCombined := 0.0, -1e18
+	gensSinceRunImprovement := 0
+	var milestones []Milestone
+	stopReason := ""
+
+	for stopReason == "" {
 		attempt++
 		fmt.Fprintf(os.Stderr, "\n=== Attempt %d ===\n", attempt)
 
-		population := e.strategy.Initialize(e.pool, e.rng, e.cfg.Population)
+		population := e.initialGeneration()
+		e.carry, e.carried, e.degenerate = nil, 0, 0
+		e.origins = nil
+		if e.surrogate != nil {
+			e.surrogate.skipped = 0
+		}
 
 		var bestThisAttempt *series.Candidate
 		var bestThisAttemptFitness series.Fitness
 		var bestThisAttemptResult series.EvalResult
+		var bestThisAttemptReport *GenerationReport
 		bestThisAttemptFitness.Combined = -1e18
 		gensSinceImprovement := 0
 		bestFoundAtGen := 0
 		attemptGens := 0
 
-		for unlimited || totalGensUsed < e.cfg.Generations {
-			fitnesses, results := e.evaluatePopulation(population, tabuSet)
+		for stopReason == "" {
+			screened := 0
+			if e.surrogate != nil {
+				screened = -e.surrogate.skipped
+			}
+			fitnesses, results := e.evaluateGeneration(population, tabuSet)
+			if e.surrogate != nil {
+				screened += e.surrogate.skipped
+			}
+			e.countOperators(fitnesses)
+			deferred, failed := -screened, 0
+			var firstErr error
+			for i, f := range fitnesses {
+				if f.Deferred {
+					deferred++
+				}
+				if err := results[i].Err; err != nil {
+					if failed == 0 {
+						firstErr = err
+					}
+					failed++
+				}
+			}
+			if failed > 0 {
+				fmt.Fprintf(os.Stderr, "[gen %d] Recovered %d evaluation panics (first: %v)\n",
+					attemptGens, failed, firstErr)
+			}
+			if deferred > 0 {
+				fmt.Fprintf(os.Stderr, "[gen %d] Time budget: %d/%d candidates deferred\n",
+					attemptGens, deferred, len(fitnesses))
+			}
+
+			injected := 0
+			if e.inbox != nil {
+				if seeds := e.inbox.poll(); len(seeds) > 0 {
+					injected = e.inject(population, fitnesses, results, seeds)
+					fmt.Fprintf(os.Stderr, "[gen %d] Injected %d seeds from inbox\n", attemptGens, injected)
+				}
+			}
+			e.keepElites(population, fitnesses, results)
+
+			if e.verifier != nil {
+				for i, f := range fitnesses {
+					if f.CorrectDigits < e.cfg.DiscoveryDigits || !results[i].OK || f.Deferred {
+						continue
+					}
+					tgt := e.tgt
+					if e.explore != nil {
+						if f.Match == "" {
+							continue
+						}
+						tgt = e.explore.target(f.Match)
+					}
+					c := population.at(i)
+					e.verifier.submit(series.CanonicalKey(c), c, f.CorrectDigits, tgt)
+				}
+			}
 
 			// Find best and second-best in this generation
 			bestIdx, secondIdx := 0, -1
@@ -127,58 +525,96 @@ func (e *Engine) Run() FinalReport {
 			}
 			avgFit /= float64(len(fitnesses))
 
-			improved := fitnesses[bestIdx].Combined > bestThisAttemptFitness.Combined
-			if improved {
-				bestThisAttempt = population[bestIdx].Clone()
-				bestThisAttemptFitness = fitnesses[bestIdx]
-				bestThisAttemptResult = results[bestIdx]
-				bestFoundAtGen = attemptGens
-				gensSinceImprovement = 0
-			} else {
-				gensSinceImprovement++
-			}
-
+			best := population.at(bestIdx)
 			report := GenerationReport{
 				Generation:    attemptGens,
 				BestFitness:   fitnesses[bestIdx],
-				BestCandidate: population[bestIdx].String(),
-				BestLaTeX:     population[bestIdx].LaTeX(),
+				BestCandidate: best.String(),
+				BestLaTeX:     best.LaTeX(),
 				AvgFitness:    avgFit,
+				Deferred:      deferred,
 			}
 			if results[bestIdx].OK && results[bestIdx].PartialSum != nil {
 				report.BestPartialSum = results[bestIdx].PartialSum.Text('g', 20)
 			}
-
-			if e.cfg.Verbose {
-				WriteTextReport(os.Stderr, report)
-			} else if improved {
-				fmt.Fprintf(os.Stderr, "[gen %d] NEW BEST %.1f digits | fitness %.4f\n",
-					attemptGens, bestThisAttemptFitness.CorrectDigits, bestThisAttemptFitness.Combined)
-				fmt.Fprintf(os.Stderr, "  #1: %s\n", bestThisAttempt.String())
-				if secondIdx >= 0 && results[secondIdx].OK {
-					fmt.Fprintf(os.Stderr, "  #2: %.1f digits | %s\n",
-						fitnesses[secondIdx].CorrectDigits, population[secondIdx].String())
-				}
-			} else if attemptGens%20 == 0 {
-				fmt.Fprintf(os.Stderr, "[gen %d]\n", attemptGens)
-				if bestThisAttempt != nil {
-					fmt.Fprintf(os.Stderr, "  #1: %.1f digits | %s\n",
-						bestThisAttemptFitness.CorrectDigits, bestThisAttempt.String())
+			var runnerUp *GenerationReport
+			if secondIdx >= 0 && results[secondIdx].OK {
+				runnerUp = &GenerationReport{
+					Generation:    attemptGens,
+					BestFitness:   fitnesses[secondIdx],
+					BestCandidate: population.at(secondIdx).String(),
 				}
-				if secondIdx >= 0 && results[secondIdx].OK {
-					fmt.Fprintf(os.Stderr, "  #2: %.1f digits | %s\n",
-						fitnesses[secondIdx].CorrectDigits, population[secondIdx].String())
+			}
+
+			if fitnesses[bestIdx].Combined > bestThisAttemptFitness.Combined {
+				bestThisAttempt = best.Clone()
+				bestThisAttemptFitness = fitnesses[bestIdx]
+				bestThisAttemptResult = results[bestIdx]
+				bestThisAttemptReport = &GenerationReport{}
+				*bestThisAttemptReport = report
+				bestFoundAtGen = attemptGens
+				gensSinceImprovement = 0
+				e.events.emit(Event{Type: EventNewBest, Attempt: attempt, Generation: attemptGens, TotalGenerations: totalGensUsed,
+					Report: bestThisAttemptReport, RunnerUp: runnerUp})
+			} else {
+				gensSinceImprovement++
+			}
+
+			var mem MemoryUsage
+			if e.cfg.MemoryLimit > 0 {
+				var freed string
+				if mem, freed = e.reclaimMemory(population); freed != "" {
+					fmt.Fprintf(os.Stderr, "[gen %d] Memory: %s\n", attemptGens, freed)
 				}
 			}
+			stats := GenerationStats{
+				Population: len(fitnesses),
+				Deferred:   deferred,
+				Screened:   screened,
+				Failed:     failed,
+				Injected:   injected,
+				Carried:    e.carried,
+				Degenerate: e.degenerate,
+				Memory:     mem.Total(),
+			}
+			if e.archive != nil {
+				stats.Archive = e.archive.Len()
+			}
+			if e.failed != nil {
+				stats.FailureTabu = e.failed.Len()
+			}
+			e.events.emit(Event{Type: EventGeneration, Attempt: attempt, Generation: attemptGens, TotalGenerations: totalGensUsed,
+				Report: &report, AttemptBest: bestThisAttemptReport, RunnerUp: runnerUp, Stats: &stats})
 			genReports = append(genReports, report)
+			e.board.update(population, fitnesses, results, attempt, attemptGens)
 
 			totalGensUsed++
 			attemptGens++
+			e.board.write(attempt, totalGensUsed)
 
-			// Hit the digit cap — nothing left to find, move on.
-			if bestThisAttemptFitness.CorrectDigits >= float64(series.MaxDigits) {
-				fmt.Fprintf(os.Stderr, "[gen %d] Hit %d digit cap, done\n",
-					attemptGens, series.MaxDigits)
+			if bestThisAttemptFitness.Combined > runBestCombined {
+				runBestCombined = bestThisAttemptFitness.Combined
+				gensSinceRunImprovement = 0
+			} else {
+				gensSinceRunImprovement++
+			}
+			for d := int(runBestDigits) + 1; d <= int(bestThisAttemptFitness.CorrectDigits); d++ {
+				milestones = append(milestones, Milestone{Digits: d, Generation: totalGensUsed, Elapsed: time.Since(start)})
+			}
+			runBestDigits = max(runBestDigits, bestThisAttemptFitness.CorrectDigits)
+			state := runState{
+				generations: totalGensUsed,
+				attempts:    attempt,
+				elapsed:     time.Since(start),
+				digits:      runBestDigits,
+				stagnation:  gensSinceRunImprovement,
+			}
+			if e.archive != nil {
+				state.archive = e.archive.Len()
+			}
+			if reason, ok := e.stop.met(state); ok {
+				stopReason = reason
+				fmt.Fprintf(os.Stderr, "[gen %d] Stopping: %s\n", attemptGens, reason)
 				break
 			}
 
@@ -202,7 +638,8 @@ func (e *Engine) Run() FinalReport {
 			}
 
 			// Evolve
-			population = e.strategy.Evolve(population, fitnesses, e.pool, e.rng)
+			e.failed.Advance()
+			population = e.nextGeneration(population, fitnesses)
 		}
 
 		// Save attempt result
@@ -211,23 +648,27 @@ func (e *Engine) Run() FinalReport {
 			Generations:    attemptGens,
 			BestFoundAtGen: bestFoundAtGen,
 			Timestamp:      time.Now().UTC(),
+			Provenance:     e.prov,
 		}
 		if bestThisAttempt != nil {
 			ar.BestCandidate = bestThisAttempt.String()
+			ar.Canonical = series.CanonicalKey(bestThisAttempt)
 			ar.BestLaTeX = bestThisAttempt.LaTeX()
 			ar.BestFitness = bestThisAttemptFitness
 			if bestThisAttemptResult.OK && bestThisAttemptResult.PartialSum != nil {
 				ar.BestPartialSum = bestThisAttemptResult.PartialSum.Text('g', 20)
+				ar.Identity = identityOf(bestThisAttemptResult.PartialSum, e.cfg.Target)
 			}
 		}
 		hallOfFame = append(hallOfFame, ar)
+		e.events.emit(Event{Type: EventAttemptEnd, Attempt: attempt, Generation: attemptGens, TotalGenerations: totalGensUsed, Result: &ar})
 
 		// Add best candidate to tabu set so future restarts avoid it
 		if bestThisAttempt != nil {
-			s := bestThisAttempt.String()
+			s := series.CanonicalKey(bestThisAttempt)
 			if !tabuSet[s] {
 				tabuSet[s] = true
-				fmt.Fprintf(os.Stderr, "Tabu: added %q\n", s)
+				fmt.Fprintf(os.Stderr, "Tabu: added %q\n", bestThisAttempt.String())
 			}
 		}
 
@@ -240,9 +681,33 @@ func (e *Engine) Run() FinalReport {
 
 		WriteHallOfFame(os.Stderr, hallOfFame)
 
+		if e.archive != nil {
+			fmt.Fprintf(os.Stderr, "Archive: %d candidates\n", e.archive.Len())
+		}
+		if e.failed != nil {
+			fmt.Fprintf(os.Stderr, "Failure tabu: %d structures\n", e.failed.Len())
+		}
+		e.seen.save()
+		if e.carried > 0 {
+			fmt.Fprintf(os.Stderr, "Elites carried over: %d evaluations skipped\n", e.carried)
+		}
+		if e.degenerate > 0 {
+			fmt.Fprintf(os.Stderr, "Trig probe: %d degenerate candidates failed unevaluated\n", e.degenerate)
+		}
+		if e.surrogate != nil {
+			fmt.Fprintf(os.Stderr, "Surrogate: %d candidates screened out (%d training samples)\n", e.surrogate.skipped, len(e.surrogate.feat))
+		}
+
+		cs := expr.SimplifyCacheStats()
+		fmt.Fprintf(os.Stderr, "Simplify cache: %d hits, %d misses (%.1f%% hit rate), %d entries\n",
+			cs.Hits, cs.Misses, 100*cs.HitRate(), cs.Entries)
+		if st, truncated := expr.SimplifyTotals(); truncated > 0 {
+			fmt.Fprintf(os.Stderr, "Simplify budget: %d of the trees simplified ran out (%d node visits in all)\n", truncated, st.Nodes)
+		}
+
 		// Write LaTeX hall of fame after each attempt so it survives Ctrl+C
 		if e.cfg.OutDir != "" {
-			base := fmt.Sprintf("%s_%s_%s_%s", e.cfg.Target, e.cfg.Pool, e.cfg.Strategy, runTimestamp)
+			base := fmt.Sprintf("%s_%s_%s_%s", targetFileName(e.cfg.Target), e.cfg.Pool, e.cfg.Strategy, runTimestamp)
 			tmpDir := os.TempDir()
 			tmpTex := filepath.Join(tmpDir, base+".tex")
 
@@ -250,7 +715,7 @@ func (e *Engine) Run() FinalReport {
 			if createErr != nil {
 				fmt.Fprintf(os.Stderr, "error creating %s: %v\n", tmpTex, createErr)
 			} else {
-				WriteHallOfFameLatex(f, hallOfFame, e.cfg, e.target)
+				WriteHallOfFameLatex(f, hallOfFame, e.cfg, e.prov, e.target)
 				f.Close()
 
 				// Compile to PDF if pdflatex is available
@@ -281,12 +746,23 @@ func (e *Engine) Run() FinalReport {
 					os.Remove(filepath.Join(tmpDir, base+ext))
 				}
 			}
-		}
 
-		// If global best hit the digit cap, no point restarting
-		if globalBestFitness.CorrectDigits >= float64(series.MaxDigits) {
-			fmt.Fprintf(os.Stderr, "Global best hit %d digit cap, stopping\n", series.MaxDigits)
-			break
+			// Other formats need no compiling and are written in place.
+			for _, name := range e.cfg.Reports {
+				r := hallOfFameReports[name]
+				dst := filepath.Join(e.cfg.OutDir, base+r.ext)
+				f, err := os.Create(dst)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "error creating %s: %v\n", dst, err)
+					continue
+				}
+				r.write(f, hallOfFame, e.cfg, e.prov, e.target)
+				if err := f.Close(); err != nil {
+					fmt.Fprintf(os.Stderr, "error writing %s: %v\n", dst, err)
+				} else {
+					fmt.Fprintf(os.Stderr, "Wrote %s\n", dst)
+				}
+			}
 		}
 	}
 
@@ -296,16 +772,41 @@ func (e *Engine) Run() FinalReport {
 		dedupedAttempts = dedupedAttempts[:maxHallOfFame]
 	}
 
+	var discoveries []Discovery
+	if e.verifier != nil {
+		discoveries = e.verifier.finish()
+		for i := range dedupedAttempts {
+			dedupedAttempts[i].Discovery = e.verifier.verified(dedupedAttempts[i].Canonical)
+		}
+		if e.cfg.OutDir != "" && e.cfg.Appendix > 0 {
+			e.writeAppendix(discoveries, runTimestamp)
+		}
+	}
+
 	finalReport := FinalReport{
 		Config:      e.cfg,
 		BestFitness: globalBestFitness,
 		Attempts:    dedupedAttempts,
+		RunSpec:     FormatConfig(e.cfg),
+		Provenance:  e.prov,
+		Discoveries: discoveries,
+		StopReason:  stopReason,
+
+		TotalGenerations: totalGensUsed,
+		Elapsed:          time.Since(start),
+		Milestones:       milestones,
+		Operators:        e.operatorStats(),
 	}
 
+	if e.archive != nil {
+		finalReport.Families = familiesOf(e.archive.Best(maxFamilyCandidates))
+	}
 	if e.cfg.Verbose {
 		finalReport.Generations = genReports
 	}
 
+	e.events.emit(Event{Type: EventRunEnd, Attempt: attempt, TotalGenerations: totalGensUsed, StopReason: stopReason})
+
 	if globalBest != nil {
 		finalReport.BestCandidate = globalBest.String()
 		finalReport.BestLaTeX = globalBest.LaTeX()
@@ -317,25 +818,65 @@ func (e *Engine) Run() FinalReport {
 	return finalReport
 }
 
+// writeAppendix writes the appendix section of the run's discoveries to
+// OutDir, named like the hall of fame.
+func (e *Engine) writeAppendix(discoveries []Discovery, runTimestamp string) {
+	base := fmt.Sprintf("%s_%s_%s_%s", targetFileName(e.cfg.Target), e.cfg.Pool, e.cfg.Strategy, runTimestamp)
+	dst := filepath.Join(e.cfg.OutDir, base+"_appendix.tex")
+	f, err := os.Create(dst)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error creating %s: %v\n", dst, err)
+		return
+	}
+	WriteAppendixLatex(f, discoveries, e.cfg.Appendix, e.cfg, e.prov)
+	if err := f.Close(); err != nil {
+		fmt.Fprintf(os.Stderr, "error writing %s: %v\n", dst, err)
+	} else {
+		fmt.Fprintf(os.Stderr, "Wrote %s\n", dst)
+	}
+}
+
 // evaluatePopulation evaluates all candidates in parallel, using a two-phase
 // float64 fast path when F64PromotionThreshold > 0. Phase 1 evaluates all
 // candidates at float64 speed. Phase 2 promotes only candidates that cleared
 // the digit threshold to the expensive big.Float path.
-func (e *Engine) evaluatePopulation(pop []*series.Candidate, tabuSet map[string]bool) ([]series.Fitness, []series.EvalResult) {
+//
+// A non-zero deadline bounds the whole call: candidates are started in
+// evalOrder, and any not started by the deadline are deferred. With screen
+// and a surrogate (Config.SurrogateFraction), only the candidates it picks
+// are evaluated; the rest are deferred too.
+func (e *Engine) evaluatePopulation(pop []*series.Candidate, tabuSet map[string]bool, deadline time.Time, screen bool) ([]series.Fitness, []series.EvalResult) {
 	n := len(pop)
 	fitnesses := make([]series.Fitness, n)
 	results := make([]series.EvalResult, n)
 
-	// Pre-compute string representations once for tabu lookups.
+	// Pre-compute canonical keys once for tabu and archive lookups, so
+	// rewritings of the same series (see series.Canonical) share them.
 	strs := make([]string, n)
 	for i, c := range pop {
-		strs[i] = c.String()
+		strs[i] = series.CanonicalKey(c)
+	}
+	done := e.carryOver(strs, fitnesses, results, tabuSet)
+	if e.cfg.TrigProbe {
+		done = e.probeDegenerate(pop, fitnesses, done)
+	}
+	if screen && e.surrogate != nil {
+		var sc *screening
+		done, sc = e.screen(pop, strs, fitnesses, done, tabuSet)
+		defer e.learn(sc, fitnesses)
+	}
+
+	var order []int
+	if !deadline.IsZero() {
+		order = evalOrder(pop)
 	}
 
 	threshold := e.cfg.F64PromotionThreshold
 	if threshold <= 0 {
 		// Disabled — fall through to big.Float for everyone.
-		e.evaluateBigFloat(pop, fitnesses, results, nil, tabuSet, strs)
+		e.evaluateBigFloat(pop, fitnesses, results, nil, done, tabuSet, strs, order, deadline)
+		e.recordFailures(fitnesses, strs, tabuSet)
+		e.seen.record(strs, fitnesses, tabuSet)
 		return fitnesses, results
 	}
 
@@ -361,36 +902,71 @@ func (e *Engine) evaluatePopulation(pop []*series.Candidate, tabuSet map[string]
 		go func() {
 			defer wg.Done()
 			for j := range jobs {
-				if tabuSet[j.str] {
+				if tabuSet[j.str] || e.seen.before(j.str) {
 					fitnesses[j.idx] = series.WorstFitness()
 					continue
 				}
-				r64 := series.EvaluateCandidateF64(j.candidate, e.cfg.MaxTerms)
-				f64 := series.ComputeFitnessF64(j.candidate, r64, e.targetF64, e.cfg.Weights)
-				fitnesses[j.idx] = f64
-				if f64.CorrectDigits >= threshold {
-					promote[j.idx] = true
+				if pastDeadline(deadline) {
+					fitnesses[j.idx] = e.deferredFitness(j.str)
+					continue
 				}
+				func() {
+					defer recoverEval(j.candidate, &fitnesses[j.idx], &results[j.idx])
+					r64 := series.EvaluateCandidateF64(j.candidate, e.cfg.MaxTerms)
+					f64 := e.fitnessF64(j.candidate, r64)
+					fitnesses[j.idx] = f64
+					if f64.CorrectDigits >= threshold {
+						promote[j.idx] = true
+					}
+				}()
 			}
 		}()
 	}
 
-	for i, c := range pop {
-		jobs <- job{idx: i, candidate: c, str: strs[i]}
-	}
+	forEachInOrder(n, order, func(i int) {
+		if done == nil || !done[i] {
+			jobs <- job{idx: i, candidate: pop[i], str: strs[i]}
+		}
+	})
 	close(jobs)
 	wg.Wait()
 
 	// Phase 2: big.Float eval for promoted candidates only.
-	e.evaluateBigFloat(pop, fitnesses, results, promote, tabuSet, strs)
+	e.evaluateBigFloat(pop, fitnesses, results, promote, done, tabuSet, strs, order, deadline)
+	e.recordFailures(fitnesses, strs, tabuSet)
+	e.seen.record(strs, fitnesses, tabuSet)
 
 	return fitnesses, results
 }
 
+// recordFailures adds to the failure tabu every candidate that scored the
+// worst fitness (divergent, domain error, failed evaluation) rather than
+// merely being deferred or skipped as a previous attempt's best (tabuSet)
+// or as seen in an earlier run. strs are the candidates' canonical keys.
+func (e *Engine) recordFailures(fitnesses []series.Fitness, strs []string, tabuSet map[string]bool) {
+	if e.failed == nil {
+		return
+	}
+	worst := series.WorstFitness().Combined
+	for i, f := range fitnesses {
+		if f.Combined <= worst && !f.Deferred && !tabuSet[strs[i]] && !e.seen.before(strs[i]) {
+			e.failed.Add(tabu.KeyOf(strs[i]))
+		}
+	}
+}
+
 // evaluateBigFloat runs big.Float evaluation on selected candidates.
 // If promote is nil, all candidates are evaluated. Otherwise only promote[i]==true.
-// strs contains pre-computed String() representations for tabu lookups.
-func (e *Engine) evaluateBigFloat(pop []*series.Candidate, fitnesses []series.Fitness, results []series.EvalResult, promote []bool, tabuSet map[string]bool, strs []string) {
+// Candidates with done[i] (carried-over elites; done may be nil) are skipped.
+// strs contains pre-computed canonical keys for tabu and archive lookups.
+// order and deadline are as for evaluatePopulation; a promoted candidate
+// that misses the deadline keeps its float64 fitness, marked Deferred.
+//
+// Workers only write their own candidate's slots. The evaluated
+// candidates go into the archive afterwards, in population order, since
+// which of two equal entries it keeps, and where, depends on the order
+// they arrive in; finishing order would tie that to the scheduler.
+func (e *Engine) evaluateBigFloat(pop []*series.Candidate, fitnesses []series.Fitness, results []series.EvalResult, promote, done []bool, tabuSet map[string]bool, strs []string, order []int, deadline time.Time) {
 	workers := e.cfg.Workers
 	if workers <= 0 {
 		workers = 1
@@ -403,6 +979,7 @@ func (e *Engine) evaluateBigFloat(pop []*series.Candidate, fitnesses []series.Fi
 	}
 
 	jobs := make(chan job, len(pop))
+	evaluated := make([]bool, len(pop))
 	var wg sync.WaitGroup
 
 	for w := 0; w < workers; w++ {
@@ -410,25 +987,162 @@ func (e *Engine) evaluateBigFloat(pop []*series.Candidate, fitnesses []series.Fi
 		go func() {
 			defer wg.Done()
 			for j := range jobs {
-				if tabuSet[j.str] {
+				if tabuSet[j.str] || e.seen.before(j.str) {
 					fitnesses[j.idx] = series.WorstFitness()
 					continue
 				}
-				result := series.EvaluateCandidate(j.candidate, e.cfg.MaxTerms, e.cfg.Precision)
-				fitness := series.ComputeFitness(j.candidate, result, e.target, e.cfg.Weights)
-				results[j.idx] = result
-				fitnesses[j.idx] = fitness
+				if pastDeadline(deadline) {
+					if promote == nil {
+						fitnesses[j.idx] = e.deferredFitness(j.str)
+					} else {
+						fitnesses[j.idx].Deferred = true
+					}
+					continue
+				}
+				func() {
+					defer recoverEval(j.candidate, &fitnesses[j.idx], &results[j.idx])
+					fitness, result := e.evaluate(j.candidate)
+					results[j.idx] = result
+					fitnesses[j.idx] = fitness
+					evaluated[j.idx] = true
+				}()
 			}
 		}()
 	}
 
-	for i, c := range pop {
-		if promote == nil || promote[i] {
-			jobs <- job{idx: i, candidate: c, str: strs[i]}
+	forEachInOrder(len(pop), order, func(i int) {
+		if (promote == nil || promote[i]) && (done == nil || !done[i]) {
+			jobs <- job{idx: i, candidate: pop[i], str: strs[i]}
 		}
-	}
+	})
 	close(jobs)
 	wg.Wait()
+
+	if e.archive == nil {
+		return
+	}
+	for i, ok := range evaluated {
+		if ok && fitnesses[i].Combined > series.WorstFitness().Combined {
+			e.archive.AddKeyed(strs[i], pop[i], fitnesses[i])
+		}
+	}
+}
+
+// evaluate runs the full evaluation of c: the evaluator and ComputeFitness
+// against the target (ComputeFitnessWithin for an interval), or against
+// each constant of an exploration, or for a sequence target
+// SequenceFitness, then the fitness hook.
+func (e *Engine) evaluate(c *series.Candidate) (series.Fitness, series.EvalResult) {
+	if e.seq != nil {
+		f, result := series.SequenceFitness(c, e.seq, e.cfg.MaxTerms, e.cfg.Weights)
+		return e.adjusted(c, f), result
+	}
+	opts := e.evalOpts
+	if e.cfg.DynamicPrecision {
+		opts.Prec = series.CandidatePrecision(c, opts.MaxTerms, opts.Prec)
+	}
+	result := e.evaluator.Evaluate(c, opts)
+	switch {
+	case e.explore != nil:
+		return e.adjusted(c, e.explore.fitness(c, result, e.cfg.Weights)), result
+	case e.bounds != nil:
+		return e.adjusted(c, series.ComputeFitnessWithin(c, result, e.bounds.lo, e.bounds.hi, e.cfg.Weights)), result
+	}
+	return e.adjusted(c, series.ComputeFitness(c, result, e.target, e.cfg.Weights)), result
+}
+
+// fitnessF64 is the float64 prescreen's fitness of c from its result r:
+// ComputeFitnessF64 against the target, then the fitness hook.
+func (e *Engine) fitnessF64(c *series.Candidate, r series.EvalResultF64) series.Fitness {
+	switch {
+	case e.explore != nil:
+		return e.adjusted(c, e.explore.fitnessF64(c, r, e.cfg.Weights))
+	case e.bounds != nil:
+		return e.adjusted(c, series.ComputeFitnessWithinF64(c, r, e.bounds.lo64, e.bounds.hi64, e.cfg.Weights))
+	}
+	return e.adjusted(c, series.ComputeFitnessF64(c, r, e.targetF64, e.cfg.Weights))
+}
+
+// targetBounds is a Bounded target's interval at the search's precision
+// and in float64.
+type targetBounds struct {
+	lo, hi     *big.Float
+	lo64, hi64 float64
+}
+
+func newTargetBounds(b constants.Bounded, prec uint) *targetBounds {
+	lo, hi := b.Bounds(prec)
+	lo64, _ := lo.Float64()
+	hi64, _ := hi.Float64()
+	return &targetBounds{lo: lo, hi: hi, lo64: lo64, hi64: hi64}
+}
+
+// adjusted is f after the fitness hook, if there is one and c was
+// evaluated.
+func (e *Engine) adjusted(c *series.Candidate, f series.Fitness) series.Fitness {
+	if e.adjust == nil || f.Deferred || f.Combined <= series.WorstFitness().Combined {
+		return f
+	}
+	return e.adjust(c, f)
+}
+
+// recoverEval, deferred around one candidate's evaluation, turns a panic
+// into a failed result so that a single bad candidate cannot kill the run.
+// Evaluators already recover their own panics; this also covers the
+// float64 path and fitness scoring.
+func recoverEval(c *series.Candidate, f *series.Fitness, r *series.EvalResult) {
+	if p := recover(); p != nil {
+		*f = series.WorstFitness()
+		*r = series.EvalResult{Err: &series.EvalError{Candidate: c.String(), Panic: p}}
+	}
+}
+
+// evalOrder returns the order in which to start evaluations under a time
+// budget: cheapest first by estimated evaluation cost (Candidate.EvalCost),
+// since cheap candidates let the most through and small trees are the
+// most likely to be worth keeping, with ties in population order.
+func evalOrder(pop []*series.Candidate) []int {
+	order := make([]int, len(pop))
+	cost := make([]float64, len(pop))
+	for i, c := range pop {
+		order[i] = i
+		cost[i] = c.EvalCost()
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return cost[order[a]] < cost[order[b]]
+	})
+	return order
+}
+
+// forEachInOrder calls fn for 0..n-1, in the given order if non-nil.
+func forEachInOrder(n int, order []int, fn func(int)) {
+	if order == nil {
+		for i := 0; i < n; i++ {
+			fn(i)
+		}
+		return
+	}
+	for _, i := range order {
+		fn(i)
+	}
+}
+
+func pastDeadline(deadline time.Time) bool {
+	return !deadline.IsZero() && time.Now().After(deadline)
+}
+
+// deferredFitness is the fitness of a candidate the time budget skipped:
+// its archived fitness if it was evaluated before (elites usually were),
+// otherwise the worst fitness. Either way it is marked Deferred.
+func (e *Engine) deferredFitness(key string) series.Fitness {
+	f := series.WorstFitness()
+	if e.archive != nil {
+		if entry, ok := e.archive.Lookup(key); ok {
+			f = entry.Fitness
+		}
+	}
+	f.Deferred = true
+	return f
 }
 
 // copyFile copies src to dst, creating or overwriting dst.
@@ -1,10 +1,25 @@
 package engine
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
+
+	"github.com/wildfunctions/genetic_series/pkg/constants"
+	"github.com/wildfunctions/genetic_series/pkg/expr"
+	"github.com/wildfunctions/genetic_series/pkg/series"
 
 	_ "github.com/wildfunctions/genetic_series/pkg/pool"
-	_ "github.com/wildfunctions/genetic_series/pkg/strategy"
+	"github.com/wildfunctions/genetic_series/pkg/strategy"
 )
 
 func TestEngine_SmallRun(t *testing.T) {
@@ -16,6 +31,9 @@ func TestEngine_SmallRun(t *testing.T) {
 	cfg.Seed = 42
 	cfg.Verbose = false
 	cfg.StagnationLimit = 5
+	// No float64 prescreen: every candidate reaches the big.Float stage,
+	// so the archive fills whatever digits the run finds.
+	cfg.F64PromotionThreshold = 0
 
 	e, err := New(cfg)
 	if err != nil {
@@ -33,6 +51,36 @@ func TestEngine_SmallRun(t *testing.T) {
 	if len(report.Attempts) == 0 {
 		t.Error("Expected at least one attempt in hall of fame")
 	}
+	if a := e.Archive(); a == nil || a.Len() == 0 {
+		t.Error("Expected evaluated candidates in the archive")
+	} else if best := a.Best(1); best[0].Fitness.Combined < report.BestFitness.Combined {
+		t.Errorf("archive best %.4f below run best %.4f", best[0].Fitness.Combined, report.BestFitness.Combined)
+	}
+	if report.BestFitness.CorrectDigits >= cfg.DiscoveryDigits {
+		verified := false
+		for _, d := range report.Discoveries {
+			verified = verified || d.Verified
+		}
+		if !verified {
+			t.Errorf("best has %.1f digits but no verified discovery in %+v", report.BestFitness.CorrectDigits, report.Discoveries)
+		}
+	}
+	for i, m := range report.Milestones {
+		if m.Digits != i+1 || m.Generation < 1 || m.Generation > report.TotalGenerations ||
+			i > 0 && m.Generation < report.Milestones[i-1].Generation {
+			t.Errorf("milestone %d = %+v, want digits %d in generation order", i, m, i+1)
+		}
+	}
+	if g, ok := report.GenerationsTo(1); ok != (len(report.Milestones) > 0) || ok && g != report.Milestones[0].Generation {
+		t.Errorf("GenerationsTo(1) = %d, %v", g, ok)
+	}
+	members := 0
+	for _, f := range report.Families {
+		members += f.Size
+	}
+	if want := min(e.Archive().Len(), maxFamilyCandidates); members != want {
+		t.Errorf("families cover %d candidates, want %d", members, want)
+	}
 
 	t.Logf("Best after %d attempts: fitness=%.4f, digits=%.1f, candidate=%s",
 		len(report.Attempts), report.BestFitness.Combined, report.BestFitness.CorrectDigits, report.BestCandidate)
@@ -59,10 +107,17 @@ func TestEngine_Restart(t *testing.T) {
 		t.Errorf("Expected multiple attempts with stagnation limit 5 and 50 gens, got %d", len(report.Attempts))
 	}
 
-	// Verify attempts are populated correctly
+	// Verify attempts are populated correctly. The report lists them best
+	// first, so numbers must be distinct and in range but not in order.
+	seen := map[int]bool{}
 	for i, a := range report.Attempts {
-		if a.Attempt != i+1 {
-			t.Errorf("Attempt %d has wrong attempt number %d", i+1, a.Attempt)
+		if a.Attempt < 1 || a.Attempt > len(report.Attempts)+cfg.Generations || seen[a.Attempt] {
+			t.Errorf("Attempt %d has bad or duplicate attempt number %d", i+1, a.Attempt)
+		}
+		seen[a.Attempt] = true
+		if i > 0 && a.BestFitness.CorrectDigits > report.Attempts[i-1].BestFitness.CorrectDigits {
+			t.Errorf("Attempt %d (%.1f digits) ranked below attempt %d (%.1f digits)",
+				a.Attempt, a.BestFitness.CorrectDigits, report.Attempts[i-1].Attempt, report.Attempts[i-1].BestFitness.CorrectDigits)
 		}
 		if a.Generations == 0 {
 			t.Errorf("Attempt %d has 0 generations", a.Attempt)
@@ -115,13 +170,117 @@ func TestEngine_Tournament(t *testing.T) {
 		report.BestFitness.Combined, report.BestFitness.CorrectDigits)
 }
 
+func TestEngine_Ratio(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Target = "pi"
+	cfg.Strategy = "tournament"
+	cfg.Population = 30
+	cfg.Generations = 10
+	cfg.MaxTerms = 128
+	cfg.Seed = 42
+	cfg.Ratio = true
+
+	e, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	report := e.Run()
+	if !strings.Contains(report.BestCandidate, ") / (Sum_") {
+		t.Errorf("best candidate %q is not a ratio", report.BestCandidate)
+	}
+
+	path := filepath.Join(t.TempDir(), "b.txt")
+	if err := os.WriteFile(path, []byte("1 2\n2 4\n3 8\n4 16\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, bad := range []func(*Config){
+		func(c *Config) { c.Strategy = "consttune" },
+		func(c *Config) { c.StreamBatch = 10 },
+		func(c *Config) { c.Target = "seq:" + path },
+	} {
+		c := cfg
+		bad(&c)
+		if _, err := New(c); err == nil || !strings.Contains(err.Error(), "-ratio") {
+			t.Errorf("%s, streaming %d, target %s: %v, want a -ratio error", c.Strategy, c.StreamBatch, c.Target, err)
+		}
+	}
+}
+
 func TestEngine_InvalidTarget(t *testing.T) {
 	cfg := DefaultConfig()
 	cfg.Target = "nonexistent"
 
 	_, err := New(cfg)
 	if err == nil {
-		t.Error("Expected error for invalid target")
+		t.Fatal("Expected error for invalid target")
+	}
+	// The constants are listed once, in order.
+	list := fmt.Sprint(constants.Names())
+	if n := strings.Count(err.Error(), list); n != 1 {
+		t.Errorf("error %q lists the constants %s %d times, want once", err, list, n)
+	}
+}
+
+func TestEngine_ExpressionTarget(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Target = "pi^2/6"
+	cfg.Precision = 1024
+
+	e, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.target.Prec() != 1024 {
+		t.Errorf("target precision %d, want 1024", e.target.Prec())
+	}
+	if math.Abs(e.targetF64-math.Pi*math.Pi/6) > 1e-15 {
+		t.Errorf("targetF64 = %v, want π²/6", e.targetF64)
+	}
+	if got := fileSafe(cfg.Target); got != "pi_2_6" {
+		t.Errorf("fileSafe(%q) = %q", cfg.Target, got)
+	}
+}
+
+func TestEngine_SequenceTarget(t *testing.T) {
+	// a(n) = 2^n + 1, n >= 1
+	var b strings.Builder
+	for n := 1; n <= 30; n++ {
+		fmt.Fprintf(&b, "%d %d\n", n, 1<<n+1)
+	}
+	path := filepath.Join(t.TempDir(), "b.txt")
+	if err := os.WriteFile(path, []byte(b.String()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := DefaultConfig()
+	cfg.Target = "seq:" + path
+	cfg.Population = 30
+	cfg.Generations = 5
+	cfg.Seed = 3
+	cfg.Workers = 1
+
+	e, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.tgt != nil || e.cfg.F64PromotionThreshold != 0 || e.cfg.DiscoveryDigits != 0 {
+		t.Errorf("sequence mode kept a target or sum-only phases: %+v", e.cfg)
+	}
+	for target, want := range map[string]string{cfg.Target: "seq_b", "seq:A000045": "seq_A000045", "seq:../x/sq.b.txt": "seq_sq.b"} {
+		if got := targetFileName(target); got != want {
+			t.Errorf("targetFileName(%q) = %q, want %q", target, got, want)
+		}
+	}
+	if got := e.stop.String(); got != "generations >= 5 or digits >= 30" {
+		t.Errorf("stop = %s", got)
+	}
+	report := e.Run()
+	if report.BestFitness.Combined <= series.WorstFitness().Combined || report.BestCandidate == "" {
+		t.Errorf("no candidate matched a term: %+v", report.BestFitness)
+	}
+
+	cfg.Target = "seq:" + filepath.Join(t.TempDir(), "missing.txt")
+	if _, err := New(cfg); err == nil {
+		t.Error("missing b-file accepted")
 	}
 }
 
@@ -145,6 +304,264 @@ func TestEngine_InvalidPool(t *testing.T) {
 	}
 }
 
+func TestEngine_RNGStreams(t *testing.T) {
+	run := func(kind string, stream int) string {
+		cfg := DefaultConfig()
+		cfg.Population = 20
+		cfg.Generations = 3
+		cfg.MaxTerms = 64
+		cfg.Workers = 2
+		cfg.Seed = 9
+		cfg.RNG = kind
+		cfg.RNGStream = stream
+		e, err := New(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return e.Run().BestCandidate
+	}
+	for _, kind := range []string{"go", "pcg", "xoshiro"} {
+		if a, b := run(kind, 3), run(kind, 3); a != b {
+			t.Errorf("%s stream 3 gave %s, then %s", kind, a, b)
+		}
+	}
+
+	cfg := DefaultConfig()
+	cfg.RNG = "mersenne"
+	if _, err := New(cfg); err == nil {
+		t.Error("unknown rng accepted")
+	}
+	cfg.RNG, cfg.RNGStream = "pcg", -1
+	if _, err := New(cfg); err == nil {
+		t.Error("negative stream accepted")
+	}
+}
+
+func TestEngine_WorkersReproduce(t *testing.T) {
+	// A small archive fills in the first generation, so every later one
+	// evicts, and equal fitnesses compete for slots.
+	run := func(workers int) string {
+		cfg := DefaultConfig()
+		cfg.Population = 200
+		cfg.Generations = 5
+		cfg.MaxTerms = 64
+		cfg.Workers = workers
+		cfg.Seed = 21
+		cfg.ArchiveSize = 8
+		cfg.F64PromotionThreshold = 0
+		e, err := New(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		report := e.Run()
+		var b strings.Builder
+		fmt.Fprintf(&b, "%s %v\n", report.BestCandidate, report.BestFitness.Combined)
+		for _, entry := range e.Archive().Best(0) {
+			fmt.Fprintf(&b, "%s %s %v\n", entry.Key, entry.Candidate, entry.Fitness.Combined)
+		}
+		return b.String()
+	}
+	want := run(1)
+	for _, workers := range []int{3, 16} {
+		if got := run(workers); got != want {
+			t.Errorf("%d workers:\n%s\n1 worker:\n%s", workers, got, want)
+		}
+	}
+}
+
+func TestEngine_SeenFilter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "seen.bloom")
+	run := func() *seenFilter {
+		cfg := DefaultConfig()
+		cfg.Population = 20
+		cfg.Generations = 3
+		cfg.MaxTerms = 64
+		cfg.Seed = 11
+		cfg.SeenFile = path
+		cfg.SeenCapacity = 1000
+		e, err := New(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		e.Run()
+		return e.seen
+	}
+	first := run()
+	if first.prior != nil || first.skipped != 0 || first.next.Len() == 0 {
+		t.Fatalf("first run: prior %v, skipped %d, %d keys", first.prior, first.skipped, first.next.Len())
+	}
+	// The same seed breeds the same initial population, all seen before.
+	second := run()
+	if second.prior.Len() != first.next.Len() || second.skipped < 20 {
+		t.Errorf("second run: %d prior keys (want %d), skipped %d", second.prior.Len(), first.next.Len(), second.skipped)
+	}
+}
+
+func TestSeenFilterAcrossRuns(t *testing.T) {
+	// Eight runs of 500 new candidates each into a filter sized for 500:
+	// the saved filter keeps every earlier run's candidates and grows, so
+	// new ones are not taken for explored at most runs' rate.
+	path := filepath.Join(t.TempDir(), "seen.bloom")
+	const runs, perRun = 8, 500
+	for r := 0; r < runs; r++ {
+		s, err := loadSeen(path, perRun)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for p := 0; p < r; p++ {
+			if !s.before(fmt.Sprintf("run %d candidate %d", p, perRun-1)) {
+				t.Fatalf("run %d: run %d's candidates forgotten", r, p)
+			}
+		}
+		keys := make([]string, perRun)
+		for i := range keys {
+			keys[i] = fmt.Sprintf("run %d candidate %d", r, i)
+		}
+		s.record(keys, make([]series.Fitness, perRun), nil)
+		if s.skipped > perRun/20 {
+			t.Errorf("run %d: %d of %d new candidates skipped as seen", r, s.skipped, perRun)
+		}
+		s.save()
+	}
+	s, err := loadSeen(path, perRun)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Candidates taken for seen, or for this run's, are not added again.
+	if n := s.prior.Len(); n < runs*perRun*95/100 || n > runs*perRun || s.prior.FalsePositiveRate() > 2*seenFalsePositiveRate {
+		t.Errorf("after %d runs: %d keys in %d filters, %.3f false positives", runs, s.prior.Len(), s.prior.Filters(), s.prior.FalsePositiveRate())
+	}
+	fp := 0
+	for i := 0; i < 20000; i++ {
+		if s.before(fmt.Sprintf("never %d", i)) {
+			fp++
+		}
+	}
+	if rate := float64(fp) / 20000; rate > 2*seenFalsePositiveRate {
+		t.Errorf("after %d runs: %.3f of unseen candidates taken for seen", runs, rate)
+	}
+}
+
+func TestEngine_Leaderboard(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "board.json")
+	cfg := DefaultConfig()
+	cfg.Population = 20
+	cfg.Generations = 4
+	cfg.MaxTerms = 64
+	cfg.Seed = 11
+	cfg.Leaderboard = path
+	cfg.LeaderboardSize = 5
+	e, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	report := e.Run()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b Leaderboard
+	if err := json.Unmarshal(data, &b); err != nil {
+		t.Fatal(err)
+	}
+	if b.RunID != report.Provenance.RunID || b.Generations != report.TotalGenerations || len(b.Entries) == 0 || len(b.Entries) > 5 {
+		t.Fatalf("leaderboard %+v after %d generations", b, report.TotalGenerations)
+	}
+	keys := map[string]bool{}
+	for i, entry := range b.Entries {
+		if keys[entry.Canonical] || i > 0 && entry.Fitness.Combined > b.Entries[i-1].Fitness.Combined {
+			t.Errorf("entry %d (%s) repeated or out of order", i, entry.Candidate)
+		}
+		keys[entry.Canonical] = true
+	}
+	if b.Entries[0].Fitness.Combined != report.BestFitness.Combined {
+		t.Errorf("top entry %v, run best %v", b.Entries[0].Fitness.Combined, report.BestFitness.Combined)
+	}
+	tex, err := os.ReadFile(filepath.Join(filepath.Dir(path), "board.tex"))
+	if err != nil || !strings.Contains(string(tex), b.Entries[0].LaTeX) {
+		t.Errorf("LaTeX snippet missing the top entry: %v\n%s", err, tex)
+	}
+}
+
+func TestEngine_Reports(t *testing.T) {
+	dir := t.TempDir()
+	cfg := DefaultConfig()
+	cfg.Population = 10
+	cfg.Generations = 2
+	cfg.MaxTerms = 32
+	cfg.Seed = 5
+	cfg.OutDir = dir
+	cfg.Reports = []string{"typst", "markdown"}
+	e, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	report := e.Run()
+
+	for ext, math := range map[string]string{".typ": "$ sum_(n=", ".md": "$$\n\\sum_{n="} {
+		files, _ := filepath.Glob(filepath.Join(dir, "*"+ext))
+		if len(files) != 1 {
+			t.Fatalf("%s files: %v", ext, files)
+		}
+		data, err := os.ReadFile(files[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s := string(data); !strings.Contains(s, "provenance: run_id="+report.Provenance.RunID) || !strings.Contains(s, math) {
+			t.Errorf("%s report lacks provenance or formulas:\n%s", ext, s)
+		}
+	}
+
+	cfg.Reports = []string{"html"}
+	if _, err := New(cfg); err == nil {
+		t.Error("New accepted an unknown report format")
+	}
+}
+
+func TestEngine_Rescore(t *testing.T) {
+	board := Leaderboard{Target: "e", Entries: []LeaderboardEntry{
+		{LaTeX: `\sum_{n=0}^{\infty} \frac{1}{n!}`, Fitness: series.Fitness{CorrectDigits: 50}},
+		{LaTeX: `\sum_{n=1}^{\infty} \frac{1}{n^{2}}`, Fitness: series.Fitness{CorrectDigits: 1}},
+	}}
+	cfg := DefaultConfig()
+	cfg.Target = "pi^2/6"
+	cfg.MaxTerms = 4096
+	e, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rescored, entries, err := e.Rescore(board)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rescored.Target != "pi^2/6" || len(rescored.Entries) != 2 || len(entries) != 2 {
+		t.Fatalf("rescored board %+v", rescored)
+	}
+	// Against π²/6, Σ1/n² moves to the top and Σ1/n! shares no digits.
+	if entries[0].PreviousRank != 2 || entries[0].Fitness.CorrectDigits < 3 || entries[0].Previous.CorrectDigits != 1 {
+		t.Errorf("top entry %+v, want Σ1/n² from rank 2", entries[0])
+	}
+	if entries[1].Fitness.CorrectDigits > 1 || rescored.Entries[0].LaTeX != board.Entries[1].LaTeX {
+		t.Errorf("second entry %+v", entries[1])
+	}
+
+	// LaTeX this release cannot parse falls back on the genome.
+	c, err := series.ParseCandidateLatex(board.Entries[1].LaTeX)
+	if err != nil {
+		t.Fatal(err)
+	}
+	board.Entries[1].LaTeX, board.Entries[1].Genome = `\zeta(2)`, series.EncodeCandidate(c)
+	if _, entries, err := e.Rescore(board); err != nil || entries[0].PreviousRank != 2 {
+		t.Errorf("genome fallback: %v, %+v", err, entries)
+	}
+
+	board.Entries = append(board.Entries, LeaderboardEntry{LaTeX: `\frac{`})
+	if _, _, err := e.Rescore(board); err == nil {
+		t.Error("unparseable entry not reported")
+	}
+}
+
 // TestEngine_F64Disabled verifies that threshold=0 (no float64 fast path) still works.
 func TestEngine_F64Disabled(t *testing.T) {
 	cfg := DefaultConfig()
@@ -196,3 +613,455 @@ func TestEngine_JSONFormat(t *testing.T) {
 		t.Error("Expected a best candidate in JSON mode")
 	}
 }
+
+func TestEngine_StreamingMatchesInMemory(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Target = "e"
+	cfg.Population = 30
+	cfg.Generations = 8
+	cfg.MaxTerms = 64
+	cfg.Seed = 42
+	cfg.StagnationLimit = 0
+	cfg.Workers = 1
+
+	run := func(batch int) FinalReport {
+		c := cfg
+		c.StreamBatch = batch
+		e, err := New(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return e.Run()
+	}
+
+	want := run(0)
+	got := run(7)
+	if got.BestCandidate != want.BestCandidate || got.BestFitness != want.BestFitness {
+		t.Errorf("streaming best %q (%.4f), in-memory best %q (%.4f)",
+			got.BestCandidate, got.BestFitness.Combined, want.BestCandidate, want.BestFitness.Combined)
+	}
+	if got.BestPartialSum != want.BestPartialSum {
+		t.Errorf("streaming best partial sum %q, in-memory %q", got.BestPartialSum, want.BestPartialSum)
+	}
+}
+
+// TestEngine_GenerationBudget checks that an exhausted budget defers
+// candidates instead of evaluating them, and the run still completes.
+func TestEngine_GenerationBudget(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Target = "e"
+	cfg.Population = 30
+	cfg.Generations = 3
+	cfg.MaxTerms = 64
+	cfg.Seed = 42
+	cfg.Workers = 1
+	cfg.GenerationBudget = time.Nanosecond
+	cfg.Verbose = true // keep per-generation reports
+
+	e, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	report := e.Run()
+
+	if len(report.Generations) == 0 {
+		t.Fatal("Expected generation reports")
+	}
+	for _, g := range report.Generations {
+		if g.Deferred == 0 {
+			t.Errorf("gen %d: no candidates deferred under a 1ns budget", g.Generation)
+		}
+	}
+}
+
+func TestEvalOrder(t *testing.T) {
+	small := &series.Candidate{Numerator: &expr.ConstNode{Val: 1}, Denominator: &expr.VarNode{}}
+	big := &series.Candidate{
+		Numerator:   &expr.ConstNode{Val: 1},
+		Denominator: &expr.UnaryNode{Op: expr.OpFactorial, Child: &expr.VarNode{}},
+	}
+	// 1/(n·n + n + 1): more nodes than 1/n!, but cheaper to evaluate.
+	poly := &series.Candidate{
+		Numerator: &expr.ConstNode{Val: 1},
+		Denominator: &expr.BinaryNode{Op: expr.OpAdd,
+			Left:  &expr.BinaryNode{Op: expr.OpAdd, Left: &expr.BinaryNode{Op: expr.OpMul, Left: &expr.VarNode{}, Right: &expr.VarNode{}}, Right: &expr.VarNode{}},
+			Right: &expr.ConstNode{Val: 1},
+		},
+	}
+	got := evalOrder([]*series.Candidate{big, small, big, small, poly})
+	want := []int{1, 3, 4, 0, 2}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("evalOrder = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestRecoverEval(t *testing.T) {
+	c := &series.Candidate{Numerator: &expr.ConstNode{Val: 1}, Denominator: &expr.VarNode{}}
+	f := series.Fitness{Combined: 5}
+	var r series.EvalResult
+	func() {
+		defer recoverEval(c, &f, &r)
+		panic("boom")
+	}()
+	var evalErr *series.EvalError
+	if !errors.As(r.Err, &evalErr) || evalErr.Panic != "boom" {
+		t.Errorf("Err = %v, want EvalError wrapping the panic", r.Err)
+	}
+	if f != series.WorstFitness() || r.OK {
+		t.Errorf("fitness = %+v, OK = %v; want worst, false", f, r.OK)
+	}
+}
+
+func TestIdentityOf(t *testing.T) {
+	ln2 := constants.Get("ln2").Value
+	three := new(big.Float).SetPrec(512).Mul(ln2, big.NewFloat(3))
+	if got := identityOf(three, "pi"); got != "3ln2" {
+		t.Errorf("identityOf(3 ln2) = %q, want 3ln2", got)
+	}
+	if got := identityOf(ln2, "ln2"); got != "" {
+		t.Errorf("identityOf(ln2) for target ln2 = %q, want none", got)
+	}
+}
+
+func TestEngine_FitnessHook(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Target = "e"
+	cfg.Population = 20
+	cfg.Generations = 3
+	cfg.MaxTerms = 64
+	cfg.Seed = 5
+	cfg.FitnessScript = `1000 + digits`
+
+	e, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var calls atomic.Int64
+	e.AdjustFitness(func(c *series.Candidate, f series.Fitness) series.Fitness {
+		calls.Add(1)
+		if f.Combined != 1000+f.CorrectDigits {
+			t.Errorf("hook saw Combined %v before the script", f.Combined)
+		}
+		f.Combined += float64(c.Start)
+		return f
+	})
+	report := e.Run()
+	if calls.Load() == 0 {
+		t.Fatal("fitness hook never called")
+	}
+	if report.BestFitness.Combined < 1000 {
+		t.Errorf("best fitness %v not rescored by the script", report.BestFitness.Combined)
+	}
+
+	cfg.FitnessScript = `digits +`
+	if _, err := New(cfg); err == nil || !strings.Contains(err.Error(), "fitness script") {
+		t.Errorf("New with a bad script: %v", err)
+	}
+}
+
+func TestEngine_Exploration(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Target = "any"
+	cfg.Strategy = "consttune"
+	cfg.SeedFormula = `\sum_{n=0}^{\infty} \frac{1}{n!}`
+	cfg.Population = 10
+	cfg.Generations = 2
+	cfg.MaxTerms = 64
+	cfg.Seed = 1
+	cfg.VerifyInterval = 0
+
+	e, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.tgt != nil || e.explore == nil || len(e.explore.targets) != len(constants.Names()) {
+		t.Fatalf("exploration targets: %v", e.explore)
+	}
+	report := e.Run()
+	if report.BestFitness.Match != "e" {
+		t.Errorf("best %s matched %q, want e", report.BestCandidate, report.BestFitness.Match)
+	}
+	found := false
+	for _, d := range report.Discoveries {
+		found = found || d.Verified && d.Constant == "e"
+	}
+	if !found {
+		t.Errorf("no verified discovery of e in %+v", report.Discoveries)
+	}
+	var b strings.Builder
+	WriteDiscoveries(&b, report.Discoveries)
+	if !strings.Contains(b.String(), "  e (") {
+		t.Errorf("discoveries not bucketed by constant:\n%s", b.String())
+	}
+
+	cfg.Target = "any:pi, e,nope"
+	if _, err := New(cfg); err == nil || !strings.Contains(err.Error(), `"nope"`) {
+		t.Errorf("New with an unknown exploration constant: %v", err)
+	}
+}
+
+func TestEngine_IntervalTarget(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Target = "[2.7, 2.72]"
+	cfg.Strategy = "consttune"
+	cfg.SeedFormula = `\sum_{n=0}^{\infty} \frac{1}{n!}`
+	cfg.Population = 10
+	cfg.Generations = 2
+	cfg.MaxTerms = 64
+	cfg.Seed = 1
+
+	e, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.bounds == nil || e.cfg.DiscoveryDigits != 0 {
+		t.Fatalf("interval target: bounds %v, discovery digits %v", e.bounds, e.cfg.DiscoveryDigits)
+	}
+	report := e.Run()
+	if want := math.Log10(2.71 / 0.01); math.Abs(report.BestFitness.CorrectDigits-want) > 1e-6 {
+		t.Errorf("best %s has %.4f digits, want the interval's %.4f", report.BestCandidate, report.BestFitness.CorrectDigits, want)
+	}
+}
+
+func TestEngine_CarriesElites(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Target = "e"
+	cfg.Strategy = "consttune"
+	cfg.SeedFormula = `\sum_{n=0}^{\infty} \frac{1}{n! + 1}`
+	cfg.Population = 20
+	cfg.Generations = 5
+	cfg.MaxTerms = 64
+	cfg.Seed = 3
+	cfg.F64PromotionThreshold = 0
+
+	run := func(carry bool) (FinalReport, int64, int) {
+		e, err := New(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !carry {
+			e.elites = 0
+		}
+		var calls atomic.Int64
+		e.AdjustFitness(func(_ *series.Candidate, f series.Fitness) series.Fitness {
+			calls.Add(1)
+			return f
+		})
+		return e.Run(), calls.Load(), e.carried
+	}
+
+	with, evals, carried := run(true)
+	without, allEvals, _ := run(false)
+	if want := 2 * (cfg.Generations - 1); carried < want {
+		t.Errorf("carried %d elites over, want at least %d", carried, want)
+	}
+	if evals+int64(carried) != allEvals {
+		t.Errorf("%d evaluations + %d carried, want the %d of a run without carry-over", evals, carried, allEvals)
+	}
+	if with.BestCandidate != without.BestCandidate || with.BestFitness != without.BestFitness {
+		t.Errorf("carry-over changed the run: %s %v vs %s %v",
+			with.BestCandidate, with.BestFitness, without.BestCandidate, without.BestFitness)
+	}
+}
+
+func TestEngine_OperatorStats(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Target = "catalan"
+	cfg.Strategy = "tournament"
+	cfg.Population = 40
+	cfg.Generations = 6
+	cfg.MaxTerms = 64
+	cfg.Seed = 5
+
+	e, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := e.Run()
+	if len(r.Operators) == 0 {
+		t.Fatal("no operator stats in the report")
+	}
+	offspring := 0
+	ops := map[string]bool{}
+	for _, s := range r.Operators {
+		ops[s.Op] = true
+		offspring += s.Offspring
+		binned := 0
+		for _, n := range s.Deltas {
+			binned += n
+		}
+		if binned != s.Scored || s.Improved > s.Scored || s.Failed+s.Deferred+s.Scored > s.Offspring {
+			t.Errorf("%s: inconsistent counts %+v", s.Op, s)
+		}
+	}
+	// Every generation but the first was bred, and every member counted.
+	if want := cfg.Population * (r.TotalGenerations - 1); offspring != want {
+		t.Errorf("%d offspring counted, want %d", offspring, want)
+	}
+	for _, op := range []string{strategy.OpElite, strategy.OpCrossover} {
+		if !ops[op] {
+			t.Errorf("no %s offspring in %v", op, r.Operators)
+		}
+	}
+
+	var buf bytes.Buffer
+	WriteTextFinal(&buf, r)
+	if !strings.Contains(buf.String(), "--- Operators") {
+		t.Errorf("text report has no operators:\n%s", buf.String())
+	}
+}
+
+func TestEngine_Surrogate(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Target = "catalan"
+	cfg.Population = 100
+	cfg.Generations = 8
+	cfg.MaxTerms = 64
+	cfg.Seed = 2
+	cfg.F64PromotionThreshold = 0
+	cfg.SurrogateFraction = 0.25
+
+	e, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var calls atomic.Int64
+	e.AdjustFitness(func(_ *series.Candidate, f series.Fitness) series.Fitness {
+		calls.Add(1)
+		return f
+	})
+	e.Run()
+	if e.surrogate.skipped == 0 {
+		t.Fatal("surrogate screened nothing out")
+	}
+	// Warm-up takes the first few generations; after that about a quarter
+	// of each is evaluated.
+	if max := int64(cfg.Population * cfg.Generations); calls.Load()+int64(e.surrogate.skipped) > max {
+		t.Errorf("%d evaluations + %d screened out, more than %d candidates", calls.Load(), e.surrogate.skipped, max)
+	}
+
+	// Predictions follow the training data.
+	s := newSurrogate(0.5)
+	for i := 0; i < 100; i++ {
+		var x features
+		x[3] = float64(i%20) - 16
+		s.train(x, series.Fitness{Combined: -x[3]})
+	}
+	s.rescale()
+	near, _ := s.predict(features{3: -15})
+	far, _ := s.predict(features{3: 2})
+	if near <= far {
+		t.Errorf("predicted %v near the target, %v far from it", near, far)
+	}
+
+	cfg.SurrogateFraction = 1.5
+	if _, err := New(cfg); err == nil {
+		t.Error("New accepted a surrogate fraction of 1.5")
+	}
+}
+
+func TestEngine_EventLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	cfg := DefaultConfig()
+	cfg.Population = 20
+	cfg.Generations = 4
+	cfg.MaxTerms = 64
+	cfg.Seed = 11
+	cfg.EventLog = path
+	e, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var hooked []string
+	e.OnEvent(func(ev Event) { hooked = append(hooked, ev.Type) })
+	report := e.Run()
+	perRun := len(hooked)
+	e.Run() // a second run appends
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	// The second run carries on from the first's archive and random
+	// stream, so it need not emit as many events.
+	if len(lines) != len(hooked) || len(hooked) <= perRun {
+		t.Fatalf("%d lines in the log, %d events hooked, %d in the first run", len(lines), len(hooked), perRun)
+	}
+	var types []string
+	var gens, bests int
+	var last float64
+	for i, line := range lines[:perRun] {
+		var ev Event
+		if err := json.Unmarshal([]byte(line), &ev); err != nil {
+			t.Fatalf("line %d: %v", i+1, err)
+		}
+		if ev.RunID != report.Provenance.RunID {
+			t.Errorf("line %d: run %q, want %q", i+1, ev.RunID, report.Provenance.RunID)
+		}
+		types = append(types, ev.Type)
+		switch ev.Type {
+		case EventGeneration:
+			if ev.Report == nil || ev.Stats == nil || ev.Stats.Population != cfg.Population || ev.TotalGenerations != gens {
+				t.Errorf("line %d: generation event %s", i+1, line)
+			}
+			gens++
+		case EventNewBest:
+			if ev.Report == nil || ev.Report.BestFitness.Combined <= last && bests > 0 {
+				t.Errorf("line %d: new best %s does not improve on %v", i+1, line, last)
+			}
+			last = ev.Report.BestFitness.Combined
+			bests++
+		}
+	}
+	if types[0] != EventRunStart || types[len(types)-2] != EventAttemptEnd || types[len(types)-1] != EventRunEnd {
+		t.Errorf("event types %v", types)
+	}
+	if strings.Join(types, " ") != strings.Join(hooked[:perRun], " ") {
+		t.Errorf("log %v, hook %v", types, hooked[:perRun])
+	}
+	if gens != report.TotalGenerations || bests == 0 || last != report.BestFitness.Combined {
+		t.Errorf("%d generation and %d new-best events, best %v; report has %d generations, best %v",
+			gens, bests, last, report.TotalGenerations, report.BestFitness.Combined)
+	}
+}
+
+func TestEngine_TrigProbe(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Target = "pi^2/6"
+	cfg.MaxTerms = 64
+	cfg.Workers = 1
+	cfg.TrigProbe = true
+
+	e, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	evaluated := map[string]bool{}
+	e.AdjustFitness(func(c *series.Candidate, f series.Fitness) series.Fitness {
+		evaluated[c.String()] = true
+		return f
+	})
+	var pop []*series.Candidate
+	for _, f := range []string{
+		`\sum_{n=1}^{\infty} \frac{\sin{(\pi n)}}{n^{2}}`,
+		`\sum_{n=1}^{\infty} \frac{1}{n^{2}}`,
+		`\sum_{n=0}^{\infty} \frac{\cos{(2 \pi n)}}{n! + 1}`,
+	} {
+		c, err := series.ParseCandidateLatex(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pop = append(pop, c)
+	}
+	fitnesses, _ := e.evaluatePopulation(pop, nil, time.Time{}, false)
+	worst := series.WorstFitness().Combined
+	if fitnesses[0].Combined != worst || fitnesses[1].Combined <= worst || e.degenerate != 1 {
+		t.Errorf("fitnesses %v, %d degenerate, want only the first failed", fitnesses, e.degenerate)
+	}
+	if len(evaluated) != 2 || evaluated[pop[0].String()] {
+		t.Errorf("evaluated %v, want the last two", evaluated)
+	}
+}
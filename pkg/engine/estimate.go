@@ -0,0 +1,184 @@
+package engine
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"time"
+
+	"github.com/wildfunctions/genetic_series/pkg/series"
+)
+
+// maxEstimateBigFloat bounds how many sample candidates Estimate evaluates
+// with big.Float, the slow phase; promoted candidates are taken first.
+const maxEstimateBigFloat = 50
+
+// Per-entry overheads assumed for the archive and failure tabu on top of
+// the candidates themselves: map and slice bookkeeping, keys and fitness.
+const (
+	archiveEntryBytes = 160
+	tabuEntryBytes    = 40
+)
+
+// EstimateReport is a dry run's timing of each phase of a generation on a
+// sample of candidates, and what it projects for the full configuration.
+// Times are per candidate on one worker.
+type EstimateReport struct {
+	Sample             int           `json:"sample"`
+	Init               time.Duration `json:"init"`     // strategy.Initialize
+	Keys               time.Duration `json:"keys"`     // canonical keys for tabu and archive lookups
+	F64                time.Duration `json:"f64"`      // float64 prescreen
+	BigFloat           time.Duration `json:"bigfloat"` // full evaluation (Config.Evaluator) at the configured precision and terms
+	Breed              time.Duration `json:"breed"`    // strategy.Evolve: selection, mutation, crossover, simplification
+	PromoteRate        float64       `json:"promote_rate"`
+	FailRate           float64       `json:"fail_rate"`  // candidates scoring the worst fitness
+	Generation         time.Duration `json:"generation"` // projected wall clock per generation
+	DeferRate          float64       `json:"defer_rate"` // projected fraction deferred by GenerationBudget
+	GenerationsPerHour float64       `json:"generations_per_hour"`
+	CandidateBytes     int64         `json:"candidate_bytes"` // live heap per tree candidate
+	GenomeBytes        int64         `json:"genome_bytes"`    // per candidate in streaming mode
+	MemoryBytes        int64         `json:"memory_bytes"`    // projected population, archive and tabu
+}
+
+// Estimate times a generation's phases on sample candidates from the
+// configured pool and strategy, without running a search, and projects the
+// time per generation and memory use of the full population. Evaluation
+// is timed on one goroutine and assumed to scale linearly with Workers;
+// breeding runs on one goroutine in the search too.
+func (e *Engine) Estimate(sample int) EstimateReport {
+	if sample < 1 {
+		sample = 1
+	}
+	r := EstimateReport{Sample: sample}
+	per := func(d time.Duration, n int) time.Duration { return d / time.Duration(max(n, 1)) }
+
+	// The second GC empties the sync.Pool victim caches the first left
+	// behind, so what they free is not counted against the sample.
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	t := time.Now()
+	pop := e.strategy.Initialize(e.pool, e.rng, sample)
+	r.Init = per(time.Since(t), sample)
+	runtime.GC()
+	runtime.ReadMemStats(&after)
+	if after.HeapAlloc > before.HeapAlloc {
+		r.CandidateBytes = int64(after.HeapAlloc-before.HeapAlloc) / int64(sample)
+	}
+	runtime.KeepAlive(pop)
+
+	t = time.Now()
+	for _, c := range pop {
+		series.CanonicalKey(c)
+	}
+	r.Keys = per(time.Since(t), sample)
+
+	var genomeBytes int
+	for _, c := range pop {
+		genomeBytes += len(series.EncodeCandidate(c)) + 24 // slice header
+	}
+	r.GenomeBytes = int64(genomeBytes / sample)
+
+	// float64 prescreen, deciding which candidates go on to big.Float.
+	fitnesses := make([]series.Fitness, sample)
+	results := make([]series.EvalResult, sample)
+	threshold := e.cfg.F64PromotionThreshold
+	var promoted, rest []int
+	if threshold > 0 {
+		t = time.Now()
+		for i, c := range pop {
+			func() {
+				defer recoverEval(c, &fitnesses[i], &results[i])
+				fitnesses[i] = e.fitnessF64(c, series.EvaluateCandidateF64(c, e.cfg.MaxTerms))
+			}()
+			if fitnesses[i].CorrectDigits >= threshold {
+				promoted = append(promoted, i)
+			} else {
+				rest = append(rest, i)
+			}
+		}
+		r.F64 = per(time.Since(t), sample)
+		r.PromoteRate = float64(len(promoted)) / float64(sample)
+	} else {
+		for i := range pop {
+			promoted = append(promoted, i)
+		}
+		r.PromoteRate = 1
+	}
+
+	// big.Float on promoted candidates first, then others, so the cost is
+	// measured even if the sample promotes none.
+	bigSample := append(promoted, rest...)
+	if len(bigSample) > maxEstimateBigFloat {
+		bigSample = bigSample[:maxEstimateBigFloat]
+	}
+	t = time.Now()
+	for _, i := range bigSample {
+		c := pop[i]
+		func() {
+			defer recoverEval(c, &fitnesses[i], &results[i])
+			fitnesses[i], _ = e.evaluate(c)
+		}()
+	}
+	r.BigFloat = per(time.Since(t), len(bigSample))
+
+	failed := 0
+	for _, f := range fitnesses {
+		if f.Combined <= series.WorstFitness().Combined {
+			failed++
+		}
+	}
+	r.FailRate = float64(failed) / float64(sample)
+
+	t = time.Now()
+	e.strategy.Evolve(pop, fitnesses, e.pool, e.rng)
+	r.Breed = per(time.Since(t), sample)
+
+	// Project to the full population.
+	n := time.Duration(e.cfg.Population)
+	workers := time.Duration(max(e.cfg.Workers, 1))
+	perEval := r.Keys + r.F64 + time.Duration(r.PromoteRate*float64(r.BigFloat))
+	eval := n * perEval / workers
+	if b := e.cfg.GenerationBudget; b > 0 && eval > b {
+		r.DeferRate = 1 - float64(b)/float64(eval)
+		eval = b
+	}
+	r.Generation = eval + n*r.Breed
+	if r.Generation > 0 {
+		r.GenerationsPerHour = float64(time.Hour) / float64(r.Generation)
+	}
+
+	// Two generations are live while the next is bred.
+	popBytes := 2 * int64(e.cfg.Population) * r.CandidateBytes
+	if e.cfg.StreamBatch > 0 {
+		popBytes = 2*int64(e.cfg.Population)*r.GenomeBytes + int64(e.cfg.StreamBatch)*r.CandidateBytes
+	}
+	r.MemoryBytes = popBytes + int64(e.cfg.ArchiveSize)*(r.CandidateBytes+archiveEntryBytes)
+	if e.failed != nil {
+		r.MemoryBytes += int64(e.cfg.FailTabuSize) * tabuEntryBytes
+	}
+	return r
+}
+
+// WriteEstimate writes an estimate in human-readable format.
+func WriteEstimate(w io.Writer, cfg Config, r EstimateReport) {
+	fmt.Fprintf(w, "Estimate from %d sample candidates (per candidate, one worker):\n", r.Sample)
+	fmt.Fprintf(w, "  init      %v\n", r.Init)
+	fmt.Fprintf(w, "  keys      %v\n", r.Keys)
+	if cfg.F64PromotionThreshold > 0 {
+		fmt.Fprintf(w, "  float64   %v (%.1f%% promoted)\n", r.F64, 100*r.PromoteRate)
+	}
+	fmt.Fprintf(w, "  %-9s %v (%d terms, %d bits)\n", cfg.Evaluator, r.BigFloat, cfg.MaxTerms, cfg.Precision)
+	fmt.Fprintf(w, "  breed     %v\n", r.Breed)
+	fmt.Fprintf(w, "  failed    %.1f%%\n", 100*r.FailRate)
+	fmt.Fprintf(w, "Projected for population %d on %d workers:\n", cfg.Population, cfg.Workers)
+	fmt.Fprintf(w, "  %v per generation, %.0f generations/hour\n", r.Generation.Round(time.Millisecond), r.GenerationsPerHour)
+	if r.DeferRate > 0 {
+		fmt.Fprintf(w, "  %.1f%% of candidates deferred by the %v generation budget\n", 100*r.DeferRate, cfg.GenerationBudget)
+	}
+	fmt.Fprintf(w, "  %.1f MiB (%d bytes/candidate, %d/genome)\n", float64(r.MemoryBytes)/(1<<20), r.CandidateBytes, r.GenomeBytes)
+	if cfg.MemoryLimit > 0 && r.MemoryBytes > int64(cfg.MemoryLimit)<<20 {
+		fmt.Fprintf(w, "  over the %d MiB -memlimit: the run will evict caches and shrink the archive to stay under it\n", cfg.MemoryLimit)
+	}
+}
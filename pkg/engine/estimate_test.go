@@ -0,0 +1,43 @@
+package engine
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestEstimate(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.MaxTerms = 64
+	cfg.Seed = 1
+	cfg.Workers = 2
+
+	project := func(population int) EstimateReport {
+		cfg.Population = population
+		e, err := New(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return e.Estimate(40)
+	}
+	small, large := project(100), project(10000)
+
+	if small.BigFloat <= 0 || small.Breed <= 0 || small.Keys <= 0 {
+		t.Errorf("phase timings not measured: %+v", small)
+	}
+	if small.CandidateBytes <= 0 || small.GenomeBytes <= 0 {
+		t.Errorf("sizes not measured: %+v", small)
+	}
+	if large.Generation <= small.Generation || large.GenerationsPerHour >= small.GenerationsPerHour {
+		t.Errorf("projection does not grow with population: %v vs %v", small.Generation, large.Generation)
+	}
+	if large.MemoryBytes <= small.MemoryBytes {
+		t.Errorf("memory does not grow with population: %d vs %d", small.MemoryBytes, large.MemoryBytes)
+	}
+
+	var buf bytes.Buffer
+	WriteEstimate(&buf, cfg, large)
+	if !strings.Contains(buf.String(), "generations/hour") {
+		t.Errorf("text estimate missing projection:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,172 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/wildfunctions/genetic_series/pkg/series"
+)
+
+// Event is one entry of a run's event stream: what Run prints, what it
+// appends to Config.EventLog, and what OnEvent hooks receive. Which of the
+// optional fields are set depends on Type.
+type Event struct {
+	Type             string        `json:"type"`
+	Time             time.Time     `json:"time"`
+	Elapsed          time.Duration `json:"elapsed"` // since the run started
+	RunID            string        `json:"run_id"`
+	Attempt          int           `json:"attempt,omitempty"`
+	Generation       int           `json:"generation"`        // within the attempt
+	TotalGenerations int           `json:"total_generations"` // across all attempts, before this one
+
+	Provenance  *series.Provenance `json:"provenance,omitempty"`   // EventRunStart
+	RunSpec     string             `json:"run_spec,omitempty"`     // EventRunStart
+	Report      *GenerationReport  `json:"report,omitempty"`       // EventGeneration: the generation; EventNewBest: the new best
+	AttemptBest *GenerationReport  `json:"attempt_best,omitempty"` // EventGeneration: the attempt's best so far, from the generation that found it
+	RunnerUp    *GenerationReport  `json:"runner_up,omitempty"`    // EventGeneration, EventNewBest: the generation's second best, if it evaluated
+	Stats       *GenerationStats   `json:"stats,omitempty"`        // EventGeneration
+	Result      *AttemptResult     `json:"result,omitempty"`       // EventAttemptEnd
+	Discovery   *Discovery         `json:"discovery,omitempty"`    // EventDiscovery
+	StopReason  string             `json:"stop_reason,omitempty"`  // EventRunEnd
+}
+
+// Event types, in the order a run emits them: one EventRunStart, then per
+// attempt an EventGeneration per generation, preceded by an EventNewBest
+// when the generation improved on the attempt, and an EventAttemptEnd;
+// EventDiscovery whenever a candidate passes verification; and last one
+// EventRunEnd.
+const (
+	EventRunStart   = "run_start"
+	EventGeneration = "generation"
+	EventNewBest    = "new_best"
+	EventAttemptEnd = "attempt_end"
+	EventDiscovery  = "discovery"
+	EventRunEnd     = "run_end"
+)
+
+// GenerationStats counts what the engine did with a generation, besides
+// scoring it.
+type GenerationStats struct {
+	Population  int   `json:"population"`
+	Deferred    int   `json:"deferred,omitempty"`     // by the generation time budget
+	Screened    int   `json:"screened,omitempty"`     // out by the surrogate
+	Failed      int   `json:"failed,omitempty"`       // evaluations that panicked
+	Injected    int   `json:"injected,omitempty"`     // seeds from the inbox
+	Carried     int   `json:"carried,omitempty"`      // evaluations skipped by carrying elites over, this attempt
+	Degenerate  int   `json:"degenerate,omitempty"`   // failed unevaluated by the trig probe (Config.TrigProbe), this attempt
+	Archive     int   `json:"archive,omitempty"`      // candidates in the archive
+	FailureTabu int   `json:"failure_tabu,omitempty"` // structures in the failure tabu
+	Memory      int64 `json:"memory,omitempty"`       // accounted bytes (see MemoryUsage), with Config.MemoryLimit set
+}
+
+// OnEvent adds f to the consumers of the run's event stream, after the
+// text output and Config.EventLog. f is called synchronously, from the
+// search loop or, for EventDiscovery, the verifier; calls never overlap.
+// Set it before Run.
+func (e *Engine) OnEvent(f func(Event)) {
+	e.eventHooks = append(e.eventHooks, f)
+}
+
+// events delivers a run's events to its consumers in turn.
+type events struct {
+	mu    sync.Mutex
+	runID string
+	start time.Time
+	sinks []func(Event)
+	log   *os.File // Config.EventLog, or nil
+}
+
+// newEvents opens the stream for a run: the text view on w, the event log
+// at path if not empty, then hooks.
+func newEvents(w io.Writer, verbose bool, path string, runID string, hooks []func(Event)) *events {
+	ev := &events{runID: runID, start: time.Now()}
+	text := &textEvents{w: w, verbose: verbose}
+	ev.sinks = append(ev.sinks, text.write)
+	if path != "" {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error opening event log: %v\n", err)
+		} else {
+			ev.log = f
+			ev.sinks = append(ev.sinks, ev.append)
+		}
+	}
+	ev.sinks = append(ev.sinks, hooks...)
+	return ev
+}
+
+// emit stamps e and passes it to every consumer.
+func (ev *events) emit(e Event) {
+	ev.mu.Lock()
+	defer ev.mu.Unlock()
+	e.Time = time.Now().UTC()
+	e.Elapsed = time.Since(ev.start)
+	e.RunID = ev.runID
+	for _, f := range ev.sinks {
+		f(e)
+	}
+}
+
+// append writes e to the event log as one JSON line, in a single write, so
+// a reader tailing the file sees whole lines and runs appending to the
+// same file interleave by line.
+func (ev *events) append(e Event) {
+	data, err := json.Marshal(e)
+	if err == nil {
+		_, err = ev.log.Write(append(data, '\n'))
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error writing event log: %v\n", err)
+	}
+}
+
+func (ev *events) close() {
+	if ev.log != nil {
+		ev.log.Close()
+	}
+}
+
+// textEvents is the terminal view of the stream: with verbose, every
+// generation's report; otherwise each new best, and the attempt's best
+// every 20 generations without one.
+type textEvents struct {
+	w        io.Writer
+	verbose  bool
+	improved bool // the generation being reported had an EventNewBest
+}
+
+func (t *textEvents) write(e Event) {
+	switch e.Type {
+	case EventNewBest:
+		t.improved = true
+		if t.verbose {
+			return
+		}
+		fmt.Fprintf(t.w, "[gen %d] NEW BEST %.1f digits | fitness %.4f\n",
+			e.Generation, e.Report.BestFitness.CorrectDigits, e.Report.BestFitness.Combined)
+		fmt.Fprintf(t.w, "  #1: %s\n", e.Report.BestCandidate)
+		t.writeRunnerUp(e.RunnerUp)
+	case EventGeneration:
+		improved := t.improved
+		t.improved = false
+		if t.verbose {
+			WriteTextReport(t.w, *e.Report)
+		} else if !improved && e.Generation%20 == 0 {
+			fmt.Fprintf(t.w, "[gen %d]\n", e.Generation)
+			if b := e.AttemptBest; b != nil {
+				fmt.Fprintf(t.w, "  #1: %.1f digits | %s\n", b.BestFitness.CorrectDigits, b.BestCandidate)
+			}
+			t.writeRunnerUp(e.RunnerUp)
+		}
+	}
+}
+
+func (t *textEvents) writeRunnerUp(r *GenerationReport) {
+	if r != nil {
+		fmt.Fprintf(t.w, "  #2: %.1f digits | %s\n", r.BestFitness.CorrectDigits, r.BestCandidate)
+	}
+}
@@ -0,0 +1,37 @@
+package engine_test
+
+import (
+	"fmt"
+
+	"github.com/wildfunctions/genetic_series/pkg/engine"
+)
+
+func ExampleNew() {
+	cfg := engine.DefaultConfig()
+	cfg.Target = "e"
+	cfg.Strategy = "consttune"
+	cfg.SeedFormula = `\sum_{n=0}^{\infty} \frac{2}{n!}`
+	cfg.Population = 16
+	cfg.Generations = 5 // or until the default 50 digits
+	cfg.MaxTerms = 64
+	cfg.Seed = 1
+	cfg.Workers = 1
+	cfg.DiscoveryDigits = 0 // no background re-verification
+
+	e, err := engine.New(cfg)
+	if err != nil {
+		panic(err)
+	}
+	generations := 0
+	e.OnEvent(func(ev engine.Event) {
+		if ev.Type == engine.EventGeneration {
+			generations++
+		}
+	})
+	r := e.Run() // progress goes to stderr
+	fmt.Println(generations, r.BestLaTeX)
+	fmt.Println(r.StopReason)
+	// Output:
+	// 2 \sum_{n=0}^{\infty} \frac{1}{{n}!}
+	// digits >= 50 (89.53097960156903)
+}
@@ -0,0 +1,85 @@
+package engine
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/wildfunctions/genetic_series/pkg/constants"
+	"github.com/wildfunctions/genetic_series/pkg/series"
+)
+
+// exploration is the target set of an exploration run ("any" or
+// "any:pi,e,..."): candidates are scored against whichever constant they
+// come closest to, and matches past series.MatchThreshold record it.
+type exploration struct {
+	targets []constants.Target
+	at      []*big.Float // targets at the search's precision
+	f64     []float64
+}
+
+// parseExploration returns the exploration for a target spec, or nil if
+// spec is not one. "any" is every registered constant; "any:" takes a
+// comma-separated list of target specs (see constants.ParseTarget).
+func parseExploration(spec string, prec uint) (*exploration, error) {
+	var specs []string
+	switch {
+	case spec == "any":
+		specs = constants.Names()
+		sort.Strings(specs)
+	case strings.HasPrefix(spec, "any:"):
+		specs = strings.Split(strings.TrimPrefix(spec, "any:"), ",")
+	default:
+		return nil, nil
+	}
+	x := &exploration{}
+	for _, s := range specs {
+		t, err := constants.ParseTarget(strings.TrimSpace(s))
+		if err != nil {
+			return nil, fmt.Errorf("invalid target %q: %w", s, err)
+		}
+		at := t.At(prec)
+		f64, _ := at.Float64()
+		x.targets = append(x.targets, t)
+		x.at = append(x.at, at)
+		x.f64 = append(x.f64, f64)
+	}
+	return x, nil
+}
+
+// matched records in f the constant at index i if c clears its threshold.
+func (x *exploration) matched(c *series.Candidate, f series.Fitness, i int) series.Fitness {
+	if i >= 0 && f.CorrectDigits >= series.MatchThreshold(c) {
+		f.Match = x.targets[i].String()
+	}
+	return f
+}
+
+func (x *exploration) fitness(c *series.Candidate, result series.EvalResult, w series.FitnessWeights) series.Fitness {
+	f, i := series.ComputeBestFitness(c, result, x.at, w)
+	return x.matched(c, f, i)
+}
+
+func (x *exploration) fitnessF64(c *series.Candidate, result series.EvalResultF64, w series.FitnessWeights) series.Fitness {
+	f, i := series.ComputeBestFitnessF64(c, result, x.f64, w)
+	return x.matched(c, f, i)
+}
+
+// target returns the constant named name.
+func (x *exploration) target(name string) constants.Target {
+	for _, t := range x.targets {
+		if t.String() == name {
+			return t
+		}
+	}
+	return nil
+}
+
+func (x *exploration) String() string {
+	names := make([]string, len(x.targets))
+	for i, t := range x.targets {
+		names[i] = t.String()
+	}
+	return strings.Join(names, ", ")
+}
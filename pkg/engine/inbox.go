@@ -0,0 +1,133 @@
+package engine
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/wildfunctions/genetic_series/pkg/series"
+)
+
+// inboxExts are the file extensions the inbox reads. Writers should create
+// a file under another name (or outside the directory) and rename it in,
+// so that a half-written file is never picked up.
+var inboxExts = map[string]bool{".tex": true, ".txt": true}
+
+// inboxDoneSuffix is appended to a file's name once it has been read.
+const inboxDoneSuffix = ".done"
+
+// inbox is a directory of seed formulas dropped in while a run is going,
+// from a human or another tool. Each file holds one LaTeX series per line
+// (see series.ParseCandidateLatex); blank lines and lines starting with %
+// or # are skipped.
+type inbox struct {
+	dir string
+}
+
+func newInbox(dir string) (*inbox, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("creating inbox: %w", err)
+	}
+	return &inbox{dir: dir}, nil
+}
+
+// poll reads every new file in the inbox, in name order, renames it with
+// inboxDoneSuffix so it is read only once, and returns the candidates it
+// held. Lines that fail to parse are reported and skipped.
+func (ib *inbox) poll() []*series.Candidate {
+	entries, err := os.ReadDir(ib.dir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[inbox] %v\n", err)
+		return nil
+	}
+	var names []string
+	for _, ent := range entries {
+		if ent.Type().IsRegular() && inboxExts[filepath.Ext(ent.Name())] {
+			names = append(names, ent.Name())
+		}
+	}
+	sort.Strings(names)
+
+	var seeds []*series.Candidate
+	for _, name := range names {
+		path := filepath.Join(ib.dir, name)
+		cs, err := readSeeds(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[inbox] %v\n", err)
+		}
+		seeds = append(seeds, cs...)
+		if err := os.Rename(path, path+inboxDoneSuffix); err != nil {
+			// Leaving it would inject the same seeds every generation.
+			fmt.Fprintf(os.Stderr, "[inbox] %v; removing %s\n", err, name)
+			os.Remove(path)
+		}
+	}
+	return seeds
+}
+
+// readSeeds parses the seed formulas in path. It returns the candidates
+// that parsed along with the first error, prefixed with its line number.
+func readSeeds(path string) ([]*series.Candidate, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var seeds []*series.Candidate
+	var firstErr error
+	sc := bufio.NewScanner(f)
+	for line := 1; sc.Scan(); line++ {
+		s := strings.TrimSpace(sc.Text())
+		if s == "" || strings.HasPrefix(s, "%") || strings.HasPrefix(s, "#") {
+			continue
+		}
+		c, err := series.ParseCandidateLatex(s)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("%s:%d: %w", path, line, err)
+			}
+			continue
+		}
+		seeds = append(seeds, c)
+	}
+	if err := sc.Err(); err != nil && firstErr == nil {
+		firstErr = fmt.Errorf("%s: %w", path, err)
+	}
+	return seeds, firstErr
+}
+
+// inject evaluates seeds and puts them in place of the least fit members
+// of g, updating fitnesses and results to match, so that the strategy
+// breeds from them in the next generation. Elites are never displaced: at
+// most half the population is replaced. It returns the number injected.
+// The restart tabu set is not consulted, since a seed is a deliberate guess.
+func (e *Engine) inject(g generation, fitnesses []series.Fitness, results []series.EvalResult, seeds []*series.Candidate) int {
+	if n := g.len() / 2; len(seeds) > n {
+		fmt.Fprintf(os.Stderr, "[inbox] %d seeds, injecting the first %d\n", len(seeds), n)
+		seeds = seeds[:n]
+	}
+	if len(seeds) == 0 {
+		return 0
+	}
+	// Seeds are few, so they are not held to the generation budget.
+	f, r := e.evaluatePopulation(seeds, nil, time.Time{}, false)
+
+	worst := make([]int, g.len())
+	for i := range worst {
+		worst[i] = i
+	}
+	sort.SliceStable(worst, func(a, b int) bool {
+		return fitnesses[worst[a]].Combined < fitnesses[worst[b]].Combined
+	})
+	for k, c := range seeds {
+		i := worst[k]
+		g.set(i, c)
+		fitnesses[i], results[i] = f[k], r[k]
+	}
+	return len(seeds)
+}
@@ -0,0 +1,78 @@
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/wildfunctions/genetic_series/pkg/series"
+)
+
+func TestInboxPoll(t *testing.T) {
+	dir := t.TempDir()
+	ib, err := newInbox(filepath.Join(dir, "inbox"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	write := func(name, body string) {
+		if err := os.WriteFile(filepath.Join(ib.dir, name), []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("a.tex", "% guesses\n\\sum_{n=0}^{\\infty} \\frac{1}{n!}\n\n\\sum_{n oops\n")
+	write("b.txt", "# from another tool\n\\sum_{k=1}^{\\infty} \\frac{1}{k^2}\n")
+	write("c.json", "\\sum_{n=0}^{\\infty} \\frac{1}{2^n}\n")
+
+	seeds := ib.poll()
+	if len(seeds) != 2 {
+		t.Fatalf("poll returned %d seeds, want 2", len(seeds))
+	}
+	if got := seeds[1].String(); got != "Sum_{n=1}^{inf} (1) / ((n)^(2))" {
+		t.Errorf("second seed = %s", got)
+	}
+	for _, name := range []string{"a.tex.done", "b.txt.done", "c.json"} {
+		if _, err := os.Stat(filepath.Join(ib.dir, name)); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+	if seeds := ib.poll(); len(seeds) != 0 {
+		t.Errorf("second poll returned %d seeds, want 0", len(seeds))
+	}
+}
+
+func TestInjectReplacesWorst(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.MaxTerms = 128
+	e, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	seed, err := series.ParseCandidateLatex(`\sum_{n=0}^{\infty} \frac{1}{n!}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pop := make([]*series.Candidate, 4)
+	fitnesses := make([]series.Fitness, 4)
+	for i := range pop {
+		pop[i] = &series.Candidate{Numerator: seed.Numerator, Denominator: seed.Denominator, Start: int64(i + 5)}
+		fitnesses[i] = series.Fitness{Combined: float64(i)}
+	}
+	fitnesses[2].Combined = -5
+	g := generation{trees: pop}
+	results := make([]series.EvalResult, 4)
+
+	// Three seeds, but only half the population may be replaced.
+	if n := e.inject(g, fitnesses, results, []*series.Candidate{seed, seed, seed}); n != 2 {
+		t.Fatalf("injected %d, want 2", n)
+	}
+	if g.trees[2] != seed || g.trees[0] != seed {
+		t.Errorf("worst members not replaced: %v", g.trees)
+	}
+	if g.trees[1] == seed || g.trees[3] == seed {
+		t.Error("fitter members replaced")
+	}
+	if fitnesses[2].CorrectDigits < 30 || !results[2].OK {
+		t.Errorf("injected fitness %+v not evaluated", fitnesses[2])
+	}
+}
@@ -0,0 +1,200 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/wildfunctions/genetic_series/pkg/series"
+)
+
+// Leaderboard is the Config.Leaderboard file: the run's best distinct
+// candidates so far, rewritten every generation.
+type Leaderboard struct {
+	RunID       string             `json:"run_id"`
+	Target      string             `json:"target"`
+	Updated     time.Time          `json:"updated"`
+	Attempt     int                `json:"attempt"`
+	Generations int                `json:"generations"` // across all attempts
+	Entries     []LeaderboardEntry `json:"entries"`     // best first
+}
+
+// LeaderboardEntry is one candidate on the leaderboard.
+type LeaderboardEntry struct {
+	Candidate  string         `json:"candidate"`
+	LaTeX      string         `json:"latex"`
+	Canonical  string         `json:"canonical"`
+	Fitness    series.Fitness `json:"fitness"`
+	PartialSum string         `json:"partial_sum,omitempty"`
+	Attempt    int            `json:"attempt"`
+	Generation int            `json:"generation"` // within the attempt, when it first scored this well
+
+	// Genome is the candidate's series.EncodeCandidate, none for a ratio.
+	// Rescore falls back on it when LaTeX does not parse, as LaTeX printed
+	// by an older release need not.
+	Genome series.Genome `json:"genome,omitempty"`
+}
+
+// leaderboard keeps the top k candidates by fitness, one per canonical key,
+// and writes them to path as JSON and beside it as a LaTeX snippet.
+type leaderboard struct {
+	path  string
+	k     int
+	board Leaderboard
+}
+
+func newLeaderboard(path string, k int, prov series.Provenance, target string) *leaderboard {
+	return &leaderboard{path: path, k: max(k, 1), board: Leaderboard{RunID: prov.RunID, Target: target}}
+}
+
+// update offers a generation's scored candidates; deferred and failed ones
+// are left out. Only those that would make the board are keyed, so a full
+// board costs a comparison per candidate.
+func (lb *leaderboard) update(pop generation, fitnesses []series.Fitness, results []series.EvalResult, attempt, gen int) {
+	if lb == nil {
+		return
+	}
+	worst := series.WorstFitness().Combined
+	changed := false
+	for i, f := range fitnesses {
+		if f.Deferred || f.Combined <= worst {
+			continue
+		}
+		entries := lb.board.Entries
+		if len(entries) == lb.k && f.Combined <= entries[len(entries)-1].Fitness.Combined {
+			continue
+		}
+		c := pop.at(i)
+		if lb.offer(series.CanonicalKey(c), c, f, results[i], attempt, gen) {
+			changed = true
+		}
+	}
+	if changed {
+		sort.SliceStable(lb.board.Entries, func(a, b int) bool {
+			return lb.board.Entries[a].Fitness.Combined > lb.board.Entries[b].Fitness.Combined
+		})
+		if len(lb.board.Entries) > lb.k {
+			lb.board.Entries = lb.board.Entries[:lb.k]
+		}
+	}
+}
+
+// offer adds c under key, or replaces the entry for key if c scores
+// better, and reports whether the board changed.
+func (lb *leaderboard) offer(key string, c *series.Candidate, f series.Fitness, r series.EvalResult, attempt, gen int) bool {
+	entry := LeaderboardEntry{
+		Candidate:  c.String(),
+		LaTeX:      c.LaTeX(),
+		Canonical:  key,
+		Fitness:    f,
+		Attempt:    attempt,
+		Generation: gen,
+	}
+	if c.Over == nil {
+		entry.Genome = series.EncodeCandidate(c)
+	}
+	if r.OK && r.PartialSum != nil {
+		entry.PartialSum = r.PartialSum.Text('g', 20)
+	}
+	for i, old := range lb.board.Entries {
+		if old.Canonical == key {
+			if f.Combined <= old.Fitness.Combined {
+				return false
+			}
+			lb.board.Entries[i] = entry
+			return true
+		}
+	}
+	lb.board.Entries = append(lb.board.Entries, entry)
+	return true
+}
+
+// write saves the board after generations generations, in the middle of
+// attempt.
+func (lb *leaderboard) write(attempt, generations int) {
+	if lb == nil {
+		return
+	}
+	lb.board.Attempt = attempt
+	lb.board.Generations = generations
+	lb.board.Updated = time.Now().UTC()
+
+	if err := WriteLeaderboard(lb.path, lb.board); err != nil {
+		fmt.Fprintf(os.Stderr, "error writing leaderboard: %v\n", err)
+	}
+}
+
+// LoadLeaderboard reads a Config.Leaderboard file.
+func LoadLeaderboard(path string) (Leaderboard, error) {
+	var b Leaderboard
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return b, err
+	}
+	if err := json.Unmarshal(data, &b); err != nil {
+		return b, fmt.Errorf("%s: %w", path, err)
+	}
+	return b, nil
+}
+
+// WriteLeaderboard writes b to path as JSON and beside it as a LaTeX
+// snippet, as a run's Config.Leaderboard is written.
+func WriteLeaderboard(path string, b Leaderboard) error {
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(path, append(data, '\n')); err != nil {
+		return err
+	}
+	var tex strings.Builder
+	writeLeaderboardLatex(&tex, b)
+	return writeFileAtomic(leaderboardTexPath(path), []byte(tex.String()))
+}
+
+// leaderboardTexPath is where the LaTeX snippet of the leaderboard at path
+// goes: path with its extension replaced by .tex.
+func leaderboardTexPath(path string) string {
+	return strings.TrimSuffix(path, filepath.Ext(path)) + ".tex"
+}
+
+// writeLeaderboardLatex writes b as a LaTeX fragment, for \input into a
+// document that loads amsmath.
+func writeLeaderboardLatex(w io.Writer, b Leaderboard) {
+	fmt.Fprintf(w, "%% Leaderboard for %s: run %s, attempt %d, %d generations, %s\n",
+		b.Target, b.RunID, b.Attempt, b.Generations, b.Updated.Format("2006-01-02 15:04:05 UTC"))
+	if len(b.Entries) == 0 {
+		return
+	}
+	fmt.Fprintln(w, `\begin{enumerate}`)
+	for _, e := range b.Entries {
+		fmt.Fprintf(w, "  \\item %.1f digits (attempt %d, gen %d)\n", e.Fitness.CorrectDigits, e.Attempt, e.Generation)
+		fmt.Fprintf(w, "  \\[ %s \\]\n", e.LaTeX)
+	}
+	fmt.Fprintln(w, `\end{enumerate}`)
+}
+
+// writeFileAtomic writes data to path through a temporary file renamed
+// into place, so a reader never sees a half-written file.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
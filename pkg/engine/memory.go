@@ -0,0 +1,105 @@
+package engine
+
+import (
+	"fmt"
+	"runtime/debug"
+	"strings"
+
+	"github.com/wildfunctions/genetic_series/pkg/expr"
+	"github.com/wildfunctions/genetic_series/pkg/series"
+)
+
+// Heap sizes the memory accounting charges, besides archiveEntryBytes and
+// tabuEntryBytes. They are approximate: a node is a header, op and two
+// child interfaces; a simplify cache entry is its map slot and key plus
+// the part of its trees no candidate shares.
+const (
+	nodeBytes          = 48
+	candidateBytes     = 64 // a Candidate besides its trees
+	genomeBytes        = 24 // a Genome's slice header, besides its bytes
+	simplifyEntryBytes = 320
+)
+
+// minArchiveCap is the capacity below which reclaimMemory stops shrinking
+// the archive.
+const minArchiveCap = 32
+
+// MemoryUsage is the approximate heap, in bytes, held by the state of a
+// run that grows with its size: the current generation, the archive, the
+// failure tabu and the shared simplify cache. Evaluation scratch, the
+// generation being bred and the Go runtime's own overhead are not
+// counted, so the process uses more than Total.
+type MemoryUsage struct {
+	Population    int64 `json:"population"`
+	Archive       int64 `json:"archive"`
+	FailureTabu   int64 `json:"failure_tabu"`
+	SimplifyCache int64 `json:"simplify_cache"`
+}
+
+// Total is the sum of the parts.
+func (m MemoryUsage) Total() int64 {
+	return m.Population + m.Archive + m.FailureTabu + m.SimplifyCache
+}
+
+// treeBytes is what the accounting charges for a candidate held as trees.
+func treeBytes(c *series.Candidate) int64 {
+	return candidateBytes + int64(c.NodeCount())*nodeBytes
+}
+
+// memoryUsage accounts g and the engine's long-lived state.
+func (e *Engine) memoryUsage(g generation) MemoryUsage {
+	var m MemoryUsage
+	for _, c := range g.trees {
+		m.Population += treeBytes(c)
+	}
+	for _, gn := range g.genomes {
+		m.Population += genomeBytes + int64(len(gn))
+	}
+	if e.archive != nil {
+		m.Archive = int64(e.archive.NodeCount())*nodeBytes + int64(e.archive.Len())*(candidateBytes+archiveEntryBytes)
+	}
+	m.FailureTabu = int64(e.failed.Len()) * tabuEntryBytes
+	m.SimplifyCache = int64(expr.SimplifyCacheStats().Entries) * simplifyEntryBytes
+	return m
+}
+
+// reclaimMemory accounts g and the engine's state and, if that is over
+// Config.MemoryLimit, frees what it can, cheapest loss first: the simplify
+// cache, which refills from the trees that come up again; the failure
+// tabu's expired keys; then the worse half of the archive, once per call
+// while the total stays over, down to minArchiveCap. It returns the usage
+// after reclaiming and what was freed ("" if nothing was, or if the limit
+// is off).
+func (e *Engine) reclaimMemory(g generation) (MemoryUsage, string) {
+	m := e.memoryUsage(g)
+	limit := int64(e.cfg.MemoryLimit) << 20
+	if limit <= 0 || m.Total() <= limit {
+		return m, ""
+	}
+	before := m.Total()
+	var freed []string
+	if n := expr.EvictSimplifyCache(); n > 0 {
+		freed = append(freed, fmt.Sprintf("evicted %d simplify cache entries", n))
+	}
+	if n := e.failed.Compact(); n > 0 {
+		freed = append(freed, fmt.Sprintf("compacted the failure tabu by %d expired structures", n))
+	}
+	m = e.memoryUsage(g)
+	if e.archive != nil && m.Total() > limit {
+		if c := e.archive.Cap() / 2; c >= minArchiveCap {
+			n := e.archive.Shrink(c)
+			freed = append(freed, fmt.Sprintf("shrank the archive to %d candidates (%d dropped)", c, n))
+			m = e.memoryUsage(g)
+		}
+	}
+	if len(freed) == 0 {
+		return m, ""
+	}
+	debug.FreeOSMemory()
+	summary := fmt.Sprintf("~%.1f MiB over the %d MiB limit: %s; ~%.1f MiB now",
+		float64(before-limit)/(1<<20), e.cfg.MemoryLimit, strings.Join(freed, ", "), float64(m.Total())/(1<<20))
+	if m.Total() > limit {
+		summary += " (still over: lower -population or use -stream)"
+	}
+	return m, summary
+}
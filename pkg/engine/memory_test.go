@@ -0,0 +1,84 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/wildfunctions/genetic_series/pkg/expr"
+	"github.com/wildfunctions/genetic_series/pkg/series"
+)
+
+func TestReclaimMemory(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Population = 50
+	cfg.Seed = 4
+	cfg.ArchiveSize = 4096
+	e, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := e.initialGeneration()
+	for k := int64(1); k <= 4096; k++ {
+		c := &series.Candidate{
+			Numerator:   &expr.ConstNode{Val: 1},
+			Denominator: &expr.BinaryNode{Op: expr.OpAdd, Left: &expr.VarNode{}, Right: &expr.ConstNode{Val: k}},
+		}
+		e.archive.Add(c, series.Fitness{Combined: float64(k)})
+	}
+
+	if _, freed := e.reclaimMemory(g); freed != "" {
+		t.Errorf("reclaimed with the limit off: %s", freed)
+	}
+	before := e.memoryUsage(g)
+	if before.Population == 0 || before.Archive == 0 {
+		t.Fatalf("usage %+v does not count the population and archive", before)
+	}
+
+	e.cfg.MemoryLimit = 1 // MiB; the archive alone is over it
+	after, freed := e.reclaimMemory(g)
+	if !strings.Contains(freed, "shrank the archive to 2048") {
+		t.Errorf("reclaimMemory: %q, want the archive halved", freed)
+	}
+	if after.Archive >= before.Archive || e.archive.Len() != 2048 {
+		t.Errorf("archive %d bytes, %d candidates after reclaiming; was %d bytes", after.Archive, e.archive.Len(), before.Archive)
+	}
+	if best := e.archive.Best(1); best[0].Fitness.Combined != 4096 {
+		t.Errorf("shrinking dropped the best candidate, kept %v", best[0].Fitness.Combined)
+	}
+	if got := expr.SimplifyCacheStats().Entries; got != 0 {
+		t.Errorf("%d simplify cache entries left", got)
+	}
+
+	e.cfg.MemoryLimit = 1 << 20
+	if _, freed := e.reclaimMemory(g); freed != "" {
+		t.Errorf("reclaimed under the limit: %s", freed)
+	}
+}
+
+func TestEngine_MemoryLimit(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Population = 30
+	cfg.Generations = 4
+	cfg.MaxTerms = 64
+	cfg.Seed = 5
+	cfg.MemoryLimit = 1
+	e, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var accounted []int64
+	e.OnEvent(func(ev Event) {
+		if ev.Stats != nil {
+			accounted = append(accounted, ev.Stats.Memory)
+		}
+	})
+	e.Run()
+	if len(accounted) == 0 || accounted[0] == 0 {
+		t.Errorf("generation stats report memory %v", accounted)
+	}
+
+	cfg.MemoryLimit = -1
+	if _, err := New(cfg); err == nil {
+		t.Error("New accepted a negative memory limit")
+	}
+}
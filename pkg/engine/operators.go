@@ -0,0 +1,132 @@
+package engine
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+
+	"github.com/wildfunctions/genetic_series/pkg/series"
+	"github.com/wildfunctions/genetic_series/pkg/strategy"
+)
+
+// OperatorStats is how the offspring of one operator of the strategy (see
+// strategy.Origin) fared over a run. An offspring is Scored when it and
+// the parent it is compared with were both evaluated and neither failed;
+// its delta is its Combined fitness less the parent's.
+type OperatorStats struct {
+	Op        string                  `json:"op"`
+	Offspring int                     `json:"offspring"`
+	Failed    int                     `json:"failed"`             // scored the worst fitness
+	Deferred  int                     `json:"deferred,omitempty"` // not evaluated: time budget or surrogate
+	Scored    int                     `json:"scored"`
+	Improved  int                     `json:"improved"` // scored with a positive delta
+	MeanDelta float64                 `json:"mean_delta"`
+	MaxDelta  float64                 `json:"max_delta"`
+	Deltas    [deltaEdgeCount + 1]int `json:"deltas"` // scored offspring by delta, in the bins of DeltaBinLabels
+}
+
+// DeltaBinLabels name the bins of OperatorStats.Deltas. A delta on an edge
+// goes to the bin nearer 0, and only an unchanged fitness to "0".
+var DeltaBinLabels = [deltaEdgeCount + 1]string{"<-10", "-10..-1", "-1..-0.1", "-0.1..0", "0", "0..0.1", "0.1..1", "1..10", ">10"}
+
+// deltaEdges are the edges between the Deltas bins, 0 on both sides of
+// the "0" bin.
+var deltaEdges = [deltaEdgeCount]float64{-10, -1, -0.1, 0, 0, 0.1, 1, 10}
+
+const deltaEdgeCount = 8
+
+func deltaBin(d float64) int {
+	switch {
+	case d == 0:
+		return 4
+	case d < 0:
+		return sort.Search(4, func(i int) bool { return d < deltaEdges[i] })
+	}
+	return 5 + sort.Search(3, func(i int) bool { return d <= deltaEdges[5+i] })
+}
+
+// countOperators adds the offspring of the generation just evaluated to
+// the run's OperatorStats, by the origins the strategy reported when it
+// bred them. It does nothing for the initial generation, or for a
+// strategy that is not strategy.Attributing.
+func (e *Engine) countOperators(fitnesses []series.Fitness) {
+	if len(e.origins) != len(fitnesses) {
+		return
+	}
+	if e.operators == nil {
+		e.operators = map[string]*OperatorStats{}
+	}
+	worst := series.WorstFitness().Combined
+	for i, o := range e.origins {
+		st := e.operators[o.Op]
+		if st == nil {
+			st = &OperatorStats{Op: o.Op, MaxDelta: math.Inf(-1)}
+			e.operators[o.Op] = st
+		}
+		st.Offspring++
+		f := fitnesses[i]
+		switch {
+		case f.Deferred:
+			st.Deferred++
+			continue
+		case f.Combined <= worst:
+			st.Failed++
+			continue
+		case math.IsNaN(o.Parent) || o.Parent <= worst:
+			continue
+		}
+		d := f.Combined - o.Parent
+		st.Scored++
+		if d > 0 {
+			st.Improved++
+		}
+		st.MeanDelta += (d - st.MeanDelta) / float64(st.Scored)
+		st.MaxDelta = max(st.MaxDelta, d)
+		st.Deltas[deltaBin(d)]++
+	}
+}
+
+// operatorStats returns the run's OperatorStats, most offspring first.
+func (e *Engine) operatorStats() []OperatorStats {
+	var stats []OperatorStats
+	for _, st := range e.operators {
+		s := *st
+		if s.Scored == 0 {
+			s.MaxDelta = 0
+		}
+		stats = append(stats, s)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Offspring != stats[j].Offspring {
+			return stats[i].Offspring > stats[j].Offspring
+		}
+		return stats[i].Op < stats[j].Op
+	})
+	return stats
+}
+
+// setOrigins records the origins of the generation the strategy just bred,
+// if it reports them.
+func (e *Engine) setOrigins() {
+	e.origins = nil
+	if a, ok := e.strategy.(strategy.Attributing); ok {
+		e.origins = append(e.origins, a.Origins()...)
+	}
+}
+
+// WriteOperators writes how each operator's offspring fared.
+func WriteOperators(w io.Writer, stats []OperatorStats) {
+	if len(stats) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "\n--- Operators (%d) ---\n", len(stats))
+	for _, s := range stats {
+		rate := 0.0
+		if s.Scored > 0 {
+			rate = 100 * float64(s.Improved) / float64(s.Scored)
+		}
+		fmt.Fprintf(w, "  %-10s %7d offspring | %6d failed | %5.1f%% of %d improved | mean Δ %+8.3f | max Δ %+8.3f\n",
+			s.Op, s.Offspring, s.Failed, rate, s.Scored, s.MeanDelta, s.MaxDelta)
+	}
+}
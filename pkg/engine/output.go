@@ -5,44 +5,83 @@ import (
 	"fmt"
 	"io"
 	"math/big"
+	"path/filepath"
 	"sort"
 	"strings"
 	"time"
+	"unicode"
 
+	"github.com/wildfunctions/genetic_series/pkg/archive"
+	"github.com/wildfunctions/genetic_series/pkg/constants"
 	"github.com/wildfunctions/genetic_series/pkg/series"
 )
 
 // GenerationReport summarizes one generation.
 type GenerationReport struct {
-	Generation    int            `json:"generation"`
-	BestFitness   series.Fitness `json:"best_fitness"`
-	BestCandidate string         `json:"best_candidate"`
-	BestLaTeX     string         `json:"best_latex,omitempty"`
-	AvgFitness    float64        `json:"avg_fitness"`
-	BestPartialSum string        `json:"best_partial_sum,omitempty"`
+	Generation     int            `json:"generation"`
+	BestFitness    series.Fitness `json:"best_fitness"`
+	BestCandidate  string         `json:"best_candidate"`
+	BestLaTeX      string         `json:"best_latex,omitempty"`
+	AvgFitness     float64        `json:"avg_fitness"`
+	BestPartialSum string         `json:"best_partial_sum,omitempty"`
+	Deferred       int            `json:"deferred,omitempty"` // candidates skipped by the generation time budget
 }
 
 // AttemptResult summarizes one restart attempt.
 type AttemptResult struct {
-	Attempt        int            `json:"attempt"`
-	Generations    int            `json:"generations"`
-	BestFoundAtGen int            `json:"best_found_at_gen"`
-	BestCandidate  string         `json:"best_candidate"`
-	BestLaTeX      string         `json:"best_latex"`
-	BestFitness    series.Fitness `json:"best_fitness"`
-	BestPartialSum string         `json:"best_partial_sum"`
-	Timestamp      time.Time      `json:"timestamp"`
+	Attempt        int               `json:"attempt"`
+	Generations    int               `json:"generations"`
+	BestFoundAtGen int               `json:"best_found_at_gen"`
+	BestCandidate  string            `json:"best_candidate"`
+	BestLaTeX      string            `json:"best_latex"`
+	BestFitness    series.Fitness    `json:"best_fitness"`
+	BestPartialSum string            `json:"best_partial_sum"`
+	Timestamp      time.Time         `json:"timestamp"`
+	Provenance     series.Provenance `json:"provenance"`
+	Identity       string            `json:"identity,omitempty"`  // closed form the partial sum matches, e.g. "π/4"
+	Canonical      string            `json:"canonical,omitempty"` // series.CanonicalKey of the best candidate
+	Discovery      bool              `json:"discovery,omitempty"` // best candidate passed the precision ladder
 }
 
 // FinalReport summarizes the entire run.
 type FinalReport struct {
-	Config        Config             `json:"config"`
-	Generations   []GenerationReport `json:"generations,omitempty"`
-	BestCandidate string             `json:"best_candidate"`
-	BestLaTeX     string             `json:"best_latex"`
-	BestFitness   series.Fitness     `json:"best_fitness"`
-	BestPartialSum string            `json:"best_partial_sum"`
-	Attempts      []AttemptResult    `json:"attempts,omitempty"`
+	Config         Config             `json:"config"`
+	Generations    []GenerationReport `json:"generations,omitempty"`
+	BestCandidate  string             `json:"best_candidate"`
+	BestLaTeX      string             `json:"best_latex"`
+	BestFitness    series.Fitness     `json:"best_fitness"`
+	BestPartialSum string             `json:"best_partial_sum"`
+	Attempts       []AttemptResult    `json:"attempts,omitempty"`
+	RunSpec        string             `json:"run_spec"` // FormatConfig(Config): feed to -config to rerun
+	Provenance     series.Provenance  `json:"provenance"`
+	Families       []Family           `json:"families,omitempty"`    // archive's best candidates grouped by similarity
+	Discoveries    []Discovery        `json:"discoveries,omitempty"` // candidates that reached DiscoveryDigits, with their ladders
+	StopReason     string             `json:"stop_reason"`           // the stop condition that ended the run, with the values that met it
+
+	TotalGenerations int           `json:"total_generations"` // across all attempts
+	Elapsed          time.Duration `json:"elapsed"`
+	Milestones       []Milestone   `json:"milestones,omitempty"` // when the run's best first reached each whole digit
+
+	Operators []OperatorStats `json:"operators,omitempty"` // how each of the strategy's operators did, most used first
+}
+
+// Milestone is the point in a run where its best candidate first reached
+// Digits correct digits, counting generations across attempts.
+type Milestone struct {
+	Digits     int           `json:"digits"`
+	Generation int           `json:"generation"`
+	Elapsed    time.Duration `json:"elapsed"`
+}
+
+// GenerationsTo returns the generation at which the run first reached
+// digits, or false if it never did.
+func (r FinalReport) GenerationsTo(digits float64) (int, bool) {
+	for _, m := range r.Milestones {
+		if float64(m.Digits) >= digits {
+			return m.Generation, true
+		}
+	}
+	return 0, false
 }
 
 // WriteTextReport writes a generation report in human-readable format.
@@ -60,6 +99,87 @@ func WriteAttemptSummary(w io.Writer, a AttemptResult) {
 
 const maxHallOfFame = 100
 
+// identityTol is the relative tolerance for suggesting a closed form for
+// an attempt's partial sum: loose enough for a 20-digit partial sum of a
+// slowly converging series, tight enough that small-denominator matches
+// are rarely coincidences.
+const identityTol = 1e-10
+
+// identityOf suggests a closed form for an attempt's best partial sum,
+// skipping the uninformative "it is the target".
+func identityOf(sum *big.Float, target string) string {
+	for _, id := range constants.Identify(sum, identityTol, 1000) {
+		if id.Base == target && id.P == 1 && id.Q == 1 {
+			continue
+		}
+		return id.String()
+	}
+	return ""
+}
+
+// Family is a group of structurally similar discoveries (see series.Cluster),
+// led by its best member.
+type Family struct {
+	Size          int            `json:"size"`
+	BestCandidate string         `json:"best_candidate"`
+	BestLaTeX     string         `json:"best_latex"`
+	BestFitness   series.Fitness `json:"best_fitness"`
+	Members       []string       `json:"members"` // best first, including the leader
+}
+
+// maxFamilyCandidates caps how many of the archive's best candidates are
+// clustered for the report, and maxFamiliesShown how many families the text
+// report lists.
+const (
+	maxFamilyCandidates = 256
+	maxFamiliesShown    = 10
+)
+
+// familiesOf clusters entries, which must be sorted best first.
+func familiesOf(entries []archive.Entry) []Family {
+	cands := make([]*series.Candidate, len(entries))
+	for i, e := range entries {
+		cands[i] = e.Candidate
+	}
+	var families []Family
+	for _, idx := range series.Cluster(cands, series.FamilyThreshold) {
+		lead := entries[idx[0]]
+		f := Family{
+			Size:          len(idx),
+			BestCandidate: lead.Key,
+			BestLaTeX:     lead.Candidate.LaTeX(),
+			BestFitness:   lead.Fitness,
+		}
+		for _, i := range idx {
+			f.Members = append(f.Members, entries[i].Key)
+		}
+		families = append(families, f)
+	}
+	return families
+}
+
+// WriteFamilies writes the largest families of a run's discoveries.
+func WriteFamilies(w io.Writer, families []Family) {
+	if len(families) == 0 {
+		return
+	}
+	sorted := make([]Family, len(families))
+	copy(sorted, families)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Size > sorted[j].Size })
+	if len(sorted) > maxFamiliesShown {
+		sorted = sorted[:maxFamiliesShown]
+	}
+	fmt.Fprintf(w, "\n--- Families (%d) ---\n", len(families))
+	for i, f := range sorted {
+		variants := "variant"
+		if f.Size != 1 {
+			variants = "variants"
+		}
+		fmt.Fprintf(w, "  #%d: %3d %-8s | best %5.1f digits | %s\n",
+			i+1, f.Size, variants, f.BestFitness.CorrectDigits, f.BestCandidate)
+	}
+}
+
 // sortByDigits returns a copy of attempts sorted by CorrectDigits descending.
 func sortByDigits(attempts []AttemptResult) []AttemptResult {
 	sorted := make([]AttemptResult, len(attempts))
@@ -74,21 +194,25 @@ func sortByDigits(attempts []AttemptResult) []AttemptResult {
 }
 
 // dedupAttempts removes duplicate candidates, keeping only the first (best) entry.
-// Deduplicates on both the candidate string AND the partial sum value, so algebraically
-// equivalent formulas with different tree structures are also caught.
+// Deduplicates on both the canonical key (see series.Canonical) AND the partial sum
+// value, so algebraically equivalent formulas with different tree structures are also caught.
 // Input must already be sorted by quality descending.
 func dedupAttempts(sorted []AttemptResult) []AttemptResult {
 	seenExpr := map[string]bool{}
 	seenSum := map[string]bool{}
 	result := make([]AttemptResult, 0, len(sorted))
 	for _, a := range sorted {
-		if seenExpr[a.BestCandidate] {
+		key := a.Canonical
+		if key == "" {
+			key = a.BestCandidate // reports from before canonical keys
+		}
+		if seenExpr[key] {
 			continue
 		}
 		if a.BestPartialSum != "" && seenSum[a.BestPartialSum] {
 			continue
 		}
-		seenExpr[a.BestCandidate] = true
+		seenExpr[key] = true
 		if a.BestPartialSum != "" {
 			seenSum[a.BestPartialSum] = true
 		}
@@ -106,8 +230,15 @@ func WriteHallOfFame(w io.Writer, attempts []AttemptResult) {
 	}
 	fmt.Fprintln(w, "\n--- Hall of Fame ---")
 	for i, a := range sorted {
-		fmt.Fprintf(w, "  #%d: [attempt %d, gen %d] %5.1f digits | %s\n",
-			i+1, a.Attempt, a.BestFoundAtGen, a.BestFitness.CorrectDigits, a.BestCandidate)
+		identity := ""
+		if a.Identity != "" {
+			identity = " ≈ " + a.Identity
+		}
+		if a.Discovery {
+			identity += " [discovery]"
+		}
+		fmt.Fprintf(w, "  #%d: [attempt %d, gen %d] %5.1f digits | %s%s\n",
+			i+1, a.Attempt, a.BestFoundAtGen, a.BestFitness.CorrectDigits, a.BestCandidate, identity)
 	}
 }
 
@@ -116,6 +247,9 @@ func WriteTextFinal(w io.Writer, r FinalReport) {
 	if len(r.Attempts) > 0 {
 		WriteHallOfFame(w, r.Attempts)
 	}
+	WriteFamilies(w, r.Families)
+	WriteOperators(w, r.Operators)
+	WriteDiscoveries(w, r.Discoveries)
 	fmt.Fprintln(w, "\n========== FINAL RESULT ==========")
 	fmt.Fprintf(w, "Target:    %s\n", r.Config.Target)
 	fmt.Fprintf(w, "Strategy:  %s\n", r.Config.Strategy)
@@ -125,6 +259,9 @@ func WriteTextFinal(w io.Writer, r FinalReport) {
 	fmt.Fprintf(w, "Fitness:   %.4f\n", r.BestFitness.Combined)
 	fmt.Fprintf(w, "Digits:    %.1f\n", r.BestFitness.CorrectDigits)
 	fmt.Fprintf(w, "Partial:   %s\n", r.BestPartialSum)
+	fmt.Fprintf(w, "Stopped:   %s\n", r.StopReason)
+	fmt.Fprintf(w, "Run:       %s (config %s, version %s, seed %d)\n",
+		r.Provenance.RunID, r.Provenance.ConfigHash, r.Provenance.Version, r.Provenance.Seed)
 	fmt.Fprintln(w, "==================================")
 }
 
@@ -140,21 +277,48 @@ func latexEscape(s string) string {
 	return strings.ReplaceAll(s, "_", `\_`)
 }
 
+// targetFileName names the files of a run for its target: fileSafe(target),
+// except that a sequence read from a file is named by the file's base name
+// without its extension (seq_b for seq:/tmp/b.txt), not its whole path.
+func targetFileName(target string) string {
+	if spec, ok := strings.CutPrefix(target, "seq:"); ok {
+		base := filepath.Base(spec)
+		return "seq_" + fileSafe(strings.TrimSuffix(base, filepath.Ext(base)))
+	}
+	return fileSafe(target)
+}
+
+// fileSafe replaces the characters of s that do not belong in a file name,
+// such as the operators of a target expression, with underscores.
+func fileSafe(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '_' || r == '-' || r == '.' || unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return '_'
+	}, s)
+}
+
 // WriteHallOfFameLatex writes a compilable LaTeX document of the hall of fame.
-func WriteHallOfFameLatex(w io.Writer, attempts []AttemptResult, cfg Config, targetValue *big.Float) {
+func WriteHallOfFameLatex(w io.Writer, attempts []AttemptResult, cfg Config, prov series.Provenance, targetValue *big.Float) {
 	sorted := sortByDigits(attempts)
 	sorted = dedupAttempts(sorted)
 	if len(sorted) > maxHallOfFame {
 		sorted = sorted[:maxHallOfFame]
 	}
 
-	targetStr := targetValue.Text('g', 50)
-
-	genBudget := "unlimited"
-	if cfg.Generations > 0 {
-		genBudget = fmt.Sprintf("%d", cfg.Generations)
+	genBudget := "Gen budget: unlimited"
+	if cfg.Stop != "" {
+		genBudget = fmt.Sprintf("Stop: \\verb|%s|", cfg.Stop)
+	} else if cfg.Generations > 0 {
+		genBudget = fmt.Sprintf("Gen budget: %d", cfg.Generations)
 	}
 
+	writeLatexProvenance(w, prov)
+	// The run spec goes in as comments, so the .tex alone can rerun the search.
+	for _, line := range strings.Split(strings.TrimSpace(FormatConfig(cfg)), "\n") {
+		fmt.Fprintf(w, "%% %s\n", line)
+	}
 	fmt.Fprintln(w, `\documentclass{article}`)
 	fmt.Fprintln(w, `\usepackage{amsmath}`)
 	fmt.Fprintln(w, `\usepackage{geometry}`)
@@ -166,9 +330,12 @@ func WriteHallOfFameLatex(w io.Writer, attempts []AttemptResult, cfg Config, tar
 	fmt.Fprintln(w)
 	fmt.Fprintf(w, "\\noindent Target: \\texttt{%s}, Pool: \\texttt{%s}, Strategy: \\texttt{%s}\\\\\n",
 		latexEscape(cfg.Target), latexEscape(cfg.Pool), latexEscape(cfg.Strategy))
-	fmt.Fprintf(w, "Population: %d, Gen budget: %s, Stagnation: %d, Workers: %d, Seed: %d\\\\\n",
+	fmt.Fprintf(w, "Population: %d, %s, Stagnation: %d, Workers: %d, Seed: %d\\\\\n",
 		cfg.Population, genBudget, cfg.StagnationLimit, cfg.Workers, cfg.Seed)
-	fmt.Fprintf(w, "Target value: \\verb|%s|\\ldots\n\n", targetStr)
+	if targetValue != nil { // nil for a sequence target
+		fmt.Fprintf(w, "Target value: \\verb|%s|\\ldots\\\\\n", targetValue.Text('g', 50))
+	}
+	fmt.Fprintf(w, "Run: \\verb|%s|, config \\verb|%s|, version \\verb|%s|\n\n", prov.RunID, prov.ConfigHash, prov.Version)
 
 	for i, a := range sorted {
 		fmt.Fprintf(w, "\\subsection*{\\#%d --- %.1f digits (attempt %d, gen %d, %s)}\n",
@@ -178,13 +345,9 @@ func WriteHallOfFameLatex(w io.Writer, attempts []AttemptResult, cfg Config, tar
 		fmt.Fprintf(w, "  %s\n", a.BestLaTeX)
 		fmt.Fprintln(w, `\]`)
 		if a.BestPartialSum != "" {
-			// Compute error = |partial_sum - target|
-			partialSum, _, err := big.ParseFloat(a.BestPartialSum, 10, targetValue.Prec(), big.ToNearestEven)
-			if err == nil {
-				diff := new(big.Float).Sub(partialSum, targetValue)
-				diff.Abs(diff)
+			if e, ok := partialSumError(a, targetValue); ok {
 				fmt.Fprintf(w, "\\noindent Partial sum: \\verb|%s|\\\\\n", a.BestPartialSum)
-				fmt.Fprintf(w, "Error: \\verb|%s|\n\n", diff.Text('e', 10))
+				fmt.Fprintf(w, "Error: \\verb|%s|\n\n", e)
 			} else {
 				fmt.Fprintf(w, "\\noindent Partial sum: \\verb|%s|\n\n", a.BestPartialSum)
 			}
@@ -193,3 +356,108 @@ func WriteHallOfFameLatex(w io.Writer, attempts []AttemptResult, cfg Config, tar
 
 	fmt.Fprintln(w, `\end{document}`)
 }
+
+// hallOfFameReports are the hall-of-fame documents Config.Reports can
+// name, besides the .tex, with their file extensions.
+var hallOfFameReports = map[string]struct {
+	ext   string
+	write func(io.Writer, []AttemptResult, Config, series.Provenance, *big.Float)
+}{
+	"typst":    {".typ", WriteHallOfFameTypst},
+	"markdown": {".md", WriteHallOfFameMarkdown},
+}
+
+// partialSumError is |partial sum - target| for a, formatted, or false if
+// a has no partial sum or there is no target value.
+func partialSumError(a AttemptResult, targetValue *big.Float) (string, bool) {
+	if a.BestPartialSum == "" || targetValue == nil {
+		return "", false
+	}
+	partialSum, _, err := big.ParseFloat(a.BestPartialSum, 10, targetValue.Prec(), big.ToNearestEven)
+	if err != nil {
+		return "", false
+	}
+	diff := new(big.Float).Sub(partialSum, targetValue)
+	return diff.Abs(diff).Text('e', 10), true
+}
+
+// WriteHallOfFameTypst writes the hall of fame as a Typst document, with
+// each formula converted from its LaTeX (see series.Candidate.Typst); a
+// formula that does not parse is shown as LaTeX source.
+func WriteHallOfFameTypst(w io.Writer, attempts []AttemptResult, cfg Config, prov series.Provenance, targetValue *big.Float) {
+	sorted := sortByDigits(attempts)
+	sorted = dedupAttempts(sorted)
+	if len(sorted) > maxHallOfFame {
+		sorted = sorted[:maxHallOfFame]
+	}
+
+	fmt.Fprintf(w, "// provenance: %s\n", provenanceFields(prov))
+	for _, line := range strings.Split(strings.TrimSpace(FormatConfig(cfg)), "\n") {
+		fmt.Fprintf(w, "// %s\n", line)
+	}
+	fmt.Fprintln(w, `#set page(margin: 1in)`)
+	fmt.Fprintf(w, "= Hall of Fame --- Target: `%s`\n\n", cfg.Target)
+	fmt.Fprintf(w, "Target: `%s`, Pool: `%s`, Strategy: `%s` \\\n", cfg.Target, cfg.Pool, cfg.Strategy)
+	fmt.Fprintf(w, "Population: %d, Stagnation: %d, Workers: %d, Seed: %d \\\n",
+		cfg.Population, cfg.StagnationLimit, cfg.Workers, cfg.Seed)
+	if targetValue != nil {
+		fmt.Fprintf(w, "Target value: `%s`... \\\n", targetValue.Text('g', 50))
+	}
+	fmt.Fprintf(w, "Run: `%s`, config `%s`, version `%s`\n", prov.RunID, prov.ConfigHash, prov.Version)
+
+	for i, a := range sorted {
+		fmt.Fprintf(w, "\n== \\#%d --- %.1f digits (attempt %d, gen %d, %s)\n\n",
+			i+1, a.BestFitness.CorrectDigits, a.Attempt, a.BestFoundAtGen,
+			a.Timestamp.Format("2006-01-02 15:04:05 UTC"))
+		if c, err := series.ParseCandidateLatex(a.BestLaTeX); err == nil {
+			fmt.Fprintf(w, "$ %s $\n", c.Typst())
+		} else {
+			fmt.Fprintf(w, "```latex\n%s\n```\n", a.BestLaTeX)
+		}
+		if a.BestPartialSum != "" {
+			fmt.Fprintf(w, "\nPartial sum: `%s`", a.BestPartialSum)
+			if e, ok := partialSumError(a, targetValue); ok {
+				fmt.Fprintf(w, " \\\nError: `%s`", e)
+			}
+			fmt.Fprintln(w)
+		}
+	}
+}
+
+// WriteHallOfFameMarkdown writes the hall of fame as Markdown with KaTeX
+// math (see series.MarkdownMath), for GitHub and static-site renderers.
+func WriteHallOfFameMarkdown(w io.Writer, attempts []AttemptResult, cfg Config, prov series.Provenance, targetValue *big.Float) {
+	sorted := sortByDigits(attempts)
+	sorted = dedupAttempts(sorted)
+	if len(sorted) > maxHallOfFame {
+		sorted = sorted[:maxHallOfFame]
+	}
+
+	fmt.Fprintf(w, "<!-- provenance: %s -->\n", provenanceFields(prov))
+	fmt.Fprintln(w, "<!--")
+	// "--" may not appear inside an HTML comment.
+	fmt.Fprintln(w, strings.ReplaceAll(strings.TrimSpace(FormatConfig(cfg)), "--", "- -"))
+	fmt.Fprintln(w, "-->")
+	fmt.Fprintf(w, "# Hall of Fame — Target: `%s`\n\n", cfg.Target)
+	fmt.Fprintf(w, "Target: `%s`, Pool: `%s`, Strategy: `%s`  \n", cfg.Target, cfg.Pool, cfg.Strategy)
+	fmt.Fprintf(w, "Population: %d, Stagnation: %d, Workers: %d, Seed: %d  \n",
+		cfg.Population, cfg.StagnationLimit, cfg.Workers, cfg.Seed)
+	if targetValue != nil {
+		fmt.Fprintf(w, "Target value: `%s`…  \n", targetValue.Text('g', 50))
+	}
+	fmt.Fprintf(w, "Run: `%s`, config `%s`, version `%s`\n", prov.RunID, prov.ConfigHash, prov.Version)
+
+	for i, a := range sorted {
+		fmt.Fprintf(w, "\n## #%d — %.1f digits (attempt %d, gen %d, %s)\n\n",
+			i+1, a.BestFitness.CorrectDigits, a.Attempt, a.BestFoundAtGen,
+			a.Timestamp.Format("2006-01-02 15:04:05 UTC"))
+		fmt.Fprintln(w, series.MarkdownMath(a.BestLaTeX, true))
+		if a.BestPartialSum != "" {
+			fmt.Fprintf(w, "\nPartial sum: `%s`", a.BestPartialSum)
+			if e, ok := partialSumError(a, targetValue); ok {
+				fmt.Fprintf(w, "  \nError: `%s`", e)
+			}
+			fmt.Fprintln(w)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package engine
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/wildfunctions/genetic_series/pkg/series"
+)
+
+// latexProvenancePrefix starts the comment line that carries provenance in
+// a hall-of-fame .tex file.
+const latexProvenancePrefix = "% provenance:"
+
+// ConfigHash returns a short hash of the settings that shape the search.
+// The seed, stream and the output and worker settings are left out, so
+// runs that differ only in those share a hash.
+func ConfigHash(cfg Config) string {
+	cfg.Seed = 0
+	cfg.RNGStream = 0
+	cfg.Workers = 0
+	cfg.Format = ""
+	cfg.Verbose = false
+	cfg.OutDir = ""
+	cfg.Leaderboard = ""
+	cfg.LeaderboardSize = 0
+	cfg.Reports = nil
+	cfg.Appendix = 0
+	sum := sha256.Sum256([]byte(FormatConfig(cfg)))
+	return hex.EncodeToString(sum[:8])
+}
+
+// newProvenance stamps a run starting now.
+func newProvenance(cfg Config) series.Provenance {
+	now := time.Now().UTC()
+	var suffix [4]byte
+	rand.Read(suffix[:])
+	return series.Provenance{
+		RunID:      now.Format("20060102-150405") + "-" + hex.EncodeToString(suffix[:]),
+		ConfigHash: ConfigHash(cfg),
+		Version:    series.BuildVersion(),
+		Seed:       cfg.Seed,
+		Timestamp:  now,
+	}
+}
+
+// writeLatexProvenance writes p as a single comment line.
+func writeLatexProvenance(w io.Writer, p series.Provenance) {
+	fmt.Fprintf(w, "%s %s\n", latexProvenancePrefix, provenanceFields(p))
+}
+
+// provenanceFields formats p as the key=value fields of a provenance line.
+func provenanceFields(p series.Provenance) string {
+	return fmt.Sprintf("run_id=%s config_hash=%s version=%s seed=%d timestamp=%s",
+		p.RunID, p.ConfigHash, p.Version, p.Seed, p.Timestamp.Format(time.RFC3339))
+}
+
+// ReadReport decodes a final report written by WriteJSONFinal.
+func ReadReport(r io.Reader) (FinalReport, error) {
+	var report FinalReport
+	err := json.NewDecoder(r).Decode(&report)
+	return report, err
+}
+
+// ReadLatexProvenance reads the provenance of a hall-of-fame .tex file
+// written by WriteHallOfFameLatex.
+func ReadLatexProvenance(r io.Reader) (series.Provenance, error) {
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		rest, ok := strings.CutPrefix(sc.Text(), latexProvenancePrefix)
+		if !ok {
+			continue
+		}
+		var p series.Provenance
+		for _, field := range strings.Fields(rest) {
+			key, val, _ := strings.Cut(field, "=")
+			var err error
+			switch key {
+			case "run_id":
+				p.RunID = val
+			case "config_hash":
+				p.ConfigHash = val
+			case "version":
+				p.Version = val
+			case "seed":
+				p.Seed, err = strconv.ParseInt(val, 10, 64)
+			case "timestamp":
+				p.Timestamp, err = time.Parse(time.RFC3339, val)
+			}
+			if err != nil {
+				return series.Provenance{}, fmt.Errorf("provenance %s: %w", key, err)
+			}
+		}
+		return p, nil
+	}
+	if err := sc.Err(); err != nil {
+		return series.Provenance{}, err
+	}
+	return series.Provenance{}, fmt.Errorf("no provenance line found")
+}
@@ -0,0 +1,78 @@
+package engine
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestConfigHash(t *testing.T) {
+	a := DefaultConfig()
+	b := a
+	b.Seed = 1234
+	b.Workers = 3
+	b.OutDir = "elsewhere"
+	if ConfigHash(a) != ConfigHash(b) {
+		t.Error("hash depends on seed, workers or outdir")
+	}
+	b.Population++
+	if ConfigHash(a) == ConfigHash(b) {
+		t.Error("hash ignores population")
+	}
+}
+
+func TestProvenanceRoundTrip(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Seed = 42
+	prov := newProvenance(cfg)
+	prov.Timestamp = prov.Timestamp.Truncate(time.Second)
+	attempts := []AttemptResult{{Attempt: 1, BestCandidate: "x", BestLaTeX: "x", Provenance: prov}}
+
+	var tex bytes.Buffer
+	WriteHallOfFameLatex(&tex, attempts, cfg, prov, big.NewFloat(1))
+	got, err := ReadLatexProvenance(&tex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != prov {
+		t.Errorf("LaTeX provenance = %+v, want %+v", got, prov)
+	}
+
+	var js bytes.Buffer
+	if err := WriteJSONFinal(&js, FinalReport{Config: cfg, Attempts: attempts, Provenance: prov}); err != nil {
+		t.Fatal(err)
+	}
+	report, err := ReadReport(&js)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.Provenance.Timestamp.Equal(prov.Timestamp) || report.Attempts[0].Provenance.RunID != prov.RunID {
+		t.Errorf("JSON provenance = %+v, want %+v", report.Provenance, prov)
+	}
+}
+
+func TestEngine_RecordsProvenance(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Population = 10
+	cfg.Generations = 2
+	cfg.MaxTerms = 32
+
+	e, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	report := e.Run()
+	p := report.Provenance
+	if p.RunID == "" || p.Seed == 0 || p.Version == "" || p.ConfigHash != ConfigHash(report.Config) {
+		t.Errorf("incomplete provenance %+v", p)
+	}
+	for _, a := range report.Attempts {
+		if a.Provenance != p {
+			t.Errorf("attempt %d provenance %+v, want run's %+v", a.Attempt, a.Provenance, p)
+		}
+	}
+	if p.Seed != report.Config.Seed {
+		t.Errorf("provenance seed %d, config seed %d", p.Seed, report.Config.Seed)
+	}
+}
@@ -0,0 +1,87 @@
+package engine
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/wildfunctions/genetic_series/pkg/series"
+)
+
+// Rescored is a leaderboard entry after Rescore, beside how it scored and
+// ranked on the board it came from.
+type Rescored struct {
+	LeaderboardEntry
+	Previous     series.Fitness `json:"previous_fitness"`
+	PreviousRank int            `json:"previous_rank"` // 1-based
+}
+
+// Rescore re-evaluates every candidate of b, parsed from its LaTeX (or
+// decoded from its genome if that fails), as the engine's full-precision
+// phase would: against e's target, at its
+// precision and terms, with its evaluator, fitness weights and fitness
+// hooks. It returns the board re-ranked by the new fitness, for e's
+// target, and its entries beside their previous scores, best first. Failed
+// candidates are kept, with the worst fitness. Candidates are evaluated on
+// Config.Workers goroutines.
+func (e *Engine) Rescore(b Leaderboard) (Leaderboard, []Rescored, error) {
+	cands := make([]*series.Candidate, len(b.Entries))
+	for i, entry := range b.Entries {
+		c, err := series.ParseCandidateLatex(entry.LaTeX)
+		if err != nil && entry.Genome != nil {
+			c, err = entry.Genome.Decode()
+		}
+		if err != nil {
+			return Leaderboard{}, nil, fmt.Errorf("entry %d (%s): %w", i+1, entry.LaTeX, err)
+		}
+		cands[i] = c
+	}
+
+	out := make([]Rescored, len(cands))
+	sem := make(chan struct{}, max(e.cfg.Workers, 1))
+	var wg sync.WaitGroup
+	for i, c := range cands {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, c *series.Candidate) {
+			defer func() { <-sem; wg.Done() }()
+			var f series.Fitness
+			var r series.EvalResult
+			func() {
+				defer recoverEval(c, &f, &r)
+				f, r = e.evaluate(c)
+			}()
+			entry := b.Entries[i]
+			entry.Fitness = f
+			entry.PartialSum = ""
+			if r.OK && r.PartialSum != nil {
+				entry.PartialSum = r.PartialSum.Text('g', 20)
+			}
+			out[i] = Rescored{LeaderboardEntry: entry, Previous: b.Entries[i].Fitness, PreviousRank: i + 1}
+		}(i, c)
+	}
+	wg.Wait()
+
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Fitness.Combined > out[j].Fitness.Combined })
+	board := b
+	board.Target = e.cfg.Target
+	board.Updated = time.Now().UTC()
+	board.Entries = make([]LeaderboardEntry, len(out))
+	for i, r := range out {
+		board.Entries[i] = r.LeaderboardEntry
+	}
+	return board, out, nil
+}
+
+// WriteRescoreReport writes the rescored entries as a ranked table: new
+// and previous rank and digits, and the formula.
+func WriteRescoreReport(w io.Writer, from, to string, entries []Rescored) {
+	fmt.Fprintf(w, "Rescored %d candidates: %s -> %s\n", len(entries), from, to)
+	fmt.Fprintf(w, "%4s %5s %8s %8s %8s  %s\n", "rank", "was", "digits", "before", "change", "formula")
+	for i, r := range entries {
+		d, p := r.Fitness.CorrectDigits, r.Previous.CorrectDigits
+		fmt.Fprintf(w, "%4d %5d %8.2f %8.2f %+8.2f  %s\n", i+1, r.PreviousRank, d, p, d-p, r.LaTeX)
+	}
+}
@@ -0,0 +1,80 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"github.com/wildfunctions/genetic_series/pkg/bloom"
+	"github.com/wildfunctions/genetic_series/pkg/series"
+	"github.com/wildfunctions/genetic_series/pkg/tabu"
+)
+
+// seenFalsePositiveRate is the false positive rate a new seen filter is
+// sized for at Config.SeenCapacity keys. The filter grows past them (see
+// bloom.Scalable), so runs that keep adding keys keep the rate below
+// twice this.
+const seenFalsePositiveRate = 0.01
+
+// seenFilter carries the candidates explored by earlier runs into this one
+// (Config.SeenFile). Candidates on prior are skipped like tabu ones; prior
+// is never added to during the run, so this run's own survivors are not.
+type seenFilter struct {
+	path    string
+	prior   *bloom.Scalable // loaded at start; nil for a new file
+	next    *bloom.Scalable // prior plus everything this run evaluated
+	skipped int
+}
+
+// loadSeen loads the filter at path, or starts one sized for capacity
+// keys if there is none yet.
+func loadSeen(path string, capacity int) (*seenFilter, error) {
+	prior, err := bloom.LoadScalable(path, seenFalsePositiveRate)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &seenFilter{path: path, next: bloom.NewScalable(capacity, seenFalsePositiveRate)}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &seenFilter{path: path, prior: prior, next: prior.Clone()}, nil
+}
+
+// before reports whether the candidate with canonical key was explored by
+// an earlier run. It is safe for concurrent use by evaluation workers.
+func (s *seenFilter) before(key string) bool {
+	return s != nil && s.prior.Contains(tabu.KeyOf(key))
+}
+
+// record adds the candidates of a generation that were evaluated, rather
+// than skipped or deferred, to the filter saved for the next run. strs are
+// their canonical keys.
+func (s *seenFilter) record(strs []string, fitnesses []series.Fitness, tabuSet map[string]bool) {
+	if s == nil {
+		return
+	}
+	for i, f := range fitnesses {
+		switch {
+		case s.before(strs[i]):
+			s.skipped++
+		case !f.Deferred && !tabuSet[strs[i]]:
+			// Survivors come back every generation; count each key once.
+			if k := tabu.KeyOf(strs[i]); !s.next.Contains(k) {
+				s.next.Add(k)
+			}
+		}
+	}
+}
+
+// save writes the filter for the next run and reports on it.
+func (s *seenFilter) save() {
+	if s == nil {
+		return
+	}
+	if err := s.next.Save(s.path); err != nil {
+		fmt.Fprintf(os.Stderr, "error writing %s: %v\n", s.path, err)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Seen filter: skipped %d evaluations of candidates from earlier runs; %d keys in %d filters in %s (%.2g%% false positives)\n",
+		s.skipped, s.next.Len(), s.next.Filters(), s.path, 100*s.next.FalsePositiveRate())
+}
@@ -0,0 +1,246 @@
+package engine
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// runState is what stop conditions see, taken after every generation.
+type runState struct {
+	generations int           // total across attempts
+	attempts    int           // attempts started
+	elapsed     time.Duration // wall clock since Run started
+	digits      float64       // best correct digits of the run
+	stagnation  int           // generations since the run's best fitness improved
+	archive     int           // candidates in the archive (0 when disabled)
+}
+
+// stopCriteria are the quantities a stop condition can bound, each read
+// from a runState. time is compared in seconds.
+var stopCriteria = map[string]func(runState) float64{
+	"generations": func(s runState) float64 { return float64(s.generations) },
+	"attempts":    func(s runState) float64 { return float64(s.attempts) },
+	"time":        func(s runState) float64 { return s.elapsed.Seconds() },
+	"digits":      func(s runState) float64 { return s.digits },
+	"stagnation":  func(s runState) float64 { return float64(s.stagnation) },
+	"archive":     func(s runState) float64 { return float64(s.archive) },
+}
+
+// stopCond decides when a run is over.
+type stopCond interface {
+	// met reports whether the condition holds for s and, if so, why.
+	met(s runState) (reason string, ok bool)
+	String() string
+}
+
+// stopLeaf is "criterion >= limit".
+type stopLeaf struct {
+	name  string
+	limit float64
+	text  string // limit as written, e.g. "2h"
+}
+
+func (l stopLeaf) met(s runState) (string, bool) {
+	v := stopCriteria[l.name](s)
+	if v < l.limit {
+		return "", false
+	}
+	if l.name == "time" {
+		return fmt.Sprintf("%s (%s)", l, s.elapsed.Round(time.Second)), true
+	}
+	return fmt.Sprintf("%s (%s)", l, strconv.FormatFloat(v, 'f', -1, 64)), true
+}
+
+func (l stopLeaf) String() string { return l.name + " >= " + l.text }
+
+// stopAll holds when every condition does.
+type stopAll []stopCond
+
+func (a stopAll) met(s runState) (string, bool) {
+	reasons := make([]string, len(a))
+	for i, c := range a {
+		r, ok := c.met(s)
+		if !ok {
+			return "", false
+		}
+		reasons[i] = r
+	}
+	return strings.Join(reasons, " and "), true
+}
+
+func (a stopAll) String() string { return joinConds(a, " and ") }
+
+// stopAny holds when some condition does; the first one that holds is the
+// reason.
+type stopAny []stopCond
+
+func (a stopAny) met(s runState) (string, bool) {
+	for _, c := range a {
+		if r, ok := c.met(s); ok {
+			return r, true
+		}
+	}
+	return "", false
+}
+
+func (a stopAny) String() string { return joinConds(a, " or ") }
+
+func joinConds(cs []stopCond, sep string) string {
+	parts := make([]string, len(cs))
+	for i, c := range cs {
+		parts[i] = c.String()
+		if _, ok := c.(stopLeaf); !ok {
+			parts[i] = "(" + parts[i] + ")"
+		}
+	}
+	return strings.Join(parts, sep)
+}
+
+// stopConditionOf returns the condition that ends a run with cfg:
+// cfg.Stop if given, otherwise the generation budget (none if unlimited),
+// in either case or'ed with reaching maxDigits (series.MaxDigits, or a
+// sequence target's length), past which there is nothing left to find.
+func stopConditionOf(cfg Config, maxDigits int) (stopCond, error) {
+	digitCap := stopLeaf{name: "digits", limit: float64(maxDigits), text: strconv.Itoa(maxDigits)}
+	switch {
+	case cfg.Stop != "":
+		c, err := parseStop(cfg.Stop)
+		if err != nil {
+			return nil, fmt.Errorf("invalid stop condition: %w", err)
+		}
+		return stopAny{c, digitCap}, nil
+	case cfg.Generations > 0:
+		gens := stopLeaf{name: "generations", limit: float64(cfg.Generations), text: strconv.Itoa(cfg.Generations)}
+		return stopAny{gens, digitCap}, nil
+	}
+	return digitCap, nil
+}
+
+// parseStop parses a stop condition such as
+//
+//	generations >= 1000 or (time >= 2h and stagnation >= 500)
+//
+// Each term is "criterion >= limit" for a criterion in stopCriteria (time
+// takes a duration); "and" (or "&&") binds tighter than "or" (or "||").
+func parseStop(s string) (stopCond, error) {
+	for _, op := range []string{"(", ")", ">="} {
+		s = strings.ReplaceAll(s, op, " "+op+" ")
+	}
+	s = strings.NewReplacer("&&", " and ", "||", " or ").Replace(s)
+	p := &stopParser{toks: strings.Fields(s)}
+	c, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.toks) {
+		return nil, fmt.Errorf("unexpected %q", p.toks[p.pos])
+	}
+	return c, nil
+}
+
+type stopParser struct {
+	toks []string
+	pos  int
+}
+
+func (p *stopParser) next() string {
+	if p.pos == len(p.toks) {
+		return ""
+	}
+	t := p.toks[p.pos]
+	p.pos++
+	return t
+}
+
+func (p *stopParser) peek() string {
+	if p.pos == len(p.toks) {
+		return ""
+	}
+	return p.toks[p.pos]
+}
+
+func (p *stopParser) or() (stopCond, error) {
+	c, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	alts := stopAny{c}
+	for strings.EqualFold(p.peek(), "or") {
+		p.next()
+		c, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		alts = append(alts, c)
+	}
+	if len(alts) == 1 {
+		return alts[0], nil
+	}
+	return alts, nil
+}
+
+func (p *stopParser) and() (stopCond, error) {
+	c, err := p.term()
+	if err != nil {
+		return nil, err
+	}
+	all := stopAll{c}
+	for strings.EqualFold(p.peek(), "and") {
+		p.next()
+		c, err := p.term()
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, c)
+	}
+	if len(all) == 1 {
+		return all[0], nil
+	}
+	return all, nil
+}
+
+func (p *stopParser) term() (stopCond, error) {
+	name := p.next()
+	switch name {
+	case "":
+		return nil, fmt.Errorf("unexpected end of condition")
+	case "(":
+		c, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if t := p.next(); t != ")" {
+			return nil, fmt.Errorf("expected ), got %q", t)
+		}
+		return c, nil
+	}
+	if _, ok := stopCriteria[name]; !ok {
+		return nil, fmt.Errorf("unknown criterion %q (want %s)", name, strings.Join(stopCriteriaNames(), ", "))
+	}
+	if t := p.next(); t != ">=" {
+		return nil, fmt.Errorf("expected >= after %s, got %q", name, t)
+	}
+	text := p.next()
+	var limit float64
+	if name == "time" {
+		d, err := time.ParseDuration(text)
+		if err != nil {
+			return nil, err
+		}
+		limit = d.Seconds()
+	} else {
+		v, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid limit %q", name, text)
+		}
+		limit = v
+	}
+	return stopLeaf{name: name, limit: limit, text: text}, nil
+}
+
+// stopCriteriaNames lists the stop criteria in a fixed order, for errors.
+func stopCriteriaNames() []string {
+	return []string{"generations", "attempts", "time", "digits", "stagnation", "archive"}
+}
@@ -0,0 +1,107 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/wildfunctions/genetic_series/pkg/series"
+)
+
+func TestParseStop(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string // String() of the parsed condition
+	}{
+		{"generations>=1000", "generations >= 1000"},
+		{"time >= 2h || digits >= 30", "time >= 2h or digits >= 30"},
+		{"digits >= 20 or time >= 1h and stagnation >= 500", "digits >= 20 or (time >= 1h and stagnation >= 500)"},
+		{"(digits >= 20 OR archive >= 100) && attempts >= 3", "(digits >= 20 or archive >= 100) and attempts >= 3"},
+	}
+	for _, tt := range tests {
+		c, err := parseStop(tt.in)
+		if err != nil {
+			t.Errorf("parseStop(%q): %v", tt.in, err)
+			continue
+		}
+		if got := c.String(); got != tt.want {
+			t.Errorf("parseStop(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"", "speed >= 3", "digits > 3", "digits >= x", "time >= 5", "(digits >= 3", "digits >= 3 or", "digits >= 3 generations >= 2"} {
+		if _, err := parseStop(bad); err == nil {
+			t.Errorf("parseStop(%q) succeeded, want error", bad)
+		}
+	}
+}
+
+func TestStopMet(t *testing.T) {
+	c, err := parseStop("digits >= 20 or (time >= 1h and stagnation >= 500)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		s    runState
+		want string // "" = not met
+	}{
+		{runState{digits: 12, elapsed: 2 * time.Hour, stagnation: 100}, ""},
+		{runState{digits: 12, elapsed: 30 * time.Minute, stagnation: 900}, ""},
+		{runState{digits: 21.5}, "digits >= 20 (21.5)"},
+		{runState{digits: 12, elapsed: 2 * time.Hour, stagnation: 500}, "time >= 1h (2h0m0s) and stagnation >= 500 (500)"},
+	}
+	for _, tt := range tests {
+		got, ok := c.met(tt.s)
+		if ok != (tt.want != "") || got != tt.want {
+			t.Errorf("met(%+v) = %q, %v; want %q", tt.s, got, ok, tt.want)
+		}
+	}
+}
+
+func TestStopConditionOf(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Generations = 0
+	c, _ := stopConditionOf(cfg, series.MaxDigits)
+	if got := c.String(); got != "digits >= 50" {
+		t.Errorf("unlimited: %s", got)
+	}
+	cfg.Generations = 300
+	c, _ = stopConditionOf(cfg, series.MaxDigits)
+	if got := c.String(); got != "generations >= 300 or digits >= 50" {
+		t.Errorf("budget: %s", got)
+	}
+	cfg.Stop = "time >= 10m"
+	c, _ = stopConditionOf(cfg, series.MaxDigits)
+	if got := c.String(); got != "time >= 10m or digits >= 50" {
+		t.Errorf("stop: %s", got)
+	}
+	cfg.Stop = "time >= forever"
+	if _, err := New(cfg); err == nil || !strings.Contains(err.Error(), "stop condition") {
+		t.Errorf("New with bad stop: err = %v", err)
+	}
+}
+
+func TestEngine_StopReason(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Population = 20
+	cfg.MaxTerms = 64
+	cfg.Seed = 3
+	cfg.StagnationLimit = 5
+	cfg.Stop = "generations >= 7 and attempts >= 1"
+
+	e, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	report := e.Run()
+	total := 0
+	for _, a := range report.Attempts {
+		total += a.Generations
+	}
+	if !strings.HasPrefix(report.StopReason, "generations >= 7 (7) and attempts >= 1") && !strings.HasPrefix(report.StopReason, "digits >= 50") {
+		t.Errorf("StopReason = %q", report.StopReason)
+	}
+	if total > 7 {
+		t.Errorf("ran %d generations, want at most 7", total)
+	}
+}
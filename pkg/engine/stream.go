@@ -0,0 +1,114 @@
+package engine
+
+import (
+	"time"
+
+	"github.com/wildfunctions/genetic_series/pkg/series"
+	"github.com/wildfunctions/genetic_series/pkg/strategy"
+)
+
+// generation is the population the run loop is working on. In streaming
+// mode (Config.StreamBatch > 0) it is held as compact genomes and only
+// StreamBatch candidates are materialized as trees at any one time.
+type generation struct {
+	trees   []*series.Candidate
+	genomes []series.Genome
+}
+
+func (g generation) len() int {
+	if g.genomes != nil {
+		return len(g.genomes)
+	}
+	return len(g.trees)
+}
+
+// at returns member i. For genomes this decodes a fresh copy, so it is
+// meant for the handful of candidates a report needs, not bulk access.
+func (g generation) at(i int) *series.Candidate {
+	if g.genomes != nil {
+		return g.genomes[i].MustDecode()
+	}
+	return g.trees[i]
+}
+
+// set replaces member i with c.
+func (g generation) set(i int, c *series.Candidate) {
+	if g.genomes != nil {
+		g.genomes[i] = series.EncodeCandidate(c)
+		return
+	}
+	g.trees[i] = c
+}
+
+// initialGeneration builds the starting population, in batches when
+// streaming so the full tree population never exists at once.
+func (e *Engine) initialGeneration() generation {
+	if e.cfg.StreamBatch <= 0 {
+		return generation{trees: e.strategy.Initialize(e.pool, e.rng, e.cfg.Population)}
+	}
+	genomes := make([]series.Genome, 0, e.cfg.Population)
+	for len(genomes) < e.cfg.Population {
+		size := min(e.cfg.StreamBatch, e.cfg.Population-len(genomes))
+		for _, c := range e.strategy.Initialize(e.pool, e.rng, size) {
+			genomes = append(genomes, series.EncodeCandidate(c))
+		}
+	}
+	return generation{genomes: genomes}
+}
+
+// evaluateGeneration scores every member of g within the generation budget.
+func (e *Engine) evaluateGeneration(g generation, tabuSet map[string]bool) ([]series.Fitness, []series.EvalResult) {
+	var deadline time.Time
+	if e.cfg.GenerationBudget > 0 {
+		deadline = time.Now().Add(e.cfg.GenerationBudget)
+	}
+	if g.genomes == nil {
+		return e.evaluatePopulation(g.trees, tabuSet, deadline, true)
+	}
+
+	n := len(g.genomes)
+	fitnesses := make([]series.Fitness, n)
+	results := make([]series.EvalResult, n)
+	batch := make([]*series.Candidate, 0, e.cfg.StreamBatch)
+	for lo := 0; lo < n; lo += e.cfg.StreamBatch {
+		hi := min(lo+e.cfg.StreamBatch, n)
+		batch = batch[:0]
+		for _, gn := range g.genomes[lo:hi] {
+			batch = append(batch, gn.MustDecode())
+		}
+		f, r := e.evaluatePopulation(batch, tabuSet, deadline, true)
+		copy(fitnesses[lo:hi], f)
+		copy(results[lo:hi], r)
+		dropPartialSums(fitnesses[lo:hi], results[lo:hi])
+	}
+	return fitnesses, results
+}
+
+// dropPartialSums clears the big.Float partial sums of all but the two
+// fittest results, which are the only ones the run loop reports.
+func dropPartialSums(fitnesses []series.Fitness, results []series.EvalResult) {
+	best, second := -1, -1
+	for i, f := range fitnesses {
+		switch {
+		case best < 0 || f.Combined > fitnesses[best].Combined:
+			best, second = i, best
+		case second < 0 || f.Combined > fitnesses[second].Combined:
+			second = i
+		}
+	}
+	for i := range results {
+		if i != best && i != second {
+			results[i].PartialSum = nil
+		}
+	}
+}
+
+// nextGeneration breeds the successor of g.
+func (e *Engine) nextGeneration(g generation, fitnesses []series.Fitness) generation {
+	defer e.setOrigins()
+	if g.genomes == nil {
+		return generation{trees: e.strategy.Evolve(g.trees, fitnesses, e.pool, e.rng)}
+	}
+	gs := e.strategy.(strategy.GenomeStrategy)
+	return generation{genomes: gs.EvolveGenomes(g.genomes, fitnesses, e.pool, e.rng)}
+}
@@ -0,0 +1,183 @@
+package engine
+
+import (
+	"math"
+	"sort"
+
+	"github.com/wildfunctions/genetic_series/pkg/series"
+)
+
+const (
+	surrogateK        = 8    // neighbours a prediction averages over
+	surrogateCapacity = 4096 // training samples kept, newest replacing oldest
+	surrogateWarmup   = 256  // samples before the surrogate screens anything
+	surrogateNovelty  = 0.25 // share of the evaluated fraction picked for novelty rather than prediction
+	surrogateFailed   = -10  // score a failed candidate trains as, in place of WorstFitness
+)
+
+// features is a candidate's position in the surrogate's feature space:
+// its series.Features, then log10 of its 64-term partial sum's distance to
+// the target.
+type features [series.NumFeatures + 1]float64
+
+// surrogate is a nearest-neighbour model of fitness over features, trained
+// online on every candidate the engine evaluates. It picks which members of
+// a generation are worth a full evaluation (Config.SurrogateFraction).
+type surrogate struct {
+	fraction float64
+	feat     []features
+	score    []float64
+	weight   features // per-feature distance weight, 1/variance over feat (see rescale)
+	next     int      // ring position of the next sample once full
+	skipped  int      // candidates screened out, this attempt
+}
+
+func newSurrogate(fraction float64) *surrogate {
+	return &surrogate{fraction: fraction}
+}
+
+var featSum64, _ = series.FeatureIndex("sum64")
+
+// featuresOf computes c's features.
+func (e *Engine) featuresOf(c *series.Candidate) features {
+	var f features
+	v := series.Features(c)
+	copy(f[:], v[:])
+	// Undo the signed log of the sum.
+	s := v[featSum64]
+	sum := math.Copysign(math.Pow(10, math.Abs(s))-1, s)
+	d := math.Log10(math.Abs(sum-e.targetF64) + 1e-16)
+	f[series.NumFeatures] = math.Max(-16, math.Min(4, d))
+	return f
+}
+
+// rescale sets the distance weights from the training samples, so every
+// feature counts in units of its own spread; constant features count for
+// nothing.
+func (s *surrogate) rescale() {
+	var mean, sq features
+	for _, f := range s.feat {
+		for j, v := range f {
+			mean[j] += v
+			sq[j] += v * v
+		}
+	}
+	n := float64(len(s.feat))
+	for j := range s.weight {
+		s.weight[j] = 0
+		if v := sq[j]/n - (mean[j]/n)*(mean[j]/n); v > 1e-12 {
+			s.weight[j] = 1 / v
+		}
+	}
+}
+
+// predict returns the distance-weighted mean score of x's nearest
+// neighbours, and the distance to the nearest one, in the metric set by
+// rescale.
+func (s *surrogate) predict(x features) (score, nearest float64) {
+	type neighbour struct{ d, score float64 }
+	best := make([]neighbour, 0, surrogateK+1)
+	for i, f := range s.feat {
+		d := 0.0
+		for j := range f {
+			d += s.weight[j] * (f[j] - x[j]) * (f[j] - x[j])
+		}
+		if len(best) == surrogateK && d >= best[surrogateK-1].d {
+			continue
+		}
+		at := sort.Search(len(best), func(k int) bool { return best[k].d > d })
+		best = append(best, neighbour{})
+		copy(best[at+1:], best[at:])
+		best[at] = neighbour{d, s.score[i]}
+		if len(best) > surrogateK {
+			best = best[:surrogateK]
+		}
+	}
+	var sum, weight float64
+	for _, nb := range best {
+		w := 1 / (math.Sqrt(nb.d) + 1e-6)
+		sum += w * nb.score
+		weight += w
+	}
+	return sum / weight, math.Sqrt(best[0].d)
+}
+
+// train adds a sample, replacing the oldest once at capacity.
+func (s *surrogate) train(x features, f series.Fitness) {
+	score := f.Combined
+	if score <= series.WorstFitness().Combined {
+		score = surrogateFailed
+	}
+	if len(s.feat) < surrogateCapacity {
+		s.feat = append(s.feat, x)
+		s.score = append(s.score, score)
+		return
+	}
+	s.feat[s.next], s.score[s.next] = x, score
+	s.next = (s.next + 1) % surrogateCapacity
+}
+
+// screening is the surrogate's pass over one population: the features of
+// the candidates it saw and which of those it screened out.
+type screening struct {
+	feat     []features
+	eligible []bool
+	skip     []bool // nil while warming up
+}
+
+// screen runs the surrogate over pop, skipping members already done (see
+// carryOver) or on the tabu lists. Members screened out get their deferred
+// fitness and are marked in done, which is returned, allocated if need be.
+// While the surrogate is warming up every eligible member is evaluated;
+// after that, the fraction with the best predicted score and, for a
+// quarter of the fraction, the ones farthest from anything it has seen.
+func (e *Engine) screen(pop []*series.Candidate, strs []string, fitnesses []series.Fitness, done []bool, tabuSet map[string]bool) ([]bool, *screening) {
+	s := e.surrogate
+	sc := &screening{feat: make([]features, len(pop)), eligible: make([]bool, len(pop))}
+	var idx []int
+	for i, c := range pop {
+		if (done == nil || !done[i]) && !tabuSet[strs[i]] && !e.seen.before(strs[i]) {
+			sc.eligible[i] = true
+			sc.feat[i] = e.featuresOf(c)
+			idx = append(idx, i)
+		}
+	}
+	if len(s.feat) < surrogateWarmup || len(idx) == 0 {
+		return done, sc
+	}
+
+	s.rescale()
+	pred := make([]float64, len(pop))
+	novelty := make([]float64, len(pop))
+	for _, i := range idx {
+		pred[i], novelty[i] = s.predict(sc.feat[i])
+	}
+	keep := int(math.Ceil(s.fraction * float64(len(idx))))
+	novel := int(surrogateNovelty * float64(keep))
+
+	sort.SliceStable(idx, func(a, b int) bool { return pred[idx[a]] > pred[idx[b]] })
+	rest := idx[keep-novel:]
+	sort.SliceStable(rest, func(a, b int) bool { return novelty[rest[a]] > novelty[rest[b]] })
+
+	if done == nil {
+		done = make([]bool, len(pop))
+	}
+	sc.skip = make([]bool, len(pop))
+	for _, i := range rest[novel:] {
+		sc.skip[i] = true
+		done[i] = true
+		fitnesses[i] = e.deferredFitness(strs[i])
+		s.skipped++
+	}
+	return done, sc
+}
+
+// learn trains the surrogate on the members of a screened population that
+// were evaluated, once their fitnesses are in.
+func (e *Engine) learn(sc *screening, fitnesses []series.Fitness) {
+	for i, ok := range sc.eligible {
+		if ok && (sc.skip == nil || !sc.skip[i]) && !fitnesses[i].Deferred {
+			e.surrogate.train(sc.feat[i], fitnesses[i])
+		}
+	}
+}
@@ -0,0 +1,22 @@
+package engine
+
+import "github.com/wildfunctions/genetic_series/pkg/series"
+
+// probeDegenerate fails every member of pop not already done that
+// series.ProbeTrig finds degenerate, with the worst fitness and no
+// evaluation, so that recordFailures puts its structure on the failure
+// tabu like any other failure. It returns done with those marked in it.
+func (e *Engine) probeDegenerate(pop []*series.Candidate, fitnesses []series.Fitness, done []bool) []bool {
+	for i, c := range pop {
+		if done != nil && done[i] || series.ProbeTrig(c) == series.NotDegenerate {
+			continue
+		}
+		if done == nil {
+			done = make([]bool, len(pop))
+		}
+		fitnesses[i] = series.WorstFitness()
+		done[i] = true
+		e.degenerate++
+	}
+	return done
+}
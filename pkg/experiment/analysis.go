@@ -0,0 +1,171 @@
+package experiment
+
+import (
+	"math"
+	"sort"
+
+	"github.com/wildfunctions/genetic_series/pkg/stats"
+)
+
+// significance is the level at which a comparison names a winner, after
+// the Holm adjustment over all the summary's comparisons.
+const significance = 0.05
+
+// ComboSummary is the finished trials of one combination.
+type ComboSummary struct {
+	Combo             int     `json:"combo"`
+	Params            []Param `json:"params"`
+	Trials            int     `json:"trials"` // finished
+	Successes         int     `json:"successes"`
+	SuccessRate       float64 `json:"success_rate"`
+	SuccessLo         float64 `json:"success_lo"` // 95% Wilson interval
+	SuccessHi         float64 `json:"success_hi"`
+	MedianDigits      float64 `json:"median_digits"`
+	MedianGenerations float64 `json:"median_generations"` // to SuccessDigits; -1 if fewer than half the trials got there
+
+	digits      []float64 // one per finished trial
+	generations []float64 // to SuccessDigits, +Inf for failures
+}
+
+// Comparison tests two combinations that differ only in the value of Key.
+// Each p-value is two-sided and Holm-adjusted over every comparison in the
+// summary, for the test it names: Fisher's exact test on success rates and
+// Mann–Whitney U on digits reached and on generations to SuccessDigits
+// (failures ranked last).
+type Comparison struct {
+	Key          string  `json:"key"`
+	A            string  `json:"a"`
+	B            string  `json:"b"`
+	Others       []Param `json:"others,omitempty"` // the settings A and B share
+	SuccessP     float64 `json:"success_p"`
+	DigitsP      float64 `json:"digits_p"`
+	GenerationsP float64 `json:"generations_p"`
+	Better       string  `json:"better,omitempty"` // A or B if a test is significant, by its direction
+}
+
+// summarizeCombos groups finished results by combination.
+func summarizeCombos(spec Spec, results []Result) []ComboSummary {
+	byCombo := map[int]*ComboSummary{}
+	var order []int
+	for _, res := range results {
+		cs, ok := byCombo[res.Run.Combo]
+		if !ok {
+			cs = &ComboSummary{Combo: res.Run.Combo, Params: res.Run.Params}
+			byCombo[res.Run.Combo] = cs
+			order = append(order, res.Run.Combo)
+		}
+		r := res.Report
+		if r == nil {
+			continue
+		}
+		cs.Trials++
+		cs.digits = append(cs.digits, r.BestFitness.CorrectDigits)
+		if g, ok := r.GenerationsTo(spec.SuccessDigits); ok {
+			cs.Successes++
+			cs.generations = append(cs.generations, float64(g))
+		} else {
+			cs.generations = append(cs.generations, math.Inf(1))
+		}
+	}
+	sort.Ints(order)
+	combos := make([]ComboSummary, len(order))
+	for i, c := range order {
+		cs := byCombo[c]
+		if cs.Trials > 0 {
+			cs.SuccessRate = float64(cs.Successes) / float64(cs.Trials)
+			cs.SuccessLo, cs.SuccessHi = stats.Wilson(cs.Successes, cs.Trials)
+			cs.MedianDigits = stats.Median(cs.digits)
+		}
+		cs.MedianGenerations = stats.Median(cs.generations)
+		if math.IsInf(cs.MedianGenerations, 1) || math.IsNaN(cs.MedianGenerations) {
+			cs.MedianGenerations = -1
+		}
+		combos[i] = *cs
+	}
+	return combos
+}
+
+// compareCombos tests every pair of combinations that differ in exactly
+// one axis.
+func compareCombos(combos []ComboSummary) []Comparison {
+	type pending struct {
+		c                             Comparison
+		successDir, digitsDir, genDir float64 // > 0 favors A
+	}
+	var ps []pending
+	for i := range combos {
+		for j := i + 1; j < len(combos); j++ {
+			a, b := &combos[i], &combos[j]
+			axis := differingAxis(a.Params, b.Params)
+			if axis < 0 || a.Trials == 0 || b.Trials == 0 {
+				continue
+			}
+			c := Comparison{Key: a.Params[axis].Key, A: a.Params[axis].Value, B: b.Params[axis].Value}
+			for k, p := range a.Params {
+				if k != axis {
+					c.Others = append(c.Others, p)
+				}
+			}
+			c.SuccessP = stats.FisherExact(a.Successes, a.Trials, b.Successes, b.Trials)
+			uD, pD := stats.MannWhitney(a.digits, b.digits)
+			uG, pG := stats.MannWhitney(a.generations, b.generations)
+			c.DigitsP, c.GenerationsP = pD, pG
+			half := float64(a.Trials*b.Trials) / 2
+			ps = append(ps, pending{
+				c:          c,
+				successDir: a.SuccessRate - b.SuccessRate,
+				digitsDir:  uD - half,
+				genDir:     half - uG, // fewer generations is better
+			})
+		}
+	}
+
+	// Adjust each test family over all comparisons.
+	adjust := func(get func(*Comparison) *float64) {
+		raw := make([]float64, len(ps))
+		for i := range ps {
+			raw[i] = *get(&ps[i].c)
+		}
+		for i, p := range stats.Holm(raw) {
+			*get(&ps[i].c) = p
+		}
+	}
+	adjust(func(c *Comparison) *float64 { return &c.SuccessP })
+	adjust(func(c *Comparison) *float64 { return &c.DigitsP })
+	adjust(func(c *Comparison) *float64 { return &c.GenerationsP })
+
+	out := make([]Comparison, len(ps))
+	for i, p := range ps {
+		c := p.c
+		// The most significant test decides.
+		best, dir := 1.0, 0.0
+		for _, t := range []struct{ p, dir float64 }{{c.SuccessP, p.successDir}, {c.DigitsP, p.digitsDir}, {c.GenerationsP, p.genDir}} {
+			if t.p < best && t.dir != 0 {
+				best, dir = t.p, t.dir
+			}
+		}
+		if best < significance {
+			c.Better = c.A
+			if dir < 0 {
+				c.Better = c.B
+			}
+		}
+		out[i] = c
+	}
+	return out
+}
+
+// differingAxis returns the one axis on which a and b differ, or -1 if
+// they differ on none or several.
+func differingAxis(a, b []Param) int {
+	axis := -1
+	for k := range a {
+		if a[k].Value != b[k].Value {
+			if axis >= 0 {
+				return -1
+			}
+			axis = k
+		}
+	}
+	return axis
+}
@@ -0,0 +1,183 @@
+package experiment
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/wildfunctions/genetic_series/pkg/engine"
+)
+
+const testSpec = `
+target = "e"
+seed = 7
+
+[strategy]
+population = 10
+generations = 3
+stagnation = 0
+
+[eval]
+max_terms = 32
+workers = 1
+discovery_digits = 0
+
+[archive]
+size = 0
+
+[sweep]
+strategy.name = ["hillclimb", "tournament"]
+strategy.population = [8, 12]  # overrides the template
+`
+
+func TestParseSpecAndRuns(t *testing.T) {
+	spec, err := ParseSpec(strings.NewReader(testSpec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if spec.Base.Target != "e" || spec.Base.MaxTerms != 32 || len(spec.Axes) != 2 {
+		t.Fatalf("spec = %+v", spec)
+	}
+	runs := spec.Runs()
+	if len(runs) != 4 {
+		t.Fatalf("%d runs, want 4", len(runs))
+	}
+	last := runs[3]
+	if last.Name != "run003" || last.Config.Strategy != "tournament" || last.Config.Population != 12 {
+		t.Errorf("last run = %s %s %d", last.Name, last.Config.Strategy, last.Config.Population)
+	}
+	if got := runs[1].Label(); got != "strategy.name=hillclimb strategy.population=12" {
+		t.Errorf("Label() = %q", got)
+	}
+	if runs[0].Config.MaxTerms != 32 || runs[0].Config.Seed != 7 {
+		t.Error("runs lost the template settings")
+	}
+
+	for _, bad := range []string{
+		"[sweep]\nstrategy.nmae = [\"hillclimb\"]",
+		"[sweep]\nstrategy.population = [10, \"x\"]",
+		"[sweep]\nstrategy.population = []",
+		"[sweep]\nstrategy.population = 10",
+		"[sweep]\nseed = [1]\nseed = [2]",
+		"[experiment]\ntrials = 0",
+		"[experiment]\ntrails = 3",
+	} {
+		if _, err := ParseSpec(strings.NewReader(bad)); err == nil {
+			t.Errorf("ParseSpec(%q) succeeded, want error", bad)
+		}
+	}
+}
+
+func TestExecuteResumesAndSummarizes(t *testing.T) {
+	spec, err := ParseSpec(strings.NewReader(testSpec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	runs := spec.Runs()
+	dir := t.TempDir()
+
+	// Run half, as if interrupted, then the rest.
+	if _, err := Execute(runs[:2], dir, 2, io.Discard); err != nil {
+		t.Fatal(err)
+	}
+	results, err := Execute(runs, dir, 2, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, res := range results {
+		if res.Report == nil || res.Err != nil {
+			t.Fatalf("%s: report %v, err %v", res.Run.Name, res.Report, res.Err)
+		}
+		if res.Report.Config.Population != res.Run.Config.Population {
+			t.Errorf("%s ran with population %d, want %d", res.Run.Name, res.Report.Config.Population, res.Run.Config.Population)
+		}
+	}
+	if _, err := os.Stat(specPath(dir, runs[3])); err != nil {
+		t.Errorf("run spec not written: %v", err)
+	}
+	planned, err := engine.LoadConfigFile(specPath(dir, runs[3]))
+	if err != nil || planned.Strategy != "tournament" || planned.Population != 12 {
+		t.Errorf("planned spec = %+v, %v", planned, err)
+	}
+
+	s := Summarize(spec, Load(runs, dir))
+	if len(s.Runs) != 4 || len(s.Effects) != 2 {
+		t.Fatalf("summary = %+v", s)
+	}
+	for i := 1; i < len(s.Runs); i++ {
+		if s.Runs[i].Digits > s.Runs[i-1].Digits {
+			t.Errorf("runs not sorted by digits: %+v", s.Runs)
+		}
+	}
+	for _, lv := range s.Effects[0].Levels {
+		if lv.Runs != 2 || lv.MeanGenerations != 3 {
+			t.Errorf("level %+v, want 2 runs of 3 generations", lv)
+		}
+	}
+	var b strings.Builder
+	WriteSummary(&b, s)
+	if !strings.Contains(b.String(), "4/4 runs finished") || !strings.Contains(b.String(), "tournament") {
+		t.Errorf("summary text:\n%s", b.String())
+	}
+}
+
+func TestTrialsAndComparisons(t *testing.T) {
+	spec, err := ParseSpec(strings.NewReader(`
+seed = 100
+[sweep]
+strategy.name = ["hillclimb", "tournament"]
+pool.name = ["conservative"]
+[experiment]
+trials = 8
+success_digits = 12
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	runs := spec.Runs()
+	if len(runs) != 16 || runs[9].Name != "run001_t01" || runs[9].Combo != 1 || runs[9].Trial != 1 {
+		t.Fatalf("runs: %d, runs[9] = %+v", len(runs), runs[9])
+	}
+	for _, r := range []Run{runs[1], runs[9]} {
+		if r.Config.Seed != 100 || r.Config.RNGStream != 1 {
+			t.Errorf("%s: seed %d stream %d, want stream 1 of seed 100", r.Name, r.Config.Seed, r.Config.RNGStream)
+		}
+	}
+
+	// Tournament trials all reach 15 digits by generation 40;
+	// hillclimb trials stall at 5.
+	results := make([]Result, len(runs))
+	for i, r := range runs {
+		report := &engine.FinalReport{}
+		if r.Config.Strategy == "tournament" {
+			report.BestFitness.CorrectDigits = 15 + float64(r.Trial)/10
+			for d := 1; d <= 15; d++ {
+				report.Milestones = append(report.Milestones, engine.Milestone{Digits: d, Generation: 10 + d*2 + r.Trial})
+			}
+		} else {
+			report.BestFitness.CorrectDigits = 5 + float64(r.Trial)/10
+		}
+		results[i] = Result{Run: r, Report: report}
+	}
+	s := Summarize(spec, results)
+	if len(s.Combos) != 2 || len(s.Comparisons) != 1 {
+		t.Fatalf("%d combos, %d comparisons", len(s.Combos), len(s.Comparisons))
+	}
+	hc, tn := s.Combos[0], s.Combos[1]
+	if hc.Successes != 0 || hc.MedianGenerations != -1 || tn.Successes != 8 || tn.MedianGenerations != 37.5 {
+		t.Errorf("combos = %+v / %+v", hc, tn)
+	}
+	c := s.Comparisons[0]
+	if c.Key != "strategy.name" || c.Better != "tournament" || c.DigitsP > 0.001 || c.SuccessP > 0.001 {
+		t.Errorf("comparison = %+v, want tournament significantly better", c)
+	}
+	if len(c.Others) != 1 || c.Others[0].Value != "conservative" {
+		t.Errorf("others = %+v", c.Others)
+	}
+	var b strings.Builder
+	WriteSummary(&b, s)
+	if !strings.Contains(b.String(), "tournament better") {
+		t.Errorf("summary text:\n%s", b.String())
+	}
+}
@@ -0,0 +1,143 @@
+package experiment
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/wildfunctions/genetic_series/pkg/engine"
+)
+
+// Result is a run and, once it has finished, its report.
+type Result struct {
+	Run    Run
+	Report *engine.FinalReport // nil if the run has not finished or failed
+	Err    error
+}
+
+// specPath and reportPath are where a run's spec and report live in dir.
+func specPath(dir string, r Run) string   { return filepath.Join(dir, r.Name+".toml") }
+func reportPath(dir string, r Run) string { return filepath.Join(dir, r.Name+".json") }
+
+// Plan writes each run's complete run spec to dir, so that runs can be
+// farmed out to other machines: each is a
+//
+//	genetic_series -config dir/runNNN.toml -format json > dir/runNNN.json
+//
+// and Load collects the reports back.
+func Plan(runs []Run, dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	for _, r := range runs {
+		if err := os.WriteFile(specPath(dir, r), []byte(engine.FormatConfig(r.Config)), 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Command is the shell command that runs r from its planned spec.
+func Command(dir string, r Run) string {
+	return fmt.Sprintf("genetic_series -config %s -format json > %s", specPath(dir, r), reportPath(dir, r))
+}
+
+// Execute plans runs in dir and runs in-process those without a report
+// there yet, parallel at a time, writing each report as it finishes. An
+// interrupted experiment therefore resumes where it stopped. Progress goes
+// to log; the engines' own output goes to stderr as usual. It returns the
+// results of all runs, finished before or now.
+func Execute(runs []Run, dir string, parallel int, log io.Writer) ([]Result, error) {
+	if err := Plan(runs, dir); err != nil {
+		return nil, err
+	}
+	results := Load(runs, dir)
+
+	var pending []int
+	for i, res := range results {
+		if res.Report == nil {
+			pending = append(pending, i)
+		}
+	}
+	fmt.Fprintf(log, "[experiment] %d runs, %d already done\n", len(runs), len(runs)-len(pending))
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	for w := 0; w < max(parallel, 1); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				r := runs[i]
+				report, err := runOne(r, dir)
+				mu.Lock()
+				results[i] = Result{Run: r, Report: report, Err: err}
+				if err != nil {
+					fmt.Fprintf(log, "[experiment] %s failed: %v\n", r.Name, err)
+				} else {
+					fmt.Fprintf(log, "[experiment] %s done: %.1f digits in %d generations | %s\n",
+						r.Name, report.BestFitness.CorrectDigits, report.TotalGenerations, r.Label())
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, i := range pending {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return results, nil
+}
+
+// runOne runs r and writes its report to dir.
+func runOne(r Run, dir string) (*engine.FinalReport, error) {
+	e, err := engine.New(r.Config)
+	if err != nil {
+		return nil, err
+	}
+	report := e.Run()
+
+	// Write under a temporary name, so a report on disk is always whole.
+	path := reportPath(dir, r)
+	f, err := os.Create(path + ".tmp")
+	if err != nil {
+		return nil, err
+	}
+	if err := engine.WriteJSONFinal(f, report); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if err := f.Close(); err != nil {
+		return nil, err
+	}
+	return &report, os.Rename(path+".tmp", path)
+}
+
+// Load reads the reports of runs from dir. Runs without a report have a
+// nil Report and no error; unreadable reports are errors.
+func Load(runs []Run, dir string) []Result {
+	results := make([]Result, len(runs))
+	for i, r := range runs {
+		results[i].Run = r
+		data, err := os.ReadFile(reportPath(dir, r))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err == nil {
+			var report engine.FinalReport
+			if err = json.Unmarshal(data, &report); err == nil {
+				results[i].Report = &report
+				continue
+			}
+		}
+		results[i].Err = err
+	}
+	return results
+}
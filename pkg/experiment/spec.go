@@ -0,0 +1,184 @@
+// Package experiment runs a search over a grid of settings and compares
+// the results: the methodical version of trying a few flags by hand.
+package experiment
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/wildfunctions/genetic_series/pkg/engine"
+)
+
+// Spec is an experiment: a run spec template and the settings swept over
+// it. It is written as a run spec (see engine.ParseConfig) with a [sweep]
+// section listing the values of each swept setting:
+//
+//	target = "pi"
+//	[eval]
+//	max_terms = 256
+//
+//	[sweep]
+//	strategy.name = ["hillclimb", "tournament"]
+//	strategy.population = [200, 1000]
+//
+//	[experiment]
+//	trials = 10          # independent seeded runs of each combination
+//	success_digits = 15  # a trial succeeds when it reaches this many digits
+//
+// which expands to forty runs, ten for each combination.
+type Spec struct {
+	Base          engine.Config
+	Axes          []Axis
+	Trials        int     // runs per combination, on streams 0, 1, ... of Base.Seed
+	SuccessDigits float64 // digits a trial must reach to count as a success
+}
+
+// Defaults for the [experiment] section.
+const (
+	defaultTrials        = 1
+	defaultSuccessDigits = 10
+)
+
+// Axis is one swept setting.
+type Axis struct {
+	Key    string   // run spec setting, e.g. "strategy.population"
+	Values []string // as written in the spec, e.g. `"tournament"` or `400`
+}
+
+// LoadSpec reads an experiment spec from path.
+func LoadSpec(path string) (Spec, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Spec{}, err
+	}
+	defer f.Close()
+	s, err := ParseSpec(f)
+	if err != nil {
+		return Spec{}, fmt.Errorf("%s:%w", path, err)
+	}
+	return s, nil
+}
+
+// ParseSpec reads an experiment spec from r. Every swept value is checked
+// against its setting, so a typo fails here rather than one run in.
+func ParseSpec(r io.Reader) (Spec, error) {
+	var axes []Axis
+	sweep := func(key, val string) error {
+		values, err := engine.SplitArray(val)
+		if err != nil {
+			return err
+		}
+		if len(values) == 0 {
+			return fmt.Errorf("no values")
+		}
+		for _, a := range axes {
+			if a.Key == key {
+				return fmt.Errorf("swept twice")
+			}
+		}
+		for _, v := range values {
+			var cfg engine.Config
+			if err := engine.SetConfigValue(&cfg, key, v); err != nil {
+				return err
+			}
+		}
+		axes = append(axes, Axis{Key: key, Values: values})
+		return nil
+	}
+	spec := Spec{Trials: defaultTrials, SuccessDigits: defaultSuccessDigits}
+	settings := func(key, val string) error {
+		switch key {
+		case "trials":
+			n, err := strconv.Atoi(val)
+			if err == nil && n < 1 {
+				err = fmt.Errorf("must be at least 1")
+			}
+			spec.Trials = n
+			return err
+		case "success_digits":
+			d, err := strconv.ParseFloat(val, 64)
+			spec.SuccessDigits = d
+			return err
+		}
+		return fmt.Errorf("unknown setting")
+	}
+	base, err := engine.ParseConfigSections(r, map[string]func(key, val string) error{
+		"sweep":      sweep,
+		"experiment": settings,
+	})
+	if err != nil {
+		return Spec{}, err
+	}
+	spec.Base, spec.Axes = base, axes
+	return spec, nil
+}
+
+// Param is the value a run takes for one axis.
+type Param struct {
+	Key   string `json:"key"`
+	Value string `json:"value"` // unquoted
+}
+
+// Run is one trial of one combination of an experiment.
+type Run struct {
+	Name   string        `json:"name"`  // run000, run001, ... (run000_t00, ... with trials); also its file names
+	Combo  int           `json:"combo"` // index of the combination of axis values
+	Trial  int           `json:"trial"`
+	Params []Param       `json:"params"`
+	Config engine.Config `json:"-"`
+}
+
+// Label renders the run's parameters, e.g. "strategy.name=tournament
+// strategy.population=200".
+func (r Run) Label() string {
+	parts := make([]string, len(r.Params))
+	for i, p := range r.Params {
+		parts[i] = p.Key + "=" + p.Value
+	}
+	return strings.Join(parts, " ")
+}
+
+// Runs expands the spec into every combination of its axes, the last axis
+// varying fastest, each repeated Trials times. A spec with no axes is the
+// template alone. With more than one trial, trial k of every combination
+// draws from stream k of Base.Seed (of seed 1 if the template leaves the
+// seed random), so trials are independent and combinations are compared
+// on the same streams.
+func (s Spec) Runs() []Run {
+	combos := 1
+	for _, a := range s.Axes {
+		combos *= len(a.Values)
+	}
+	trials := max(s.Trials, 1)
+	runs := make([]Run, 0, combos*trials)
+	for i := 0; i < combos; i++ {
+		cfg := s.Base
+		params := make([]Param, len(s.Axes))
+		rest := i
+		for j := len(s.Axes) - 1; j >= 0; j-- {
+			a := s.Axes[j]
+			v := a.Values[rest%len(a.Values)]
+			rest /= len(a.Values)
+			// Checked by ParseSpec.
+			engine.SetConfigValue(&cfg, a.Key, v)
+			params[j] = Param{Key: a.Key, Value: unquote(v)}
+		}
+		if trials == 1 {
+			runs = append(runs, Run{Name: fmt.Sprintf("run%03d", i), Combo: i, Params: params, Config: cfg})
+			continue
+		}
+		for k := 0; k < trials; k++ {
+			c := cfg
+			c.Seed = max(s.Base.Seed, 1)
+			c.RNGStream = k
+			runs = append(runs, Run{Name: fmt.Sprintf("run%03d_t%02d", i, k), Combo: i, Trial: k, Params: params, Config: c})
+		}
+	}
+	return runs
+}
+
+// unquote strips the quotes of a string value as written in a spec.
+func unquote(v string) string { return strings.Trim(v, `"'`) }
@@ -0,0 +1,190 @@
+package experiment
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+// Summary compares the runs of an experiment.
+type Summary struct {
+	Trials        int            `json:"trials"` // per combination
+	SuccessDigits float64        `json:"success_digits"`
+	Runs          []RunSummary   `json:"runs"`    // best digits first; unfinished runs last
+	Effects       []Effect       `json:"effects"` // one per axis
+	Combos        []ComboSummary `json:"combos"`
+	Comparisons   []Comparison   `json:"comparisons,omitempty"` // with more than one trial
+}
+
+// RunSummary is a run's outcome.
+type RunSummary struct {
+	Name        string        `json:"name"`
+	Combo       int           `json:"combo"`
+	Trial       int           `json:"trial"`
+	Params      []Param       `json:"params"`
+	Done        bool          `json:"done"`
+	Error       string        `json:"error,omitempty"`
+	Digits      float64       `json:"digits"`
+	Fitness     float64       `json:"fitness"`
+	Generations int           `json:"generations"`
+	Elapsed     time.Duration `json:"elapsed"`
+	Discoveries int           `json:"discoveries"`
+	StopReason  string        `json:"stop_reason,omitempty"`
+	Best        string        `json:"best,omitempty"`
+}
+
+// Effect is how the finished runs fared at each value of one axis, every
+// other axis averaged over.
+type Effect struct {
+	Key    string  `json:"key"`
+	Levels []Level `json:"levels"`
+}
+
+// Level is the finished runs that share one value of an axis.
+type Level struct {
+	Value           string  `json:"value"`
+	Runs            int     `json:"runs"`
+	MeanDigits      float64 `json:"mean_digits"`
+	BestDigits      float64 `json:"best_digits"`
+	MeanGenerations float64 `json:"mean_generations"`
+	MeanSeconds     float64 `json:"mean_seconds"`
+}
+
+// Summarize builds the comparison of results, the runs of spec.
+func Summarize(spec Spec, results []Result) Summary {
+	s := Summary{Trials: max(spec.Trials, 1), SuccessDigits: spec.SuccessDigits}
+	for _, res := range results {
+		rs := RunSummary{Name: res.Run.Name, Combo: res.Run.Combo, Trial: res.Run.Trial, Params: res.Run.Params}
+		if res.Err != nil {
+			rs.Error = res.Err.Error()
+		}
+		if r := res.Report; r != nil {
+			rs.Done = true
+			rs.Digits = r.BestFitness.CorrectDigits
+			rs.Fitness = r.BestFitness.Combined
+			rs.Generations = r.TotalGenerations
+			rs.Elapsed = r.Elapsed
+			rs.Discoveries = len(r.Discoveries)
+			rs.StopReason = r.StopReason
+			rs.Best = r.BestCandidate
+		}
+		s.Runs = append(s.Runs, rs)
+	}
+
+	for j, a := range spec.Axes {
+		eff := Effect{Key: a.Key, Levels: make([]Level, len(a.Values))}
+		index := map[string]int{}
+		for i, v := range a.Values {
+			eff.Levels[i].Value = unquote(v)
+			index[unquote(v)] = i
+		}
+		for _, rs := range s.Runs {
+			if !rs.Done {
+				continue
+			}
+			lv := &eff.Levels[index[rs.Params[j].Value]]
+			lv.Runs++
+			lv.MeanDigits += rs.Digits
+			lv.BestDigits = max(lv.BestDigits, rs.Digits)
+			lv.MeanGenerations += float64(rs.Generations)
+			lv.MeanSeconds += rs.Elapsed.Seconds()
+		}
+		for i := range eff.Levels {
+			if lv := &eff.Levels[i]; lv.Runs > 0 {
+				n := float64(lv.Runs)
+				lv.MeanDigits /= n
+				lv.MeanGenerations /= n
+				lv.MeanSeconds /= n
+			}
+		}
+		s.Effects = append(s.Effects, eff)
+	}
+
+	s.Combos = summarizeCombos(spec, results)
+	if s.Trials > 1 {
+		s.Comparisons = compareCombos(s.Combos)
+	}
+
+	sort.SliceStable(s.Runs, func(a, b int) bool {
+		ra, rb := s.Runs[a], s.Runs[b]
+		if ra.Done != rb.Done {
+			return ra.Done
+		}
+		return ra.Digits > rb.Digits
+	})
+	return s
+}
+
+// WriteSummary writes a summary in human-readable format.
+func WriteSummary(w io.Writer, s Summary) {
+	done := 0
+	for _, rs := range s.Runs {
+		if rs.Done {
+			done++
+		}
+	}
+	fmt.Fprintf(w, "Experiment: %d/%d runs finished\n", done, len(s.Runs))
+	if s.Trials > 1 {
+		writeTrials(w, s)
+		return
+	}
+	for _, rs := range s.Runs {
+		label := Run{Params: rs.Params}.Label()
+		switch {
+		case rs.Done:
+			fmt.Fprintf(w, "  %s %6.1f digits  %6d gens  %8s | %s | %s\n",
+				rs.Name, rs.Digits, rs.Generations, rs.Elapsed.Round(time.Second), label, rs.Best)
+		case rs.Error != "":
+			fmt.Fprintf(w, "  %s failed: %s | %s\n", rs.Name, rs.Error, label)
+		default:
+			fmt.Fprintf(w, "  %s not run | %s\n", rs.Name, label)
+		}
+	}
+	writeEffects(w, s.Effects)
+}
+
+// writeTrials writes the per-combination statistics and comparisons of a
+// summary with repeated trials.
+func writeTrials(w io.Writer, s Summary) {
+	fmt.Fprintf(w, "%d trials per combination; success = %g digits\n", s.Trials, s.SuccessDigits)
+	for _, cs := range s.Combos {
+		gens := "-"
+		if cs.MedianGenerations >= 0 {
+			gens = fmt.Sprintf("%.0f", cs.MedianGenerations)
+		}
+		fmt.Fprintf(w, "  %2d/%-2d success %3.0f%% [%3.0f%%, %3.0f%%] | median %5.1f digits, %6s gens to success | %s\n",
+			cs.Successes, cs.Trials, 100*cs.SuccessRate, 100*cs.SuccessLo, 100*cs.SuccessHi,
+			cs.MedianDigits, gens, Run{Params: cs.Params}.Label())
+	}
+	if len(s.Comparisons) > 0 {
+		fmt.Fprintf(w, "\nComparisons (Holm-adjusted p-values: success / digits / generations):\n")
+	}
+	for _, c := range s.Comparisons {
+		verdict := "no significant difference"
+		if c.Better != "" {
+			verdict = c.Better + " better"
+		}
+		fmt.Fprintf(w, "  %s: %s vs %s | p = %.3f / %.3f / %.3f | %s",
+			c.Key, c.A, c.B, c.SuccessP, c.DigitsP, c.GenerationsP, verdict)
+		if len(c.Others) > 0 {
+			fmt.Fprintf(w, " | at %s", Run{Params: c.Others}.Label())
+		}
+		fmt.Fprintln(w)
+	}
+	writeEffects(w, s.Effects)
+}
+
+func writeEffects(w io.Writer, effects []Effect) {
+	for _, eff := range effects {
+		fmt.Fprintf(w, "\n%s:\n", eff.Key)
+		for _, lv := range eff.Levels {
+			if lv.Runs == 0 {
+				fmt.Fprintf(w, "  %-16s no finished runs\n", lv.Value)
+				continue
+			}
+			fmt.Fprintf(w, "  %-16s %2d runs | mean %5.1f digits, best %5.1f | mean %.0f gens, %.0fs\n",
+				lv.Value, lv.Runs, lv.MeanDigits, lv.BestDigits, lv.MeanGenerations, lv.MeanSeconds)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package expr
+
+import (
+	"math"
+	"math/big"
+)
+
+// bigArctan computes arctan x at prec. An argument of magnitude at least
+// 1 is reflected through arctan x = ±π/2 - arctan(1/x), then halved k
+// times by arctan x = 2·arctan(x/(1+√(1+x²))), leaving it below 2^-k, so
+// that each term of the Taylor series x - x³/3 + x⁵/5 - ... gains 2k
+// bits. The doublings back cost k bits, which the working precision
+// carries.
+func bigArctan(x *big.Float, prec uint) (*big.Float, bool) {
+	if x.IsInf() {
+		return nil, false
+	}
+	if x.Sign() == 0 {
+		return newFloat(prec), true
+	}
+	k := int(math.Sqrt(float64(prec)) / 2)
+	wp := prec + uint(k) + 32
+	one := new(big.Float).SetPrec(wp).SetInt64(1)
+	y := new(big.Float).SetPrec(wp).Set(x)
+	reflect := y.MantExp(nil) > 0 // |x| ≥ 1
+	if reflect {
+		y.Quo(one, y)
+	}
+	t := new(big.Float).SetPrec(wp)
+	for i := 0; i < k; i++ {
+		t.Mul(y, y)
+		s := bigSqrt(t.Add(t, one), wp)
+		y.Quo(y, t.Add(s, one))
+		ReleaseFloat(s)
+	}
+
+	sum := new(big.Float).SetPrec(wp).Set(y)
+	pow := new(big.Float).SetPrec(wp).Set(y)
+	y2 := new(big.Float).SetPrec(wp).Mul(y, y)
+	for j := int64(3); ; j += 2 {
+		pow.Mul(pow, y2)
+		pow.Neg(pow)
+		t.Quo(pow, t.SetInt64(j))
+		if t.Sign() == 0 || t.MantExp(nil) < sum.MantExp(nil)-int(wp) {
+			break
+		}
+		sum.Add(sum, t)
+	}
+	sum.SetMantExp(sum, k)
+
+	if reflect {
+		half := new(big.Float).SetPrec(wp).SetMantExp(bigPi(wp), -1)
+		if x.Sign() < 0 {
+			half.Neg(half)
+		}
+		sum.Sub(half, sum)
+	}
+	return newFloat(prec).Set(sum), true
+}
+
+// bigArcsin computes arcsin x, |x| ≤ 1, at prec as
+// arctan(x/√((1-x)(1+x))); the factored form keeps 1-x² exact near ±1.
+func bigArcsin(x *big.Float, prec uint) (*big.Float, bool) {
+	wp := prec + 32
+	one := new(big.Float).SetPrec(wp).SetInt64(1)
+	a := new(big.Float).SetPrec(wp).Abs(x)
+	switch a.Cmp(one) {
+	case 1:
+		return nil, false
+	case 0:
+		half := newFloat(prec).SetMantExp(bigPi(wp), -1)
+		if x.Sign() < 0 {
+			half.Neg(half)
+		}
+		return half, true
+	}
+	d := new(big.Float).SetPrec(wp).Sub(one, x)
+	d.Mul(d, one.Add(one, x))
+	s := bigSqrt(d, wp)
+	defer ReleaseFloat(s)
+	r, ok := bigArctan(d.Quo(x, s), wp)
+	if !ok {
+		return nil, false
+	}
+	return newFloat(prec).Set(r), true
+}
@@ -0,0 +1,198 @@
+package expr
+
+import (
+	"math/big"
+	"testing"
+)
+
+// benchTerm is the summand of the 11.8-digit pi formula
+// from investigations/pi-consttune-experiments.md:
+// 26 * n! * (2n)! / ((3n)! * 2^n).
+func benchTerm() ExprNode {
+	n := &VarNode{}
+	num := &BinaryNode{Op: OpMul,
+		Left: &BinaryNode{Op: OpMul,
+			Left:  &ConstNode{Val: 26},
+			Right: &UnaryNode{Op: OpFactorial, Child: n},
+		},
+		Right: &UnaryNode{Op: OpFactorial, Child: &BinaryNode{Op: OpMul, Left: &ConstNode{Val: 2}, Right: n}},
+	}
+	den := &BinaryNode{Op: OpMul,
+		Left:  &UnaryNode{Op: OpFactorial, Child: &BinaryNode{Op: OpMul, Left: &ConstNode{Val: 3}, Right: n}},
+		Right: &BinaryNode{Op: OpPow, Left: &ConstNode{Val: 2}, Right: n},
+	}
+	return &BinaryNode{Op: OpDiv, Left: num, Right: den}
+}
+
+func benchmarkEval(b *testing.B, node ExprNode, prec uint) {
+	b.ReportAllocs()
+	nf := new(big.Float).SetPrec(prec)
+	for i := 0; i < b.N; i++ {
+		nf.SetInt64(int64(i % 64))
+		v, ok := node.Eval(nf, prec)
+		if !ok {
+			b.Fatal("Eval failed")
+		}
+		ReleaseFloat(v)
+	}
+}
+
+func BenchmarkEvalPiTerm512(b *testing.B)  { benchmarkEval(b, benchTerm(), 512) }
+func BenchmarkEvalPiTerm4096(b *testing.B) { benchmarkEval(b, benchTerm(), 4096) }
+
+func BenchmarkEvalRational512(b *testing.B) {
+	// (-1)^n * (4n + 1) / ((2n + 1) * (n + 3)) — arithmetic-heavy, no sequence ops.
+	n := &VarNode{}
+	node := &BinaryNode{Op: OpDiv,
+		Left: &BinaryNode{Op: OpMul,
+			Left:  &UnaryNode{Op: OpAltSign, Child: n},
+			Right: &BinaryNode{Op: OpAdd, Left: &BinaryNode{Op: OpMul, Left: &ConstNode{Val: 4}, Right: n}, Right: &ConstNode{Val: 1}},
+		},
+		Right: &BinaryNode{Op: OpMul,
+			Left:  &BinaryNode{Op: OpAdd, Left: &BinaryNode{Op: OpMul, Left: &ConstNode{Val: 2}, Right: n}, Right: &ConstNode{Val: 1}},
+			Right: &BinaryNode{Op: OpAdd, Left: n, Right: &ConstNode{Val: 3}},
+		},
+	}
+	benchmarkEval(b, node, 512)
+}
+
+func BenchmarkEvalSqrt512(b *testing.B) {
+	node := &UnaryNode{Op: OpSqrt, Child: &BinaryNode{Op: OpAdd, Left: &VarNode{}, Right: &ConstNode{Val: 2}}}
+	benchmarkEval(b, node, 512)
+}
+
+// deepTree builds a depth-d tree that mixes foldable constants, identity
+// elements, and genuine structure, similar to what crossover produces.
+func deepTree(d int) ExprNode {
+	if d == 0 {
+		return &VarNode{}
+	}
+	child := deepTree(d - 1)
+	switch d % 4 {
+	case 0:
+		return &BinaryNode{Op: OpAdd, Left: child, Right: &BinaryNode{Op: OpMul, Left: &ConstNode{Val: 0}, Right: &VarNode{}}}
+	case 1:
+		return &BinaryNode{Op: OpMul, Left: &BinaryNode{Op: OpAdd, Left: &ConstNode{Val: 2}, Right: &ConstNode{Val: 3}}, Right: child}
+	case 2:
+		return &UnaryNode{Op: OpNeg, Child: &UnaryNode{Op: OpNeg, Child: child}}
+	default:
+		return &BinaryNode{Op: OpDiv, Left: child, Right: &BinaryNode{Op: OpPow, Left: &VarNode{}, Right: &ConstNode{Val: 1}}}
+	}
+}
+
+func BenchmarkSimplifyDeepTree(b *testing.B) {
+	tree := deepTree(12)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		ResetSimplifyCache()
+		Simplify(tree)
+	}
+}
+
+func BenchmarkSimplifyDeepTreeCached(b *testing.B) {
+	tree := deepTree(12)
+	ResetSimplifyCache()
+	Simplify(tree)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Simplify(tree)
+	}
+}
+
+func BenchmarkSimplifyBigFloatDeepTree(b *testing.B) {
+	tree := deepTree(12)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		ResetSimplifyCache()
+		SimplifyBigFloat(tree, 128)
+	}
+}
+
+// BenchmarkCloneDeepTree and BenchmarkReplaceAtDeepTree compare the old
+// copy-then-mutate child construction against path copying.
+func BenchmarkCloneDeepTree(b *testing.B) {
+	tree := deepTree(12)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		tree.Clone()
+	}
+}
+
+func BenchmarkReplaceAtDeepTree(b *testing.B) {
+	tree := deepTree(12)
+	count := tree.NodeCount()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		ReplaceAt(tree, i%count, &ConstNode{Val: 5})
+	}
+}
+
+// benchmarkTermEvaluator evaluates node over n = 0..terms-1, comparing
+// per-n Eval, a TermEvaluator, and a BlockEvaluator in blocks of 128.
+func benchmarkTermEvaluator(b *testing.B, node ExprNode, terms int64, prec uint) {
+	b.Run("Eval", func(b *testing.B) {
+		b.ReportAllocs()
+		nf := new(big.Float).SetPrec(prec)
+		for i := 0; i < b.N; i++ {
+			for n := int64(0); n < terms; n++ {
+				v, ok := node.Eval(nf.SetInt64(n), prec)
+				if !ok {
+					b.Fatal("Eval failed")
+				}
+				ReleaseFloat(v)
+			}
+		}
+	})
+	b.Run("TermEvaluator", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			te := NewTermEvaluator(node, prec)
+			for n := int64(0); n < terms; n++ {
+				v, ok := te.Eval(n)
+				if !ok {
+					b.Fatal("Eval failed")
+				}
+				ReleaseFloat(v)
+			}
+		}
+	})
+	b.Run("BlockEvaluator", func(b *testing.B) {
+		b.ReportAllocs()
+		dst := make([]*big.Float, 128)
+		for i := 0; i < b.N; i++ {
+			be := NewBlockEvaluator(node, prec)
+			for n := int64(0); n < terms; n += int64(len(dst)) {
+				blk := dst[:min(int64(len(dst)), terms-n)]
+				if be.EvalBlock(n, blk) != len(blk) {
+					b.Fatal("EvalBlock failed")
+				}
+				releaseAll(blk)
+			}
+		}
+	})
+}
+
+func BenchmarkTermEvaluatorPiTerm(b *testing.B) { benchmarkTermEvaluator(b, benchTerm(), 300, 512) }
+
+func BenchmarkTermEvaluatorFibonacci(b *testing.B) {
+	// (-1)^n F_{2n+1} / (2n)!!
+	n := &VarNode{}
+	twoN := &BinaryNode{Op: OpMul, Left: &ConstNode{Val: 2}, Right: n}
+	node := &BinaryNode{Op: OpDiv,
+		Left: &BinaryNode{Op: OpMul,
+			Left:  &UnaryNode{Op: OpAltSign, Child: n},
+			Right: &UnaryNode{Op: OpFibonacci, Child: &BinaryNode{Op: OpAdd, Left: twoN, Right: &ConstNode{Val: 1}}},
+		},
+		Right: &UnaryNode{Op: OpDoubleFactorial, Child: twoN},
+	}
+	benchmarkTermEvaluator(b, node, 480, 512)
+}
+
+func BenchmarkTermEvaluatorRational(b *testing.B) {
+	node, err := ParseExprLatex(`\frac{(-1)^{n} (4n+1) - \sqrt{2}}{(2n+1)(n+3)(n^{2}+7)}`)
+	if err != nil {
+		b.Fatal(err)
+	}
+	benchmarkTermEvaluator(b, node, 1024, 512)
+}
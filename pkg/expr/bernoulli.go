@@ -0,0 +1,109 @@
+package expr
+
+import (
+	"math"
+	"math/big"
+	"sync"
+)
+
+// bernoulliCache holds B_0, B_1, ... as exact rationals, with B_1 = -1/2.
+var bernoulliCache struct {
+	mu     sync.RWMutex
+	values []*big.Rat
+}
+
+// bernoulliRat returns the Bernoulli number B_iv, for 0 ≤ iv ≤
+// maxComputeInput, from the shared table. The result must not be modified.
+func bernoulliRat(iv int64) (*big.Rat, bool) {
+	if iv < 0 || iv > maxComputeInput {
+		return nil, false
+	}
+	bernoulliCache.mu.RLock()
+	if iv < int64(len(bernoulliCache.values)) {
+		v := bernoulliCache.values[iv]
+		bernoulliCache.mu.RUnlock()
+		return v, true
+	}
+	bernoulliCache.mu.RUnlock()
+
+	bernoulliCache.mu.Lock()
+	defer bernoulliCache.mu.Unlock()
+	if iv >= int64(len(bernoulliCache.values)) {
+		// The table is recomputed rather than extended, so grow it by at
+		// least double.
+		n := min(max(iv, 2*int64(len(bernoulliCache.values)), 64), maxComputeInput)
+		bernoulliCache.values = bernoulliTable(int(n))
+	}
+	return bernoulliCache.values[iv], true
+}
+
+// bernoulliTable computes B_0..B_n from the tangent numbers T_k by
+// B_2k = (-1)^(k-1) 2k T_k / (4^k (4^k - 1)), the odd ones past B_1 being
+// zero. T_1..T_m take O(m²) small multiplications of integers (Brent and
+// Harvey, "Fast computation of Bernoulli, tangent and secant numbers").
+func bernoulliTable(n int) []*big.Rat {
+	m := n / 2
+	t := make([]*big.Int, m+1)
+	if m >= 1 {
+		t[1] = big.NewInt(1)
+	}
+	for k := 2; k <= m; k++ {
+		t[k] = new(big.Int).Mul(t[k-1], big.NewInt(int64(k-1)))
+	}
+	var a, b big.Int
+	for k := 2; k <= m; k++ {
+		for j := k; j <= m; j++ {
+			a.Mul(t[j-1], big.NewInt(int64(j-k)))
+			b.Mul(t[j], big.NewInt(int64(j-k+2)))
+			t[j].Add(&a, &b)
+		}
+	}
+
+	out := make([]*big.Rat, n+1)
+	zero := new(big.Rat)
+	for i := range out {
+		out[i] = zero
+	}
+	out[0] = big.NewRat(1, 1)
+	if n >= 1 {
+		out[1] = big.NewRat(-1, 2)
+	}
+	for k := 1; k <= m; k++ {
+		num := new(big.Int).Mul(t[k], big.NewInt(int64(2*k)))
+		if k%2 == 0 {
+			num.Neg(num)
+		}
+		four := new(big.Int).Lsh(big.NewInt(1), uint(2*k))
+		den := new(big.Int).Sub(four, big.NewInt(1))
+		den.Mul(den, four)
+		out[2*k] = new(big.Rat).SetFrac(num, den)
+	}
+	return out
+}
+
+func bigBernoulli(f *big.Float, prec uint) (*big.Float, bool) {
+	iv, ok := toInt64(f)
+	if !ok {
+		return nil, false
+	}
+	v, ok := bernoulliRat(iv)
+	if !ok {
+		return nil, false
+	}
+	return newFloat(prec).SetRat(v), true
+}
+
+// bernoulliF64 holds B_0, B_1, ... as float64, up to the last finite one;
+// it is built on first use, since the table behind it takes milliseconds.
+var bernoulliF64 = sync.OnceValue(func() []float64 {
+	var out []float64
+	for i := int64(0); i <= maxComputeInput; i++ {
+		v, _ := bernoulliRat(i)
+		f, _ := v.Float64()
+		if math.IsInf(f, 0) {
+			break
+		}
+		out = append(out, f)
+	}
+	return out
+})
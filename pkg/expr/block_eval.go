@@ -0,0 +1,182 @@
+package expr
+
+import "math/big"
+
+// BlockEvaluator evaluates an expression over runs of consecutive n, making
+// one pass over the tree per block instead of one per term. Incremental
+// subterms (see TermEvaluator) step through the block in order, subtrees
+// without n are evaluated once, and every other node applies its op across
+// the whole block before handing it to its parent.
+//
+// A BlockEvaluator is not safe for concurrent use.
+type BlockEvaluator struct {
+	root blockTerm
+	prec uint
+}
+
+// NewBlockEvaluator prepares node for block evaluation at precision prec.
+func NewBlockEvaluator(node ExprNode, prec uint) *BlockEvaluator {
+	return &BlockEvaluator{root: compileBlock(node, prec), prec: prec}
+}
+
+// EvalBlock evaluates the expression at n = start, start+1, ...,
+// start+len(dst)-1 and stores the values in dst. It returns the number of
+// leading terms that evaluated successfully; evaluation stops at the first
+// failure and dst[k:] is left nil. Values have the same semantics as
+// ExprNode.Eval, are owned by the caller, and may be passed to ReleaseFloat.
+func (b *BlockEvaluator) EvalBlock(start int64, dst []*big.Float) int {
+	k := b.root.evalBlock(start, dst, b.prec)
+	for i := k; i < len(dst); i++ {
+		dst[i] = nil
+	}
+	return k
+}
+
+// blockTerm fills out with values at n = start, start+1, ... and returns
+// how many leading entries succeeded. Entries past that are undefined and
+// hold nothing the caller must release.
+type blockTerm interface {
+	evalBlock(start int64, out []*big.Float, prec uint) int
+}
+
+// compileBlock mirrors compileTerm, but compiles the whole tree so that no
+// node is re-traversed per term.
+func compileBlock(node ExprNode, prec uint) blockTerm {
+	if !containsVar(node) {
+		return &constBlock{node: node}
+	}
+	switch nd := node.(type) {
+	case *VarNode:
+		return varBlock{}
+	case *UnaryNode:
+		if t := compileSeq(nd); t != nil {
+			return &stepBlock{t: t}
+		}
+		return &unaryBlock{op: nd, child: compileBlock(nd.Child, prec)}
+	case *BinaryNode:
+		if nd.Op == OpPow {
+			if t := compilePow(nd, prec); t != nil {
+				return &stepBlock{t: t}
+			}
+		}
+		return &binaryBlock{op: nd, left: compileBlock(nd.Left, prec), right: compileBlock(nd.Right, prec)}
+	}
+	return &stepBlock{t: exprTerm{node}}
+}
+
+type varBlock struct{}
+
+func (varBlock) evalBlock(start int64, out []*big.Float, prec uint) int {
+	for i := range out {
+		out[i] = newFloat(prec).SetInt64(start + int64(i))
+	}
+	return len(out)
+}
+
+// constBlock evaluates a subtree without n once and copies it per term.
+type constBlock struct {
+	node  ExprNode
+	done  bool
+	val   *big.Float
+	valOK bool
+}
+
+func (c *constBlock) evalBlock(_ int64, out []*big.Float, prec uint) int {
+	if !c.done {
+		c.val, c.valOK = c.node.Eval(bigZero, prec)
+		c.done = true
+	}
+	if !c.valOK {
+		return 0
+	}
+	for i := range out {
+		out[i] = newFloat(prec).Set(c.val)
+	}
+	return len(out)
+}
+
+// stepBlock drives an incremental term through the block in order.
+type stepBlock struct {
+	t  term
+	nf *big.Float
+}
+
+func (s *stepBlock) evalBlock(start int64, out []*big.Float, prec uint) int {
+	if s.nf == nil {
+		s.nf = new(big.Float).SetPrec(prec)
+	}
+	for i := range out {
+		n := start + int64(i)
+		v, ok := s.t.eval(n, s.nf.SetInt64(n), prec)
+		if !ok {
+			return i
+		}
+		out[i] = v
+	}
+	return len(out)
+}
+
+type unaryBlock struct {
+	op    *UnaryNode
+	child blockTerm
+}
+
+func (u *unaryBlock) evalBlock(start int64, out []*big.Float, prec uint) int {
+	k := u.child.evalBlock(start, out, prec)
+	for i := 0; i < k; i++ {
+		r, ok := u.op.apply(out[i], prec)
+		if r != out[i] {
+			ReleaseFloat(out[i])
+		}
+		if !ok {
+			releaseAll(out[i+1 : k])
+			return i
+		}
+		out[i] = r
+	}
+	return k
+}
+
+type binaryBlock struct {
+	op          *BinaryNode
+	left, right blockTerm
+	scratch     []*big.Float
+}
+
+func (b *binaryBlock) evalBlock(start int64, out []*big.Float, prec uint) int {
+	k := b.left.evalBlock(start, out, prec)
+	if k == 0 {
+		return 0
+	}
+	if cap(b.scratch) < k {
+		b.scratch = make([]*big.Float, k)
+	}
+	right := b.scratch[:k]
+	rk := b.right.evalBlock(start, right, prec)
+	if rk < k {
+		releaseAll(out[rk:k])
+		k = rk
+	}
+	for i := 0; i < k; i++ {
+		r, ok := b.op.apply(out[i], right[i], prec)
+		if r != out[i] {
+			ReleaseFloat(out[i])
+		}
+		ReleaseFloat(right[i])
+		right[i] = nil
+		if !ok {
+			releaseAll(out[i+1 : k])
+			releaseAll(right[i+1 : k])
+			return i
+		}
+		out[i] = r
+	}
+	return k
+}
+
+func releaseAll(fs []*big.Float) {
+	for i, f := range fs {
+		ReleaseFloat(f)
+		fs[i] = nil
+	}
+}
@@ -0,0 +1,60 @@
+package expr
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestBlockEvaluatorMatchesEval(t *testing.T) {
+	tests := []string{
+		`n`,
+		`7`,
+		`\sqrt{8}`,
+		`\frac{(-1)^{n} (4n+1)}{(2n+1)(n+3)}`,
+		`\frac{26 n! (2n)!}{(3n)! 2^{n}}`,
+		`\frac{F_{2n+1}}{(2n)!!}`,
+		`\frac{1}{n - 5}`,         // fails mid-block at n = 5
+		`(n - 40)!`,               // fails until n = 40
+		`\ln(n) + \sqrt{n} n^{n}`, // non-incremental ops throughout
+		`\binom{2n}{n} \sin(n)^{2}`,
+		`\frac{\lfloor \frac{n}{3} \rfloor}{n^{2} + 1}`,
+	}
+
+	const prec = 256
+	for _, src := range tests {
+		node, err := ParseExprLatex(src)
+		if err != nil {
+			t.Fatalf("ParseExprLatex(%q): %v", src, err)
+		}
+		t.Run(src, func(t *testing.T) {
+			be := NewBlockEvaluator(node, prec)
+			// Uneven block sizes, then a jump backwards to exercise
+			// reinitialization of incremental state.
+			starts := []struct{ start, size int64 }{{0, 1}, {1, 7}, {8, 32}, {40, 13}, {53, 64}, {3, 9}}
+			for _, bl := range starts {
+				dst := make([]*big.Float, bl.size)
+				k := be.EvalBlock(bl.start, dst)
+
+				wantK := 0
+				for ; int64(wantK) < bl.size; wantK++ {
+					nf := new(big.Float).SetPrec(prec).SetInt64(bl.start + int64(wantK))
+					want, ok := node.Eval(nf, prec)
+					if !ok {
+						break
+					}
+					if wantK < k && dst[wantK].Cmp(want) != 0 {
+						t.Errorf("n=%d: got %s, want %s", bl.start+int64(wantK), dst[wantK].Text('g', 20), want.Text('g', 20))
+					}
+				}
+				if k != wantK {
+					t.Errorf("block [%d, +%d): %d terms ok, want %d", bl.start, bl.size, k, wantK)
+				}
+				for i := k; i < len(dst); i++ {
+					if dst[i] != nil {
+						t.Errorf("block [%d, +%d): dst[%d] not cleared after failure", bl.start, bl.size, i)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,209 @@
+package expr
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// BytecodeVersion is the leading byte of every encoding produced by Encode.
+// Bump it whenever the layout changes so stored genomes can be told apart,
+// and keep the decoder reading every older version: genomes outlive the
+// release that wrote them.
+//
+//	1  ops as their values, custom ones included
+//	2  custom ops as their IDs (see appendOp); built-in ones as before
+//
+// New node types and ops need no bump: tags and op values are only ever
+// appended, so older encodings stay valid as they are.
+const BytecodeVersion byte = 2
+
+// minBytecodeVersion is the oldest version Decode reads.
+const minBytecodeVersion byte = 1
+
+// maxDecodeDepth bounds recursion when decoding untrusted input.
+const maxDecodeDepth = 4 * maxRecurseDepth
+
+// Encode serializes an expression tree into compact prefix bytecode: one
+// tag byte per node (the same tags Hash uses), followed by an op for
+// unary/binary nodes (see appendOp), a varint value for constants or a
+// uvarint Symbol for symbolic constants. Typical search trees
+// encode to 10–30 bytes versus several hundred for the pointer tree.
+func Encode(node ExprNode) []byte {
+	return AppendEncode(make([]byte, 0, 1+2*node.NodeCount()), node)
+}
+
+// AppendEncode appends the encoding of node (including the version byte)
+// to dst and returns the extended slice.
+func AppendEncode(dst []byte, node ExprNode) []byte {
+	dst = append(dst, BytecodeVersion)
+	return appendNode(dst, node)
+}
+
+func appendNode(dst []byte, node ExprNode) []byte {
+	switch n := node.(type) {
+	case *VarNode:
+		return append(dst, hashTagVar)
+	case *ConstNode:
+		dst = append(dst, hashTagConst)
+		return binary.AppendVarint(dst, n.Val)
+	case *SymbolicConstNode:
+		dst = append(dst, hashTagSymbol)
+		return binary.AppendUvarint(dst, uint64(n.Sym))
+	case *UnaryNode:
+		dst = append(dst, hashTagUnary)
+		dst = appendOp(dst, uint64(n.Op), unaryIDOf[n.Op])
+		return appendNode(dst, n.Child)
+	case *BinaryNode:
+		dst = append(dst, hashTagBinary)
+		dst = appendOp(dst, uint64(n.Op), binaryIDOf[n.Op])
+		dst = appendNode(dst, n.Left)
+		return appendNode(dst, n.Right)
+	default:
+		panic(fmt.Sprintf("expr: cannot encode %T", node))
+	}
+}
+
+// appendOp appends a built-in op as its uvarint value, and a custom op as
+// the value customOpBase followed by its ID, length first. Custom op values
+// follow the order RegisterOp was called in, which another program, or a
+// later release of this one, need not repeat; the ID it is looked up by.
+func appendOp(dst []byte, op uint64, id string) []byte {
+	if op < customOpBase {
+		return binary.AppendUvarint(dst, op)
+	}
+	dst = binary.AppendUvarint(dst, customOpBase)
+	dst = binary.AppendUvarint(dst, uint64(len(id)))
+	return append(dst, id...)
+}
+
+// Decode rebuilds the tree encoded by Encode, by this release or an older
+// one. It rejects unknown versions, truncated input and trailing bytes.
+func Decode(b []byte) (ExprNode, error) {
+	node, rest, err := DecodePrefix(b)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) != 0 {
+		return nil, fmt.Errorf("bytecode: %d trailing bytes", len(rest))
+	}
+	return node, nil
+}
+
+// DecodePrefix decodes one encoded tree from the front of b and returns the
+// unconsumed remainder, so several trees can be stored back to back.
+func DecodePrefix(b []byte) (ExprNode, []byte, error) {
+	if len(b) == 0 {
+		return nil, nil, fmt.Errorf("bytecode: empty input")
+	}
+	if b[0] > BytecodeVersion {
+		return nil, nil, fmt.Errorf("bytecode: version %d is newer than this release reads (%d)", b[0], BytecodeVersion)
+	}
+	if b[0] < minBytecodeVersion {
+		return nil, nil, fmt.Errorf("bytecode: unsupported version %d", b[0])
+	}
+	d := decoder{buf: b, pos: 1, version: b[0]}
+	node, err := d.node(0)
+	if err != nil {
+		return nil, nil, err
+	}
+	return node, b[d.pos:], nil
+}
+
+type decoder struct {
+	buf     []byte
+	pos     int
+	version byte // of the encoding in buf
+}
+
+func (d *decoder) node(depth int) (ExprNode, error) {
+	if depth > maxDecodeDepth {
+		return nil, fmt.Errorf("bytecode: tree deeper than %d", maxDecodeDepth)
+	}
+	if d.pos >= len(d.buf) {
+		return nil, fmt.Errorf("bytecode: unexpected end of input at byte %d", d.pos)
+	}
+	tag := d.buf[d.pos]
+	d.pos++
+	switch tag {
+	case hashTagVar:
+		return &VarNode{}, nil
+	case hashTagConst:
+		v, n := binary.Varint(d.buf[d.pos:])
+		if n <= 0 {
+			return nil, fmt.Errorf("bytecode: bad constant at byte %d", d.pos)
+		}
+		d.pos += n
+		return &ConstNode{Val: v}, nil
+	case hashTagSymbol:
+		s, err := d.uvarint()
+		if err != nil {
+			return nil, err
+		}
+		if s >= uint64(numSymbols) {
+			return nil, fmt.Errorf("bytecode: unknown symbol %d", s)
+		}
+		return &SymbolicConstNode{Sym: Symbol(s)}, nil
+	case hashTagUnary:
+		op, err := decodeOp(d, unaryOpIDs)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := unaryOpNames[op]; !ok {
+			return nil, fmt.Errorf("bytecode: unknown unary op %d", op)
+		}
+		child, err := d.node(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		return &UnaryNode{Op: op, Child: child}, nil
+	case hashTagBinary:
+		op, err := decodeOp(d, binaryOpIDs)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := binaryOpSymbols[op]; !ok {
+			return nil, fmt.Errorf("bytecode: unknown binary op %d", op)
+		}
+		left, err := d.node(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		right, err := d.node(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		return &BinaryNode{Op: op, Left: left, Right: right}, nil
+	default:
+		return nil, fmt.Errorf("bytecode: unknown tag %d at byte %d", tag, d.pos-1)
+	}
+}
+
+// decodeOp reads an op written by appendOp, or in version 1 a bare value,
+// and returns its value in this process; ids are the ops of its arity by
+// ID.
+func decodeOp[Op UnaryOp | BinaryOp](d *decoder, ids map[string]Op) (Op, error) {
+	op, err := d.uvarint()
+	if err != nil || op != customOpBase || d.version < 2 {
+		return Op(op), err
+	}
+	size, err := d.uvarint()
+	if err != nil || size > uint64(len(d.buf)-d.pos) {
+		return 0, fmt.Errorf("bytecode: bad op id at byte %d", d.pos)
+	}
+	id := string(d.buf[d.pos : d.pos+int(size)])
+	d.pos += int(size)
+	v, ok := ids[id]
+	if !ok {
+		return 0, fmt.Errorf("bytecode: op %q is not registered", id)
+	}
+	return v, nil
+}
+
+func (d *decoder) uvarint() (uint64, error) {
+	v, n := binary.Uvarint(d.buf[d.pos:])
+	if n <= 0 {
+		return 0, fmt.Errorf("bytecode: bad op at byte %d", d.pos)
+	}
+	d.pos += n
+	return v, nil
+}
@@ -0,0 +1,115 @@
+package expr
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+func TestBytecode_RoundTrip(t *testing.T) {
+	exprs := []string{
+		`n`,
+		`-7`,
+		`n!`,
+		`\frac{(-1)^{n}}{2n+1}`,
+		`\binom{2n}{n} 16^{n}`,
+		`\sqrt{|\sin(n)|} - \ln(n+1)`,
+		`\lfloor \frac{n}{3} \rfloor + \lceil \frac{n}{2} \rceil`,
+		`F_{n} (2n)!! n^{-3}`,
+		`9223372036854775807 - n`,
+		`\frac{\pi^{2}}{6} - e n + \phi \gamma`,
+	}
+	for _, src := range exprs {
+		node, err := ParseExprLatex(src)
+		if err != nil {
+			t.Fatalf("ParseExprLatex(%q): %v", src, err)
+		}
+		b := Encode(node)
+		got, err := Decode(b)
+		if err != nil {
+			t.Fatalf("Decode(%q): %v", src, err)
+		}
+		if !Equal(node, got) {
+			t.Errorf("round trip of %q: got %s, want %s", src, got, node)
+		}
+		t.Logf("%-45s %3d nodes -> %3d bytes", src, node.NodeCount(), len(b))
+	}
+}
+
+func TestBytecode_Rejects(t *testing.T) {
+	good := Encode(&BinaryNode{Op: OpAdd, Left: &VarNode{}, Right: &ConstNode{Val: 3}})
+	cases := map[string][]byte{
+		"empty":     nil,
+		"version":   append([]byte{BytecodeVersion + 1}, good[1:]...),
+		"truncated": good[:len(good)-2],
+		"trailing":  append(append([]byte{}, good...), hashTagVar),
+		"bad tag":   {BytecodeVersion, 0xff},
+		"bad op":    {BytecodeVersion, hashTagUnary, 0x7f, hashTagVar},
+		"bad sym":   {BytecodeVersion, hashTagSymbol, 0x7f},
+		"bad id":    {BytecodeVersion, hashTagUnary, 0x80, 0x08, 9, 'c', hashTagVar},
+		"no id":     {BytecodeVersion, hashTagUnary, 0x80, 0x08, 6, 'n', 'o', 's', 'u', 'c', 'h', hashTagVar},
+	}
+	for name, b := range cases {
+		if _, err := Decode(b); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestBytecode_DecodePrefix(t *testing.T) {
+	a := &UnaryNode{Op: OpFactorial, Child: &VarNode{}}
+	b := &ConstNode{Val: -12}
+	buf := AppendEncode(Encode(a), b)
+
+	gotA, rest, err := DecodePrefix(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gotB, err := Decode(rest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !Equal(a, gotA) || !Equal(b, gotB) {
+		t.Errorf("got %s, %s; want %s, %s", gotA, gotB, a, b)
+	}
+}
+
+func TestBytecode_ReadsOlderVersions(t *testing.T) {
+	// Version 1 as the first release wrote it: (n + 3) and sqrt(n!).
+	v1 := map[string][]byte{
+		"(n + 3)":    {1, hashTagBinary, byte(OpAdd), hashTagVar, hashTagConst, 6},
+		"sqrt((n)!)": {1, hashTagUnary, byte(OpSqrt), hashTagUnary, byte(OpFactorial), hashTagVar},
+	}
+	for want, b := range v1 {
+		node, err := Decode(b)
+		if err != nil {
+			t.Fatalf("%s: %v", want, err)
+		}
+		if node.String() != want {
+			t.Errorf("decoded %s, want %s", node, want)
+		}
+		// Built-in ops are laid out as they were.
+		if got := Encode(node); !bytes.Equal(got[1:], b[1:]) {
+			t.Errorf("%s re-encodes as % x, version 1 was % x", want, got, b)
+		}
+	}
+
+	// Version 1 stored custom ops by value, which is what this process
+	// registered them as; version 2 stores them by ID.
+	if err := registerTestOps(); err != nil {
+		t.Fatal(err)
+	}
+	cube, _ := LookupUnaryOp("cube")
+	node := &UnaryNode{Op: cube, Child: &VarNode{}}
+	old := binary.AppendUvarint([]byte{1, hashTagUnary}, uint64(cube))
+	if got, err := Decode(append(old, hashTagVar)); err != nil || !Equal(got, node) {
+		t.Errorf("version 1 custom op: %v, %v", got, err)
+	}
+	b := Encode(node)
+	if !bytes.Contains(b, []byte("cube")) {
+		t.Errorf("custom op encoded as % x, without its id", b)
+	}
+	if got, err := Decode(b); err != nil || !Equal(got, node) {
+		t.Errorf("custom op round trip: %v, %v", got, err)
+	}
+}
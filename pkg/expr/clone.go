@@ -8,6 +8,10 @@ func (c *ConstNode) Clone() ExprNode {
 	return &ConstNode{Val: c.Val}
 }
 
+func (s *SymbolicConstNode) Clone() ExprNode {
+	return &SymbolicConstNode{Sym: s.Sym}
+}
+
 func (u *UnaryNode) Clone() ExprNode {
 	return &UnaryNode{
 		Op:    u.Op,
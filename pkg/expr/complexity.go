@@ -2,16 +2,18 @@ package expr
 
 import "math"
 
-func (v *VarNode) NodeCount() int { return 1 }
-func (c *ConstNode) NodeCount() int { return 1 }
-func (u *UnaryNode) NodeCount() int { return 1 + u.Child.NodeCount() }
+func (v *VarNode) NodeCount() int           { return 1 }
+func (c *ConstNode) NodeCount() int         { return 1 }
+func (s *SymbolicConstNode) NodeCount() int { return 1 }
+func (u *UnaryNode) NodeCount() int         { return 1 + u.Child.NodeCount() }
 func (b *BinaryNode) NodeCount() int {
 	return 1 + b.Left.NodeCount() + b.Right.NodeCount()
 }
 
-func (v *VarNode) Depth() int { return 1 }
-func (c *ConstNode) Depth() int { return 1 }
-func (u *UnaryNode) Depth() int { return 1 + u.Child.Depth() }
+func (v *VarNode) Depth() int           { return 1 }
+func (c *ConstNode) Depth() int         { return 1 }
+func (s *SymbolicConstNode) Depth() int { return 1 }
+func (u *UnaryNode) Depth() int         { return 1 + u.Child.Depth() }
 func (b *BinaryNode) Depth() int {
 	ld := b.Left.Depth()
 	rd := b.Right.Depth()
@@ -22,7 +24,8 @@ func (b *BinaryNode) Depth() int {
 }
 
 // WeightedComplexity returns a complexity score with heavier weight for
-// operations that are more "expensive" (factorial, trig, etc.).
+// operations that are more "expensive" (factorial, trig, etc.). Fitness
+// now uses DescriptionLength, which also charges constants for their size.
 func WeightedComplexity(node ExprNode) float64 {
 	switch n := node.(type) {
 	case *VarNode:
@@ -36,6 +39,8 @@ func WeightedComplexity(node ExprNode) float64 {
 			return 1.0
 		}
 		return 1.0 + math.Log10(float64(v))
+	case *SymbolicConstNode:
+		return 1.5
 	case *UnaryNode:
 		w := unaryWeight(n.Op)
 		return w + WeightedComplexity(n.Child)
@@ -53,9 +58,9 @@ func unaryWeight(op UnaryOp) float64 {
 		return 1.0
 	case OpFactorial, OpAltSign:
 		return 2.0
-	case OpDoubleFactorial, OpFibonacci:
+	case OpDoubleFactorial, OpSubfactorial, OpFibonacci, OpGamma, OpDigamma, OpBernoulli, OpPrime:
 		return 3.0
-	case OpSin, OpCos, OpLn:
+	case OpSin, OpCos, OpTan, OpExp, OpLn, OpArctan, OpArcsin, OpSinh, OpCosh, OpTanh:
 		return 3.0
 	case OpFloor, OpCeil:
 		return 2.0
@@ -74,9 +79,76 @@ func binaryWeight(op BinaryOp) float64 {
 		return 1.5
 	case OpPow:
 		return 2.0
-	case OpBinomial:
+	case OpBinomial, OpPochhammer:
 		return 3.0
+	case OpMod, OpRoot:
+		return 2.0
 	default:
 		return 1.5
 	}
 }
+
+// DescriptionLength returns the minimum-description-length cost of node in
+// bits: each node pays for its symbol under a fixed prior that makes
+// n, +, -, *, / cheap and rare ops expensive, and each constant pays one
+// sign bit plus the Elias gamma length of |v|+1. Unlike node counts or
+// WeightedComplexity, a single large constant cannot hide information:
+// 26390 costs 32 bits, more than 2·3·5 written out.
+func DescriptionLength(node ExprNode) float64 {
+	switch n := node.(type) {
+	case *VarNode:
+		return varBits
+	case *ConstNode:
+		return constBits + intBits(n.Val)
+	case *SymbolicConstNode:
+		return symbolBits
+	case *UnaryNode:
+		return unaryBits(n.Op) + DescriptionLength(n.Child)
+	case *BinaryNode:
+		return binaryBits(n.Op) + DescriptionLength(n.Left) + DescriptionLength(n.Right)
+	default:
+		return varBits
+	}
+}
+
+// Symbol costs (bits) of the leaves under the DescriptionLength prior.
+const (
+	varBits    = 2.0
+	constBits  = 2.0 // plus intBits(value)
+	symbolBits = 8.0 // π, e, φ or γ: priced like the integer 3
+)
+
+// intBits is the length of a signed integer code: a sign bit and the Elias
+// gamma code of |v|+1 (2·⌊log2(|v|+1)⌋+1 bits), so 0 and ±1 cost 2–4 bits
+// and each doubling of magnitude costs two more.
+func intBits(v int64) float64 {
+	u := uint64(v)
+	if v < 0 {
+		u = uint64(-(v + 1)) + 1 // |v| without overflowing at MinInt64
+	}
+	return 1 + 2*math.Floor(math.Log2(float64(u)+1)) + 1
+}
+
+func unaryBits(op UnaryOp) float64 {
+	switch op {
+	case OpNeg:
+		return 3.0
+	case OpFactorial, OpAltSign:
+		return 4.0
+	case OpSqrt, OpAbs:
+		return 5.0
+	default: // double factorial, Fibonacci, Γ, Bernoulli, prime, trig, inverse trig and hyperbolic, exp, ln, floor, ceil
+		return 6.0
+	}
+}
+
+func binaryBits(op BinaryOp) float64 {
+	switch op {
+	case OpAdd, OpSub, OpMul, OpDiv:
+		return 3.0
+	case OpPow:
+		return 4.0
+	default: // binomial, Pochhammer, mod, root
+		return 5.0
+	}
+}
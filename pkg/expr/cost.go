@@ -0,0 +1,55 @@
+package expr
+
+// EvalCost estimates the cost of evaluating node at one n at search
+// precision, in units of one big.Float addition. The per-op costs are
+// rough relative timings at 128-1024 bits: factorials, binomials and the
+// transcendental functions cost tens of additions, so a small tree of
+// factorials can cost more than a large rational one. Leaves are free;
+// the block evaluator hoists constant subtrees, but they are charged here
+// like any other, which keeps the estimate a plain sum over nodes.
+func EvalCost(node ExprNode) float64 {
+	switch n := node.(type) {
+	case *UnaryNode:
+		return unaryCost(n.Op) + EvalCost(n.Child)
+	case *BinaryNode:
+		return binaryCost(n.Op) + EvalCost(n.Left) + EvalCost(n.Right)
+	default:
+		return 0
+	}
+}
+
+func unaryCost(op UnaryOp) float64 {
+	switch op {
+	case OpNeg, OpAbs, OpAltSign:
+		return 0.5
+	case OpFloor, OpCeil:
+		return 1
+	case OpSqrt:
+		return 8
+	case OpFibonacci, OpBernoulli, OpPrime:
+		return 10
+	case OpFactorial, OpDoubleFactorial, OpSubfactorial:
+		return 30
+	default: // sin, cos, tan, exp, ln, Γ, ψ, arctan, arcsin, sinh, cosh, tanh
+		return 60
+	}
+}
+
+func binaryCost(op BinaryOp) float64 {
+	switch op {
+	case OpAdd, OpSub:
+		return 1
+	case OpMul:
+		return 2
+	case OpDiv:
+		return 4
+	case OpPow:
+		return 8
+	case OpMod:
+		return 8
+	case OpRoot:
+		return 120
+	default: // binomial, Pochhammer
+		return 40
+	}
+}
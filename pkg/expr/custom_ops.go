@@ -0,0 +1,157 @@
+package expr
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+)
+
+// OpDef describes an op added by RegisterOp.
+type OpDef struct {
+	ID    string // identifier (see OpIDs); also parses as \operatorname{ID}
+	LaTeX string // command it renders and parses as, without the backslash; empty = \operatorname{ID}
+	Arity int    // 1 or 2
+
+	// Eval computes the op on its arguments at prec bits, reporting false
+	// where it is undefined. It may reuse args[0] as the result but must not
+	// retain the other arguments.
+	Eval func(args []*big.Float, prec uint) (*big.Float, bool)
+
+	// EvalF64 is the float64 form. If nil, the op is evaluated through Eval
+	// at float64 precision, which is correct but slow.
+	EvalF64 func(args []float64) (float64, bool)
+}
+
+// Custom op values start past any built-in op, in registration order.
+const customOpBase = 1 << 10
+
+var (
+	customUnary  = map[UnaryOp]*OpDef{}
+	customBinary = map[BinaryOp]*OpDef{}
+
+	// binaryFunctions maps \operatorname names of binary custom ops, which
+	// parse with two arguments, \operatorname{ID}{(a)}{(b)}.
+	binaryFunctions = map[string]BinaryOp{}
+)
+
+// RegisterOp adds a custom op that parses, prints, evaluates, encodes and
+// hashes like a built-in one and is drawn by mutation once the engine's
+// pool includes it (see pool.WithCustomOps). Op values are assigned in
+// registration order, but bytecode stores the ID, so programs reading each
+// other's genomes need only register the same IDs. An ID or LaTeX name the
+// parser already takes as a function or binary op is an error, rather than
+// replacing it. Like RegisterFunction it is meant for init time.
+//
+// Exact (rational) evaluation and the term-ratio analyses treat a custom
+// op as unknown, so sequence targets and hypergeometric detection skip
+// trees that use one.
+func RegisterOp(def OpDef) error {
+	if def.ID == "" || letterRun(def.ID) != len(def.ID) {
+		return fmt.Errorf("op id %q must be letters", def.ID)
+	}
+	if _, ok := unaryOpIDs[def.ID]; ok {
+		return fmt.Errorf("op %q already registered", def.ID)
+	}
+	if _, ok := binaryOpIDs[def.ID]; ok {
+		return fmt.Errorf("op %q already registered", def.ID)
+	}
+	if def.Eval == nil {
+		return fmt.Errorf("op %q has no Eval", def.ID)
+	}
+	// Both names go into the parser's tables, which must not lose what
+	// \sin or an earlier op already parses as.
+	for _, name := range []string{def.ID, def.LaTeX} {
+		if _, ok := functions[name]; ok {
+			return fmt.Errorf("op %q: %q already parses as a function", def.ID, name)
+		}
+		if _, ok := binaryFunctions[name]; ok {
+			return fmt.Errorf("op %q: %q already parses as a binary op", def.ID, name)
+		}
+	}
+	cmd := `\operatorname{` + def.ID + `}`
+	if def.LaTeX != "" {
+		cmd = `\` + def.LaTeX
+	}
+	d := def
+	switch def.Arity {
+	case 1:
+		op := UnaryOp(customOpBase + len(customUnary))
+		customUnary[op] = &d
+		unaryOpIDs[def.ID] = op
+		unaryIDOf[op] = def.ID
+		unaryOpNames[op] = def.ID
+		unaryLaTeX[op] = [2]string{cmd + "{(", ")}"}
+		functions[def.ID] = UnaryFunc(op)
+		if def.LaTeX != "" {
+			functions[def.LaTeX] = UnaryFunc(op)
+		}
+	case 2:
+		op := BinaryOp(customOpBase + len(customBinary))
+		customBinary[op] = &d
+		binaryOpIDs[def.ID] = op
+		binaryIDOf[op] = def.ID
+		binaryOpSymbols[op] = def.ID
+		binaryLaTeX[op] = [3]string{cmd + "{(", ")}{(", ")}"}
+		binaryFunctions[def.ID] = op
+		if def.LaTeX != "" {
+			binaryFunctions[def.LaTeX] = op
+		}
+	default:
+		return fmt.Errorf("op %q has arity %d, want 1 or 2", def.ID, def.Arity)
+	}
+	return nil
+}
+
+// CustomUnaryOps returns the registered unary custom ops, in registration
+// order.
+func CustomUnaryOps() []UnaryOp {
+	return sortedOps(customUnary)
+}
+
+// CustomBinaryOps returns the registered binary custom ops, in
+// registration order.
+func CustomBinaryOps() []BinaryOp {
+	return sortedOps(customBinary)
+}
+
+func sortedOps[Op UnaryOp | BinaryOp](m map[Op]*OpDef) []Op {
+	ops := make([]Op, 0, len(m))
+	for op := range m {
+		ops = append(ops, op)
+	}
+	sort.Slice(ops, func(i, j int) bool { return ops[i] < ops[j] })
+	return ops
+}
+
+func (d *OpDef) eval(prec uint, args ...*big.Float) (*big.Float, bool) {
+	r, ok := d.Eval(args, prec)
+	if !ok || r == nil || r.IsInf() {
+		return nil, false
+	}
+	return r, true
+}
+
+func (d *OpDef) evalF64(args ...float64) (float64, bool) {
+	var r float64
+	ok := true
+	if d.EvalF64 != nil {
+		r, ok = d.EvalF64(args)
+	} else {
+		bigs := make([]*big.Float, len(args))
+		for i, a := range args {
+			if math.IsInf(a, 0) || math.IsNaN(a) {
+				return 0, false
+			}
+			bigs[i] = new(big.Float).SetFloat64(a)
+		}
+		var v *big.Float
+		if v, ok = d.eval(53, bigs...); ok {
+			r, _ = v.Float64()
+		}
+	}
+	if !ok || math.IsInf(r, 0) || math.IsNaN(r) {
+		return 0, false
+	}
+	return r, true
+}
@@ -0,0 +1,97 @@
+package expr
+
+import (
+	"math"
+	"math/big"
+	"sync"
+	"testing"
+)
+
+var registerTestOps = sync.OnceValue(func() error {
+	if err := RegisterOp(OpDef{
+		ID:    "cube",
+		Arity: 1,
+		Eval: func(args []*big.Float, prec uint) (*big.Float, bool) {
+			x := new(big.Float).SetPrec(prec).Mul(args[0], args[0])
+			return args[0].Mul(args[0], x), true
+		},
+	}); err != nil {
+		return err
+	}
+	return RegisterOp(OpDef{
+		ID:    "hypot",
+		Arity: 2,
+		Eval: func(args []*big.Float, prec uint) (*big.Float, bool) {
+			y := new(big.Float).SetPrec(prec).Mul(args[1], args[1])
+			x := args[0].Mul(args[0], args[0])
+			return x.Sqrt(x.Add(x, y)), true
+		},
+		EvalF64: func(args []float64) (float64, bool) {
+			return math.Hypot(args[0], args[1]), true
+		},
+	})
+})
+
+func TestRegisterOp(t *testing.T) {
+	if err := registerTestOps(); err != nil {
+		t.Fatal(err)
+	}
+	node, err := ParseExprLatex(`\operatorname{cube}(n) + \operatorname{hypot}{(n)}{(3)}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n := new(big.Float).SetPrec(128).SetInt64(4)
+	v, ok := node.Eval(n, 128)
+	if !ok {
+		t.Fatal("Eval failed")
+	}
+	if f, _ := v.Float64(); f != 69 {
+		t.Errorf("Eval = %v, want 69", f)
+	}
+	if f, ok := node.EvalF64(4); !ok || f != 69 {
+		t.Errorf("EvalF64 = %v, %v, want 69", f, ok)
+	}
+
+	if got, want := node.String(), "(cube(n) + hypot(n, 3))"; got != want {
+		t.Errorf("String = %q, want %q", got, want)
+	}
+	again, err := ParseExprLatex(node.LaTeX())
+	if err != nil || !Equal(node, again) {
+		t.Errorf("LaTeX %q reparses to %v, %v", node.LaTeX(), again, err)
+	}
+	dec, err := Decode(Encode(node))
+	if err != nil || !Equal(node, dec) {
+		t.Errorf("bytecode round trip: %v, %v", dec, err)
+	}
+
+	if _, ok := LookupUnaryOp("cube"); !ok {
+		t.Error("cube not in the op table")
+	}
+	if err := RegisterOp(OpDef{ID: "cube", Arity: 1, Eval: func([]*big.Float, uint) (*big.Float, bool) { return nil, false }}); err == nil {
+		t.Error("registering cube twice succeeded")
+	}
+	if err := RegisterOp(OpDef{ID: "tri", Arity: 3}); err == nil {
+		t.Error("arity 3 accepted")
+	}
+
+	// Neither name may take over one the parser already knows.
+	eval := func(args []*big.Float, _ uint) (*big.Float, bool) { return args[0], true }
+	for _, def := range []OpDef{
+		{ID: "mysin", LaTeX: "sin", Arity: 1, Eval: eval},  // a built-in function
+		{ID: "psi", Arity: 1, Eval: eval},                  // a built-in's LaTeX name
+		{ID: "cubic", LaTeX: "cube", Arity: 1, Eval: eval}, // an earlier unary op
+		{ID: "pair", LaTeX: "hypot", Arity: 2, Eval: eval}, // an earlier binary op
+		{ID: "hypotenuse", LaTeX: "hypot", Arity: 1, Eval: eval},
+	} {
+		if err := RegisterOp(def); err == nil {
+			t.Errorf("RegisterOp(%s, \\%s) succeeded", def.ID, def.LaTeX)
+		}
+		if _, ok := LookupUnaryOp(def.ID); ok {
+			t.Errorf("rejected op %s left in the op table", def.ID)
+		}
+	}
+	if node, err := ParseExprLatex(`\sin(n)`); err != nil || node.(*UnaryNode).Op != OpSin {
+		t.Errorf(`\sin(n) parses as %v, %v`, node, err)
+	}
+}
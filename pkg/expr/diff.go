@@ -0,0 +1,51 @@
+package expr
+
+// Change is one difference between two trees: the subtree Old at preorder
+// index Index of the first tree became New, at NewIndex of the second.
+type Change struct {
+	Index, NewIndex int
+	Old, New        ExprNode
+}
+
+// Diff returns the smallest subtrees that differ between a and b, in
+// preorder. Nodes that match in kind, operation and value are descended
+// into; the first mismatch on each path is one Change. Shared subtrees
+// (as ReplaceAt leaves them) are skipped without being walked, and equal
+// trees give no changes.
+func Diff(a, b ExprNode) []Change {
+	var changes []Change
+	diffAt(a, b, 0, 0, &changes)
+	return changes
+}
+
+func diffAt(a, b ExprNode, ia, ib int, changes *[]Change) {
+	if a == b {
+		return
+	}
+	switch x := a.(type) {
+	case *VarNode:
+		if _, ok := b.(*VarNode); ok {
+			return
+		}
+	case *ConstNode:
+		if y, ok := b.(*ConstNode); ok && y.Val == x.Val {
+			return
+		}
+	case *SymbolicConstNode:
+		if y, ok := b.(*SymbolicConstNode); ok && y.Sym == x.Sym {
+			return
+		}
+	case *UnaryNode:
+		if y, ok := b.(*UnaryNode); ok && y.Op == x.Op {
+			diffAt(x.Child, y.Child, ia+1, ib+1, changes)
+			return
+		}
+	case *BinaryNode:
+		if y, ok := b.(*BinaryNode); ok && y.Op == x.Op {
+			diffAt(x.Left, y.Left, ia+1, ib+1, changes)
+			diffAt(x.Right, y.Right, ia+1+x.Left.NodeCount(), ib+1+y.Left.NodeCount(), changes)
+			return
+		}
+	}
+	*changes = append(*changes, Change{Index: ia, NewIndex: ib, Old: a, New: b})
+}
@@ -0,0 +1,29 @@
+package expr
+
+import "testing"
+
+func TestDiff(t *testing.T) {
+	n := &VarNode{}
+	c := func(v int64) ExprNode { return &ConstNode{Val: v} }
+	// (2n + 1)!
+	tree := &UnaryNode{Op: OpFactorial, Child: &BinaryNode{Op: OpAdd, Left: &BinaryNode{Op: OpMul, Left: c(2), Right: n}, Right: c(1)}}
+
+	if d := Diff(tree, tree.Clone()); len(d) != 0 {
+		t.Errorf("Diff of equal trees = %v", d)
+	}
+	changed := ReplaceAt(ReplaceAt(tree, 3, c(4)), 5, n)
+	d := Diff(tree, changed)
+	if len(d) != 2 || d[0].Index != 3 || d[0].New.String() != "4" || d[1].Index != 5 || d[1].Old.String() != "1" {
+		t.Errorf("Diff = %+v", d)
+	}
+	// A different operation is one change at that node, not one per child.
+	sub := ReplaceAt(tree, 1, &BinaryNode{Op: OpSub, Left: &BinaryNode{Op: OpMul, Left: c(2), Right: n}, Right: c(1)})
+	if d := Diff(tree, sub); len(d) != 1 || d[0].Index != 1 {
+		t.Errorf("Diff across an op change = %+v", d)
+	}
+	// Indices into the second tree follow its own shape.
+	grown := ReplaceAt(ReplaceAt(tree, 2, &UnaryNode{Op: OpNeg, Child: &BinaryNode{Op: OpMul, Left: c(2), Right: n}}), 6, c(5))
+	if d := Diff(tree, grown); len(d) != 2 || d[1].Index != 5 || d[1].NewIndex != 6 || NodeAt(grown, d[1].NewIndex) != d[1].New {
+		t.Errorf("Diff after growth = %+v", d)
+	}
+}
@@ -0,0 +1,88 @@
+package expr
+
+import (
+	"math"
+	"math/big"
+)
+
+// bigDigamma computes ψ(x) = Γ'(x)/Γ(x) at prec. x is shifted up past
+// digammaShift by ψ(x) = ψ(x+1) - 1/x, and ψ there summed by the
+// asymptotic expansion
+//
+//	ψ(x) = ln x - 1/(2x) - Σ_{k≥1} B_2k/(2k x^2k),
+//
+// which, that far out, falls below 2^-prec long before its terms turn to
+// grow. ψ is undefined at 0 and the negative integers, and below
+// -maxComputeInput, where the shift would take too long.
+func bigDigamma(x *big.Float, prec uint) (*big.Float, bool) {
+	if x.IsInf() || x.Cmp(gammaMin) < 0 {
+		return nil, false
+	}
+	if x.IsInt() && x.Sign() <= 0 {
+		return nil, false
+	}
+
+	// ψ has a root near 1.46 and its value drops bits there, as Γ's
+	// callers would; the guard bits cover the shift and the series.
+	wp := prec + 32
+	z := new(big.Float).SetPrec(wp).Set(x)
+	shift := new(big.Float).SetPrec(wp)
+	t := new(big.Float).SetPrec(wp)
+	limit := new(big.Float).SetInt64(digammaShift(wp))
+	for z.Cmp(limit) < 0 {
+		shift.Add(shift, t.Quo(bigOne, z))
+		z.Add(z, bigOne)
+	}
+
+	r := bigLn(z, wp)
+	r.Sub(r, t.Quo(bigHalf, z))
+	inv2 := new(big.Float).SetPrec(wp).Mul(z, z)
+	inv2.Quo(bigOne, inv2)
+	pow := new(big.Float).SetPrec(wp).Set(inv2)
+	eps := new(big.Float).SetMantExp(bigOne, r.MantExp(nil)-int(wp))
+	eps.Abs(eps)
+	b := new(big.Float).SetPrec(wp)
+	for k := int64(1); k <= int64(wp); k++ {
+		bk, ok := bernoulliRat(2 * k)
+		if !ok {
+			break
+		}
+		b.SetRat(bk)
+		t.Mul(b, pow)
+		t.Quo(t, b.SetInt64(2*k))
+		r.Sub(r, t)
+		if t.Abs(t).Cmp(eps) < 0 {
+			break
+		}
+		pow.Mul(pow, inv2)
+	}
+	r.Sub(r, shift)
+	return newFloat(prec).Set(r), true
+}
+
+var bigHalf = big.NewFloat(0.5)
+
+// digammaShift is how far bigDigamma shifts its argument at prec: past
+// prec/2, the least term of the expansion, about e^(-2πx), is far below
+// 2^-prec, and it is reached within a few dozen terms.
+func digammaShift(prec uint) int64 { return int64(prec/2) + 8 }
+
+// digammaF64 is ψ(x) in float64: negative x reflected by
+// ψ(x) = ψ(1-x) - π/tan(πx), then shifted past 16 and summed to B_10.
+func digammaF64(x float64) (float64, bool) {
+	if math.IsInf(x, 0) || math.IsNaN(x) || x <= 0 && x == math.Trunc(x) {
+		return 0, false
+	}
+	if x < 0 {
+		r, ok := digammaF64(1 - x)
+		return r - math.Pi/math.Tan(math.Pi*x), ok
+	}
+	shift := 0.0
+	for ; x < 16; x++ {
+		shift += 1 / x
+	}
+	t := 1 / (x * x)
+	r := math.Log(x) - 0.5/x -
+		t*(1.0/12-t*(1.0/120-t*(1.0/252-t*(1.0/240-t*(1.0/132)))))
+	return r - shift, true
+}
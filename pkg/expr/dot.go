@@ -0,0 +1,95 @@
+package expr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// nodeLabel is the short label a node gets in tree diagrams: its op symbol
+// or leaf value, without its children.
+func nodeLabel(node ExprNode) string {
+	switch n := node.(type) {
+	case *VarNode:
+		return "n"
+	case *ConstNode:
+		return strconv.FormatInt(n.Val, 10)
+	case *SymbolicConstNode:
+		return n.Sym.String()
+	case *UnaryNode:
+		if name, ok := unaryOpNames[n.Op]; ok {
+			return name
+		}
+		return fmt.Sprintf("unary(%d)", n.Op)
+	case *BinaryNode:
+		if sym, ok := binaryOpSymbols[n.Op]; ok {
+			return sym
+		}
+		return fmt.Sprintf("binary(%d)", n.Op)
+	}
+	return fmt.Sprintf("%T", node)
+}
+
+// ToDOT renders node as a Graphviz digraph, one graph node per tree node in
+// preorder (so node i is NodeAt(root, i)). Pipe it to `dot -Tsvg` to view.
+func ToDOT(node ExprNode) string {
+	var b strings.Builder
+	b.WriteString("digraph expr {\n")
+	b.WriteString("\tnode [shape=box, fontname=\"monospace\"];\n")
+	next := 0
+	var walk func(ExprNode) int
+	walk = func(nd ExprNode) int {
+		id := next
+		next++
+		shape := ""
+		switch nd.(type) {
+		case *VarNode, *ConstNode, *SymbolicConstNode:
+			shape = ", shape=ellipse"
+		}
+		fmt.Fprintf(&b, "\tn%d [label=%q%s];\n", id, nodeLabel(nd), shape)
+		switch n := nd.(type) {
+		case *UnaryNode:
+			fmt.Fprintf(&b, "\tn%d -> n%d;\n", id, walk(n.Child))
+		case *BinaryNode:
+			fmt.Fprintf(&b, "\tn%d -> n%d [label=\"L\"];\n", id, walk(n.Left))
+			fmt.Fprintf(&b, "\tn%d -> n%d [label=\"R\"];\n", id, walk(n.Right))
+		}
+		return id
+	}
+	walk(node)
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// ToASCII renders node as an indented tree, one node per line, children
+// below their parent (left operand first):
+//
+//	/
+//	├── 1
+//	└── !
+//	    └── n
+func ToASCII(node ExprNode) string {
+	var b strings.Builder
+	b.WriteString(nodeLabel(node))
+	b.WriteByte('\n')
+	asciiChildren(&b, node, "")
+	return b.String()
+}
+
+func asciiChildren(b *strings.Builder, node ExprNode, prefix string) {
+	var children []ExprNode
+	switch n := node.(type) {
+	case *UnaryNode:
+		children = []ExprNode{n.Child}
+	case *BinaryNode:
+		children = []ExprNode{n.Left, n.Right}
+	}
+	for i, c := range children {
+		branch, indent := "├── ", "│   "
+		if i == len(children)-1 {
+			branch, indent = "└── ", "    "
+		}
+		b.WriteString(prefix + branch + nodeLabel(c) + "\n")
+		asciiChildren(b, c, prefix+indent)
+	}
+}
@@ -0,0 +1,49 @@
+package expr
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestToASCII(t *testing.T) {
+	node := &BinaryNode{
+		Op:   OpDiv,
+		Left: &ConstNode{Val: 1},
+		Right: &BinaryNode{
+			Op:    OpMul,
+			Left:  &UnaryNode{Op: OpFactorial, Child: &VarNode{}},
+			Right: &ConstNode{Val: -3},
+		},
+	}
+	want := `/
+├── 1
+└── *
+    ├── !
+    │   └── n
+    └── -3
+`
+	if got := ToASCII(node); got != want {
+		t.Errorf("ToASCII =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestToDOT(t *testing.T) {
+	node := &BinaryNode{
+		Op:    OpAdd,
+		Left:  &UnaryNode{Op: OpSqrt, Child: &VarNode{}},
+		Right: &ConstNode{Val: 2},
+	}
+	dot := ToDOT(node)
+	if !strings.HasPrefix(dot, "digraph expr {") || !strings.HasSuffix(dot, "}\n") {
+		t.Fatalf("not a digraph:\n%s", dot)
+	}
+	// One declaration per node, in preorder, and one edge per child.
+	for i, label := range []string{"+", "sqrt", "n", "2"} {
+		if !strings.Contains(dot, "n"+string(rune('0'+i))+` [label="`+label+`"`) {
+			t.Errorf("missing node %d %q in\n%s", i, label, dot)
+		}
+	}
+	if edges := strings.Count(dot, "->"); edges != node.NodeCount()-1 {
+		t.Errorf("%d edges, want %d", edges, node.NodeCount()-1)
+	}
+}
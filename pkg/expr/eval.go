@@ -11,12 +11,43 @@ var (
 	bigOne  = big.NewFloat(1)
 )
 
+// floatPool recycles big.Float operands between evaluations. Every Eval
+// returns a value owned by the caller, so interior nodes can hand their
+// children's results back (or reuse them in place) as soon as they are
+// combined. Reusing a float keeps its mantissa backing array, which is where
+// nearly all of the allocation cost lives at 512+ bits.
+var floatPool = sync.Pool{
+	New: func() any { return new(big.Float) },
+}
+
+// newFloat returns a pooled big.Float set to zero at the given precision.
+func newFloat(prec uint) *big.Float {
+	f := floatPool.Get().(*big.Float)
+	f.SetPrec(prec)
+	f.SetInt64(0)
+	return f
+}
+
+// ReleaseFloat returns a value produced by Eval to the operand pool.
+// Callers must not use f afterwards. Releasing is optional — an unreleased
+// value is simply garbage collected — but in hot loops it removes most of the
+// allocation and GC pressure.
+func ReleaseFloat(f *big.Float) {
+	if f != nil {
+		floatPool.Put(f)
+	}
+}
+
 func (v *VarNode) Eval(n *big.Float, prec uint) (*big.Float, bool) {
-	return new(big.Float).SetPrec(prec).Copy(n), true
+	return newFloat(prec).Set(n), true
 }
 
 func (c *ConstNode) Eval(n *big.Float, prec uint) (*big.Float, bool) {
-	return new(big.Float).SetPrec(prec).SetInt64(c.Val), true
+	return newFloat(prec).SetInt64(c.Val), true
+}
+
+func (s *SymbolicConstNode) Eval(n *big.Float, prec uint) (*big.Float, bool) {
+	return newFloat(prec).Set(symbolValue(s.Sym, prec)), true
 }
 
 func (u *UnaryNode) Eval(n *big.Float, prec uint) (*big.Float, bool) {
@@ -24,51 +55,116 @@ func (u *UnaryNode) Eval(n *big.Float, prec uint) (*big.Float, bool) {
 	if !ok {
 		return nil, false
 	}
+	result, ok := u.apply(child, prec)
+	if result != child {
+		ReleaseFloat(child)
+	}
+	return result, ok
+}
 
+// apply computes op(child). It may reuse child in place as the result.
+func (u *UnaryNode) apply(child *big.Float, prec uint) (*big.Float, bool) {
 	switch u.Op {
 	case OpNeg:
-		return new(big.Float).SetPrec(prec).Neg(child), true
+		return child.Neg(child), true
 
 	case OpFactorial:
 		return bigFactorial(child, prec)
 
+	case OpGamma:
+		return bigGamma(child, prec)
+
+	case OpDigamma:
+		return bigDigamma(child, prec)
+
 	case OpAltSign:
-		// (-1)^child — child must be a non-negative integer
-		iv, ok := toInt64(child)
-		if !ok || iv < 0 {
+		// (-1)^child — child must be a non-negative integer; past int64
+		// its parity is read off the big.Int
+		if !child.IsInt() || child.Sign() < 0 {
 			return nil, false
 		}
-		if iv%2 == 0 {
-			return new(big.Float).SetPrec(prec).SetInt64(1), true
+		odd := false
+		if iv, ok := toInt64(child); ok {
+			odd = iv%2 != 0
+		} else {
+			i, _ := child.Int(nil)
+			odd = i.Bit(0) == 1
+		}
+		if odd {
+			return child.SetInt64(-1), true
 		}
-		return new(big.Float).SetPrec(prec).SetInt64(-1), true
+		return child.SetInt64(1), true
 
 	case OpDoubleFactorial:
 		return bigDoubleFactorial(child, prec)
 
+	case OpSubfactorial:
+		return bigSubfactorial(child, prec)
+
 	case OpFibonacci:
 		return bigFibonacci(child, prec)
 
+	case OpBernoulli:
+		return bigBernoulli(child, prec)
+
+	case OpPrime:
+		return bigPrime(child, prec)
+
+	case OpArctan:
+		return bigArctan(child, prec)
+
+	case OpArcsin:
+		return bigArcsin(child, prec)
+
+	case OpSinh:
+		return bigSinh(child, prec)
+
+	case OpCosh:
+		return bigCosh(child, prec)
+
+	case OpTanh:
+		return bigTanh(child, prec)
+
 	case OpSin:
 		f, _ := child.Float64()
 		if math.IsInf(f, 0) || math.IsNaN(f) {
 			return nil, false
 		}
-		return new(big.Float).SetPrec(prec).SetFloat64(math.Sin(f)), true
+		return child.SetFloat64(math.Sin(f)), true
 
 	case OpCos:
 		f, _ := child.Float64()
 		if math.IsInf(f, 0) || math.IsNaN(f) {
 			return nil, false
 		}
-		return new(big.Float).SetPrec(prec).SetFloat64(math.Cos(f)), true
+		return child.SetFloat64(math.Cos(f)), true
+
+	case OpTan:
+		return bigTan(child, prec)
+
+	case OpExp:
+		if child.IsInf() {
+			return nil, false
+		}
+		// Past 2^32, e^x is beyond big.Float's exponent range either way.
+		if child.MantExp(nil) > 32 {
+			if child.Sign() < 0 {
+				return child.SetInt64(0), true
+			}
+			return nil, false
+		}
+		r := bigExp(child, prec)
+		if r.IsInf() {
+			return nil, false
+		}
+		return r, true
 
 	case OpLn:
 		f, _ := child.Float64()
 		if f <= 0 || math.IsInf(f, 0) || math.IsNaN(f) {
 			return nil, false
 		}
-		return new(big.Float).SetPrec(prec).SetFloat64(math.Log(f)), true
+		return child.SetFloat64(math.Log(f)), true
 
 	case OpFloor:
 		return bigFloor(child, prec), true
@@ -77,7 +173,7 @@ func (u *UnaryNode) Eval(n *big.Float, prec uint) (*big.Float, bool) {
 		return bigCeil(child, prec), true
 
 	case OpAbs:
-		return new(big.Float).SetPrec(prec).Abs(child), true
+		return child.Abs(child), true
 
 	case OpSqrt:
 		if child.Sign() < 0 {
@@ -86,6 +182,9 @@ func (u *UnaryNode) Eval(n *big.Float, prec uint) (*big.Float, bool) {
 		return bigSqrt(child, prec), true
 
 	default:
+		if d, ok := customUnary[u.Op]; ok {
+			return d.eval(prec, child)
+		}
 		return nil, false
 	}
 }
@@ -97,24 +196,35 @@ func (b *BinaryNode) Eval(n *big.Float, prec uint) (*big.Float, bool) {
 	}
 	right, ok := b.Right.Eval(n, prec)
 	if !ok {
+		ReleaseFloat(left)
 		return nil, false
 	}
+	result, ok := b.apply(left, right, prec)
+	if result != left {
+		ReleaseFloat(left)
+	}
+	ReleaseFloat(right)
+	return result, ok
+}
 
+// apply computes left op right. It may reuse left in place as the result;
+// right is never retained.
+func (b *BinaryNode) apply(left, right *big.Float, prec uint) (*big.Float, bool) {
 	switch b.Op {
 	case OpAdd:
-		return new(big.Float).SetPrec(prec).Add(left, right), true
+		return left.Add(left, right), true
 
 	case OpSub:
-		return new(big.Float).SetPrec(prec).Sub(left, right), true
+		return left.Sub(left, right), true
 
 	case OpMul:
-		return new(big.Float).SetPrec(prec).Mul(left, right), true
+		return left.Mul(left, right), true
 
 	case OpDiv:
 		if right.Cmp(bigZero) == 0 {
 			return nil, false
 		}
-		return new(big.Float).SetPrec(prec).Quo(left, right), true
+		return left.Quo(left, right), true
 
 	case OpPow:
 		return bigPow(left, right, prec)
@@ -122,7 +232,19 @@ func (b *BinaryNode) Eval(n *big.Float, prec uint) (*big.Float, bool) {
 	case OpBinomial:
 		return bigBinomial(left, right, prec)
 
+	case OpPochhammer:
+		return bigPochhammer(left, right, prec)
+
+	case OpMod:
+		return bigMod(left, right, prec)
+
+	case OpRoot:
+		return bigRoot(left, right, prec)
+
 	default:
+		if d, ok := customBinary[b.Op]; ok {
+			return d.eval(prec, left, right)
+		}
 		return nil, false
 	}
 }
@@ -145,6 +267,7 @@ const maxComputeInput = 1000
 var (
 	factorialCache = &mathCache{}
 	dblFactCache   = &mathCache{}
+	subfactCache   = &mathCache{}
 	fibonacciCache = &mathCache{}
 )
 
@@ -182,6 +305,15 @@ func init() {
 	}
 	dblFactCache.values = dfacts
 
+	// Seed subfactorial: !0 = 1, !1 = 0, ..., !20 = 895014631192902121
+	subfacts := make([]*big.Int, 21)
+	subfacts[0] = big.NewInt(1)
+	for i := int64(1); i <= 20; i++ {
+		subfacts[i] = new(big.Int).Mul(subfacts[i-1], big.NewInt(i))
+		subfacts[i].Add(subfacts[i], big.NewInt(1-2*(i%2)))
+	}
+	subfactCache.values = subfacts
+
 	// Seed fibonacci
 	fibs := make([]*big.Int, 21)
 	fibs[0] = big.NewInt(0)
@@ -194,46 +326,82 @@ func init() {
 
 func bigFactorial(f *big.Float, prec uint) (*big.Float, bool) {
 	iv, ok := toInt64(f)
-	if !ok || iv < 0 || iv > maxComputeInput {
+	if !ok {
+		return nil, false
+	}
+	v, ok := factorialInt(iv)
+	if !ok {
+		return nil, false
+	}
+	return newFloat(prec).SetInt(v), true
+}
+
+func bigDoubleFactorial(f *big.Float, prec uint) (*big.Float, bool) {
+	iv, ok := toInt64(f)
+	if !ok {
+		return nil, false
+	}
+	v, ok := doubleFactorialInt(iv)
+	if !ok {
+		return nil, false
+	}
+	return newFloat(prec).SetInt(v), true
+}
+
+func bigSubfactorial(f *big.Float, prec uint) (*big.Float, bool) {
+	iv, ok := toInt64(f)
+	if !ok {
+		return nil, false
+	}
+	v, ok := subfactorialInt(iv)
+	if !ok {
+		return nil, false
+	}
+	return newFloat(prec).SetInt(v), true
+}
+
+func bigFibonacci(f *big.Float, prec uint) (*big.Float, bool) {
+	iv, ok := toInt64(f)
+	if !ok {
+		return nil, false
+	}
+	v, ok := fibonacciInt(iv)
+	if !ok {
+		return nil, false
+	}
+	return newFloat(prec).SetInt(v), true
+}
+
+// factorialInt returns iv! from the shared cache. The result must not be modified.
+func factorialInt(iv int64) (*big.Int, bool) {
+	if iv < 0 || iv > maxComputeInput {
 		return nil, false
 	}
 	if v, ok := factorialCache.get(iv); ok {
-		return new(big.Float).SetPrec(prec).SetInt(v), true
+		return v, true
 	}
 	// Extend cache up to iv
 	factorialCache.mu.Lock()
+	defer factorialCache.mu.Unlock()
 	// Re-check after acquiring write lock
-	if iv < int64(len(factorialCache.values)) {
-		v := factorialCache.values[iv]
-		factorialCache.mu.Unlock()
-		return new(big.Float).SetPrec(prec).SetInt(v), true
-	}
-	cur := int64(len(factorialCache.values))
-	for i := cur; i <= iv; i++ {
+	for i := int64(len(factorialCache.values)); i <= iv; i++ {
 		next := new(big.Int).Mul(factorialCache.values[i-1], big.NewInt(i))
 		factorialCache.values = append(factorialCache.values, next)
 	}
-	v := factorialCache.values[iv]
-	factorialCache.mu.Unlock()
-	return new(big.Float).SetPrec(prec).SetInt(v), true
+	return factorialCache.values[iv], true
 }
 
-func bigDoubleFactorial(f *big.Float, prec uint) (*big.Float, bool) {
-	iv, ok := toInt64(f)
-	if !ok || iv < 0 || iv > maxComputeInput {
+// doubleFactorialInt returns iv!! from the shared cache. The result must not be modified.
+func doubleFactorialInt(iv int64) (*big.Int, bool) {
+	if iv < 0 || iv > maxComputeInput {
 		return nil, false
 	}
 	if v, ok := dblFactCache.get(iv); ok {
-		return new(big.Float).SetPrec(prec).SetInt(v), true
+		return v, true
 	}
 	dblFactCache.mu.Lock()
-	if iv < int64(len(dblFactCache.values)) {
-		v := dblFactCache.values[iv]
-		dblFactCache.mu.Unlock()
-		return new(big.Float).SetPrec(prec).SetInt(v), true
-	}
-	cur := int64(len(dblFactCache.values))
-	for i := cur; i <= iv; i++ {
+	defer dblFactCache.mu.Unlock()
+	for i := int64(len(dblFactCache.values)); i <= iv; i++ {
 		var next *big.Int
 		if i < 2 {
 			next = big.NewInt(1)
@@ -242,56 +410,69 @@ func bigDoubleFactorial(f *big.Float, prec uint) (*big.Float, bool) {
 		}
 		dblFactCache.values = append(dblFactCache.values, next)
 	}
-	v := dblFactCache.values[iv]
-	dblFactCache.mu.Unlock()
-	return new(big.Float).SetPrec(prec).SetInt(v), true
+	return dblFactCache.values[iv], true
 }
 
-func bigFibonacci(f *big.Float, prec uint) (*big.Float, bool) {
-	iv, ok := toInt64(f)
-	if !ok || iv < 0 || iv > maxComputeInput {
+// subfactorialInt returns !iv from the shared cache, by
+// !n = n·!(n-1) + (-1)^n. The result must not be modified.
+func subfactorialInt(iv int64) (*big.Int, bool) {
+	if iv < 0 || iv > maxComputeInput {
+		return nil, false
+	}
+	if v, ok := subfactCache.get(iv); ok {
+		return v, true
+	}
+	subfactCache.mu.Lock()
+	defer subfactCache.mu.Unlock()
+	for i := int64(len(subfactCache.values)); i <= iv; i++ {
+		next := new(big.Int).Mul(subfactCache.values[i-1], big.NewInt(i))
+		next.Add(next, big.NewInt(1-2*(i%2)))
+		subfactCache.values = append(subfactCache.values, next)
+	}
+	return subfactCache.values[iv], true
+}
+
+// fibonacciInt returns F_iv from the shared cache. The result must not be modified.
+func fibonacciInt(iv int64) (*big.Int, bool) {
+	if iv < 0 || iv > maxComputeInput {
 		return nil, false
 	}
 	if v, ok := fibonacciCache.get(iv); ok {
-		return new(big.Float).SetPrec(prec).SetInt(v), true
+		return v, true
 	}
 	fibonacciCache.mu.Lock()
-	if iv < int64(len(fibonacciCache.values)) {
-		v := fibonacciCache.values[iv]
-		fibonacciCache.mu.Unlock()
-		return new(big.Float).SetPrec(prec).SetInt(v), true
-	}
-	cur := int64(len(fibonacciCache.values))
-	for i := cur; i <= iv; i++ {
+	defer fibonacciCache.mu.Unlock()
+	for i := int64(len(fibonacciCache.values)); i <= iv; i++ {
 		next := new(big.Int).Add(fibonacciCache.values[i-1], fibonacciCache.values[i-2])
 		fibonacciCache.values = append(fibonacciCache.values, next)
 	}
-	v := fibonacciCache.values[iv]
-	fibonacciCache.mu.Unlock()
-	return new(big.Float).SetPrec(prec).SetInt(v), true
+	return fibonacciCache.values[iv], true
 }
 
 // bigSqrt computes sqrt(x) to full precision using Newton's method.
 func bigSqrt(x *big.Float, prec uint) *big.Float {
 	if x.Sign() == 0 {
-		return new(big.Float).SetPrec(prec)
+		return newFloat(prec)
 	}
 	// Initial guess from float64.
 	f, _ := x.Float64()
-	guess := new(big.Float).SetPrec(prec).SetFloat64(math.Sqrt(f))
+	guess := newFloat(prec).SetFloat64(math.Sqrt(f))
 
 	// Newton iteration: g' = (g + x/g) / 2
 	// Converges quadratically, so ~log2(prec) iterations suffice.
-	two := new(big.Float).SetPrec(prec).SetInt64(2)
+	quo := newFloat(prec)
+	next := newFloat(prec)
 	for i := 0; i < 64; i++ {
-		quo := new(big.Float).SetPrec(prec).Quo(x, guess)
-		next := new(big.Float).SetPrec(prec).Add(guess, quo)
-		next.Quo(next, two)
+		quo.Quo(x, guess)
+		next.Add(guess, quo)
+		next.SetMantExp(next, -1) // exact halving
 		if next.Cmp(guess) == 0 {
 			break
 		}
-		guess = next
+		guess, next = next, guess
 	}
+	ReleaseFloat(quo)
+	ReleaseFloat(next)
 	return guess
 }
 
@@ -308,8 +489,7 @@ func bigPow(base, exp *big.Float, prec uint) (*big.Float, bool) {
 			if !ok {
 				return nil, false
 			}
-			return new(big.Float).SetPrec(prec).Quo(
-				new(big.Float).SetPrec(prec).SetInt64(1), pos), true
+			return pos.Quo(bigOne, pos), true
 		}
 		return intPow(base, ei, prec)
 	}
@@ -323,19 +503,25 @@ func bigPow(base, exp *big.Float, prec uint) (*big.Float, bool) {
 	if math.IsInf(result, 0) || math.IsNaN(result) {
 		return nil, false
 	}
-	return new(big.Float).SetPrec(prec).SetFloat64(result), true
+	return newFloat(prec).SetFloat64(result), true
 }
 
 func intPow(base *big.Float, exp int64, prec uint) (*big.Float, bool) {
 	if exp > 10000 {
 		return nil, false
 	}
-	result := new(big.Float).SetPrec(prec).SetInt64(1)
-	b := new(big.Float).SetPrec(prec).Copy(base)
+	result := newFloat(prec).SetInt64(1)
+	b := newFloat(prec).Set(base)
+	defer ReleaseFloat(b)
 	for exp > 0 {
 		if exp%2 == 1 {
 			result.Mul(result, b)
 		}
+		// Past big.Float's exponent range the power is ±Inf: stop there.
+		if result.IsInf() {
+			ReleaseFloat(result)
+			return nil, false
+		}
 		b.Mul(b, b)
 		exp /= 2
 	}
@@ -359,24 +545,116 @@ func bigBinomial(nf, kf *big.Float, prec uint) (*big.Float, bool) {
 		result.Mul(result, big.NewInt(n-i))
 		result.Div(result, big.NewInt(i+1))
 	}
-	return new(big.Float).SetPrec(prec).SetInt(result), true
+	return newFloat(prec).SetInt(result), true
+}
+
+// bigPochhammer computes the rising factorial (a)_k = a(a+1)...(a+k-1)
+// for an integer 0 ≤ k ≤ maxComputeInput. An integer a is multiplied out
+// exactly; any other is multiplied term by term with guard bits for the k
+// roundings.
+func bigPochhammer(af, kf *big.Float, prec uint) (*big.Float, bool) {
+	k, ok := toInt64(kf)
+	if !ok || k < 0 || k > maxComputeInput {
+		return nil, false
+	}
+	if a, ok := toInt64(af); ok {
+		result, x, one := big.NewInt(1), big.NewInt(a), big.NewInt(1)
+		for i := int64(0); i < k; i++ {
+			result.Mul(result, x)
+			x.Add(x, one)
+		}
+		return newFloat(prec).SetInt(result), true
+	}
+	wp := prec + 16
+	result := newFloat(wp).SetInt64(1)
+	x := newFloat(wp).Set(af)
+	for i := int64(0); i < k; i++ {
+		result.Mul(result, x)
+		x.Add(x, bigOne)
+	}
+	return newFloat(prec).Set(result), true
+}
+
+// bigMod is a mod b with Euclidean semantics: the r in [0, |b|) that
+// differs from a by a multiple of b, undefined for b = 0. Integers are
+// reduced exactly. Otherwise the quotient a/|b| is floored, which fails
+// once its integer part takes every bit of prec, leaving none for r.
+func bigMod(a, b *big.Float, prec uint) (*big.Float, bool) {
+	if b.Sign() == 0 || a.IsInf() || b.IsInf() {
+		return nil, false
+	}
+	if a.IsInt() && b.IsInt() {
+		ai, _ := a.Int(nil)
+		bi, _ := b.Int(nil)
+		return newFloat(prec).SetInt(ai.Mod(ai, bi.Abs(bi))), true
+	}
+	wp := prec + 64
+	m := new(big.Float).SetPrec(wp).Abs(b)
+	q := new(big.Float).SetPrec(wp).Quo(a, m)
+	if q.MantExp(nil) > int(prec) {
+		return nil, false
+	}
+	qi, _ := q.Int(nil)
+	if q.Sign() < 0 && !q.IsInt() {
+		qi.Sub(qi, big.NewInt(1))
+	}
+	r := new(big.Float).SetPrec(2*wp + 64).SetInt(qi)
+	r.Mul(r, m)
+	r.Sub(a, r)
+	// The rounded quotient can be one off; step r back into [0, |b|).
+	if r.Sign() < 0 {
+		r.Add(r, m)
+	} else if r.Cmp(m) >= 0 {
+		r.Sub(r, m)
+	}
+	return newFloat(prec).Set(r), true
+}
+
+// bigRoot is the real k-th root of x for an integer k ≥ 1, undefined for
+// negative x when k is even. An integer that is a perfect k-th power gives
+// its root exactly; anything else is exp(ln|x|/k) with 32 guard bits.
+func bigRoot(x, kf *big.Float, prec uint) (*big.Float, bool) {
+	k, ok := toInt64(kf)
+	if !ok || k < 1 || x.IsInf() || (x.Sign() < 0 && k%2 == 0) {
+		return nil, false
+	}
+	if k == 1 || x.Sign() == 0 {
+		return newFloat(prec).Set(x), true
+	}
+	if x.IsInt() && x.MantExp(nil) <= int(prec) {
+		xi, _ := x.Int(nil)
+		neg := xi.Sign() < 0
+		if r, ok := exactRoot(xi.Abs(xi), k); ok {
+			if neg {
+				r.Neg(r)
+			}
+			return newFloat(prec).SetInt(r), true
+		}
+	}
+	wp := prec + 32
+	l := bigLn(new(big.Float).SetPrec(wp).Abs(x), wp)
+	r := bigExp(l.Quo(l, new(big.Float).SetPrec(wp).SetInt64(k)), wp)
+	if x.Sign() < 0 {
+		r.Neg(r)
+	}
+	return newFloat(prec).Set(r), true
 }
 
 func bigFloor(f *big.Float, prec uint) *big.Float {
 	i, _ := f.Int(nil)
-	result := new(big.Float).SetPrec(prec).SetInt(i)
+	result := newFloat(prec).SetInt(i)
 	// If f was negative and not an integer, subtract 1
 	if f.Sign() < 0 && result.Cmp(f) != 0 {
-		result.Sub(result, new(big.Float).SetPrec(prec).SetInt64(1))
+		result.Sub(result, bigOne)
 	}
 	return result
 }
 
 func bigCeil(f *big.Float, prec uint) *big.Float {
 	i, _ := f.Int(nil)
-	result := new(big.Float).SetPrec(prec).SetInt(i)
+	result := newFloat(prec).SetInt(i)
 	if f.Sign() > 0 && result.Cmp(f) != 0 {
-		result.Add(result, new(big.Float).SetPrec(prec).SetInt64(1))
+		result.Add(result, bigOne)
 	}
 	return result
 }
@@ -6,6 +6,7 @@ import "math"
 var (
 	factorialF64    [171]float64  // 170! is the last finite float64 factorial
 	dblFactorialF64 [301]float64  // overflow around ~300
+	subfactorialF64 [171]float64  // !170 ≈ 170!/e; !171 overflows
 	fibonacciF64    [1477]float64 // fib(1476) is the last finite float64
 )
 
@@ -23,6 +24,12 @@ func init() {
 		dblFactorialF64[i] = dblFactorialF64[i-2] * float64(i)
 	}
 
+	// Subfactorials: !n = n * !(n-1) + (-1)^n
+	subfactorialF64[0] = 1
+	for i := 1; i < len(subfactorialF64); i++ {
+		subfactorialF64[i] = subfactorialF64[i-1]*float64(i) + float64(1-2*(i%2))
+	}
+
 	// Fibonacci
 	fibonacciF64[0] = 0
 	fibonacciF64[1] = 1
@@ -41,6 +48,11 @@ func (c *ConstNode) EvalF64(n float64) (float64, bool) {
 	return float64(c.Val), true
 }
 
+// EvalF64 for SymbolicConstNode returns the constant's float64 value.
+func (s *SymbolicConstNode) EvalF64(n float64) (float64, bool) {
+	return symbolF64[s.Sym], true
+}
+
 // EvalF64 for UnaryNode dispatches on op.
 func (u *UnaryNode) EvalF64(n float64) (float64, bool) {
 	child, ok := u.Child.EvalF64(n)
@@ -76,6 +88,13 @@ func (u *UnaryNode) EvalF64(n float64) (float64, bool) {
 		}
 		return dblFactorialF64[iv], true
 
+	case OpSubfactorial:
+		iv := int64(child)
+		if child != float64(iv) || iv < 0 || iv >= int64(len(subfactorialF64)) {
+			return 0, false
+		}
+		return subfactorialF64[iv], true
+
 	case OpFibonacci:
 		iv := int64(child)
 		if child != float64(iv) || iv < 0 || iv >= int64(len(fibonacciF64)) {
@@ -83,6 +102,56 @@ func (u *UnaryNode) EvalF64(n float64) (float64, bool) {
 		}
 		return fibonacciF64[iv], true
 
+	case OpBernoulli:
+		iv := int64(child)
+		if child != float64(iv) || iv < 0 {
+			return 0, false
+		}
+		if t := bernoulliF64(); iv < int64(len(t)) {
+			return t[iv], true
+		}
+		return 0, false
+
+	case OpPrime:
+		iv := int64(child)
+		if child != float64(iv) {
+			return 0, false
+		}
+		p, ok := primeAt(iv)
+		return float64(p), ok
+
+	case OpArctan:
+		if math.IsInf(child, 0) || math.IsNaN(child) {
+			return 0, false
+		}
+		return math.Atan(child), true
+
+	case OpArcsin:
+		if !(math.Abs(child) <= 1) {
+			return 0, false
+		}
+		return math.Asin(child), true
+
+	case OpSinh:
+		r := math.Sinh(child)
+		if math.IsInf(r, 0) || math.IsNaN(r) {
+			return 0, false
+		}
+		return r, true
+
+	case OpCosh:
+		r := math.Cosh(child)
+		if math.IsInf(r, 0) || math.IsNaN(r) {
+			return 0, false
+		}
+		return r, true
+
+	case OpTanh:
+		if math.IsInf(child, 0) || math.IsNaN(child) {
+			return 0, false
+		}
+		return math.Tanh(child), true
+
 	case OpSin:
 		if math.IsInf(child, 0) || math.IsNaN(child) {
 			return 0, false
@@ -95,6 +164,33 @@ func (u *UnaryNode) EvalF64(n float64) (float64, bool) {
 		}
 		return math.Cos(child), true
 
+	case OpTan:
+		if math.IsInf(child, 0) || math.IsNaN(child) {
+			return 0, false
+		}
+		return math.Tan(child), true
+
+	case OpExp:
+		r := math.Exp(child)
+		if math.IsInf(r, 0) || math.IsNaN(r) {
+			return 0, false
+		}
+		return r, true
+
+	case OpGamma:
+		r := math.Gamma(child)
+		if math.IsInf(r, 0) || math.IsNaN(r) {
+			return 0, false
+		}
+		return r, true
+
+	case OpDigamma:
+		r, ok := digammaF64(child)
+		if !ok || math.IsInf(r, 0) || math.IsNaN(r) {
+			return 0, false
+		}
+		return r, true
+
 	case OpLn:
 		if child <= 0 || math.IsInf(child, 0) || math.IsNaN(child) {
 			return 0, false
@@ -123,6 +219,9 @@ func (u *UnaryNode) EvalF64(n float64) (float64, bool) {
 		return math.Sqrt(child), true
 
 	default:
+		if d, ok := customUnary[u.Op]; ok {
+			return d.evalF64(child)
+		}
 		return 0, false
 	}
 }
@@ -176,7 +275,19 @@ func (b *BinaryNode) EvalF64(n float64) (float64, bool) {
 	case OpBinomial:
 		return binomialF64(left, right)
 
+	case OpPochhammer:
+		return pochhammerF64(left, right)
+
+	case OpMod:
+		return modF64(left, right)
+
+	case OpRoot:
+		return rootF64(left, right)
+
 	default:
+		if d, ok := customBinary[b.Op]; ok {
+			return d.evalF64(left, right)
+		}
 		return 0, false
 	}
 }
@@ -235,6 +346,38 @@ func intPowF64(base float64, exp int64) (float64, bool) {
 }
 
 // binomialF64 computes C(n, k) in float64.
+// pochhammerF64 computes (a)_k for an integer 0 ≤ k ≤ maxComputeInput.
+func pochhammerF64(a, kf float64) (float64, bool) {
+	k := int64(kf)
+	if kf != float64(k) || k < 0 || k > maxComputeInput {
+		return 0, false
+	}
+	result := 1.0
+	for i := int64(0); i < k; i++ {
+		result *= a + float64(i)
+		if math.IsInf(result, 0) || math.IsNaN(result) {
+			return 0, false
+		}
+	}
+	return result, true
+}
+
+// modF64 is a mod b in [0, |b|), as bigMod.
+func modF64(a, b float64) (float64, bool) {
+	m := math.Abs(b)
+	if m == 0 || math.IsInf(m, 0) || math.IsInf(a, 0) {
+		return 0, false
+	}
+	r := math.Mod(a, m)
+	if r < 0 {
+		r += m
+		if r == m { // a tiny negative r rounds up to m
+			r = 0
+		}
+	}
+	return r, true
+}
+
 func binomialF64(nf, kf float64) (float64, bool) {
 	ni := int64(nf)
 	ki := int64(kf)
@@ -256,3 +399,33 @@ func binomialF64(nf, kf float64) (float64, bool) {
 	}
 	return result, true
 }
+
+// rootF64 is the real k-th root of x, as bigRoot. math.Pow can miss an
+// integer root by an ulp, so a root that rounds to one is checked.
+func rootF64(x, kf float64) (float64, bool) {
+	k := int64(kf)
+	if kf != float64(k) || k < 1 || math.IsInf(x, 0) || (x < 0 && k%2 == 0) {
+		return 0, false
+	}
+	a := math.Abs(x)
+	var r float64
+	switch k {
+	case 1:
+		r = a
+	case 2:
+		r = math.Sqrt(a)
+	case 3:
+		r = math.Cbrt(a)
+	default:
+		r = math.Pow(a, 1/float64(k))
+		if ri := math.Round(r); ri != r {
+			if p, ok := intPowF64(ri, k); ok && p == a {
+				r = ri
+			}
+		}
+	}
+	if x < 0 {
+		r = -r
+	}
+	return r, true
+}
@@ -34,6 +34,15 @@ func TestEvalF64_MatchesBigFloat(t *testing.T) {
 		{"sin(n)", &UnaryNode{Op: OpSin, Child: &VarNode{}}},
 		{"cos(n)", &UnaryNode{Op: OpCos, Child: &VarNode{}}},
 		{"ln(n)", &UnaryNode{Op: OpLn, Child: &VarNode{}}},
+		{"tan(n)", &UnaryNode{Op: OpTan, Child: &VarNode{}}},
+		{"exp(n)", &UnaryNode{Op: OpExp, Child: &VarNode{}}},
+		{"gamma(n)", &UnaryNode{Op: OpGamma, Child: &VarNode{}}},
+		{"bernoulli(n)", &UnaryNode{Op: OpBernoulli, Child: &VarNode{}}},
+		{"prime(n)", &UnaryNode{Op: OpPrime, Child: &VarNode{}}},
+		{"gamma(n/3)", &UnaryNode{Op: OpGamma, Child: &BinaryNode{
+			Op: OpDiv, Left: &VarNode{}, Right: &ConstNode{Val: 3}}}},
+		{"gamma(n-3)", &UnaryNode{Op: OpGamma, Child: &BinaryNode{
+			Op: OpSub, Left: &VarNode{}, Right: &ConstNode{Val: 3}}}},
 		{"floor(n/3)", &UnaryNode{Op: OpFloor, Child: &BinaryNode{
 			Op: OpDiv, Left: &VarNode{}, Right: &ConstNode{Val: 3}}}},
 		{"ceil(n/3)", &UnaryNode{Op: OpCeil, Child: &BinaryNode{
@@ -46,6 +55,18 @@ func TestEvalF64_MatchesBigFloat(t *testing.T) {
 		{"n/4", &BinaryNode{Op: OpDiv, Left: &VarNode{}, Right: &ConstNode{Val: 4}}},
 		{"2^n", &BinaryNode{Op: OpPow, Left: &ConstNode{Val: 2}, Right: &VarNode{}}},
 		{"C(10,n)", &BinaryNode{Op: OpBinomial, Left: &ConstNode{Val: 10}, Right: &VarNode{}}},
+		{"poch(n/3,n)", &BinaryNode{Op: OpPochhammer, Left: &BinaryNode{
+			Op: OpDiv, Left: &VarNode{}, Right: &ConstNode{Val: 3}}, Right: &VarNode{}}},
+		{"(3n+1) mod 5", &BinaryNode{Op: OpMod, Left: &BinaryNode{Op: OpAdd,
+			Left: &BinaryNode{Op: OpMul, Left: &ConstNode{Val: 3}, Right: &VarNode{}}, Right: &ConstNode{Val: 1}}, Right: &ConstNode{Val: 5}}},
+		{"arctan(1/n)", &UnaryNode{Op: OpArctan, Child: &BinaryNode{Op: OpDiv, Left: &ConstNode{Val: 1}, Right: &VarNode{}}}},
+		{"arcsin(1/n)", &UnaryNode{Op: OpArcsin, Child: &BinaryNode{Op: OpDiv, Left: &ConstNode{Val: 1}, Right: &VarNode{}}}},
+		{"psi(n)/n^2", &BinaryNode{Op: OpDiv, Left: &UnaryNode{Op: OpDigamma, Child: &VarNode{}}, Right: &BinaryNode{Op: OpPow, Left: &VarNode{}, Right: &ConstNode{Val: 2}}}},
+		{"!n/n!", &BinaryNode{Op: OpDiv, Left: &UnaryNode{Op: OpSubfactorial, Child: &VarNode{}}, Right: &UnaryNode{Op: OpFactorial, Child: &VarNode{}}}},
+		{"sinh(1/n)", &UnaryNode{Op: OpSinh, Child: &BinaryNode{Op: OpDiv, Left: &ConstNode{Val: 1}, Right: &VarNode{}}}},
+		{"cosh(n)/tanh(n)", &BinaryNode{Op: OpDiv, Left: &UnaryNode{Op: OpCosh, Child: &VarNode{}}, Right: &UnaryNode{Op: OpTanh, Child: &VarNode{}}}},
+		{"cbrt(n^2+1)", &BinaryNode{Op: OpRoot, Left: &BinaryNode{Op: OpAdd,
+			Left: &BinaryNode{Op: OpPow, Left: &VarNode{}, Right: &ConstNode{Val: 2}}, Right: &ConstNode{Val: 1}}, Right: &ConstNode{Val: 3}}},
 		{"1/n!", &BinaryNode{Op: OpDiv, Left: &ConstNode{Val: 1},
 			Right: &UnaryNode{Op: OpFactorial, Child: &VarNode{}}}},
 	}
@@ -0,0 +1,165 @@
+package expr
+
+import "github.com/wildfunctions/genetic_series/pkg/numeric"
+
+// EvalNum evaluates node at n using backend b, with the same semantics as
+// Eval. Arithmetic, integer powers, square roots and the integer sequence
+// ops run natively in the backend; the rest (trig, ln, floor, ceil,
+// binomial, non-integer powers) round-trip through big.Float.
+//
+// The result is owned by the caller and should be passed to b.Release.
+func EvalNum[T any](node ExprNode, b numeric.Backend[T], n T, prec uint) (T, bool) {
+	var zero T
+	switch nd := node.(type) {
+	case *VarNode:
+		return b.Set(b.New(prec), n), true
+
+	case *ConstNode:
+		return b.SetInt64(b.New(prec), nd.Val), true
+
+	case *SymbolicConstNode:
+		return b.SetBig(b.New(prec), symbolValue(nd.Sym, prec)), true
+
+	case *UnaryNode:
+		child, ok := EvalNum(nd.Child, b, n, prec)
+		if !ok {
+			return zero, false
+		}
+		r, ok := applyUnaryNum(nd, b, child, prec)
+		if !ok {
+			b.Release(child)
+		}
+		return r, ok
+
+	case *BinaryNode:
+		left, ok := EvalNum(nd.Left, b, n, prec)
+		if !ok {
+			return zero, false
+		}
+		right, ok := EvalNum(nd.Right, b, n, prec)
+		if !ok {
+			b.Release(left)
+			return zero, false
+		}
+		r, ok := applyBinaryNum(nd, b, left, right, prec)
+		if !ok {
+			b.Release(left)
+		}
+		b.Release(right)
+		return r, ok
+	}
+	return zero, false
+}
+
+// applyUnaryNum computes op(x) in place in x.
+func applyUnaryNum[T any](u *UnaryNode, b numeric.Backend[T], x T, prec uint) (T, bool) {
+	switch u.Op {
+	case OpNeg:
+		return b.Neg(x, x), true
+
+	case OpAbs:
+		return b.Abs(x, x), true
+
+	case OpSqrt:
+		if b.Sign(x) < 0 {
+			return x, false
+		}
+		return b.Sqrt(x, x), true
+
+	case OpAltSign:
+		iv, ok := b.Int64(x)
+		if !ok || iv < 0 {
+			return x, false
+		}
+		if iv%2 == 0 {
+			return b.SetInt64(x, 1), true
+		}
+		return b.SetInt64(x, -1), true
+
+	case OpFactorial, OpDoubleFactorial, OpSubfactorial, OpFibonacci:
+		iv, ok := b.Int64(x)
+		if !ok {
+			return x, false
+		}
+		lookup := factorialInt
+		switch u.Op {
+		case OpDoubleFactorial:
+			lookup = doubleFactorialInt
+		case OpSubfactorial:
+			lookup = subfactorialInt
+		case OpFibonacci:
+			lookup = fibonacciInt
+		}
+		v, ok := lookup(iv)
+		if !ok {
+			return x, false
+		}
+		return b.SetInt(x, v), true
+	}
+
+	r, ok := u.apply(b.Big(x), prec)
+	if !ok {
+		return x, false
+	}
+	return b.SetBig(x, r), true
+}
+
+// applyBinaryNum computes left op right in place in left.
+func applyBinaryNum[T any](bn *BinaryNode, b numeric.Backend[T], left, right T, prec uint) (T, bool) {
+	switch bn.Op {
+	case OpAdd:
+		return b.Add(left, left, right), true
+
+	case OpSub:
+		return b.Sub(left, left, right), true
+
+	case OpMul:
+		return b.Mul(left, left, right), true
+
+	case OpDiv:
+		if b.Sign(right) == 0 {
+			return left, false
+		}
+		return b.Quo(left, left, right), true
+
+	case OpPow:
+		if e, ok := b.Int64(right); ok {
+			return intPowNum(b, left, e, prec)
+		}
+	}
+
+	r, ok := bn.apply(b.Big(left), b.Big(right), prec)
+	if !ok {
+		return left, false
+	}
+	return b.SetBig(left, r), true
+}
+
+// intPowNum sets x to x^e by binary exponentiation, with intPow's limits.
+func intPowNum[T any](b numeric.Backend[T], x T, e int64, prec uint) (T, bool) {
+	neg := e < 0
+	if neg {
+		e = -e
+		if b.Sign(x) == 0 {
+			return x, false
+		}
+	}
+	if e > 10000 {
+		return x, false
+	}
+	result := b.SetInt64(b.New(prec), 1)
+	for e > 0 {
+		if e%2 == 1 {
+			b.Mul(result, result, x)
+		}
+		b.Mul(x, x, x)
+		e /= 2
+	}
+	if neg {
+		b.Quo(x, b.SetInt64(x, 1), result)
+	} else {
+		b.Set(x, result)
+	}
+	b.Release(result)
+	return x, true
+}
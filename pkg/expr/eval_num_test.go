@@ -0,0 +1,55 @@
+package expr
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/wildfunctions/genetic_series/pkg/numeric"
+)
+
+func TestEvalNumMatchesEval(t *testing.T) {
+	n := &VarNode{}
+	c := func(v int64) ExprNode { return &ConstNode{Val: v} }
+	tests := []struct {
+		name string
+		node ExprNode
+	}{
+		{"pi term", benchTerm()},
+		{"altsign rational", &BinaryNode{Op: OpDiv, Left: &UnaryNode{Op: OpAltSign, Child: n}, Right: &BinaryNode{Op: OpAdd, Left: n, Right: c(1)}}},
+		{"sqrt", &UnaryNode{Op: OpSqrt, Child: &BinaryNode{Op: OpAdd, Left: n, Right: c(2)}}},
+		{"negative pow", &BinaryNode{Op: OpPow, Left: &BinaryNode{Op: OpAdd, Left: n, Right: c(1)}, Right: c(-3)}},
+		{"fib and dfact", &BinaryNode{Op: OpMul, Left: &UnaryNode{Op: OpFibonacci, Child: n}, Right: &UnaryNode{Op: OpDoubleFactorial, Child: n}}},
+		{"fallback ops", &BinaryNode{Op: OpAdd,
+			Left:  &UnaryNode{Op: OpFloor, Child: &UnaryNode{Op: OpSqrt, Child: n}},
+			Right: &BinaryNode{Op: OpBinomial, Left: &BinaryNode{Op: OpMul, Left: c(2), Right: n}, Right: n},
+		}},
+		{"div by zero at n=0", &BinaryNode{Op: OpDiv, Left: c(1), Right: n}},
+		{"abs neg", &UnaryNode{Op: OpAbs, Child: &UnaryNode{Op: OpNeg, Child: &BinaryNode{Op: OpSub, Left: c(3), Right: n}}}},
+	}
+
+	const prec = 256
+	b := numeric.BigFloat{}
+	eps := new(big.Float).SetMantExp(big.NewFloat(1), -(prec - 4))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := int64(0); i < 30; i++ {
+				nf := new(big.Float).SetPrec(prec).SetInt64(i)
+				want, wantOK := tt.node.Eval(nf, prec)
+				got, gotOK := EvalNum[*big.Float](tt.node, b, nf, prec)
+				if gotOK != wantOK {
+					t.Fatalf("n=%d: ok = %v, want %v", i, gotOK, wantOK)
+				}
+				if !gotOK {
+					continue
+				}
+				diff := new(big.Float).Sub(got, want)
+				if want.Sign() != 0 {
+					diff.Quo(diff, want)
+				}
+				if diff.Abs(diff).Cmp(eps) > 0 {
+					t.Errorf("n=%d: got %s, want %s", i, got.Text('g', 30), want.Text('g', 30))
+				}
+			}
+		})
+	}
+}
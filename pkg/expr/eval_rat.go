@@ -0,0 +1,292 @@
+package expr
+
+import "math/big"
+
+// EvalRat evaluates node exactly at the integer n. It returns false if the
+// value is undefined (as for Eval) or not an exact rational: sin, cos and
+// ln, square roots of non-squares, non-integer powers and the symbolic
+// constants. Input limits on
+// factorials, binomials and powers are those of Eval.
+func EvalRat(node ExprNode, n int64) (*big.Rat, bool) {
+	switch nd := node.(type) {
+	case *VarNode:
+		return new(big.Rat).SetInt64(n), true
+
+	case *ConstNode:
+		return new(big.Rat).SetInt64(nd.Val), true
+
+	case *UnaryNode:
+		x, ok := EvalRat(nd.Child, n)
+		if !ok {
+			return nil, false
+		}
+		return applyUnaryRat(nd.Op, x)
+
+	case *BinaryNode:
+		l, ok := EvalRat(nd.Left, n)
+		if !ok {
+			return nil, false
+		}
+		r, ok := EvalRat(nd.Right, n)
+		if !ok {
+			return nil, false
+		}
+		return applyBinaryRat(nd.Op, l, r)
+	}
+	return nil, false
+}
+
+// applyUnaryRat computes op(x), reusing x for the result.
+func applyUnaryRat(op UnaryOp, x *big.Rat) (*big.Rat, bool) {
+	switch op {
+	case OpNeg:
+		return x.Neg(x), true
+
+	case OpAbs:
+		return x.Abs(x), true
+
+	case OpAltSign:
+		iv, ok := ratInt64(x)
+		if !ok || iv < 0 {
+			return nil, false
+		}
+		if iv%2 == 0 {
+			return x.SetInt64(1), true
+		}
+		return x.SetInt64(-1), true
+
+	case OpFactorial, OpDoubleFactorial, OpSubfactorial, OpFibonacci:
+		iv, ok := ratInt64(x)
+		if !ok {
+			return nil, false
+		}
+		var v *big.Int
+		switch op {
+		case OpFactorial:
+			v, ok = factorialInt(iv)
+		case OpDoubleFactorial:
+			v, ok = doubleFactorialInt(iv)
+		case OpSubfactorial:
+			v, ok = subfactorialInt(iv)
+		default:
+			v, ok = fibonacciInt(iv)
+		}
+		if !ok {
+			return nil, false
+		}
+		return x.SetInt(v), true
+
+	case OpPrime:
+		iv, ok := ratInt64(x)
+		if !ok {
+			return nil, false
+		}
+		p, ok := primeAt(iv)
+		if !ok {
+			return nil, false
+		}
+		return x.SetInt64(p), true
+
+	case OpBernoulli:
+		iv, ok := ratInt64(x)
+		if !ok {
+			return nil, false
+		}
+		v, ok := bernoulliRat(iv)
+		if !ok {
+			return nil, false
+		}
+		return x.Set(v), true
+
+	case OpGamma:
+		// Γ(k) = (k-1)!; at other rationals Γ is irrational or undefined.
+		iv, ok := ratInt64(x)
+		if !ok || iv <= 0 {
+			return nil, false
+		}
+		v, ok := factorialInt(iv - 1)
+		if !ok {
+			return nil, false
+		}
+		return x.SetInt(v), true
+
+	case OpFloor:
+		return x.SetInt(ratFloor(x)), true
+
+	case OpCeil:
+		// ⌈x⌉ = -⌊-x⌋
+		f := ratFloor(x.Neg(x))
+		return x.SetInt(f.Neg(f)), true
+
+	case OpSqrt:
+		if x.Sign() < 0 {
+			return nil, false
+		}
+		num, ok := exactSqrt(x.Num())
+		if !ok {
+			return nil, false
+		}
+		den, ok := exactSqrt(x.Denom())
+		if !ok {
+			return nil, false
+		}
+		return x.SetFrac(num, den), true
+	}
+	// sin, cos, arctan, arcsin, the hyperbolic functions and ln of a
+	// rational are irrational (or, at 0 and 1, not worth the special case),
+	// and ψ at a rational carries γ.
+	return nil, false
+}
+
+// applyBinaryRat computes l op r, reusing l for the result.
+func applyBinaryRat(op BinaryOp, l, r *big.Rat) (*big.Rat, bool) {
+	switch op {
+	case OpAdd:
+		return l.Add(l, r), true
+
+	case OpSub:
+		return l.Sub(l, r), true
+
+	case OpMul:
+		return l.Mul(l, r), true
+
+	case OpDiv:
+		if r.Sign() == 0 {
+			return nil, false
+		}
+		return l.Quo(l, r), true
+
+	case OpPow:
+		e, ok := ratInt64(r)
+		if !ok || e > 10000 || e < -10000 {
+			return nil, false
+		}
+		if e < 0 {
+			if l.Sign() == 0 {
+				return nil, false
+			}
+			l.Inv(l)
+			e = -e
+		}
+		ee := big.NewInt(e)
+		num := new(big.Int).Exp(l.Num(), ee, nil)
+		den := new(big.Int).Exp(l.Denom(), ee, nil)
+		return l.SetFrac(num, den), true
+
+	case OpBinomial:
+		nv, ok := ratInt64(l)
+		if !ok || nv < 0 || nv > maxComputeInput {
+			return nil, false
+		}
+		kv, ok := ratInt64(r)
+		if !ok || kv < 0 || kv > nv {
+			return nil, false
+		}
+		return l.SetInt(new(big.Int).Binomial(nv, kv)), true
+
+	case OpPochhammer:
+		kv, ok := ratInt64(r)
+		if !ok || kv < 0 || kv > maxComputeInput {
+			return nil, false
+		}
+		result, x, one := big.NewRat(1, 1), new(big.Rat).Set(l), big.NewRat(1, 1)
+		for i := int64(0); i < kv; i++ {
+			result.Mul(result, x)
+			x.Add(x, one)
+		}
+		return l.Set(result), true
+
+	case OpMod:
+		if r.Sign() == 0 {
+			return nil, false
+		}
+		m := new(big.Rat).Abs(r)
+		q := ratFloor(new(big.Rat).Quo(l, m))
+		return l.Sub(l, m.Mul(m, new(big.Rat).SetInt(q))), true
+
+	case OpRoot:
+		// Only a perfect k-th power has a rational root.
+		k, ok := ratInt64(r)
+		if !ok || k < 1 || (l.Sign() < 0 && k%2 == 0) {
+			return nil, false
+		}
+		num, ok := exactRoot(new(big.Int).Abs(l.Num()), k)
+		if !ok {
+			return nil, false
+		}
+		den, ok := exactRoot(l.Denom(), k)
+		if !ok {
+			return nil, false
+		}
+		if l.Sign() < 0 {
+			num.Neg(num)
+		}
+		return l.SetFrac(num, den), true
+	}
+	return nil, false
+}
+
+// ratInt64 converts x to int64 if it is a whole number in range.
+func ratInt64(x *big.Rat) (int64, bool) {
+	if !x.IsInt() || !x.Num().IsInt64() {
+		return 0, false
+	}
+	return x.Num().Int64(), true
+}
+
+// ratNode returns x as a ConstNode if it is a whole number and otherwise
+// as \frac of two ConstNodes in lowest terms, or false if either does not
+// fit in an int64.
+func ratNode(x *big.Rat) (ExprNode, bool) {
+	if !x.Num().IsInt64() || !x.Denom().IsInt64() {
+		return nil, false
+	}
+	if x.IsInt() {
+		return &ConstNode{Val: x.Num().Int64()}, true
+	}
+	return &BinaryNode{Op: OpDiv, Left: &ConstNode{Val: x.Num().Int64()}, Right: &ConstNode{Val: x.Denom().Int64()}}, true
+}
+
+// ratFloor returns ⌊x⌋. big.Int.Div rounds toward -∞ for a positive
+// divisor, and Rat denominators are always positive.
+func ratFloor(x *big.Rat) *big.Int {
+	return new(big.Int).Div(x.Num(), x.Denom())
+}
+
+// exactSqrt returns √v if v is a perfect square.
+func exactSqrt(v *big.Int) (*big.Int, bool) {
+	s := new(big.Int).Sqrt(v)
+	if new(big.Int).Mul(s, s).Cmp(v) != 0 {
+		return nil, false
+	}
+	return s, true
+}
+
+// exactRoot returns the k-th root of v ≥ 0 if v is a perfect k-th power,
+// k ≥ 1. The floor of the root comes from Newton's method on big.Int,
+// x ← ((k-1)x + v/x^(k-1))/k, which decreases to it from any start above.
+func exactRoot(v *big.Int, k int64) (*big.Int, bool) {
+	if k == 1 || v.Cmp(big.NewInt(1)) <= 0 {
+		return new(big.Int).Set(v), true
+	}
+	if k >= int64(v.BitLen()) {
+		return nil, false // 1 < v < 2^k: the root lies strictly between 1 and 2
+	}
+	bk, bk1 := big.NewInt(k), big.NewInt(k-1)
+	x := new(big.Int).Lsh(big.NewInt(1), uint((int64(v.BitLen())+k-1)/k))
+	next, pow := new(big.Int), new(big.Int)
+	for {
+		pow.Exp(x, bk1, nil)
+		next.Quo(v, pow)
+		next.Add(next, pow.Mul(x, bk1))
+		next.Quo(next, bk)
+		if next.Cmp(x) >= 0 {
+			break
+		}
+		x, next = next, x
+	}
+	if pow.Exp(x, bk, nil).Cmp(v) != 0 {
+		return nil, false
+	}
+	return x, true
+}
@@ -0,0 +1,57 @@
+package expr
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestEvalRat(t *testing.T) {
+	n := &VarNode{}
+	c := func(v int64) ExprNode { return &ConstNode{Val: v} }
+	tests := []struct {
+		name string
+		node ExprNode
+		at   int64
+		want string // "" if EvalRat should fail
+	}{
+		{"quotient", &BinaryNode{Op: OpDiv, Left: c(2), Right: &BinaryNode{Op: OpMul, Left: c(4), Right: n}}, 3, "1/6"},
+		{"factorial", &UnaryNode{Op: OpFactorial, Child: &BinaryNode{Op: OpMul, Left: c(2), Right: n}}, 5, "3628800"},
+		{"altsign", &UnaryNode{Op: OpAltSign, Child: n}, 7, "-1"},
+		{"prime", &UnaryNode{Op: OpPrime, Child: &BinaryNode{Op: OpAdd, Left: n, Right: c(1)}}, 9, "29"},
+		{"bernoulli", &UnaryNode{Op: OpBernoulli, Child: &BinaryNode{Op: OpMul, Left: c(2), Right: n}}, 6, "-691/2730"},
+		{"negative pow", &BinaryNode{Op: OpPow, Left: &BinaryNode{Op: OpDiv, Left: c(2), Right: c(3)}, Right: &UnaryNode{Op: OpNeg, Child: n}}, 3, "27/8"},
+		{"binomial", &BinaryNode{Op: OpBinomial, Left: &BinaryNode{Op: OpMul, Left: c(2), Right: n}, Right: n}, 10, "184756"},
+		{"pochhammer", &BinaryNode{Op: OpPochhammer, Left: &BinaryNode{Op: OpDiv, Left: c(3), Right: c(2)}, Right: n}, 3, "105/8"},
+		{"mod", &BinaryNode{Op: OpMod, Left: &UnaryNode{Op: OpNeg, Child: n}, Right: &BinaryNode{Op: OpDiv, Left: c(3), Right: c(2)}}, 5, "1"},
+		{"root", &BinaryNode{Op: OpRoot, Left: &BinaryNode{Op: OpDiv, Left: &BinaryNode{Op: OpPow, Left: n, Right: c(4)}, Right: c(16)}, Right: c(4)}, 3, "3/2"},
+		{"irrational root", &BinaryNode{Op: OpRoot, Left: n, Right: c(3)}, 2, ""},
+		{"floor, ceil", &BinaryNode{Op: OpSub,
+			Left:  &UnaryNode{Op: OpCeil, Child: &BinaryNode{Op: OpDiv, Left: n, Right: c(-4)}},
+			Right: &UnaryNode{Op: OpFloor, Child: &BinaryNode{Op: OpDiv, Left: n, Right: c(-4)}}}, 7, "1"},
+		{"square sqrt", &UnaryNode{Op: OpSqrt, Child: &BinaryNode{Op: OpDiv, Left: &BinaryNode{Op: OpMul, Left: n, Right: n}, Right: c(9)}}, 5, "5/3"},
+		{"irrational sqrt", &UnaryNode{Op: OpSqrt, Child: n}, 2, ""},
+		{"sin", &UnaryNode{Op: OpSin, Child: n}, 1, ""},
+		{"fractional pow", &BinaryNode{Op: OpPow, Left: c(4), Right: &BinaryNode{Op: OpDiv, Left: c(1), Right: c(2)}}, 0, ""},
+		{"div by zero", &BinaryNode{Op: OpDiv, Left: c(1), Right: n}, 0, ""},
+	}
+	for _, tt := range tests {
+		got, ok := EvalRat(tt.node, tt.at)
+		if tt.want == "" {
+			if ok {
+				t.Errorf("%s: EvalRat = %s, want failure", tt.name, got.RatString())
+			}
+			continue
+		}
+		if !ok || got.RatString() != tt.want {
+			t.Errorf("%s: EvalRat = %v, %v; want %s", tt.name, got, ok, tt.want)
+			continue
+		}
+		// Eval agrees wherever the value is exact.
+		f, _ := tt.node.Eval(new(big.Float).SetInt64(tt.at), 256)
+		r, _ := got.Float64()
+		g, _ := f.Float64()
+		if r != g {
+			t.Errorf("%s: EvalRat %v, Eval %v", tt.name, r, g)
+		}
+	}
+}
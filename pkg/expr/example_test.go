@@ -0,0 +1,61 @@
+package expr_test
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/wildfunctions/genetic_series/pkg/expr"
+)
+
+func ExampleParseExprLatex() {
+	node, err := expr.ParseExprLatex(`\frac{(-1)^{n}}{2n + 1}`)
+	if err != nil {
+		panic(err)
+	}
+	v, ok := node.Eval(big.NewFloat(3), 64)
+	fmt.Println(node, v.Text('g', 6), ok)
+	fmt.Println(node.LaTeX())
+	// Output:
+	// ((-1)^(n) / ((2 * n) + 1)) -0.142857 true
+	// \frac{(-1)^{n}}{{{2} \cdot {n}} + {1}}
+}
+
+func ExampleSimplify() {
+	node, err := expr.ParseExprLatex(`(1)_{n} \cdot 1 + 0`)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(expr.Simplify(node))
+	// Output: (n)!
+}
+
+func ExampleEvalRat() {
+	node, err := expr.ParseExprLatex(`\frac{B_{n}}{n!}`)
+	if err != nil {
+		panic(err)
+	}
+	r, ok := expr.EvalRat(node, 6)
+	fmt.Println(r.RatString(), ok)
+	// Output: 1/30240 true
+}
+
+func ExampleRegisterOp() {
+	// A unary op whose value is half its argument.
+	err := expr.RegisterOp(expr.OpDef{
+		ID:    "halve",
+		Arity: 1,
+		Eval: func(args []*big.Float, prec uint) (*big.Float, bool) {
+			return args[0].Quo(args[0], big.NewFloat(2)), true
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+	node, err := expr.ParseExprLatex(`\operatorname{halve}(n) + 1`)
+	if err != nil {
+		panic(err)
+	}
+	v, _ := node.Eval(big.NewFloat(5), 64)
+	fmt.Println(node, v)
+	// Output: (halve(n) + 1) 3.5
+}
@@ -1,9 +1,11 @@
 package expr
 
 import (
+	"fmt"
 	"math"
 	"math/big"
 	"testing"
+	"time"
 )
 
 const testPrec = 512
@@ -149,6 +151,548 @@ func TestDoubleFactorial(t *testing.T) {
 	assertEval(t, node, 0, 48, 0)
 }
 
+func TestGamma(t *testing.T) {
+	gamma := func(x *big.Float) (*big.Float, bool) {
+		return (&UnaryNode{Op: OpGamma, Child: &VarNode{}}).Eval(x, testPrec)
+	}
+	ratio := func(p, q int64) *big.Float {
+		return new(big.Float).SetPrec(testPrec).Quo(bfInt(p), bfInt(q))
+	}
+	within := func(name string, got, want *big.Float) {
+		t.Helper()
+		rel := new(big.Float).Sub(got, want)
+		rel.Quo(rel, want)
+		if rel.Abs(rel).Cmp(new(big.Float).SetMantExp(bfInt(1), -int(testPrec)+8)) > 0 {
+			t.Errorf("%s = %s, want %s", name, got.Text('g', 40), want.Text('g', 40))
+		}
+	}
+
+	// Γ(k) = (k-1)!; Γ(0) and Γ(-k) are poles.
+	if v, ok := gamma(bfInt(6)); !ok || v.Cmp(bfInt(120)) != 0 {
+		t.Errorf("Γ(6) = %v, %v, want 120", v, ok)
+	}
+	for _, x := range []int64{0, -3} {
+		if _, ok := gamma(bfInt(x)); ok {
+			t.Errorf("Γ(%d) defined, want a pole", x)
+		}
+	}
+
+	// Γ(1/2) = √π, Γ(-3/2) = 4√π/3, against the float64 digits and
+	// the reflection Γ(1/2)Γ(-1/2) = -2π.
+	half, _ := gamma(ratio(1, 2))
+	if f, _ := half.Float64(); math.Abs(f-math.Sqrt(math.Pi)) > 1e-15 {
+		t.Errorf("Γ(1/2) = %v, want √π", f)
+	}
+	v, _ := gamma(ratio(-3, 2))
+	within("Γ(-3/2)", v, new(big.Float).Quo(new(big.Float).Mul(half, bfInt(4)), bfInt(3)))
+	v, _ = gamma(ratio(-1, 2))
+	pi := new(big.Float).SetPrec(testPrec).Mul(half, half)
+	within("Γ(1/2)Γ(-1/2)", v.Mul(v, half), new(big.Float).Mul(pi, bfInt(-2)))
+
+	// Spouge, for everything else: Γ(1/3)Γ(2/3) = 2π/√3 and
+	// Γ(x+1) = xΓ(x) to full precision, negative x included.
+	a, _ := gamma(ratio(1, 3))
+	b, _ := gamma(ratio(2, 3))
+	want := new(big.Float).SetPrec(testPrec).Mul(pi, bfInt(2))
+	want.Quo(want, new(big.Float).SetPrec(testPrec).Sqrt(bfInt(3)))
+	within("Γ(1/3)Γ(2/3)", a.Mul(a, b), want)
+	if f, _ := a.Float64(); math.Abs(f-2*math.Pi/math.Sqrt(3)) > 1e-14 {
+		t.Errorf("Γ(1/3)Γ(2/3) = %v in float64", f)
+	}
+	x := ratio(-7, 3)
+	gx, _ := gamma(x)
+	gx1, _ := gamma(new(big.Float).SetPrec(testPrec).Add(x, bfInt(1)))
+	within("Γ(-4/3)", gx1, new(big.Float).Mul(gx, x))
+	if f, _ := gx.Float64(); math.Abs(f-math.Gamma(-7.0/3)) > 1e-14 {
+		t.Errorf("Γ(-7/3) = %v, want %v", f, math.Gamma(-7.0/3))
+	}
+
+	// Like the factorial, Γ stops at maxComputeInput.
+	if _, ok := gamma(bfInt(maxComputeInput + 2)); ok {
+		t.Error("Γ past maxComputeInput+1 defined")
+	}
+}
+
+func TestBernoulli(t *testing.T) {
+	b := &UnaryNode{Op: OpBernoulli, Child: &VarNode{}}
+	for k, want := range map[int64]string{0: "1", 1: "-1/2", 2: "1/6", 3: "0", 4: "-1/30", 6: "1/42", 12: "-691/2730", 31: "0", 60: "-1215233140483755572040304994079820246041491/56786730"} {
+		if got, ok := EvalRat(b, k); !ok || got.RatString() != want {
+			t.Errorf("B_%d = %v, %v, want %s", k, got, ok, want)
+		}
+	}
+
+	// Σ_{k<m+1} C(m+1, k) B_k = 0 for every m ≥ 1, in exact arithmetic.
+	for m := int64(1); m <= 80; m++ {
+		sum := new(big.Rat)
+		for k := int64(0); k <= m; k++ {
+			bk, _ := bernoulliRat(k)
+			sum.Add(sum, new(big.Rat).Mul(bk, new(big.Rat).SetInt(new(big.Int).Binomial(m+1, k))))
+		}
+		if sum.Sign() != 0 {
+			t.Fatalf("recurrence fails at m = %d: %s", m, sum.RatString())
+		}
+	}
+
+	// B_2k/(2k)! → ±2/(2π)^2k; in float64 and big.Float alike.
+	v, ok := b.Eval(bfInt(200), testPrec)
+	f, fok := b.EvalF64(200)
+	if g, _ := v.Float64(); !ok || !fok || g != f || g > -1e215 || g < -1e216 {
+		t.Errorf("B_200 = %v (%v), %v (%v) in float64", v, ok, f, fok)
+	}
+	for _, x := range []float64{-2, 2.5, maxComputeInput + 2} {
+		if _, ok := b.EvalF64(x); ok {
+			t.Errorf("B_(%v) defined in float64", x)
+		}
+		if _, ok := b.Eval(new(big.Float).SetFloat64(x), testPrec); ok {
+			t.Errorf("B_(%v) defined", x)
+		}
+	}
+	if _, ok := b.EvalF64(maxComputeInput); ok {
+		t.Error("B_1000 defined in float64, past the largest finite value")
+	}
+}
+
+func TestPrime(t *testing.T) {
+	p := &UnaryNode{Op: OpPrime, Child: &VarNode{}}
+	for k, want := range map[int64]int64{1: 2, 2: 3, 3: 5, 6: 13, 10: 29, 1000: 7919, 100000: 1299709, maxPrimeIndex: 16290047} {
+		v, ok := p.Eval(bfInt(k), testPrec)
+		f, fok := p.EvalF64(float64(k))
+		if g, _ := v.Int64(); !ok || !fok || g != want || f != float64(want) {
+			t.Errorf("p_%d = %v (%v), %v (%v) in float64, want %d", k, v, ok, f, fok, want)
+		}
+	}
+	for _, x := range []float64{0, -3, 2.5, maxPrimeIndex + 1} {
+		if _, ok := p.EvalF64(x); ok {
+			t.Errorf("p_(%v) defined in float64", x)
+		}
+		if _, ok := p.Eval(new(big.Float).SetFloat64(x), testPrec); ok {
+			t.Errorf("p_(%v) defined", x)
+		}
+	}
+	if got := SimplifyBigFloat(&UnaryNode{Op: OpPrime, Child: &ConstNode{Val: 5}}, testPrec).String(); got != "11" {
+		t.Errorf("p_5 folds to %s, want 11", got)
+	}
+	// The table is sieved in steps; every prime of a shorter one is kept.
+	small := sievePrimes(1500)
+	for i, q := range small {
+		if got, _ := primeAt(int64(i + 1)); got != q {
+			t.Fatalf("p_%d = %d from the table, %d sieved alone", i+1, got, q)
+		}
+	}
+}
+
+func TestMod(t *testing.T) {
+	mod := func(a, b ExprNode) ExprNode { return &BinaryNode{Op: OpMod, Left: a, Right: b} }
+	c := func(v int64) ExprNode { return &ConstNode{Val: v} }
+	half := &BinaryNode{Op: OpDiv, Left: c(1), Right: c(2)}
+
+	// Euclidean: the result is in [0, |b|) whatever the signs.
+	assertEval(t, mod(&VarNode{}, c(4)), 7, 3, 0)
+	assertEval(t, mod(c(-7), c(4)), 0, 1, 0)
+	assertEval(t, mod(c(-7), c(-4)), 0, 1, 0)
+	assertEval(t, mod(c(7), c(-4)), 0, 3, 0)
+	assertEval(t, mod(c(-8), c(4)), 0, 0, 0)
+	// 7/2 mod 1/2 = 0, -5/2 mod 1 = 1/2
+	assertEval(t, mod(&BinaryNode{Op: OpDiv, Left: c(7), Right: c(2)}, half), 0, 0, 0)
+	assertEval(t, mod(&BinaryNode{Op: OpDiv, Left: c(-5), Right: c(2)}, c(1)), 0, 0.5, 0)
+
+	// π mod 1 at high precision is π - 3.
+	pi := &SymbolicConstNode{Sym: SymPi}
+	v, ok := mod(pi, c(1)).Eval(bfInt(0), testPrec)
+	p, _ := pi.Eval(bfInt(0), testPrec)
+	want := new(big.Float).SetPrec(testPrec).Sub(p, big.NewFloat(3))
+	if diff := new(big.Float).Sub(v, want); !ok || diff.Abs(diff).Cmp(new(big.Float).SetMantExp(big.NewFloat(1), -int(testPrec-8))) > 0 {
+		t.Errorf("pi mod 1 = %v, %v, want %v", v, ok, want)
+	}
+	if r, ok := EvalRat(mod(c(-7), half), 0); !ok || r.Cmp(big.NewRat(0, 1)) != 0 {
+		t.Errorf("-7 mod 1/2 = %v, %v exactly", r, ok)
+	}
+	if r, ok := EvalRat(mod(&BinaryNode{Op: OpDiv, Left: c(-7), Right: c(3)}, c(2)), 0); !ok || r.Cmp(big.NewRat(5, 3)) != 0 {
+		t.Errorf("-7/3 mod 2 = %v, %v exactly, want 5/3", r, ok)
+	}
+
+	// A zero modulus fails in every evaluator instead of dividing by zero.
+	zero := mod(&VarNode{}, &BinaryNode{Op: OpSub, Left: &VarNode{}, Right: c(3)})
+	if _, ok := zero.Eval(bfInt(3), testPrec); ok {
+		t.Error("3 mod 0 defined")
+	}
+	if _, ok := zero.EvalF64(3); ok {
+		t.Error("3 mod 0 defined in float64")
+	}
+	if _, ok := EvalRat(zero, 3); ok {
+		t.Error("3 mod 0 defined exactly")
+	}
+}
+
+func TestRoot(t *testing.T) {
+	root := func(a ExprNode, k int64) ExprNode { return &BinaryNode{Op: OpRoot, Left: a, Right: &ConstNode{Val: k}} }
+	c := func(v int64) ExprNode { return &ConstNode{Val: v} }
+
+	// Perfect powers are exact, odd roots of negatives are negative.
+	assertEval(t, root(c(27), 3), 0, 3, 0)
+	assertEval(t, root(c(-32), 5), 0, -2, 0)
+	assertEval(t, root(&VarNode{}, 1), 7, 7, 0)
+	assertEval(t, root(c(0), 4), 0, 0, 0)
+	v, ok := root(c(1<<40), 10).Eval(bfInt(0), testPrec)
+	if !ok || v.Cmp(big.NewFloat(16)) != 0 {
+		t.Errorf("root(2^40, 10) = %v, %v, want 16 exactly", v, ok)
+	}
+
+	// 2^{1/3} at high precision cubes back to 2.
+	v, ok = root(c(2), 3).Eval(bfInt(0), testPrec)
+	cube := new(big.Float).SetPrec(testPrec).Mul(v, v)
+	cube.Mul(cube, v)
+	if diff := cube.Sub(cube, big.NewFloat(2)); !ok || diff.Abs(diff).Cmp(new(big.Float).SetMantExp(big.NewFloat(1), -int(testPrec-8))) > 0 {
+		t.Errorf("root(2, 3)^3 - 2 = %v, %v", diff, ok)
+	}
+	if f, ok := root(c(2), 3).EvalF64(0); !ok || math.Abs(f-math.Cbrt(2)) > 1e-15 {
+		t.Errorf("root(2, 3) = %v, %v in float64", f, ok)
+	}
+	if f, ok := root(c(-243), 5).EvalF64(0); !ok || f != -3 {
+		t.Errorf("root(-243, 5) = %v, %v in float64, want -3", f, ok)
+	}
+
+	// Rationals: only perfect powers have an exact root.
+	frac := &BinaryNode{Op: OpDiv, Left: c(-8), Right: c(27)}
+	if r, ok := EvalRat(root(frac, 3), 0); !ok || r.Cmp(big.NewRat(-2, 3)) != 0 {
+		t.Errorf("root(-8/27, 3) = %v, %v exactly, want -2/3", r, ok)
+	}
+	if _, ok := EvalRat(root(c(2), 3), 0); ok {
+		t.Error("root(2, 3) rational")
+	}
+
+	// Even roots of negatives and indices that aren't positive integers
+	// fail in every evaluator.
+	half := &BinaryNode{Op: OpDiv, Left: c(1), Right: c(2)}
+	for _, bad := range []ExprNode{root(c(-4), 2), root(c(8), 0), root(c(8), -3), &BinaryNode{Op: OpRoot, Left: c(8), Right: half}} {
+		if _, ok := bad.Eval(bfInt(0), testPrec); ok {
+			t.Errorf("%s defined", bad)
+		}
+		if _, ok := bad.EvalF64(0); ok {
+			t.Errorf("%s defined in float64", bad)
+		}
+		if _, ok := EvalRat(bad, 0); ok {
+			t.Errorf("%s defined exactly", bad)
+		}
+	}
+
+	if s := root(&VarNode{}, 3).LaTeX(); s != `\sqrt[3]{n}` {
+		t.Errorf("LaTeX = %q", s)
+	}
+}
+
+func TestArctanArcsin(t *testing.T) {
+	atan := func(a ExprNode) ExprNode { return &UnaryNode{Op: OpArctan, Child: a} }
+	asin := func(a ExprNode) ExprNode { return &UnaryNode{Op: OpArcsin, Child: a} }
+	c := func(v int64) ExprNode { return &ConstNode{Val: v} }
+	frac := func(a, b int64) ExprNode { return &BinaryNode{Op: OpDiv, Left: c(a), Right: c(b)} }
+	mul := func(k int64, x ExprNode) ExprNode { return &BinaryNode{Op: OpMul, Left: c(k), Right: x} }
+	pi, _ := (&SymbolicConstNode{Sym: SymPi}).Eval(bfInt(0), testPrec)
+
+	// Each is π at full precision: Machin's formula, arctan past 1 through
+	// the reflection, and arcsin inside and at the end of its domain.
+	for _, tt := range []struct {
+		name string
+		node ExprNode
+	}{
+		{"machin", mul(4, &BinaryNode{Op: OpSub, Left: mul(4, atan(frac(1, 5))), Right: atan(frac(1, 239))})},
+		{"arctan 1", mul(4, atan(c(1)))},
+		{"arctan sqrt 3", mul(3, atan(&UnaryNode{Op: OpSqrt, Child: c(3)}))},
+		{"arctan -1/sqrt 3", mul(-6, atan(&UnaryNode{Op: OpNeg, Child: &BinaryNode{Op: OpDiv, Left: c(1), Right: &UnaryNode{Op: OpSqrt, Child: c(3)}}}))},
+		{"arcsin 1/2", mul(6, asin(frac(1, 2)))},
+		{"arcsin 1", mul(2, asin(c(1)))},
+		{"arcsin -1", mul(-2, asin(c(-1)))},
+		{"arcsin sqrt 2 / 2", mul(4, asin(&BinaryNode{Op: OpDiv, Left: &UnaryNode{Op: OpSqrt, Child: c(2)}, Right: c(2)}))},
+	} {
+		v, ok := tt.node.Eval(bfInt(0), testPrec)
+		if diff := new(big.Float).Sub(v, pi); !ok || diff.Abs(diff).Cmp(new(big.Float).SetMantExp(big.NewFloat(1), -int(testPrec-8))) > 0 {
+			t.Errorf("%s: %v, %v, want pi", tt.name, v, ok)
+		}
+		if f, ok := tt.node.EvalF64(0); !ok || math.Abs(f-math.Pi) > 1e-14 {
+			t.Errorf("%s: %v, %v in float64, want pi", tt.name, f, ok)
+		}
+	}
+
+	// arctan of a large argument approaches π/2 from below.
+	assertEval(t, atan(c(1_000_000)), 0, math.Pi/2-1e-6, 1e-15)
+	assertEval(t, atan(c(0)), 0, 0, 0)
+	for _, bad := range []ExprNode{asin(c(2)), asin(frac(-3, 2))} {
+		if _, ok := bad.Eval(bfInt(0), testPrec); ok {
+			t.Errorf("%s defined", bad)
+		}
+		if _, ok := bad.EvalF64(0); ok {
+			t.Errorf("%s defined in float64", bad)
+		}
+	}
+}
+
+func TestHyperbolic(t *testing.T) {
+	un := func(op UnaryOp, a ExprNode) ExprNode { return &UnaryNode{Op: op, Child: a} }
+	frac := func(a, b int64) ExprNode {
+		return &BinaryNode{Op: OpDiv, Left: &ConstNode{Val: a}, Right: &ConstNode{Val: b}}
+	}
+	tol := new(big.Float).SetMantExp(big.NewFloat(1), -int(testPrec-8))
+
+	// cosh² - sinh² = 1 and tanh = sinh/cosh to full precision, including
+	// near 0, where e^x - e^-x cancels.
+	for _, x := range []ExprNode{frac(3, 2), frac(-7, 1), frac(1, 1_000_000_000)} {
+		s, sok := un(OpSinh, x).Eval(bfInt(0), testPrec)
+		c, cok := un(OpCosh, x).Eval(bfInt(0), testPrec)
+		th, tok := un(OpTanh, x).Eval(bfInt(0), testPrec)
+		if !sok || !cok || !tok {
+			t.Fatalf("%s: sinh, cosh, tanh defined = %v, %v, %v", x, sok, cok, tok)
+		}
+		one := new(big.Float).SetPrec(testPrec).Mul(c, c)
+		one.Sub(one, new(big.Float).SetPrec(testPrec).Mul(s, s))
+		if d := one.Sub(one, big.NewFloat(1)); d.Abs(d).Cmp(new(big.Float).Mul(tol, new(big.Float).Mul(c, c))) > 0 {
+			t.Errorf("%s: cosh^2 - sinh^2 - 1 = %v", x, d)
+		}
+		q := new(big.Float).SetPrec(testPrec).Quo(s, c)
+		if d := q.Sub(q, th); d.Abs(d).Cmp(new(big.Float).Mul(tol, new(big.Float).Abs(th))) > 0 {
+			t.Errorf("%s: sinh/cosh - tanh = %v", x, d)
+		}
+	}
+
+	assertEval(t, un(OpSinh, &VarNode{}), 1, math.Sinh(1), 1e-15)
+	assertEval(t, un(OpCosh, &VarNode{}), 2, math.Cosh(2), 1e-14)
+	assertEval(t, un(OpTanh, &VarNode{}), 1e6, 1, 0)
+	assertEval(t, un(OpTanh, &VarNode{}), -3, math.Tanh(-3), 1e-15)
+	if f, ok := un(OpSinh, frac(-1, 2)).EvalF64(0); !ok || f != math.Sinh(-0.5) {
+		t.Errorf("sinh(-1/2) = %v, %v in float64", f, ok)
+	}
+
+	// Past the exponent range sinh and cosh fail; tanh is ±1.
+	huge := un(OpCosh, &BinaryNode{Op: OpPow, Left: &ConstNode{Val: 10}, Right: &ConstNode{Val: 10}})
+	if _, ok := huge.Eval(bfInt(0), testPrec); ok {
+		t.Error("cosh(10^10) defined")
+	}
+	if _, ok := huge.EvalF64(0); ok {
+		t.Error("cosh(10^10) defined in float64")
+	}
+}
+
+func TestTanExp(t *testing.T) {
+	un := func(op UnaryOp, a ExprNode) ExprNode { return &UnaryNode{Op: op, Child: a} }
+	c := func(v int64) ExprNode { return &ConstNode{Val: v} }
+	piOver := func(k int64) ExprNode {
+		return &BinaryNode{Op: OpDiv, Left: &SymbolicConstNode{Sym: SymPi}, Right: c(k)}
+	}
+	tol := new(big.Float).SetMantExp(big.NewFloat(1), -int(testPrec-8))
+	near := func(name string, node ExprNode, want *big.Float) {
+		t.Helper()
+		v, ok := node.Eval(bfInt(0), testPrec)
+		if !ok {
+			t.Errorf("%s undefined", name)
+			return
+		}
+		d := new(big.Float).Sub(v, want)
+		if d.Abs(d).Cmp(new(big.Float).Mul(tol, new(big.Float).Abs(want))) > 0 {
+			t.Errorf("%s = %v, off by %v", name, v, d)
+		}
+	}
+
+	// Both to full precision: e^1 = e, tan(π/4) = 1, tan(π/3) = √3 and
+	// tan(-π/6) = -1/√3, with π reduced out of 7π/4 too.
+	e, _ := (&SymbolicConstNode{Sym: SymE}).Eval(bfInt(0), testPrec)
+	near("exp(1)", un(OpExp, c(1)), e)
+	one := new(big.Float).SetPrec(testPrec).SetInt64(1)
+	near("tan(pi/4)", un(OpTan, piOver(4)), one)
+	near("tan(7pi/4)", un(OpTan, &BinaryNode{Op: OpMul, Left: c(7), Right: piOver(4)}), new(big.Float).Neg(one))
+	root3 := bigSqrt(new(big.Float).SetPrec(testPrec).SetInt64(3), testPrec)
+	near("tan(pi/3)", un(OpTan, piOver(3)), root3)
+	near("tan(-pi/6)", un(OpTan, un(OpNeg, piOver(6))), new(big.Float).SetPrec(testPrec).Quo(new(big.Float).Neg(one), root3))
+
+	assertEval(t, un(OpTan, &VarNode{}), 1e6, math.Tan(1e6), 1e-9)
+	assertEval(t, un(OpExp, &VarNode{}), -50, math.Exp(-50), 1e-35)
+
+	// e^x past float64's range is still defined; past big.Float's it
+	// fails, or is 0 for a negative x.
+	if _, ok := un(OpExp, c(1000)).Eval(bfInt(0), testPrec); !ok {
+		t.Error("exp(1000) undefined")
+	}
+	huge := &BinaryNode{Op: OpPow, Left: c(10), Right: c(10)}
+	if _, ok := un(OpExp, huge).Eval(bfInt(0), testPrec); ok {
+		t.Error("exp(10^10) defined")
+	}
+	if v, ok := un(OpExp, un(OpNeg, huge)).Eval(bfInt(0), testPrec); !ok || v.Sign() != 0 {
+		t.Errorf("exp(-10^10) = %v, %v; want 0", v, ok)
+	}
+}
+
+func TestDigamma(t *testing.T) {
+	psi := func(a ExprNode) ExprNode { return &UnaryNode{Op: OpDigamma, Child: a} }
+	frac := func(a, b int64) ExprNode {
+		return &BinaryNode{Op: OpDiv, Left: &ConstNode{Val: a}, Right: &ConstNode{Val: b}}
+	}
+	eval := func(node ExprNode, prec uint) *big.Float {
+		t.Helper()
+		v, ok := node.Eval(bfInt(0), prec)
+		if !ok {
+			t.Fatalf("%s undefined", node)
+		}
+		return v
+	}
+	near := func(what string, got, want *big.Float, prec uint) {
+		t.Helper()
+		d := new(big.Float).Sub(got, want)
+		tol := new(big.Float).SetMantExp(new(big.Float).Abs(want), -int(prec-8))
+		if d.Abs(d).Cmp(tol) > 0 {
+			t.Errorf("%s = %s, want %s", what, got.Text('g', 30), want.Text('g', 30))
+		}
+	}
+
+	// ψ(1) = -γ and ψ(1/2) = -γ - 2 ln 2, at two precisions.
+	for _, prec := range []uint{testPrec, 2048} {
+		gamma := symbolValue(SymGamma, prec)
+		near("psi(1)", eval(psi(&ConstNode{Val: 1}), prec), new(big.Float).Neg(gamma), prec)
+		want := new(big.Float).SetPrec(prec).Mul(bigLn(new(big.Float).SetPrec(prec).SetInt64(2), prec), big.NewFloat(2))
+		want.Add(want, gamma).Neg(want)
+		near("psi(1/2)", eval(psi(frac(1, 2)), prec), want, prec)
+	}
+
+	// ψ(x+1) - ψ(x) = 1/x, either side of 0 and far out, where the
+	// difference cancels some 30 bits.
+	for _, x := range [][2]int64{{7, 3}, {-5, 2}, {-11, 7}, {1_000_000_001, 10}} {
+		lo := eval(psi(frac(x[0], x[1])), testPrec)
+		hi := eval(psi(frac(x[0]+x[1], x[1])), testPrec)
+		near(fmt.Sprintf("psi(%d/%d + 1) - psi(%d/%d)", x[0], x[1], x[0], x[1]),
+			new(big.Float).Sub(hi, lo), new(big.Float).SetPrec(testPrec).Quo(bfInt(x[1]), bfInt(x[0])), testPrec-40)
+	}
+
+	for _, x := range []float64{1, 1.4616321449683623, 3.5, 20, -0.5, -2.75, 1e9} {
+		assertEval(t, psi(&VarNode{}), x, mustDigammaF64(t, x), 1e-13)
+	}
+	if f, ok := psi(frac(1, 2)).EvalF64(0); !ok || math.Abs(f+1.9635100260214235) > 1e-15 {
+		t.Errorf("psi(1/2) = %v, %v in float64", f, ok)
+	}
+	for _, x := range []ExprNode{&ConstNode{Val: 0}, &ConstNode{Val: -3}, frac(-4001, 2)} {
+		if _, ok := psi(x).Eval(bfInt(0), testPrec); ok {
+			t.Errorf("psi(%s) defined", x)
+		}
+	}
+	if _, ok := psi(&ConstNode{Val: -3}).EvalF64(0); ok {
+		t.Error("psi(-3) defined in float64")
+	}
+}
+
+// mustDigammaF64 is digammaF64(x), failing t if it is undefined.
+func mustDigammaF64(t *testing.T, x float64) float64 {
+	t.Helper()
+	r, ok := digammaF64(x)
+	if !ok {
+		t.Fatalf("digammaF64(%v) undefined", x)
+	}
+	return r
+}
+
+func TestSubfactorial(t *testing.T) {
+	sub := func(a ExprNode) ExprNode { return &UnaryNode{Op: OpSubfactorial, Child: a} }
+
+	// !0..!10, the derangement numbers (OEIS A000166).
+	want := []int64{1, 0, 1, 2, 9, 44, 265, 1854, 14833, 133496, 1334961}
+	for k, w := range want {
+		assertEval(t, sub(&ConstNode{Val: int64(k)}), 0, float64(w), 0)
+		if v, ok := sub(&VarNode{}).EvalF64(float64(k)); !ok || v != float64(w) {
+			t.Errorf("!%d in float64 = %v, %v, want %d", k, v, ok, w)
+		}
+	}
+
+	// Past the seeded table the recurrence keeps !n = round(n!/e) exactly.
+	v, ok := subfactorialInt(30)
+	if !ok || v.String() != "97581073836835777732377428235481" {
+		t.Errorf("!30 = %v, %v, want 97581073836835777732377428235481", v, ok)
+	}
+	if r, ok := EvalRat(sub(&ConstNode{Val: 25}), 0); !ok || r.Num().String() != "5706255282633466762357224" {
+		t.Errorf("EvalRat !25 = %v, %v", r, ok)
+	}
+
+	for _, x := range []int64{-1, -4} {
+		if _, ok := sub(&ConstNode{Val: x}).Eval(bfInt(0), testPrec); ok {
+			t.Errorf("!(%d) defined, want undefined", x)
+		}
+	}
+	half := &BinaryNode{Op: OpDiv, Left: &ConstNode{Val: 1}, Right: &ConstNode{Val: 2}}
+	if _, ok := sub(half).Eval(bfInt(0), testPrec); ok {
+		t.Error("!(1/2) defined, want undefined")
+	}
+}
+
+func TestPochhammer(t *testing.T) {
+	poch := func(a ExprNode, k int64) ExprNode {
+		return &BinaryNode{Op: OpPochhammer, Left: a, Right: &ConstNode{Val: k}}
+	}
+	half := &BinaryNode{Op: OpDiv, Left: &ConstNode{Val: 1}, Right: &ConstNode{Val: 2}}
+
+	// (3)_4 = 3·4·5·6, (1)_5 = 5!, (-2)_3 = (-2)(-1)(0), (x)_0 = 1
+	assertEval(t, poch(&ConstNode{Val: 3}, 4), 0, 360, 0)
+	assertEval(t, poch(&ConstNode{Val: 1}, 5), 0, 120, 0)
+	assertEval(t, poch(&ConstNode{Val: -2}, 3), 0, 0, 0)
+	assertEval(t, poch(&VarNode{}, 0), 7, 1, 0)
+	// (1/2)_3 = 1/2 · 3/2 · 5/2
+	assertEval(t, poch(half, 3), 0, 15.0/8, 1e-15)
+
+	// (1/2)_k = (2k)! / (4^k k!), exactly and at high precision.
+	for _, k := range []int64{10, 200} {
+		v, ok := poch(half, k).Eval(bfInt(0), testPrec)
+		r, rok := EvalRat(poch(half, k), 0)
+		want := new(big.Rat).SetFrac(new(big.Int).MulRange(k+1, 2*k), new(big.Int).Lsh(big.NewInt(1), uint(2*k)))
+		wf := new(big.Float).SetPrec(testPrec).SetRat(want)
+		diff := new(big.Float).Sub(v, wf)
+		diff.Quo(diff, wf)
+		if !ok || !rok || r.Cmp(want) != 0 || diff.Abs(diff).Cmp(new(big.Float).SetMantExp(big.NewFloat(1), -int(testPrec-8))) > 0 {
+			t.Errorf("(1/2)_%d = %v (%v), %v (%v) exactly", k, v, ok, r, rok)
+		}
+	}
+
+	for _, k := range []int64{-1, maxComputeInput + 1} {
+		if _, ok := poch(half, k).Eval(bfInt(0), testPrec); ok {
+			t.Errorf("(1/2)_%d defined", k)
+		}
+		if _, ok := poch(half, k).EvalF64(0); ok {
+			t.Errorf("(1/2)_%d defined in float64", k)
+		}
+	}
+	frac := &BinaryNode{Op: OpPochhammer, Left: &ConstNode{Val: 2}, Right: half}
+	if _, ok := frac.Eval(bfInt(0), testPrec); ok {
+		t.Error("(2)_{1/2} defined")
+	}
+}
+
+func TestSymbolicConst(t *testing.T) {
+	const prec = 2048
+	sym := func(s Symbol) ExprNode { return &SymbolicConstNode{Sym: s} }
+
+	// φ² = φ + 1 holds to the last few bits only if φ is exact at prec.
+	phi, _ := sym(SymPhi).Eval(bfInt(0), prec)
+	lhs := new(big.Float).SetPrec(prec).Mul(phi, phi)
+	rhs := new(big.Float).SetPrec(prec).Add(phi, bfInt(1))
+	if d := lhs.Sub(lhs, rhs); d.Sign() != 0 && d.MantExp(nil) > 8-prec {
+		t.Errorf("φ² - φ - 1 = %s", d.Text('g', 5))
+	}
+	if phi.Prec() != prec {
+		t.Errorf("φ at %d bits, want %d", phi.Prec(), prec)
+	}
+	for s, want := range map[Symbol]float64{SymPi: math.Pi, SymE: math.E, SymPhi: math.Phi, SymGamma: 0.5772156649015329} {
+		if f, _ := sym(s).EvalF64(0); f != want {
+			t.Errorf("%s in float64 = %v, want %v", s, f, want)
+		}
+		if _, ok := EvalRat(sym(s), 1); ok {
+			t.Errorf("EvalRat(%s) exact", s)
+		}
+	}
+
+	// Folding rounds away nothing with a symbol in it, but still folds the
+	// integer parts beside it.
+	node, _ := ParseExprLatex(`\frac{\pi}{2} + \frac{6}{2} n`)
+	got, warnings := SimplifyBigFloatWarnings(node, testPrec)
+	if got.String() != "((3 * n) + (pi / 2))" || len(warnings) != 0 {
+		t.Errorf("SimplifyBigFloat = %s, %v, want ((3 * n) + (pi / 2)) and no warnings", got, warnings)
+	}
+	if Equal(sym(SymPi), sym(SymE)) || Hash(sym(SymPi)) == Hash(sym(SymE)) || StableHash(sym(SymPi)) == StableHash(&ConstNode{Val: 0}) {
+		t.Error("symbols not told apart by Equal and the hashes")
+	}
+}
+
 func TestClone(t *testing.T) {
 	original := &BinaryNode{
 		Op:   OpAdd,
@@ -194,6 +738,54 @@ func TestComplexity(t *testing.T) {
 	}
 }
 
+func TestDescriptionLength(t *testing.T) {
+	for _, tc := range []struct {
+		v    int64
+		bits float64
+	}{{0, 4}, {1, 6}, {-1, 6}, {2, 6}, {3, 8}, {26390, 32}, {math.MinInt64, 130}} {
+		if got := DescriptionLength(&ConstNode{Val: tc.v}); got != tc.bits {
+			t.Errorf("DescriptionLength(%d) = %v, want %v", tc.v, got, tc.bits)
+		}
+	}
+
+	// One large constant is not simpler than three small ones multiplied.
+	large := &BinaryNode{Op: OpMul, Left: &ConstNode{Val: 26390}, Right: &VarNode{}}
+	small := &BinaryNode{Op: OpMul,
+		Left:  &BinaryNode{Op: OpMul, Left: &ConstNode{Val: 2}, Right: &ConstNode{Val: 3}},
+		Right: &BinaryNode{Op: OpMul, Left: &ConstNode{Val: 5}, Right: &VarNode{}},
+	}
+	if DescriptionLength(large) <= DescriptionLength(small) {
+		t.Errorf("26390·n (%v bits) should cost more than 2·3·5·n (%v bits)",
+			DescriptionLength(large), DescriptionLength(small))
+	}
+}
+
+func TestEvalCost(t *testing.T) {
+	// n! · (2n)! / (n+1)!: 10 nodes, three factorials.
+	factorials := &BinaryNode{Op: OpDiv,
+		Left: &BinaryNode{Op: OpMul,
+			Left:  &UnaryNode{Op: OpFactorial, Child: &VarNode{}},
+			Right: &UnaryNode{Op: OpFactorial, Child: &BinaryNode{Op: OpMul, Left: &ConstNode{Val: 2}, Right: &VarNode{}}},
+		},
+		Right: &UnaryNode{Op: OpFactorial, Child: &BinaryNode{Op: OpAdd, Left: &VarNode{}, Right: &ConstNode{Val: 1}}},
+	}
+	// 1/(n(n+1)(n+2)...(n+5)): 23 nodes, all arithmetic.
+	var rational ExprNode = &VarNode{}
+	for i := int64(1); i <= 5; i++ {
+		rational = &BinaryNode{Op: OpMul, Left: rational, Right: &BinaryNode{Op: OpAdd, Left: &VarNode{}, Right: &ConstNode{Val: i}}}
+	}
+	rational = &BinaryNode{Op: OpDiv, Left: &ConstNode{Val: 1}, Right: rational}
+	if factorials.NodeCount() >= rational.NodeCount() {
+		t.Fatalf("factorial tree has %d nodes, rational %d", factorials.NodeCount(), rational.NodeCount())
+	}
+	if fc, rc := EvalCost(factorials), EvalCost(rational); fc < 5*rc {
+		t.Errorf("EvalCost: factorials %v, rational %v; want factorials far dearer", fc, rc)
+	}
+	if got := EvalCost(&VarNode{}); got != 0 {
+		t.Errorf("EvalCost(n) = %v, want 0", got)
+	}
+}
+
 func TestString(t *testing.T) {
 	// 1 / n!
 	tree := &BinaryNode{
@@ -225,6 +817,45 @@ func TestLaTeX(t *testing.T) {
 	}
 }
 
+func TestTypst(t *testing.T) {
+	tests := []struct {
+		latex, want string
+	}{
+		{`\frac{1}{{n}!}`, "(1)/(n!)"},
+		{`(n + 1)!`, "(n + 1)!"},
+		{`n - (n + 2)`, "n - (n + 2)"},
+		{`(n + 1) \cdot (n - 1)`, "(n + 1) dot (n - 1)"},
+		{`(n + 1)^{2 n}`, "(n + 1)^(2 dot n)"},
+		{`\binom{2 n}{n}`, "binom(2 dot n, n)"},
+		{`\sqrt{n} + \sin(n)`, "sqrt(n) + sin(n)"},
+		{`\Gamma(n)`, "Gamma(n)"},
+		{`(-1)^{n}`, "(-1)^(n)"},
+		{`F_{n}`, "F_(n)"},
+		{`(\frac{1}{2})_{n}`, "((1)/(2))_(n)"},
+		{`(2n + 1) \bmod 4`, "(2 dot n + 1) mod 4"},
+		{`n \bmod (2 \cdot 3)`, "n mod (2 dot 3)"},
+		{`\sqrt[3]{n + 1}`, "root(3, n + 1)"},
+		{`\arctan(\frac{1}{n})`, "arctan((1)/(n))"},
+		{`\cosh(n) - \tanh(n)`, "cosh(n) - tanh(n)"},
+		{`\psi(n + 1)`, "psi(n + 1)"},
+		{`{!{n + 1}}`, "!(n + 1)"},
+	}
+	for _, tt := range tests {
+		node, err := ParseExprLatex(tt.latex)
+		if err != nil {
+			t.Fatalf("ParseExprLatex(%q): %v", tt.latex, err)
+		}
+		if got := Typst(node); got != tt.want {
+			t.Errorf("Typst(%s) = %q, want %q", tt.latex, got, tt.want)
+		}
+	}
+
+	neg := &BinaryNode{Op: OpAdd, Left: &VarNode{}, Right: &ConstNode{Val: -3}}
+	if got := Typst(neg); got != "n + (-3)" {
+		t.Errorf("Typst(n + -3) = %q", got)
+	}
+}
+
 // TestKnownSeries_EMinusOne: Sum_{n=0}^{inf} 1/n! = e
 // We verify the partial sum of 1/n! for n=0..20 ≈ e with high precision.
 func TestKnownSeries_EMinusOne(t *testing.T) {
@@ -366,6 +997,196 @@ func TestSimplify(t *testing.T) {
 			&BinaryNode{Op: OpSub, Left: &VarNode{}, Right: &ConstNode{Val: math.MinInt64}},
 			"(n - -9223372036854775808)",
 		},
+		{
+			"0^n kept (1 at n = 0)",
+			&BinaryNode{Op: OpPow, Left: &ConstNode{Val: 0}, Right: &VarNode{}},
+			"(0)^(n)",
+		},
+		{
+			"0^2 = 0",
+			&BinaryNode{Op: OpPow, Left: &ConstNode{Val: 0}, Right: &ConstNode{Val: 2}},
+			"0",
+		},
+		{
+			"63^16 overflows, not folded",
+			&BinaryNode{Op: OpPow, Left: &ConstNode{Val: 63}, Right: &ConstNode{Val: 16}},
+			"(63)^(16)",
+		},
+		{
+			"tan(0) = 0",
+			&UnaryNode{Op: OpTan, Child: &ConstNode{Val: 0}},
+			"0",
+		},
+		{
+			"e^0 = 1",
+			&UnaryNode{Op: OpExp, Child: &BinaryNode{Op: OpSub, Left: &VarNode{}, Right: &VarNode{}}},
+			"1",
+		},
+		{
+			"ln(e^x) = x",
+			&UnaryNode{Op: OpLn, Child: &UnaryNode{Op: OpExp, Child: &VarNode{}}},
+			"n",
+		},
+		{
+			"e^a * e^b = e^(a+b)",
+			&BinaryNode{Op: OpMul, Left: &UnaryNode{Op: OpExp, Child: &VarNode{}}, Right: &UnaryNode{Op: OpExp, Child: &ConstNode{Val: 2}}},
+			"exp((2 + n))",
+		},
+		{
+			"Γ(5) = 4!",
+			&UnaryNode{Op: OpGamma, Child: &ConstNode{Val: 5}},
+			"24",
+		},
+		{
+			"Γ(n+1) = n!",
+			&UnaryNode{Op: OpGamma, Child: &BinaryNode{Op: OpAdd, Left: &VarNode{}, Right: &ConstNode{Val: 1}}},
+			"(n)!",
+		},
+		{
+			"Γ(n-2) = (n-3)!",
+			&UnaryNode{Op: OpGamma, Child: &BinaryNode{Op: OpSub, Left: &VarNode{}, Right: &ConstNode{Val: 2}}},
+			"((n - 3))!",
+		},
+		{
+			"Γ(n/2) kept",
+			&UnaryNode{Op: OpGamma, Child: &BinaryNode{Op: OpDiv, Left: &VarNode{}, Right: &ConstNode{Val: 2}}},
+			"gamma((n / 2))",
+		},
+		{
+			"B_7 = 0",
+			&UnaryNode{Op: OpBernoulli, Child: &ConstNode{Val: 7}},
+			"0",
+		},
+		{
+			"B_4 kept",
+			&UnaryNode{Op: OpBernoulli, Child: &ConstNode{Val: 4}},
+			"bernoulli(4)",
+		},
+		{
+			"(x)_0 = 1",
+			&BinaryNode{Op: OpPochhammer, Left: &VarNode{}, Right: &ConstNode{Val: 0}},
+			"1",
+		},
+		{
+			"(x)_1 = x",
+			&BinaryNode{Op: OpPochhammer, Left: &VarNode{}, Right: &ConstNode{Val: 1}},
+			"n",
+		},
+		{
+			"(1)_n = n!",
+			&BinaryNode{Op: OpPochhammer, Left: &ConstNode{Val: 1}, Right: &VarNode{}},
+			"(n)!",
+		},
+		{
+			"(2)_n = (n+1)!",
+			&BinaryNode{Op: OpPochhammer, Left: &ConstNode{Val: 2}, Right: &VarNode{}},
+			"((1 + n))!",
+		},
+		{
+			"(3)_4 = 360",
+			&BinaryNode{Op: OpPochhammer, Left: &ConstNode{Val: 3}, Right: &ConstNode{Val: 4}},
+			"360",
+		},
+		{
+			"(3)_n kept",
+			&BinaryNode{Op: OpPochhammer, Left: &ConstNode{Val: 3}, Right: &VarNode{}},
+			"poch(3, n)",
+		},
+		{
+			"-7 mod 3 = 2",
+			&BinaryNode{Op: OpMod, Left: &ConstNode{Val: -7}, Right: &ConstNode{Val: 3}},
+			"2",
+		},
+		{
+			"7 mod -3 = 1",
+			&BinaryNode{Op: OpMod, Left: &ConstNode{Val: 7}, Right: &ConstNode{Val: -3}},
+			"1",
+		},
+		{
+			"3 mod 0 kept",
+			&BinaryNode{Op: OpMod, Left: &ConstNode{Val: 3}, Right: &ConstNode{Val: 0}},
+			"(3 mod 0)",
+		},
+		{
+			"n! mod 1 = 0",
+			&BinaryNode{Op: OpMod, Left: &UnaryNode{Op: OpFactorial, Child: &VarNode{}}, Right: &ConstNode{Val: 1}},
+			"0",
+		},
+		{
+			"(n/2) mod 1 kept",
+			&BinaryNode{Op: OpMod, Left: &BinaryNode{Op: OpDiv, Left: &VarNode{}, Right: &ConstNode{Val: 2}}, Right: &ConstNode{Val: 1}},
+			"((n / 2) mod 1)",
+		},
+		{
+			"(n mod 4) mod 4 = n mod 4",
+			&BinaryNode{Op: OpMod, Left: &BinaryNode{Op: OpMod, Left: &VarNode{}, Right: &ConstNode{Val: 4}}, Right: &ConstNode{Val: 4}},
+			"(n mod 4)",
+		},
+		{
+			"arctan(0) = 0",
+			&UnaryNode{Op: OpArctan, Child: &ConstNode{Val: 0}},
+			"0",
+		},
+		{
+			"arcsin(0) = 0",
+			&UnaryNode{Op: OpArcsin, Child: &BinaryNode{Op: OpSub, Left: &ConstNode{Val: 3}, Right: &ConstNode{Val: 3}}},
+			"0",
+		},
+		{
+			"sinh(0) = 0, cosh(0) = 1",
+			&BinaryNode{Op: OpAdd, Left: &UnaryNode{Op: OpSinh, Child: &ConstNode{Val: 0}}, Right: &UnaryNode{Op: OpCosh, Child: &ConstNode{Val: 0}}},
+			"1",
+		},
+		{
+			"tanh(0) = 0",
+			&UnaryNode{Op: OpTanh, Child: &ConstNode{Val: 0}},
+			"0",
+		},
+		{
+			"psi(1) = -gamma",
+			&UnaryNode{Op: OpDigamma, Child: &ConstNode{Val: 1}},
+			"(-gamma)",
+		},
+		{
+			"!5 = 44",
+			&UnaryNode{Op: OpSubfactorial, Child: &ConstNode{Val: 5}},
+			"44",
+		},
+		{
+			"root(-27, 3) = -3",
+			&BinaryNode{Op: OpRoot, Left: &ConstNode{Val: -27}, Right: &ConstNode{Val: 3}},
+			"-3",
+		},
+		{
+			"root(2, 3) kept",
+			&BinaryNode{Op: OpRoot, Left: &ConstNode{Val: 2}, Right: &ConstNode{Val: 3}},
+			"root(2, 3)",
+		},
+		{
+			"root(n, 1) = n",
+			&BinaryNode{Op: OpRoot, Left: &VarNode{}, Right: &ConstNode{Val: 1}},
+			"n",
+		},
+		{
+			"root(n, 2) = sqrt(n)",
+			&BinaryNode{Op: OpRoot, Left: &VarNode{}, Right: &ConstNode{Val: 2}},
+			"sqrt(n)",
+		},
+		{
+			"root(n^6, 3) = n^2",
+			&BinaryNode{Op: OpRoot, Left: &BinaryNode{Op: OpPow, Left: &VarNode{}, Right: &ConstNode{Val: 6}}, Right: &ConstNode{Val: 3}},
+			"(n)^(2)",
+		},
+		{
+			"root(n^6, 2) = |n|^3",
+			&BinaryNode{Op: OpRoot, Left: &BinaryNode{Op: OpPow, Left: &VarNode{}, Right: &ConstNode{Val: 6}}, Right: &ConstNode{Val: 2}},
+			"(abs(n))^(3)",
+		},
+		{
+			"MaxInt64 + 1 overflows, not folded",
+			&BinaryNode{Op: OpAdd, Left: &ConstNode{Val: math.MaxInt64}, Right: &ConstNode{Val: 1}},
+			"(1 + 9223372036854775807)",
+		},
 	}
 
 	for _, tc := range tests {
@@ -378,6 +1199,257 @@ func TestSimplify(t *testing.T) {
 	}
 }
 
+func TestSimplifyAltSign(t *testing.T) {
+	alt := func(e ExprNode) ExprNode { return &UnaryNode{Op: OpAltSign, Child: e} }
+	pow := func(a, b ExprNode) ExprNode { return &BinaryNode{Op: OpPow, Left: a, Right: b} }
+	mul := func(a, b ExprNode) ExprNode { return &BinaryNode{Op: OpMul, Left: a, Right: b} }
+	div := func(a, b ExprNode) ExprNode { return &BinaryNode{Op: OpDiv, Left: a, Right: b} }
+	n := &VarNode{}
+	k := func(v int64) ExprNode { return &ConstNode{Val: v} }
+	nPlus1 := &BinaryNode{Op: OpAdd, Left: n, Right: k(1)}
+	nMinus1 := &BinaryNode{Op: OpSub, Left: n, Right: k(1)}
+
+	tests := []struct {
+		name string
+		node ExprNode
+		want string
+	}{
+		{"(-1)^n", pow(k(-1), n), "(-1)^(n)"},
+		{"(-2)^n", pow(k(-2), n), "((-1)^(n) * (2)^(n))"},
+		{"(-n)^(n+1)", pow(&UnaryNode{Op: OpNeg, Child: n}, nPlus1), "((-1)^((1 + n)) * (n)^((1 + n)))"},
+		{"(-2)^(n-1) kept", pow(k(-2), nMinus1), "(-2)^((n - 1))"},
+		{"n·(-1)^n", mul(n, alt(n)), "((-1)^(n) * n)"},
+		{"nested product", mul(k(3), mul(n, alt(n))), "((-1)^(n) * (3 * n))"},
+		{"sign over denominator", div(mul(alt(n), k(3)), n), "((-1)^(n) * (3 / n))"},
+		{"sign in denominator", div(k(3), mul(alt(n), nPlus1)), "((-1)^(n) * (3 / (1 + n)))"},
+		{"bare sign in denominator", div(n, alt(n)), "((-1)^(n) * n)"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := Simplify(tc.node)
+			if got.String() != tc.want {
+				t.Errorf("Simplify(%s) = %s, want %s", tc.node, got, tc.want)
+			}
+			for i := int64(1); i <= 6; i++ {
+				want, wok := tc.node.Eval(bfInt(i), testPrec)
+				v, ok := got.Eval(bfInt(i), testPrec)
+				if wok != ok || ok && want.Cmp(v) != 0 {
+					t.Errorf("n=%d: %s = %v (%v), simplified %v (%v)", i, tc.node, want, wok, v, ok)
+				}
+			}
+		})
+	}
+}
+
+func TestSimplifyCache(t *testing.T) {
+	ResetSimplifyCache()
+	defer ResetSimplifyCache()
+
+	build := func() ExprNode {
+		return &BinaryNode{Op: OpAdd,
+			Left:  &BinaryNode{Op: OpMul, Left: &VarNode{}, Right: &ConstNode{Val: 1}},
+			Right: &BinaryNode{Op: OpAdd, Left: &ConstNode{Val: 2}, Right: &ConstNode{Val: 3}},
+		}
+	}
+
+	first := SimplifyBigFloat(build(), 128)
+	before := SimplifyCacheStats()
+	second := SimplifyBigFloat(build(), 128)
+	after := SimplifyCacheStats()
+
+	if first.String() != second.String() {
+		t.Fatalf("cached result %s differs from first result %s", second, first)
+	}
+	if after.Hits != before.Hits+1 {
+		t.Errorf("expected one cache hit, got %d -> %d", before.Hits, after.Hits)
+	}
+
+	// Results must be independent copies: mutating one can't poison the cache.
+	if b, ok := second.(*BinaryNode); ok {
+		b.Op = OpSub
+	}
+	third := SimplifyBigFloat(build(), 128)
+	if third.String() != first.String() {
+		t.Errorf("cache returned mutated tree %s, want %s", third, first)
+	}
+
+	// Different fold precision is a distinct cache entry.
+	misses := SimplifyCacheStats().Misses
+	SimplifyBigFloat(build(), 256)
+	if SimplifyCacheStats().Misses == misses {
+		t.Error("expected a miss for a new precision")
+	}
+
+	// Eviction empties the cache but keeps the counters.
+	st := SimplifyCacheStats()
+	if n := EvictSimplifyCache(); n != st.Entries || n == 0 {
+		t.Errorf("EvictSimplifyCache dropped %d entries, want the %d cached", n, st.Entries)
+	}
+	if got := SimplifyCacheStats(); got.Entries != 0 || got.Hits != st.Hits || got.Misses != st.Misses {
+		t.Errorf("after eviction: %+v, want no entries and the counters of %+v", got, st)
+	}
+	SimplifyBigFloat(build(), 128)
+	if SimplifyCacheStats().Misses == st.Misses {
+		t.Error("evicted entry still served")
+	}
+}
+
+func TestSimplifyWithBudget(t *testing.T) {
+	// A balanced sum of 1024 copies of n·1, too big to be deep.
+	var build func(k int) ExprNode
+	build = func(k int) ExprNode {
+		if k == 1 {
+			return &BinaryNode{Op: OpMul, Left: &VarNode{}, Right: &ConstNode{Val: 1}}
+		}
+		return &BinaryNode{Op: OpAdd, Left: build(k / 2), Right: build(k - k/2)}
+	}
+	node := build(1024)
+	n := big.NewFloat(7)
+	want, _ := node.Eval(n, 64)
+
+	out, stats := SimplifyWithBudget(node, SimplifyBudget{})
+	if stats.Truncated || out.NodeCount() != 2047 {
+		t.Fatalf("unbounded: %d nodes, truncated %v", out.NodeCount(), stats.Truncated)
+	}
+	if got := stats.Applied()["mul-one"]; got != 1024 {
+		t.Errorf("mul-one fired %d times, want 1024 (%v)", got, stats.Applied())
+	}
+	if stats.Passes != 2 {
+		t.Errorf("%d passes, want 2 (one to rewrite, one to see nothing changes)", stats.Passes)
+	}
+
+	out, stats = SimplifyWithBudget(node, SimplifyBudget{MaxNodes: 1000})
+	if !stats.Truncated || stats.Passes != 1 {
+		t.Fatalf("budget of 1000: truncated %v after %d passes", stats.Truncated, stats.Passes)
+	}
+	got, _ := out.Eval(n, 64)
+	if got.Cmp(want) != 0 || out.NodeCount() >= node.NodeCount() || out.NodeCount() <= 2047 {
+		t.Errorf("partial result has %d nodes and value %s; want between 2047 and %d, and %s",
+			out.NodeCount(), got, node.NodeCount(), want)
+	}
+
+	if _, stats = SimplifyWithBudget(node, SimplifyBudget{Timeout: time.Nanosecond}); !stats.Truncated {
+		t.Error("a 1ns timeout did not truncate")
+	}
+}
+
+func TestSimplifyMaxGrowth(t *testing.T) {
+	// (-2)^E → (-1)^E · 2^E copies E, here a 41-node polynomial in n.
+	e := ExprNode(&VarNode{})
+	for i := int64(1); i <= 10; i++ {
+		e = &BinaryNode{Op: OpAdd, Left: &BinaryNode{Op: OpMul, Left: e, Right: &VarNode{}}, Right: &ConstNode{Val: i}}
+	}
+	pow := func() ExprNode { return &BinaryNode{Op: OpPow, Left: &ConstNode{Val: -2}, Right: e} }
+	node := &BinaryNode{Op: OpAdd, Left: pow(), Right: pow()}
+	n := big.NewFloat(1)
+	want, ok := node.Eval(n, 256)
+	if !ok {
+		t.Fatal("tree undefined at n = 1")
+	}
+
+	free, stats := SimplifyWithBudget(node, SimplifyBudget{})
+	if free.NodeCount() < 2*node.NodeCount()-10 || stats.Rejected != 0 {
+		t.Fatalf("unbounded: %d nodes from %d, %d rejected", free.NodeCount(), node.NodeCount(), stats.Rejected)
+	}
+
+	for _, growth := range []float64{1, 1.5, 2} {
+		out, stats := SimplifyWithBudget(node, SimplifyBudget{MaxGrowth: growth})
+		got, ok := out.Eval(n, 256)
+		if limit := int(growth * float64(node.NodeCount())); out.NodeCount() > limit || !ok || got.Cmp(want) != 0 {
+			t.Errorf("growth %v: %d nodes (limit %d), value %s, want %s", growth, out.NodeCount(), limit, got, want)
+		}
+		if stats.Truncated || stats.Passes >= maxSimplifyPasses {
+			t.Errorf("growth %v: truncated %v after %d passes", growth, stats.Truncated, stats.Passes)
+		}
+		// One extraction fits in 1.5×, neither in 1×, both in 2×.
+		if wantRejected := map[float64]bool{1: true, 1.5: true, 2: false}[growth]; (stats.Rejected > 0) != wantRejected {
+			t.Errorf("growth %v: %d rejected, want some: %v", growth, stats.Rejected, wantRejected)
+		}
+	}
+
+	// A rejected rule stays rejected: the result is a fixed point.
+	out, _ := SimplifyWithBudget(node, SimplifyBudget{MaxGrowth: 1})
+	if again, stats := SimplifyWithBudget(out, SimplifyBudget{MaxGrowth: 1}); !Equal(again, out) || stats.Passes != 1 {
+		t.Errorf("resimplified in %d passes to %s", stats.Passes, again)
+	}
+}
+
+func TestSimplifyWarnings(t *testing.T) {
+	nMinus1 := &BinaryNode{Op: OpSub, Left: &VarNode{}, Right: &ConstNode{Val: 1}}
+	tests := []struct {
+		node ExprNode
+		want []WarningKind
+	}{
+		{&BinaryNode{Op: OpDiv, Left: nMinus1, Right: nMinus1}, []WarningKind{WarnCancel}},
+		{&BinaryNode{Op: OpMul, Left: &ConstNode{Val: 0}, Right: &UnaryNode{Op: OpLn, Child: nMinus1}}, []WarningKind{WarnCancel}},
+		{&BinaryNode{Op: OpDiv, Left: &ConstNode{Val: 4}, Right: &ConstNode{Val: 4}}, nil}, // no n: nothing can vanish
+		{&BinaryNode{Op: OpAdd, Left: &VarNode{}, Right: &ConstNode{Val: 1}}, nil},
+	}
+	for _, tt := range tests {
+		want := Simplify(tt.node)
+		got, warnings := SimplifyWarnings(tt.node)
+		if !Equal(got, want) {
+			t.Errorf("SimplifyWarnings(%s) = %s, Simplify gives %s", tt.node, got, want)
+		}
+		if len(warnings) != len(tt.want) {
+			t.Errorf("SimplifyWarnings(%s) warnings = %v, want %v", tt.node, warnings, tt.want)
+			continue
+		}
+		for i, w := range warnings {
+			if w.Kind != tt.want[i] || w.Pos != -1 {
+				t.Errorf("SimplifyWarnings(%s)[%d] = %v, want %s", tt.node, i, w, tt.want[i])
+			}
+		}
+	}
+
+	// √2 + n: the constant subtree is rounded to 1.
+	root2 := &UnaryNode{Op: OpSqrt, Child: &ConstNode{Val: 2}}
+	out, warnings := SimplifyBigFloatWarnings(&BinaryNode{Op: OpAdd, Left: root2, Right: &VarNode{}}, 128)
+	if len(warnings) != 1 || warnings[0].Kind != WarnLossyFold {
+		t.Errorf("SimplifyBigFloatWarnings(√2 + n) warnings = %v, want one lossy fold", warnings)
+	}
+	if want := SimplifyBigFloat(&BinaryNode{Op: OpAdd, Left: root2, Right: &VarNode{}}, 128); !Equal(out, want) {
+		t.Errorf("SimplifyBigFloatWarnings(√2 + n) = %s, SimplifyBigFloat gives %s", out, want)
+	}
+	// 1/3 + 1 + n: an exact rational folds to 4/3, without a warning.
+	third := &BinaryNode{Op: OpAdd, Left: &BinaryNode{Op: OpDiv, Left: &ConstNode{Val: 1}, Right: &ConstNode{Val: 3}}, Right: &ConstNode{Val: 1}}
+	out, warnings = SimplifyBigFloatWarnings(&BinaryNode{Op: OpAdd, Left: third, Right: &VarNode{}}, 128)
+	if len(warnings) != 0 || out.String() != "((4 / 3) + n)" {
+		t.Errorf("SimplifyBigFloatWarnings(1/3 + 1 + n) = %s, %v; want (4/3 + n)", out, warnings)
+	}
+}
+
+func TestHashEqual(t *testing.T) {
+	a := &BinaryNode{Op: OpDiv, Left: &VarNode{}, Right: &UnaryNode{Op: OpFactorial, Child: &VarNode{}}}
+	b := a.Clone()
+	if Hash(a) != Hash(b) || !Equal(a, b) {
+		t.Error("clone should hash and compare equal")
+	}
+	c := &BinaryNode{Op: OpDiv, Left: &VarNode{}, Right: &UnaryNode{Op: OpDoubleFactorial, Child: &VarNode{}}}
+	if Hash(a) == Hash(c) || Equal(a, c) {
+		t.Error("different ops should hash and compare differently")
+	}
+	if Equal(&ConstNode{Val: 1}, &ConstNode{Val: 2}) {
+		t.Error("different constants should not be equal")
+	}
+}
+
+func TestStableHash(t *testing.T) {
+	// n / (n + 3)!. Canonical keys persisted by earlier runs depend on this
+	// value: if it changes, StableHash has changed format.
+	a := &BinaryNode{Op: OpDiv, Left: &VarNode{}, Right: &UnaryNode{Op: OpFactorial, Child: &BinaryNode{Op: OpAdd, Left: &VarNode{}, Right: &ConstNode{Val: 3}}}}
+	if got := StableHash(a); got != 0xbca55cd7c3ef2480 {
+		t.Errorf("StableHash = %#x, want 0xbca55cd7c3ef2480", got)
+	}
+	if StableHash(a) != StableHash(a.Clone()) {
+		t.Error("clone should hash equally")
+	}
+	c := &BinaryNode{Op: OpDiv, Left: &VarNode{}, Right: &UnaryNode{Op: OpDoubleFactorial, Child: a.Right.(*UnaryNode).Child}}
+	if StableHash(a) == StableHash(c) {
+		t.Error("different ops should hash differently")
+	}
+}
+
 func TestFloorCeil(t *testing.T) {
 	// floor(3.7) = 3
 	node := &UnaryNode{Op: OpFloor, Child: &BinaryNode{
@@ -0,0 +1,143 @@
+package expr
+
+import "math/big"
+
+// FaultKind is the domain error behind a Fault.
+type FaultKind int
+
+const (
+	FaultZeroDivisor FaultKind = iota + 1 // a divisor, or the base of a negative power, is 0
+	FaultNegativeArg                      // a factorial or double factorial argument is a negative integer
+)
+
+func (k FaultKind) String() string {
+	switch k {
+	case FaultZeroDivisor:
+		return "zero divisor"
+	case FaultNegativeArg:
+		return "negative factorial argument"
+	}
+	return "no fault"
+}
+
+// Fault is the subtree that leaves a tree undefined at some n: the zero
+// divisor (its zero factor, if it is a product) or the negative factorial
+// argument, by preorder index, with its integer value there.
+type Fault struct {
+	Kind  FaultKind
+	Index int
+	Value int64
+}
+
+// FindFault evaluates node at n as Eval does and reports the innermost
+// division by zero or factorial of a negative integer that makes it
+// undefined. Other failures (ln of a non-positive, inputs past the
+// factorial cap, ...) are not faults: it returns false for them, as for a
+// defined value.
+func FindFault(node ExprNode, n int64, prec uint) (Fault, bool) {
+	nf := new(big.Float).SetPrec(prec).SetInt64(n)
+	v, f := findFault(node, nf, prec, 0)
+	ReleaseFloat(v)
+	return f, f.Kind != 0
+}
+
+// ZeroDivisorFault returns the fault of dividing by node at n, if node is
+// zero there.
+func ZeroDivisorFault(node ExprNode, n int64, prec uint) (Fault, bool) {
+	nf := new(big.Float).SetPrec(prec).SetInt64(n)
+	v, ok := node.Eval(nf, prec)
+	zero := ok && v.Sign() == 0
+	ReleaseFloat(v)
+	if !zero {
+		return Fault{}, false
+	}
+	return Fault{Kind: FaultZeroDivisor, Index: zeroFactor(node, nf, prec)}, true
+}
+
+// zeroFactor returns the preorder index, relative to node, of the
+// innermost factor that makes node zero at n: through products, negations,
+// absolute values and positive powers, down to the first zero operand.
+func zeroFactor(node ExprNode, n *big.Float, prec uint) int {
+	isZero := func(x ExprNode) bool {
+		v, ok := x.Eval(n, prec)
+		defer ReleaseFloat(v)
+		return ok && v.Sign() == 0
+	}
+	switch nd := node.(type) {
+	case *UnaryNode:
+		if nd.Op == OpNeg || nd.Op == OpAbs {
+			return 1 + zeroFactor(nd.Child, n, prec)
+		}
+	case *BinaryNode:
+		switch {
+		case (nd.Op == OpMul || nd.Op == OpPow || nd.Op == OpDiv) && isZero(nd.Left):
+			return 1 + zeroFactor(nd.Left, n, prec)
+		case nd.Op == OpMul && isZero(nd.Right):
+			return 1 + nd.Left.NodeCount() + zeroFactor(nd.Right, n, prec)
+		}
+	}
+	return 0
+}
+
+// findFault returns node's value at n, or nil with the fault if one is
+// found under node (at preorder index idx).
+func findFault(node ExprNode, n *big.Float, prec uint, idx int) (*big.Float, Fault) {
+	switch nd := node.(type) {
+	case *UnaryNode:
+		child, f := findFault(nd.Child, n, prec, idx+1)
+		if child == nil {
+			return nil, f
+		}
+		if nd.Op == OpFactorial || nd.Op == OpDoubleFactorial || nd.Op == OpSubfactorial {
+			if iv, ok := toInt64(child); ok && iv < 0 {
+				ReleaseFloat(child)
+				return nil, Fault{Kind: FaultNegativeArg, Index: idx + 1, Value: iv}
+			}
+		}
+		result, ok := nd.apply(child, prec)
+		if result != child {
+			ReleaseFloat(child)
+		}
+		if !ok {
+			return nil, Fault{}
+		}
+		return result, Fault{}
+
+	case *BinaryNode:
+		ri := idx + 1 + nd.Left.NodeCount()
+		left, f := findFault(nd.Left, n, prec, idx+1)
+		if left == nil {
+			return nil, f
+		}
+		right, f := findFault(nd.Right, n, prec, ri)
+		if right == nil {
+			ReleaseFloat(left)
+			return nil, f
+		}
+		switch {
+		case nd.Op == OpDiv && right.Sign() == 0:
+			f = Fault{Kind: FaultZeroDivisor, Index: ri + zeroFactor(nd.Right, n, prec)}
+		case nd.Op == OpPow && left.Sign() == 0 && right.Sign() < 0:
+			f = Fault{Kind: FaultZeroDivisor, Index: idx + 1 + zeroFactor(nd.Left, n, prec)}
+		}
+		if f.Kind != 0 {
+			ReleaseFloat(left)
+			ReleaseFloat(right)
+			return nil, f
+		}
+		result, ok := nd.apply(left, right, prec)
+		if result != left {
+			ReleaseFloat(left)
+		}
+		ReleaseFloat(right)
+		if !ok {
+			return nil, Fault{}
+		}
+		return result, Fault{}
+	}
+	v, ok := node.Eval(n, prec)
+	if !ok {
+		return nil, Fault{}
+	}
+	return v, Fault{}
+}
@@ -0,0 +1,78 @@
+package expr
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Function is what a function name parses to: it builds the node for the
+// function applied to arg. UnaryFunc gives the Function of an existing
+// unary op; any other Function expands the name into an expression over
+// the existing ops, which is how a function with a closed form in them
+// (say \operatorname{sech}) is added without a new op.
+type Function func(arg ExprNode) (ExprNode, error)
+
+// UnaryFunc returns the Function that applies op to its argument.
+func UnaryFunc(op UnaryOp) Function {
+	return func(arg ExprNode) (ExprNode, error) {
+		return &UnaryNode{Op: op, Child: arg}, nil
+	}
+}
+
+// functions maps function names to what they parse to, both as
+// \operatorname{NAME}(x) and as \NAME(x).
+var functions = map[string]Function{
+	"sin":       UnaryFunc(OpSin),
+	"cos":       UnaryFunc(OpCos),
+	"tan":       UnaryFunc(OpTan),
+	"exp":       UnaryFunc(OpExp),
+	"Gamma":     UnaryFunc(OpGamma),
+	"ln":        UnaryFunc(OpLn),
+	"sqrt":      UnaryFunc(OpSqrt),
+	"abs":       UnaryFunc(OpAbs),
+	"floor":     UnaryFunc(OpFloor),
+	"ceil":      UnaryFunc(OpCeil),
+	"fib":       UnaryFunc(OpFibonacci),
+	"bernoulli": UnaryFunc(OpBernoulli),
+	"prime":     UnaryFunc(OpPrime),
+	"arctan":    UnaryFunc(OpArctan),
+	"arcsin":    UnaryFunc(OpArcsin),
+	"sinh":      UnaryFunc(OpSinh),
+	"cosh":      UnaryFunc(OpCosh),
+	"tanh":      UnaryFunc(OpTanh),
+	"psi":       UnaryFunc(OpDigamma),
+	"D":         UnaryFunc(OpSubfactorial),
+}
+
+// RegisterFunction makes the LaTeX parser accept \operatorname{name}(x)
+// and \name(x) as f(x). A name registered again replaces the earlier
+// Function. Like pool.Register it is meant for init time, before any
+// parsing.
+func RegisterFunction(name string, f Function) {
+	functions[name] = f
+}
+
+// LookupFunction returns the Function registered under name.
+func LookupFunction(name string) (Function, bool) {
+	f, ok := functions[name]
+	return f, ok
+}
+
+// FunctionNames returns the registered function names, sorted.
+func FunctionNames() []string {
+	names := make([]string, 0, len(functions))
+	for name := range functions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// applyFunction applies the Function registered under name to arg.
+func applyFunction(name string, arg ExprNode) (ExprNode, error) {
+	f, ok := functions[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown function %s (registered: %v)", name, FunctionNames())
+	}
+	return f(arg)
+}
@@ -0,0 +1,235 @@
+package expr
+
+import (
+	"math"
+	"math/big"
+	"sync"
+)
+
+// bigGamma computes Γ(x) at prec. Integers are exact factorials,
+// Γ(k) = (k-1)!, and half-integers m+1/2 exact multiples of √π; any other
+// x is shifted up to x ≥ 1 by Γ(x) = Γ(x+1)/x and summed by Spouge's
+// approximation. Γ is undefined at 0 and the negative integers, and, like
+// the factorial, past maxComputeInput.
+func bigGamma(x *big.Float, prec uint) (*big.Float, bool) {
+	if x.IsInf() || x.Cmp(gammaMax) > 0 || x.Cmp(gammaMin) < 0 {
+		return nil, false
+	}
+	if iv, ok := toInt64(x); ok {
+		if iv <= 0 {
+			return nil, false
+		}
+		v, _ := factorialInt(iv - 1)
+		return newFloat(prec).SetInt(v), true
+	}
+	twice := new(big.Float).SetMantExp(x, 1)
+	if m2, ok := toInt64(twice); ok {
+		return halfIntegerGamma((m2-1)/2, m2 < 0, prec)
+	}
+
+	wp := spougePrec(prec)
+	z := new(big.Float).SetPrec(wp).Set(x)
+	div := new(big.Float).SetPrec(wp).SetInt64(1)
+	for z.Cmp(bigOne) < 0 {
+		div.Mul(div, z)
+		z.Add(z, bigOne)
+	}
+	z.Sub(z, bigOne)
+	g := spouge(z, prec)
+	g.Quo(g, div)
+	return newFloat(prec).Set(g), true
+}
+
+var (
+	gammaMax = new(big.Float).SetInt64(maxComputeInput + 1)
+	gammaMin = new(big.Float).SetInt64(-maxComputeInput)
+)
+
+// halfIntegerGamma is Γ(m+1/2) = (2m)!/(4^m·m!)·√π for m ≥ 0 and, with
+// j = -m (negative set), Γ(1/2-j) = (-4)^j·j!/(2j)!·√π.
+func halfIntegerGamma(m int64, negative bool, prec uint) (*big.Float, bool) {
+	if negative {
+		m = -m
+	}
+	f2m, ok := factorialInt(2 * m)
+	if !ok {
+		return nil, false
+	}
+	fm, _ := factorialInt(m)
+	r := new(big.Rat).SetFrac(f2m, new(big.Int).Lsh(fm, uint(2*m)))
+	if negative {
+		r.Inv(r)
+		if m%2 == 1 {
+			r.Neg(r)
+		}
+	}
+	v := newFloat(prec + 32).SetRat(r)
+	v.Mul(v, bigSqrt(bigPi(prec+32), prec+32))
+	return v.SetPrec(prec), true
+}
+
+// spougePrec is the working precision for Γ at prec: Spouge's
+// coefficients alternate in sign and grow large, and their sum cancels a
+// growing share of the bits (about prec/8 at 2048).
+func spougePrec(prec uint) uint { return prec + prec/2 + 64 }
+
+// spouge computes Γ(z+1) for z ≥ 0 to prec bits, working at
+// spougePrec(prec):
+//
+//	Γ(z+1) = (z+a)^(z+1/2) e^-(z+a) [c0 + Σ_{k=1}^{a-1} c_k/(z+k)]
+//
+// with the coefficients of spougeCoeffs.
+func spouge(z *big.Float, prec uint) *big.Float {
+	wp := spougePrec(prec)
+	c := spougeCoeffs(prec)
+	sum := new(big.Float).SetPrec(wp).Set(c[0])
+	t := new(big.Float).SetPrec(wp)
+	zk := new(big.Float).SetPrec(wp)
+	for k := 1; k < len(c); k++ {
+		zk.SetInt64(int64(k))
+		zk.Add(zk, z)
+		sum.Add(sum, t.Quo(c[k], zk))
+	}
+
+	// (z+a)^(z+1/2) e^-(z+a) = exp((z+1/2)·ln(z+a) - (z+a))
+	za := new(big.Float).SetPrec(wp).SetInt64(int64(len(c)))
+	za.Add(za, z)
+	e := bigLn(za, wp)
+	t.SetFloat64(0.5)
+	t.Add(t, z)
+	e.Mul(e, t)
+	e.Sub(e, za)
+	return sum.Mul(sum, bigExp(e, wp))
+}
+
+// spougeCache holds Spouge's coefficients per precision.
+var spougeCache struct {
+	mu     sync.Mutex
+	coeffs map[uint][]*big.Float
+}
+
+// spougeCoeffs returns Spouge's coefficients for Γ at prec, a of them at
+// spougePrec(prec) bits, with a chosen so that the relative error, below
+// a^-1/2·(2π)^-(a+1/2), is under 2^-(prec+16):
+//
+//	c0 = √(2π),  c_k = (-1)^(k-1)/(k-1)! · (a-k)^(k-1/2) · e^(a-k)
+//
+// They are computed once per precision and must not be modified.
+func spougeCoeffs(prec uint) []*big.Float {
+	spougeCache.mu.Lock()
+	defer spougeCache.mu.Unlock()
+	if c, ok := spougeCache.coeffs[prec]; ok {
+		return c
+	}
+
+	wp := spougePrec(prec)
+	a := int64(math.Ceil(float64(prec+16)/math.Log2(2*math.Pi))) + 1
+	c := make([]*big.Float, a)
+	c[0] = new(big.Float).SetPrec(wp).SetMantExp(bigPi(wp), 1)
+	c[0] = bigSqrt(c[0], wp)
+	e := bigExp(new(big.Float).SetPrec(wp).SetInt64(1), wp)
+	fact := new(big.Float).SetPrec(wp).SetInt64(1) // (k-1)!
+	for k := int64(1); k < a; k++ {
+		if k > 1 {
+			fact.Mul(fact, new(big.Float).SetInt64(k-1))
+		}
+		ak := new(big.Float).SetPrec(wp).SetInt64(a - k)
+		v, _ := intPow(ak, k-1, wp)
+		v.Mul(v, bigSqrt(ak, wp))
+		p, _ := intPow(e, a-k, wp)
+		v.Mul(v, p)
+		v.Quo(v, fact)
+		if k%2 == 0 {
+			v.Neg(v)
+		}
+		c[k] = new(big.Float).Copy(v)
+	}
+	if spougeCache.coeffs == nil {
+		spougeCache.coeffs = map[uint][]*big.Float{}
+	}
+	spougeCache.coeffs[prec] = c
+	return c
+}
+
+// bigExp computes e^x at prec: the Taylor series of x/2^s, small enough
+// to converge in a few dozen terms, squared s times.
+func bigExp(x *big.Float, prec uint) *big.Float {
+	s := 0
+	if x.Sign() != 0 {
+		s = max(0, x.MantExp(nil)+int(math.Sqrt(float64(prec))))
+	}
+	wp := prec + uint(s) + 32
+	r := new(big.Float).SetPrec(wp).SetMantExp(x, -s)
+	sum := new(big.Float).SetPrec(wp).SetInt64(1)
+	term := new(big.Float).SetPrec(wp).SetInt64(1)
+	k := new(big.Float).SetPrec(wp)
+	for i := int64(1); ; i++ {
+		term.Mul(term, r)
+		term.Quo(term, k.SetInt64(i))
+		if term.Sign() == 0 || term.MantExp(nil) < sum.MantExp(nil)-int(wp) {
+			break
+		}
+		sum.Add(sum, term)
+	}
+	for ; s > 0; s-- {
+		sum.Mul(sum, sum)
+	}
+	return sum.SetPrec(prec)
+}
+
+// bigLn computes ln x, x > 0, at prec by Newton's method on bigExp,
+// y ← y + 2(x - e^y)/(x + e^y), which triples the correct bits of the
+// float64 logarithm it starts from each step.
+func bigLn(x *big.Float, prec uint) *big.Float {
+	mant := new(big.Float)
+	exp := x.MantExp(mant)
+	m, _ := mant.Float64()
+	y := new(big.Float).SetPrec(prec).SetFloat64(math.Log(m) + float64(exp)*math.Ln2)
+	num := new(big.Float).SetPrec(prec)
+	den := new(big.Float).SetPrec(prec)
+	for bits := uint(3 * 48); ; bits *= 3 {
+		ey := bigExp(y, prec)
+		num.Sub(x, ey)
+		den.Add(x, ey)
+		num.Quo(num, den)
+		y.Add(y, num.SetMantExp(num, 1))
+		if bits >= prec {
+			return y
+		}
+	}
+}
+
+// piCache holds π per precision.
+var piCache sync.Map // uint → *big.Float
+
+// bigPi computes π at prec by the Gauss–Legendre iteration, which doubles
+// the correct digits each step. The result must not be modified.
+func bigPi(prec uint) *big.Float {
+	if v, ok := piCache.Load(prec); ok {
+		return v.(*big.Float)
+	}
+	wp := prec + 32
+	a := new(big.Float).SetPrec(wp).SetInt64(1)
+	b := new(big.Float).SetPrec(wp).SetFloat64(0.5)
+	b = bigSqrt(b, wp)
+	t := new(big.Float).SetPrec(wp).SetFloat64(0.25)
+	an := new(big.Float).SetPrec(wp)
+	d := new(big.Float).SetPrec(wp)
+	// a ← (a+b)/2, b ← √(ab), t ← t - 2^k·(a-a')², to twice wp bits
+	for k, bits := 0, uint(1); bits < 2*wp; k, bits = k+1, bits*2 {
+		an.Add(a, b)
+		an.SetMantExp(an, -1)
+		b.Mul(a, b)
+		b = bigSqrt(b, wp)
+		d.Sub(a, an)
+		d.Mul(d, d)
+		t.Sub(t, d.SetMantExp(d, k))
+		a.Set(an)
+	}
+	pi := new(big.Float).SetPrec(wp).Add(a, b)
+	pi.Mul(pi, pi)
+	pi.Quo(pi, t.SetMantExp(t, 2))
+	pi.SetPrec(prec)
+	piCache.Store(prec, pi)
+	return pi
+}
@@ -0,0 +1,163 @@
+package expr
+
+// FNV-1a 64-bit parameters.
+const (
+	fnvOffset64 = 14695981039346656037
+	fnvPrime64  = 1099511628211
+)
+
+// Node kind tags mixed into the hash so that, e.g., ConstNode{1} and
+// UnaryNode{OpFactorial, ...} can never collide on a shared prefix.
+const (
+	hashTagVar byte = iota + 1
+	hashTagConst
+	hashTagUnary
+	hashTagBinary
+	hashTagSymbol
+)
+
+// Hash returns a structural hash of the expression tree. Structurally equal
+// trees always hash equally; distinct trees collide only with FNV-1a
+// probability, so callers that need certainty should confirm with Equal.
+func Hash(node ExprNode) uint64 {
+	return hashD(node, fnvOffset64)
+}
+
+func hashByte(h uint64, b byte) uint64 {
+	h ^= uint64(b)
+	h *= fnvPrime64
+	return h
+}
+
+func hashInt64(h uint64, v int64) uint64 {
+	u := uint64(v)
+	for i := 0; i < 8; i++ {
+		h = hashByte(h, byte(u))
+		u >>= 8
+	}
+	return h
+}
+
+func hashD(node ExprNode, h uint64) uint64 {
+	switch n := node.(type) {
+	case *VarNode:
+		return hashByte(h, hashTagVar)
+	case *ConstNode:
+		h = hashByte(h, hashTagConst)
+		return hashInt64(h, n.Val)
+	case *SymbolicConstNode:
+		h = hashByte(h, hashTagSymbol)
+		return hashInt64(h, int64(n.Sym))
+	case *UnaryNode:
+		h = hashByte(h, hashTagUnary)
+		h = hashInt64(h, int64(n.Op))
+		return hashD(n.Child, h)
+	case *BinaryNode:
+		h = hashByte(h, hashTagBinary)
+		h = hashInt64(h, int64(n.Op))
+		h = hashD(n.Left, h)
+		return hashD(n.Right, h)
+	default:
+		return h
+	}
+}
+
+// Equal reports whether two expression trees are structurally identical.
+func Equal(a, b ExprNode) bool {
+	switch x := a.(type) {
+	case *VarNode:
+		_, ok := b.(*VarNode)
+		return ok
+	case *ConstNode:
+		y, ok := b.(*ConstNode)
+		return ok && x.Val == y.Val
+	case *SymbolicConstNode:
+		y, ok := b.(*SymbolicConstNode)
+		return ok && x.Sym == y.Sym
+	case *UnaryNode:
+		y, ok := b.(*UnaryNode)
+		return ok && x.Op == y.Op && Equal(x.Child, y.Child)
+	case *BinaryNode:
+		y, ok := b.(*BinaryNode)
+		return ok && x.Op == y.Op && Equal(x.Left, y.Left) && Equal(x.Right, y.Right)
+	default:
+		return false
+	}
+}
+
+// ShapeFeatures appends to dst the hash of every subtree of node with all
+// constant values erased, so 1/(2n+1) and 1/(2n+3) share every feature
+// (and π/n and e/n do).
+// The multiset of features is a cheap fingerprint of a tree's structure:
+// trees that differ by one local edit share most of it.
+func ShapeFeatures(dst []uint64, node ExprNode) []uint64 {
+	dst, _ = shapeD(dst, node)
+	return dst
+}
+
+func shapeD(dst []uint64, node ExprNode) ([]uint64, uint64) {
+	h := uint64(fnvOffset64)
+	switch n := node.(type) {
+	case *VarNode:
+		h = hashByte(h, hashTagVar)
+	case *ConstNode:
+		h = hashByte(h, hashTagConst)
+	case *SymbolicConstNode:
+		h = hashByte(h, hashTagSymbol)
+	case *UnaryNode:
+		var c uint64
+		dst, c = shapeD(dst, n.Child)
+		h = hashByte(h, hashTagUnary)
+		h = hashInt64(h, int64(n.Op))
+		h = hashInt64(h, int64(c))
+	case *BinaryNode:
+		var l, r uint64
+		dst, l = shapeD(dst, n.Left)
+		dst, r = shapeD(dst, n.Right)
+		h = hashByte(h, hashTagBinary)
+		h = hashInt64(h, int64(n.Op))
+		h = hashInt64(h, int64(l))
+		h = hashInt64(h, int64(r))
+	}
+	return append(dst, h), h
+}
+
+// StableHash is a structural hash like Hash whose values are part of the
+// persisted format: it hashes op identifiers (see OpIDs) instead of op
+// numbers, so adding or reordering ops leaves it unchanged. Canonical keys
+// order commutative operands by it, so keys saved by one release match the
+// next's; changing how a tree hashes here breaks that.
+func StableHash(node ExprNode) uint64 {
+	return stableHashD(node, fnvOffset64)
+}
+
+func hashString(h uint64, s string) uint64 {
+	for i := 0; i < len(s); i++ {
+		h = hashByte(h, s[i])
+	}
+	return hashByte(h, 0)
+}
+
+func stableHashD(node ExprNode, h uint64) uint64 {
+	switch n := node.(type) {
+	case *VarNode:
+		return hashByte(h, hashTagVar)
+	case *ConstNode:
+		h = hashByte(h, hashTagConst)
+		return hashInt64(h, n.Val)
+	case *SymbolicConstNode:
+		h = hashByte(h, hashTagSymbol)
+		return hashString(h, n.Sym.String())
+	case *UnaryNode:
+		h = hashByte(h, hashTagUnary)
+		h = hashString(h, unaryIDOf[n.Op])
+		return stableHashD(n.Child, h)
+	case *BinaryNode:
+		h = hashByte(h, hashTagBinary)
+		h = hashString(h, binaryIDOf[n.Op])
+		h = stableHashD(n.Left, h)
+		return stableHashD(n.Right, h)
+	default:
+		return h
+	}
+}
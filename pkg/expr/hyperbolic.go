@@ -0,0 +1,67 @@
+package expr
+
+import "math/big"
+
+// hyperbolicWorkPrec is the working precision for e^x - e^-x at x: the
+// difference loses the bits by which |x| is below 1.
+func hyperbolicWorkPrec(x *big.Float, prec uint) uint {
+	return prec + uint(max(0, -x.MantExp(nil))) + 32
+}
+
+// bigSinh computes sinh x = (e^x - e^-x)/2 at prec. Below 2^-prec it is x
+// to prec bits, since the next term, x³/6, is that much smaller again.
+func bigSinh(x *big.Float, prec uint) (*big.Float, bool) {
+	if x.IsInf() {
+		return nil, false
+	}
+	if x.Sign() == 0 || -x.MantExp(nil) > int(prec) {
+		return newFloat(prec).Set(x), true
+	}
+	wp := hyperbolicWorkPrec(x, prec)
+	e := bigExp(x, wp)
+	r := new(big.Float).SetPrec(wp).Quo(new(big.Float).SetPrec(wp).SetInt64(1), e)
+	r.Sub(e, r)
+	if r.IsInf() {
+		return nil, false
+	}
+	return newFloat(prec).SetMantExp(r, -1), true
+}
+
+// bigCosh computes cosh x = (e^x + e^-x)/2 at prec. It fails, like
+// bigSinh, once e^|x| is past big.Float's exponent range.
+func bigCosh(x *big.Float, prec uint) (*big.Float, bool) {
+	if x.IsInf() {
+		return nil, false
+	}
+	wp := prec + 32
+	e := bigExp(x, wp)
+	r := new(big.Float).SetPrec(wp).Quo(new(big.Float).SetPrec(wp).SetInt64(1), e)
+	r.Add(e, r)
+	if r.IsInf() {
+		return nil, false
+	}
+	return newFloat(prec).SetMantExp(r, -1), true
+}
+
+// bigTanh computes tanh x at prec as ±(1 - t)/(1 + t) with t = e^{-2|x|},
+// which stays in range for large |x|, where it rounds to ±1.
+func bigTanh(x *big.Float, prec uint) (*big.Float, bool) {
+	if x.IsInf() {
+		return nil, false
+	}
+	if x.Sign() == 0 || -x.MantExp(nil) > int(prec) {
+		return newFloat(prec).Set(x), true
+	}
+	wp := hyperbolicWorkPrec(x, prec)
+	a := new(big.Float).SetPrec(wp).Abs(x)
+	t := bigExp(a.Neg(a.SetMantExp(a, 1)), wp)
+	num := new(big.Float).SetPrec(wp).SetInt64(1)
+	den := new(big.Float).SetPrec(wp).SetInt64(1)
+	num.Sub(num, t)
+	den.Add(den, t)
+	r := newFloat(prec).Quo(num, den)
+	if x.Sign() < 0 {
+		r.Neg(r)
+	}
+	return r, true
+}
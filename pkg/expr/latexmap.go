@@ -0,0 +1,72 @@
+package expr
+
+import "strings"
+
+// Span is the byte range [Start, End) of a node's rendering in a LaTeX
+// string.
+type Span struct {
+	Start, End int
+}
+
+// LaTeXMap renders node exactly as LaTeX does and also returns where each
+// node landed: spans[i] is the range of the i-th node in preorder (the
+// indexing of NodeAt, ReplaceAt and Diff), so a UI can highlight the
+// subtree a mutation changed or the node an evaluation failed at.
+func LaTeXMap(node ExprNode) (string, []Span) {
+	var b strings.Builder
+	spans := make([]Span, 0, node.NodeCount())
+	latexMap(node, &b, &spans)
+	return b.String(), spans
+}
+
+// LaTeXMapAt is LaTeXMap for node rendered after offset bytes of other
+// text: the returned spans are shifted by offset.
+func LaTeXMapAt(node ExprNode, offset int) (string, []Span) {
+	s, spans := LaTeXMap(node)
+	for i := range spans {
+		spans[i].Start += offset
+		spans[i].End += offset
+	}
+	return s, spans
+}
+
+func latexMap(node ExprNode, b *strings.Builder, spans *[]Span) {
+	i := len(*spans)
+	*spans = append(*spans, Span{Start: b.Len()})
+	switch n := node.(type) {
+	case *UnaryNode:
+		t := unaryLaTeX[n.Op]
+		b.WriteString(t[0])
+		latexMap(n.Child, b, spans)
+		b.WriteString(t[1])
+	case *BinaryNode:
+		t, ok := binaryLaTeX[n.Op]
+		if !ok {
+			// Rendered as nothing: the children get empty spans here.
+			for k := n.NodeCount() - 1; k > 0; k-- {
+				*spans = append(*spans, Span{Start: b.Len(), End: b.Len()})
+			}
+			break
+		}
+		if rightFirst[n.Op] {
+			// The right child is written first but indexed after the
+			// left, so its spans are held back until the left's are in.
+			var right []Span
+			b.WriteString(t[0])
+			latexMap(n.Right, b, &right)
+			b.WriteString(t[1])
+			latexMap(n.Left, b, spans)
+			b.WriteString(t[2])
+			*spans = append(*spans, right...)
+			break
+		}
+		b.WriteString(t[0])
+		latexMap(n.Left, b, spans)
+		b.WriteString(t[1])
+		latexMap(n.Right, b, spans)
+		b.WriteString(t[2])
+	default:
+		b.WriteString(node.LaTeX())
+	}
+	(*spans)[i].End = b.Len()
+}
@@ -0,0 +1,35 @@
+package expr
+
+import "testing"
+
+func TestLaTeXMap(t *testing.T) {
+	n := &VarNode{}
+	c := func(v int64) ExprNode { return &ConstNode{Val: v} }
+	var all ExprNode = n
+	for op := range unaryLaTeX {
+		all = &BinaryNode{Op: OpAdd, Left: &UnaryNode{Op: op, Child: n}, Right: all}
+	}
+	for op := range binaryLaTeX {
+		all = &BinaryNode{Op: op, Left: all, Right: c(int64(op) + 2)}
+	}
+
+	for _, tree := range []ExprNode{n, c(-7), deepTree(5), all} {
+		s, spans := LaTeXMap(tree)
+		if s != tree.LaTeX() {
+			t.Fatalf("LaTeXMap = %q, LaTeX = %q", s, tree.LaTeX())
+		}
+		if len(spans) != tree.NodeCount() {
+			t.Fatalf("%d spans for %d nodes", len(spans), tree.NodeCount())
+		}
+		for i, sp := range spans {
+			if got, want := s[sp.Start:sp.End], NodeAt(tree, i).LaTeX(); got != want {
+				t.Errorf("node %d spans %q, renders %q", i, got, want)
+			}
+		}
+	}
+
+	_, shifted := LaTeXMapAt(n, 10)
+	if shifted[0] != (Span{10, 11}) {
+		t.Errorf("LaTeXMapAt span = %+v", shifted[0])
+	}
+}
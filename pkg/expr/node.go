@@ -1,3 +1,9 @@
+// Package expr is the expression language of candidate series: trees of
+// the variable n, integer and symbolic constants and unary and binary ops,
+// with exact and arbitrary-precision evaluation, simplification, hashing,
+// a compact bytecode, and LaTeX and Typst parsing and printing. Ops are
+// identified in configs by the stable names of OpIDs; RegisterOp adds new
+// ones.
 package expr
 
 import "math/big"
@@ -29,6 +35,18 @@ const (
 	OpCeil
 	OpAbs
 	OpSqrt
+	OpTan
+	OpExp
+	OpGamma
+	OpBernoulli
+	OpPrime // p_k, the k-th prime
+	OpArctan
+	OpArcsin
+	OpSinh
+	OpCosh
+	OpTanh
+	OpDigamma      // ψ(x) = Γ'(x)/Γ(x)
+	OpSubfactorial // !n, the derangements of n things
 )
 
 // BinaryOp identifies a binary operation.
@@ -40,7 +58,10 @@ const (
 	OpMul
 	OpDiv
 	OpPow
-	OpBinomial // C(a, b)
+	OpBinomial   // C(a, b)
+	OpPochhammer // (a)_k, the rising factorial a(a+1)...(a+k-1)
+	OpMod        // a mod b, Euclidean: in [0, |b|)
+	OpRoot       // root(a, k), the real k-th root of a
 )
 
 // VarNode represents the variable n.
@@ -51,6 +72,12 @@ type ConstNode struct {
 	Val int64
 }
 
+// SymbolicConstNode represents a named irrational constant, π, e, φ or γ,
+// evaluated from pkg/constants at the requested precision.
+type SymbolicConstNode struct {
+	Sym Symbol
+}
+
 // UnaryNode applies a unary operation to a child expression.
 type UnaryNode struct {
 	Op    UnaryOp
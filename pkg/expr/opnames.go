@@ -0,0 +1,94 @@
+package expr
+
+import "sort"
+
+// Op identifiers are the stable plain-text names used in config files and
+// op whitelists. Unlike the display symbols in print.go they are unique
+// across unary and binary ops ("neg" vs "sub").
+var unaryOpIDs = map[string]UnaryOp{
+	"neg":             OpNeg,
+	"factorial":       OpFactorial,
+	"altsign":         OpAltSign,
+	"doublefactorial": OpDoubleFactorial,
+	"fib":             OpFibonacci,
+	"sin":             OpSin,
+	"cos":             OpCos,
+	"ln":              OpLn,
+	"floor":           OpFloor,
+	"ceil":            OpCeil,
+	"abs":             OpAbs,
+	"sqrt":            OpSqrt,
+	"tan":             OpTan,
+	"exp":             OpExp,
+	"gamma":           OpGamma,
+	"bernoulli":       OpBernoulli,
+	"prime":           OpPrime,
+	"arctan":          OpArctan,
+	"arcsin":          OpArcsin,
+	"sinh":            OpSinh,
+	"cosh":            OpCosh,
+	"tanh":            OpTanh,
+	"digamma":         OpDigamma,
+	"subfactorial":    OpSubfactorial,
+}
+
+var binaryOpIDs = map[string]BinaryOp{
+	"add":        OpAdd,
+	"sub":        OpSub,
+	"mul":        OpMul,
+	"div":        OpDiv,
+	"pow":        OpPow,
+	"binomial":   OpBinomial,
+	"pochhammer": OpPochhammer,
+	"mod":        OpMod,
+	"root":       OpRoot,
+}
+
+// unaryIDOf and binaryIDOf invert the identifier tables.
+var (
+	unaryIDOf  = invert(unaryOpIDs)
+	binaryIDOf = invert(binaryOpIDs)
+)
+
+func invert[Op comparable](ids map[string]Op) map[Op]string {
+	out := make(map[Op]string, len(ids))
+	for id, op := range ids {
+		out[op] = id
+	}
+	return out
+}
+
+// LookupUnaryOp returns the unary op with the given identifier.
+func LookupUnaryOp(id string) (UnaryOp, bool) {
+	op, ok := unaryOpIDs[id]
+	return op, ok
+}
+
+// LookupBinaryOp returns the binary op with the given identifier.
+func LookupBinaryOp(id string) (BinaryOp, bool) {
+	op, ok := binaryOpIDs[id]
+	return op, ok
+}
+
+// UnaryOpID returns the identifier of op, or "" if it has none.
+func UnaryOpID(op UnaryOp) string {
+	return unaryIDOf[op]
+}
+
+// BinaryOpID returns the identifier of op, or "" if it has none.
+func BinaryOpID(op BinaryOp) string {
+	return binaryIDOf[op]
+}
+
+// OpIDs returns every op identifier, sorted.
+func OpIDs() []string {
+	ids := make([]string, 0, len(unaryOpIDs)+len(binaryOpIDs))
+	for id := range unaryOpIDs {
+		ids = append(ids, id)
+	}
+	for id := range binaryOpIDs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
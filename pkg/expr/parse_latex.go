@@ -2,6 +2,7 @@ package expr
 
 import (
 	"fmt"
+	"math/big"
 	"strconv"
 	"strings"
 	"unicode"
@@ -9,16 +10,57 @@ import (
 
 // ParseExprLatex parses a single LaTeX expression into an ExprNode.
 func ParseExprLatex(s string) (ExprNode, error) {
+	node, _, err := ParseExprLatexWarnings(s)
+	return node, err
+}
+
+// ParseExprLatexWarnings is ParseExprLatex, also returning the warnings
+// the parse raised (see LatexParser.Warnings).
+func ParseExprLatexWarnings(s string) (ExprNode, []Warning, error) {
 	p := &LatexParser{src: s}
 	node, err := p.ParseExpr()
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	p.SkipSpaces()
 	if p.pos < len(p.src) {
-		return nil, fmt.Errorf("unexpected trailing input at pos %d: %q", p.pos, p.src[p.pos:])
+		return nil, nil, fmt.Errorf("unexpected trailing input at pos %d: %q", p.pos, p.src[p.pos:])
 	}
-	return node, nil
+	return node, p.Warnings, nil
+}
+
+// ParseExprLatexPrefix parses the longest prefix of s that is a valid
+// expression and returns it with the rest of s, for expressions embedded
+// in larger text: `\frac{1}{n!}, as before` gives 1/n! and ", as before".
+// It fails only if no prefix of s parses.
+func ParseExprLatexPrefix(s string) (ExprNode, string, error) {
+	node, end, err := LongestPrefix(s, func(s string) (ExprNode, int, error) {
+		p := &LatexParser{src: s}
+		node, err := p.ParseExpr()
+		return node, p.pos, err
+	})
+	if err != nil {
+		return nil, s, err
+	}
+	return node, s[end:], nil
+}
+
+// LongestPrefix runs parse, which reports how far it got, on s and then,
+// if it failed, on ever shorter prefixes of s, starting from where it
+// failed, until one parses. It returns the result and the length of input
+// consumed, or the first error. Grammars embedding LatexParser use
+// it for their own prefix forms (see series.ParseCandidateLatexPrefix).
+func LongestPrefix[T any](s string, parse func(s string) (T, int, error)) (T, int, error) {
+	v, end, err := parse(s)
+	if err == nil {
+		return v, end, nil
+	}
+	for n := min(end, len(s)); n > 0; n-- {
+		if w, m, err := parse(s[:n]); err == nil {
+			return w, m, nil
+		}
+	}
+	return v, 0, err
 }
 
 // LatexParser is a recursive-descent parser for LaTeX math expressions.
@@ -26,13 +68,24 @@ func ParseExprLatex(s string) (ExprNode, error) {
 //
 // Precedence (low to high):
 //  1. + - (additive)
-//  2. implicit multiplication, \cdot (multiplicative)
+//  2. implicit multiplication, \cdot, \times, \bmod (multiplicative)
 //  3. unary minus
 //  4. ! !! ^ (postfix)
 //  5. primaries: numbers, n, \frac, \sqrt, (...), {...}, ...
 type LatexParser struct {
 	src string
 	pos int
+
+	// Commands are extra primaries, keyed by the prefix that starts them
+	// (such as `\sum_{`), for grammars that embed this one. A command is
+	// called with the parser at its prefix and consumes the whole primary.
+	// No prefix may begin with another.
+	Commands map[string]func(*LatexParser) (ExprNode, error)
+
+	// Warnings collects what was accepted but may not mean what it looks
+	// like: a number multiplied by juxtaposition (n 2, 2 3) and an unbraced
+	// exponent of more than one character (2^10, which TeX sets as 2^1 0).
+	Warnings []Warning
 }
 
 // NewLatexParser creates a parser for the given input string.
@@ -40,6 +93,11 @@ func NewLatexParser(s string) *LatexParser {
 	return &LatexParser{src: s}
 }
 
+// warn records a warning at pos.
+func (p *LatexParser) warn(kind WarningKind, pos int, format string, args ...any) {
+	p.Warnings = append(p.Warnings, Warning{Kind: kind, Pos: pos, Msg: fmt.Sprintf(format, args...)})
+}
+
 // Pos returns the current position.
 func (p *LatexParser) Pos() int { return p.pos }
 
@@ -139,7 +197,8 @@ func (p *LatexParser) parseAddSub() (ExprNode, error) {
 	return left, nil
 }
 
-// parseMul handles explicit (\cdot) and implicit multiplication.
+// parseMul handles explicit (\cdot, \times) and implicit multiplication,
+// and \bmod (or \mod), which binds like them: a b \bmod c is (a b) mod c.
 func (p *LatexParser) parseMul() (ExprNode, error) {
 	left, err := p.parseFactor()
 	if err != nil {
@@ -147,8 +206,18 @@ func (p *LatexParser) parseMul() (ExprNode, error) {
 	}
 	for {
 		p.SkipSpaces()
-		if p.HasPrefix(`\cdot`) {
-			p.pos += 5
+		if sym := p.modSymbol(); sym != "" {
+			p.pos += len(sym)
+			p.SkipSpaces()
+			right, err := p.parseFactor()
+			if err != nil {
+				return nil, err
+			}
+			left = &BinaryNode{Op: OpMod, Left: left, Right: right}
+			continue
+		}
+		if sym := p.mulSymbol(); sym != "" {
+			p.pos += len(sym)
 			p.SkipSpaces()
 			right, err := p.parseFactor()
 			if err != nil {
@@ -158,10 +227,15 @@ func (p *LatexParser) parseMul() (ExprNode, error) {
 			continue
 		}
 		if p.canStartImplicitMul() {
+			start := p.pos
 			right, err := p.parseFactor()
 			if err != nil {
 				return nil, err
 			}
+			if p.digitAt(start) {
+				p.warn(WarnImplicitMul, start, "%s followed by %s read as their product; write \\cdot if that is meant",
+					left.LaTeX(), right.LaTeX())
+			}
 			left = &BinaryNode{Op: OpMul, Left: left, Right: right}
 			continue
 		}
@@ -170,10 +244,35 @@ func (p *LatexParser) parseMul() (ExprNode, error) {
 	return left, nil
 }
 
+// mulSymbol returns the explicit multiplication symbol at the current
+// position, or "" if there is none.
+func (p *LatexParser) mulSymbol() string {
+	for _, sym := range []string{`\cdot`, `\times`} {
+		if p.HasPrefix(sym) {
+			return sym
+		}
+	}
+	return ""
+}
+
+// modSymbol returns the mod operator at the current position, or "" if
+// there is none. The command must end there, so \models is not \mod.
+func (p *LatexParser) modSymbol() string {
+	for _, sym := range []string{`\bmod`, `\mod`} {
+		if p.HasPrefix(sym) {
+			if end := p.pos + len(sym); end < len(p.src) && unicode.IsLetter(rune(p.src[end])) {
+				continue
+			}
+			return sym
+		}
+	}
+	return ""
+}
+
 // parseFactor handles unary minus (binds tighter than +/- but looser than postfix).
 func (p *LatexParser) parseFactor() (ExprNode, error) {
 	if p.peek() == '-' {
-		// Negative integer literal: let parsePrimary handle it.
+		// Negative number literal: let parsePrimary handle it.
 		if p.pos+1 < len(p.src) && unicode.IsDigit(rune(p.src[p.pos+1])) {
 			return p.parsePostfix()
 		}
@@ -189,12 +288,21 @@ func (p *LatexParser) parseFactor() (ExprNode, error) {
 	return p.parsePostfix()
 }
 
-// parsePostfix handles ! !! and ^ (highest precedence).
+// parsePostfix handles ! !! and ^ (highest precedence), after a prefix !
+// for the subfactorial, which takes only the primary after it: !n! is (!n)!.
 func (p *LatexParser) parsePostfix() (ExprNode, error) {
+	sub := p.peek() == '!'
+	if sub {
+		p.pos++
+		p.SkipSpaces()
+	}
 	node, err := p.parsePrimary()
 	if err != nil {
 		return nil, err
 	}
+	if sub {
+		node = &UnaryNode{Op: OpSubfactorial, Child: node}
+	}
 	for {
 		if p.HasPrefix("!!") {
 			p.pos += 2
@@ -208,22 +316,9 @@ func (p *LatexParser) parsePostfix() (ExprNode, error) {
 		}
 		if p.peek() == '^' {
 			p.pos++
-			var exp ExprNode
-			if p.peek() == '{' {
-				p.pos++
-				exp, err = p.ParseExpr()
-				if err != nil {
-					return nil, err
-				}
-				if err := p.Consume("}"); err != nil {
-					return nil, err
-				}
-			} else {
-				// Bare exponent: single primary (e.g. ^2, ^n)
-				exp, err = p.parsePrimary()
-				if err != nil {
-					return nil, err
-				}
+			exp, err := p.parseExponent()
+			if err != nil {
+				return nil, err
 			}
 			node = &BinaryNode{Op: OpPow, Left: node, Right: exp}
 			continue
@@ -233,12 +328,39 @@ func (p *LatexParser) parsePostfix() (ExprNode, error) {
 	return node, nil
 }
 
+// parseExponent parses the exponent after a ^: {expr}, or a single
+// primary (e.g. ^2, ^n).
+func (p *LatexParser) parseExponent() (ExprNode, error) {
+	if p.peek() != '{' {
+		start := p.pos
+		exp, err := p.parsePrimary()
+		if err == nil && p.pos-start > 1 && (p.src[start] == '-' || p.digitAt(start)) {
+			lit := p.src[start:p.pos]
+			p.warn(WarnDeprecated, start, "unbraced exponent ^%s read as ^{%s}, which TeX sets as ^{%s}%s", lit, lit, lit[:1], lit[1:])
+		}
+		return exp, err
+	}
+	p.pos++
+	exp, err := p.ParseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.Consume("}"); err != nil {
+		return nil, err
+	}
+	return exp, nil
+}
+
 // parsePrimary parses an atomic expression.
 func (p *LatexParser) parsePrimary() (ExprNode, error) {
 	if p.pos >= len(p.src) {
 		return nil, fmt.Errorf("unexpected end of input at pos %d", p.pos)
 	}
 
+	if cmd := p.command(); cmd != nil {
+		return cmd(p)
+	}
+
 	// \frac{...}{...}
 	if p.HasPrefix(`\frac{`) {
 		p.pos += 6
@@ -292,30 +414,24 @@ func (p *LatexParser) parsePrimary() (ExprNode, error) {
 		return &UnaryNode{Op: OpSqrt, Child: child}, nil
 	}
 
-	// \sin, \cos, \ln — accept both {(expr)} (engine) and (expr) (user)
-	if p.HasPrefix(`\sin`) {
-		p.pos += 4
-		child, err := p.parseFuncArg()
+	// \sqrt[k]{...}
+	if p.HasPrefix(`\sqrt[`) {
+		p.pos += 6
+		k, err := p.ParseExpr()
 		if err != nil {
 			return nil, err
 		}
-		return &UnaryNode{Op: OpSin, Child: child}, nil
-	}
-	if p.HasPrefix(`\cos`) {
-		p.pos += 4
-		child, err := p.parseFuncArg()
-		if err != nil {
+		if err := p.Consume("]{"); err != nil {
 			return nil, err
 		}
-		return &UnaryNode{Op: OpCos, Child: child}, nil
-	}
-	if p.HasPrefix(`\ln`) {
-		p.pos += 3
-		child, err := p.parseFuncArg()
+		child, err := p.ParseExpr()
 		if err != nil {
 			return nil, err
 		}
-		return &UnaryNode{Op: OpLn, Child: child}, nil
+		if err := p.Consume("}"); err != nil {
+			return nil, err
+		}
+		return &BinaryNode{Op: OpRoot, Left: child, Right: k}, nil
 	}
 
 	// \lfloor ... \rfloor
@@ -387,6 +503,42 @@ func (p *LatexParser) parsePrimary() (ExprNode, error) {
 		return &UnaryNode{Op: OpFibonacci, Child: child}, nil
 	}
 
+	// B_{...} → OpBernoulli
+	if p.HasPrefix(`B_{`) {
+		p.pos += 3
+		child, err := p.ParseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.Consume("}"); err != nil {
+			return nil, err
+		}
+		return &UnaryNode{Op: OpBernoulli, Child: child}, nil
+	}
+
+	// p_{...} → OpPrime
+	if p.HasPrefix(`p_{`) {
+		p.pos += 3
+		child, err := p.ParseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.Consume("}"); err != nil {
+			return nil, err
+		}
+		return &UnaryNode{Op: OpPrime, Child: child}, nil
+	}
+
+	// e^{...} → OpExp
+	if p.HasPrefix("e^") {
+		p.pos += 2
+		child, err := p.parseExponent()
+		if err != nil {
+			return nil, err
+		}
+		return &UnaryNode{Op: OpExp, Child: child}, nil
+	}
+
 	// {...} → brace grouping
 	if p.peek() == '{' {
 		p.pos++
@@ -400,7 +552,7 @@ func (p *LatexParser) parsePrimary() (ExprNode, error) {
 		return node, nil
 	}
 
-	// (...) → paren grouping
+	// (...) → paren grouping, and (...)_{...} → OpPochhammer
 	if p.peek() == '(' {
 		p.pos++
 		node, err := p.ParseExpr()
@@ -410,22 +562,59 @@ func (p *LatexParser) parsePrimary() (ExprNode, error) {
 		if err := p.Consume(")"); err != nil {
 			return nil, err
 		}
+		if p.HasPrefix("_{") {
+			p.pos += 2
+			k, err := p.ParseExpr()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.Consume("}"); err != nil {
+				return nil, err
+			}
+			return &BinaryNode{Op: OpPochhammer, Left: node, Right: k}, nil
+		}
 		return node, nil
 	}
 
+	// \operatorname{NAME}(...) and \NAME(...) for registered functions (see
+	// RegisterFunction), \sin, \cos, \tan, \exp and \ln among them — accept
+	// both {(expr)} (engine) and (expr) (user)
+	if name, size, ok := p.functionName(); ok {
+		start := p.pos
+		p.pos += size
+		child, err := p.parseFuncArg()
+		if err != nil {
+			return nil, err
+		}
+		if op, ok := binaryFunctions[name]; ok {
+			right, err := p.parseFuncArg()
+			if err != nil {
+				return nil, err
+			}
+			return &BinaryNode{Op: op, Left: child, Right: right}, nil
+		}
+		node, err := applyFunction(name, child)
+		if err != nil {
+			return nil, fmt.Errorf("%w, at pos %d", err, start)
+		}
+		return node, nil
+	}
+
+	// \pi, \phi, \varphi, \gamma and a bare e → SymbolicConstNode
+	if sym, size, ok := p.symbol(); ok {
+		p.pos += size
+		return &SymbolicConstNode{Sym: sym}, nil
+	}
+
 	// n → VarNode
 	if p.peek() == 'n' {
 		p.pos++
 		return &VarNode{}, nil
 	}
 
-	// Integer (possibly negative)
-	if p.peek() == '-' || unicode.IsDigit(rune(p.peek())) {
-		v, err := p.ParseInt()
-		if err != nil {
-			return nil, err
-		}
-		return &ConstNode{Val: v}, nil
+	// Number (possibly negative, or a decimal like .5)
+	if p.peek() == '-' || unicode.IsDigit(rune(p.peek())) || (p.peek() == '.' && p.digitAt(p.pos+1)) {
+		return p.parseNumber()
 	}
 
 	got := p.src[p.pos:]
@@ -435,6 +624,76 @@ func (p *LatexParser) parsePrimary() (ExprNode, error) {
 	return nil, fmt.Errorf("unexpected token at pos %d: %q", p.pos, got)
 }
 
+// command returns the Commands entry whose prefix is at the current
+// position, or nil.
+func (p *LatexParser) command() func(*LatexParser) (ExprNode, error) {
+	for prefix, cmd := range p.Commands {
+		if p.HasPrefix(prefix) {
+			return cmd
+		}
+	}
+	return nil
+}
+
+// functionName returns the function name at the current position, in
+// \operatorname{NAME} or \NAME form, and the length of that markup. Any
+// \operatorname counts, so an unregistered name gets a clear error; a bare
+// \NAME only if registered, so other commands (\cdot, \rfloor) are left
+// alone.
+func (p *LatexParser) functionName() (name string, size int, ok bool) {
+	const op = `\operatorname{`
+	if p.HasPrefix(op) {
+		rest := p.src[p.pos+len(op):]
+		end := strings.IndexByte(rest, '}')
+		if end <= 0 {
+			return "", 0, false
+		}
+		return rest[:end], len(op) + end + 1, true
+	}
+	if p.peek() != '\\' {
+		return "", 0, false
+	}
+	name = p.src[p.pos+1 : p.pos+1+letterRun(p.src[p.pos+1:])]
+	_, ok = functions[name]
+	if !ok {
+		_, ok = binaryFunctions[name]
+	}
+	return name, 1 + len(name), ok
+}
+
+// symbolCommands maps the commands \NAME that denote a symbolic constant
+// to it; e is the one written as a bare letter.
+var symbolCommands = map[string]Symbol{
+	"pi":     SymPi,
+	"phi":    SymPhi,
+	"varphi": SymPhi,
+	"gamma":  SymGamma,
+}
+
+// symbol returns the symbolic constant at the current position and the
+// length of its markup. An e followed by ^ is e^{...}, OpExp, not the
+// symbol.
+func (p *LatexParser) symbol() (sym Symbol, size int, ok bool) {
+	switch p.peek() {
+	case 'e':
+		return SymE, 1, !p.HasPrefix("e^")
+	case '\\':
+		name := p.src[p.pos+1 : p.pos+1+letterRun(p.src[p.pos+1:])]
+		sym, ok = symbolCommands[name]
+		return sym, 1 + len(name), ok
+	}
+	return 0, 0, false
+}
+
+// letterRun returns the length of the run of ASCII letters s starts with.
+func letterRun(s string) int {
+	i := 0
+	for i < len(s) && ('a' <= s[i] && s[i] <= 'z' || 'A' <= s[i] && s[i] <= 'Z') {
+		i++
+	}
+	return i
+}
+
 // parseFuncArg parses a function argument in {(expr)}, (expr), or {expr} form.
 func (p *LatexParser) parseFuncArg() (ExprNode, error) {
 	// Engine format: {(expr)}
@@ -483,10 +742,19 @@ func (p *LatexParser) canStartImplicitMul() bool {
 		return false
 	}
 	c := p.src[p.pos]
-	if unicode.IsDigit(rune(c)) || c == 'n' || c == '(' || c == '{' {
+	if unicode.IsDigit(rune(c)) || c == 'n' || c == '(' || c == '{' || p.command() != nil {
+		return true
+	}
+	if _, _, ok := p.functionName(); ok {
+		return true
+	}
+	if (c == 'F' || c == 'B' || c == 'p') && p.pos+1 < len(p.src) && p.src[p.pos+1] == '_' {
 		return true
 	}
-	if c == 'F' && p.pos+1 < len(p.src) && p.src[p.pos+1] == '_' {
+	if p.HasPrefix("e^") {
+		return true
+	}
+	if _, _, ok := p.symbol(); ok {
 		return true
 	}
 	if c == '\\' {
@@ -494,15 +762,80 @@ func (p *LatexParser) canStartImplicitMul() bool {
 		return strings.HasPrefix(rest, `\frac`) ||
 			strings.HasPrefix(rest, `\binom`) ||
 			strings.HasPrefix(rest, `\sqrt`) ||
-			strings.HasPrefix(rest, `\sin`) ||
-			strings.HasPrefix(rest, `\cos`) ||
-			strings.HasPrefix(rest, `\ln`) ||
 			strings.HasPrefix(rest, `\lfloor`) ||
 			strings.HasPrefix(rest, `\lceil`)
 	}
 	return false
 }
 
+// parseNumber parses a (possibly negative) integer or decimal literal, with
+// an optional exponent: 3, 0.5, .5, 5., 3.14, 2.5e-3, 1E6. The literal
+// becomes the exact rational it denotes, a ConstNode if that is an integer
+// and otherwise \frac of two ConstNodes in lowest terms (0.5 → 1/2).
+// Literals whose numerator or denominator does not fit in an int64, and
+// ones with a second decimal point (1.2.3), are an error.
+func (p *LatexParser) parseNumber() (ExprNode, error) {
+	start := p.pos
+	if p.peek() == '-' {
+		p.pos++
+	}
+	digits := p.pos
+	p.skipDigits()
+	decimal := false
+	if p.peek() == '.' {
+		decimal = true
+		p.pos++
+		p.skipDigits()
+	}
+	if m := p.src[digits:p.pos]; m == "" || m == "." {
+		return nil, fmt.Errorf("expected number at pos %d", start)
+	}
+	if c := p.peek(); c == 'e' || c == 'E' {
+		i := p.pos + 1
+		if i < len(p.src) && (p.src[i] == '+' || p.src[i] == '-') {
+			i++
+		}
+		if p.digitAt(i) {
+			decimal = true
+			p.pos = i
+			p.skipDigits()
+		}
+	}
+	lit := p.src[start:p.pos]
+	if p.peek() == '.' && p.digitAt(p.pos+1) {
+		for p.peek() == '.' || p.digitAt(p.pos) {
+			p.pos++
+		}
+		return nil, fmt.Errorf("malformed number %q at pos %d", p.src[start:p.pos], start)
+	}
+	if !decimal {
+		v, err := strconv.ParseInt(lit, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid integer %q: %w", lit, err)
+		}
+		return &ConstNode{Val: v}, nil
+	}
+	r, ok := new(big.Rat).SetString(lit)
+	if !ok {
+		return nil, fmt.Errorf("malformed number %q at pos %d", lit, start)
+	}
+	node, ok := ratNode(r)
+	if !ok {
+		return nil, fmt.Errorf("number %q at pos %d is not a fraction of int64s", lit, start)
+	}
+	return node, nil
+}
+
+func (p *LatexParser) digitAt(i int) bool {
+	return i < len(p.src) && unicode.IsDigit(rune(p.src[i]))
+}
+
+func (p *LatexParser) skipDigits() {
+	for p.digitAt(p.pos) {
+		p.pos++
+	}
+}
+
 // ParseInt parses a (possibly negative) integer.
 func (p *LatexParser) ParseInt() (int64, error) {
 	start := p.pos
@@ -1,6 +1,7 @@
 package expr
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -13,6 +14,11 @@ func TestParseExprLatexRoundTrip(t *testing.T) {
 		{"var", &VarNode{}},
 		{"const", &ConstNode{Val: 42}},
 		{"negative const", &ConstNode{Val: -7}},
+		{"pi", &SymbolicConstNode{Sym: SymPi}},
+		{"e", &SymbolicConstNode{Sym: SymE}},
+		{"phi", &SymbolicConstNode{Sym: SymPhi}},
+		{"gamma", &SymbolicConstNode{Sym: SymGamma}},
+		{"e to the n", &BinaryNode{Op: OpPow, Left: &SymbolicConstNode{Sym: SymE}, Right: &VarNode{}}},
 
 		// All unary ops
 		{"neg", &UnaryNode{Op: OpNeg, Child: &VarNode{}}},
@@ -27,6 +33,20 @@ func TestParseExprLatexRoundTrip(t *testing.T) {
 		{"ceil", &UnaryNode{Op: OpCeil, Child: &VarNode{}}},
 		{"abs", &UnaryNode{Op: OpAbs, Child: &VarNode{}}},
 		{"sqrt", &UnaryNode{Op: OpSqrt, Child: &VarNode{}}},
+		{"tan", &UnaryNode{Op: OpTan, Child: &VarNode{}}},
+		{"exp", &UnaryNode{Op: OpExp, Child: &VarNode{}}},
+		{"gamma", &UnaryNode{Op: OpGamma, Child: &VarNode{}}},
+		{"bernoulli", &UnaryNode{Op: OpBernoulli, Child: &VarNode{}}},
+		{"prime", &UnaryNode{Op: OpPrime, Child: &VarNode{}}},
+		{"arctan", &UnaryNode{Op: OpArctan, Child: &BinaryNode{Op: OpDiv, Left: &ConstNode{Val: 1}, Right: &VarNode{}}}},
+		{"arcsin", &UnaryNode{Op: OpArcsin, Child: &VarNode{}}},
+		{"sinh", &UnaryNode{Op: OpSinh, Child: &VarNode{}}},
+		{"subfactorial", &UnaryNode{Op: OpSubfactorial, Child: &VarNode{}}},
+		{"factorial of subfactorial", &UnaryNode{Op: OpFactorial, Child: &UnaryNode{Op: OpSubfactorial, Child: &BinaryNode{Op: OpAdd, Left: &VarNode{}, Right: &ConstNode{Val: 1}}}}},
+		{"digamma", &UnaryNode{Op: OpDigamma, Child: &BinaryNode{Op: OpAdd, Left: &VarNode{}, Right: &ConstNode{Val: 1}}}},
+		{"cosh", &UnaryNode{Op: OpCosh, Child: &BinaryNode{Op: OpMul, Left: &ConstNode{Val: 2}, Right: &VarNode{}}}},
+		{"tanh", &UnaryNode{Op: OpTanh, Child: &BinaryNode{Op: OpDiv, Left: &ConstNode{Val: 1}, Right: &VarNode{}}}},
+		{"exp squared", &BinaryNode{Op: OpPow, Left: &UnaryNode{Op: OpExp, Child: &VarNode{}}, Right: &ConstNode{Val: 2}}},
 
 		// All binary ops
 		{"add", &BinaryNode{Op: OpAdd, Left: &VarNode{}, Right: &ConstNode{Val: 3}}},
@@ -35,6 +55,9 @@ func TestParseExprLatexRoundTrip(t *testing.T) {
 		{"div", &BinaryNode{Op: OpDiv, Left: &VarNode{}, Right: &ConstNode{Val: 3}}},
 		{"pow", &BinaryNode{Op: OpPow, Left: &VarNode{}, Right: &ConstNode{Val: 3}}},
 		{"binomial", &BinaryNode{Op: OpBinomial, Left: &VarNode{}, Right: &ConstNode{Val: 3}}},
+		{"pochhammer", &BinaryNode{Op: OpPochhammer, Left: &BinaryNode{Op: OpAdd, Left: &VarNode{}, Right: &ConstNode{Val: 1}}, Right: &VarNode{}}},
+		{"mod", &BinaryNode{Op: OpMod, Left: &BinaryNode{Op: OpMul, Left: &ConstNode{Val: 2}, Right: &VarNode{}}, Right: &ConstNode{Val: 4}}},
+		{"root", &BinaryNode{Op: OpRoot, Left: &BinaryNode{Op: OpAdd, Left: &VarNode{}, Right: &ConstNode{Val: 1}}, Right: &BinaryNode{Op: OpAdd, Left: &VarNode{}, Right: &ConstNode{Val: 2}}}},
 
 		// Nested expressions (3+ levels)
 		{"nested add-mul", &BinaryNode{
@@ -105,6 +128,116 @@ func TestParseExprLatexRoundTrip(t *testing.T) {
 	}
 }
 
+func TestParseExprLatexNumbers(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{`0.5`, "(1 / 2)"},
+		{`3.14`, "(157 / 50)"},
+		{`-0.25 n`, "((-1 / 4) * n)"},
+		{`2.0`, "2"},
+		{`2.5e-3`, "(1 / 400)"},
+		{`1E6`, "1000000"},
+		{`1.5e+2`, "150"},
+		{`3 \times 10^{-3}`, "(3 * (10)^(-3))"},
+		{`0.5^{n}`, "((1 / 2))^(n)"},
+		{`2.5n`, "((5 / 2) * n)"},
+		{`.5`, "(1 / 2)"},
+		{`2.`, "2"},
+		{`1.2.3`, ""},
+		{`-`, ""},
+	}
+	for _, tt := range tests {
+		node, err := ParseExprLatex(tt.input)
+		if tt.want == "" {
+			if err == nil {
+				t.Errorf("ParseExprLatex(%q) = %s, want an error", tt.input, node)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseExprLatex(%q): %v", tt.input, err)
+		} else if got := node.String(); got != tt.want {
+			t.Errorf("ParseExprLatex(%q) = %s, want %s", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestParseExprLatexFunctions(t *testing.T) {
+	RegisterFunction("half", func(arg ExprNode) (ExprNode, error) {
+		return &BinaryNode{Op: OpDiv, Left: arg, Right: &ConstNode{Val: 2}}, nil
+	})
+	defer delete(functions, "half")
+
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{`\operatorname{sin}(n)`, "sin(n)"},
+		{`\operatorname{ln}{(n)}`, "ln(n)"},
+		{`\operatorname{half}(n) + 1`, "((n / 2) + 1)"},
+		{`\half(n)`, "(n / 2)"},
+		{`3 \operatorname{half}(n)`, "(3 * (n / 2))"},
+		{`n \sin(n)`, "(n * sin(n))"},
+		{`\tan(n)`, "tan(n)"},
+		{`\exp(-n)`, "exp((-n))"},
+		{`e^{n+1}`, "exp((n + 1))"},
+		{`e^n!`, "(exp(n))!"},
+		{`2 e^{n}`, "(2 * exp(n))"},
+		{`2e^{n}`, "(2 * exp(n))"},
+		{`\Gamma(n + \frac{1}{2})`, "gamma((n + (1 / 2)))"},
+		{`2\pi n`, "((2 * pi) * n)"},
+		{`\frac{e}{n}`, "(e / n)"},
+		{`2e`, "(2 * e)"},
+		{`\varphi^{n} \gamma`, "((phi)^(n) * gamma)"},
+		{`\pin`, ""},
+		{`\operatorname{Gamma}(n)`, "gamma(n)"},
+		{`B_{2n} n`, "(bernoulli((2 * n)) * n)"},
+		{`2 B_{n}`, "(2 * bernoulli(n))"},
+		{`\operatorname{bernoulli}(n)`, "bernoulli(n)"},
+		{`n p_{n+1}`, "(n * prime((n + 1)))"},
+		{`p_{n} \pi`, "(prime(n) * pi)"},
+		{`\operatorname{prime}(2n)`, "prime((2 * n))"},
+		{`(\frac{1}{2})_{n} (n+1)`, "(poch((1 / 2), n) * (n + 1))"},
+		{`(n)_{2n}!`, "(poch(n, (2 * n)))!"},
+		{`(n)_{k}`, ""},
+		{`2n \bmod 4 + 1`, "(((2 * n) mod 4) + 1)"},
+		{`n \mod 3 \cdot 2`, "((n mod 3) * 2)"},
+		{`\sqrt[3]{2} n`, "(root(2, 3) * n)"},
+		{`4 \arctan(\frac{1}{5})`, "(4 * arctan((1 / 5)))"},
+		{`\operatorname{arcsin}(n)`, "arcsin(n)"},
+		{`\sqrt[n+1]{\frac{1}{n}}`, "root((1 / n), (n + 1))"},
+		{`\sqrt[3 {2}`, ""},
+		{`n \models 3`, ""},
+		{`\operatorname{Li2}(n)`, ""},
+		{`\sinh(n)`, "sinh(n)"},
+		{`\psi(n + 1) + \gamma`, "(digamma((n + 1)) + gamma)"},
+		{`!n + \operatorname{D}(n + 1)`, "(!(n) + !((n + 1)))"},
+		{`!n!`, "(!(n))!"},
+		{`\operatorname{tanh}(2n) \cosh(n)`, "(tanh((2 * n)) * cosh(n))"},
+		{`\coth(n)`, ""},
+		{`\operatorname{half} n`, ""},
+	}
+	for _, tt := range tests {
+		node, err := ParseExprLatex(tt.input)
+		if tt.want == "" {
+			if err == nil {
+				t.Errorf("ParseExprLatex(%q) = %s, want an error", tt.input, node)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseExprLatex(%q): %v", tt.input, err)
+		} else if got := node.String(); got != tt.want {
+			t.Errorf("ParseExprLatex(%q) = %s, want %s", tt.input, got, tt.want)
+		}
+	}
+	if _, err := ParseExprLatex(`\operatorname{Li2}(n)`); err == nil || !strings.Contains(err.Error(), "unknown function Li2") {
+		t.Errorf("unregistered function: %v", err)
+	}
+}
+
 func TestParseExprLatexErrors(t *testing.T) {
 	tests := []struct {
 		name  string
@@ -115,6 +248,7 @@ func TestParseExprLatexErrors(t *testing.T) {
 		{"missing brace", `{n`},
 		{"unknown token", `@`},
 		{"trailing junk", `n xyz`},
+		{"decimal too precise", `3.14159265358979323846`},
 	}
 
 	for _, tt := range tests {
@@ -126,3 +260,71 @@ func TestParseExprLatexErrors(t *testing.T) {
 		})
 	}
 }
+
+func TestParseExprLatexPrefix(t *testing.T) {
+	tests := []struct {
+		input, want, rest string
+	}{
+		{`\frac{1}{n!}, as before`, "(1 / (n)!)", ", as before"},
+		{`n + 1`, "(n + 1)", ""},
+		{`n xyz`, "n", "xyz"},
+		{`{n}^{2} + \foo`, "(n)^(2)", "+ \\foo"},
+		{`2n) and more`, "(2 * n)", ") and more"},
+	}
+	for _, tt := range tests {
+		node, rest, err := ParseExprLatexPrefix(tt.input)
+		if err != nil {
+			t.Errorf("ParseExprLatexPrefix(%q): %v", tt.input, err)
+			continue
+		}
+		if node.String() != tt.want || rest != tt.rest {
+			t.Errorf("ParseExprLatexPrefix(%q) = %s, %q; want %s, %q", tt.input, node, rest, tt.want, tt.rest)
+		}
+	}
+	for _, input := range []string{"", "@", `\frac{3`} {
+		if _, rest, err := ParseExprLatexPrefix(input); err == nil || rest != input {
+			t.Errorf("ParseExprLatexPrefix(%q) = rest %q, err %v; want an error and the whole input", input, rest, err)
+		}
+	}
+}
+
+func TestParseExprLatexWarnings(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []Warning
+	}{
+		{`2n + n^2 + 2^{10}`, nil},
+		{`n 2`, []Warning{{Kind: WarnImplicitMul, Pos: 2}}},
+		{`(n+1)3`, []Warning{{Kind: WarnImplicitMul, Pos: 5}}},
+		{`2^10`, []Warning{{Kind: WarnDeprecated, Pos: 2}}},
+		{`n^-1 + 2 3`, []Warning{{Kind: WarnDeprecated, Pos: 2}, {Kind: WarnImplicitMul, Pos: 9}}},
+	}
+	for _, tt := range tests {
+		_, got, err := ParseExprLatexWarnings(tt.input)
+		if err != nil {
+			t.Fatalf("ParseExprLatexWarnings(%q): %v", tt.input, err)
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("ParseExprLatexWarnings(%q) = %v, want %d warnings", tt.input, got, len(tt.want))
+			continue
+		}
+		for i, w := range got {
+			if w.Kind != tt.want[i].Kind || w.Pos != tt.want[i].Pos || w.Msg == "" {
+				t.Errorf("ParseExprLatexWarnings(%q)[%d] = %v, want %s at pos %d", tt.input, i, w, tt.want[i].Kind, tt.want[i].Pos)
+			}
+		}
+	}
+}
+
+func TestWriteWarnings(t *testing.T) {
+	var b strings.Builder
+	WriteWarnings(&b, "A: ", []Warning{
+		{Kind: WarnImplicitMul, Pos: 2, Msg: "2 after n read as a product"},
+		{Kind: WarnLossyFold, Pos: -1, Msg: "folded"},
+	})
+	want := "A: warning: pos 2: 2 after n read as a product [implicit-mul]\n" +
+		"A: warning: folded [lossy-fold]\n"
+	if b.String() != want {
+		t.Errorf("WriteWarnings wrote %q, want %q", b.String(), want)
+	}
+}
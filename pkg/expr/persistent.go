@@ -0,0 +1,157 @@
+package expr
+
+// Expression trees are persistent: once built, a node is never modified, so
+// any subtree may be shared by many trees (parents and children in a
+// population, cache entries, archive copies). Variants are built with
+// ReplaceAt, which copies only the nodes on the path from the root to the
+// replaced position and shares everything else. Clone is still available
+// for callers that want a private deep copy.
+
+// NodeAt returns the i-th node of root in preorder (root is 0), or nil if i
+// is out of range.
+func NodeAt(root ExprNode, i int) ExprNode {
+	if i < 0 {
+		return nil
+	}
+	for {
+		if i == 0 {
+			return root
+		}
+		i--
+		switch n := root.(type) {
+		case *UnaryNode:
+			root = n.Child
+		case *BinaryNode:
+			if lc := n.Left.NodeCount(); i >= lc {
+				i -= lc
+				root = n.Right
+			} else {
+				root = n.Left
+			}
+		default:
+			return nil
+		}
+	}
+}
+
+// ReplaceAt returns a tree equal to root except that its i-th preorder node
+// is repl. root is left untouched; the result shares every subtree off the
+// root-to-i path with it. An out-of-range i returns root itself.
+func ReplaceAt(root ExprNode, i int, repl ExprNode) ExprNode {
+	if i == 0 {
+		return repl
+	}
+	switch n := root.(type) {
+	case *UnaryNode:
+		child := ReplaceAt(n.Child, i-1, repl)
+		if child == n.Child {
+			return root
+		}
+		return &UnaryNode{Op: n.Op, Child: child}
+	case *BinaryNode:
+		lc := n.Left.NodeCount()
+		if i-1 < lc {
+			left := ReplaceAt(n.Left, i-1, repl)
+			if left == n.Left {
+				return root
+			}
+			return &BinaryNode{Op: n.Op, Left: left, Right: n.Right}
+		}
+		right := ReplaceAt(n.Right, i-1-lc, repl)
+		if right == n.Right {
+			return root
+		}
+		return &BinaryNode{Op: n.Op, Left: n.Left, Right: right}
+	default:
+		return root
+	}
+}
+
+// ConstIndices returns the preorder indices of all ConstNodes in root.
+func ConstIndices(root ExprNode) []int {
+	var out []int
+	var walk func(ExprNode, int) int
+	walk = func(node ExprNode, i int) int {
+		switch n := node.(type) {
+		case *ConstNode:
+			out = append(out, i)
+			return i + 1
+		case *UnaryNode:
+			return walk(n.Child, i+1)
+		case *BinaryNode:
+			return walk(n.Right, walk(n.Left, i+1))
+		default:
+			return i + 1
+		}
+	}
+	walk(root, 0)
+	return out
+}
+
+// ConstIndicesByRole splits ConstIndices(root) by the role each constant
+// plays. Structural constants shape the series: they sit in an exponent or
+// a root's index, in the argument of a factorial, double factorial,
+// Fibonacci or Bernoulli number, prime or (-1)^e, in a binomial or
+// Pochhammer symbol, or in either side of a mod, which sets a period.
+// Coefficients are the rest, which scale it.
+func ConstIndicesByRole(root ExprNode) (structural, coefficient []int) {
+	var walk func(ExprNode, int, bool) int
+	walk = func(node ExprNode, i int, inSkeleton bool) int {
+		switch n := node.(type) {
+		case *ConstNode:
+			if inSkeleton {
+				structural = append(structural, i)
+			} else {
+				coefficient = append(coefficient, i)
+			}
+			return i + 1
+		case *UnaryNode:
+			switch n.Op {
+			case OpFactorial, OpDoubleFactorial, OpSubfactorial, OpFibonacci, OpBernoulli, OpPrime, OpAltSign:
+				inSkeleton = true
+			}
+			return walk(n.Child, i+1, inSkeleton)
+		case *BinaryNode:
+			switch n.Op {
+			case OpPow, OpRoot:
+				return walk(n.Right, walk(n.Left, i+1, inSkeleton), true)
+			case OpBinomial, OpPochhammer, OpMod:
+				inSkeleton = true
+			}
+			return walk(n.Right, walk(n.Left, i+1, inSkeleton), inSkeleton)
+		default:
+			return i + 1
+		}
+	}
+	walk(root, 0, false)
+	return structural, coefficient
+}
+
+// ConstPolarities returns, for each of ConstIndices(root) in order, +1 if
+// raising that constant raises root's value and -1 if it lowers it.
+// Negation and the right side of a subtraction or division flip the
+// sign; everything else is taken to be increasing in its operands, which
+// holds for the positive bases and arguments series terms mostly have.
+func ConstPolarities(root ExprNode) []int {
+	var out []int
+	var walk func(ExprNode, int)
+	walk = func(node ExprNode, sign int) {
+		switch n := node.(type) {
+		case *ConstNode:
+			out = append(out, sign)
+		case *UnaryNode:
+			if n.Op == OpNeg {
+				sign = -sign
+			}
+			walk(n.Child, sign)
+		case *BinaryNode:
+			walk(n.Left, sign)
+			if n.Op == OpSub || n.Op == OpDiv {
+				sign = -sign
+			}
+			walk(n.Right, sign)
+		}
+	}
+	walk(root, 1)
+	return out
+}
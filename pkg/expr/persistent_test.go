@@ -0,0 +1,129 @@
+package expr
+
+import "testing"
+
+func preorder(node ExprNode, out *[]ExprNode) {
+	*out = append(*out, node)
+	switch n := node.(type) {
+	case *UnaryNode:
+		preorder(n.Child, out)
+	case *BinaryNode:
+		preorder(n.Left, out)
+		preorder(n.Right, out)
+	}
+}
+
+func TestNodeAt_Preorder(t *testing.T) {
+	tree := deepTree(6)
+	var want []ExprNode
+	preorder(tree, &want)
+	for i, w := range want {
+		if got := NodeAt(tree, i); got != w {
+			t.Errorf("NodeAt(%d) = %v, want %v", i, got, w)
+		}
+	}
+	if NodeAt(tree, len(want)) != nil || NodeAt(tree, -1) != nil {
+		t.Error("out-of-range NodeAt should return nil")
+	}
+}
+
+func TestReplaceAt_PathCopy(t *testing.T) {
+	tree := deepTree(6)
+	before := tree.String()
+	var nodes []ExprNode
+	preorder(tree, &nodes)
+
+	for i := range nodes {
+		repl := &ConstNode{Val: 42}
+		got := ReplaceAt(tree, i, repl)
+		if tree.String() != before {
+			t.Fatalf("ReplaceAt(%d) modified the original tree", i)
+		}
+		if NodeAt(got, i) != repl {
+			t.Errorf("ReplaceAt(%d): replacement not at index %d", i, i)
+		}
+		if got.NodeCount() != tree.NodeCount()-nodes[i].NodeCount()+1 {
+			t.Errorf("ReplaceAt(%d): node count %d", i, got.NodeCount())
+		}
+
+		// Everything off the root-to-i path is shared, not copied.
+		var after []ExprNode
+		preorder(got, &after)
+		shared := 0
+		for _, n := range after {
+			for _, o := range nodes {
+				if n == o {
+					shared++
+					break
+				}
+			}
+		}
+		if copied := len(after) - shared - 1; copied > tree.Depth() {
+			t.Errorf("ReplaceAt(%d) copied %d nodes, depth is only %d", i, copied, tree.Depth())
+		}
+	}
+
+	if ReplaceAt(tree, len(nodes), &VarNode{}) != tree {
+		t.Error("out-of-range ReplaceAt should return the original tree")
+	}
+}
+
+func TestConstIndices(t *testing.T) {
+	tree, err := ParseExprLatex(`\frac{3n + 1}{n^{2} - 7}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var vals []int64
+	for _, i := range ConstIndices(tree) {
+		c, ok := NodeAt(tree, i).(*ConstNode)
+		if !ok {
+			t.Fatalf("index %d is %T, not a constant", i, NodeAt(tree, i))
+		}
+		vals = append(vals, c.Val)
+	}
+	if len(vals) != 4 || vals[0] != 3 || vals[1] != 1 || vals[2] != 2 || vals[3] != 7 {
+		t.Errorf("constants in preorder = %v, want [3 1 2 7]", vals)
+	}
+}
+
+func TestConstIndicesByRole(t *testing.T) {
+	tree, err := ParseExprLatex(`\frac{5 (2n+1)!}{3 \cdot 4^{n+2} (-1)^{n+1}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vals := func(idx []int) []int64 {
+		var out []int64
+		for _, i := range idx {
+			out = append(out, NodeAt(tree, i).(*ConstNode).Val)
+		}
+		return out
+	}
+	structural, coefficient := ConstIndicesByRole(tree)
+	if got := vals(structural); len(got) != 4 || got[0] != 2 || got[1] != 1 || got[2] != 2 || got[3] != 1 {
+		t.Errorf("structural constants = %v, want [2 1 2 1]", got)
+	}
+	if got := vals(coefficient); len(got) != 3 || got[0] != 5 || got[1] != 3 || got[2] != 4 {
+		t.Errorf("coefficients = %v, want [5 3 4]", got)
+	}
+	if len(structural)+len(coefficient) != len(ConstIndices(tree)) {
+		t.Errorf("roles cover %d constants, want %d", len(structural)+len(coefficient), len(ConstIndices(tree)))
+	}
+}
+
+func TestConstPolarities(t *testing.T) {
+	tree, err := ParseExprLatex(`\frac{2n + 3}{5 - n} - 7 \cdot (-(4 - n))`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := ConstPolarities(tree)
+	want := []int{1, 1, -1, -1, 1}
+	if len(got) != len(want) || len(got) != len(ConstIndices(tree)) {
+		t.Fatalf("ConstPolarities = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("ConstPolarities = %v, want %v", got, want)
+			break
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package expr
+
+import (
+	"math"
+	"math/big"
+	"sync"
+)
+
+// maxPrimeIndex bounds the index of OpPrime: p_k for k up to 2^20, the
+// largest 16290047, keeps the sieve behind the table near 16 MB.
+const maxPrimeIndex = 1 << 20
+
+// primeCache holds p_1, p_2, ... (2, 3, 5, ...) at primes[0], primes[1], ...
+var primeCache struct {
+	mu     sync.RWMutex
+	primes []int64
+}
+
+// primeAt returns the k-th prime, for 1 ≤ k ≤ maxPrimeIndex, from the
+// shared table.
+func primeAt(k int64) (int64, bool) {
+	if k < 1 || k > maxPrimeIndex {
+		return 0, false
+	}
+	primeCache.mu.RLock()
+	if k <= int64(len(primeCache.primes)) {
+		p := primeCache.primes[k-1]
+		primeCache.mu.RUnlock()
+		return p, true
+	}
+	primeCache.mu.RUnlock()
+
+	primeCache.mu.Lock()
+	defer primeCache.mu.Unlock()
+	if k > int64(len(primeCache.primes)) {
+		// The table is sieved afresh rather than extended, so grow it by
+		// at least double.
+		n := min(max(k, 2*int64(len(primeCache.primes)), 1024), maxPrimeIndex)
+		primeCache.primes = sievePrimes(int(n))
+	}
+	return primeCache.primes[k-1], true
+}
+
+// sievePrimes returns the first n primes, sieving up to Rosser's bound
+// p_n < n(ln n + ln ln n) for n ≥ 6.
+func sievePrimes(n int) []int64 {
+	limit := 13
+	if n >= 6 {
+		fn := float64(n)
+		limit = int(fn*(math.Log(fn)+math.Log(math.Log(fn)))) + 1
+	}
+	composite := make([]bool, limit+1)
+	out := make([]int64, 0, n)
+	for i := 2; i <= limit && len(out) < n; i++ {
+		if composite[i] {
+			continue
+		}
+		out = append(out, int64(i))
+		for j := i * i; j <= limit; j += i {
+			composite[j] = true
+		}
+	}
+	return out
+}
+
+func bigPrime(f *big.Float, prec uint) (*big.Float, bool) {
+	iv, ok := toInt64(f)
+	if !ok {
+		return nil, false
+	}
+	p, ok := primeAt(iv)
+	if !ok {
+		return nil, false
+	}
+	return newFloat(prec).SetInt64(p), true
+}
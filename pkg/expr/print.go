@@ -3,27 +3,42 @@ package expr
 import "fmt"
 
 var unaryOpNames = map[UnaryOp]string{
-	OpNeg:              "-",
-	OpFactorial:        "!",
-	OpAltSign:          "(-1)^",
-	OpDoubleFactorial:  "!!",
-	OpFibonacci:        "fib",
-	OpSin:              "sin",
-	OpCos:              "cos",
-	OpLn:               "ln",
-	OpFloor:            "floor",
-	OpCeil:             "ceil",
-	OpAbs:              "abs",
-	OpSqrt:             "sqrt",
+	OpNeg:             "-",
+	OpFactorial:       "!",
+	OpAltSign:         "(-1)^",
+	OpDoubleFactorial: "!!",
+	OpFibonacci:       "fib",
+	OpSin:             "sin",
+	OpCos:             "cos",
+	OpLn:              "ln",
+	OpFloor:           "floor",
+	OpCeil:            "ceil",
+	OpAbs:             "abs",
+	OpSqrt:            "sqrt",
+	OpTan:             "tan",
+	OpExp:             "exp",
+	OpGamma:           "gamma",
+	OpBernoulli:       "bernoulli",
+	OpPrime:           "prime",
+	OpArctan:          "arctan",
+	OpArcsin:          "arcsin",
+	OpSinh:            "sinh",
+	OpCosh:            "cosh",
+	OpTanh:            "tanh",
+	OpDigamma:         "digamma",
+	OpSubfactorial:    "subfactorial",
 }
 
 var binaryOpSymbols = map[BinaryOp]string{
-	OpAdd:      "+",
-	OpSub:      "-",
-	OpMul:      "*",
-	OpDiv:      "/",
-	OpPow:      "^",
-	OpBinomial: "C",
+	OpAdd:        "+",
+	OpSub:        "-",
+	OpMul:        "*",
+	OpDiv:        "/",
+	OpPow:        "^",
+	OpBinomial:   "C",
+	OpPochhammer: "poch",
+	OpMod:        "mod",
+	OpRoot:       "root",
 }
 
 // String methods
@@ -36,6 +51,10 @@ func (c *ConstNode) String() string {
 	return fmt.Sprintf("%d", c.Val)
 }
 
+func (s *SymbolicConstNode) String() string {
+	return s.Sym.String()
+}
+
 func (u *UnaryNode) String() string {
 	child := u.Child.String()
 	switch u.Op {
@@ -47,6 +66,8 @@ func (u *UnaryNode) String() string {
 		return fmt.Sprintf("(-1)^(%s)", child)
 	case OpDoubleFactorial:
 		return fmt.Sprintf("(%s)!!", child)
+	case OpSubfactorial:
+		return fmt.Sprintf("!(%s)", child)
 	default:
 		name := unaryOpNames[u.Op]
 		return fmt.Sprintf("%s(%s)", name, child)
@@ -60,13 +81,67 @@ func (b *BinaryNode) String() string {
 	switch b.Op {
 	case OpBinomial:
 		return fmt.Sprintf("C(%s, %s)", left, right)
+	case OpPochhammer:
+		return fmt.Sprintf("poch(%s, %s)", left, right)
+	case OpRoot:
+		return fmt.Sprintf("root(%s, %s)", left, right)
 	case OpPow:
 		return fmt.Sprintf("(%s)^(%s)", left, right)
 	default:
+		if _, ok := customBinary[b.Op]; ok {
+			return fmt.Sprintf("%s(%s, %s)", sym, left, right)
+		}
 		return fmt.Sprintf("(%s %s %s)", left, sym, right)
 	}
 }
 
+// LaTeX templates: the text before and after a unary node's child, and
+// before, between and after a binary node's children. LaTeX and LaTeXMap
+// both render from these.
+
+var unaryLaTeX = map[UnaryOp][2]string{
+	OpNeg:             {"-{", "}"},
+	OpFactorial:       {"{", "}!"},
+	OpAltSign:         {"(-1)^{", "}"},
+	OpDoubleFactorial: {"{", "}!!"},
+	OpFibonacci:       {"F_{", "}"},
+	OpSin:             {"\\sin{(", ")}"},
+	OpCos:             {"\\cos{(", ")}"},
+	OpLn:              {"\\ln{(", ")}"},
+	OpFloor:           {"\\lfloor ", " \\rfloor"},
+	OpCeil:            {"\\lceil ", " \\rceil"},
+	OpAbs:             {"|", "|"},
+	OpSqrt:            {"\\sqrt{", "}"},
+	OpTan:             {"\\tan{(", ")}"},
+	OpExp:             {"e^{", "}"},
+	OpGamma:           {"\\Gamma{(", ")}"},
+	OpBernoulli:       {"B_{", "}"},
+	OpPrime:           {"p_{", "}"},
+	OpArctan:          {"\\arctan{(", ")}"},
+	OpArcsin:          {"\\arcsin{(", ")}"},
+	OpSinh:            {"\\sinh{(", ")}"},
+	OpCosh:            {"\\cosh{(", ")}"},
+	OpTanh:            {"\\tanh{(", ")}"},
+	OpDigamma:         {"\\psi{(", ")}"},
+	OpSubfactorial:    {"{!{", "}}"}, // braced, or a !n after an operand would read as its factorial
+}
+
+var binaryLaTeX = map[BinaryOp][3]string{
+	OpAdd:        {"{", "} + {", "}"},
+	OpSub:        {"{", "} - {", "}"},
+	OpMul:        {"{", "} \\cdot {", "}"},
+	OpDiv:        {"\\frac{", "}{", "}"},
+	OpPow:        {"{", "}^{", "}"},
+	OpBinomial:   {"\\binom{", "}{", "}"},
+	OpPochhammer: {"(", ")_{", "}"},
+	OpMod:        {"{", "} \\bmod {", "}"},
+	OpRoot:       {"\\sqrt[", "]{", "}"}, // index first: see rightFirst
+}
+
+// rightFirst holds the binary ops whose LaTeX writes the right child
+// first, as \sqrt[k]{a} does.
+var rightFirst = map[BinaryOp]bool{OpRoot: true}
+
 // LaTeX methods
 
 func (v *VarNode) LaTeX() string {
@@ -77,55 +152,22 @@ func (c *ConstNode) LaTeX() string {
 	return fmt.Sprintf("%d", c.Val)
 }
 
+func (s *SymbolicConstNode) LaTeX() string {
+	return symbolDefs[s.Sym].LaTeX
+}
+
 func (u *UnaryNode) LaTeX() string {
-	child := u.Child.LaTeX()
-	switch u.Op {
-	case OpNeg:
-		return fmt.Sprintf("-{%s}", child)
-	case OpFactorial:
-		return fmt.Sprintf("{%s}!", child)
-	case OpAltSign:
-		return fmt.Sprintf("(-1)^{%s}", child)
-	case OpDoubleFactorial:
-		return fmt.Sprintf("{%s}!!", child)
-	case OpFibonacci:
-		return fmt.Sprintf("F_{%s}", child)
-	case OpSin:
-		return fmt.Sprintf("\\sin{(%s)}", child)
-	case OpCos:
-		return fmt.Sprintf("\\cos{(%s)}", child)
-	case OpLn:
-		return fmt.Sprintf("\\ln{(%s)}", child)
-	case OpFloor:
-		return fmt.Sprintf("\\lfloor %s \\rfloor", child)
-	case OpCeil:
-		return fmt.Sprintf("\\lceil %s \\rceil", child)
-	case OpAbs:
-		return fmt.Sprintf("|%s|", child)
-	case OpSqrt:
-		return fmt.Sprintf("\\sqrt{%s}", child)
-	default:
-		return child
-	}
+	t := unaryLaTeX[u.Op] // an unknown op renders as its child
+	return t[0] + u.Child.LaTeX() + t[1]
 }
 
 func (b *BinaryNode) LaTeX() string {
-	left := b.Left.LaTeX()
-	right := b.Right.LaTeX()
-	switch b.Op {
-	case OpAdd:
-		return fmt.Sprintf("{%s} + {%s}", left, right)
-	case OpSub:
-		return fmt.Sprintf("{%s} - {%s}", left, right)
-	case OpMul:
-		return fmt.Sprintf("{%s} \\cdot {%s}", left, right)
-	case OpDiv:
-		return fmt.Sprintf("\\frac{%s}{%s}", left, right)
-	case OpPow:
-		return fmt.Sprintf("{%s}^{%s}", left, right)
-	case OpBinomial:
-		return fmt.Sprintf("\\binom{%s}{%s}", left, right)
-	default:
+	t, ok := binaryLaTeX[b.Op]
+	if !ok {
 		return ""
 	}
+	if rightFirst[b.Op] {
+		return t[0] + b.Right.LaTeX() + t[1] + b.Left.LaTeX() + t[2]
+	}
+	return t[0] + b.Left.LaTeX() + t[1] + b.Right.LaTeX() + t[2]
 }
@@ -1,8 +1,11 @@
 package expr
 
 import (
+	"fmt"
 	"math"
 	"math/big"
+	"sync"
+	"time"
 )
 
 // maxRecurseDepth caps recursion in simplification to prevent stack overflow
@@ -10,33 +13,225 @@ import (
 const maxRecurseDepth = 100
 
 // Simplify applies rewrite rules to reduce an expression tree.
-// It repeatedly applies rules until no further changes occur.
+// It repeatedly applies rules until no further changes occur, within
+// DefaultSimplifyBudget. Results are memoized by structural hash; see
+// SimplifyCacheStats.
 func Simplify(node ExprNode) ExprNode {
-	for i := 0; i < 20; i++ { // cap iterations
-		next := simplifyD(node, 0)
-		if next.String() == node.String() {
-			return next
+	key := simplifyKey{hash: Hash(node)}
+	if out, ok := defaultSimplifyCache.get(key, node); ok {
+		return out
+	}
+	out, stats := SimplifyWithBudget(node, DefaultSimplifyBudget)
+	if !stats.Truncated {
+		defaultSimplifyCache.put(key, node, out)
+	}
+	return out
+}
+
+// maxSimplifyPasses caps the rewrite passes of one simplification.
+const maxSimplifyPasses = 20
+
+// SimplifyBudget bounds the work of one simplification. A zero field is
+// no bound.
+type SimplifyBudget struct {
+	MaxNodes int           // node visits, summed over passes
+	Timeout  time.Duration // wall time; checked every simplifyClockEvery visits
+
+	// MaxGrowth caps the result at MaxGrowth times the input's node count.
+	// A rule application that would take the tree past it is rejected
+	// (and counted in SimplifyStats.Rejected), and the node is left as
+	// the other rules make it. Few rules grow a tree, but (-k)^e →
+	// (-1)^e · k^e copies e, so without a cap a tree with large exponents
+	// can come out bigger than it went in.
+	MaxGrowth float64
+}
+
+// DefaultSimplifyBudget is the budget Simplify runs with. It only bounds
+// node visits and growth, so results stay deterministic; 2^16 visits is
+// thousands of times what a tree within the search's size limits needs,
+// but stops a giant tree from crossover or a user formula from stalling a
+// worker, and a result may at most double the input.
+var DefaultSimplifyBudget = SimplifyBudget{MaxNodes: 1 << 16, MaxGrowth: 2}
+
+// simplifyClockEvery is how many node visits pass between clock checks.
+const simplifyClockEvery = 256
+
+// SimplifyStats reports what one simplification did.
+type SimplifyStats struct {
+	Passes    int                   // rewrite passes over the tree
+	Nodes     int                   // node visits, summed over passes
+	Truncated bool                  // the budget ran out; the result is only partly simplified
+	Rejected  int                   // rule applications refused by MaxGrowth
+	Rules     [numSimplifyRules]int // times each rule fired, indexed by SimplifyRule
+}
+
+// Applied returns the rules that fired, by name.
+func (s SimplifyStats) Applied() map[string]int {
+	m := make(map[string]int)
+	for r, n := range s.Rules {
+		if n > 0 {
+			m[SimplifyRule(r).String()] = n
 		}
-		node = next
 	}
-	return node
+	return m
 }
 
-func simplifyD(node ExprNode, depth int) ExprNode {
-	if depth > maxRecurseDepth {
+// add accumulates o into s.
+func (s *SimplifyStats) add(o SimplifyStats) {
+	s.Passes += o.Passes
+	s.Nodes += o.Nodes
+	s.Rejected += o.Rejected
+	if o.Truncated {
+		s.Truncated = true
+	}
+	for r, n := range o.Rules {
+		s.Rules[r] += n
+	}
+}
+
+// simplifyTotals accumulates the stats of every uncached Simplify; see
+// SimplifyTotals.
+var simplifyTotals struct {
+	mu        sync.Mutex
+	stats     SimplifyStats
+	truncated int
+}
+
+// SimplifyTotals returns the stats of every simplification Simplify has
+// computed (cache hits are not counted), summed, and how many of them ran
+// out of budget.
+func SimplifyTotals() (SimplifyStats, int) {
+	simplifyTotals.mu.Lock()
+	defer simplifyTotals.mu.Unlock()
+	return simplifyTotals.stats, simplifyTotals.truncated
+}
+
+// SimplifyWithBudget is Simplify without the cache, bounded by b, and
+// reporting what it did. When the budget runs out mid-pass the subtrees not
+// yet visited are kept as they are, so the result is still equivalent to
+// node, just less simplified.
+func SimplifyWithBudget(node ExprNode, b SimplifyBudget) (ExprNode, SimplifyStats) {
+	out, stats, _ := simplifyWith(node, b, false)
+	return out, stats
+}
+
+// SimplifyWarnings is Simplify without the cache, also returning warnings
+// for the rewrites that can change the value at some n: x/x folded to 1
+// and 0·x or 0/x to 0, for x with n in it, which may vanish or fail to
+// evaluate there.
+func SimplifyWarnings(node ExprNode) (ExprNode, []Warning) {
+	out, _, warnings := simplifyWith(node, DefaultSimplifyBudget, true)
+	return out, warnings
+}
+
+// simplifyWith is SimplifyWithBudget, collecting warnings if warn is set.
+func simplifyWith(node ExprNode, b SimplifyBudget, warn bool) (ExprNode, SimplifyStats, []Warning) {
+	s := &simplifier{budget: b, collect: warn}
+	if b.Timeout > 0 {
+		s.deadline = time.Now().Add(b.Timeout)
+	}
+	if b.MaxGrowth > 0 {
+		in := node.NodeCount()
+		s.maxSize = max(in, int(b.MaxGrowth*float64(in)))
+	}
+	for s.stats.Passes < maxSimplifyPasses && !s.stats.Truncated {
+		s.dirty = false
+		s.stats.Passes++
+		if s.maxSize > 0 {
+			s.size = node.NodeCount()
+		}
+		node = s.rewrite(node, 0)
+		if !s.dirty {
+			break
+		}
+	}
+	simplifyTotals.mu.Lock()
+	simplifyTotals.stats.add(s.stats)
+	if s.stats.Truncated {
+		simplifyTotals.truncated++
+	}
+	simplifyTotals.mu.Unlock()
+	return node, s.stats, s.warnings
+}
+
+// simplifier is the state of one SimplifyWithBudget: the budget, and the
+// dirty flag the rules set, which ends the passes once one changes nothing.
+type simplifier struct {
+	budget   SimplifyBudget
+	deadline time.Time
+	maxSize  int // MaxGrowth as a node count; 0 is no bound
+	size     int // bound on the tree's node count so far this pass (see fits)
+	dirty    bool
+	stats    SimplifyStats
+	collect  bool // record warnings (see SimplifyWarnings)
+	warnings []Warning
+}
+
+// cancelled warns, if collecting, that x, with n in it, was dropped by how.
+func (s *simplifier) cancelled(x ExprNode, how string) {
+	if s.collect && containsVar(x) {
+		s.warnings = append(s.warnings, Warning{Kind: WarnCancel, Pos: -1,
+			Msg: fmt.Sprintf("%s: %s may vanish or fail at some n", how, x.LaTeX())})
+	}
+}
+
+// fire records that rule r rewrote the tree.
+func (s *simplifier) fire(r SimplifyRule) {
+	s.dirty = true
+	s.stats.Rules[r]++
+}
+
+// fits reports whether a rule may replace a node of old nodes with out
+// under MaxGrowth; the rules that can grow the tree ask it first. The
+// tree's size is counted at the start of each pass and raised by every
+// growth allowed; shrinking rewrites are not subtracted, so the count
+// only overestimates and the bound holds.
+func (s *simplifier) fits(old int, out ExprNode) bool {
+	if s.maxSize == 0 {
+		return true
+	}
+	d := out.NodeCount() - old
+	if d <= 0 {
+		return true
+	}
+	if s.size+d > s.maxSize {
+		s.stats.Rejected++
+		return false
+	}
+	s.size += d
+	return true
+}
+
+// exhausted counts a node visit and reports whether the budget has run
+// out; once it has, it stays out.
+func (s *simplifier) exhausted() bool {
+	if s.stats.Truncated {
+		return true
+	}
+	s.stats.Nodes++
+	if s.budget.MaxNodes > 0 && s.stats.Nodes > s.budget.MaxNodes ||
+		!s.deadline.IsZero() && s.stats.Nodes%simplifyClockEvery == 0 && time.Now().After(s.deadline) {
+		s.stats.Truncated = true
+	}
+	return s.stats.Truncated
+}
+
+func (s *simplifier) rewrite(node ExprNode, depth int) ExprNode {
+	if depth > maxRecurseDepth || s.exhausted() {
 		return node
 	}
 
 	switch n := node.(type) {
-	case *VarNode, *ConstNode:
+	case *VarNode, *ConstNode, *SymbolicConstNode:
 		return node
 
 	case *UnaryNode:
-		child := simplifyD(n.Child, depth+1)
+		child := s.rewrite(n.Child, depth+1)
 
 		// Double negation: -(-x) = x
 		if n.Op == OpNeg {
 			if inner, ok := child.(*UnaryNode); ok && inner.Op == OpNeg {
+				s.fire(ruleDoubleNeg)
 				return inner.Child
 			}
 		}
@@ -44,6 +239,7 @@ func simplifyD(node ExprNode, depth int) ExprNode {
 		// Neg of const: -(k) = -k (guard: -MinInt64 overflows)
 		if n.Op == OpNeg {
 			if c, ok := child.(*ConstNode); ok && (c.Val > math.MinInt64) {
+				s.fire(ruleNegConst)
 				return &ConstNode{Val: -c.Val}
 			}
 		}
@@ -55,6 +251,7 @@ func simplifyD(node ExprNode, depth int) ExprNode {
 				for i := int64(2); i <= c.Val; i++ {
 					result *= i
 				}
+				s.fire(ruleFactorialConst)
 				return &ConstNode{Val: result}
 			}
 		}
@@ -66,16 +263,28 @@ func simplifyD(node ExprNode, depth int) ExprNode {
 				for i := c.Val; i >= 2; i -= 2 {
 					result *= i
 				}
+				s.fire(ruleDoubleFactorialConst)
 				return &ConstNode{Val: result}
 			}
 		}
 
+		// Subfactorial of small constants
+		if n.Op == OpSubfactorial {
+			if c, ok := child.(*ConstNode); ok && c.Val >= 0 && c.Val <= 20 {
+				v, _ := subfactorialInt(c.Val)
+				s.fire(ruleSubfactorialConst)
+				return &ConstNode{Val: v.Int64()}
+			}
+		}
+
 		// AltSign constant folding
 		if n.Op == OpAltSign {
 			if c, ok := child.(*ConstNode); ok && c.Val >= 0 {
 				if c.Val%2 == 0 {
+					s.fire(ruleAltSignConst)
 					return &ConstNode{Val: 1}
 				}
+				s.fire(ruleAltSignConst)
 				return &ConstNode{Val: -1}
 			}
 		}
@@ -84,8 +293,10 @@ func simplifyD(node ExprNode, depth int) ExprNode {
 		if n.Op == OpAbs {
 			if c, ok := child.(*ConstNode); ok {
 				if c.Val < 0 {
+					s.fire(ruleAbsConst)
 					return &ConstNode{Val: -c.Val}
 				}
+				s.fire(ruleAbsConst)
 				return c
 			}
 		}
@@ -95,16 +306,81 @@ func simplifyD(node ExprNode, depth int) ExprNode {
 			if c, ok := child.(*ConstNode); ok && c.Val >= 0 {
 				root := int64(math.Sqrt(float64(c.Val)))
 				if root*root == c.Val {
+					s.fire(ruleSqrtSquare)
 					return &ConstNode{Val: root}
 				}
 			}
 		}
 
+		// tan(0) = arctan(0) = arcsin(0) = sinh(0) = tanh(0) = 0,
+		// e^0 = cosh(0) = 1
+		if c, ok := child.(*ConstNode); ok && c.Val == 0 {
+			switch n.Op {
+			case OpTan:
+				s.fire(ruleTanZero)
+				return c
+			case OpArctan:
+				s.fire(ruleArctanZero)
+				return c
+			case OpArcsin:
+				s.fire(ruleArcsinZero)
+				return c
+			case OpSinh:
+				s.fire(ruleSinhZero)
+				return c
+			case OpTanh:
+				s.fire(ruleTanhZero)
+				return c
+			case OpCosh:
+				s.fire(ruleCoshZero)
+				return &ConstNode{Val: 1}
+			case OpExp:
+				s.fire(ruleExpZero)
+				return &ConstNode{Val: 1}
+			}
+		}
+
+		// ln(e^x) = x
+		if n.Op == OpLn {
+			if inner, ok := child.(*UnaryNode); ok && inner.Op == OpExp {
+				s.fire(ruleLnExp)
+				return inner.Child
+			}
+		}
+
+		// Γ(k) = (k-1)!, for constants and for the index (n, n±j)
+		if n.Op == OpGamma {
+			if c, ok := child.(*ConstNode); ok && c.Val >= 1 && c.Val <= 21 {
+				v, _ := factorialInt(c.Val - 1)
+				s.fire(ruleGammaConst)
+				return &ConstNode{Val: v.Int64()}
+			}
+			if k, ok := indexPlus(child); ok && k > math.MinInt64 {
+				s.fire(ruleGammaIndex)
+				return &UnaryNode{Op: OpFactorial, Child: nPlus(k - 1)}
+			}
+		}
+
+		// ψ(1) = -γ
+		if c, ok := child.(*ConstNode); ok && c.Val == 1 && n.Op == OpDigamma {
+			s.fire(ruleDigammaOne)
+			return &UnaryNode{Op: OpNeg, Child: &SymbolicConstNode{Sym: SymGamma}}
+		}
+
+		// B_k is an integer only at k = 0 and odd k ≥ 3
+		if c, ok := child.(*ConstNode); ok && n.Op == OpBernoulli {
+			if c.Val == 0 || c.Val >= 3 && c.Val%2 == 1 && c.Val <= maxComputeInput {
+				v, _ := bernoulliRat(c.Val)
+				s.fire(ruleBernoulliConst)
+				return &ConstNode{Val: v.Num().Int64()}
+			}
+		}
+
 		return &UnaryNode{Op: n.Op, Child: child}
 
 	case *BinaryNode:
-		left := simplifyD(n.Left, depth+1)
-		right := simplifyD(n.Right, depth+1)
+		left := s.rewrite(n.Left, depth+1)
+		right := s.rewrite(n.Right, depth+1)
 
 		lc, lok := left.(*ConstNode)
 		rc, rok := right.(*ConstNode)
@@ -112,6 +388,7 @@ func simplifyD(node ExprNode, depth int) ExprNode {
 		// Constant folding for basic ops
 		if lok && rok {
 			if result, ok := foldConstants(n.Op, lc.Val, rc.Val); ok {
+				s.fire(ruleFold)
 				return &ConstNode{Val: result}
 			}
 		}
@@ -120,105 +397,221 @@ func simplifyD(node ExprNode, depth int) ExprNode {
 		case OpAdd:
 			// x + 0 = x
 			if rok && rc.Val == 0 {
+				s.fire(ruleAddZero)
 				return left
 			}
 			// 0 + x = x
 			if lok && lc.Val == 0 {
+				s.fire(ruleAddZero)
 				return right
 			}
 			// x + (-k) = x - k (guard: -MinInt64 overflows back to negative)
 			if rok && rc.Val < 0 && -rc.Val > 0 {
-				return simplifyD(&BinaryNode{Op: OpSub, Left: left, Right: &ConstNode{Val: -rc.Val}}, depth+1)
+				s.fire(ruleAddNeg)
+				return s.rewrite(&BinaryNode{Op: OpSub, Left: left, Right: &ConstNode{Val: -rc.Val}}, depth+1)
 			}
 			// x + neg(y) = x - y
 			if ru, ok := right.(*UnaryNode); ok && ru.Op == OpNeg {
-				return simplifyD(&BinaryNode{Op: OpSub, Left: left, Right: ru.Child}, depth+1)
+				s.fire(ruleAddNeg)
+				return s.rewrite(&BinaryNode{Op: OpSub, Left: left, Right: ru.Child}, depth+1)
 			}
 
 		case OpSub:
 			// x - 0 = x
 			if rok && rc.Val == 0 {
+				s.fire(ruleSubZero)
 				return left
 			}
 			// 0 - x = -x
 			if lok && lc.Val == 0 {
-				return simplifyD(&UnaryNode{Op: OpNeg, Child: right}, depth+1)
+				s.fire(ruleZeroSub)
+				return s.rewrite(&UnaryNode{Op: OpNeg, Child: right}, depth+1)
 			}
 			// x - (-k) = x + k (guard: -MinInt64 overflows back to negative)
 			if rok && rc.Val < 0 && -rc.Val > 0 {
-				return simplifyD(&BinaryNode{Op: OpAdd, Left: left, Right: &ConstNode{Val: -rc.Val}}, depth+1)
+				s.fire(ruleSubNeg)
+				return s.rewrite(&BinaryNode{Op: OpAdd, Left: left, Right: &ConstNode{Val: -rc.Val}}, depth+1)
 			}
 			// x - neg(y) = x + y
 			if ru, ok := right.(*UnaryNode); ok && ru.Op == OpNeg {
-				return simplifyD(&BinaryNode{Op: OpAdd, Left: left, Right: ru.Child}, depth+1)
+				s.fire(ruleSubNeg)
+				return s.rewrite(&BinaryNode{Op: OpAdd, Left: left, Right: ru.Child}, depth+1)
 			}
 			// x - x = 0 (structural equality)
-			if left.String() == right.String() {
+			if Equal(left, right) {
+				s.fire(ruleSubSelf)
 				return &ConstNode{Val: 0}
 			}
 
 		case OpMul:
 			// x * 0 = 0
 			if rok && rc.Val == 0 {
+				s.fire(ruleMulZero)
+				s.cancelled(left, "x·0 folded to 0")
 				return &ConstNode{Val: 0}
 			}
 			if lok && lc.Val == 0 {
+				s.fire(ruleMulZero)
+				s.cancelled(right, "0·x folded to 0")
 				return &ConstNode{Val: 0}
 			}
 			// x * 1 = x
 			if rok && rc.Val == 1 {
+				s.fire(ruleMulOne)
 				return left
 			}
 			// 1 * x = x
 			if lok && lc.Val == 1 {
+				s.fire(ruleMulOne)
 				return right
 			}
 			// x * (-1) = -x
 			if rok && rc.Val == -1 {
-				return simplifyD(&UnaryNode{Op: OpNeg, Child: left}, depth+1)
+				s.fire(ruleMulMinusOne)
+				return s.rewrite(&UnaryNode{Op: OpNeg, Child: left}, depth+1)
 			}
 			// (-1) * x = -x
 			if lok && lc.Val == -1 {
-				return simplifyD(&UnaryNode{Op: OpNeg, Child: right}, depth+1)
+				s.fire(ruleMulMinusOne)
+				return s.rewrite(&UnaryNode{Op: OpNeg, Child: right}, depth+1)
+			}
+			// e^a * e^b = e^(a+b)
+			lu, lexp := left.(*UnaryNode)
+			ru, rexp := right.(*UnaryNode)
+			if lexp && rexp && lu.Op == OpExp && ru.Op == OpExp {
+				s.fire(ruleExpMul)
+				return s.rewrite(&UnaryNode{Op: OpExp, Child: &BinaryNode{Op: OpAdd, Left: lu.Child, Right: ru.Child}}, depth+1)
 			}
 
 		case OpDiv:
 			// x / 1 = x
 			if rok && rc.Val == 1 {
+				s.fire(ruleDivOne)
 				return left
 			}
 			// 0 / x = 0
 			if lok && lc.Val == 0 {
+				s.fire(ruleZeroDiv)
+				s.cancelled(right, "0/x folded to 0")
 				return &ConstNode{Val: 0}
 			}
 			// x / x = 1 (structural equality, non-zero)
-			if left.String() == right.String() {
+			if Equal(left, right) {
+				s.fire(ruleDivSelf)
+				s.cancelled(left, "x/x folded to 1")
 				return &ConstNode{Val: 1}
 			}
 
 		case OpPow:
 			// x^0 = 1
 			if rok && rc.Val == 0 {
+				s.fire(rulePowZero)
 				return &ConstNode{Val: 1}
 			}
 			// x^1 = x
 			if rok && rc.Val == 1 {
+				s.fire(rulePowOne)
 				return left
 			}
-			// 0^x = 0 (for positive x)
-			if lok && lc.Val == 0 {
+			// 0^k = 0 (for positive k; 0^0 is 1)
+			if lok && lc.Val == 0 && rok && rc.Val > 0 {
+				s.fire(ruleZeroPow)
 				return &ConstNode{Val: 0}
 			}
 			// 1^x = 1
 			if lok && lc.Val == 1 {
+				s.fire(ruleOnePow)
 				return &ConstNode{Val: 1}
 			}
+
+		case OpPochhammer:
+			// (x)_0 = 1
+			if rok && rc.Val == 0 {
+				s.fire(rulePochhammerZero)
+				return &ConstNode{Val: 1}
+			}
+			// (x)_1 = x
+			if rok && rc.Val == 1 {
+				s.fire(rulePochhammerOne)
+				return left
+			}
+			// (1)_k = k!, (2)_k = (k+1)!
+			if lok && (lc.Val == 1 || lc.Val == 2) {
+				k := right
+				if lc.Val == 2 {
+					k = &BinaryNode{Op: OpAdd, Left: right, Right: &ConstNode{Val: 1}}
+				}
+				if out := (&UnaryNode{Op: OpFactorial, Child: k}); s.fits(2+right.NodeCount(), out) {
+					s.fire(rulePochhammerFactorial)
+					return s.rewrite(out, depth+1)
+				}
+			}
+
+		case OpMod:
+			// x mod ±1 = 0, for integer x
+			if rok && (rc.Val == 1 || rc.Val == -1) && nonNegativeInt(left) {
+				s.fire(ruleModOne)
+				s.cancelled(left, "x mod 1 folded to 0")
+				return &ConstNode{Val: 0}
+			}
+			// x mod x = 0
+			if Equal(left, right) {
+				s.fire(ruleModSelf)
+				s.cancelled(left, "x mod x folded to 0")
+				return &ConstNode{Val: 0}
+			}
+			// (x mod m) mod m = x mod m
+			if l, ok := left.(*BinaryNode); ok && l.Op == OpMod && Equal(l.Right, right) {
+				s.fire(ruleModMod)
+				return left
+			}
+
+		case OpRoot:
+			// root(x, 1) = x
+			if rok && rc.Val == 1 {
+				s.fire(ruleRootOne)
+				return left
+			}
+			// root(x^{jk}, k) = x^j, taken absolute where k is even and
+			// x^j may be negative
+			if l, ok := left.(*BinaryNode); ok && l.Op == OpPow && rok && rc.Val > 0 {
+				if m, ok := l.Right.(*ConstNode); ok && m.Val > 0 && m.Val%rc.Val == 0 {
+					j := m.Val / rc.Val
+					var out ExprNode = l.Left
+					if rc.Val%2 == 0 && j%2 == 1 {
+						out = &UnaryNode{Op: OpAbs, Child: out}
+					}
+					if j != 1 {
+						out = &BinaryNode{Op: OpPow, Left: out, Right: &ConstNode{Val: j}}
+					}
+					s.fire(ruleRootPow)
+					return s.rewrite(out, depth+1)
+				}
+			}
+			// root(x, 2) = sqrt(x)
+			if rok && rc.Val == 2 {
+				s.fire(ruleRootSqrt)
+				return s.rewrite(&UnaryNode{Op: OpSqrt, Child: left}, depth+1)
+			}
+		}
+
+		if out, ok := extractAltSign(n.Op, left, right); ok && s.fits(1+left.NodeCount()+right.NodeCount(), out) {
+			s.fire(ruleAltSignExtract)
+			return s.rewrite(out, depth+1)
 		}
 
 		// Canonicalize commutative ops: sort children so equivalent
 		// expressions like (n + 21) and (21 + n) get the same string.
+		// A (-1)^e factor always leads its product.
 		if n.Op == OpAdd || n.Op == OpMul {
-			if left.String() > right.String() {
+			la, ra := isAltSign(left), isAltSign(right)
+			if n.Op == OpMul && la != ra {
+				if ra {
+					s.fire(ruleCommute)
+					left, right = right, left
+				}
+			} else if left.String() > right.String() {
+				s.fire(ruleCommute)
 				left, right = right, left
 			}
 		}
@@ -230,12 +623,123 @@ func simplifyD(node ExprNode, depth int) ExprNode {
 	}
 }
 
+// extractAltSign makes alternating structure explicit in left op right
+// (children already simplified): powers of negative bases split off a
+// (-1)^e factor, and (-1)^e factors move out of products and quotients to
+// the front, where Simplify's commutative ordering keeps them:
+//
+//	(-1)^e       → (-1)^e as AltSign
+//	(-k)^e       → (-1)^e · k^e      (also (-x)^e)
+//	a · ((-1)^e · b) → (-1)^e · (a · b)
+//	((-1)^e · a) / b → (-1)^e · (a / b)
+//	a / (-1)^e     → (-1)^e · a
+//	a / ((-1)^e · b) → (-1)^e · (a / b)
+//
+// The power rules need e to be a non-negative integer, where AltSign is
+// defined, so they only fire when nonNegativeInt proves it.
+func extractAltSign(op BinaryOp, left, right ExprNode) (ExprNode, bool) {
+	alt := func(e ExprNode) ExprNode { return &UnaryNode{Op: OpAltSign, Child: e} }
+	mul := func(a, b ExprNode) ExprNode { return &BinaryNode{Op: OpMul, Left: a, Right: b} }
+
+	switch op {
+	case OpPow:
+		if !nonNegativeInt(right) {
+			return nil, false
+		}
+		switch l := left.(type) {
+		case *ConstNode:
+			if l.Val == -1 {
+				return alt(right), true
+			}
+			if l.Val < -1 && l.Val > math.MinInt64 {
+				return mul(alt(right), &BinaryNode{Op: OpPow, Left: &ConstNode{Val: -l.Val}, Right: right.Clone()}), true
+			}
+		case *UnaryNode:
+			if l.Op == OpNeg {
+				return mul(alt(right), &BinaryNode{Op: OpPow, Left: l.Child, Right: right.Clone()}), true
+			}
+		}
+
+	case OpMul:
+		if sign, rest, ok := splitAltSign(right); ok && !isAltSign(left) && rest != nil {
+			return mul(sign, mul(left, rest)), true
+		}
+		if sign, rest, ok := splitAltSign(left); ok && !isAltSign(right) && rest != nil {
+			return mul(sign, mul(rest, right)), true
+		}
+
+	case OpDiv:
+		if isAltSign(right) {
+			return mul(right, left), true
+		}
+		if sign, rest, ok := splitAltSign(right); ok {
+			return mul(sign, &BinaryNode{Op: OpDiv, Left: left, Right: rest}), true
+		}
+		if sign, rest, ok := splitAltSign(left); ok && rest != nil {
+			return mul(sign, &BinaryNode{Op: OpDiv, Left: rest, Right: right}), true
+		}
+	}
+	return nil, false
+}
+
+// isAltSign reports whether node is (-1)^e.
+func isAltSign(node ExprNode) bool {
+	u, ok := node.(*UnaryNode)
+	return ok && u.Op == OpAltSign
+}
+
+// splitAltSign splits a product led by (-1)^e into the sign and the rest.
+// A bare (-1)^e gives a nil rest.
+func splitAltSign(node ExprNode) (sign, rest ExprNode, ok bool) {
+	if isAltSign(node) {
+		return node, nil, true
+	}
+	if b, ok := node.(*BinaryNode); ok && b.Op == OpMul && isAltSign(b.Left) {
+		return b.Left, b.Right, true
+	}
+	return nil, nil, false
+}
+
+// nonNegativeInt reports whether node is a non-negative integer for every
+// n = 0, 1, 2, ... at which it is defined: n, non-negative constants, and
+// sums, products, powers, factorials, subfactorials, primes, binomials and
+// Pochhammer symbols of those, and mods of them.
+func nonNegativeInt(node ExprNode) bool {
+	switch n := node.(type) {
+	case *VarNode:
+		return true
+	case *ConstNode:
+		return n.Val >= 0
+	case *UnaryNode:
+		switch n.Op {
+		case OpFactorial, OpDoubleFactorial, OpSubfactorial, OpFibonacci, OpPrime:
+			return true // defined only at non-negative integers
+		case OpAbs:
+			return nonNegativeInt(n.Child)
+		}
+	case *BinaryNode:
+		switch n.Op {
+		case OpAdd, OpMul, OpPow:
+			return nonNegativeInt(n.Left) && nonNegativeInt(n.Right)
+		case OpBinomial:
+			return true
+		case OpPochhammer:
+			return nonNegativeInt(n.Left) // k is a non-negative integer wherever (a)_k is defined
+		case OpMod:
+			return nonNegativeInt(n.Left) && nonNegativeInt(n.Right)
+		}
+	}
+	return false
+}
+
 func foldConstants(op BinaryOp, a, b int64) (int64, bool) {
 	switch op {
 	case OpAdd:
-		return a + b, true
+		r := a + b
+		return r, (r > a) == (b > 0)
 	case OpSub:
-		return a - b, true
+		r := a - b
+		return r, (r < a) == (b > 0)
 	case OpMul:
 		// Check for overflow
 		if a != 0 && b != 0 {
@@ -262,45 +766,115 @@ func foldConstants(op BinaryOp, a, b int64) (int64, bool) {
 			return 0, false
 		}
 		result := int64(1)
-		base := a
 		for i := int64(0); i < b; i++ {
-			result *= base
+			var ok bool
+			if result, ok = foldConstants(OpMul, result, a); !ok {
+				return 0, false
+			}
 		}
 		return result, true
+	case OpPochhammer:
+		if b < 0 || b > 20 {
+			return 0, false
+		}
+		result := int64(1)
+		for i := int64(0); i < b; i++ {
+			x, ok := foldConstants(OpAdd, a, i)
+			if !ok {
+				return 0, false
+			}
+			if result, ok = foldConstants(OpMul, result, x); !ok {
+				return 0, false
+			}
+		}
+		return result, true
+	case OpMod:
+		if b == 0 {
+			return 0, false
+		}
+		r := a % b
+		if r < 0 {
+			if b < 0 {
+				r -= b
+			} else {
+				r += b
+			}
+		}
+		return r, true
+	case OpRoot:
+		// Only perfect powers fold.
+		if b < 1 || (a < 0 && b%2 == 0) {
+			return 0, false
+		}
+		r, ok := exactRoot(new(big.Int).Abs(big.NewInt(a)), b)
+		if !ok {
+			return 0, false
+		}
+		if a < 0 {
+			r.Neg(r)
+		}
+		return r.Int64(), true
 	default:
 		return 0, false
 	}
 }
 
 // SimplifyBigFloat evaluates constant subtrees and replaces them with ConstNodes.
-// This recursively finds subtrees with no VarNode and evaluates them.
+// This recursively finds subtrees with no VarNode and evaluates them;
+// subtrees with a SymbolicConstNode are kept, since folding would round
+// them away.
 func SimplifyBigFloat(node ExprNode, prec uint) ExprNode {
-	node = Simplify(node)
-	node = foldConstantSubtrees(node, prec)
-	node = Simplify(node) // second pass to clean up after folding
-	return node
+	key := simplifyKey{hash: Hash(node), fold: true, prec: prec}
+	if out, ok := defaultSimplifyCache.get(key, node); ok {
+		return out
+	}
+	out := Simplify(node)
+	out = foldConstantSubtrees(out, prec, nil)
+	out = Simplify(out) // second pass to clean up after folding
+	defaultSimplifyCache.put(key, node, out)
+	return out
 }
 
-func foldConstantSubtrees(node ExprNode, prec uint) ExprNode {
-	return foldConstantSubtreesD(node, prec, 0)
+// SimplifyBigFloatWarnings is SimplifyBigFloat without the cache, also
+// returning the warnings of SimplifyWarnings and one for each constant
+// subtree rounded to an integer when folded.
+func SimplifyBigFloatWarnings(node ExprNode, prec uint) (ExprNode, []Warning) {
+	out, warnings := SimplifyWarnings(node)
+	out = foldConstantSubtrees(out, prec, &warnings)
+	out, more := SimplifyWarnings(out)
+	return out, append(warnings, more...)
 }
 
-func foldConstantSubtreesD(node ExprNode, prec uint, depth int) ExprNode {
+// foldConstantSubtrees folds the constant subtrees of node, appending a
+// warning to warnings, if not nil, for each that is rounded.
+func foldConstantSubtrees(node ExprNode, prec uint, warnings *[]Warning) ExprNode {
+	return foldConstantSubtreesD(node, prec, warnings, 0)
+}
+
+func foldConstantSubtreesD(node ExprNode, prec uint, warnings *[]Warning, depth int) ExprNode {
 	if depth > maxRecurseDepth {
 		return node
 	}
 
-	if !containsVar(node) {
+	if !containsVar(node) && !containsSymbol(node) {
 		dummyN := new(big.Float).SetPrec(prec).SetInt64(0)
 		if val, ok := node.Eval(dummyN, prec); ok {
 			if iv, ok := toInt64Approx(val); ok {
 				return &ConstNode{Val: iv}
 			}
-			// Non-integer constant subtree (e.g. 1/(-13) + 9 ≈ 8.923):
-			// round to nearest integer so the GA can work with a clean constant.
-			// TODO: support rational constants (e.g. RatNode{Num, Den}) so we
-			// can fold 1/3 + 1 to 4/3 instead of rounding to 1.
+			// Non-integer constant subtree: an exact rational (1/(-13) + 9)
+			// folds to \frac{116}{13}; anything else (√2) is rounded to the
+			// nearest integer so the GA can work with a clean constant.
+			if r, ok := EvalRat(node, 0); ok {
+				if folded, ok := ratNode(r); ok {
+					return folded
+				}
+			}
 			if iv, ok := roundToInt64(val); ok {
+				if warnings != nil {
+					*warnings = append(*warnings, Warning{Kind: WarnLossyFold, Pos: -1,
+						Msg: fmt.Sprintf("%s = %s rounded to %d", node.LaTeX(), val.Text('g', 10), iv)})
+				}
 				return &ConstNode{Val: iv}
 			}
 		}
@@ -309,11 +883,11 @@ func foldConstantSubtreesD(node ExprNode, prec uint, depth int) ExprNode {
 
 	switch n := node.(type) {
 	case *UnaryNode:
-		return &UnaryNode{Op: n.Op, Child: foldConstantSubtreesD(n.Child, prec, depth+1)}
+		return &UnaryNode{Op: n.Op, Child: foldConstantSubtreesD(n.Child, prec, warnings, depth+1)}
 	case *BinaryNode:
 		return &BinaryNode{Op: n.Op,
-			Left:  foldConstantSubtreesD(n.Left, prec, depth+1),
-			Right: foldConstantSubtreesD(n.Right, prec, depth+1),
+			Left:  foldConstantSubtreesD(n.Left, prec, warnings, depth+1),
+			Right: foldConstantSubtreesD(n.Right, prec, warnings, depth+1),
 		}
 	default:
 		return node
@@ -382,3 +956,40 @@ func roundToInt64(f *big.Float) (int64, bool) {
 	}
 	return i, true
 }
+
+// indexPlus reports whether node is n, n+k, k+n or n-k for a constant k,
+// and k.
+func indexPlus(node ExprNode) (int64, bool) {
+	if _, ok := node.(*VarNode); ok {
+		return 0, true
+	}
+	b, ok := node.(*BinaryNode)
+	if !ok {
+		return 0, false
+	}
+	_, lvar := b.Left.(*VarNode)
+	_, rvar := b.Right.(*VarNode)
+	lc, lconst := b.Left.(*ConstNode)
+	rc, rconst := b.Right.(*ConstNode)
+	switch {
+	case b.Op == OpAdd && lvar && rconst:
+		return rc.Val, true
+	case b.Op == OpAdd && lconst && rvar:
+		return lc.Val, true
+	case b.Op == OpSub && lvar && rconst && rc.Val > math.MinInt64:
+		return -rc.Val, true
+	}
+	return 0, false
+}
+
+// nPlus returns n+k, written as n, n + k or n - |k|.
+func nPlus(k int64) ExprNode {
+	switch {
+	case k == 0:
+		return &VarNode{}
+	case k > 0:
+		return &BinaryNode{Op: OpAdd, Left: &VarNode{}, Right: &ConstNode{Val: k}}
+	default:
+		return &BinaryNode{Op: OpSub, Left: &VarNode{}, Right: &ConstNode{Val: -k}}
+	}
+}
@@ -0,0 +1,129 @@
+package expr
+
+import "sync"
+
+// simplifyCacheSize bounds the number of entries kept per cache generation.
+// The cache holds at most two generations, so the worst case is twice this.
+const simplifyCacheSize = 1 << 15
+
+// CacheStats reports simplification cache effectiveness.
+type CacheStats struct {
+	Hits    uint64
+	Misses  uint64
+	Entries int
+}
+
+// HitRate returns the fraction of lookups served from the cache.
+func (s CacheStats) HitRate() float64 {
+	total := s.Hits + s.Misses
+	if total == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(total)
+}
+
+type simplifyKey struct {
+	hash uint64
+	fold bool // SimplifyBigFloat rather than Simplify
+	prec uint // fold precision; zero when fold is false
+}
+
+type simplifyEntry struct {
+	in, out ExprNode
+}
+
+// simplifyCache memoizes simplification results across the population.
+// Identical subtrees show up constantly (elites, crossover siblings, the
+// same random leaves), so most children hit an entry computed earlier.
+//
+// Eviction is generational: when the current map fills up it becomes the
+// previous generation and a fresh map starts. Lookups that hit the previous
+// generation are promoted, so hot entries survive indefinitely.
+//
+// Trees are persistent, so entries share nodes with the trees they were
+// built from and are handed out without copying.
+type simplifyCache struct {
+	mu     sync.Mutex
+	cur    map[simplifyKey]simplifyEntry
+	prev   map[simplifyKey]simplifyEntry
+	hits   uint64
+	misses uint64
+}
+
+var defaultSimplifyCache = newSimplifyCache()
+
+func newSimplifyCache() *simplifyCache {
+	return &simplifyCache{cur: make(map[simplifyKey]simplifyEntry)}
+}
+
+func (c *simplifyCache) get(key simplifyKey, node ExprNode) (ExprNode, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.cur[key]; ok && Equal(e.in, node) {
+		c.hits++
+		return e.out, true
+	}
+	if e, ok := c.prev[key]; ok && Equal(e.in, node) {
+		c.hits++
+		c.insertLocked(key, e)
+		return e.out, true
+	}
+	c.misses++
+	return nil, false
+}
+
+func (c *simplifyCache) put(key simplifyKey, in, out ExprNode) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.insertLocked(key, simplifyEntry{in: in, out: out})
+}
+
+func (c *simplifyCache) insertLocked(key simplifyKey, e simplifyEntry) {
+	if len(c.cur) >= simplifyCacheSize {
+		c.prev = c.cur
+		c.cur = make(map[simplifyKey]simplifyEntry, simplifyCacheSize)
+	}
+	c.cur[key] = e
+}
+
+func (c *simplifyCache) stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return CacheStats{Hits: c.hits, Misses: c.misses, Entries: len(c.cur) + len(c.prev)}
+}
+
+func (c *simplifyCache) reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cur = make(map[simplifyKey]simplifyEntry)
+	c.prev = nil
+	c.hits, c.misses = 0, 0
+}
+
+// evict drops the entries, keeping the counters, and returns how many it
+// dropped.
+func (c *simplifyCache) evict() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := len(c.cur) + len(c.prev)
+	c.cur = make(map[simplifyKey]simplifyEntry)
+	c.prev = nil
+	return n
+}
+
+// SimplifyCacheStats returns hit/miss counters for the shared simplification cache.
+func SimplifyCacheStats() CacheStats {
+	return defaultSimplifyCache.stats()
+}
+
+// ResetSimplifyCache drops all cached simplifications and zeroes the counters.
+func ResetSimplifyCache() {
+	defaultSimplifyCache.reset()
+}
+
+// EvictSimplifyCache drops all cached simplifications, freeing the trees
+// only they hold, and returns how many entries it dropped. Unlike
+// ResetSimplifyCache it keeps the hit and miss counters.
+func EvictSimplifyCache() int {
+	return defaultSimplifyCache.evict()
+}
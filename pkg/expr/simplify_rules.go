@@ -0,0 +1,80 @@
+package expr
+
+import "fmt"
+
+// SimplifyRule identifies a rewrite rule of Simplify, for SimplifyStats.
+type SimplifyRule int
+
+const (
+	ruleDoubleNeg            SimplifyRule = iota // -(-x) = x
+	ruleNegConst                                 // -(k) = -k
+	ruleFactorialConst                           // k! folded, k ≤ 20
+	ruleDoubleFactorialConst                     // k!! folded, k ≤ 20
+	ruleAltSignConst                             // (-1)^k folded
+	ruleAbsConst                                 // |k| folded
+	ruleSqrtSquare                               // sqrt(k²) = k
+	ruleFold                                     // k op j folded
+	ruleAddZero                                  // x + 0, 0 + x
+	ruleAddNeg                                   // x + (-k), x + -y
+	ruleSubZero                                  // x - 0
+	ruleZeroSub                                  // 0 - x
+	ruleSubNeg                                   // x - (-k), x - -y
+	ruleSubSelf                                  // x - x
+	ruleMulZero                                  // x · 0, 0 · x
+	ruleMulOne                                   // x · 1, 1 · x
+	ruleMulMinusOne                              // x · -1, -1 · x
+	ruleDivOne                                   // x / 1
+	ruleZeroDiv                                  // 0 / x
+	ruleDivSelf                                  // x / x
+	rulePowZero                                  // x^0
+	rulePowOne                                   // x^1
+	ruleZeroPow                                  // 0^x
+	ruleOnePow                                   // 1^x
+	ruleAltSignExtract                           // see extractAltSign
+	ruleCommute                                  // operands of + and · reordered
+	ruleTanZero                                  // tan(0) = 0
+	ruleExpZero                                  // e^0 = 1
+	ruleLnExp                                    // ln(e^x) = x
+	ruleExpMul                                   // e^a · e^b = e^{a+b}
+	ruleGammaConst                               // Γ(k) = (k-1)! folded, 1 ≤ k ≤ 21
+	ruleGammaIndex                               // Γ(n+k) = (n+k-1)!
+	ruleBernoulliConst                           // B_k folded where it is an integer: B_0 = 1, B_k = 0 for odd k ≥ 3
+	rulePochhammerZero                           // (x)_0 = 1
+	rulePochhammerOne                            // (x)_1 = x
+	rulePochhammerFactorial                      // (1)_k = k!, (2)_k = (k+1)!
+	ruleModOne                                   // x mod ±1 = 0 for integer x
+	ruleModSelf                                  // x mod x = 0
+	ruleModMod                                   // (x mod m) mod m = x mod m
+	ruleRootOne                                  // root(x, 1) = x
+	ruleRootSqrt                                 // root(x, 2) = sqrt(x)
+	ruleRootPow                                  // root(x^{jk}, k) = x^j, or |x|^j for even k and odd j
+	ruleArctanZero                               // arctan(0) = 0
+	ruleArcsinZero                               // arcsin(0) = 0
+	ruleSinhZero                                 // sinh(0) = 0
+	ruleCoshZero                                 // cosh(0) = 1
+	ruleTanhZero                                 // tanh(0) = 0
+	ruleDigammaOne                               // ψ(1) = -γ
+	ruleSubfactorialConst                        // !k folded, k ≤ 20
+	numSimplifyRules
+)
+
+var simplifyRuleNames = [numSimplifyRules]string{
+	"double-neg", "neg-const", "factorial-const", "double-factorial-const",
+	"altsign-const", "abs-const", "sqrt-square", "fold",
+	"add-zero", "add-neg", "sub-zero", "zero-sub", "sub-neg", "sub-self",
+	"mul-zero", "mul-one", "mul-minus-one", "div-one", "zero-div", "div-self",
+	"pow-zero", "pow-one", "zero-pow", "one-pow", "altsign-extract", "commute",
+	"tan-zero", "exp-zero", "ln-exp", "exp-mul", "gamma-const", "gamma-index",
+	"bernoulli-const", "pochhammer-zero", "pochhammer-one", "pochhammer-factorial",
+	"mod-one", "mod-self", "mod-mod", "root-one", "root-sqrt", "root-pow",
+	"arctan-zero", "arcsin-zero", "sinh-zero", "cosh-zero", "tanh-zero",
+	"digamma-one",
+	"subfactorial-const",
+}
+
+func (r SimplifyRule) String() string {
+	if r < 0 || r >= numSimplifyRules {
+		return fmt.Sprintf("SimplifyRule(%d)", int(r))
+	}
+	return simplifyRuleNames[r]
+}
@@ -0,0 +1,97 @@
+package expr
+
+import (
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/wildfunctions/genetic_series/pkg/constants"
+)
+
+// Symbol identifies a symbolic constant leaf.
+type Symbol int
+
+const (
+	SymPi Symbol = iota
+	SymE
+	SymPhi
+	SymGamma // Euler–Mascheroni γ
+	numSymbols
+)
+
+// symbolDef describes a Symbol: ID is its stable name, LaTeX and Typst
+// how it prints (and LaTeX how it parses), Constant its pkg/constants
+// name. The bytecode stores the Symbol itself, so new symbols are
+// appended.
+type symbolDef struct {
+	ID, LaTeX, Typst, Constant string
+}
+
+var symbolDefs = [numSymbols]symbolDef{
+	SymPi:    {"pi", `\pi`, "pi", "pi"},
+	SymE:     {"e", "e", "e", "e"},
+	SymPhi:   {"phi", `\phi`, "phi", "phi"},
+	SymGamma: {"gamma", `\gamma`, "gamma", "euler_gamma"},
+}
+
+// symbolF64 holds each symbol's float64 value.
+var symbolF64 [numSymbols]float64
+
+func init() {
+	for s, d := range symbolDefs {
+		c := constants.Get(d.Constant)
+		if c == nil {
+			panic("expr: no constant " + d.Constant + " for symbol " + d.ID)
+		}
+		symbolF64[s] = c.Float64Value
+	}
+}
+
+func (s Symbol) String() string {
+	if s < 0 || s >= numSymbols {
+		return fmt.Sprintf("Symbol(%d)", int(s))
+	}
+	return symbolDefs[s].ID
+}
+
+// Symbols returns every Symbol, in order.
+func Symbols() []Symbol {
+	out := make([]Symbol, numSymbols)
+	for i := range out {
+		out[i] = Symbol(i)
+	}
+	return out
+}
+
+// symbolCache holds each symbol's value per precision.
+var symbolCache sync.Map // symbolKey → *big.Float
+
+type symbolKey struct {
+	sym  Symbol
+	prec uint
+}
+
+// symbolValue returns s at prec, from pkg/constants. The result must not
+// be modified.
+func symbolValue(s Symbol, prec uint) *big.Float {
+	k := symbolKey{s, prec}
+	if v, ok := symbolCache.Load(k); ok {
+		return v.(*big.Float)
+	}
+	v := constants.Get(symbolDefs[s].Constant).At(prec)
+	symbolCache.Store(k, v)
+	return v
+}
+
+// containsSymbol reports whether node has a SymbolicConstNode leaf.
+func containsSymbol(node ExprNode) bool {
+	switch n := node.(type) {
+	case *SymbolicConstNode:
+		return true
+	case *UnaryNode:
+		return containsSymbol(n.Child)
+	case *BinaryNode:
+		return containsSymbol(n.Left) || containsSymbol(n.Right)
+	}
+	return false
+}
@@ -0,0 +1,331 @@
+package expr
+
+import "math/big"
+
+// guardBits is the extra working precision carried by incremental state so
+// that rounding error accumulated over thousands of steps stays well below
+// one ulp of the caller's precision.
+const guardBits = 64
+
+// maxAffineCoeff bounds the coefficients accepted by affineIn so that
+// a*n + b cannot overflow int64 for any n the evaluator will see.
+const maxAffineCoeff = 1 << 20
+
+// maxPolyDegree bounds k for incremental (an+b)^k; the difference table holds
+// k+1 big.Ints per node.
+const maxPolyDegree = 32
+
+// TermEvaluator evaluates an expression at consecutive integers n, carrying
+// state between calls so that subterms like c^n, n^k, (an+b)!, (an+b)!!,
+// F_{an+b} and (-1)^n cost O(1) big-number operations per term instead of
+// being recomputed from scratch. Calls in any
+// other order are still correct; they just reinitialize the state.
+//
+// A TermEvaluator is not safe for concurrent use.
+type TermEvaluator struct {
+	root term
+	prec uint
+	nf   *big.Float
+}
+
+// NewTermEvaluator prepares node for evaluation at precision prec.
+func NewTermEvaluator(node ExprNode, prec uint) *TermEvaluator {
+	return &TermEvaluator{
+		root: compileTerm(node, prec),
+		prec: prec,
+		nf:   new(big.Float).SetPrec(prec),
+	}
+}
+
+// Eval returns the value of the expression at n, with the same semantics as
+// ExprNode.Eval. The result is owned by the caller and may be passed to
+// ReleaseFloat.
+func (t *TermEvaluator) Eval(n int64) (*big.Float, bool) {
+	t.nf.SetInt64(n)
+	return t.root.eval(n, t.nf, t.prec)
+}
+
+// term is a compiled expression node. n and nf carry the same value.
+type term interface {
+	eval(n int64, nf *big.Float, prec uint) (*big.Float, bool)
+}
+
+// exprTerm evaluates a subtree with no incremental structure directly.
+type exprTerm struct{ node ExprNode }
+
+func (e exprTerm) eval(_ int64, nf *big.Float, prec uint) (*big.Float, bool) {
+	return e.node.Eval(nf, prec)
+}
+
+type unaryTerm struct {
+	op    *UnaryNode
+	child term
+}
+
+func (u unaryTerm) eval(n int64, nf *big.Float, prec uint) (*big.Float, bool) {
+	child, ok := u.child.eval(n, nf, prec)
+	if !ok {
+		return nil, false
+	}
+	result, ok := u.op.apply(child, prec)
+	if result != child {
+		ReleaseFloat(child)
+	}
+	return result, ok
+}
+
+type binaryTerm struct {
+	op          *BinaryNode
+	left, right term
+}
+
+func (b binaryTerm) eval(n int64, nf *big.Float, prec uint) (*big.Float, bool) {
+	left, ok := b.left.eval(n, nf, prec)
+	if !ok {
+		return nil, false
+	}
+	right, ok := b.right.eval(n, nf, prec)
+	if !ok {
+		ReleaseFloat(left)
+		return nil, false
+	}
+	result, ok := b.op.apply(left, right, prec)
+	if result != left {
+		ReleaseFloat(left)
+	}
+	ReleaseFloat(right)
+	return result, ok
+}
+
+// compileTerm builds the term tree for node, replacing recognized power and
+// sequence patterns with incremental terms. Subtrees without any such pattern are
+// left as plain ExprNodes.
+func compileTerm(node ExprNode, prec uint) term {
+	switch nd := node.(type) {
+	case *UnaryNode:
+		if t := compileSeq(nd); t != nil {
+			return t
+		}
+		child := compileTerm(nd.Child, prec)
+		if _, plain := child.(exprTerm); plain {
+			return exprTerm{node}
+		}
+		return unaryTerm{op: nd, child: child}
+
+	case *BinaryNode:
+		if nd.Op == OpPow {
+			if t := compilePow(nd, prec); t != nil {
+				return t
+			}
+		}
+		left := compileTerm(nd.Left, prec)
+		right := compileTerm(nd.Right, prec)
+		_, lp := left.(exprTerm)
+		_, rp := right.(exprTerm)
+		if lp && rp {
+			return exprTerm{node}
+		}
+		return binaryTerm{op: nd, left: left, right: right}
+	}
+	return exprTerm{node}
+}
+
+// compilePow recognizes c^(an+b) for a constant base c and (an+b)^k for a
+// constant integer k. It returns nil if neither applies.
+func compilePow(p *BinaryNode, prec uint) term {
+	if !containsVar(p.Left) {
+		a, b, ok := affineIn(p.Right)
+		if !ok || a == 0 {
+			return nil
+		}
+		base, ok := p.Left.Eval(bigZero, prec+guardBits)
+		if !ok || base.Sign() == 0 {
+			return nil
+		}
+		return &expPowTerm{base: base, a: a, b: b}
+	}
+	if !containsVar(p.Right) {
+		a, b, ok := affineIn(p.Left)
+		if !ok || a == 0 {
+			return nil
+		}
+		kf, ok := p.Right.Eval(bigZero, prec)
+		if !ok {
+			return nil
+		}
+		k, ok := toInt64(kf)
+		ReleaseFloat(kf)
+		if !ok || k < -maxPolyDegree || k > maxPolyDegree {
+			return nil
+		}
+		return &polyPowTerm{a: a, b: b, k: k}
+	}
+	return nil
+}
+
+// affineIn reports whether node is a*n + b for integer constants a and b.
+func affineIn(node ExprNode) (a, b int64, ok bool) {
+	a, b, ok = affineInD(node, 0)
+	if !ok || a < -maxAffineCoeff || a > maxAffineCoeff || b < -maxAffineCoeff || b > maxAffineCoeff {
+		return 0, 0, false
+	}
+	return a, b, true
+}
+
+func affineInD(node ExprNode, depth int) (a, b int64, ok bool) {
+	if depth > maxRecurseDepth {
+		return 0, 0, false
+	}
+	bound := func(v int64) bool { return v >= -maxAffineCoeff && v <= maxAffineCoeff }
+	switch nd := node.(type) {
+	case *VarNode:
+		return 1, 0, true
+	case *ConstNode:
+		return 0, nd.Val, bound(nd.Val)
+	case *UnaryNode:
+		if nd.Op != OpNeg {
+			return 0, 0, false
+		}
+		a, b, ok := affineInD(nd.Child, depth+1)
+		return -a, -b, ok
+	case *BinaryNode:
+		la, lb, ok := affineInD(nd.Left, depth+1)
+		if !ok {
+			return 0, 0, false
+		}
+		ra, rb, ok := affineInD(nd.Right, depth+1)
+		if !ok {
+			return 0, 0, false
+		}
+		switch nd.Op {
+		case OpAdd:
+			a, b = la+ra, lb+rb
+		case OpSub:
+			a, b = la-ra, lb-rb
+		case OpMul:
+			if la != 0 && ra != 0 {
+				return 0, 0, false // quadratic
+			}
+			a, b = la*rb+ra*lb, lb*rb
+		default:
+			return 0, 0, false
+		}
+		return a, b, bound(a) && bound(b)
+	}
+	return 0, 0, false
+}
+
+// expPowTerm computes c^(an+b), multiplying by c^a for each step in n.
+type expPowTerm struct {
+	base *big.Float // c, at working precision
+	a, b int64
+
+	valid bool
+	last  int64
+	val   *big.Float // c^(a*last+b)
+	step  *big.Float // c^a
+}
+
+func (p *expPowTerm) eval(n int64, _ *big.Float, prec uint) (*big.Float, bool) {
+	e := p.a*n + p.b
+	if e > 10000 || e < -10000 {
+		p.valid = false
+		return nil, false // same domain as intPow
+	}
+	switch {
+	case p.valid && n == p.last:
+	case p.valid && n == p.last+1:
+		p.val.Mul(p.val, p.step)
+	default:
+		if !p.init(e, prec) {
+			return nil, false
+		}
+	}
+	p.last = n
+	return newFloat(prec).Set(p.val), true
+}
+
+func (p *expPowTerm) init(e int64, prec uint) bool {
+	wp := prec + guardBits
+	val, ok := powInt64(p.base, e, wp)
+	if !ok {
+		return false
+	}
+	step, ok := powInt64(p.base, p.a, wp)
+	if !ok {
+		return false
+	}
+	ReleaseFloat(p.val)
+	ReleaseFloat(p.step)
+	p.val, p.step, p.valid = val, step, true
+	return true
+}
+
+// powInt64 returns base^e for any integer e, or false if base is zero and
+// e is negative.
+func powInt64(base *big.Float, e int64, prec uint) (*big.Float, bool) {
+	if e >= 0 {
+		return intPow(base, e, prec)
+	}
+	if base.Sign() == 0 {
+		return nil, false
+	}
+	pos, ok := intPow(base, -e, prec)
+	if !ok {
+		return nil, false
+	}
+	return pos.Quo(bigOne, pos), true
+}
+
+// polyPowTerm computes (an+b)^k exactly with a forward difference table,
+// so each step is k big.Int additions.
+type polyPowTerm struct {
+	a, b, k int64
+
+	valid bool
+	last  int64
+	diffs []*big.Int // diffs[i] = Δ^i p(last), p(n) = (an+b)^|k|
+}
+
+func (p *polyPowTerm) eval(n int64, _ *big.Float, prec uint) (*big.Float, bool) {
+	switch {
+	case p.valid && n == p.last:
+	case p.valid && n == p.last+1:
+		for i := 0; i < len(p.diffs)-1; i++ {
+			p.diffs[i].Add(p.diffs[i], p.diffs[i+1])
+		}
+	default:
+		p.init(n)
+	}
+	p.last = n
+
+	v := p.diffs[0]
+	if p.k >= 0 {
+		return newFloat(prec).SetInt(v), true
+	}
+	if v.Sign() == 0 {
+		return nil, false
+	}
+	r := newFloat(prec).SetInt(v)
+	return r.Quo(bigOne, r), true
+}
+
+func (p *polyPowTerm) init(n int64) {
+	k := p.k
+	if k < 0 {
+		k = -k
+	}
+	// Values p(n), p(n+1), ..., p(n+k), then difference in place.
+	d := make([]*big.Int, k+1)
+	for j := int64(0); j <= k; j++ {
+		x := big.NewInt(p.a*(n+j) + p.b)
+		d[j] = new(big.Int).Exp(x, big.NewInt(k), nil)
+	}
+	for i := int64(1); i <= k; i++ {
+		for j := k; j >= i; j-- {
+			d[j].Sub(d[j], d[j-1])
+		}
+	}
+	p.diffs = d
+	p.valid = true
+}
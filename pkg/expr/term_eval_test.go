@@ -0,0 +1,123 @@
+package expr
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestTermEvaluatorMatchesEval(t *testing.T) {
+	n := &VarNode{}
+	c := func(v int64) ExprNode { return &ConstNode{Val: v} }
+	affine := func(a, b int64) ExprNode {
+		return &BinaryNode{Op: OpAdd, Left: &BinaryNode{Op: OpMul, Left: c(a), Right: n}, Right: c(b)}
+	}
+	pow := func(l, r ExprNode) ExprNode { return &BinaryNode{Op: OpPow, Left: l, Right: r} }
+
+	tests := []struct {
+		name string
+		node ExprNode
+	}{
+		{"2^n", pow(c(2), n)},
+		{"396^(4n)", pow(c(396), &BinaryNode{Op: OpMul, Left: c(4), Right: n})},
+		{"3^(5-2n)", pow(c(3), affine(-2, 5))},
+		{"sqrt(8)^n", pow(&UnaryNode{Op: OpSqrt, Child: c(8)}, n)},
+		{"(-3)^n", pow(c(-3), n)},
+		{"n^2", pow(n, c(2))},
+		{"(2n+1)^3", pow(affine(2, 1), c(3))},
+		{"(n-3)^5", pow(&BinaryNode{Op: OpSub, Left: n, Right: c(3)}, c(5))},
+		{"(n+1)^-2", pow(affine(1, 1), c(-2))},
+		{"n^0", pow(n, c(0))},
+		{"(2n)!/(2^n * n^3)", &BinaryNode{Op: OpDiv,
+			Left:  &UnaryNode{Op: OpFactorial, Child: affine(2, 0)},
+			Right: &BinaryNode{Op: OpMul, Left: pow(c(2), n), Right: pow(n, c(3))},
+		}},
+		{"n^n is not incremental", pow(n, n)},
+		{"n!", &UnaryNode{Op: OpFactorial, Child: n}},
+		{"(3n+1)!", &UnaryNode{Op: OpFactorial, Child: affine(3, 1)}},
+		{"(n-4)!", &UnaryNode{Op: OpFactorial, Child: affine(1, -4)}},
+		{"(25n)! leaves the domain", &UnaryNode{Op: OpFactorial, Child: affine(25, 0)}},
+		{"n!!", &UnaryNode{Op: OpDoubleFactorial, Child: n}},
+		{"(2n+1)!!", &UnaryNode{Op: OpDoubleFactorial, Child: affine(2, 1)}},
+		{"(3n-2)!!", &UnaryNode{Op: OpDoubleFactorial, Child: affine(3, -2)}},
+		{"F_n", &UnaryNode{Op: OpFibonacci, Child: n}},
+		{"F_{3n+2}", &UnaryNode{Op: OpFibonacci, Child: affine(3, 2)}},
+		{"F_{20n} leaves the domain", &UnaryNode{Op: OpFibonacci, Child: affine(20, 0)}},
+		{"(-1)^n", &UnaryNode{Op: OpAltSign, Child: n}},
+		{"(-1)^(3n+1)", &UnaryNode{Op: OpAltSign, Child: affine(3, 1)}},
+		{"(-1)^(5-n)", &UnaryNode{Op: OpAltSign, Child: affine(-1, 5)}},
+		{"(-1)^n F_n / (2n)!!", &BinaryNode{Op: OpDiv,
+			Left:  &BinaryNode{Op: OpMul, Left: &UnaryNode{Op: OpAltSign, Child: n}, Right: &UnaryNode{Op: OpFibonacci, Child: n}},
+			Right: &UnaryNode{Op: OpDoubleFactorial, Child: affine(2, 0)},
+		}},
+	}
+
+	const prec = 256
+	eps := new(big.Float).SetMantExp(big.NewFloat(1), -(prec - 8))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			te := NewTermEvaluator(tt.node, prec)
+			// Sequential run, then a jump backwards to exercise reinitialization.
+			ns := make([]int64, 0, 64)
+			for i := int64(0); i < 60; i++ {
+				ns = append(ns, i)
+			}
+			ns = append(ns, 7, 7, 8, 40)
+			for _, i := range ns {
+				nf := new(big.Float).SetPrec(prec).SetInt64(i)
+				want, wantOK := tt.node.Eval(nf, prec)
+				got, gotOK := te.Eval(i)
+				if gotOK != wantOK {
+					t.Fatalf("n=%d: ok = %v, want %v", i, gotOK, wantOK)
+				}
+				if !gotOK {
+					continue
+				}
+				diff := new(big.Float).Sub(got, want)
+				if want.Sign() != 0 {
+					diff.Quo(diff, want)
+				}
+				if diff.Abs(diff).Cmp(eps) > 0 {
+					t.Errorf("n=%d: got %s, want %s", i, got.Text('g', 30), want.Text('g', 30))
+				}
+			}
+		})
+	}
+}
+
+func TestTermEvaluatorPowDomain(t *testing.T) {
+	// 2^(n) beyond the intPow exponent bound must fail exactly as Eval does.
+	node := &BinaryNode{Op: OpPow, Left: &ConstNode{Val: 2}, Right: &BinaryNode{Op: OpMul, Left: &ConstNode{Val: 1000}, Right: &VarNode{}}}
+	te := NewTermEvaluator(node, 64)
+	for i := int64(0); i <= 12; i++ {
+		_, ok := te.Eval(i)
+		if want := i <= 10; ok != want {
+			t.Errorf("n=%d: ok = %v, want %v", i, ok, want)
+		}
+	}
+}
+
+func TestTermEvaluatorSequencesLongRun(t *testing.T) {
+	// Step across the whole memoized domain and past its end; rounding error
+	// in the incremental state must stay below the output precision.
+	const prec = 128
+	eps := new(big.Float).SetMantExp(big.NewFloat(1), -(prec - 2))
+	for _, op := range []UnaryOp{OpFactorial, OpDoubleFactorial, OpFibonacci} {
+		node := &UnaryNode{Op: op, Child: &VarNode{}}
+		te := NewTermEvaluator(node, prec)
+		for i := int64(0); i <= maxComputeInput+5; i++ {
+			want, wantOK := node.Eval(new(big.Float).SetPrec(prec).SetInt64(i), prec)
+			got, gotOK := te.Eval(i)
+			if gotOK != wantOK {
+				t.Fatalf("%s n=%d: ok = %v, want %v", node, i, gotOK, wantOK)
+			}
+			if !gotOK || want.Sign() == 0 {
+				continue
+			}
+			diff := new(big.Float).Sub(got, want)
+			diff.Quo(diff, want)
+			if diff.Abs(diff).Cmp(eps) > 0 {
+				t.Fatalf("%s n=%d: relative error %s", node, i, diff.Text('e', 3))
+			}
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package expr
+
+import "math/big"
+
+// compileSeq recognizes op(an+b) for the integer sequence ops and returns an
+// incremental term for it, or nil. Arguments outside [0, maxComputeInput]
+// fail exactly as Eval does.
+func compileSeq(u *UnaryNode) term {
+	switch u.Op {
+	case OpAltSign, OpFactorial, OpDoubleFactorial, OpFibonacci:
+	default:
+		return nil
+	}
+	a, b, ok := affineIn(u.Child)
+	if !ok || a == 0 {
+		return nil
+	}
+	switch u.Op {
+	case OpAltSign:
+		return altSignTerm{a: a, b: b}
+	case OpFactorial:
+		if a > 0 {
+			return &factTerm{a: a, b: b}
+		}
+	case OpDoubleFactorial:
+		if a > 0 {
+			return &dfactTerm{a: a, b: b}
+		}
+	case OpFibonacci:
+		if a > 0 && a < maxComputeInput {
+			return &fibTerm{a: a, b: b}
+		}
+	}
+	return nil
+}
+
+// altSignTerm computes (-1)^(an+b) from the parity of an integer, without
+// evaluating the argument in big.Float at all.
+type altSignTerm struct{ a, b int64 }
+
+func (s altSignTerm) eval(n int64, _ *big.Float, prec uint) (*big.Float, bool) {
+	e := s.a*n + s.b
+	if e < 0 {
+		return nil, false
+	}
+	if e%2 == 0 {
+		return newFloat(prec).SetInt64(1), true
+	}
+	return newFloat(prec).SetInt64(-1), true
+}
+
+// seqArg returns an+b if it is a valid sequence index.
+func seqArg(a, b, n int64) (int64, bool) {
+	e := a*n + b
+	return e, e >= 0 && e <= maxComputeInput
+}
+
+// mulRange sets x to x * lo * (lo+step) * ... * hi, batching the small
+// factors into int64 products so that most steps cost one big.Float Mul.
+func mulRange(x *big.Float, lo, hi, step int64, scratch *big.Float) {
+	p := int64(1)
+	for k := lo; k <= hi; k += step {
+		if p > (1<<62)/k {
+			x.Mul(x, scratch.SetInt64(p))
+			p = 1
+		}
+		p *= k
+	}
+	if p != 1 {
+		x.Mul(x, scratch.SetInt64(p))
+	}
+}
+
+// factTerm computes (an+b)! for a > 0, multiplying in the a new factors at
+// each step in n.
+type factTerm struct {
+	a, b int64
+
+	valid   bool
+	last    int64
+	val     *big.Float // (a*last+b)! at working precision
+	scratch *big.Float
+}
+
+func (f *factTerm) eval(n int64, _ *big.Float, prec uint) (*big.Float, bool) {
+	e, ok := seqArg(f.a, f.b, n)
+	if !ok {
+		f.valid = false
+		return nil, false
+	}
+	switch {
+	case f.valid && n == f.last:
+	case f.valid && n == f.last+1:
+		mulRange(f.val, e-f.a+1, e, 1, f.scratch)
+	default:
+		v, _ := factorialInt(e)
+		f.init(prec)
+		f.val.SetInt(v)
+	}
+	f.last = n
+	return newFloat(prec).Set(f.val), true
+}
+
+func (f *factTerm) init(prec uint) {
+	if f.val == nil {
+		f.val = new(big.Float).SetPrec(prec + guardBits)
+		f.scratch = new(big.Float).SetPrec(64)
+	}
+	f.valid = true
+}
+
+// dfactTerm computes (an+b)!! for a > 0. Consecutive arguments can differ
+// in parity, so it steps from the value two terms back, whose argument is
+// 2a lower and of the same parity: (e+a)!! = (e-a)!! * (e-a+2)...(e+a).
+type dfactTerm struct {
+	a, b int64
+
+	valid   bool
+	last    int64
+	cur     *big.Float // (a*last+b)!!
+	prev    *big.Float // (a*last+b-a)!!, if prevOK
+	prevOK  bool
+	scratch *big.Float
+}
+
+func (d *dfactTerm) eval(n int64, _ *big.Float, prec uint) (*big.Float, bool) {
+	e, ok := seqArg(d.a, d.b, n)
+	if !ok {
+		d.valid = false
+		return nil, false
+	}
+	switch {
+	case d.valid && n == d.last:
+	case d.valid && n == d.last+1 && d.prevOK:
+		mulRange(d.prev, e-2*d.a+2, e, 2, d.scratch)
+		d.prev, d.cur = d.cur, d.prev
+	case d.valid && n == d.last+1:
+		v, _ := doubleFactorialInt(e)
+		d.prev, d.cur = d.cur, d.prev
+		d.cur.SetInt(v)
+		d.prevOK = true
+	default:
+		d.init(prec)
+		v, _ := doubleFactorialInt(e)
+		d.cur.SetInt(v)
+		pv, ok := doubleFactorialInt(e - d.a)
+		if ok {
+			d.prev.SetInt(pv)
+		}
+		d.prevOK = ok
+	}
+	d.last = n
+	return newFloat(prec).Set(d.cur), true
+}
+
+func (d *dfactTerm) init(prec uint) {
+	if d.cur == nil {
+		d.cur = new(big.Float).SetPrec(prec + guardBits)
+		d.prev = new(big.Float).SetPrec(prec + guardBits)
+		d.scratch = new(big.Float).SetPrec(64)
+	}
+	d.valid = true
+}
+
+// fibTerm computes F(an+b) for a > 0 by carrying the pair (F_e, F_{e+1})
+// and advancing it with F_{e+a} = F_{a-1} F_e + F_a F_{e+1} and
+// F_{e+a+1} = F_a F_e + F_{a+1} F_{e+1}.
+type fibTerm struct {
+	a, b int64
+
+	valid  bool
+	last   int64
+	f0, f1 *big.Float // F_e, F_{e+1} at working precision
+	c0, c1 *big.Float // F_{a-1}, F_a
+	c2     *big.Float // F_{a+1}
+	t0, t1 *big.Float
+}
+
+func (f *fibTerm) eval(n int64, _ *big.Float, prec uint) (*big.Float, bool) {
+	e, ok := seqArg(f.a, f.b, n)
+	if !ok {
+		f.valid = false
+		return nil, false
+	}
+	switch {
+	case f.valid && n == f.last:
+	case f.valid && n == f.last+1 && f.a == 1:
+		f.f0.Add(f.f0, f.f1)
+		f.f0, f.f1 = f.f1, f.f0
+	case f.valid && n == f.last+1:
+		f.t0.Mul(f.c0, f.f0)
+		f.t1.Mul(f.c1, f.f1)
+		f.t0.Add(f.t0, f.t1) // F_{e}
+		f.t1.Mul(f.c1, f.f0)
+		f.f1.Mul(f.c2, f.f1)
+		f.f1.Add(f.f1, f.t1) // F_{e+1}
+		f.f0, f.t0 = f.t0, f.f0
+	default:
+		f.init(prec)
+		v, _ := fibonacciInt(e)
+		f.f0.SetInt(v)
+		// F_{e+1} is only needed to step forward, and any step from
+		// e = maxComputeInput leaves the domain anyway.
+		if v, ok := fibonacciInt(e + 1); ok {
+			f.f1.SetInt(v)
+		}
+	}
+	f.last = n
+	return newFloat(prec).Set(f.f0), true
+}
+
+func (f *fibTerm) init(prec uint) {
+	if f.f0 == nil {
+		wp := prec + guardBits
+		f.f0 = new(big.Float).SetPrec(wp)
+		f.f1 = new(big.Float).SetPrec(wp)
+		f.t0 = new(big.Float).SetPrec(wp)
+		f.t1 = new(big.Float).SetPrec(wp)
+		c0, _ := fibonacciInt(f.a - 1)
+		c1, _ := fibonacciInt(f.a)
+		c2, _ := fibonacciInt(f.a + 1)
+		f.c0 = new(big.Float).SetPrec(wp).SetInt(c0)
+		f.c1 = new(big.Float).SetPrec(wp).SetInt(c1)
+		f.c2 = new(big.Float).SetPrec(wp).SetInt(c2)
+	}
+	f.valid = true
+}
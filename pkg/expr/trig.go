@@ -0,0 +1,87 @@
+package expr
+
+import (
+	"math"
+	"math/big"
+)
+
+// maxTrigExp bounds the binary exponent of a trig argument: reducing x
+// mod π/2 needs π to as many bits past prec as x has before the point.
+const maxTrigExp = 1 << 16
+
+// bigSinCos computes sin x and cos x at prec. x is reduced by the nearest
+// multiple k of π/2, the remainder r, |r| ≤ π/4, halved s times to sum
+// the Taylor series of sin in a few dozen terms, and the double-angle
+// formulas applied s times; k mod 4 then picks the signs and which is
+// which. It fails past maxTrigExp.
+func bigSinCos(x *big.Float, prec uint) (sin, cos *big.Float, ok bool) {
+	if x.IsInf() || x.MantExp(nil) > maxTrigExp {
+		return nil, nil, false
+	}
+	s := int(math.Sqrt(float64(prec)) / 2)
+	wp := prec + uint(s) + 32
+	// π/2 to wp bits past the ones of x, rounded up to a multiple of 64
+	// so bigPi's cache holds few precisions.
+	pp := (wp + uint(max(0, x.MantExp(nil))) + 63) &^ 63
+	halfPi := new(big.Float).SetPrec(pp).SetMantExp(bigPi(pp), -1)
+	r := new(big.Float).SetPrec(pp).Quo(x, halfPi)
+	if r.Sign() >= 0 {
+		r.Add(r, big.NewFloat(0.5))
+	} else {
+		r.Sub(r, big.NewFloat(0.5))
+	}
+	k, _ := r.Int(nil)
+	r.SetInt(k)
+	r.Sub(x, r.Mul(r, halfPi))
+	r.SetPrec(wp).SetMantExp(r, -s)
+
+	// sin r/2^s by its Taylor series, cos from it, then s doublings.
+	sn := new(big.Float).SetPrec(wp).Set(r)
+	term := new(big.Float).SetPrec(wp).Set(r)
+	r2 := new(big.Float).SetPrec(wp).Mul(r, r)
+	d := new(big.Float).SetPrec(wp)
+	for j := int64(2); term.Sign() != 0; j += 2 {
+		term.Mul(term, r2)
+		term.Quo(term, d.SetInt64(j*(j+1)))
+		term.Neg(term)
+		if term.MantExp(nil) < sn.MantExp(nil)-int(wp) {
+			break
+		}
+		sn.Add(sn, term)
+	}
+	one := new(big.Float).SetPrec(wp).SetInt64(1)
+	cs := new(big.Float).SetPrec(wp).Mul(sn, sn)
+	cs = bigSqrt(cs.Sub(one, cs), wp)
+	t := new(big.Float).SetPrec(wp)
+	for i := 0; i < s; i++ {
+		// sin 2y = 2 sin y cos y, cos 2y = 1 - 2 sin² y
+		t.Mul(sn, sn)
+		sn.Mul(sn, cs)
+		sn.SetMantExp(sn, 1)
+		cs.Sub(one, t.SetMantExp(t, 1))
+	}
+
+	q := new(big.Int).And(k, big.NewInt(3)).Int64()
+	if q == 1 || q == 3 {
+		sn, cs = cs, sn
+	}
+	if q == 1 || q == 2 {
+		cs.Neg(cs)
+	}
+	if q == 2 || q == 3 {
+		sn.Neg(sn)
+	}
+	return newFloat(prec).Set(sn), newFloat(prec).Set(cs), true
+}
+
+// bigTan computes tan x = sin x / cos x at prec, failing where bigSinCos
+// does.
+func bigTan(x *big.Float, prec uint) (*big.Float, bool) {
+	sin, cos, ok := bigSinCos(x, prec)
+	if !ok || cos.Sign() == 0 {
+		return nil, false
+	}
+	sin.Quo(sin, cos)
+	ReleaseFloat(cos)
+	return sin, true
+}
@@ -0,0 +1,128 @@
+package expr
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Typst renders node in Typst math syntax. Typst has no invisible
+// grouping like LaTeX's braces, so parentheses are written only where
+// precedence needs them, and around fraction operands and exponents,
+// which Typst strips when it typesets them.
+func Typst(node ExprNode) string {
+	s, _ := typst(node)
+	return s
+}
+
+// Typst precedence of a rendering: what it can be an operand of without
+// parentheses.
+const (
+	typstSum     = iota + 1 // a + b, a - b
+	typstNeg                // -a, -3
+	typstProduct            // a dot b
+	typstFrac               // (a)/(b)
+	typstPostfix            // a^(b), a!, e^(a)
+	typstAtom               // n, 3, f(a)
+)
+
+// typstUnary holds the functions unary ops render as, f(x); ops missing
+// from it are handled in typst.
+var typstUnary = map[UnaryOp]string{
+	OpSin:     "sin",
+	OpCos:     "cos",
+	OpTan:     "tan",
+	OpLn:      "ln",
+	OpFloor:   "floor",
+	OpCeil:    "ceil",
+	OpAbs:     "abs",
+	OpSqrt:    "sqrt",
+	OpGamma:   "Gamma",
+	OpArctan:  "arctan",
+	OpArcsin:  "arcsin",
+	OpSinh:    "sinh",
+	OpCosh:    "cosh",
+	OpTanh:    "tanh",
+	OpDigamma: "psi",
+}
+
+// typst returns node's rendering and its precedence.
+func typst(node ExprNode) (string, int) {
+	switch n := node.(type) {
+	case *VarNode:
+		return "n", typstAtom
+	case *ConstNode:
+		if n.Val < 0 {
+			return strconv.FormatInt(n.Val, 10), typstNeg
+		}
+		return strconv.FormatInt(n.Val, 10), typstAtom
+	case *SymbolicConstNode:
+		return symbolDefs[n.Sym].Typst, typstAtom
+	case *UnaryNode:
+		child, p := typst(n.Child)
+		if f, ok := typstUnary[n.Op]; ok {
+			return f + "(" + child + ")", typstAtom
+		}
+		switch n.Op {
+		case OpNeg:
+			return "-" + typstWrap(child, p, typstProduct), typstNeg
+		case OpFactorial:
+			return typstWrap(child, p, typstAtom) + "!", typstPostfix
+		case OpDoubleFactorial:
+			return typstWrap(child, p, typstAtom) + "!!", typstPostfix
+		case OpSubfactorial:
+			return "!" + typstWrap(child, p, typstAtom), typstPostfix
+		case OpAltSign:
+			return "(-1)^(" + child + ")", typstPostfix
+		case OpFibonacci:
+			return "F_(" + child + ")", typstPostfix
+		case OpBernoulli:
+			return "B_(" + child + ")", typstPostfix
+		case OpPrime:
+			return "p_(" + child + ")", typstPostfix
+		case OpExp:
+			return "e^(" + child + ")", typstPostfix
+		}
+		if def, ok := customUnary[n.Op]; ok {
+			return fmt.Sprintf("op(%q)(%s)", def.ID, child), typstAtom
+		}
+		return child, p // an unknown op renders as its child, as in LaTeX
+	case *BinaryNode:
+		left, lp := typst(n.Left)
+		right, rp := typst(n.Right)
+		switch n.Op {
+		case OpAdd:
+			if rp == typstNeg {
+				right = "(" + right + ")"
+			}
+			return left + " + " + right, typstSum
+		case OpSub:
+			return left + " - " + typstWrap(right, rp, typstProduct), typstSum
+		case OpMul:
+			return typstWrap(left, lp, typstNeg) + " dot " + typstWrap(right, rp, typstProduct), typstProduct
+		case OpDiv:
+			return "(" + left + ")/(" + right + ")", typstFrac
+		case OpPow:
+			return typstWrap(left, lp, typstAtom) + "^(" + right + ")", typstPostfix
+		case OpBinomial:
+			return "binom(" + left + ", " + right + ")", typstAtom
+		case OpPochhammer:
+			return "(" + left + ")_(" + right + ")", typstPostfix
+		case OpMod:
+			return typstWrap(left, lp, typstNeg) + " mod " + typstWrap(right, rp, typstFrac), typstProduct
+		case OpRoot:
+			return "root(" + right + ", " + left + ")", typstAtom
+		}
+		if def, ok := customBinary[n.Op]; ok {
+			return fmt.Sprintf("op(%q)(%s, %s)", def.ID, left, right), typstAtom
+		}
+	}
+	return "", typstAtom
+}
+
+// typstWrap parenthesizes s, of precedence p, if it is below min.
+func typstWrap(s string, p, min int) string {
+	if p < min {
+		return "(" + s + ")"
+	}
+	return s
+}
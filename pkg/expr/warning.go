@@ -0,0 +1,51 @@
+package expr
+
+import (
+	"fmt"
+	"io"
+)
+
+// WarningKind classifies a Warning.
+type WarningKind int
+
+const (
+	WarnImplicitMul WarningKind = iota // juxtaposition read as a product that may have meant one number or an index
+	WarnLossyFold                      // a constant folded to a value it only approximates
+	WarnDeprecated                     // syntax accepted for compatibility that TeX reads differently
+	WarnCancel                         // a subtree with n cancelled or dropped, though it may vanish or fail at some n
+	numWarningKinds
+)
+
+var warningKindNames = [numWarningKinds]string{"implicit-mul", "lossy-fold", "deprecated", "cancel"}
+
+func (k WarningKind) String() string {
+	if k < 0 || k >= numWarningKinds {
+		return fmt.Sprintf("WarningKind(%d)", int(k))
+	}
+	return warningKindNames[k]
+}
+
+// Warning is a non-fatal note from parsing or simplification: input that
+// was accepted, or a tree that was rewritten, in a way that can change the
+// value from what was meant. Pos is the byte offset in the parsed input,
+// or -1 for a warning from Simplify.
+type Warning struct {
+	Kind WarningKind
+	Pos  int
+	Msg  string
+}
+
+func (w Warning) String() string {
+	if w.Pos < 0 {
+		return fmt.Sprintf("%s [%s]", w.Msg, w.Kind)
+	}
+	return fmt.Sprintf("pos %d: %s [%s]", w.Pos, w.Msg, w.Kind)
+}
+
+// WriteWarnings writes warnings to w one per line, as the commands print
+// them to stderr, each prefixed with label.
+func WriteWarnings(w io.Writer, label string, warnings []Warning) {
+	for _, warning := range warnings {
+		fmt.Fprintf(w, "%swarning: %s\n", label, warning)
+	}
+}
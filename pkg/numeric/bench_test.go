@@ -0,0 +1,35 @@
+package numeric
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+)
+
+// benchmarkBackend times the operations that dominate high-precision
+// evaluation. Run with -tags mpfr to compare against BenchmarkMPFR.
+func benchmarkBackend[T any](b *testing.B, be Backend[T]) {
+	for _, prec := range []uint{4096, 65536} {
+		third := new(big.Float).SetPrec(prec).Quo(big.NewFloat(1), big.NewFloat(3))
+		x := be.SetBig(be.New(prec), third)
+		y := be.Sqrt(be.New(prec), be.SetInt64(be.New(prec), 7))
+		z := be.New(prec)
+		ops := []struct {
+			name string
+			f    func()
+		}{
+			{"Mul", func() { be.Mul(z, x, y) }},
+			{"Quo", func() { be.Quo(z, x, y) }},
+			{"Sqrt", func() { be.Sqrt(z, y) }},
+		}
+		for _, op := range ops {
+			b.Run(fmt.Sprintf("%s/prec%d", op.name, prec), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					op.f()
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkBigFloat(b *testing.B) { benchmarkBackend[*big.Float](b, BigFloat{}) }
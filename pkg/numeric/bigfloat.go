@@ -0,0 +1,65 @@
+package numeric
+
+import (
+	"math/big"
+	"sync"
+)
+
+// BigFloat is the math/big backend. It is always available.
+type BigFloat struct{}
+
+var _ Backend[*big.Float] = BigFloat{}
+
+var bigFloatPool = sync.Pool{
+	New: func() any { return new(big.Float) },
+}
+
+func (BigFloat) Name() string { return "big" }
+
+func (BigFloat) New(prec uint) *big.Float {
+	f := bigFloatPool.Get().(*big.Float)
+	f.SetPrec(prec)
+	return f.SetInt64(0)
+}
+
+func (BigFloat) Release(x *big.Float) {
+	if x != nil {
+		bigFloatPool.Put(x)
+	}
+}
+
+func (BigFloat) Set(z, x *big.Float) *big.Float                { return z.Set(x) }
+func (BigFloat) SetInt64(z *big.Float, v int64) *big.Float     { return z.SetInt64(v) }
+func (BigFloat) SetFloat64(z *big.Float, v float64) *big.Float { return z.SetFloat64(v) }
+func (BigFloat) SetInt(z *big.Float, v *big.Int) *big.Float    { return z.SetInt(v) }
+func (BigFloat) SetBig(z, x *big.Float) *big.Float             { return z.Set(x) }
+
+func (BigFloat) Big(x *big.Float) *big.Float {
+	return new(big.Float).Copy(x)
+}
+
+func (BigFloat) Float64(x *big.Float) float64 {
+	f, _ := x.Float64()
+	return f
+}
+
+func (BigFloat) Int64(x *big.Float) (int64, bool) {
+	if !x.IsInt() {
+		return 0, false
+	}
+	i, acc := x.Int64()
+	return i, acc == big.Exact
+}
+
+func (BigFloat) Add(z, x, y *big.Float) *big.Float { return z.Add(x, y) }
+func (BigFloat) Sub(z, x, y *big.Float) *big.Float { return z.Sub(x, y) }
+func (BigFloat) Mul(z, x, y *big.Float) *big.Float { return z.Mul(x, y) }
+func (BigFloat) Quo(z, x, y *big.Float) *big.Float { return z.Quo(x, y) }
+func (BigFloat) Neg(z, x *big.Float) *big.Float    { return z.Neg(x) }
+func (BigFloat) Abs(z, x *big.Float) *big.Float    { return z.Abs(x) }
+
+// Sqrt uses math/big's Sqrt, which is correctly rounded.
+func (BigFloat) Sqrt(z, x *big.Float) *big.Float { return z.Sqrt(x) }
+
+func (BigFloat) Sign(x *big.Float) int   { return x.Sign() }
+func (BigFloat) Cmp(x, y *big.Float) int { return x.Cmp(y) }
@@ -0,0 +1,212 @@
+//go:build mpfr && cgo
+
+package numeric
+
+/*
+#cgo LDFLAGS: -lmpfr -lgmp
+#include <stdlib.h>
+#include <gmp.h>
+#include <mpfr.h>
+
+// Some MPFR entry points are macros; wrap them so cgo can call them.
+static int gs_sgn(mpfr_srcptr x) { return mpfr_sgn(x); }
+static int gs_number_p(mpfr_srcptr x) { return mpfr_number_p(x); }
+static int gs_integer_p(mpfr_srcptr x) { return mpfr_integer_p(x); }
+static int gs_fits_slong_p(mpfr_srcptr x) { return mpfr_fits_slong_p(x, MPFR_RNDN); }
+
+// gs_set_words sets x from a little-endian magnitude of n words of size sz.
+static void gs_set_words(mpfr_ptr x, const void *w, size_t n, size_t sz, int neg) {
+	mpz_t z;
+	mpz_init(z);
+	mpz_import(z, n, -1, sz, 0, 0, w);
+	if (neg) {
+		mpz_neg(z, z);
+	}
+	mpfr_set_z(x, z, MPFR_RNDN);
+	mpz_clear(z);
+}
+*/
+import "C"
+
+import (
+	"math/big"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"unsafe"
+)
+
+// MPFR is a GNU MPFR backend, built with -tags mpfr (requires libmpfr and
+// libgmp headers). All operations round to nearest.
+type MPFR struct{}
+
+var _ Backend[*MPFRFloat] = MPFR{}
+
+// MPFRFloat is an MPFR number. Released values are recycled; the C storage
+// is freed by a finalizer once the Go value is collected.
+type MPFRFloat struct {
+	x C.mpfr_t
+}
+
+var mpfrPool = sync.Pool{
+	New: func() any {
+		f := new(MPFRFloat)
+		C.mpfr_init2(f.ptr(), C.mpfr_prec_t(64))
+		runtime.SetFinalizer(f, (*MPFRFloat).free)
+		return f
+	},
+}
+
+func (f *MPFRFloat) ptr() *C.__mpfr_struct { return &f.x[0] }
+
+func (f *MPFRFloat) free() { C.mpfr_clear(f.ptr()) }
+
+const rndn = C.MPFR_RNDN
+
+func (MPFR) Name() string { return "mpfr" }
+
+func (MPFR) New(prec uint) *MPFRFloat {
+	f := mpfrPool.Get().(*MPFRFloat)
+	if uint(C.mpfr_get_prec(f.ptr())) != prec {
+		C.mpfr_set_prec(f.ptr(), C.mpfr_prec_t(prec))
+	}
+	C.mpfr_set_si(f.ptr(), 0, rndn)
+	return f
+}
+
+func (MPFR) Release(x *MPFRFloat) {
+	if x != nil {
+		mpfrPool.Put(x)
+	}
+}
+
+func (MPFR) Set(z, x *MPFRFloat) *MPFRFloat {
+	C.mpfr_set(z.ptr(), x.ptr(), rndn)
+	return z
+}
+
+func (MPFR) SetInt64(z *MPFRFloat, v int64) *MPFRFloat {
+	C.mpfr_set_si(z.ptr(), C.long(v), rndn)
+	return z
+}
+
+func (MPFR) SetFloat64(z *MPFRFloat, v float64) *MPFRFloat {
+	C.mpfr_set_d(z.ptr(), C.double(v), rndn)
+	return z
+}
+
+func (MPFR) SetInt(z *MPFRFloat, v *big.Int) *MPFRFloat {
+	words := v.Bits()
+	if len(words) == 0 {
+		C.mpfr_set_si(z.ptr(), 0, rndn)
+		return z
+	}
+	neg := C.int(0)
+	if v.Sign() < 0 {
+		neg = 1
+	}
+	C.gs_set_words(z.ptr(), unsafe.Pointer(&words[0]), C.size_t(len(words)), C.size_t(unsafe.Sizeof(words[0])), neg)
+	return z
+}
+
+// SetBig converts exactly through big.Float's hexadecimal mantissa form,
+// then rounds once to z's precision.
+func (m MPFR) SetBig(z *MPFRFloat, x *big.Float) *MPFRFloat {
+	if x.IsInf() {
+		C.mpfr_set_inf(z.ptr(), C.int(x.Sign()))
+		return z
+	}
+	return m.setString(z, x.Text('p', 0), 0)
+}
+
+func (MPFR) setString(z *MPFRFloat, s string, base int) *MPFRFloat {
+	cs := C.CString(s)
+	defer C.free(unsafe.Pointer(cs))
+	C.mpfr_set_str(z.ptr(), cs, C.int(base), rndn)
+	return z
+}
+
+// Big converts x exactly (hex digits, binary exponent) to a big.Float at
+// x's precision. NaN converts to zero; callers check Sign/Cmp domains first.
+func (MPFR) Big(x *MPFRFloat) *big.Float {
+	prec := uint(C.mpfr_get_prec(x.ptr()))
+	out := new(big.Float).SetPrec(prec)
+	if C.gs_number_p(x.ptr()) == 0 {
+		if s := C.gs_sgn(x.ptr()); s != 0 {
+			out.SetInf(s < 0)
+		}
+		return out
+	}
+	if C.gs_sgn(x.ptr()) == 0 {
+		return out
+	}
+	var exp C.mpfr_exp_t
+	cs := C.mpfr_get_str(nil, &exp, 16, 0, x.ptr(), rndn)
+	digits := C.GoString(cs)
+	C.mpfr_free_str(cs)
+
+	// x = 0.digits × 16^exp
+	neg := strings.HasPrefix(digits, "-")
+	digits = strings.TrimPrefix(digits, "-")
+	s := "0x." + digits + "p" + strconv.FormatInt(4*int64(exp), 10)
+	if neg {
+		s = "-" + s
+	}
+	out.SetString(s)
+	return out
+}
+
+func (MPFR) Float64(x *MPFRFloat) float64 {
+	return float64(C.mpfr_get_d(x.ptr(), rndn))
+}
+
+func (MPFR) Int64(x *MPFRFloat) (int64, bool) {
+	if C.gs_integer_p(x.ptr()) == 0 || C.gs_fits_slong_p(x.ptr()) == 0 {
+		return 0, false
+	}
+	return int64(C.mpfr_get_si(x.ptr(), rndn)), true
+}
+
+func (MPFR) Add(z, x, y *MPFRFloat) *MPFRFloat {
+	C.mpfr_add(z.ptr(), x.ptr(), y.ptr(), rndn)
+	return z
+}
+
+func (MPFR) Sub(z, x, y *MPFRFloat) *MPFRFloat {
+	C.mpfr_sub(z.ptr(), x.ptr(), y.ptr(), rndn)
+	return z
+}
+
+func (MPFR) Mul(z, x, y *MPFRFloat) *MPFRFloat {
+	C.mpfr_mul(z.ptr(), x.ptr(), y.ptr(), rndn)
+	return z
+}
+
+func (MPFR) Quo(z, x, y *MPFRFloat) *MPFRFloat {
+	C.mpfr_div(z.ptr(), x.ptr(), y.ptr(), rndn)
+	return z
+}
+
+func (MPFR) Neg(z, x *MPFRFloat) *MPFRFloat {
+	C.mpfr_neg(z.ptr(), x.ptr(), rndn)
+	return z
+}
+
+func (MPFR) Abs(z, x *MPFRFloat) *MPFRFloat {
+	C.mpfr_abs(z.ptr(), x.ptr(), rndn)
+	return z
+}
+
+func (MPFR) Sqrt(z, x *MPFRFloat) *MPFRFloat {
+	C.mpfr_sqrt(z.ptr(), x.ptr(), rndn)
+	return z
+}
+
+func (MPFR) Sign(x *MPFRFloat) int {
+	return int(C.gs_sgn(x.ptr()))
+}
+
+func (MPFR) Cmp(x, y *MPFRFloat) int {
+	return int(C.mpfr_cmp(x.ptr(), y.ptr()))
+}
@@ -0,0 +1,56 @@
+//go:build mpfr && cgo
+
+package numeric
+
+import (
+	"math/big"
+	"testing"
+)
+
+func BenchmarkMPFR(b *testing.B) { benchmarkBackend[*MPFRFloat](b, MPFR{}) }
+
+func TestMPFRRoundTrip(t *testing.T) {
+	const prec = 1024
+	m := MPFR{}
+	values := []*big.Float{
+		new(big.Float).SetPrec(prec).Quo(big.NewFloat(1), big.NewFloat(3)),
+		new(big.Float).SetPrec(prec).SetInt64(-12345),
+		new(big.Float).SetPrec(prec).SetMantExp(big.NewFloat(-0.75), -3000),
+		new(big.Float).SetPrec(prec),
+	}
+	for _, v := range values {
+		x := m.SetBig(m.New(prec), v)
+		if got := m.Big(x); got.Cmp(v) != 0 {
+			t.Errorf("round trip %s: got %s", v.Text('g', 20), got.Text('g', 20))
+		}
+		m.Release(x)
+	}
+
+	fact, _ := new(big.Int).SetString("-93326215443944152681699238856266700490715968264381621468592963895217599993229915608941463976156518286253697920827223758251185210916864000000000000000000000000", 10)
+	x := m.SetInt(m.New(prec), fact)
+	want := new(big.Float).SetPrec(prec).SetInt(fact)
+	if got := m.Big(x); got.Cmp(want) != 0 {
+		t.Errorf("SetInt(-100!): got %s", got.Text('g', 20))
+	}
+}
+
+func TestMPFRArithmeticMatchesBigFloat(t *testing.T) {
+	const prec = 4096
+	m, bf := MPFR{}, BigFloat{}
+	x := m.Sqrt(m.New(prec), m.SetInt64(m.New(prec), 2))
+	y := bf.Sqrt(bf.New(prec), bf.SetInt64(bf.New(prec), 2))
+	if got := m.Big(x); got.Cmp(y) != 0 {
+		t.Errorf("sqrt(2) differs: %s vs %s", got.Text('g', 40), y.Text('g', 40))
+	}
+	m.Quo(x, x, m.SetInt64(m.New(prec), 7))
+	bf.Quo(y, y, bf.SetInt64(bf.New(prec), 7))
+	if got := m.Big(x); got.Cmp(y) != 0 {
+		t.Errorf("sqrt(2)/7 differs: %s vs %s", got.Text('g', 40), y.Text('g', 40))
+	}
+	if v, ok := m.Int64(m.SetInt64(x, -42)); !ok || v != -42 {
+		t.Errorf("Int64 = %d, %v", v, ok)
+	}
+	if _, ok := m.Int64(m.SetFloat64(x, 0.5)); ok {
+		t.Error("Int64(0.5) should fail")
+	}
+}
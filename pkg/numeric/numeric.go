@@ -0,0 +1,40 @@
+// Package numeric abstracts the arbitrary-precision float type used for
+// evaluation so that math/big can be swapped for a faster library (MPFR,
+// behind the mpfr build tag) without duplicating the evaluator.
+package numeric
+
+import "math/big"
+
+// Backend is arithmetic over an arbitrary-precision binary float type T.
+// Methods follow math/big conventions: z is the receiver, it is set to the
+// result and returned, and it may alias the operands. Values are created by
+// New and handed back with Release once no longer needed.
+type Backend[T any] interface {
+	Name() string
+
+	New(prec uint) T
+	Release(x T)
+
+	Set(z, x T) T
+	SetInt64(z T, v int64) T
+	SetFloat64(z T, v float64) T
+	SetInt(z T, v *big.Int) T
+	SetBig(z T, x *big.Float) T
+
+	// Big converts x to a big.Float at x's precision.
+	Big(x T) *big.Float
+	Float64(x T) float64
+	// Int64 returns x as an int64 if it is an integer in range.
+	Int64(x T) (int64, bool)
+
+	Add(z, x, y T) T
+	Sub(z, x, y T) T
+	Mul(z, x, y T) T
+	Quo(z, x, y T) T
+	Neg(z, x T) T
+	Abs(z, x T) T
+	Sqrt(z, x T) T
+
+	Sign(x T) int
+	Cmp(x, y T) int
+}
@@ -1,9 +1,8 @@
 package pool
 
 import (
-	"math/rand"
-
 	"github.com/wildfunctions/genetic_series/pkg/expr"
+	"github.com/wildfunctions/genetic_series/pkg/random"
 )
 
 func init() {
@@ -16,7 +15,7 @@ type ConservativePool struct{}
 
 func (p *ConservativePool) Name() string { return "conservative" }
 
-func (p *ConservativePool) RandomLeaf(rng *rand.Rand) expr.ExprNode {
+func (p *ConservativePool) RandomLeaf(rng random.Rand) expr.ExprNode {
 	if rng.Float64() < 0.4 {
 		return &expr.VarNode{}
 	}
@@ -29,7 +28,7 @@ var conservativeUnary = []expr.UnaryOp{
 	expr.OpNeg,
 }
 
-func (p *ConservativePool) RandomUnary(rng *rand.Rand) expr.UnaryOp {
+func (p *ConservativePool) RandomUnary(rng random.Rand) expr.UnaryOp {
 	return conservativeUnary[rng.Intn(len(conservativeUnary))]
 }
 
@@ -40,10 +39,10 @@ var conservativeBinary = []expr.BinaryOp{
 	expr.OpDiv,
 }
 
-func (p *ConservativePool) RandomBinary(rng *rand.Rand) expr.BinaryOp {
+func (p *ConservativePool) RandomBinary(rng random.Rand) expr.BinaryOp {
 	return conservativeBinary[rng.Intn(len(conservativeBinary))]
 }
 
-func (p *ConservativePool) RandomTree(rng *rand.Rand, maxDepth int) expr.ExprNode {
-	return randomTree(p, rng, maxDepth)
+func (p *ConservativePool) RandomTree(rng random.Rand, maxDepth int) expr.ExprNode {
+	return GrowTree(p, rng, maxDepth)
 }
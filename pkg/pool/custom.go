@@ -0,0 +1,45 @@
+package pool
+
+import (
+	"github.com/wildfunctions/genetic_series/pkg/expr"
+	"github.com/wildfunctions/genetic_series/pkg/random"
+)
+
+// customOpShare is the fraction of op draws a pool with custom ops gives
+// to them, split evenly among the custom ops of that arity.
+const customOpShare = 0.2
+
+// customPool adds the ops registered with expr.RegisterOp to another pool.
+type customPool struct {
+	Pool
+	unary  []expr.UnaryOp
+	binary []expr.BinaryOp
+}
+
+// WithCustomOps returns p drawing the registered custom ops (see
+// expr.RegisterOp) alongside its own, or p itself if none are registered.
+func WithCustomOps(p Pool) Pool {
+	c := &customPool{Pool: p, unary: expr.CustomUnaryOps(), binary: expr.CustomBinaryOps()}
+	if len(c.unary) == 0 && len(c.binary) == 0 {
+		return p
+	}
+	return c
+}
+
+func (c *customPool) RandomUnary(rng random.Rand) expr.UnaryOp {
+	if len(c.unary) > 0 && rng.Float64() < customOpShare {
+		return c.unary[rng.Intn(len(c.unary))]
+	}
+	return c.Pool.RandomUnary(rng)
+}
+
+func (c *customPool) RandomBinary(rng random.Rand) expr.BinaryOp {
+	if len(c.binary) > 0 && rng.Float64() < customOpShare {
+		return c.binary[rng.Intn(len(c.binary))]
+	}
+	return c.Pool.RandomBinary(rng)
+}
+
+func (c *customPool) RandomTree(rng random.Rand, maxDepth int) expr.ExprNode {
+	return GrowTree(c, rng, maxDepth)
+}
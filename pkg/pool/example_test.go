@@ -0,0 +1,65 @@
+package pool_test
+
+import (
+	"fmt"
+
+	"github.com/wildfunctions/genetic_series/pkg/expr"
+	"github.com/wildfunctions/genetic_series/pkg/pool"
+	"github.com/wildfunctions/genetic_series/pkg/random"
+)
+
+// powersPool draws n and 2 under powers, products and factorials.
+type powersPool struct{}
+
+func (powersPool) Name() string { return "powers" }
+
+func (powersPool) RandomLeaf(rng random.Rand) expr.ExprNode {
+	if rng.Intn(2) == 0 {
+		return &expr.VarNode{}
+	}
+	return &expr.ConstNode{Val: 2}
+}
+
+func (powersPool) RandomUnary(random.Rand) expr.UnaryOp { return expr.OpFactorial }
+
+func (powersPool) RandomBinary(rng random.Rand) expr.BinaryOp {
+	return []expr.BinaryOp{expr.OpPow, expr.OpMul}[rng.Intn(2)]
+}
+
+func (p powersPool) RandomTree(rng random.Rand, maxDepth int) expr.ExprNode {
+	return pool.GrowTree(p, rng, maxDepth)
+}
+
+func ExampleRegister() {
+	pool.Register("powers", func() pool.Pool { return powersPool{} })
+
+	p, err := pool.Get("powers") // or engine.Config{Pool: "powers"}
+	if err != nil {
+		panic(err)
+	}
+	rng, _ := random.New("go", 7)
+	for i := 0; i < 3; i++ {
+		fmt.Println(p.RandomTree(rng, 3))
+	}
+	// Output:
+	// (2)^((n)^(n))
+	// n
+	// (n)!
+}
+
+func ExampleRestrict() {
+	p, _ := pool.Get("moderate")
+	p, err := pool.Restrict(p, []string{"factorial", "add", "div"})
+	if err != nil {
+		panic(err)
+	}
+	rng, _ := random.New("go", 1)
+	for i := 0; i < 4; i++ {
+		fmt.Println(expr.UnaryOpID(p.RandomUnary(rng)), expr.BinaryOpID(p.RandomBinary(rng)))
+	}
+	// Output:
+	// factorial add
+	// factorial add
+	// factorial div
+	// factorial add
+}
@@ -1,21 +1,21 @@
 package pool
 
 import (
-	"math/rand"
-
 	"github.com/wildfunctions/genetic_series/pkg/expr"
+	"github.com/wildfunctions/genetic_series/pkg/random"
 )
 
 func init() {
 	Register("kitchensink", func() Pool { return &KitchenSinkPool{} })
 }
 
-// KitchenSinkPool extends moderate with trig, ln, floor, ceil.
+// KitchenSinkPool extends moderate with trig, ln, floor, ceil, and leaves
+// that are sometimes π, e, φ or γ.
 type KitchenSinkPool struct{}
 
 func (p *KitchenSinkPool) Name() string { return "kitchensink" }
 
-func (p *KitchenSinkPool) RandomLeaf(rng *rand.Rand) expr.ExprNode {
+func (p *KitchenSinkPool) RandomLeaf(rng random.Rand) expr.ExprNode {
 	r := rng.Float64()
 	switch {
 	case r < 0.35:
@@ -26,9 +26,12 @@ func (p *KitchenSinkPool) RandomLeaf(rng *rand.Rand) expr.ExprNode {
 		exp := rng.Intn(4) + 1
 		val := int64(1) << uint(exp)
 		return &expr.ConstNode{Val: val}
-	default:
+	case r < 0.95:
 		vals := []int64{3, 9, 27}
 		return &expr.ConstNode{Val: vals[rng.Intn(len(vals))]}
+	default:
+		syms := expr.Symbols()
+		return &expr.SymbolicConstNode{Sym: syms[rng.Intn(len(syms))]}
 	}
 }
 
@@ -37,16 +40,28 @@ var kitchenSinkUnary = []expr.UnaryOp{
 	expr.OpAltSign,
 	expr.OpNeg,
 	expr.OpDoubleFactorial,
+	expr.OpSubfactorial,
 	expr.OpFibonacci,
 	expr.OpSqrt,
 	expr.OpSin,
 	expr.OpCos,
+	expr.OpTan,
+	expr.OpArctan,
+	expr.OpArcsin,
+	expr.OpSinh,
+	expr.OpCosh,
+	expr.OpTanh,
+	expr.OpExp,
+	expr.OpGamma,
+	expr.OpDigamma,
+	expr.OpBernoulli,
+	expr.OpPrime,
 	expr.OpLn,
 	expr.OpFloor,
 	expr.OpCeil,
 }
 
-func (p *KitchenSinkPool) RandomUnary(rng *rand.Rand) expr.UnaryOp {
+func (p *KitchenSinkPool) RandomUnary(rng random.Rand) expr.UnaryOp {
 	return kitchenSinkUnary[rng.Intn(len(kitchenSinkUnary))]
 }
 
@@ -57,12 +72,15 @@ var kitchenSinkBinary = []expr.BinaryOp{
 	expr.OpDiv,
 	expr.OpPow,
 	expr.OpBinomial,
+	expr.OpPochhammer,
+	expr.OpMod,
+	expr.OpRoot,
 }
 
-func (p *KitchenSinkPool) RandomBinary(rng *rand.Rand) expr.BinaryOp {
+func (p *KitchenSinkPool) RandomBinary(rng random.Rand) expr.BinaryOp {
 	return kitchenSinkBinary[rng.Intn(len(kitchenSinkBinary))]
 }
 
-func (p *KitchenSinkPool) RandomTree(rng *rand.Rand, maxDepth int) expr.ExprNode {
-	return randomTree(p, rng, maxDepth)
+func (p *KitchenSinkPool) RandomTree(rng random.Rand, maxDepth int) expr.ExprNode {
+	return GrowTree(p, rng, maxDepth)
 }
@@ -1,9 +1,8 @@
 package pool
 
 import (
-	"math/rand"
-
 	"github.com/wildfunctions/genetic_series/pkg/expr"
+	"github.com/wildfunctions/genetic_series/pkg/random"
 )
 
 func init() {
@@ -16,7 +15,7 @@ type ModeratePool struct{}
 
 func (p *ModeratePool) Name() string { return "moderate" }
 
-func (p *ModeratePool) RandomLeaf(rng *rand.Rand) expr.ExprNode {
+func (p *ModeratePool) RandomLeaf(rng random.Rand) expr.ExprNode {
 	r := rng.Float64()
 	switch {
 	case r < 0.35:
@@ -42,7 +41,7 @@ var moderateUnary = []expr.UnaryOp{
 	expr.OpSqrt,
 }
 
-func (p *ModeratePool) RandomUnary(rng *rand.Rand) expr.UnaryOp {
+func (p *ModeratePool) RandomUnary(rng random.Rand) expr.UnaryOp {
 	return moderateUnary[rng.Intn(len(moderateUnary))]
 }
 
@@ -54,10 +53,10 @@ var moderateBinary = []expr.BinaryOp{
 	expr.OpPow,
 }
 
-func (p *ModeratePool) RandomBinary(rng *rand.Rand) expr.BinaryOp {
+func (p *ModeratePool) RandomBinary(rng random.Rand) expr.BinaryOp {
 	return moderateBinary[rng.Intn(len(moderateBinary))]
 }
 
-func (p *ModeratePool) RandomTree(rng *rand.Rand, maxDepth int) expr.ExprNode {
-	return randomTree(p, rng, maxDepth)
+func (p *ModeratePool) RandomTree(rng random.Rand, maxDepth int) expr.ExprNode {
+	return GrowTree(p, rng, maxDepth)
 }
@@ -1,19 +1,30 @@
+// Package pool holds the gene pools the search draws leaves and ops from.
+// Pools are registered by name (conservative, moderate, kitchensink) and
+// may be added by programs that import the package.
 package pool
 
 import (
 	"fmt"
-	"math/rand"
 
 	"github.com/wildfunctions/genetic_series/pkg/expr"
+	"github.com/wildfunctions/genetic_series/pkg/random"
 )
 
 // Pool provides random building blocks for constructing expression trees.
+// Every draw must come from rng, so that seeded runs repeat. A pool added
+// with Register can be named by engine.Config.Pool, and GrowTree builds
+// RandomTree from the other methods.
 type Pool interface {
+	// Name returns the name the pool is registered under.
 	Name() string
-	RandomLeaf(rng *rand.Rand) expr.ExprNode
-	RandomUnary(rng *rand.Rand) expr.UnaryOp
-	RandomBinary(rng *rand.Rand) expr.BinaryOp
-	RandomTree(rng *rand.Rand, maxDepth int) expr.ExprNode
+	// RandomLeaf returns a new leaf: n, a constant or a symbol.
+	RandomLeaf(rng random.Rand) expr.ExprNode
+	// RandomUnary and RandomBinary return an op of the pool's set.
+	RandomUnary(rng random.Rand) expr.UnaryOp
+	RandomBinary(rng random.Rand) expr.BinaryOp
+	// RandomTree returns a new tree at most maxDepth deep, built from the
+	// pool's leaves and ops.
+	RandomTree(rng random.Rand, maxDepth int) expr.ExprNode
 }
 
 var registry = map[string]func() Pool{}
@@ -41,8 +52,10 @@ func Names() []string {
 	return names
 }
 
-// randomTree is a shared helper for building random trees.
-func randomTree(p Pool, rng *rand.Rand, maxDepth int) expr.ExprNode {
+// GrowTree builds a random tree of p's leaves and ops at most maxDepth
+// deep, biased toward leaves so trees stay small. It is the RandomTree of
+// every built-in pool, for pools defined elsewhere to share.
+func GrowTree(p Pool, rng random.Rand, maxDepth int) expr.ExprNode {
 	if maxDepth <= 1 {
 		return p.RandomLeaf(rng)
 	}
@@ -54,13 +67,47 @@ func randomTree(p Pool, rng *rand.Rand, maxDepth int) expr.ExprNode {
 	case r < 0.6:
 		return &expr.UnaryNode{
 			Op:    p.RandomUnary(rng),
-			Child: randomTree(p, rng, maxDepth-1),
+			Child: GrowTree(p, rng, maxDepth-1),
 		}
 	default:
 		return &expr.BinaryNode{
 			Op:    p.RandomBinary(rng),
-			Left:  randomTree(p, rng, maxDepth-1),
-			Right: randomTree(p, rng, maxDepth-1),
+			Left:  GrowTree(p, rng, maxDepth-1),
+			Right: GrowTree(p, rng, maxDepth-1),
 		}
 	}
 }
+
+// RandomTreeOfSize builds a random tree of p's building blocks with exactly
+// size nodes and depth at most maxDepth. A size that does not fit in
+// maxDepth levels is capped at the largest that does, a full binary tree.
+func RandomTreeOfSize(p Pool, rng random.Rand, size, maxDepth int) expr.ExprNode {
+	maxDepth = max(maxDepth, 1)
+	size = min(max(size, 1), maxNodes(maxDepth))
+	if size == 1 {
+		return p.RandomLeaf(rng)
+	}
+	below := maxNodes(maxDepth - 1)
+	// A unary node needs its child to fit below; a size-2 tree must be one.
+	if size == 2 || size-1 <= below && rng.Float64() < 0.3 {
+		return &expr.UnaryNode{
+			Op:    p.RandomUnary(rng),
+			Child: RandomTreeOfSize(p, rng, size-1, maxDepth-1),
+		}
+	}
+	lo, hi := max(1, size-1-below), min(below, size-2)
+	left := lo + rng.Intn(hi-lo+1)
+	return &expr.BinaryNode{
+		Op:    p.RandomBinary(rng),
+		Left:  RandomTreeOfSize(p, rng, left, maxDepth-1),
+		Right: RandomTreeOfSize(p, rng, size-1-left, maxDepth-1),
+	}
+}
+
+// maxNodes is the size of a full binary tree of depth levels.
+func maxNodes(depth int) int {
+	if depth >= 30 {
+		return 1<<30 - 1
+	}
+	return 1<<depth - 1
+}
@@ -4,6 +4,8 @@ import (
 	"math/big"
 	"math/rand"
 	"testing"
+
+	"github.com/wildfunctions/genetic_series/pkg/expr"
 )
 
 const testPrec = 512
@@ -109,3 +111,77 @@ func TestUnknownPool(t *testing.T) {
 		t.Error("Expected error for unknown pool")
 	}
 }
+
+func TestRestrict(t *testing.T) {
+	base, err := Get("kitchensink")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := Restrict(base, []string{"factorial", "sqrt", "mul", "div"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 500; i++ {
+		if op := p.RandomUnary(rng); op != expr.OpFactorial && op != expr.OpSqrt {
+			t.Fatalf("RandomUnary = %v, outside whitelist", op)
+		}
+		if op := p.RandomBinary(rng); op != expr.OpMul && op != expr.OpDiv {
+			t.Fatalf("RandomBinary = %v, outside whitelist", op)
+		}
+	}
+
+	if _, err := Restrict(base, []string{"factorial", "warp"}); err == nil {
+		t.Error("unknown op accepted")
+	}
+	if _, err := Restrict(base, []string{"add"}); err == nil {
+		t.Error("whitelist without unary ops accepted")
+	}
+}
+
+func TestRandomTreeOfSize(t *testing.T) {
+	p, _ := Get("conservative")
+	rng := rand.New(rand.NewSource(7))
+	for size := 1; size <= 20; size++ {
+		for i := 0; i < 50; i++ {
+			tree := RandomTreeOfSize(p, rng, size, 4)
+			if want := min(size, 15); tree.NodeCount() != want || tree.Depth() > 4 {
+				t.Fatalf("size %d: %s has %d nodes, depth %d; want %d nodes, depth <= 4", size, tree, tree.NodeCount(), tree.Depth(), want)
+			}
+		}
+	}
+}
+
+func TestWithCustomOps(t *testing.T) {
+	p, err := Get("conservative")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := expr.LookupUnaryOp("double"); !ok {
+		if WithCustomOps(p) != p {
+			t.Fatal("WithCustomOps wrapped a pool with no custom ops registered")
+		}
+		if err := expr.RegisterOp(expr.OpDef{
+			ID:    "double",
+			Arity: 1,
+			Eval: func(args []*big.Float, prec uint) (*big.Float, bool) {
+				return args[0].Add(args[0], args[0]), true
+			},
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	double, _ := expr.LookupUnaryOp("double")
+
+	rng := rand.New(rand.NewSource(42))
+	c := WithCustomOps(p)
+	drawn := 0
+	for i := 0; i < 1000; i++ {
+		if c.RandomUnary(rng) == double {
+			drawn++
+		}
+	}
+	if drawn < 100 || drawn > 300 {
+		t.Errorf("custom op drawn %d/1000 times, want about %d", drawn, int(customOpShare*1000))
+	}
+}
@@ -0,0 +1,70 @@
+package pool
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/wildfunctions/genetic_series/pkg/expr"
+	"github.com/wildfunctions/genetic_series/pkg/random"
+)
+
+// restrictRetries is how many draws a restricted pool takes from the
+// underlying pool before falling back to a uniform pick from the whitelist.
+const restrictRetries = 32
+
+// restrictedPool limits another pool to a whitelist of ops. Leaves are
+// unchanged.
+type restrictedPool struct {
+	Pool
+	unary  []expr.UnaryOp
+	binary []expr.BinaryOp
+}
+
+// Restrict returns p limited to the ops named in ids (see expr.OpIDs). The
+// whitelist must name at least one unary and one binary op. Ops are still
+// drawn with p's own weights; if p keeps drawing ops outside the whitelist,
+// one is picked uniformly from it instead.
+func Restrict(p Pool, ids []string) (Pool, error) {
+	r := &restrictedPool{Pool: p}
+	for _, id := range ids {
+		if op, ok := expr.LookupUnaryOp(id); ok {
+			r.unary = append(r.unary, op)
+		} else if op, ok := expr.LookupBinaryOp(id); ok {
+			r.binary = append(r.binary, op)
+		} else {
+			return nil, fmt.Errorf("unknown op %q (available: %s)", id, strings.Join(expr.OpIDs(), ", "))
+		}
+	}
+	if len(r.unary) == 0 || len(r.binary) == 0 {
+		return nil, fmt.Errorf("op whitelist needs at least one unary and one binary op")
+	}
+	return r, nil
+}
+
+func (r *restrictedPool) RandomUnary(rng random.Rand) expr.UnaryOp {
+	for i := 0; i < restrictRetries; i++ {
+		op := r.Pool.RandomUnary(rng)
+		for _, ok := range r.unary {
+			if op == ok {
+				return op
+			}
+		}
+	}
+	return r.unary[rng.Intn(len(r.unary))]
+}
+
+func (r *restrictedPool) RandomBinary(rng random.Rand) expr.BinaryOp {
+	for i := 0; i < restrictRetries; i++ {
+		op := r.Pool.RandomBinary(rng)
+		for _, ok := range r.binary {
+			if op == ok {
+				return op
+			}
+		}
+	}
+	return r.binary[rng.Intn(len(r.binary))]
+}
+
+func (r *restrictedPool) RandomTree(rng random.Rand, maxDepth int) expr.ExprNode {
+	return GrowTree(r, rng, maxDepth)
+}
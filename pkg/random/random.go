@@ -0,0 +1,121 @@
+// Package random provides the seeded generators the search draws from.
+// A run has one master seed; everything that needs its own randomness
+// (a trial of an experiment, a worker, an island) splits an independent
+// stream off it, so results depend on the seed and the stream number only.
+package random
+
+import (
+	"fmt"
+	"math/rand"
+	randv2 "math/rand/v2"
+)
+
+// Rand is the randomness pools and strategies draw on. *rand.Rand and
+// *Stream implement it.
+type Rand interface {
+	Intn(n int) int
+	Int63n(n int64) int64
+	Float64() float64
+}
+
+// Kinds lists the generators New accepts. "go" is math/rand's seeded
+// source, the default, so seeds replay runs made before the generator was
+// configurable. It has only 2^31-1 distinct seeds; "pcg" (PCG-DXSM, from
+// math/rand/v2) and "xoshiro" (xoshiro256**) are faster and take the full
+// 64-bit seed, which matters when many streams are split off one seed.
+var Kinds = []string{"go", "pcg", "xoshiro"}
+
+// Stream is a seeded generator that can split off independent streams.
+type Stream struct {
+	*rand.Rand
+	kind string
+	seed uint64
+}
+
+// New returns the master stream of the named generator for seed. An empty
+// kind is "go".
+func New(kind string, seed int64) (*Stream, error) {
+	if kind == "" {
+		kind = "go"
+	}
+	src, ok := newSource(kind, uint64(seed))
+	if !ok {
+		return nil, fmt.Errorf("unknown rng: %s (available: %v)", kind, Kinds)
+	}
+	return &Stream{Rand: rand.New(src), kind: kind, seed: uint64(seed)}, nil
+}
+
+// Kind returns the name of the generator behind s.
+func (s *Stream) Kind() string { return s.kind }
+
+// Split returns stream i of s: a generator of the same kind whose seed is
+// a hash of s's seed and i. It does not draw from s, so Split(i) gives the
+// same stream however far s has advanced, and streams can be split again.
+func (s *Stream) Split(i uint64) *Stream {
+	seed := splitmix64(s.seed ^ splitmix64(i+1))
+	src, _ := newSource(s.kind, seed)
+	return &Stream{Rand: rand.New(src), kind: s.kind, seed: seed}
+}
+
+func newSource(kind string, seed uint64) (rand.Source, bool) {
+	switch kind {
+	case "go":
+		return rand.NewSource(int64(seed)), true
+	case "pcg":
+		src := &pcgSource{}
+		src.Seed(int64(seed))
+		return src, true
+	case "xoshiro":
+		src := &xoshiroSource{}
+		src.Seed(int64(seed))
+		return src, true
+	}
+	return nil, false
+}
+
+// splitmix64 is the SplitMix64 finalizer: a bijective mix of x, used to
+// derive stream seeds and to expand one seed into generator state.
+func splitmix64(x uint64) uint64 {
+	x += 0x9e3779b97f4a7c15
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	return x ^ (x >> 31)
+}
+
+// pcgSource adapts math/rand/v2's PCG to a math/rand source.
+type pcgSource struct{ pcg randv2.PCG }
+
+func (p *pcgSource) Seed(seed int64) {
+	s := uint64(seed)
+	p.pcg.Seed(s, splitmix64(s))
+}
+
+func (p *pcgSource) Uint64() uint64 { return p.pcg.Uint64() }
+func (p *pcgSource) Int63() int64   { return int64(p.pcg.Uint64() >> 1) }
+
+// xoshiroSource is xoshiro256** (Blackman and Vigna), seeded through
+// SplitMix64 as its authors recommend.
+type xoshiroSource struct{ s [4]uint64 }
+
+func (x *xoshiroSource) Seed(seed int64) {
+	for i := range x.s {
+		x.s[i] = splitmix64(uint64(seed) + uint64(i)*0x9e3779b97f4a7c15)
+	}
+}
+
+func (x *xoshiroSource) Uint64() uint64 {
+	s := &x.s
+	result := rotl(s[1]*5, 7) * 9
+	t := s[1] << 17
+	s[2] ^= s[0]
+	s[3] ^= s[1]
+	s[1] ^= s[2]
+	s[0] ^= s[3]
+	s[2] ^= t
+	s[3] = rotl(s[3], 45)
+	return result
+}
+
+func (x *xoshiroSource) Int63() int64 { return int64(x.Uint64() >> 1) }
+
+func rotl(v uint64, k uint) uint64 { return v<<k | v>>(64-k) }
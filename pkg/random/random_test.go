@@ -0,0 +1,79 @@
+package random
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func draws(r Rand, n int) []int64 {
+	out := make([]int64, n)
+	for i := range out {
+		out[i] = r.Int63n(1 << 40)
+	}
+	return out
+}
+
+func equal(a, b []int64) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestGoMatchesMathRand(t *testing.T) {
+	s, err := New("", 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !equal(draws(s, 50), draws(rand.New(rand.NewSource(5)), 50)) {
+		t.Error(`"go" master stream differs from rand.NewSource(seed)`)
+	}
+}
+
+func TestXoshiroReference(t *testing.T) {
+	// Outputs of the reference C implementation from state {1, 2, 3, 4}.
+	x := &xoshiroSource{s: [4]uint64{1, 2, 3, 4}}
+	for i, want := range []uint64{11520, 0, 1509978240, 1215971899390074240} {
+		if got := x.Uint64(); got != want {
+			t.Errorf("output %d = %d, want %d", i, got, want)
+		}
+	}
+}
+
+func TestSplit(t *testing.T) {
+	for _, kind := range Kinds {
+		a, _ := New(kind, 42)
+		b, _ := New(kind, 42)
+		if !equal(draws(a, 20), draws(b, 20)) {
+			t.Errorf("%s: same seed gave different draws", kind)
+		}
+		// a has advanced, b has not: splits must not depend on that.
+		if !equal(draws(a.Split(1), 20), draws(b.Split(1), 20)) {
+			t.Errorf("%s: Split(1) depends on the parent's position", kind)
+		}
+		if a.Split(1).Kind() != kind {
+			t.Errorf("%s: split changed the kind to %s", kind, a.Split(1).Kind())
+		}
+
+		seen := map[int64]uint64{}
+		for i := uint64(0); i < 100; i++ {
+			first := draws(b.Split(i), 1)[0]
+			if j, dup := seen[first]; dup {
+				t.Errorf("%s: streams %d and %d start alike", kind, j, i)
+			}
+			seen[first] = i
+		}
+		c, _ := New(kind, 43)
+		if equal(draws(b.Split(1), 20), draws(c.Split(1), 20)) {
+			t.Errorf("%s: stream 1 of seeds 42 and 43 agree", kind)
+		}
+		if equal(draws(b.Split(1), 20), draws(b.Split(1).Split(1), 20)) {
+			t.Errorf("%s: stream 1 of stream 1 is stream 1", kind)
+		}
+	}
+	if _, err := New("mersenne", 1); err == nil {
+		t.Error("unknown kind accepted")
+	}
+}
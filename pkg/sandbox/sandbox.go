@@ -0,0 +1,122 @@
+// Package sandbox bounds the work done for an untrusted formula, as a
+// service that parses and evaluates submitted formulas must: without
+// limits, \frac{(n!)!}{1} at a few million bits ties up a worker
+// indefinitely. Limits come from a Profiles table keyed by API key; a
+// handler calls Parse, Options and Evaluate with the caller's Limits and
+// answers a *LimitError with its limit-exceeded response.
+package sandbox
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/wildfunctions/genetic_series/pkg/series"
+)
+
+// Limits caps one request.
+type Limits struct {
+	MaxSource    int           // bytes of LaTeX source
+	MaxNodes     int           // expression nodes, numerator and denominator together
+	MaxPrecision uint          // bits
+	MaxTerms     int64         // terms summed
+	CPUTime      time.Duration // wall-clock evaluation time
+}
+
+// DefaultLimits are the limits of an anonymous caller: enough to check a
+// search result to a few hundred digits.
+func DefaultLimits() Limits {
+	return Limits{
+		MaxSource:    4096,
+		MaxNodes:     64,
+		MaxPrecision: 2048,
+		MaxTerms:     4096,
+		CPUTime:      time.Second,
+	}
+}
+
+// Profiles maps API keys to their limits. Unknown and empty keys get
+// Default.
+type Profiles struct {
+	Default Limits
+	Keys    map[string]Limits
+}
+
+// For returns the limits of key.
+func (p Profiles) For(key string) Limits {
+	if l, ok := p.Keys[key]; ok {
+		return l
+	}
+	return p.Default
+}
+
+// LimitError reports a request over one of its limits.
+type LimitError struct {
+	Limit    string // "source", "nodes", "precision", "terms" or "cpu_time"
+	Got, Max int64  // for cpu_time, in milliseconds
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("limit exceeded: %s %d > %d", e.Limit, e.Got, e.Max)
+}
+
+// IsLimit reports whether err is, or wraps, a *LimitError.
+func IsLimit(err error) bool {
+	var le *LimitError
+	return errors.As(err, &le)
+}
+
+// Parse parses a candidate, refusing source or trees over the limits. The
+// source is checked before parsing, so oversized input costs nothing.
+func (l Limits) Parse(src string) (*series.Candidate, error) {
+	if len(src) > l.MaxSource {
+		return nil, &LimitError{Limit: "source", Got: int64(len(src)), Max: int64(l.MaxSource)}
+	}
+	c, err := series.ParseCandidateLatex(src)
+	if err != nil {
+		return nil, err
+	}
+	if n := c.NodeCount(); n > l.MaxNodes {
+		return nil, &LimitError{Limit: "nodes", Got: int64(n), Max: int64(l.MaxNodes)}
+	}
+	return c, nil
+}
+
+// Options returns the evaluation options for the requested terms and
+// precision, with the CPU time limit as the evaluator's timeout and the
+// search's bound on term size.
+func (l Limits) Options(maxTerms int64, prec uint) (series.EvalOptions, error) {
+	if maxTerms > l.MaxTerms {
+		return series.EvalOptions{}, &LimitError{Limit: "terms", Got: maxTerms, Max: l.MaxTerms}
+	}
+	if prec > l.MaxPrecision {
+		return series.EvalOptions{}, &LimitError{Limit: "precision", Got: int64(prec), Max: int64(l.MaxPrecision)}
+	}
+	return series.EvalOptions{MaxTerms: maxTerms, Prec: prec, Timeout: l.CPUTime, MaxExponent: series.DefaultMaxExponent}, nil
+}
+
+// Evaluate runs ev on c and reports running out of CPU time as a
+// *LimitError rather than as a failed result. Evaluators only check their
+// timeout between terms, so a single huge term can overrun it; Evaluate
+// stops waiting at twice the limit and leaves the evaluation to finish on
+// its own.
+func (l Limits) Evaluate(ev series.Evaluator, c *series.Candidate, opts series.EvalOptions) (series.EvalResult, error) {
+	overrun := &LimitError{Limit: "cpu_time", Max: l.CPUTime.Milliseconds()}
+	done := make(chan series.EvalResult, 1)
+	start := time.Now()
+	go func() { done <- ev.Evaluate(c, opts) }()
+
+	timer := time.NewTimer(2 * l.CPUTime)
+	defer timer.Stop()
+	select {
+	case res := <-done:
+		if elapsed := time.Since(start); !res.OK && res.Err == nil && elapsed >= l.CPUTime {
+			overrun.Got = elapsed.Milliseconds()
+			return series.EvalResult{}, overrun
+		}
+		return res, nil
+	case <-timer.C:
+		overrun.Got = time.Since(start).Milliseconds()
+		return series.EvalResult{}, overrun
+	}
+}
@@ -0,0 +1,80 @@
+package sandbox
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/wildfunctions/genetic_series/pkg/series"
+)
+
+// sleepyEvaluator stands in for a term too big to be interrupted.
+type sleepyEvaluator struct{ d time.Duration }
+
+func (sleepyEvaluator) Name() string { return "sleepy" }
+
+func (s sleepyEvaluator) Evaluate(*series.Candidate, series.EvalOptions) series.EvalResult {
+	time.Sleep(s.d)
+	return series.EvalResult{OK: true}
+}
+
+func limitOf(err error) string {
+	if le, ok := err.(*LimitError); ok {
+		return le.Limit
+	}
+	return ""
+}
+
+func TestLimits(t *testing.T) {
+	l := DefaultLimits()
+	l.MaxNodes = 8
+	c, err := l.Parse(`\sum_{n=0}^{\infty} \frac{1}{n!}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.Parse(`\sum_{n=1}^{\infty} \frac{(n!)!}{n^{n} + n^{3} + 1}`); limitOf(err) != "nodes" {
+		t.Errorf("big tree: %v, want a nodes limit", err)
+	}
+	if _, err := l.Parse(`\frac{\sum_{n=0}^{\infty} \frac{1}{n!}}{\sum_{n=1}^{\infty} \frac{(n!)!}{n^{n} + n^{3} + 1}}`); limitOf(err) != "nodes" {
+		t.Errorf("big divisor: %v, want a nodes limit", err)
+	}
+	if _, err := l.Parse(strings.Repeat(" ", l.MaxSource+1)); limitOf(err) != "source" {
+		t.Errorf("long source: %v, want a source limit", err)
+	}
+	if _, err := l.Parse(`\sum`); err == nil || IsLimit(err) {
+		t.Errorf("bad formula: %v, want a parse error", err)
+	}
+
+	if _, err := l.Options(l.MaxTerms+1, 256); limitOf(err) != "terms" {
+		t.Errorf("terms: %v", err)
+	}
+	if _, err := l.Options(64, l.MaxPrecision+1); limitOf(err) != "precision" {
+		t.Errorf("precision: %v", err)
+	}
+	opts, err := l.Options(64, 256)
+	if err != nil || opts.Timeout != l.CPUTime {
+		t.Fatalf("options %+v, %v", opts, err)
+	}
+	res, err := l.Evaluate(series.BigFloatEvaluator{}, c, opts)
+	if err != nil || !res.OK {
+		t.Errorf("1/n!: %+v, %v", res, err)
+	}
+
+	l.CPUTime = 20 * time.Millisecond
+	start := time.Now()
+	if _, err := l.Evaluate(sleepyEvaluator{time.Second}, c, opts); limitOf(err) != "cpu_time" {
+		t.Errorf("slow term: %v, want a cpu_time limit", err)
+	}
+	if waited := time.Since(start); waited > 500*time.Millisecond {
+		t.Errorf("waited %v for a %v limit", waited, l.CPUTime)
+	}
+}
+
+func TestProfiles(t *testing.T) {
+	trusted := DefaultLimits()
+	trusted.MaxPrecision = 1 << 16
+	p := Profiles{Default: DefaultLimits(), Keys: map[string]Limits{"k1": trusted}}
+	if p.For("k1").MaxPrecision != 1<<16 || p.For("other") != p.Default || p.For("") != p.Default {
+		t.Errorf("profiles resolved wrongly: %+v %+v", p.For("k1"), p.For("other"))
+	}
+}
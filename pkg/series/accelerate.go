@@ -0,0 +1,94 @@
+package series
+
+import (
+	"math/big"
+
+	"github.com/wildfunctions/genetic_series/pkg/constants"
+)
+
+// accelerationSums is how many trailing partial sums AcceleratedSum
+// extrapolates from. It is odd, so the last epsilon column is an estimate.
+const accelerationSums = 21
+
+// AcceleratedSum sums up to terms terms of c at prec and extrapolates the
+// limit from the last accelerationSums partial sums with Wynn's epsilon
+// algorithm (the iterated Shanks transformation). That gains many digits on
+// alternating and geometrically convergent series, leaves converged ones
+// alone, and gains little on logarithmic ones such as Σ1/n². Like
+// VerifyLadder it has no timeout and is meant for background verification.
+// A ratio is the quotient of both series' extrapolated limits.
+func AcceleratedSum(c *Candidate, terms int64, prec uint) (*big.Float, bool) {
+	if c.Over != nil {
+		top, ok := AcceleratedSum(c.Series(), terms, prec)
+		if !ok {
+			return nil, false
+		}
+		bottom, ok := AcceleratedSum(c.Over, terms, prec)
+		if !ok {
+			return nil, false
+		}
+		return quoSums(top, bottom)
+	}
+	head := terms - (accelerationSums - 1)
+	if head < 1 {
+		return nil, false
+	}
+	sum, n, ok := ladderSum(c, head, prec)
+	if !ok || n < head {
+		return nil, false
+	}
+	sums := make([]*big.Float, accelerationSums)
+	sums[0] = sum
+	for j := 1; j < accelerationSums; j++ {
+		t, ok := termAt(c, c.Start+head+int64(j-1), prec)
+		if !ok {
+			return nil, false
+		}
+		sums[j] = t.Add(t, sums[j-1])
+	}
+	return wynnEpsilon(sums, prec), true
+}
+
+// AcceleratedDigits is the correct digits of AcceleratedSum against target
+// at prec, or 0 if c cannot be summed that far.
+func AcceleratedDigits(c *Candidate, terms int64, prec uint, target constants.Target) float64 {
+	sum, ok := AcceleratedSum(c, terms, prec)
+	if !ok {
+		return 0
+	}
+	return min(countCorrectDigits(sum, target.At(prec)), MaxDigits)
+}
+
+// wynnEpsilon returns the deepest even-column entry of Wynn's epsilon
+// table of s: ε(-1) = 0, ε(0) = s and
+// ε(k+1)[i] = ε(k-1)[i+1] + 1/(ε(k)[i+1] - ε(k)[i]).
+// A zero difference in an even column means the sequence has converged;
+// one in an odd column stops the table at the last estimate.
+func wynnEpsilon(s []*big.Float, prec uint) *big.Float {
+	prev := make([]*big.Float, len(s)+1)
+	for i := range prev {
+		prev[i] = new(big.Float).SetPrec(prec)
+	}
+	cur := s
+	best := s[len(s)-1]
+	one := big.NewFloat(1)
+	for k := 0; len(cur) > 1; k++ {
+		next := make([]*big.Float, len(cur)-1)
+		for i := range next {
+			d := new(big.Float).SetPrec(prec).Sub(cur[i+1], cur[i])
+			if d.Sign() == 0 {
+				if k%2 == 0 {
+					return cur[i+1]
+				}
+				return best
+			}
+			next[i] = d.Quo(one, d)
+			next[i].Add(next[i], prev[i+1])
+		}
+		prev, cur = cur, next
+		if k%2 == 1 {
+			best = cur[len(cur)-1]
+		}
+	}
+	return best
+}
@@ -0,0 +1,27 @@
+package series
+
+import (
+	"testing"
+
+	"github.com/wildfunctions/genetic_series/pkg/constants"
+)
+
+func TestAcceleratedDigits(t *testing.T) {
+	ln2 := constants.Get("ln2")
+	alt := mustParse(t, `\sum_{n=0}^{\infty} \frac{(-1)^{n}}{n + 1}`)
+	sum, _, _ := ladderSum(alt, 64, testPrec)
+	plain := countCorrectDigits(sum, ln2.At(testPrec))
+	if acc := AcceleratedDigits(alt, 64, testPrec, ln2); acc < plain+10 {
+		t.Errorf("alternating ln 2: %.1f digits accelerated, %.1f plain", acc, plain)
+	}
+
+	// Already converged: acceleration must not lose the match.
+	e := constants.Get("e")
+	if acc := AcceleratedDigits(mustParse(t, `\sum_{n=0}^{\infty} \frac{1}{n!}`), 128, testPrec, e); acc < 40 {
+		t.Errorf("1/n!: %.1f digits accelerated", acc)
+	}
+
+	if _, ok := AcceleratedSum(alt, accelerationSums-1, testPrec); ok {
+		t.Error("accelerated with fewer terms than the epsilon table needs")
+	}
+}
@@ -0,0 +1,117 @@
+package series
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/wildfunctions/genetic_series/pkg/expr"
+	"github.com/wildfunctions/genetic_series/pkg/numeric"
+)
+
+// sumFunc sums the first terms terms of a candidate with a fixed backend.
+type sumFunc func(c *Candidate, terms int64, prec uint) (*big.Float, int64, bool)
+
+// sumBackends maps backend names to their summation entry points. Optional
+// backends add themselves from build-tagged files.
+var sumBackends = map[string]sumFunc{
+	"big": func(c *Candidate, terms int64, prec uint) (*big.Float, int64, bool) {
+		return PartialSumNum[*big.Float](c, numeric.BigFloat{}, terms, prec)
+	},
+}
+
+// SumBackends returns the numeric backends compiled into this binary.
+func SumBackends() []string {
+	names := make([]string, 0, len(sumBackends))
+	for k := range sumBackends {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// PartialSumWith sums up to terms terms of c using the named backend.
+func PartialSumWith(backend string, c *Candidate, terms int64, prec uint) (*big.Float, int64, error) {
+	f, ok := sumBackends[backend]
+	if !ok {
+		return nil, 0, fmt.Errorf("unknown numeric backend: %s (available: %v)", backend, SumBackends())
+	}
+	sum, n, ok := f(c, terms, prec)
+	if !ok {
+		return nil, n, fmt.Errorf("evaluation failed after %d terms", n)
+	}
+	return sum, n, nil
+}
+
+// PartialSumNum sums up to terms terms of c with backend b. Unlike
+// EvaluateCandidate there is no timeout and no convergence tracking; it is
+// meant for high-precision verification, where backend speed dominates.
+// Like EvaluateCandidate, it stops at the first term that fails and returns
+// the sum so far along with the number of terms used. A ratio is the
+// quotient of both partial sums, with the fewer terms.
+func PartialSumNum[T any](c *Candidate, b numeric.Backend[T], terms int64, prec uint) (*big.Float, int64, bool) {
+	if c.Over != nil {
+		return partialSumRatio(c, b, terms, prec)
+	}
+	sum, computed, _ := sumNum(c, b, EvalOptions{MaxTerms: terms, Prec: prec}, nil)
+	if computed == 0 {
+		return nil, 0, false
+	}
+	return sum, computed, true
+}
+
+// sumNum is the summation loop shared by PartialSumNum and NumEvaluator: up
+// to opts.MaxTerms terms of c with backend b, stopping at the first failed
+// term or at the timeout. If cps is non-nil, the sums after 1, 2, 4, ...
+// terms are appended to it. The sum starts from c's offset. It returns the
+// sum (nil if no term succeeded), the number of terms used, and whether
+// time ran out.
+func sumNum[T any](c *Candidate, b numeric.Backend[T], opts EvalOptions, cps *[]checkpoint) (*big.Float, int64, bool) {
+	prec := opts.Prec
+	sum := b.New(prec)
+	defer b.Release(sum)
+	off, ok := c.OffsetValue(prec)
+	if !ok {
+		return nil, 0, false
+	}
+	b.SetBig(sum, off)
+	n := b.New(prec)
+	defer b.Release(n)
+	deadline := opts.deadline()
+
+	var computed int64
+	nextCheckpoint := int64(1)
+	for i := c.Start; i < c.Start+opts.MaxTerms; i++ {
+		// Checking the clock costs about as much as a cheap term.
+		if computed%minEvalBlock == 0 && pastEvalDeadline(deadline) {
+			return nil, computed, true
+		}
+		b.SetInt64(n, i)
+		num, ok := expr.EvalNum(c.Numerator, b, n, prec)
+		if !ok {
+			break
+		}
+		den, ok := expr.EvalNum(c.Denominator, b, n, prec)
+		if !ok {
+			b.Release(num)
+			break
+		}
+		if b.Sign(den) == 0 {
+			b.Release(num)
+			b.Release(den)
+			break
+		}
+		b.Add(sum, sum, b.Quo(num, num, den))
+		b.Release(num)
+		b.Release(den)
+		computed++
+		if cps != nil && computed == nextCheckpoint {
+			*cps = append(*cps, checkpoint{terms: computed, sum: b.Big(sum)})
+			nextCheckpoint *= 2
+		}
+	}
+	if computed == 0 {
+		return nil, 0, false
+	}
+	return b.Big(sum), computed, false
+}
@@ -0,0 +1,16 @@
+//go:build mpfr && cgo
+
+package series
+
+import (
+	"math/big"
+
+	"github.com/wildfunctions/genetic_series/pkg/numeric"
+)
+
+func init() {
+	sumBackends["mpfr"] = func(c *Candidate, terms int64, prec uint) (*big.Float, int64, bool) {
+		return PartialSumNum[*numeric.MPFRFloat](c, numeric.MPFR{}, terms, prec)
+	}
+	evaluators["mpfr"] = NumEvaluator[*numeric.MPFRFloat]{Backend: numeric.MPFR{}}
+}
@@ -0,0 +1,41 @@
+package series
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestPartialSumWithMatchesEvaluateCandidate(t *testing.T) {
+	const terms = 200
+	eps := new(big.Float).SetMantExp(big.NewFloat(1), -(testPrec - 16))
+	for _, c := range parseCorpus(t) {
+		want := EvaluateCandidate(c, terms, testPrec)
+		if !want.OK {
+			continue
+		}
+		for _, backend := range SumBackends() {
+			got, n, err := PartialSumWith(backend, c, terms, testPrec)
+			if err != nil {
+				t.Errorf("%s / %s: %v", backend, c, err)
+				continue
+			}
+			if n != want.TermsComputed {
+				t.Errorf("%s / %s: %d terms, want %d", backend, c, n, want.TermsComputed)
+			}
+			diff := new(big.Float).Sub(got, want.PartialSum)
+			if want.PartialSum.Sign() != 0 {
+				diff.Quo(diff, want.PartialSum)
+			}
+			if diff.Abs(diff).Cmp(eps) > 0 {
+				t.Errorf("%s / %s: sum %s, want %s", backend, c, got.Text('g', 30), want.PartialSum.Text('g', 30))
+			}
+		}
+	}
+}
+
+func TestPartialSumWithUnknownBackend(t *testing.T) {
+	c := mustParse(t, `\sum_{n=0}^{\infty} \frac{1}{n!}`)
+	if _, _, err := PartialSumWith("nope", c, 10, testPrec); err == nil {
+		t.Error("expected error for unknown backend")
+	}
+}
@@ -0,0 +1,137 @@
+package series
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+// loadCorpus reads the benchmark candidates from testdata/corpus.txt.
+// Blank lines and lines starting with # are skipped.
+func loadCorpus(tb testing.TB) []string {
+	tb.Helper()
+	f, err := os.Open("testdata/corpus.txt")
+	if err != nil {
+		tb.Fatal(err)
+	}
+	defer f.Close()
+
+	var formulas []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		formulas = append(formulas, line)
+	}
+	if err := sc.Err(); err != nil {
+		tb.Fatal(err)
+	}
+	return formulas
+}
+
+func parseCorpus(tb testing.TB) []*Candidate {
+	tb.Helper()
+	var cands []*Candidate
+	for _, s := range loadCorpus(tb) {
+		c, err := ParseCandidateLatex(s)
+		if err != nil {
+			tb.Fatalf("ParseCandidateLatex(%q): %v", s, err)
+		}
+		cands = append(cands, c)
+	}
+	return cands
+}
+
+func BenchmarkParseLargeFormula(b *testing.B) {
+	var largest string
+	for _, s := range loadCorpus(b) {
+		if len(s) > len(largest) {
+			largest = s
+		}
+	}
+	b.ReportAllocs()
+	b.SetBytes(int64(len(largest)))
+	for i := 0; i < b.N; i++ {
+		if _, err := ParseCandidateLatex(largest); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParseCorpus(b *testing.B) {
+	formulas := loadCorpus(b)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		for _, s := range formulas {
+			if _, err := ParseCandidateLatex(s); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+// BenchmarkEvaluate4096Terms evaluates every corpus candidate to 4096 terms.
+// Candidates built on factorials stop early at the evaluator's input cap,
+// which is representative of what the engine sees.
+func BenchmarkEvaluate4096Terms(b *testing.B) {
+	cands := parseCorpus(b)
+	for _, prec := range []uint{64, 128, 512, 1024} {
+		b.Run(fmt.Sprintf("prec%d", prec), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				for _, c := range cands {
+					EvaluateCandidate(c, 4096, prec)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkEvaluateF644096Terms(b *testing.B) {
+	cands := parseCorpus(b)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		for _, c := range cands {
+			EvaluateCandidateF64(c, 4096)
+		}
+	}
+}
+
+// BenchmarkBinarySplitRamanujan sums Ramanujan's 1/pi series to ~10,000 digits.
+func BenchmarkBinarySplitRamanujan(b *testing.B) {
+	c, err := ParseCandidateLatex(`\frac{\sqrt{8}}{9801} \sum_{n=0}^{\infty} \frac{(4n)!}{(n!)^4} \frac{1103 + 26390n}{396^{4n}}`)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, _, ok := SumBinarySplit(c, 33220); !ok {
+			b.Fatal("SumBinarySplit failed")
+		}
+	}
+}
+
+// BenchmarkPartialSumBackends compares numeric backends at verification
+// precisions. Build with -tags mpfr to include MPFR.
+func BenchmarkPartialSumBackends(b *testing.B) {
+	c, err := ParseCandidateLatex(`\sum_{n=0}^{\infty} \frac{26 \cdot n! \cdot (2n)!}{(3n)! \cdot 2^n}`)
+	if err != nil {
+		b.Fatal(err)
+	}
+	for _, backend := range SumBackends() {
+		for _, prec := range []uint{4096, 16384} {
+			b.Run(fmt.Sprintf("%s/prec%d", backend, prec), func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					if _, _, err := PartialSumWith(backend, c, 300, prec); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package series
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/wildfunctions/genetic_series/pkg/expr"
+)
+
+// MaxBFileDigits is the most digits OEIS accepts for one b-file term.
+const MaxBFileDigits = 1000
+
+// RationalTerms returns the exact terms t(Start), t(Start+1), ... of c in
+// lowest terms, up to count of them. It stops early at the first term that
+// is undefined or not rational (see expr.EvalRat), or whose numerator or
+// denominator has more than MaxBFileDigits digits, so the result is always
+// a gap-free prefix of the sequence.
+func RationalTerms(c *Candidate, count int) []*big.Rat {
+	var terms []*big.Rat
+	for i := 0; i < count; i++ {
+		t, ok := rationalTerm(c, c.Start+int64(i))
+		if !ok || decimalDigits(t.Num()) > MaxBFileDigits || decimalDigits(t.Denom()) > MaxBFileDigits {
+			break
+		}
+		terms = append(terms, t)
+	}
+	return terms
+}
+
+// rationalTerm evaluates t(n) of c exactly. Like termAt it treats a
+// math/big panic as a failed term.
+func rationalTerm(c *Candidate, n int64) (t *big.Rat, ok bool) {
+	defer func() {
+		if recover() != nil {
+			t, ok = nil, false
+		}
+	}()
+	num, ok := expr.EvalRat(c.Numerator, n)
+	if !ok {
+		return nil, false
+	}
+	den, ok := expr.EvalRat(c.Denominator, n)
+	if !ok || den.Sign() == 0 {
+		return nil, false
+	}
+	return num.Quo(num, den), true
+}
+
+// decimalDigits returns the number of decimal digits of |v|, ignoring sign.
+func decimalDigits(v *big.Int) int {
+	// BitLen·log10(2) is exact to within one digit; only check near 10^k.
+	d := int(float64(v.BitLen())*0.30102999566398) + 1
+	if d > 1 && new(big.Int).Abs(v).Cmp(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(d-1)), nil)) < 0 {
+		d--
+	}
+	return d
+}
+
+// WriteBFile writes values as an OEIS b-file: "# comment" lines, then one
+// "n a(n)" line per value, numbered from offset.
+func WriteBFile(w io.Writer, offset int64, values []*big.Int, comments ...string) error {
+	bw := bufio.NewWriter(w)
+	for _, c := range comments {
+		fmt.Fprintf(bw, "# %s\n", c)
+	}
+	for i, v := range values {
+		fmt.Fprintf(bw, "%d %s\n", offset+int64(i), v)
+	}
+	return bw.Flush()
+}
+
+// ExportBFiles writes b-files for the numerator and denominator sequences
+// of c's reduced terms, up to count terms, to base.num.txt and
+// base.den.txt. Signs go in the numerators. It returns the number of terms
+// written, which is less than count where RationalTerms stopped; it is an
+// error if not even t(Start) is an exact rational.
+func ExportBFiles(c *Candidate, count int, base string) (int, error) {
+	terms := RationalTerms(c, count)
+	if len(terms) == 0 {
+		return 0, fmt.Errorf("t(%d) of %s is not an exact rational", c.Start, c.String())
+	}
+	nums := make([]*big.Int, len(terms))
+	dens := make([]*big.Int, len(terms))
+	for i, t := range terms {
+		nums[i], dens[i] = t.Num(), t.Denom()
+	}
+	header := fmt.Sprintf("terms t(n), n >= %d, of %s, in lowest terms", c.Start, c.String())
+	for _, f := range []struct {
+		ext, name string
+		values    []*big.Int
+	}{{"num", "numerator", nums}, {"den", "denominator", dens}} {
+		path := base + "." + f.ext + ".txt"
+		file, err := os.Create(path)
+		if err != nil {
+			return 0, err
+		}
+		err = WriteBFile(file, c.Start, f.values, header, "a(n) = "+f.name+" of t(n)")
+		if cerr := file.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return 0, fmt.Errorf("writing %s: %w", path, err)
+		}
+	}
+	return len(terms), nil
+}
+
+// ReadBFile parses an OEIS b-file: "n a(n)" lines with consecutive n,
+// ignoring blank lines and "#" comments. It returns the first n and the
+// values.
+func ReadBFile(r io.Reader) (offset int64, values []*big.Int, err error) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	line := 0
+	for sc.Scan() {
+		line++
+		text := strings.TrimSpace(sc.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Fields(text)
+		if len(fields) < 2 {
+			return 0, nil, fmt.Errorf("line %d: want \"n a(n)\", got %q", line, text)
+		}
+		n, err := strconv.ParseInt(fields[0], 10, 64)
+		if err != nil {
+			return 0, nil, fmt.Errorf("line %d: bad index %q", line, fields[0])
+		}
+		v, ok := new(big.Int).SetString(fields[1], 10)
+		if !ok {
+			return 0, nil, fmt.Errorf("line %d: bad value %q", line, fields[1])
+		}
+		if len(values) == 0 {
+			offset = n
+		} else if n != offset+int64(len(values)) {
+			return 0, nil, fmt.Errorf("line %d: index %d, want %d", line, n, offset+int64(len(values)))
+		}
+		values = append(values, v)
+	}
+	if err := sc.Err(); err != nil {
+		return 0, nil, err
+	}
+	if len(values) == 0 {
+		return 0, nil, fmt.Errorf("no terms")
+	}
+	return offset, values, nil
+}
@@ -0,0 +1,47 @@
+package series
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRationalTermsAndBFiles(t *testing.T) {
+	// Sum_{n=1} (-1)^n · 2n / (n+1)!: terms -1/1, 2/3, -1/4, 1/15, ...
+	c, err := ParseCandidateLatex(`\sum_{n=1}^{\infty} \frac{(-1)^{n} \cdot 2 n}{(n + 1)!}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	terms := RationalTerms(c, 4)
+	var got []string
+	for _, r := range terms {
+		got = append(got, r.RatString())
+	}
+	if strings.Join(got, " ") != "-1 2/3 -1/4 1/15" {
+		t.Errorf("RationalTerms = %v", got)
+	}
+	// The denominator of t(451) is the first past MaxBFileDigits.
+	if n := len(RationalTerms(c, 2000)); n != 450 {
+		t.Errorf("%d terms before the digit limit, want 450", n)
+	}
+
+	base := filepath.Join(t.TempDir(), "b")
+	n, err := ExportBFiles(c, 4, base)
+	if err != nil || n != 4 {
+		t.Fatalf("ExportBFiles = %d, %v", n, err)
+	}
+	num, _ := os.ReadFile(base + ".num.txt")
+	den, _ := os.ReadFile(base + ".den.txt")
+	if !strings.HasPrefix(string(num), "# ") || !strings.HasSuffix(string(num), "1 -1\n2 2\n3 -1\n4 1\n") {
+		t.Errorf("numerator b-file:\n%s", num)
+	}
+	if !strings.HasSuffix(string(den), "1 1\n2 3\n3 4\n4 15\n") {
+		t.Errorf("denominator b-file:\n%s", den)
+	}
+
+	sine, _ := ParseCandidateLatex(`\sum_{n=1}^{\infty} \frac{\sin(n)}{n^{2}}`)
+	if _, err := ExportBFiles(sine, 10, base); err == nil {
+		t.Error("ExportBFiles of a non-rational series succeeded")
+	}
+}
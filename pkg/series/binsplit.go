@@ -0,0 +1,576 @@
+package series
+
+import (
+	"math"
+	"math/big"
+
+	"github.com/wildfunctions/genetic_series/pkg/expr"
+)
+
+// Limits on what AnalyzeHypergeometric will accept. Anything larger is
+// handled by term-by-term evaluation instead.
+const (
+	maxRatioDegree     = 64      // total polynomial degree of a term ratio factor
+	maxRatioStep       = 16      // a in (an+b)! and c^(an+b)
+	maxBinarySplitTerm = 1 << 22 // terms TermsFor will consider
+)
+
+// Hypergeometric is a candidate whose term ratio t(n+1)/t(n) is a rational
+// function p(n)/q(n) with integer coefficients — factorials, binomials,
+// integer powers c^n, alternating signs, and polynomials in n, combined by
+// products and quotients. Such series can be summed by binary splitting,
+// which computes the exact rational partial sum with O(log N) big
+// multiplications instead of N big.Float additions.
+type Hypergeometric struct {
+	cand     *Candidate
+	num, den []poly // p(n) = ∏ num[i](n), q(n) = ∏ den[i](n)
+
+	numF, denF [][]float64 // float64 coefficients for TermsFor
+}
+
+// AnalyzeHypergeometric reports whether c has a rational term ratio and, if
+// so, returns it ready for summation. A ratio of two series has none.
+func AnalyzeHypergeometric(c *Candidate) (*Hypergeometric, bool) {
+	if c.Over != nil {
+		return nil, false
+	}
+	nr, ok := termRatio(c.Numerator)
+	if !ok {
+		return nil, false
+	}
+	dr, ok := termRatio(c.Denominator)
+	if !ok {
+		return nil, false
+	}
+	nr.div(dr)
+	return &Hypergeometric{cand: c, num: nr.num, den: nr.den}, true
+}
+
+// SumBinarySplit sums c to prec bits by binary splitting, choosing the
+// number of terms from the term ratio, and adds c's offset. It returns
+// false if c is not hypergeometric or does not converge geometrically fast
+// enough to reach prec. A ratio is the quotient of both series summed so,
+// with the larger term count.
+//
+// Where a numerator factor of the ratio has an integer root j, t(j+1) is
+// 0 and so are the terms after it, up to the next root m of a denominator
+// factor; the terms from m+1 are summed again from a directly evaluated
+// t(m+1), as a series of their own. At most maxSplitSegments are summed.
+func SumBinarySplit(c *Candidate, prec uint) (*big.Float, int64, bool) {
+	if c.Over != nil {
+		top, n, ok := SumBinarySplit(c.Series(), prec)
+		if !ok {
+			return nil, 0, false
+		}
+		bottom, m, ok := SumBinarySplit(c.Over, prec)
+		if !ok {
+			return nil, 0, false
+		}
+		q, ok := quoSums(top, bottom)
+		return q, max(n, m), ok
+	}
+	h, ok := AnalyzeHypergeometric(c)
+	if !ok {
+		return nil, 0, false
+	}
+	sum := new(big.Float).SetPrec(prec)
+	var total int64
+	for seg := 0; ; seg++ {
+		terms, resume, ok := h.termsFor(prec)
+		if !ok || seg == maxSplitSegments {
+			return nil, 0, false
+		}
+		s, ok := h.Sum(terms, prec)
+		if !ok {
+			return nil, 0, false
+		}
+		sum.Add(sum, s)
+		total += terms
+		if resume == 0 {
+			break
+		}
+		rest := h.cand.Clone()
+		rest.Start = resume
+		h = &Hypergeometric{cand: rest, num: h.num, den: h.den, numF: h.numF, denF: h.denF}
+	}
+	off, ok := c.OffsetValue(prec)
+	if !ok {
+		return nil, 0, false
+	}
+	return sum.Add(sum, off), total, true
+}
+
+// maxSplitSegments bounds the runs of nonzero terms SumBinarySplit sums
+// separately.
+const maxSplitSegments = 8
+
+// TermsFor estimates how many terms are needed for the tail to drop below
+// 2^-prec relative to the first term. It fails where the ratio vanishes
+// but later terms do not, which SumBinarySplit sums in segments.
+func (h *Hypergeometric) TermsFor(prec uint) (int64, bool) {
+	terms, resume, ok := h.termsFor(prec)
+	return terms, ok && resume == 0
+}
+
+// termsFor is TermsFor, also returning, when the terms vanish from a root
+// of the ratio's numerator but a root of its denominator brings them back,
+// the index from which they resume (0 if they do not).
+func (h *Hypergeometric) termsFor(prec uint) (terms, resume int64, ok bool) {
+	target := -float64(prec) - 16
+	logT := 0.0
+	for k := int64(0); k < maxBinarySplitTerm; k++ {
+		j := h.cand.Start + k
+		r, ok := h.ratioF64(j)
+		if !ok {
+			return 0, 0, false
+		}
+		if r == 0 {
+			// t(j+1) = 0, and every later term until a root m of q,
+			// at which q(m) t(m+1) = p(m) t(m) no longer pins t(m+1).
+			m, found, ok := nextRoot(h.den, j)
+			if !ok {
+				return 0, 0, false
+			}
+			if !found {
+				return k + 1, 0, true // series terminates
+			}
+			return k + 1, m + 1, true
+		}
+		if r >= 1 {
+			if k > 64 {
+				return 0, 0, false // diverging
+			}
+			logT += math.Log2(r)
+			continue
+		}
+		if k > 1024 && r > 0.999 {
+			return 0, 0, false // sub-geometric; binary splitting won't help
+		}
+		logT += math.Log2(r)
+		// Geometric tail bound: t_{k+1} / (1 - r).
+		if logT-math.Log2(1-r) < target {
+			return k + 1, 0, true
+		}
+	}
+	return 0, 0, false
+}
+
+// nextRoot returns the least integer m > j at which one of ps vanishes.
+// found is false if there is none; ok is false if one could not be ruled
+// out within maxBinarySplitTerm of j.
+func nextRoot(ps []poly, j int64) (m int64, found, ok bool) {
+	for _, p := range ps {
+		switch p.degree() {
+		case 0:
+			continue
+		case 1:
+			// an + b = 0 at n = -b/a, if that is an integer.
+			r, rem := new(big.Int).QuoRem(new(big.Int).Neg(p.coeff(0)), p.coeff(1), new(big.Int))
+			if rem.Sign() != 0 || !r.IsInt64() || r.Int64() <= j {
+				continue
+			}
+			if !found || r.Int64() < m {
+				m, found = r.Int64(), true
+			}
+			continue
+		}
+		// Every root is within Cauchy's bound 1 + max |c_i / c_d|.
+		d := p.degree()
+		lead := new(big.Float).SetInt(p.coeff(d))
+		bound := 1.0
+		for i := 0; i < d; i++ {
+			q, _ := new(big.Float).Quo(new(big.Float).SetInt(p.coeff(i)), lead).Float64()
+			bound = max(bound, 1+math.Abs(q))
+		}
+		hi := int64(math.Ceil(bound))
+		if bound > float64(j)+maxBinarySplitTerm {
+			return 0, false, false
+		}
+		if found {
+			hi = min(hi, m-1)
+		}
+		x := new(big.Int)
+		for i := j + 1; i <= hi; i++ {
+			if p.eval(x.SetInt64(i)).Sign() == 0 {
+				m, found = i, true
+				break
+			}
+		}
+	}
+	return m, found, true
+}
+
+// ratioF64 returns |p(j)/q(j)| in float64, computed as a sum of logs so
+// large factors cannot overflow. It fails at a root of q, whether or not p
+// vanishes there too.
+func (h *Hypergeometric) ratioF64(j int64) (float64, bool) {
+	if h.numF == nil {
+		h.numF, h.denF = polysF64(h.num), polysF64(h.den)
+	}
+	x := float64(j)
+	lg := 0.0
+	for _, q := range h.denF {
+		v := math.Abs(evalPolyF64(q, x))
+		if v == 0 {
+			return 0, false
+		}
+		lg -= math.Log2(v)
+	}
+	for _, p := range h.numF {
+		v := math.Abs(evalPolyF64(p, x))
+		if v == 0 {
+			return 0, true
+		}
+		lg += math.Log2(v)
+	}
+	return math.Exp2(lg), true
+}
+
+// Sum returns the sum of the first terms terms at precision prec.
+func (h *Hypergeometric) Sum(terms int64, prec uint) (*big.Float, bool) {
+	if terms <= 0 {
+		return nil, false
+	}
+	start := h.cand.Start
+	t0, ok := h.term(start, prec)
+	if !ok {
+		return nil, false
+	}
+	// Leading zero terms (e.g. a factor of n at n=0) carry no ratio
+	// information; skip past them.
+	for skipped := 0; t0.Sign() == 0; skipped++ {
+		if skipped == 4 || terms == 1 {
+			return t0, true
+		}
+		start++
+		terms--
+		if t0, ok = h.term(start, prec); !ok {
+			return nil, false
+		}
+	}
+
+	s, ok := h.split(start, start+terms)
+	if !ok {
+		return nil, false
+	}
+	sum := new(big.Float).SetPrec(prec).SetInt(s.t)
+	sum.Quo(sum, new(big.Float).SetPrec(prec).SetInt(s.q))
+	return sum.Mul(sum, t0), true
+}
+
+// term evaluates t(n) directly.
+func (h *Hypergeometric) term(n int64, prec uint) (*big.Float, bool) {
+	nf := new(big.Float).SetPrec(prec).SetInt64(n)
+	num, ok := h.cand.Numerator.Eval(nf, prec)
+	if !ok {
+		return nil, false
+	}
+	den, ok := h.cand.Denominator.Eval(nf, prec)
+	if !ok || den.Sign() == 0 {
+		return nil, false
+	}
+	return num.Quo(num, den), true
+}
+
+// splitResult holds P(a,b), Q(a,b), T(a,b) with
+// Σ_{k=a}^{b-1} ∏_{j=a}^{k-1} p(j)/q(j) = T/Q and ∏_{j=a}^{b-1} p(j)/q(j) = P/Q.
+type splitResult struct {
+	p, q, t *big.Int
+}
+
+func (h *Hypergeometric) split(a, b int64) (splitResult, bool) {
+	if b-a == 1 {
+		p := h.evalProduct(h.num, a)
+		q := h.evalProduct(h.den, a)
+		if q.Sign() == 0 {
+			return splitResult{}, false
+		}
+		return splitResult{p: p, q: q, t: new(big.Int).Set(q)}, true
+	}
+	m := a + (b-a)/2
+	l, ok := h.split(a, m)
+	if !ok {
+		return splitResult{}, false
+	}
+	r, ok := h.split(m, b)
+	if !ok {
+		return splitResult{}, false
+	}
+	// T = T1*Q2 + P1*T2, P = P1*P2, Q = Q1*Q2
+	t := new(big.Int).Mul(l.t, r.q)
+	t.Add(t, r.t.Mul(l.p, r.t))
+	return splitResult{
+		p: l.p.Mul(l.p, r.p),
+		q: l.q.Mul(l.q, r.q),
+		t: t,
+	}, true
+}
+
+func (h *Hypergeometric) evalProduct(ps []poly, j int64) *big.Int {
+	x := big.NewInt(j)
+	v := big.NewInt(1)
+	for _, p := range ps {
+		v.Mul(v, p.eval(x))
+	}
+	return v
+}
+
+// ratio is a product of polynomial factors over another.
+type ratio struct {
+	num, den []poly
+}
+
+func (r *ratio) mul(o ratio) {
+	r.num = append(r.num, o.num...)
+	r.den = append(r.den, o.den...)
+}
+
+func (r *ratio) div(o ratio) {
+	r.num = append(r.num, o.den...)
+	r.den = append(r.den, o.num...)
+}
+
+func (r ratio) degree() int {
+	d := 0
+	for _, p := range r.num {
+		d += p.degree()
+	}
+	for _, p := range r.den {
+		d += p.degree()
+	}
+	return d
+}
+
+// termRatio returns f(n+1)/f(n) for a hypergeometric factor f.
+func termRatio(node expr.ExprNode) (ratio, bool) {
+	r, ok := termRatioD(node, 0)
+	if !ok || r.degree() > maxRatioDegree {
+		return ratio{}, false
+	}
+	return r, true
+}
+
+func termRatioD(node expr.ExprNode, depth int) (ratio, bool) {
+	if depth > 50 {
+		return ratio{}, false
+	}
+	if !expr.ContainsVar(node) {
+		return ratio{}, true
+	}
+	if p, ok := polyOf(node); ok {
+		if p.degree() == 0 {
+			return ratio{}, true
+		}
+		return ratio{num: []poly{p.shift1()}, den: []poly{p}}, true
+	}
+
+	switch nd := node.(type) {
+	case *expr.UnaryNode:
+		switch nd.Op {
+		case expr.OpNeg:
+			return termRatioD(nd.Child, depth+1)
+		case expr.OpFactorial:
+			a, b, ok := affineOf(nd.Child)
+			if !ok || a <= 0 {
+				return ratio{}, false
+			}
+			return factorialRatio(a, b), true
+		case expr.OpDoubleFactorial:
+			// (an+b)!! steps by a; the factors are an+b+2, an+b+4, ..., an+b+a.
+			a, b, ok := affineOf(nd.Child)
+			if !ok || a <= 0 || a%2 != 0 {
+				return ratio{}, false
+			}
+			var r ratio
+			for i := int64(2); i <= a; i += 2 {
+				r.num = append(r.num, linearPoly(a, b+i))
+			}
+			return r, true
+		case expr.OpAltSign:
+			a, _, ok := affineOf(nd.Child)
+			if !ok {
+				return ratio{}, false
+			}
+			if a%2 == 0 {
+				return ratio{}, true
+			}
+			return ratio{num: []poly{constPoly(-1)}}, true
+		}
+
+	case *expr.BinaryNode:
+		switch nd.Op {
+		case expr.OpMul, expr.OpDiv:
+			l, ok := termRatioD(nd.Left, depth+1)
+			if !ok {
+				return ratio{}, false
+			}
+			r, ok := termRatioD(nd.Right, depth+1)
+			if !ok {
+				return ratio{}, false
+			}
+			if nd.Op == expr.OpMul {
+				l.mul(r)
+			} else {
+				l.div(r)
+			}
+			return l, true
+
+		case expr.OpPow:
+			if !expr.ContainsVar(nd.Left) {
+				return expRatio(nd.Left, nd.Right)
+			}
+			if expr.ContainsVar(nd.Right) {
+				return ratio{}, false
+			}
+			k, ok := constInt(nd.Right)
+			if !ok || k == 0 || k > maxRatioDegree || k < -maxRatioDegree {
+				return ratio{}, false
+			}
+			base, ok := termRatioD(nd.Left, depth+1)
+			if !ok {
+				return ratio{}, false
+			}
+			var r ratio
+			for i := int64(0); i < abs64(k); i++ {
+				if k > 0 {
+					r.mul(base)
+				} else {
+					r.div(base)
+				}
+			}
+			return r, true
+
+		case expr.OpBinomial:
+			return binomialRatio(nd.Left, nd.Right)
+
+		case expr.OpPochhammer:
+			return pochhammerRatio(nd.Left, nd.Right)
+		}
+	}
+	return ratio{}, false
+}
+
+// factorialRatio returns (a(n+1)+b)! / (an+b)! = ∏_{i=1}^{a} (an+b+i).
+func factorialRatio(a, b int64) ratio {
+	var r ratio
+	for i := int64(1); i <= a; i++ {
+		r.num = append(r.num, linearPoly(a, b+i))
+	}
+	return r
+}
+
+// expRatio handles c^(an+b) for an integer constant c: the ratio is c^a.
+func expRatio(base, exp expr.ExprNode) (ratio, bool) {
+	c, ok := constInt(base)
+	if !ok || c == 0 {
+		return ratio{}, false
+	}
+	a, _, ok := affineOf(exp)
+	if !ok {
+		return ratio{}, false
+	}
+	step := new(big.Int).Exp(big.NewInt(c), big.NewInt(abs64(a)), nil)
+	if a >= 0 {
+		return ratio{num: []poly{{step}}}, true
+	}
+	return ratio{den: []poly{{step}}}, true
+}
+
+// binomialRatio handles C(a1 n + b1, a2 n + b2) for a1 >= a2 >= 0.
+func binomialRatio(top, bottom expr.ExprNode) (ratio, bool) {
+	a1, b1, ok := affineOf(top)
+	if !ok {
+		return ratio{}, false
+	}
+	a2, b2, ok := affineOf(bottom)
+	if !ok || a2 < 0 || a1 < a2 {
+		return ratio{}, false
+	}
+	if a2 == 0 {
+		// C(x, k) = x (x-1) ... (x-k+1) / k!, a polynomial in n.
+		if b2 < 0 || b2 > maxRatioDegree {
+			return ratio{}, false
+		}
+		var r ratio
+		for i := int64(0); i < b2; i++ {
+			p := linearPoly(a1, b1-i)
+			r.num = append(r.num, p.shift1())
+			r.den = append(r.den, p)
+		}
+		return r, true
+	}
+	// C(x, y) = x! / (y! (x-y)!)
+	r := factorialRatio(a1, b1)
+	r.div(factorialRatio(a2, b2))
+	if a1 > a2 {
+		r.div(factorialRatio(a1-a2, b1-b2))
+	}
+	return r, true
+}
+
+// pochhammerRatio handles (x)_{an+b} for a rational constant x = u/v and
+// a > 0: the ratio is ∏_{i=0}^{a-1} (x + an+b+i) = ∏ (v·an + v(b+i) + u)/v.
+// Rising factorials of half-integers, (1/2)_n and (3/2)_n, are the
+// parameters of the hypergeometric series behind many fast π formulas.
+func pochhammerRatio(x, k expr.ExprNode) (ratio, bool) {
+	if expr.ContainsVar(x) {
+		return ratio{}, false
+	}
+	xr, ok := expr.EvalRat(x, 0)
+	if !ok || !xr.Num().IsInt64() || !xr.Denom().IsInt64() {
+		return ratio{}, false
+	}
+	u, v := xr.Num().Int64(), xr.Denom().Int64()
+	if abs64(u) > 1<<20 || v > 1<<20 {
+		return ratio{}, false
+	}
+	a, b, ok := affineOf(k)
+	if !ok || a <= 0 {
+		return ratio{}, false
+	}
+	var r ratio
+	for i := int64(0); i < a; i++ {
+		r.num = append(r.num, linearPoly(v*a, v*(b+i)+u))
+		if v != 1 {
+			r.den = append(r.den, constPoly(v))
+		}
+	}
+	return r, true
+}
+
+// affineOf returns (a, b) if node is the polynomial an+b with small integer
+// coefficients.
+func affineOf(node expr.ExprNode) (a, b int64, ok bool) {
+	p, ok := polyOf(node)
+	if !ok || p.degree() > 1 {
+		return 0, 0, false
+	}
+	if !p.coeff(0).IsInt64() || !p.coeff(1).IsInt64() {
+		return 0, 0, false
+	}
+	a, b = p.coeff(1).Int64(), p.coeff(0).Int64()
+	if abs64(a) > maxRatioStep || abs64(b) > 1<<32 {
+		return 0, 0, false
+	}
+	return a, b, true
+}
+
+// constInt evaluates a variable-free subtree that must be an integer.
+func constInt(node expr.ExprNode) (int64, bool) {
+	if expr.ContainsVar(node) {
+		return 0, false
+	}
+	v, ok := node.Eval(new(big.Float), 64)
+	if !ok || !v.IsInt() {
+		return 0, false
+	}
+	i, acc := v.Int64()
+	return i, acc == big.Exact
+}
+
+func abs64(x int64) int64 {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
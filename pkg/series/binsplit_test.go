@@ -0,0 +1,167 @@
+package series
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/wildfunctions/genetic_series/pkg/constants"
+)
+
+func mustParse(t *testing.T, latex string) *Candidate {
+	t.Helper()
+	c, err := ParseCandidateLatex(latex)
+	if err != nil {
+		t.Fatalf("ParseCandidateLatex(%q): %v", latex, err)
+	}
+	return c
+}
+
+func TestBinarySplitMatchesDirectSum(t *testing.T) {
+	tests := []string{
+		`\sum_{n=0}^{\infty} \frac{1}{n!}`,
+		`\sum_{n=0}^{\infty} \frac{26 \cdot n! \cdot (2n)!}{(3n)! \cdot 2^n}`,
+		`2 \sum_{k=0}^{\infty} \frac{k! \, (2k)! \, (25k - 3)}{(3k)! \, 2^{k}}`,
+		`\frac{\sqrt{8}}{9801} \sum_{n=0}^{\infty} \frac{(4n)!}{(n!)^4} \frac{1103 + 26390n}{396^{4n}}`,
+		`\sum_{n=1}^{\infty} \frac{\binom{2n}{n}}{4^n (n+1)}`,
+		`\sum_{n=0}^{\infty} \frac{(-1)^n (4n+1)}{(2n+1)(n+3)}`,
+		`\sum_{n=1}^{\infty} \frac{1}{n \cdot 2^n}`,
+		`\sum_{n=0}^{\infty} \frac{n}{3^n}`,
+		`\sum_{n=0}^{\infty} \frac{(2n)!!}{(2n+1)!! \cdot 5^n}`,
+		`\sum_{n=0}^{\infty} \frac{\pi (-1)^n}{n! \, {e}^{2}}`,
+		`\sum_{n=0}^{\infty} \frac{(\frac{1}{2})_{n} (\frac{3}{2})_{2n+1}}{n! \, (3)_{3n} \cdot 2^n}`,
+	}
+
+	const terms = 40
+	for _, latex := range tests {
+		c := mustParse(t, latex)
+		t.Run(c.String(), func(t *testing.T) {
+			h, ok := AnalyzeHypergeometric(c)
+			if !ok {
+				t.Fatal("not recognized as hypergeometric")
+			}
+			got, ok := h.Sum(terms, testPrec)
+			if !ok {
+				t.Fatal("Sum failed")
+			}
+			want := new(big.Float).SetPrec(testPrec)
+			for i := c.Start; i < c.Start+terms; i++ {
+				v, ok := h.term(i, testPrec)
+				if !ok {
+					t.Fatalf("term(%d) failed", i)
+				}
+				want.Add(want, v)
+			}
+			diff := new(big.Float).Sub(got, want)
+			if want.Sign() != 0 {
+				diff.Quo(diff, want)
+			}
+			eps := new(big.Float).SetMantExp(big.NewFloat(1), -(testPrec - 16))
+			if diff.Abs(diff).Cmp(eps) > 0 {
+				t.Errorf("binary split = %s, direct = %s", got.Text('g', 40), want.Text('g', 40))
+			}
+		})
+	}
+}
+
+func TestSumBinarySplitHighPrecision(t *testing.T) {
+	c := mustParse(t, `\sum_{n=0}^{\infty} \frac{1}{n!}`)
+	const prec = 16384
+	sum, terms, ok := SumBinarySplit(c, prec)
+	if !ok {
+		t.Fatal("SumBinarySplit failed")
+	}
+	t.Logf("e to %d bits in %d terms", prec, terms)
+
+	// Doubling the terms must not move the result.
+	h, _ := AnalyzeHypergeometric(c)
+	more, ok := h.Sum(2*terms, prec)
+	if !ok {
+		t.Fatal("Sum failed")
+	}
+	diff := new(big.Float).Sub(sum, more)
+	if diff.Sign() != 0 && diff.MantExp(nil) > -prec+8 {
+		t.Errorf("sum not converged: |diff| ~ 2^%d", diff.MantExp(nil))
+	}
+
+	e := constants.Get("e").Value
+	diff.Sub(sum, e)
+	if diff.MantExp(nil) > -500 {
+		t.Errorf("sum = %s, want e", sum.Text('g', 50))
+	}
+}
+
+func TestSumBinarySplitPastZeroTerms(t *testing.T) {
+	// t(3) = 0 from the root of n - 3, but the terms after it are not:
+	// the ratio's numerator vanishes at n = 2 and its denominator at 3.
+	tests := []struct {
+		latex string
+		want  string
+	}{
+		{`\sum_{n=0}^{\infty} \frac{(n-3) 2^{n}}{n!}`, "-7.38905609893065022723042746057500781318"}, // -e^2
+		{`\sum_{n=1}^{\infty} \frac{n-3}{n! \, n}`, ""},
+		{`\sum_{n=0}^{\infty} \frac{(n-1)(n-4)}{n! \cdot 3^{n}}`, ""},
+	}
+	for _, tt := range tests {
+		c := mustParse(t, tt.latex)
+		got, _, ok := SumBinarySplit(c, testPrec)
+		if !ok {
+			t.Errorf("%s: SumBinarySplit failed", c)
+			continue
+		}
+		want := new(big.Float).SetPrec(testPrec)
+		if tt.want != "" {
+			want.SetString(tt.want)
+		} else {
+			h, _ := AnalyzeHypergeometric(c)
+			for i := c.Start; i < c.Start+200; i++ {
+				v, ok := h.term(i, testPrec)
+				if !ok {
+					t.Fatalf("%s: term(%d) failed", c, i)
+				}
+				want.Add(want, v)
+			}
+		}
+		diff := new(big.Float).Sub(got, want)
+		if diff.Sign() != 0 && diff.MantExp(nil) > -120 {
+			t.Errorf("%s: binary split = %s, want %s", c, got.Text('g', 30), want.Text('g', 30))
+		}
+	}
+
+	// (-3)_n vanishes from n = 4 on with nothing to bring it back:
+	// Σ (-3)_n/n! = (1-1)^3 terminates after four terms.
+	c := mustParse(t, `\sum_{n=0}^{\infty} \frac{(-3)_{n}}{n!}`)
+	h, _ := AnalyzeHypergeometric(c)
+	if terms, ok := h.TermsFor(testPrec); !ok || terms != 4 {
+		t.Errorf("(-3)_n/n!: TermsFor = %d, %v, want 4 terms", terms, ok)
+	}
+	if sum, _, ok := SumBinarySplit(c, testPrec); !ok || sum.Sign() != 0 {
+		t.Errorf("(-3)_n/n!: SumBinarySplit = %v, %v, want 0", sum, ok)
+	}
+	// A zero term the series recovers from is no place to stop.
+	h, _ = AnalyzeHypergeometric(mustParse(t, tests[0].latex))
+	if _, ok := h.TermsFor(testPrec); ok {
+		t.Error("(n-3)2^n/n!: TermsFor stopped at the zero term")
+	}
+}
+
+func TestAnalyzeHypergeometricRejects(t *testing.T) {
+	tests := []string{
+		`\sum_{n=1}^{\infty} \frac{F_{n}}{2^n}`,
+		`\sum_{n=1}^{\infty} \frac{\sqrt{n+1} - \sqrt{n}}{n^2}`,
+		`\sum_{n=1}^{\infty} \frac{\lfloor \sqrt{n} \rfloor}{n^3}`,
+		`\sum_{n=1}^{\infty} \frac{1}{n^n}`,
+		`\sum_{n=1}^{\infty} \frac{1}{(n^2)!}`,
+	}
+	for _, latex := range tests {
+		c := mustParse(t, latex)
+		if _, ok := AnalyzeHypergeometric(c); ok {
+			t.Errorf("%s: recognized as hypergeometric", c)
+		}
+	}
+
+	// Hypergeometric but too slow for binary splitting to be useful.
+	c := mustParse(t, `\sum_{n=1}^{\infty} \frac{1}{n^2}`)
+	if _, _, ok := SumBinarySplit(c, testPrec); ok {
+		t.Error("1/n^2: SumBinarySplit should refuse sub-geometric convergence")
+	}
+}
@@ -1,43 +1,212 @@
+// Package series is a candidate infinite series, Σ_{n=Start}^∞
+// Numerator/Denominator plus an Offset (or a ratio of two), and what is
+// done with one: parsing from LaTeX, summing through an Evaluator, scoring
+// against a target (ComputeFitness), binary splitting, acceleration,
+// verification and feature vectors.
 package series
 
 import (
 	"fmt"
+	"math/big"
 
 	"github.com/wildfunctions/genetic_series/pkg/expr"
 )
 
-// Candidate represents a candidate series: Sum_{n=Start}^{inf} Numerator(n) / Denominator(n)
+// Candidate represents a candidate series:
+// Offset + Sum_{n=Start}^{inf} Numerator(n) / Denominator(n)
+// or, if Over is set, that divided by the series Over: a ratio of two
+// series, each of which may be unremarkable on its own.
 type Candidate struct {
 	Numerator   expr.ExprNode
 	Denominator expr.ExprNode
-	Start       int64 // starting index (0 or 1 typically)
+	Start       int64         // starting index (0 or 1 typically)
+	Offset      expr.ExprNode // constant added to the sum (no n in it), or nil for none
+	Over        *Candidate    // series the sum is divided by (itself without Over), or nil for none
 }
 
-// Clone returns a deep copy of the candidate.
+// Clone returns a copy of the candidate that shares its expression trees.
+// Trees are persistent (see expr.ReplaceAt), so the copy's fields can be
+// reassigned freely without affecting c; use DeepClone for private trees.
 func (c *Candidate) Clone() *Candidate {
 	return &Candidate{
+		Numerator:   c.Numerator,
+		Denominator: c.Denominator,
+		Start:       c.Start,
+		Offset:      c.Offset,
+		Over:        c.Over,
+	}
+}
+
+// Series returns the series c sums before dividing by Over: c itself if it
+// has no Over, and otherwise a copy without it.
+func (c *Candidate) Series() *Candidate {
+	if c.Over == nil {
+		return c
+	}
+	out := c.Clone()
+	out.Over = nil
+	return out
+}
+
+// DeepClone returns a copy of the candidate with its own expression trees.
+func (c *Candidate) DeepClone() *Candidate {
+	out := &Candidate{
 		Numerator:   c.Numerator.Clone(),
 		Denominator: c.Denominator.Clone(),
 		Start:       c.Start,
 	}
+	if c.Offset != nil {
+		out.Offset = c.Offset.Clone()
+	}
+	if c.Over != nil {
+		out.Over = c.Over.DeepClone()
+	}
+	return out
 }
 
 // String returns a human-readable representation.
 func (c *Candidate) String() string {
-	return fmt.Sprintf("Sum_{n=%d}^{inf} (%s) / (%s)", c.Start, c.Numerator.String(), c.Denominator.String())
+	s := fmt.Sprintf("Sum_{n=%d}^{inf} (%s) / (%s)", c.Start, c.Numerator.String(), c.Denominator.String())
+	if c.Offset != nil {
+		s = c.Offset.String() + " + " + s
+	}
+	if c.Over != nil {
+		s = "(" + s + ") / (" + c.Over.String() + ")"
+	}
+	return s
+}
+
+// SimplifyWarnings returns the warnings simplifying c as the search does
+// raises, each message prefixed with the part of c it is in. The trees
+// have their constant subtrees folded at prec (see
+// expr.SimplifyBigFloatWarnings); the offset is only simplified, never
+// folded, so it is not rounded.
+func (c *Candidate) SimplifyWarnings(prec uint) []expr.Warning {
+	parts := []struct {
+		name string
+		node expr.ExprNode
+		fold bool
+	}{{"numerator", c.Numerator, true}, {"denominator", c.Denominator, true}, {"offset", c.Offset, false}}
+	var out []expr.Warning
+	if c.Over != nil {
+		for _, w := range c.Over.SimplifyWarnings(prec) {
+			w.Msg = "divisor " + w.Msg
+			out = append(out, w)
+		}
+	}
+	for _, part := range parts {
+		if part.node == nil {
+			continue
+		}
+		var warnings []expr.Warning
+		if part.fold {
+			_, warnings = expr.SimplifyBigFloatWarnings(part.node, prec)
+		} else {
+			_, warnings = expr.SimplifyWarnings(part.node)
+		}
+		for _, w := range warnings {
+			w.Msg = part.name + ": " + w.Msg
+			out = append(out, w)
+		}
+	}
+	return out
 }
 
 // LaTeX returns a LaTeX representation.
 func (c *Candidate) LaTeX() string {
-	return fmt.Sprintf("\\sum_{n=%d}^{\\infty} \\frac{%s}{%s}", c.Start, c.Numerator.LaTeX(), c.Denominator.LaTeX())
+	s := fmt.Sprintf("\\sum_{n=%d}^{\\infty} \\frac{%s}{%s}", c.Start, c.Numerator.LaTeX(), c.Denominator.LaTeX())
+	if c.Offset != nil {
+		s = c.Offset.LaTeX() + " + " + s
+	}
+	if c.Over != nil {
+		s = "\\frac{" + s + "}{" + c.Over.LaTeX() + "}"
+	}
+	return s
 }
 
-// Complexity returns combined complexity of both trees.
+// LaTeXMap returns LaTeX() together with the span of every numerator and
+// denominator node in it, indexed in preorder as for expr.LaTeXMap. For a
+// ratio they are the nodes of c's own trees, not of Over's.
+func (c *Candidate) LaTeXMap() (latex string, num, den []expr.Span) {
+	head := fmt.Sprintf("\\sum_{n=%d}^{\\infty} \\frac{", c.Start)
+	if c.Offset != nil {
+		head = c.Offset.LaTeX() + " + " + head
+	}
+	if c.Over != nil {
+		head = "\\frac{" + head
+	}
+	numTeX, num := expr.LaTeXMapAt(c.Numerator, len(head))
+	mid := head + numTeX + "}{"
+	denTeX, den := expr.LaTeXMapAt(c.Denominator, len(mid))
+	latex = mid + denTeX + "}"
+	if c.Over != nil {
+		latex += "}{" + c.Over.LaTeX() + "}"
+	}
+	return latex, num, den
+}
+
+// bitsPerComplexityUnit converts description length to complexity units.
+// Three bits is about one node of a typical tree, which keeps Complexity on
+// the scale of the node weights it replaced (and FitnessWeights.Complexity
+// meaningful).
+const bitsPerComplexityUnit = 3.0
+
+// Complexity returns the combined description length of both trees and
+// the offset (see expr.DescriptionLength), in units of about one node,
+// and Over's added to it.
 func (c *Candidate) Complexity() float64 {
-	return expr.WeightedComplexity(c.Numerator) + expr.WeightedComplexity(c.Denominator)
+	bits := expr.DescriptionLength(c.Numerator) + expr.DescriptionLength(c.Denominator)
+	if c.Offset != nil {
+		bits += expr.DescriptionLength(c.Offset)
+	}
+	if c.Over != nil {
+		bits += c.Over.Complexity() * bitsPerComplexityUnit
+	}
+	return bits / bitsPerComplexityUnit
+}
+
+// EvalCost estimates the cost of evaluating one term of c (see
+// expr.EvalCost), and of Over's. The offset is evaluated once per sum, so
+// it is free.
+func (c *Candidate) EvalCost() float64 {
+	cost := expr.EvalCost(c.Numerator) + expr.EvalCost(c.Denominator)
+	if c.Over != nil {
+		cost += c.Over.EvalCost()
+	}
+	return cost
 }
 
-// NodeCount returns the total node count of both trees.
+// NodeCount returns the total node count of both trees and the offset,
+// and of Over.
 func (c *Candidate) NodeCount() int {
-	return c.Numerator.NodeCount() + c.Denominator.NodeCount()
+	n := c.Numerator.NodeCount() + c.Denominator.NodeCount()
+	if c.Offset != nil {
+		n += c.Offset.NodeCount()
+	}
+	if c.Over != nil {
+		n += c.Over.NodeCount()
+	}
+	return n
+}
+
+// OffsetValue evaluates the offset at prec: zero if c has none, false if
+// it is undefined. Evaluators start the sum from it.
+func (c *Candidate) OffsetValue(prec uint) (*big.Float, bool) {
+	v := new(big.Float).SetPrec(prec)
+	if c.Offset == nil {
+		return v, true
+	}
+	val, ok := c.Offset.Eval(new(big.Float).SetPrec(prec), prec)
+	if !ok {
+		return nil, false
+	}
+	return v.Set(val), true
+}
+
+// OffsetF64 is OffsetValue in float64.
+func (c *Candidate) OffsetF64() (float64, bool) {
+	if c.Offset == nil {
+		return 0, true
+	}
+	return c.Offset.EvalF64(0)
 }
@@ -0,0 +1,249 @@
+package series
+
+import (
+	"math"
+	"sort"
+
+	"github.com/wildfunctions/genetic_series/pkg/expr"
+)
+
+// Canonical returns a representative of c's symmetry class: candidates
+// that are the same series written differently map to the same
+// representative. It applies, in order:
+//
+//   - Leading zeros: exactly-zero leading terms are skipped (DropZeroLeading).
+//   - AltSign phase: (-1)^(n+k) becomes ±(-1)^n (as OpAltSign).
+//   - Reindexing: if every n outside (-1)^n appears as n+k for one k,
+//     the sum is shifted to start at Start+k, so Sum_{n=0} 1/(n+1)^2 and
+//     Sum_{n=1} 1/n^2 agree.
+//   - Sign: a negated (or negative constant) denominator moves its sign to
+//     the numerator, so -a/-b is a/b and a/-b is -a/b; so does a leading
+//     (-1)^e factor, its own reciprocal, so a/((-1)^n b) is (-1)^n a/b.
+//   - Commutation: chains of + and * are flattened and their operands
+//     sorted, (-1)^e factors first, so (a*b)*c, a*(c*b) and (c*a)*b agree.
+//   - Offset: simplified and sorted the same way, and dropped if it is 0.
+//
+// Simplify runs between the steps. A ratio has both of its series made
+// canonical. c is not modified.
+func Canonical(c *Candidate) *Candidate {
+	if c.Over != nil {
+		out := Canonical(c.Series())
+		out.Over = Canonical(c.Over)
+		return out
+	}
+	out := DropZeroLeading(c).Clone()
+	out.Numerator = normAltSign(expr.Simplify(out.Numerator))
+	out.Denominator = normAltSign(expr.Simplify(out.Denominator))
+
+	if k, ok := indexShift(out.Numerator, out.Denominator); ok && out.Start+k >= 0 {
+		out = Reindex(out, k)
+		out.Numerator = normAltSign(out.Numerator)
+		out.Denominator = normAltSign(out.Denominator)
+	}
+
+	switch d := out.Denominator.(type) {
+	case *expr.UnaryNode:
+		switch d.Op {
+		case expr.OpNeg:
+			out.Numerator = &expr.UnaryNode{Op: expr.OpNeg, Child: out.Numerator}
+			out.Denominator = d.Child
+		case expr.OpAltSign:
+			out.Numerator = &expr.BinaryNode{Op: expr.OpMul, Left: d, Right: out.Numerator}
+			out.Denominator = &expr.ConstNode{Val: 1}
+		}
+	case *expr.BinaryNode:
+		if u, ok := d.Left.(*expr.UnaryNode); ok && d.Op == expr.OpMul && u.Op == expr.OpAltSign {
+			out.Numerator = &expr.BinaryNode{Op: expr.OpMul, Left: u, Right: out.Numerator}
+			out.Denominator = d.Right
+		}
+	case *expr.ConstNode:
+		if d.Val < 0 && d.Val > math.MinInt64 {
+			out.Numerator = &expr.UnaryNode{Op: expr.OpNeg, Child: out.Numerator}
+			out.Denominator = &expr.ConstNode{Val: -d.Val}
+		}
+	}
+
+	out.Numerator = sortCommutative(expr.Simplify(out.Numerator))
+	out.Denominator = sortCommutative(expr.Simplify(out.Denominator))
+	if out.Offset != nil {
+		out.Offset = sortCommutative(expr.Simplify(out.Offset))
+		if k, ok := out.Offset.(*expr.ConstNode); ok && k.Val == 0 {
+			out.Offset = nil
+		}
+	}
+	return out
+}
+
+// CanonicalKey returns the string of Canonical(c). The engine keys the tabu
+// set, the archive and the hall of fame on it, so equivalent candidates
+// count once.
+func CanonicalKey(c *Candidate) string {
+	return Canonical(c).String()
+}
+
+// offsetOf reports whether node is n+k, k+n or n-k for a constant k, and k.
+func offsetOf(node expr.ExprNode) (int64, bool) {
+	b, ok := node.(*expr.BinaryNode)
+	if !ok {
+		return 0, false
+	}
+	_, lvar := b.Left.(*expr.VarNode)
+	_, rvar := b.Right.(*expr.VarNode)
+	lc, lconst := b.Left.(*expr.ConstNode)
+	rc, rconst := b.Right.(*expr.ConstNode)
+	switch {
+	case b.Op == expr.OpAdd && lvar && rconst:
+		return rc.Val, true
+	case b.Op == expr.OpAdd && lconst && rvar:
+		return lc.Val, true
+	case b.Op == expr.OpSub && lvar && rconst && rc.Val > math.MinInt64:
+		return -rc.Val, true
+	}
+	return 0, false
+}
+
+// isAltSignN reports whether node is (-1)^n.
+func isAltSignN(node expr.ExprNode) bool {
+	u, ok := node.(*expr.UnaryNode)
+	if !ok || u.Op != expr.OpAltSign {
+		return false
+	}
+	_, ok = u.Child.(*expr.VarNode)
+	return ok
+}
+
+// normAltSign rewrites (-1)^(n+k) as (-1)^n, negated if k is odd. A
+// power of the constant -1 (as the parser reads (-1)^n) counts as AltSign.
+func normAltSign(node expr.ExprNode) expr.ExprNode {
+	if b, ok := node.(*expr.BinaryNode); ok && b.Op == expr.OpPow {
+		if c, ok := b.Left.(*expr.ConstNode); ok && c.Val == -1 {
+			node = &expr.UnaryNode{Op: expr.OpAltSign, Child: b.Right}
+		}
+	}
+	switch n := node.(type) {
+	case *expr.UnaryNode:
+		if n.Op == expr.OpAltSign {
+			if k, ok := offsetOf(n.Child); ok {
+				alt := &expr.UnaryNode{Op: expr.OpAltSign, Child: &expr.VarNode{}}
+				if k%2 != 0 {
+					return &expr.UnaryNode{Op: expr.OpNeg, Child: alt}
+				}
+				return alt
+			}
+		}
+		child := normAltSign(n.Child)
+		if child == n.Child {
+			return n
+		}
+		return &expr.UnaryNode{Op: n.Op, Child: child}
+	case *expr.BinaryNode:
+		left, right := normAltSign(n.Left), normAltSign(n.Right)
+		if left == n.Left && right == n.Right {
+			return n
+		}
+		return &expr.BinaryNode{Op: n.Op, Left: left, Right: right}
+	}
+	return node
+}
+
+// indexShift returns the k such that every occurrence of n in the trees,
+// other than in (-1)^n, is n+k, if there is one and it is not 0.
+func indexShift(trees ...expr.ExprNode) (int64, bool) {
+	var k int64
+	found, consistent := false, true
+	var walk func(expr.ExprNode)
+	walk = func(node expr.ExprNode) {
+		if !consistent || isAltSignN(node) {
+			return
+		}
+		if off, ok := offsetOf(node); ok {
+			if found && off != k {
+				consistent = false
+			}
+			k, found = off, true
+			return
+		}
+		switch n := node.(type) {
+		case *expr.VarNode:
+			consistent = false // a bare n has offset 0
+		case *expr.UnaryNode:
+			walk(n.Child)
+		case *expr.BinaryNode:
+			walk(n.Left)
+			walk(n.Right)
+		}
+	}
+	for _, t := range trees {
+		walk(t)
+	}
+	return k, found && consistent && k != 0
+}
+
+// sortCommutative flattens each chain of + or * into its operands, sorts
+// them and rebuilds the chain left to right. The order is part of the
+// canonical key format, so it depends only on the operands' values and
+// structure, never on how they print: (-1)^e factors first, then integer
+// constants in numeric order, then everything else by expr.StableHash, with
+// structurally equal operands adjacent.
+func sortCommutative(node expr.ExprNode) expr.ExprNode {
+	switch n := node.(type) {
+	case *expr.UnaryNode:
+		return &expr.UnaryNode{Op: n.Op, Child: sortCommutative(n.Child)}
+	case *expr.BinaryNode:
+		if n.Op != expr.OpAdd && n.Op != expr.OpMul {
+			return &expr.BinaryNode{Op: n.Op, Left: sortCommutative(n.Left), Right: sortCommutative(n.Right)}
+		}
+		var operands []expr.ExprNode
+		var flatten func(expr.ExprNode)
+		flatten = func(e expr.ExprNode) {
+			if b, ok := e.(*expr.BinaryNode); ok && b.Op == n.Op {
+				flatten(b.Left)
+				flatten(b.Right)
+				return
+			}
+			operands = append(operands, sortCommutative(e))
+		}
+		flatten(n)
+		keys := make([]operandKey, len(operands))
+		idx := make([]int, len(operands))
+		for i, o := range operands {
+			keys[i], idx[i] = keyOfOperand(o, n.Op), i
+		}
+		sort.SliceStable(idx, func(a, b int) bool {
+			return keys[idx[a]].less(keys[idx[b]])
+		})
+		out := operands[idx[0]]
+		for _, i := range idx[1:] {
+			out = &expr.BinaryNode{Op: n.Op, Left: out, Right: operands[i]}
+		}
+		return out
+	}
+	return node
+}
+
+// operandKey is where an operand goes in a sorted commutative chain.
+type operandKey struct {
+	class int    // 0 for a (-1)^e factor, 1 for a constant, 2 otherwise
+	val   int64  // a constant's value
+	hash  uint64 // expr.StableHash of the rest
+}
+
+func keyOfOperand(o expr.ExprNode, op expr.BinaryOp) operandKey {
+	if u, ok := o.(*expr.UnaryNode); ok && u.Op == expr.OpAltSign && op == expr.OpMul {
+		return operandKey{class: 0, hash: expr.StableHash(o)}
+	}
+	if c, ok := o.(*expr.ConstNode); ok {
+		return operandKey{class: 1, val: c.Val}
+	}
+	return operandKey{class: 2, hash: expr.StableHash(o)}
+}
+
+func (k operandKey) less(o operandKey) bool {
+	if k.class != o.class {
+		return k.class < o.class
+	}
+	if k.val != o.val {
+		return k.val < o.val
+	}
+	return k.hash < o.hash
+}
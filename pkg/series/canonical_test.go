@@ -0,0 +1,99 @@
+package series
+
+import (
+	"testing"
+
+	"github.com/wildfunctions/genetic_series/pkg/expr"
+)
+
+func TestCanonicalKey(t *testing.T) {
+	classes := [][]string{
+		{ // negated numerator and denominator
+			`\sum_{n=1}^{\infty} \frac{1}{n^2}`,
+			`\sum_{n=1}^{\infty} \frac{-1}{-(n^2)}`,
+			`\sum_{n=0}^{\infty} \frac{1}{(n+1)^2}`,
+		},
+		{ // AltSign phase and start index
+			`\sum_{n=0}^{\infty} \frac{(-1)^n}{n+1}`,
+			`\sum_{n=1}^{\infty} \frac{(-1)^{n+1}}{n}`,
+			`\sum_{n=1}^{\infty} \frac{-(-1)^n}{n}`,
+			`\sum_{n=1}^{\infty} \frac{(-1)^n}{-n}`,
+		},
+		{ // powers of negative bases and (-1)^n in the denominator
+			`\sum_{n=0}^{\infty} \frac{(-1)^n \cdot 2^n}{n!}`,
+			`\sum_{n=0}^{\infty} \frac{(-2)^n}{n!}`,
+			`\sum_{n=0}^{\infty} \frac{2^n}{(-1)^n \cdot n!}`,
+			`\sum_{n=0}^{\infty} \frac{2^n}{n! \cdot (-1)^n}`,
+		},
+		{ // commutative factors
+			`\sum_{n=0}^{\infty} \frac{1}{n! \cdot 2^n \cdot (n+3)}`,
+			`\sum_{n=0}^{\infty} \frac{1}{2^n \cdot ((n+3) \cdot n!)}`,
+			`\sum_{n=0}^{\infty} \frac{1}{(n+3) \cdot n! \cdot 2^n}`,
+		},
+	}
+	var keys []string
+	for _, class := range classes {
+		want := CanonicalKey(mustParse(t, class[0]))
+		for _, latex := range class[1:] {
+			if got := CanonicalKey(mustParse(t, latex)); got != want {
+				t.Errorf("CanonicalKey(%s) = %s, want %s (as for %s)", latex, got, want, class[0])
+			}
+		}
+		keys = append(keys, want)
+	}
+	for i := range keys {
+		for j := i + 1; j < len(keys); j++ {
+			if keys[i] == keys[j] {
+				t.Errorf("classes %d and %d share key %s", i, j, keys[i])
+			}
+		}
+	}
+
+	// Different series stay apart.
+	a := CanonicalKey(mustParse(t, `\sum_{n=0}^{\infty} \frac{(-1)^n}{n+1}`))
+	b := CanonicalKey(mustParse(t, `\sum_{n=0}^{\infty} \frac{(-1)^n}{n+2}`))
+	if a == b {
+		t.Errorf("(-1)^n/(n+1) and (-1)^n/(n+2) share key %s", a)
+	}
+}
+
+func TestSortCommutative_Order(t *testing.T) {
+	n := &expr.VarNode{}
+	add := func(a, b expr.ExprNode) expr.ExprNode { return &expr.BinaryNode{Op: expr.OpAdd, Left: a, Right: b} }
+	k := func(v int64) expr.ExprNode { return &expr.ConstNode{Val: v} }
+	// Constants go first in numeric order (10 after 2, whatever the
+	// strings), then the rest in StableHash order.
+	fact := &expr.UnaryNode{Op: expr.OpFactorial, Child: n}
+	rest := []expr.ExprNode{n, fact}
+	if expr.StableHash(fact) < expr.StableHash(n) {
+		rest[0], rest[1] = fact, n
+	}
+	want := add(add(add(add(k(-3), k(2)), k(10)), rest[0]), rest[1]).String()
+	for _, tree := range []expr.ExprNode{
+		add(add(k(10), n), add(fact, add(k(2), k(-3)))),
+		add(fact, add(add(n, k(-3)), add(k(10), k(2)))),
+	} {
+		if got := sortCommutative(tree).String(); got != want {
+			t.Errorf("sortCommutative(%s) = %s, want %s", tree, got, want)
+		}
+	}
+}
+
+func TestCanonicalPreservesSum(t *testing.T) {
+	for _, latex := range []string{
+		`\sum_{n=0}^{\infty} \frac{(-1)^n}{(n+1)(n+1)}`,
+		`\sum_{n=1}^{\infty} \frac{(-1)^{n+1}}{-(n!)}`,
+		`\sum_{n=0}^{\infty} \frac{n+2}{(n+2)! \cdot 3}`,
+	} {
+		c := mustParse(t, latex)
+		canon := Canonical(c)
+		r1 := EvaluateCandidate(c, 200, testPrec)
+		r2 := EvaluateCandidate(canon, 200, testPrec)
+		if !r1.OK || !r2.OK {
+			t.Fatalf("%s: evaluation failed (ok %v, %v)", latex, r1.OK, r2.OK)
+		}
+		if countCorrectDigits(r2.PartialSum, r1.PartialSum) < 30 {
+			t.Errorf("Canonical(%s) = %s sums to %s, want %s", latex, canon, r2.PartialSum.Text('g', 20), r1.PartialSum.Text('g', 20))
+		}
+	}
+}
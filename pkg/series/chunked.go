@@ -0,0 +1,218 @@
+package series
+
+import (
+	"fmt"
+	"math/big"
+	"runtime"
+	"sync"
+
+	"github.com/wildfunctions/genetic_series/pkg/expr"
+)
+
+// SumOrder is the order a ResumableSum adds its terms in.
+type SumOrder int
+
+const (
+	// SumSequential adds the terms one by one from the start, with one
+	// BlockEvaluator stepping through them: the incremental terms never
+	// reinitialize, but the sum runs on one core.
+	SumSequential SumOrder = iota
+	// SumChunked splits the terms into chunks of chunkTerms, sums each
+	// chunk on its own BlockEvaluator with Neumaier compensation, and adds
+	// the chunk sums pairwise in index order. The chunks are spread over
+	// all workers, and since neither the chunks nor the reduction tree
+	// depend on how many there are or which finishes first, the sum is
+	// bit-identical for any worker count. Incremental terms reinitialize
+	// once per chunk, so it pays off where a term costs far more than that
+	// (very high precision), or where the term has nothing to step.
+	SumChunked
+)
+
+var sumOrderNames = []string{SumSequential: "sequential", SumChunked: "chunked"}
+
+// SumOrderNames lists the summation orders ParseSumOrder accepts.
+func SumOrderNames() []string {
+	return append([]string(nil), sumOrderNames...)
+}
+
+// ParseSumOrder returns the summation order named name.
+func ParseSumOrder(name string) (SumOrder, error) {
+	for i, n := range sumOrderNames {
+		if n == name {
+			return SumOrder(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown summation order %q (have %v)", name, sumOrderNames)
+}
+
+func (o SumOrder) String() string {
+	if o >= 0 && int(o) < len(sumOrderNames) {
+		return sumOrderNames[o]
+	}
+	return fmt.Sprintf("SumOrder(%d)", int(o))
+}
+
+// MarshalText writes the order by name, so checkpoints stay readable.
+func (o SumOrder) MarshalText() ([]byte, error) {
+	if o < 0 || int(o) >= len(sumOrderNames) {
+		return nil, fmt.Errorf("unknown summation order %d", int(o))
+	}
+	return []byte(o.String()), nil
+}
+
+// UnmarshalText reads an order written by MarshalText.
+func (o *SumOrder) UnmarshalText(text []byte) error {
+	v, err := ParseSumOrder(string(text))
+	if err != nil {
+		return err
+	}
+	*o = v
+	return nil
+}
+
+// chunkTerms is the length of a SumChunked chunk, and chunkRound how many
+// chunks a ResumableSum runs between checks of its stop channel and
+// checkpoint interval. Both are fixed, not derived from the worker count,
+// so they can't change the sum.
+const (
+	chunkTerms = 1024
+	chunkRound = 64
+)
+
+// sumChunks sums count terms of c from n = start in chunks of chunkTerms,
+// spread over workers goroutines (runtime.NumCPU() if workers <= 0). It
+// returns the sum of the terms before the first failing one, and how many
+// those are: count unless a term failed.
+func sumChunks(c *Candidate, start, count int64, prec uint, workers int) (*big.Float, int64) {
+	chunks := int((count + chunkTerms - 1) / chunkTerms)
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	workers = min(workers, chunks)
+
+	sums := make([]*big.Float, chunks)
+	terms := make([]int64, chunks)
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				from := start + int64(i)*chunkTerms
+				sums[i], terms[i] = sumChunk(c, from, min(chunkTerms, start+count-from), prec)
+			}
+		}()
+	}
+	for i := 0; i < chunks; i++ {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	// Everything past the first failing term is dropped, as in the
+	// sequential sum.
+	var total int64
+	for i := range sums {
+		total += terms[i]
+		if terms[i] < min(chunkTerms, count-int64(i)*chunkTerms) {
+			sums = sums[:i+1]
+			break
+		}
+	}
+	return pairwiseSum(sums, prec), total
+}
+
+// sumChunk sums count terms of c from n = start on fresh evaluators, so the
+// result depends only on the chunk, not on what the goroutine ran before.
+// It stops at the first failing term and returns the terms summed.
+func sumChunk(c *Candidate, start, count int64, prec uint) (*big.Float, int64) {
+	numEval := expr.NewBlockEvaluator(c.Numerator, prec)
+	denEval := expr.NewBlockEvaluator(c.Denominator, prec)
+	nums := make([]*big.Float, maxEvalBlock)
+	dens := make([]*big.Float, maxEvalBlock)
+	term := new(big.Float).SetPrec(prec)
+	acc := newCompensatedSum(prec)
+
+	var done int64
+	for done < count {
+		size := min(int64(maxEvalBlock), count-done)
+		k := numEval.EvalBlock(start+done, nums[:size])
+		if k > 0 {
+			k = min(k, denEval.EvalBlock(start+done, dens[:k]))
+		}
+		failed := int64(k) < size
+		for j := 0; j < k; j++ {
+			if dens[j].Sign() == 0 {
+				failed = true
+				k = j
+				break
+			}
+			acc.add(term.Quo(nums[j], dens[j]))
+		}
+		releaseBlock(nums[:size])
+		releaseBlock(dens[:size])
+		done += int64(k)
+		if failed {
+			break
+		}
+	}
+	return acc.value(), done
+}
+
+// pairwiseSum adds xs as a balanced tree in index order: neighbours first,
+// then neighbouring pairs, and so on. The error grows with the log of the
+// count rather than the count, and the order is fixed by len(xs) alone.
+func pairwiseSum(xs []*big.Float, prec uint) *big.Float {
+	if len(xs) == 0 {
+		return new(big.Float).SetPrec(prec)
+	}
+	level := append([]*big.Float(nil), xs...)
+	for len(level) > 1 {
+		half := level[:0:0]
+		for i := 0; i+1 < len(level); i += 2 {
+			half = append(half, new(big.Float).SetPrec(prec).Add(level[i], level[i+1]))
+		}
+		if len(level)%2 == 1 {
+			half = append(half, level[len(level)-1])
+		}
+		level = half
+	}
+	return level[0]
+}
+
+// compensatedSum is a Neumaier sum: comp collects the rounding error of
+// every addition to sum, recovered exactly by Fast2Sum, since all values
+// share one precision and round to nearest.
+type compensatedSum struct {
+	sum, comp, t, e *big.Float
+}
+
+func newCompensatedSum(prec uint) *compensatedSum {
+	return &compensatedSum{
+		sum:  new(big.Float).SetPrec(prec),
+		comp: new(big.Float).SetPrec(prec),
+		t:    new(big.Float).SetPrec(prec),
+		e:    new(big.Float).SetPrec(prec),
+	}
+}
+
+func (a *compensatedSum) add(x *big.Float) {
+	if x.Sign() == 0 {
+		return
+	}
+	hi, lo := a.sum, x
+	if a.sum.Sign() == 0 || x.MantExp(nil) > a.sum.MantExp(nil) {
+		hi, lo = x, a.sum
+	}
+	a.t.Add(a.sum, x)
+	a.e.Sub(hi, a.t)
+	a.e.Add(a.e, lo)
+	a.comp.Add(a.comp, a.e)
+	a.sum, a.t = a.t, a.sum
+}
+
+// value is the compensated sum, rounded once.
+func (a *compensatedSum) value() *big.Float {
+	return new(big.Float).SetPrec(a.sum.Prec()).Add(a.sum, a.comp)
+}
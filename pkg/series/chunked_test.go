@@ -0,0 +1,115 @@
+package series
+
+import (
+	"math/big"
+	"path/filepath"
+	"testing"
+)
+
+func TestResumableSumChunked(t *testing.T) {
+	c := mustParse(t, `\sum_{n=1}^{\infty} \frac{(-1)^{n+1}}{n^2 + \sin(n)}`)
+	const terms = 3*chunkTerms + 100
+	const prec = 512
+
+	seq := NewResumableSum(c, prec)
+	if err := seq.Run(c, terms, 0, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	want, _ := seq.Value()
+
+	var first *ResumableSum
+	for _, workers := range []int{1, 3, 8} {
+		r := NewResumableSum(c, prec)
+		r.Order, r.Workers = SumChunked, workers
+		if err := r.Run(c, terms, 0, nil, nil); err != nil {
+			t.Fatal(err)
+		}
+		if r.Terms != terms || r.Failed {
+			t.Fatalf("%d workers: %+v, want %d terms", workers, r, terms)
+		}
+		if first == nil {
+			first = r
+			got, _ := r.Value()
+			diff := new(big.Float).Sub(got, want)
+			if diff.Abs(diff).Cmp(new(big.Float).SetMantExp(big.NewFloat(1), -prec+16)) > 0 {
+				t.Errorf("chunked sum %s, sequential %s", got.Text('g', 40), want.Text('g', 40))
+			}
+		} else if r.Sum != first.Sum {
+			t.Errorf("%d workers: sum %s, 1 worker %s", workers, r.Sum, first.Sum)
+		}
+	}
+
+	// The order is checkpointed, and a resumed chunked sum matches an
+	// uninterrupted one.
+	const long = 2*chunkRound*chunkTerms + 10
+	whole := NewResumableSum(c, prec)
+	whole.Order = SumChunked
+	if err := whole.Run(c, long, 0, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "sum.ckpt")
+	stop := make(chan struct{})
+	part := NewResumableSum(c, prec)
+	part.Order, part.Workers = SumChunked, 2
+	save := func(r *ResumableSum) error {
+		if r.Terms < long {
+			select {
+			case <-stop:
+			default:
+				close(stop)
+			}
+		}
+		return r.Save(path)
+	}
+	if err := part.Run(c, long, 0, save, stop); err != nil {
+		t.Fatal(err)
+	}
+	resumed, err := LoadResumableSum(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resumed.Order != SumChunked || resumed.Terms >= long {
+		t.Fatalf("reloaded %+v, want a partial chunked sum", resumed)
+	}
+	if err := resumed.Run(c, long, 0, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if resumed.Sum != whole.Sum || resumed.Terms != whole.Terms {
+		t.Errorf("resumed sum %+v, uninterrupted %+v", resumed, whole)
+	}
+}
+
+func TestResumableSumChunkedStopsAtFailedTerm(t *testing.T) {
+	// 1/(n-1500) fails at n = 1500, in the second chunk.
+	c := mustParse(t, `\sum_{n=0}^{\infty} \frac{1}{n-1500}`)
+	seq := NewResumableSum(c, 256)
+	if err := seq.Run(c, 4*chunkTerms, 0, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	r := NewResumableSum(c, 256)
+	r.Order, r.Workers = SumChunked, 4
+	if err := r.Run(c, 4*chunkTerms, 0, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !r.Failed || r.Terms != 1500 || r.Next != 1500 {
+		t.Errorf("got %+v, want failure after 1500 terms", r)
+	}
+	got, _ := r.Value()
+	want, _ := seq.Value()
+	diff := new(big.Float).Sub(got, want)
+	if diff.Abs(diff).Cmp(big.NewFloat(1e-70)) > 0 {
+		t.Errorf("chunked sum %s, sequential %s", got.Text('g', 30), want.Text('g', 30))
+	}
+}
+
+func TestParseSumOrder(t *testing.T) {
+	for _, name := range SumOrderNames() {
+		o, err := ParseSumOrder(name)
+		if err != nil || o.String() != name {
+			t.Errorf("ParseSumOrder(%q) = %v, %v", name, o, err)
+		}
+	}
+	if _, err := ParseSumOrder("random"); err == nil {
+		t.Error("ParseSumOrder accepted an unknown order")
+	}
+}
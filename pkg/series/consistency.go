@@ -0,0 +1,89 @@
+package series
+
+import (
+	"fmt"
+	"io"
+	"math/big"
+)
+
+// Consistency is the result of CheckConsistency.
+type Consistency struct {
+	Candidate     string  `json:"candidate"`
+	Digits        float64 `json:"digits"`         // correct digits against the target
+	ShiftedDigits float64 `json:"shifted_digits"` // digits a(Start) plus the sum from Start+1 shares with the sum from Start
+	DoubledDigits float64 `json:"doubled_digits"` // correct digits at twice the terms
+	Consistent    bool    `json:"consistent"`
+	Problem       string  `json:"problem,omitempty"` // what failed, when not Consistent
+}
+
+// CheckConsistency evaluates c with ev three ways that must agree if the
+// evaluator handles the index correctly and the match is not an accident
+// of the term cutoff: as given; from Start+1 with one term fewer, plus
+// a(Start) evaluated directly from the trees, which must reproduce the sum
+// to at least the digits it claims; and with twice opts.MaxTerms, which
+// must lose at most a fraction of a digit against target. All digits are
+// capped at MaxDigits. The caller picks opts; drop Timeout to check a
+// candidate whose longer sum would not finish within it.
+func CheckConsistency(c *Candidate, ev Evaluator, opts EvalOptions, target *big.Float) Consistency {
+	r := Consistency{Candidate: c.String()}
+	base := ev.Evaluate(c, opts)
+	if !base.OK {
+		r.Problem = "does not evaluate"
+		return r
+	}
+	r.Digits = min(countCorrectDigits(base.PartialSum, target), MaxDigits)
+
+	// A ratio's first term is its dividend's, so the split is checked on
+	// the dividend's own sum.
+	s, whole := c.Series(), base
+	if c.Over != nil {
+		if whole = ev.Evaluate(s, opts); !whole.OK {
+			r.Problem = "dividend does not evaluate"
+			return r
+		}
+	}
+	first, ok := termAt(s, s.Start, opts.Prec)
+	shifted := s.Clone()
+	shifted.Start++
+	shiftedOpts := opts
+	shiftedOpts.MaxTerms--
+	rest := ev.Evaluate(shifted, shiftedOpts)
+	if !ok || !rest.OK {
+		r.Problem = fmt.Sprintf("does not evaluate from n = %d", c.Start+1)
+		return r
+	}
+	rest.PartialSum.Add(rest.PartialSum, first)
+	r.ShiftedDigits = min(countCorrectDigits(rest.PartialSum, whole.PartialSum), MaxDigits)
+
+	doubledOpts := opts
+	doubledOpts.MaxTerms *= 2
+	doubled := ev.Evaluate(c, doubledOpts)
+	if !doubled.OK {
+		r.Problem = fmt.Sprintf("does not evaluate to %d terms", doubledOpts.MaxTerms)
+		return r
+	}
+	r.DoubledDigits = min(countCorrectDigits(doubled.PartialSum, target), MaxDigits)
+
+	switch {
+	case r.ShiftedDigits < r.Digits-ladderSlack:
+		r.Problem = fmt.Sprintf("a(%d) plus the sum from n = %d agrees with the sum to %.1f digits, not %.1f", c.Start, c.Start+1, r.ShiftedDigits, r.Digits)
+	case r.DoubledDigits < r.Digits-ladderSlack:
+		r.Problem = fmt.Sprintf("%.1f digits at %d terms, down from %.1f", r.DoubledDigits, doubledOpts.MaxTerms, r.Digits)
+	default:
+		r.Consistent = true
+	}
+	return r
+}
+
+// WriteText writes the three evaluations and the verdict.
+func (r Consistency) WriteText(w io.Writer) {
+	fmt.Fprintf(w, "Candidate: %s\n", r.Candidate)
+	fmt.Fprintf(w, "As given:  %.1f digits\n", r.Digits)
+	fmt.Fprintf(w, "Shifted:   a(start) + sum from start+1 agrees to %.1f digits\n", r.ShiftedDigits)
+	fmt.Fprintf(w, "Doubled:   %.1f digits at twice the terms\n", r.DoubledDigits)
+	if r.Consistent {
+		fmt.Fprintln(w, "Consistent")
+	} else {
+		fmt.Fprintf(w, "Inconsistent: %s\n", r.Problem)
+	}
+}
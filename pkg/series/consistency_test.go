@@ -0,0 +1,41 @@
+package series
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/wildfunctions/genetic_series/pkg/constants"
+)
+
+// zeroBased is an evaluator with a term-index bug: it sums from n = 0
+// whatever the candidate's start.
+type zeroBased struct{}
+
+func (zeroBased) Name() string { return "zero-based" }
+
+func (zeroBased) Evaluate(c *Candidate, opts EvalOptions) EvalResult {
+	c = c.Clone()
+	c.Start = 0
+	return BigFloatEvaluator{}.Evaluate(c, opts)
+}
+
+func TestCheckConsistency(t *testing.T) {
+	opts := EvalOptions{MaxTerms: 128, Prec: testPrec}
+	e := constants.Get("e").At(testPrec)
+	c := mustParse(t, `\sum_{n=0}^{\infty} \frac{1}{n!}`)
+	if r := CheckConsistency(c, BigFloatEvaluator{}, opts, e); !r.Consistent || r.Digits < 40 || r.ShiftedDigits < r.Digits {
+		t.Errorf("1/n! vs e: %+v, want consistent", r)
+	}
+	if r := CheckConsistency(c, zeroBased{}, opts, e); r.Consistent || !strings.Contains(r.Problem, "a(0)") {
+		t.Errorf("index bug not caught: %+v", r)
+	}
+
+	// The harmonic series matches its own 128-term partial sum, and loses
+	// it at 256 terms.
+	h := mustParse(t, `\sum_{n=1}^{\infty} \frac{1}{n}`)
+	sum := BigFloatEvaluator{}.Evaluate(h, opts).PartialSum
+	r := CheckConsistency(h, BigFloatEvaluator{}, opts, sum)
+	if r.Consistent || r.Digits < 40 || r.ShiftedDigits < 40 || r.DoubledDigits > 5 {
+		t.Errorf("harmonic cutoff artifact: %+v, want inconsistent at twice the terms", r)
+	}
+}
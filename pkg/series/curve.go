@@ -0,0 +1,111 @@
+package series
+
+import (
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/wildfunctions/genetic_series/pkg/expr"
+)
+
+// curveBlock is how many terms DigitCurve evaluates per block.
+const curveBlock = 256
+
+// CurvePoint is the partial sum of a candidate's first Terms terms and the
+// digits it agrees with the target.
+type CurvePoint struct {
+	Terms      int64   `json:"terms"`
+	PartialSum string  `json:"partial_sum"`
+	Digits     float64 `json:"digits"`
+}
+
+// Curve is the digit-agreement curve of a candidate: correct digits
+// against the number of terms summed.
+type Curve struct {
+	Candidate string       `json:"candidate"`
+	Points    []CurvePoint `json:"points"`
+	Stop      string       `json:"stop"` // why summation ended
+}
+
+// DigitCurve sums c for up to maxTerms terms and records the partial sum
+// and its correct digits against target after 1, 2, 4, ... terms and after
+// the last. Terms are evaluated as in Explain, with no timeout or
+// convergence test, and the curve ends at the first term that fails. A
+// ratio has no curve.
+func DigitCurve(c *Candidate, maxTerms int64, prec uint, target *big.Float) Curve {
+	cv := Curve{Candidate: c.String(), Stop: fmt.Sprintf("reached %d terms", maxTerms)}
+	if c.Over != nil {
+		cv.Stop = ratioStop
+		return cv
+	}
+	sum, ok := c.OffsetValue(prec)
+	if !ok {
+		cv.Stop = "offset is undefined"
+		return cv
+	}
+
+	numEval := expr.NewBlockEvaluator(c.Numerator, prec)
+	denEval := expr.NewBlockEvaluator(c.Denominator, prec)
+	nums := make([]*big.Float, curveBlock)
+	dens := make([]*big.Float, curveBlock)
+	term := new(big.Float).SetPrec(prec)
+	record := func(terms int64) {
+		cv.Points = append(cv.Points, CurvePoint{
+			Terms:      terms,
+			PartialSum: sum.Text('g', traceDigits),
+			Digits:     countCorrectDigits(sum, target),
+		})
+	}
+
+	next := int64(1) // terms at which the next point is recorded
+	var terms int64
+	for terms < maxTerms {
+		size := min(int64(curveBlock), maxTerms-terms)
+		n := c.Start + terms
+		kn := numEval.EvalBlock(n, nums[:size])
+		kd := denEval.EvalBlock(n, dens[:size])
+		k := min(kn, kd)
+		for i := 0; i < k && dens[i].Sign() != 0; i++ {
+			sum.Add(sum, term.Quo(nums[i], dens[i]))
+			terms++
+			if terms == next {
+				record(terms)
+				next *= 2
+			}
+		}
+		releaseAll(nums[:kn])
+		releaseAll(dens[:kd])
+		if terms < n-c.Start+size {
+			cv.Stop = fmt.Sprintf("term failed at n=%d", c.Start+terms)
+			break
+		}
+	}
+	if terms > 0 && terms != next/2 {
+		record(terms)
+	}
+	return cv
+}
+
+// releaseAll returns evaluated values to the operand pool.
+func releaseAll(vals []*big.Float) {
+	for _, v := range vals {
+		expr.ReleaseFloat(v)
+	}
+}
+
+// WriteText writes the curve as a table, one line per point, with the
+// digits gained since the previous point.
+func (cv Curve) WriteText(w io.Writer) {
+	fmt.Fprintf(w, "Candidate: %s\n", cv.Candidate)
+	fmt.Fprintf(w, "%10s  %8s  %8s  %s\n", "terms", "digits", "gained", "partial sum")
+	var prev float64
+	for i, p := range cv.Points {
+		gained := p.Digits - prev
+		if i == 0 {
+			gained = 0
+		}
+		fmt.Fprintf(w, "%10d  %8.2f  %+8.2f  %s\n", p.Terms, p.Digits, gained, p.PartialSum)
+		prev = p.Digits
+	}
+	fmt.Fprintf(w, "Stopped:   %s\n", cv.Stop)
+}
@@ -0,0 +1,62 @@
+package series
+
+import (
+	"bytes"
+	"math"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/wildfunctions/genetic_series/pkg/constants"
+)
+
+func TestDigitCurve(t *testing.T) {
+	c := mustParse(t, `\sum_{n=0}^{\infty} \frac{1}{n!}`)
+	e := constants.Get("e").At(testPrec)
+	cv := DigitCurve(c, 20, testPrec, e)
+	var terms []int64
+	for _, p := range cv.Points {
+		terms = append(terms, p.Terms)
+	}
+	if want := []int64{1, 2, 4, 8, 16, 20}; !slices.Equal(terms, want) {
+		t.Fatalf("points at %v terms, want %v", terms, want)
+	}
+	res := EvaluateCandidate(c, 20, testPrec)
+	if last := cv.Points[len(cv.Points)-1]; last.PartialSum != res.PartialSum.Text('g', traceDigits) || last.Digits != countCorrectDigits(res.PartialSum, e) {
+		t.Errorf("last point %+v, EvaluateCandidate %s", last, res.PartialSum.Text('g', traceDigits))
+	}
+
+	// Points keep doubling across blocks.
+	basel := mustParse(t, `\sum_{n=1}^{\infty} \frac{1}{n^{2}}`)
+	cv = DigitCurve(basel, 1000, testPrec, nil)
+	if len(cv.Points) != 11 || cv.Points[10].Terms != 1000 {
+		t.Fatalf("%d points, last %+v; want 11 ending at 1000 terms", len(cv.Points), cv.Points[len(cv.Points)-1])
+	}
+	if cv.Stop != "reached 1000 terms" {
+		t.Errorf("stop %q", cv.Stop)
+	}
+
+	var buf bytes.Buffer
+	cv.WriteText(&buf)
+	if lines := strings.Count(buf.String(), "\n"); lines != 11+3 {
+		t.Errorf("text curve has %d lines, want %d:\n%s", lines, 11+3, buf.String())
+	}
+}
+
+func TestDigitCurveStops(t *testing.T) {
+	c := mustParse(t, `\sum_{n=0}^{\infty} \frac{1}{n-2}`)
+	cv := DigitCurve(c, 10, testPrec, nil)
+	if len(cv.Points) != 2 || cv.Points[1].Terms != 2 || cv.Stop != "term failed at n=2" {
+		t.Errorf("points %+v, stop %q; want 2 points and a failure at n=2", cv.Points, cv.Stop)
+	}
+
+	// The digits of the 1/n² tail, about 1/N, grow by log10(2) per doubling.
+	basel := mustParse(t, `6 \sum_{n=1}^{\infty} \frac{1}{n^{2}}`)
+	pi2 := constants.Get("pi").At(testPrec)
+	pi2.Mul(pi2, pi2)
+	cv = DigitCurve(basel, 4096, testPrec, pi2)
+	n := len(cv.Points)
+	if gained := cv.Points[n-1].Digits - cv.Points[n-2].Digits; math.Abs(gained-math.Log10(2)) > 0.01 {
+		t.Errorf("last doubling gained %.4f digits, want about %.4f", gained, math.Log10(2))
+	}
+}
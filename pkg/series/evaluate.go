@@ -1,9 +1,12 @@
 package series
 
 import (
+	"fmt"
 	"math"
 	"math/big"
 	"time"
+
+	"github.com/wildfunctions/genetic_series/pkg/expr"
 )
 
 // EvalResult holds the result of evaluating a candidate's partial sum.
@@ -13,58 +16,141 @@ type EvalResult struct {
 	Converged       bool
 	ConvergenceRate float64 // average ratio of |S_{2N} - S_N| decrease per doubling
 	OK              bool
+	Overflow        bool  // a term passed EvalOptions.MaxExponent (OK is false)
+	Err             error // set (with OK false) if evaluation panicked; see EvalError
+}
+
+// EvalError records a panic raised while evaluating a candidate, most often
+// big.ErrNaN from an undefined math/big operation (∞/∞, ∞−∞, √−x) that got
+// past the op guards. Unwrap returns the panic value if it is an error.
+type EvalError struct {
+	Candidate string
+	Panic     any
+}
+
+func (e *EvalError) Error() string {
+	return fmt.Sprintf("evaluating %s: panic: %v", e.Candidate, e.Panic)
+}
+
+func (e *EvalError) Unwrap() error {
+	err, _ := e.Panic.(error)
+	return err
 }
 
 // evalTimeout is the maximum time allowed for evaluating a single candidate.
 const evalTimeout = 100 * time.Millisecond
 
+// DefaultMaxExponent is the search's bound on term size: 2^16384 is about
+// 10^4932, far past the largest term of any series worth keeping, so a
+// term that big means the series is exploding.
+const DefaultMaxExponent = 1 << 14
+
+// Terms are evaluated in blocks that start small, so cheap rejections stay
+// cheap, and double up to maxEvalBlock.
+const (
+	minEvalBlock = 8
+	maxEvalBlock = 128
+)
+
 // EvaluateCandidate computes the partial sum of a candidate series up to maxTerms,
-// using checkpoints at powers of 2 for convergence detection.
+// using checkpoints at powers of 2 for convergence detection. A panic during
+// evaluation fails the candidate with an *EvalError instead of propagating.
+// It is BigFloatEvaluator with the search's options (SearchOptions).
 func EvaluateCandidate(c *Candidate, maxTerms int64, prec uint) EvalResult {
-	sum := new(big.Float).SetPrec(prec)
-	n := new(big.Float).SetPrec(prec)
+	return BigFloatEvaluator{}.Evaluate(c, SearchOptions(maxTerms, prec))
+}
+
+// SearchOptions are the options the search evaluates candidates with:
+// maxTerms terms at prec bits, under the per-candidate timeout and
+// DefaultMaxExponent.
+func SearchOptions(maxTerms int64, prec uint) EvalOptions {
+	return EvalOptions{MaxTerms: maxTerms, Prec: prec, Timeout: evalTimeout, MaxExponent: DefaultMaxExponent}
+}
+
+// BigFloatEvaluator is the search's evaluator: math/big, with terms
+// evaluated in blocks by expr.BlockEvaluator so that runs of n share work.
+type BigFloatEvaluator struct{}
+
+func (BigFloatEvaluator) Name() string { return "big" }
+
+func (e BigFloatEvaluator) Evaluate(c *Candidate, opts EvalOptions) (res EvalResult) {
+	defer recoverEvalResult(c, &res)
+	if c.Over != nil {
+		return evaluateRatio(e, c, opts)
+	}
+
+	prec := opts.Prec
+	sum, ok := c.OffsetValue(prec)
+	if !ok {
+		return EvalResult{OK: false}
+	}
+	term := new(big.Float).SetPrec(prec)
+	numEval := expr.NewBlockEvaluator(c.Numerator, prec)
+	denEval := expr.NewBlockEvaluator(c.Denominator, prec)
+	nums := make([]*big.Float, maxEvalBlock)
+	dens := make([]*big.Float, maxEvalBlock)
 
 	// Track partial sums at checkpoints (powers of 2)
 	var checkpoints []checkpoint
 	nextCheckpoint := int64(1)
 
 	var termsComputed int64
-	deadline := time.Now().Add(evalTimeout)
+	deadline := opts.deadline()
 
-	for i := c.Start; i < c.Start+maxTerms; i++ {
-		if time.Now().After(deadline) {
+	end := c.Start + opts.MaxTerms
+	block := int64(minEvalBlock)
+	for i := c.Start; i < end; {
+		if pastEvalDeadline(deadline) {
 			return EvalResult{OK: false}
 		}
 
-		n.SetInt64(i)
+		size := min(block, end-i)
+		block = min(2*block, maxEvalBlock)
 
-		num, ok := c.Numerator.Eval(n, prec)
-		if !ok {
-			break // term failed — use partial sum so far
+		// A failed term ends the series — use the partial sum so far.
+		k := numEval.EvalBlock(i, nums[:size])
+		if k > 0 {
+			k = min(k, denEval.EvalBlock(i, dens[:k]))
 		}
-
-		den, ok := c.Denominator.Eval(n, prec)
-		if !ok {
-			break
+		failed := int64(k) < size
+		overflow := false
+
+		for j := 0; j < k; j++ {
+			num, den := nums[j], dens[j]
+			if den.Sign() == 0 {
+				failed = true
+				break
+			}
+
+			// An exploding term fails the candidate at once, rather than
+			// being carried through the remaining terms.
+			term.Quo(num, den)
+			if term.IsInf() || opts.MaxExponent > 0 && term.MantExp(nil) > opts.MaxExponent {
+				overflow = true
+				break
+			}
+			sum.Add(sum, term)
+			termsComputed++
+
+			// Record checkpoint at powers of 2 (relative to start)
+			offset := i + int64(j) - c.Start + 1
+			if offset == nextCheckpoint {
+				checkpoints = append(checkpoints, checkpoint{
+					terms: offset,
+					sum:   new(big.Float).SetPrec(prec).Copy(sum),
+				})
+				nextCheckpoint *= 2
+			}
 		}
-
-		if den.Sign() == 0 {
-			break
+		releaseBlock(nums[:size])
+		releaseBlock(dens[:size])
+		if overflow {
+			return EvalResult{TermsComputed: termsComputed, Overflow: true}
 		}
-
-		term := new(big.Float).SetPrec(prec).Quo(num, den)
-		sum.Add(sum, term)
-		termsComputed++
-
-		// Record checkpoint at powers of 2 (relative to start)
-		offset := i - c.Start + 1
-		if offset == nextCheckpoint {
-			checkpoints = append(checkpoints, checkpoint{
-				terms: offset,
-				sum:   new(big.Float).SetPrec(prec).Copy(sum),
-			})
-			nextCheckpoint *= 2
+		if failed {
+			break
 		}
+		i += size
 	}
 
 	// Need at least a few terms for a meaningful result
@@ -84,6 +170,14 @@ func EvaluateCandidate(c *Candidate, maxTerms int64, prec uint) EvalResult {
 	}
 }
 
+// releaseBlock returns the floats left in a block buffer to the pool.
+func releaseBlock(fs []*big.Float) {
+	for i, f := range fs {
+		expr.ReleaseFloat(f)
+		fs[i] = nil
+	}
+}
+
 type checkpoint struct {
 	terms int64
 	sum   *big.Float
@@ -144,7 +238,13 @@ type EvalResultF64 struct {
 // EvaluateCandidateF64 evaluates a candidate series entirely in float64.
 // No timeout — float64 on 1024 terms runs in microseconds.
 func EvaluateCandidateF64(c *Candidate, maxTerms int64) EvalResultF64 {
-	var sum float64
+	if c.Over != nil {
+		return evaluateRatioF64(c, maxTerms)
+	}
+	sum, ok := c.OffsetF64()
+	if !ok {
+		return EvalResultF64{OK: false}
+	}
 	var termsComputed int64
 
 	// Ring buffer of 3 checkpoint sums for convergence detection.
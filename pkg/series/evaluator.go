@@ -0,0 +1,125 @@
+package series
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/wildfunctions/genetic_series/pkg/numeric"
+)
+
+// Evaluator computes the partial sum of a candidate series. Implementations
+// differ in number type, speed and guarantees, but all report through
+// EvalResult, with the sum as a big.Float, so that fitness, archiving and
+// reporting do not depend on which one produced it. A ratio (see
+// Candidate.Over) has both of its series evaluated and reports their
+// quotient. Evaluate must not panic: a panic fails the candidate with an
+// *EvalError.
+type Evaluator interface {
+	Name() string
+	Evaluate(c *Candidate, opts EvalOptions) EvalResult
+}
+
+// EvalOptions are the settings of one evaluation.
+type EvalOptions struct {
+	MaxTerms    int64         // terms summed, at most
+	Prec        uint          // bits; ignored by fixed-precision evaluators
+	Timeout     time.Duration // per candidate (0 = none)
+	MaxExponent int           // fail with Overflow once a term reaches 2^MaxExponent in magnitude (0 = no bound; BigFloatEvaluator only)
+}
+
+func (o EvalOptions) deadline() time.Time {
+	if o.Timeout <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(o.Timeout)
+}
+
+func pastEvalDeadline(deadline time.Time) bool {
+	return !deadline.IsZero() && time.Now().After(deadline)
+}
+
+// recoverEvalResult, deferred in Evaluate, turns a panic into a failed
+// result.
+func recoverEvalResult(c *Candidate, res *EvalResult) {
+	if p := recover(); p != nil {
+		*res = EvalResult{Err: &EvalError{Candidate: c.String(), Panic: p}}
+	}
+}
+
+// evaluators maps names to the evaluators compiled into this binary.
+// Optional backends add themselves from build-tagged files.
+var evaluators = map[string]Evaluator{
+	"big": BigFloatEvaluator{},
+	"f64": F64Evaluator{},
+}
+
+// GetEvaluator returns the named evaluator.
+func GetEvaluator(name string) (Evaluator, error) {
+	ev, ok := evaluators[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown evaluator: %s (available: %v)", name, EvaluatorNames())
+	}
+	return ev, nil
+}
+
+// EvaluatorNames returns the available evaluators, sorted.
+func EvaluatorNames() []string {
+	names := make([]string, 0, len(evaluators))
+	for k := range evaluators {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NumEvaluator evaluates term by term over any numeric backend, with the
+// same convergence tracking as BigFloatEvaluator. It does not share work
+// between terms, so for math/big BigFloatEvaluator is faster; it is how
+// backends such as MPFR plug into the search.
+type NumEvaluator[T any] struct {
+	Backend numeric.Backend[T]
+}
+
+func (e NumEvaluator[T]) Name() string { return e.Backend.Name() }
+
+func (e NumEvaluator[T]) Evaluate(c *Candidate, opts EvalOptions) (res EvalResult) {
+	defer recoverEvalResult(c, &res)
+	if c.Over != nil {
+		return evaluateRatio(e, c, opts)
+	}
+	var cps []checkpoint
+	sum, n, timedOut := sumNum(c, e.Backend, opts, &cps)
+	if timedOut || n < 4 {
+		return EvalResult{OK: false}
+	}
+	converged, rate := analyzeConvergence(cps, opts.Prec)
+	return EvalResult{
+		PartialSum:      sum,
+		TermsComputed:   n,
+		Converged:       converged,
+		ConvergenceRate: rate,
+		OK:              true,
+	}
+}
+
+// F64Evaluator is EvaluateCandidateF64 behind the Evaluator interface: the
+// float64 prescreen, good to about 15 digits. Prec and Timeout are ignored.
+type F64Evaluator struct{}
+
+func (F64Evaluator) Name() string { return "f64" }
+
+func (F64Evaluator) Evaluate(c *Candidate, opts EvalOptions) (res EvalResult) {
+	defer recoverEvalResult(c, &res)
+	r := EvaluateCandidateF64(c, opts.MaxTerms)
+	if !r.OK {
+		return EvalResult{OK: false}
+	}
+	return EvalResult{
+		PartialSum:    big.NewFloat(r.PartialSum),
+		TermsComputed: r.TermsComputed,
+		Converged:     r.Converged,
+		OK:            true,
+	}
+}
@@ -0,0 +1,119 @@
+package series
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/wildfunctions/genetic_series/pkg/numeric"
+)
+
+func TestEvaluatorsMatchEvaluateCandidate(t *testing.T) {
+	const terms = 200
+	evs := []Evaluator{NumEvaluator[*big.Float]{Backend: numeric.BigFloat{}}}
+	for _, name := range EvaluatorNames() {
+		ev, err := GetEvaluator(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		evs = append(evs, ev)
+	}
+	opts := EvalOptions{MaxTerms: terms, Prec: testPrec}
+
+	for _, c := range parseCorpus(t) {
+		want := EvaluateCandidate(c, terms, testPrec)
+		if !want.OK {
+			continue
+		}
+		for _, ev := range evs {
+			got := ev.Evaluate(c, opts)
+			relTol := new(big.Float).SetMantExp(big.NewFloat(1), -(testPrec - 16))
+			if ev.Name() == "f64" {
+				if !got.OK || got.TermsComputed != want.TermsComputed {
+					continue // a term left float64 range
+				}
+				relTol = big.NewFloat(1e-9)
+			} else if got.Converged != want.Converged {
+				t.Errorf("%s / %s: converged %v, want %v", ev.Name(), c, got.Converged, want.Converged)
+			}
+			if !got.OK || got.TermsComputed != want.TermsComputed {
+				t.Errorf("%s / %s: ok %v after %d terms, want %d", ev.Name(), c, got.OK, got.TermsComputed, want.TermsComputed)
+				continue
+			}
+			diff := new(big.Float).Sub(got.PartialSum, want.PartialSum)
+			if want.PartialSum.Sign() != 0 {
+				diff.Quo(diff, want.PartialSum)
+			}
+			if diff.Abs(diff).Cmp(relTol) > 0 {
+				t.Errorf("%s / %s: sum %s, want %s", ev.Name(), c, got.PartialSum.Text('g', 30), want.PartialSum.Text('g', 30))
+			}
+		}
+	}
+}
+
+func TestEvaluatorTimeout(t *testing.T) {
+	c := mustParse(t, `\sum_{n=1}^{\infty} \frac{1}{n^2}`)
+	opts := EvalOptions{MaxTerms: 1 << 30, Prec: testPrec, Timeout: time.Millisecond}
+	for _, ev := range []Evaluator{BigFloatEvaluator{}, NumEvaluator[*big.Float]{Backend: numeric.BigFloat{}}} {
+		if r := ev.Evaluate(c, opts); r.OK {
+			t.Errorf("%s: %d terms within the timeout", ev.Name(), r.TermsComputed)
+		}
+	}
+}
+
+func TestEvaluatorOverflow(t *testing.T) {
+	opts := SearchOptions(1<<20, testPrec)
+	opts.Timeout = 0
+	start := time.Now()
+	r := BigFloatEvaluator{}.Evaluate(mustParse(t, `\sum_{n=1}^{\infty} \frac{n^{n^{2}}}{1}`), opts)
+	if !r.Overflow || r.OK || r.TermsComputed != 53 {
+		t.Errorf("n^{n^2}: %+v, want an overflow at n = 54 (2^16782)", r)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("overflow took %v to detect", elapsed)
+	}
+	if r := (BigFloatEvaluator{}).Evaluate(mustParse(t, `\sum_{n=0}^{\infty} \frac{1}{n!}`), opts); !r.OK || r.Overflow {
+		t.Errorf("1/n!: %+v", r)
+	}
+}
+
+func TestEvaluatorOffset(t *testing.T) {
+	// 1/2 + Σ_{n≥0} 2^-n = 5/2, from every evaluator and summation path;
+	// the evaluators stop at 20 terms, 2^-19 short.
+	c, err := ParseCandidateLatex(`\frac{1}{2} + \sum_{n=0}^{\infty} \frac{1}{2^{n}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := big.NewFloat(2.5 - 0x1p-19)
+	tol := big.NewFloat(1e-12)
+	check := func(name string, got *big.Float) {
+		t.Helper()
+		if got == nil {
+			t.Errorf("%s: no sum", name)
+			return
+		}
+		diff := new(big.Float).Sub(got, want)
+		if diff.Abs(diff).Cmp(tol) > 0 {
+			t.Errorf("%s = %s, want %s", name, got.Text('g', 15), want.Text('g', 15))
+		}
+	}
+	opts := EvalOptions{MaxTerms: 20, Prec: testPrec}
+	for _, name := range EvaluatorNames() {
+		ev, err := GetEvaluator(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		check(name, ev.Evaluate(c, opts).PartialSum)
+	}
+	sum, _, _ := PartialSumNum[*big.Float](c, numeric.BigFloat{}, 20, testPrec)
+	check("PartialSumNum", sum)
+	want.SetFloat64(2.5)
+	sum, _, _ = SumBinarySplit(c, testPrec)
+	check("SumBinarySplit", sum)
+}
+
+func TestGetEvaluatorUnknown(t *testing.T) {
+	if _, err := GetEvaluator("nope"); err == nil {
+		t.Error("expected error for unknown evaluator")
+	}
+}
@@ -0,0 +1,60 @@
+package series_test
+
+import (
+	"fmt"
+
+	"github.com/wildfunctions/genetic_series/pkg/constants"
+	"github.com/wildfunctions/genetic_series/pkg/series"
+)
+
+func ExampleParseCandidateLatex() {
+	c, err := series.ParseCandidateLatex(`\sum_{n=0}^{\infty} \frac{(-1)^{n} 4}{2n+1}`)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(c)
+	fmt.Println(c.LaTeX())
+	// Output:
+	// Sum_{n=0}^{inf} (((-1)^(n) * 4)) / (((2 * n) + 1))
+	// \sum_{n=0}^{\infty} \frac{{(-1)^{n}} \cdot {4}}{{{2} \cdot {n}} + {1}}
+}
+
+func ExampleEvaluator() {
+	c, err := series.ParseCandidateLatex(`\sum_{n=1}^{\infty} \frac{1}{n^{2}}`)
+	if err != nil {
+		panic(err)
+	}
+	ev, err := series.GetEvaluator("big")
+	if err != nil {
+		panic(err)
+	}
+	res := ev.Evaluate(c, series.EvalOptions{MaxTerms: 1000, Prec: 128})
+	fmt.Println(res.OK, res.TermsComputed, res.PartialSum.Text('f', 4))
+	// Output: true 1000 1.6439
+}
+
+func ExampleComputeFitness() {
+	c, err := series.ParseCandidateLatex(`\sum_{n=0}^{\infty} \frac{1}{n!}`)
+	if err != nil {
+		panic(err)
+	}
+	const prec = 256
+	target, err := constants.ParseTarget("e")
+	if err != nil {
+		panic(err)
+	}
+	res := series.EvaluateCandidate(c, 64, prec)
+	f := series.ComputeFitness(c, res, target.At(prec), series.DefaultWeights())
+	fmt.Printf("%.0f digits, combined %.1f\n", f.CorrectDigits, f.Combined)
+	// Output: 76 digits, combined 754.2
+}
+
+func ExampleSumBinarySplit() {
+	c, err := series.ParseCandidateLatex(`\sum_{n=0}^{\infty} \frac{(\frac{1}{2})_{n}}{n! \, 4^{n}}`)
+	if err != nil {
+		panic(err)
+	}
+	sum, terms, ok := series.SumBinarySplit(c, 128)
+	fmt.Println(sum.Text('g', 20), terms > 0, ok) // 2/√3
+	// Output: 1.154700538379251529 true true
+}
@@ -0,0 +1,130 @@
+package series
+
+import (
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/wildfunctions/genetic_series/pkg/expr"
+)
+
+// traceDigits is how many significant digits trace values are printed with.
+const traceDigits = 20
+
+// TraceStep is one term of an explained evaluation.
+type TraceStep struct {
+	N           int64   `json:"n"`
+	Numerator   string  `json:"numerator"`
+	Denominator string  `json:"denominator"`
+	Term        string  `json:"term"`
+	PartialSum  string  `json:"partial_sum"`
+	Digits      float64 `json:"digits"` // correct digits of the partial sum, if a target was given
+}
+
+// Trace is an annotated evaluation of a candidate: every term the search
+// evaluator would add, why it stopped, and the verdict the search reached.
+type Trace struct {
+	Candidate string      `json:"candidate"`
+	Steps     []TraceStep `json:"steps"`
+	Stop      string      `json:"stop"` // why summation ended
+	Result    struct {
+		OK              bool    `json:"ok"`
+		Converged       bool    `json:"converged"`
+		ConvergenceRate float64 `json:"convergence_rate"`
+		Error           string  `json:"error,omitempty"`
+	} `json:"result"`
+	Fitness *Fitness `json:"fitness,omitempty"` // with DefaultWeights, if a target was given
+}
+
+// Explain evaluates c term by term the way EvaluateCandidate does and
+// records each term, the running partial sum, and (if target is non-nil)
+// the digits it agrees with target. It is meant for debugging fitness, not
+// for the search loop: it keeps every value and has no timeout. A ratio
+// has no single sequence of terms, so only its result is recorded.
+func Explain(c *Candidate, maxTerms int64, prec uint, target *big.Float) Trace {
+	t := Trace{Candidate: c.String(), Stop: fmt.Sprintf("reached %d terms", maxTerms)}
+
+	numEval := expr.NewBlockEvaluator(c.Numerator, prec)
+	denEval := expr.NewBlockEvaluator(c.Denominator, prec)
+	vals := make([]*big.Float, 1)
+	term := new(big.Float).SetPrec(prec)
+	sum, ok := c.OffsetValue(prec)
+	end := c.Start + maxTerms
+	if !ok {
+		t.Stop = "offset is undefined"
+		sum, end = new(big.Float).SetPrec(prec), c.Start
+	}
+	if c.Over != nil {
+		t.Stop = ratioStop
+		end = c.Start
+	}
+	for n := c.Start; n < end; n++ {
+		if numEval.EvalBlock(n, vals) == 0 {
+			t.Stop = fmt.Sprintf("numerator failed at n=%d", n)
+			break
+		}
+		num := vals[0]
+		if denEval.EvalBlock(n, vals) == 0 {
+			expr.ReleaseFloat(num)
+			t.Stop = fmt.Sprintf("denominator failed at n=%d", n)
+			break
+		}
+		den := vals[0]
+		if den.Sign() == 0 {
+			expr.ReleaseFloat(num)
+			expr.ReleaseFloat(den)
+			t.Stop = fmt.Sprintf("denominator is zero at n=%d", n)
+			break
+		}
+		term.Quo(num, den)
+		sum.Add(sum, term)
+		step := TraceStep{
+			N:           n,
+			Numerator:   num.Text('g', traceDigits),
+			Denominator: den.Text('g', traceDigits),
+			Term:        term.Text('g', traceDigits),
+			PartialSum:  sum.Text('g', traceDigits),
+		}
+		if target != nil {
+			step.Digits = countCorrectDigits(sum, target)
+		}
+		t.Steps = append(t.Steps, step)
+		expr.ReleaseFloat(num)
+		expr.ReleaseFloat(den)
+	}
+
+	result := EvaluateCandidate(c, maxTerms, prec)
+	t.Result.OK = result.OK
+	t.Result.Converged = result.Converged
+	t.Result.ConvergenceRate = result.ConvergenceRate
+	if result.Err != nil {
+		t.Result.Error = result.Err.Error()
+	} else if !result.OK && int64(len(t.Steps)) >= 4 {
+		t.Result.Error = "timed out"
+	} else if !result.OK {
+		t.Result.Error = fmt.Sprintf("only %d terms (need 4)", len(t.Steps))
+	}
+	if target != nil {
+		f := ComputeFitness(c, result, target, DefaultWeights())
+		t.Fitness = &f
+	}
+	return t
+}
+
+// WriteText writes the trace as a table, one line per term.
+func (t Trace) WriteText(w io.Writer) {
+	fmt.Fprintf(w, "Candidate: %s\n", t.Candidate)
+	fmt.Fprintf(w, "%8s  %-27s  %-27s  %6s\n", "n", "term", "partial sum", "digits")
+	for _, s := range t.Steps {
+		fmt.Fprintf(w, "%8d  %-27s  %-27s  %6.1f\n", s.N, s.Term, s.PartialSum, s.Digits)
+	}
+	fmt.Fprintf(w, "Stopped:   %s (%d terms)\n", t.Stop, len(t.Steps))
+	fmt.Fprintf(w, "Result:    ok=%v converged=%v rate=%.4g", t.Result.OK, t.Result.Converged, t.Result.ConvergenceRate)
+	if t.Result.Error != "" {
+		fmt.Fprintf(w, " (%s)", t.Result.Error)
+	}
+	fmt.Fprintln(w)
+	if t.Fitness != nil {
+		fmt.Fprintf(w, "Fitness:   %.4f (%.1f digits)\n", t.Fitness.Combined, t.Fitness.CorrectDigits)
+	}
+}
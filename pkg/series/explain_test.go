@@ -0,0 +1,48 @@
+package series
+
+import (
+	"bytes"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func TestExplainMatchesEvaluate(t *testing.T) {
+	c := mustParse(t, `\sum_{n=0}^{\infty} \frac{1}{n!}`)
+	e := big.NewFloat(0).SetPrec(testPrec)
+	e.SetString("2.71828182845904523536028747135266249775724709369995")
+
+	tr := Explain(c, 20, testPrec, e)
+	if len(tr.Steps) != 20 {
+		t.Fatalf("%d steps, want 20", len(tr.Steps))
+	}
+	res := EvaluateCandidate(c, 20, testPrec)
+	if last := tr.Steps[19].PartialSum; last != res.PartialSum.Text('g', traceDigits) {
+		t.Errorf("final partial sum %s, EvaluateCandidate %s", last, res.PartialSum.Text('g', traceDigits))
+	}
+	for i := 1; i < len(tr.Steps); i++ {
+		if tr.Steps[i].Digits < tr.Steps[i-1].Digits {
+			t.Errorf("digits fell from %.1f to %.1f at n=%d", tr.Steps[i-1].Digits, tr.Steps[i].Digits, tr.Steps[i].N)
+		}
+	}
+	if tr.Fitness == nil || tr.Fitness.CorrectDigits != tr.Steps[19].Digits {
+		t.Errorf("fitness %+v, want digits %.1f", tr.Fitness, tr.Steps[19].Digits)
+	}
+
+	var buf bytes.Buffer
+	tr.WriteText(&buf)
+	if lines := strings.Count(buf.String(), "\n"); lines != 20+5 {
+		t.Errorf("text trace has %d lines, want %d:\n%s", lines, 20+5, buf.String())
+	}
+}
+
+func TestExplainStopReason(t *testing.T) {
+	c := mustParse(t, `\sum_{n=0}^{\infty} \frac{1}{n-2}`)
+	tr := Explain(c, 10, testPrec, nil)
+	if len(tr.Steps) != 2 || tr.Stop != "denominator is zero at n=2" {
+		t.Errorf("got %d steps, stop %q", len(tr.Steps), tr.Stop)
+	}
+	if tr.Result.OK || tr.Result.Error == "" || tr.Fitness != nil {
+		t.Errorf("result %+v, fitness %v; want a failure and no fitness", tr.Result, tr.Fitness)
+	}
+}
@@ -0,0 +1,185 @@
+package series
+
+import (
+	"math"
+	"math/big"
+)
+
+// FarChunk is one range of an extended sum: Terms terms from index Start,
+// which may lie past int64. Sum is exact if Exact, and otherwise estimated
+// from the terms sampled at each end of the range.
+type FarChunk struct {
+	Start, Terms *big.Int
+	Sum          *big.Float
+	Exact        bool
+}
+
+// FarSum is a partial sum extended far past the terms that were summed
+// one by one (see ExtendSum).
+type FarSum struct {
+	Terms       *big.Int   // terms the sum covers
+	Evaluated   int64      // terms evaluated past the head
+	Sum         *big.Float // the partial sum to Terms terms
+	Accelerated *big.Float // extrapolated limit of the partial sums after each chunk; nil for fewer than three
+	Chunks      []FarChunk
+}
+
+// TermAtIndex is the term of c at n, evaluated with the precision raised
+// by the bits of n so that the index itself is exact: (-1)^n takes the
+// parity of n and floors stay integral past int64. The result is at prec.
+func TermAtIndex(c *Candidate, n *big.Int, prec uint) (t *big.Float, ok bool) {
+	defer func() {
+		if recover() != nil {
+			t, ok = nil, false
+		}
+	}()
+	wp := prec + uint(n.BitLen())
+	nf := new(big.Float).SetPrec(wp).SetInt(n)
+	num, ok := c.Numerator.Eval(nf, wp)
+	if !ok {
+		return nil, false
+	}
+	den, ok := c.Denominator.Eval(nf, wp)
+	if !ok || den.Sign() == 0 {
+		return nil, false
+	}
+	return new(big.Float).SetPrec(prec).Quo(num, den), true
+}
+
+// SummarizeChunk sums the terms terms of c from index start, failing with
+// the first term that does.
+func SummarizeChunk(c *Candidate, start *big.Int, terms int64, prec uint) (*big.Float, bool) {
+	sum := new(big.Float).SetPrec(prec)
+	n := new(big.Int).Set(start)
+	one := big.NewInt(1)
+	for i := int64(0); i < terms; i++ {
+		t, ok := TermAtIndex(c, n, prec)
+		if !ok {
+			return nil, false
+		}
+		sum.Add(sum, t)
+		n.Add(n, one)
+	}
+	return sum, true
+}
+
+// ExtendSum extends head, the exact partial sum of c's first headTerms
+// terms, to total terms. The terms past the head are taken in ranges that
+// double the terms covered. A range of at most 2·samples terms is summed;
+// a longer one is summarized by samples terms at each end (an even count,
+// so alternating signs pair up), and its sum estimated by fitting a power
+// law a·n^-p to the two means and integrating it over the range. The
+// estimate suits slowly converging series with smooth, same-signed terms
+// or term pairs, such as Σ1/n² or Σ(-1)^n/n. For a range from index N,
+// its relative error is of order 1/N² for terms that are a power of n and
+// 1/N for ones shifted from it, such as 1/(n+1)². Samples that differ in
+// sign, or vanish, fail.
+//
+// Every index is a big.Int, so total may lie past int64. The partial sums
+// after each range, geometric in the terms, are extrapolated with Wynn's
+// epsilon algorithm (as AcceleratedSum does) into Accelerated.
+func ExtendSum(c *Candidate, head *big.Float, headTerms int64, total *big.Int, samples int64, prec uint) (*FarSum, bool) {
+	if headTerms < 1 || samples < 1 {
+		return nil, false
+	}
+	samples += samples % 2
+
+	covered := big.NewInt(headTerms)
+	next := big.NewInt(c.Start + headTerms)
+	sum := new(big.Float).SetPrec(prec).Set(head)
+	partials := []*big.Float{new(big.Float).Set(sum)}
+	res := &FarSum{}
+	for covered.Cmp(total) < 0 {
+		l := new(big.Int).Sub(total, covered)
+		if l.Cmp(covered) > 0 {
+			l.Set(covered)
+		}
+		ch := FarChunk{Start: new(big.Int).Set(next), Terms: l}
+		if l.IsInt64() && l.Int64() <= 2*samples {
+			s, ok := SummarizeChunk(c, next, l.Int64(), prec)
+			if !ok {
+				return nil, false
+			}
+			ch.Sum, ch.Exact = s, true
+			res.Evaluated += l.Int64()
+		} else {
+			s, ok := estimateRange(c, next, l, samples, prec)
+			if !ok {
+				return nil, false
+			}
+			ch.Sum = s
+			res.Evaluated += 2 * samples
+		}
+		sum.Add(sum, ch.Sum)
+		partials = append(partials, new(big.Float).Set(sum))
+		res.Chunks = append(res.Chunks, ch)
+		covered.Add(covered, l)
+		next.Add(next, l)
+	}
+	res.Terms, res.Sum = covered, sum
+	if len(partials) >= 3 {
+		res.Accelerated = wynnEpsilon(partials[max(0, len(partials)-accelerationSums):], prec)
+	}
+	return res, true
+}
+
+// estimateRange estimates the sum of the l terms of c from index start
+// from the sums a and b of samples terms at each end. Terms that follow
+// A·n^-p sum over [s, s+k) to about ∫ A·x^-p over [s-1/2, s+k-1/2], so p
+// is the exponent at which those integrals over the two samples are in
+// the ratio b/a, and the range sum a times the ratio of the integral over
+// the range to that over the first sample. An exponent past maxFarPower
+// means terms that fall faster than any power, such as geometric ones;
+// then the range beyond the samples is negligible and its sum a+b.
+func estimateRange(c *Candidate, start, l *big.Int, samples int64, prec uint) (*big.Float, bool) {
+	a, ok := SummarizeChunk(c, start, samples, prec)
+	if !ok {
+		return nil, false
+	}
+	bStart := new(big.Int).Add(start, l)
+	bStart.Sub(bStart, big.NewInt(samples))
+	b, ok := SummarizeChunk(c, bStart, samples, prec)
+	if !ok || a.Sign() == 0 || a.Sign() != b.Sign() {
+		return nil, false
+	}
+	r, _ := new(big.Float).Quo(b, a).Float64()
+
+	k := float64(samples)
+	s, _ := new(big.Float).SetInt(start).Float64()
+	sb, _ := new(big.Float).SetInt(bStart).Float64()
+	lf, _ := new(big.Float).SetInt(l).Float64()
+	s, sb = s-0.5, sb-0.5
+	logRatio := func(p float64) float64 { return logPowerIntegral(p, sb, k) - logPowerIntegral(p, s, k) }
+	if r == 0 || logRatio(maxFarPower) > math.Log(r) {
+		return a.Add(a, b), true
+	}
+	if logRatio(-maxFarPower) < math.Log(r) {
+		return nil, false // terms growing faster than any power
+	}
+	lo, hi := -maxFarPower, maxFarPower
+	for i := 0; i < 200 && hi-lo > 1e-15*math.Max(1, math.Abs(lo)); i++ {
+		if mid := (lo + hi) / 2; logRatio(mid) > math.Log(r) {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	p := (lo + hi) / 2
+	scale := math.Exp(logPowerIntegral(p, s, lf) - logPowerIntegral(p, s, k))
+	return a.Mul(a, new(big.Float).SetPrec(prec).SetFloat64(scale)), true
+}
+
+// maxFarPower bounds the exponent estimateRange fits.
+const maxFarPower = 1000.0
+
+// logPowerIntegral is ln ∫ x^-p dx over [lo, lo+w], for lo, w > 0:
+// (1-p)·ln lo + ln((e^{(1-p)·L} - 1)/(1-p)) with L = ln(1 + w/lo), which
+// holds where lo+w would round to lo.
+func logPowerIntegral(p, lo, w float64) float64 {
+	e, l := 1-p, math.Log1p(w/lo)
+	f := l // the limit at p = 1
+	if x := e * l; math.Abs(x) > 1e-12 {
+		f = math.Expm1(x) / e
+	}
+	return e*math.Log(lo) + math.Log(f)
+}
@@ -0,0 +1,73 @@
+package series
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/wildfunctions/genetic_series/pkg/constants"
+)
+
+func TestTermAtIndex(t *testing.T) {
+	// 2^64 + 1: odd, and n/3 not an integer, past int64.
+	n := new(big.Int).Lsh(big.NewInt(1), 64)
+	n.Add(n, big.NewInt(1))
+	c := mustParse(t, `\sum_{n=0}^{\infty} \frac{(-1)^{n} (3 \lfloor \frac{n}{3} \rfloor - n)}{1}`)
+	if v, ok := TermAtIndex(c, n, 64); !ok || v.Cmp(big.NewFloat(2)) != 0 {
+		t.Errorf("term at 2^64+1 = %v, %v, want 2", v, ok)
+	}
+	if _, ok := TermAtIndex(mustParse(t, `\sum_{n=0}^{\infty} \frac{1}{n!}`), n, 64); ok {
+		t.Error("(2^64+1)! defined")
+	}
+}
+
+func TestExtendSum(t *testing.T) {
+	total, _ := new(big.Int).SetString("1000000000000", 10)
+	for _, tt := range []struct {
+		formula, target string
+	}{
+		{`\sum_{n=1}^{\infty} \frac{1}{n^2}`, "pi^2/6"},
+		{`\sum_{n=0}^{\infty} \frac{(-1)^{n}}{n + 1}`, "ln2"},
+	} {
+		c := mustParse(t, tt.formula)
+		tg, err := constants.ParseTarget(tt.target)
+		if err != nil {
+			t.Fatal(err)
+		}
+		head, _, _ := ladderSum(c, 1000, testPrec)
+		far, ok := ExtendSum(c, head, 1000, total, 256, testPrec)
+		if !ok {
+			t.Fatalf("%s: not extended", tt.formula)
+		}
+		plain := countCorrectDigits(head, tg.At(testPrec))
+		got := countCorrectDigits(far.Sum, tg.At(testPrec))
+		if plain > 4 || got < 7 || far.Terms.Cmp(total) != 0 || far.Evaluated > 1e5 {
+			t.Errorf("%s: %.1f digits from 1000 terms, %.1f from %s evaluating %d", tt.formula, plain, got, far.Terms, far.Evaluated)
+		}
+	}
+
+	// Ranges short enough to sum are summed, so the result is exact.
+	c := mustParse(t, `\sum_{n=1}^{\infty} \frac{1}{n^2}`)
+	head, _, _ := ladderSum(c, 10, testPrec)
+	far, ok := ExtendSum(c, head, 10, big.NewInt(40), 32, testPrec)
+	want, _, _ := ladderSum(c, 40, testPrec)
+	diff := new(big.Float).Sub(far.Sum, want)
+	if !ok || diff.Abs(diff).Cmp(big.NewFloat(1e-70)) > 0 || len(far.Chunks) != 2 || !far.Chunks[1].Exact || far.Accelerated == nil {
+		t.Errorf("short extension: %+v, %v, want sum %s", far, ok, want.Text('g', 20))
+	}
+
+	// A shorter extension leaves a tail the partial sums' extrapolation
+	// recovers.
+	head, _, _ = ladderSum(c, 1000, testPrec)
+	far, _ = ExtendSum(c, head, 1000, big.NewInt(1_000_000), 256, testPrec)
+	zeta2, _ := constants.ParseTarget("pi^2/6")
+	if got, acc := countCorrectDigits(far.Sum, zeta2.At(testPrec)), countCorrectDigits(far.Accelerated, zeta2.At(testPrec)); acc < got+3 {
+		t.Errorf("1/n^2 to 10^6 terms: %.1f digits, %.1f extrapolated", got, acc)
+	}
+
+	// Samples of opposite sign do not fit a power law.
+	c = mustParse(t, `\sum_{n=1}^{\infty} \frac{1000 - n}{n^3}`)
+	head, _, _ = ladderSum(c, 100, testPrec)
+	if far, ok := ExtendSum(c, head, 100, total, 8, testPrec); ok {
+		t.Errorf("terms changing sign extended to %s", far.Sum.Text('g', 20))
+	}
+}
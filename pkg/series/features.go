@@ -0,0 +1,222 @@
+package series
+
+import (
+	"math"
+
+	"github.com/wildfunctions/genetic_series/pkg/expr"
+)
+
+// FeatureNames names the entries of a FeatureVector, in order. The layout
+// is stable: entries are only ever appended, so vectors written by one
+// release can be read by later ones by prefix.
+//
+//	nodes, num_nodes, den_nodes, offset_nodes  node counts
+//	depth                                      deeper of the two trees
+//	start, has_offset                          start index; 1 if c has an offset
+//	vars, consts                               n leaves, constant leaves
+//	const_max, const_sum                       log10(1+|c|): largest, and of the sum of all
+//	op_<id>                                    count of each built-in op (see expr.OpIDs)
+//	op_custom                                  count of ops added with expr.RegisterOp
+//	term0, term1, term2                        slog of the first three terms
+//	sum8, sum64                                slog of the partial sums (with offset) to 8 and 64 terms
+//	decay8, decay32                            log10|a(s+2k)/a(s+k)| for k = 8, 32
+//	probe_failed                               1 if any probed term was undefined
+//	op_tan, op_exp, op_gamma                   counts of the ops added after the layout was fixed
+//	symbols                                    symbolic constant leaves (π, e, φ, γ)
+//	op_bernoulli, op_prime, op_pochhammer      counts of the ops added after symbols
+//	op_mod, op_root, op_arctan, op_arcsin
+//	op_sinh, op_cosh, op_tanh, op_digamma, op_subfactorial
+//
+// slog(x) is sign(x)·log10(1+|x|). Probes are in float64; an undefined
+// term counts as 0 and sets probe_failed, and decays are clamped to ±30.
+var FeatureNames = [...]string{
+	"nodes", "num_nodes", "den_nodes", "offset_nodes",
+	"depth",
+	"start", "has_offset",
+	"vars", "consts",
+	"const_max", "const_sum",
+	"op_neg", "op_factorial", "op_altsign", "op_doublefactorial", "op_fib",
+	"op_sin", "op_cos", "op_ln", "op_floor", "op_ceil", "op_abs", "op_sqrt",
+	"op_add", "op_sub", "op_mul", "op_div", "op_pow", "op_binomial",
+	"op_custom",
+	"term0", "term1", "term2",
+	"sum8", "sum64",
+	"decay8", "decay32",
+	"probe_failed",
+	"op_tan", "op_exp", "op_gamma",
+	"symbols",
+	"op_bernoulli", "op_prime", "op_pochhammer",
+	"op_mod", "op_root", "op_arctan", "op_arcsin",
+	"op_sinh", "op_cosh", "op_tanh", "op_digamma", "op_subfactorial",
+}
+
+// NumFeatures is the length of a FeatureVector.
+const NumFeatures = len(FeatureNames)
+
+// FeatureIndex returns the index of the named entry of a FeatureVector.
+func FeatureIndex(name string) (int, bool) {
+	for i, n := range FeatureNames {
+		if n == name {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// FeatureVector is a fixed-length numeric description of a candidate; see
+// FeatureNames for the layout.
+type FeatureVector [NumFeatures]float64
+
+// Indices into a FeatureVector of the entries laid out above.
+const (
+	featNodes = iota
+	featNumNodes
+	featDenNodes
+	featOffsetNodes
+	featDepth
+	featStart
+	featHasOffset
+	featVars
+	featConsts
+	featConstMax
+	featConstSum
+	featOpFirst // first of the op counts, in FeatureNames order
+)
+
+const (
+	featOpCustom = featOpFirst + 18 + iota
+	featTerm0
+	featTerm1
+	featTerm2
+	featSum8
+	featSum64
+	featDecay8
+	featDecay32
+	featProbeFailed
+	featOpTan
+	featOpExp
+	featOpGamma
+	featSymbols
+	featOpBernoulli
+	featOpPrime
+	featOpPochhammer
+	featOpMod
+	featOpRoot
+	featOpArctan
+	featOpArcsin
+	featOpSinh
+	featOpCosh
+	featOpTanh
+	featOpDigamma
+	featOpSubfactorial
+)
+
+// featureOps maps each built-in op identifier to its count's index.
+var featureOps = func() map[string]int {
+	m := map[string]int{}
+	for i := featOpFirst; i < featOpCustom; i++ {
+		m[FeatureNames[i][len("op_"):]] = i
+	}
+	for _, i := range []int{featOpTan, featOpExp, featOpGamma, featOpBernoulli, featOpPrime, featOpPochhammer, featOpMod, featOpRoot, featOpArctan, featOpArcsin, featOpSinh, featOpCosh, featOpTanh, featOpDigamma, featOpSubfactorial} {
+		m[FeatureNames[i][len("op_"):]] = i
+	}
+	return m
+}()
+
+// Features computes c's feature vector: structure, constants, op counts
+// and cheap float64 probes of its terms and partial sums, for surrogate
+// models, novelty and clustering, or export to external tools. A ratio's
+// are its dividend's.
+func Features(c *Candidate) FeatureVector {
+	var f FeatureVector
+	f[featNumNodes] = float64(c.Numerator.NodeCount())
+	f[featDenNodes] = float64(c.Denominator.NodeCount())
+	f[featDepth] = float64(max(c.Numerator.Depth(), c.Denominator.Depth()))
+	f[featStart] = float64(c.Start)
+	var constSum float64
+	count := func(node expr.ExprNode) { countFeatures(&f, &constSum, node) }
+	count(c.Numerator)
+	count(c.Denominator)
+	if c.Offset != nil {
+		f[featOffsetNodes] = float64(c.Offset.NodeCount())
+		f[featHasOffset] = 1
+		count(c.Offset)
+	}
+	f[featNodes] = f[featNumNodes] + f[featDenNodes] + f[featOffsetNodes]
+	f[featConstMax] = math.Log10(1 + f[featConstMax])
+	f[featConstSum] = math.Log10(1 + constSum)
+
+	failed := false
+	term := func(k int64) float64 {
+		n := float64(c.Start + k)
+		num, ok1 := c.Numerator.EvalF64(n)
+		den, ok2 := c.Denominator.EvalF64(n)
+		t := num / den
+		if !ok1 || !ok2 || den == 0 || math.IsInf(t, 0) || math.IsNaN(t) {
+			failed = true
+			return 0
+		}
+		return t
+	}
+	sum, ok := c.OffsetF64()
+	failed = !ok
+	terms := make([]float64, 65)
+	for k := range terms {
+		terms[k] = term(int64(k))
+		if k < 64 {
+			sum += terms[k]
+		}
+		if k == 7 {
+			f[featSum8] = slog(sum)
+		}
+	}
+	f[featTerm0], f[featTerm1], f[featTerm2] = slog(terms[0]), slog(terms[1]), slog(terms[2])
+	f[featSum64] = slog(sum)
+	f[featDecay8] = decay(terms[8], terms[16])
+	f[featDecay32] = decay(terms[32], terms[64])
+	if failed {
+		f[featProbeFailed] = 1
+	}
+	return f
+}
+
+func countFeatures(f *FeatureVector, constSum *float64, node expr.ExprNode) {
+	switch n := node.(type) {
+	case *expr.VarNode:
+		f[featVars]++
+	case *expr.ConstNode:
+		f[featConsts]++
+		v := math.Abs(float64(n.Val))
+		f[featConstMax] = math.Max(f[featConstMax], v)
+		*constSum += v
+	case *expr.SymbolicConstNode:
+		f[featSymbols]++
+	case *expr.UnaryNode:
+		f[opFeature(expr.UnaryOpID(n.Op))]++
+		countFeatures(f, constSum, n.Child)
+	case *expr.BinaryNode:
+		f[opFeature(expr.BinaryOpID(n.Op))]++
+		countFeatures(f, constSum, n.Left)
+		countFeatures(f, constSum, n.Right)
+	}
+}
+
+func opFeature(id string) int {
+	if i, ok := featureOps[id]; ok {
+		return i
+	}
+	return featOpCustom
+}
+
+// slog is a signed logarithm, continuous through 0.
+func slog(x float64) float64 {
+	return math.Copysign(math.Log10(1+math.Abs(x)), x)
+}
+
+// decay is log10|b/a|, clamped to ±30; 0 for a zero term.
+func decay(a, b float64) float64 {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return math.Max(-30, math.Min(30, math.Log10(math.Abs(b/a))))
+}
@@ -0,0 +1,84 @@
+package series
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFeatures(t *testing.T) {
+	if featOpSubfactorial != NumFeatures-1 {
+		t.Fatalf("feature indices end at %d, layout has %d entries", featOpSubfactorial, NumFeatures)
+	}
+	c := mustParse(t, `\sum_{n=0}^{\infty} \frac{(-1)^{n} 4}{2n+1}`)
+	f := Features(c)
+	at := func(name string) float64 {
+		i, ok := FeatureIndex(name)
+		if !ok {
+			t.Fatalf("no feature %s", name)
+		}
+		return f[i]
+	}
+	want := map[string]float64{
+		"nodes":        float64(c.NodeCount()),
+		"op_altsign":   1,
+		"op_mul":       2,
+		"op_add":       1,
+		"vars":         2,
+		"consts":       3,
+		"const_max":    math.Log10(5),
+		"term0":        slog(4),
+		"term1":        slog(-4.0 / 3),
+		"op_custom":    0,
+		"probe_failed": 0,
+	}
+	for name, v := range want {
+		if got := at(name); math.Abs(got-v) > 1e-12 {
+			t.Errorf("%s = %v, want %v", name, got, v)
+		}
+	}
+	if d := at("sum64") - slog(math.Pi); math.Abs(d) > 0.01 {
+		t.Errorf("sum64 = %v, want about slog(pi) = %v", at("sum64"), slog(math.Pi))
+	}
+
+	if f := Features(mustParse(t, `\sum_{n=1}^{\infty} \frac{\tan(\frac{1}{n})}{e^{n}}`)); f[featOpTan] != 1 || f[featOpExp] != 1 || f[featOpCustom] != 0 {
+		t.Errorf("op_tan, op_exp, op_custom = %v, %v, %v, want 1, 1, 0", f[featOpTan], f[featOpExp], f[featOpCustom])
+	}
+	if f := Features(mustParse(t, `\sum_{n=1}^{\infty} \frac{1}{\Gamma(\frac{n}{2})}`)); f[featOpGamma] != 1 {
+		t.Errorf("op_gamma = %v, want 1", f[featOpGamma])
+	}
+	if f := Features(mustParse(t, `\sum_{n=1}^{\infty} \frac{B_{2n}}{(2n)!}`)); f[featOpBernoulli] != 1 {
+		t.Errorf("op_bernoulli = %v, want 1", f[featOpBernoulli])
+	}
+	if f := Features(mustParse(t, `\sum_{n=1}^{\infty} \frac{1}{p_{n} p_{n+1}}`)); f[featOpPrime] != 2 {
+		t.Errorf("op_prime = %v, want 2", f[featOpPrime])
+	}
+	if f := Features(mustParse(t, `\sum_{n=0}^{\infty} \frac{(\frac{1}{2})_{n}}{n! \, 4^{n}}`)); f[featOpPochhammer] != 1 || f[featOpCustom] != 0 {
+		t.Errorf("op_pochhammer, op_custom = %v, %v, want 1, 0", f[featOpPochhammer], f[featOpCustom])
+	}
+	if f := Features(mustParse(t, `\sum_{n=1}^{\infty} \frac{(2 - n \bmod 4) (n \bmod 2)}{n}`)); f[featOpMod] != 2 {
+		t.Errorf("op_mod = %v, want 2", f[featOpMod])
+	}
+	if f := Features(mustParse(t, `\sum_{n=1}^{\infty} \frac{\sqrt[3]{n}}{n^{2}}`)); f[featOpRoot] != 1 {
+		t.Errorf("op_root = %v, want 1", f[featOpRoot])
+	}
+	if f := Features(mustParse(t, `\sum_{n=1}^{\infty} \frac{\arctan{(\frac{1}{n^{2}})} + \arcsin{(\frac{1}{n})}}{n}`)); f[featOpArctan] != 1 || f[featOpArcsin] != 1 {
+		t.Errorf("op_arctan, op_arcsin = %v, %v, want 1, 1", f[featOpArctan], f[featOpArcsin])
+	}
+	if f := Features(mustParse(t, `\sum_{n=1}^{\infty} \frac{\tanh{(n)} \sinh{(\frac{1}{n})}}{\cosh{(n)} \cosh{(2 n)}}`)); f[featOpSinh] != 1 || f[featOpCosh] != 2 || f[featOpTanh] != 1 {
+		t.Errorf("op_sinh, op_cosh, op_tanh = %v, %v, %v, want 1, 2, 1", f[featOpSinh], f[featOpCosh], f[featOpTanh])
+	}
+	if f := Features(mustParse(t, `\sum_{n=1}^{\infty} \frac{\psi{(n + 1)} + \gamma}{n^{2}}`)); f[featOpDigamma] != 1 {
+		t.Errorf("op_digamma = %v, want 1", f[featOpDigamma])
+	}
+	if f := Features(mustParse(t, `\sum_{n=0}^{\infty} \frac{{!{n}}}{n! \cdot 2^{n}}`)); f[featOpSubfactorial] != 1 {
+		t.Errorf("op_subfactorial = %v, want 1", f[featOpSubfactorial])
+	}
+	if f := Features(mustParse(t, `\sum_{n=1}^{\infty} \frac{\pi}{e n^{2}}`)); f[featSymbols] != 2 || f[featConsts] != 1 {
+		t.Errorf("symbols, consts = %v, %v, want 2, 1", f[featSymbols], f[featConsts])
+	}
+
+	div := mustParse(t, `\sum_{n=0}^{\infty} \frac{1}{n-3}`)
+	if Features(div)[featProbeFailed] != 1 {
+		t.Error("probe_failed not set for a term dividing by zero")
+	}
+}
@@ -12,6 +12,7 @@ type FitnessWeights struct {
 	Accuracy    float64
 	Complexity  float64 // penalty weight (subtracted)
 	Convergence float64
+	Cost        float64 // penalty weight on log10(1 + Candidate.EvalCost()) (subtracted)
 }
 
 // DefaultWeights returns the default fitness weights.
@@ -20,15 +21,19 @@ func DefaultWeights() FitnessWeights {
 		Accuracy:    10.0,
 		Complexity:  2.0,
 		Convergence: 1.0,
+		Cost:        0.5,
 	}
 }
 
 // Fitness holds the multi-objective fitness score for a candidate.
 type Fitness struct {
-	Combined        float64
-	CorrectDigits   float64
-	Simplicity      float64
-	ConvergenceRate float64
+	Combined        float64 // the score selection ranks by: Accuracy·digits less the complexity and cost penalties (see FitnessWeights)
+	CorrectDigits   float64 // decimal digits of the partial sum that agree with the target
+	Simplicity      float64 // 1 / max(Candidate.Complexity(), 1)
+	ConvergenceRate float64 // EvalResult.ConvergenceRate
+	Deferred        bool    `json:",omitempty"` // not evaluated this generation (time budget ran out)
+	Error           float64 `json:",omitempty"` // signed relative error (sum - target) / |target|, absolute for a zero target; 0 if unknown
+	Match           string  `json:",omitempty"` // exploration runs: the constant matched past MatchThreshold
 }
 
 // WorstFitness returns a fitness score for invalid/failed candidates.
@@ -84,19 +89,146 @@ func ComputeFitness(c *Candidate, result EvalResult, target *big.Float, weights
 	complexity := c.Complexity()
 	simplicity := 1.0 / math.Max(complexity, 1.0)
 
+	return Fitness{
+		Combined:        weights.combined(c, correctDigits),
+		CorrectDigits:   correctDigits,
+		Simplicity:      simplicity,
+		ConvergenceRate: result.ConvergenceRate,
+		Error:           signedError(result.PartialSum, target),
+	}
+}
+
+// combined is the Combined score of c at correctDigits.
+func (w FitnessWeights) combined(c *Candidate, correctDigits float64) float64 {
 	// Scale complexity penalty by accuracy: no penalty at 0 digits (allow exploration),
 	// full penalty at 5+ digits (prevent bloat once candidates are accurate).
 	penaltyScale := math.Min(correctDigits, 5.0) / 5.0
 
-	combined := weights.Accuracy*correctDigits -
-		weights.Complexity*complexity*penaltyScale
+	return w.Accuracy*correctDigits -
+		(w.Complexity*c.Complexity()+w.costPenalty(c))*penaltyScale
+}
 
-	return Fitness{
-		Combined:        combined,
-		CorrectDigits:   correctDigits,
-		Simplicity:      simplicity,
-		ConvergenceRate: result.ConvergenceRate,
+// ComputeFitnessWithin scores a candidate against a target known only to
+// lie in [lo, hi]. Every sum inside the interval gets the digits its width
+// allows, log10(|mid| / half-width); one outside is scored by its distance
+// d to the nearer end, as -log10((half-width + d) / |mid|), so the digits
+// fall off continuously from the interval's. Error is 0 inside and the
+// signed distance to the nearer end, relative to |mid|, outside.
+func ComputeFitnessWithin(c *Candidate, result EvalResult, lo, hi *big.Float, weights FitnessWeights) Fitness {
+	mid := new(big.Float).Add(lo, hi)
+	mid.Quo(mid, big.NewFloat(2))
+	f := ComputeFitness(c, result, mid, weights)
+	if f.Combined == WorstFitness().Combined || result.PartialSum == nil {
+		return f
+	}
+	var d *big.Float
+	switch s := result.PartialSum; {
+	case s.Cmp(lo) < 0:
+		d = new(big.Float).Sub(s, lo)
+	case s.Cmp(hi) > 0:
+		d = new(big.Float).Sub(s, hi)
+	default:
+		d = new(big.Float)
+	}
+	half, _ := new(big.Float).Sub(hi, mid).Float64()
+	df, _ := d.Float64()
+	m, _ := mid.Float64()
+	return withinFitness(c, f, half, df, m, weights)
+}
+
+// ComputeFitnessWithinF64 is ComputeFitnessWithin with float64 evaluation.
+func ComputeFitnessWithinF64(c *Candidate, result EvalResultF64, lo, hi float64, weights FitnessWeights) Fitness {
+	mid := (lo + hi) / 2
+	f := ComputeFitnessF64(c, result, mid, weights)
+	if f.Combined == WorstFitness().Combined {
+		return f
+	}
+	var d float64
+	switch s := result.PartialSum; {
+	case s < lo:
+		d = s - lo
+	case s > hi:
+		d = s - hi
+	}
+	return withinFitness(c, f, hi-mid, d, mid, weights)
+}
+
+// withinFitness rescores f for a sum at signed distance d outside an
+// interval of half-width half around mid.
+func withinFitness(c *Candidate, f Fitness, half, d, mid float64, weights FitnessWeights) Fitness {
+	scale := math.Abs(mid)
+	if scale == 0 {
+		scale = 1 // absolute error, as for a zero target
+	}
+	digits := math.Max(0, math.Min(-math.Log10((half+math.Abs(d))/scale), MaxDigits))
+	f.CorrectDigits = digits
+	f.Combined = weights.combined(c, digits)
+	f.Error = d / scale
+	return f
+}
+
+// signedError is (computed - target) / |target|, or computed - target
+// when the target is zero, as a float64. Its sign says whether the sum
+// overshoots, which digit counts alone do not.
+func signedError(computed, target *big.Float) float64 {
+	if computed == nil || target == nil {
+		return 0
+	}
+	diff := new(big.Float).Sub(computed, target)
+	if target.Sign() != 0 {
+		diff.Quo(diff, new(big.Float).Abs(target))
+	}
+	f, _ := diff.Float64()
+	return f
+}
+
+// costPenalty is the evaluation-cost part of the penalty. It grows with
+// the log of the cost, so it separates a tree of factorials from a
+// rational one of the same size without outweighing a node of complexity.
+func (w FitnessWeights) costPenalty(c *Candidate) float64 {
+	if w.Cost == 0 {
+		return 0
+	}
+	return w.Cost * math.Log10(1+c.EvalCost())
+}
+
+// matchBaseDigits and digitsPerComplexity set MatchThreshold. One unit
+// of Complexity is 3 bits, about 0.9 decimal digits.
+const (
+	matchBaseDigits     = 3
+	digitsPerComplexity = 0.9
+)
+
+// MatchThreshold is the correct digits c needs for an exploration run to
+// count it as matching a constant: a few digits more than c takes to
+// write down, so that the formula explains the digits rather than
+// encoding them, and bigger formulas need more digits to be interesting.
+func MatchThreshold(c *Candidate) float64 {
+	return matchBaseDigits + digitsPerComplexity*c.Complexity()
+}
+
+// ComputeBestFitness scores c against each of targets with ComputeFitness
+// and returns the fitness with the most correct digits, and its target's
+// index (-1, with WorstFitness, if every score is the worst).
+func ComputeBestFitness(c *Candidate, result EvalResult, targets []*big.Float, weights FitnessWeights) (Fitness, int) {
+	best, bestIdx := WorstFitness(), -1
+	for i, t := range targets {
+		if f := ComputeFitness(c, result, t, weights); f.Combined > WorstFitness().Combined && (bestIdx < 0 || f.CorrectDigits > best.CorrectDigits) {
+			best, bestIdx = f, i
+		}
+	}
+	return best, bestIdx
+}
+
+// ComputeBestFitnessF64 is ComputeBestFitness with float64 evaluation.
+func ComputeBestFitnessF64(c *Candidate, result EvalResultF64, targets []float64, weights FitnessWeights) (Fitness, int) {
+	best, bestIdx := WorstFitness(), -1
+	for i, t := range targets {
+		if f := ComputeFitnessF64(c, result, t, weights); f.Combined > WorstFitness().Combined && (bestIdx < 0 || f.CorrectDigits > best.CorrectDigits) {
+			best, bestIdx = f, i
+		}
 	}
+	return best, bestIdx
 }
 
 // MaxDigits is the cap on correct digits (limited by precision).
@@ -176,15 +308,15 @@ func ComputeFitnessF64(c *Candidate, result EvalResultF64, targetF64 float64, we
 	complexity := c.Complexity()
 	simplicity := 1.0 / math.Max(complexity, 1.0)
 
-	penaltyScale := math.Min(correctDigits, 5.0) / 5.0
-
-	combined := weights.Accuracy*correctDigits -
-		weights.Complexity*complexity*penaltyScale
-
+	e := result.PartialSum - targetF64
+	if absTgt > 0 {
+		e /= absTgt
+	}
 	return Fitness{
-		Combined:      combined,
+		Combined:      weights.combined(c, correctDigits),
 		CorrectDigits: correctDigits,
 		Simplicity:    simplicity,
+		Error:         e,
 	}
 }
 
@@ -0,0 +1,210 @@
+package series
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"math"
+	"sort"
+	"strconv"
+)
+
+// FitnessHook adjusts a fitness after the standard computation, for
+// research-specific scoring without changing this package. It is given
+// only evaluated candidates: failed and deferred ones keep their fitness.
+type FitnessHook func(c *Candidate, f Fitness) Fitness
+
+// scriptMetrics are the names a fitness script can read.
+var scriptMetrics = map[string]func(c *Candidate, f Fitness) float64{
+	"combined":    func(_ *Candidate, f Fitness) float64 { return f.Combined },
+	"digits":      func(_ *Candidate, f Fitness) float64 { return f.CorrectDigits },
+	"simplicity":  func(_ *Candidate, f Fitness) float64 { return f.Simplicity },
+	"convergence": func(_ *Candidate, f Fitness) float64 { return f.ConvergenceRate },
+	"error":       func(_ *Candidate, f Fitness) float64 { return f.Error },
+	"complexity":  func(c *Candidate, _ Fitness) float64 { return c.Complexity() },
+	"nodes":       func(c *Candidate, _ Fitness) float64 { return float64(c.NodeCount()) },
+	"cost":        func(c *Candidate, _ Fitness) float64 { return c.EvalCost() },
+	"start":       func(c *Candidate, _ Fitness) float64 { return float64(c.Start) },
+	"offset":      func(c *Candidate, _ Fitness) float64 { return boolF(c.Offset != nil) },
+}
+
+// scriptFuncs are the functions a fitness script can call, by arity.
+var scriptFuncs = map[string]struct {
+	arity int
+	f     func(args []float64) float64
+}{
+	"abs":    {1, func(a []float64) float64 { return math.Abs(a[0]) }},
+	"sqrt":   {1, func(a []float64) float64 { return math.Sqrt(a[0]) }},
+	"exp":    {1, func(a []float64) float64 { return math.Exp(a[0]) }},
+	"log":    {1, func(a []float64) float64 { return math.Log(a[0]) }},
+	"log10":  {1, func(a []float64) float64 { return math.Log10(a[0]) }},
+	"min":    {2, func(a []float64) float64 { return math.Min(a[0], a[1]) }},
+	"max":    {2, func(a []float64) float64 { return math.Max(a[0], a[1]) }},
+	"pow":    {2, func(a []float64) float64 { return math.Pow(a[0], a[1]) }},
+	"ifelse": {3, func(a []float64) float64 { return ifElse(a[0] != 0, a[1], a[2]) }},
+}
+
+type scriptFunc func(c *Candidate, f Fitness) float64
+
+// ParseFitnessScript compiles src, an expression in Go syntax over the
+// metrics combined, digits, simplicity, convergence, error (see Fitness),
+// complexity, nodes, cost, start and offset (1 if the candidate has one),
+// into a hook that replaces Combined with its value. Comparisons, &&, ||
+// and ! yield 1 or 0, and abs, sqrt, exp, log, log10, min, max, pow and
+// ifelse(cond, a, b) are available:
+//
+//	combined - 0.5*nodes + ifelse(start == 0, 1, 0)
+//
+// A NaN result leaves the fitness as computed.
+func ParseFitnessScript(src string) (FitnessHook, error) {
+	node, err := parser.ParseExpr(src)
+	if err != nil {
+		return nil, fmt.Errorf("fitness script: %w", err)
+	}
+	eval, err := compileScript(node)
+	if err != nil {
+		return nil, fmt.Errorf("fitness script: %w", err)
+	}
+	return func(c *Candidate, f Fitness) Fitness {
+		if v := eval(c, f); !math.IsNaN(v) {
+			f.Combined = v
+		}
+		return f
+	}, nil
+}
+
+func compileScript(node ast.Expr) (scriptFunc, error) {
+	switch n := node.(type) {
+	case *ast.ParenExpr:
+		return compileScript(n.X)
+	case *ast.BasicLit:
+		if n.Kind != token.INT && n.Kind != token.FLOAT {
+			return nil, fmt.Errorf("unsupported literal %s", n.Value)
+		}
+		v, err := strconv.ParseFloat(n.Value, 64)
+		if err != nil {
+			return nil, err
+		}
+		return func(*Candidate, Fitness) float64 { return v }, nil
+	case *ast.Ident:
+		switch n.Name {
+		case "true":
+			return func(*Candidate, Fitness) float64 { return 1 }, nil
+		case "false":
+			return func(*Candidate, Fitness) float64 { return 0 }, nil
+		}
+		m, ok := scriptMetrics[n.Name]
+		if !ok {
+			return nil, fmt.Errorf("unknown metric %s (have %v)", n.Name, sortedKeys(scriptMetrics))
+		}
+		return m, nil
+	case *ast.UnaryExpr:
+		x, err := compileScript(n.X)
+		if err != nil {
+			return nil, err
+		}
+		switch n.Op {
+		case token.SUB:
+			return func(c *Candidate, f Fitness) float64 { return -x(c, f) }, nil
+		case token.ADD:
+			return x, nil
+		case token.NOT:
+			return func(c *Candidate, f Fitness) float64 { return boolF(x(c, f) == 0) }, nil
+		}
+		return nil, fmt.Errorf("unsupported operator %s", n.Op)
+	case *ast.BinaryExpr:
+		return compileBinary(n)
+	case *ast.CallExpr:
+		name, ok := n.Fun.(*ast.Ident)
+		if !ok {
+			return nil, fmt.Errorf("unsupported call")
+		}
+		fn, ok := scriptFuncs[name.Name]
+		if !ok {
+			return nil, fmt.Errorf("unknown function %s", name.Name)
+		}
+		if len(n.Args) != fn.arity {
+			return nil, fmt.Errorf("%s takes %d arguments, got %d", name.Name, fn.arity, len(n.Args))
+		}
+		args := make([]scriptFunc, len(n.Args))
+		for i, a := range n.Args {
+			var err error
+			if args[i], err = compileScript(a); err != nil {
+				return nil, err
+			}
+		}
+		return func(c *Candidate, f Fitness) float64 {
+			vals := make([]float64, len(args))
+			for i, a := range args {
+				vals[i] = a(c, f)
+			}
+			return fn.f(vals)
+		}, nil
+	}
+	return nil, fmt.Errorf("unsupported expression %T", node)
+}
+
+func compileBinary(n *ast.BinaryExpr) (scriptFunc, error) {
+	x, err := compileScript(n.X)
+	if err != nil {
+		return nil, err
+	}
+	y, err := compileScript(n.Y)
+	if err != nil {
+		return nil, err
+	}
+	var op func(a, b float64) float64
+	switch n.Op {
+	case token.ADD:
+		op = func(a, b float64) float64 { return a + b }
+	case token.SUB:
+		op = func(a, b float64) float64 { return a - b }
+	case token.MUL:
+		op = func(a, b float64) float64 { return a * b }
+	case token.QUO:
+		op = func(a, b float64) float64 { return a / b }
+	case token.LSS:
+		op = func(a, b float64) float64 { return boolF(a < b) }
+	case token.LEQ:
+		op = func(a, b float64) float64 { return boolF(a <= b) }
+	case token.GTR:
+		op = func(a, b float64) float64 { return boolF(a > b) }
+	case token.GEQ:
+		op = func(a, b float64) float64 { return boolF(a >= b) }
+	case token.EQL:
+		op = func(a, b float64) float64 { return boolF(a == b) }
+	case token.NEQ:
+		op = func(a, b float64) float64 { return boolF(a != b) }
+	case token.LAND:
+		op = func(a, b float64) float64 { return boolF(a != 0 && b != 0) }
+	case token.LOR:
+		op = func(a, b float64) float64 { return boolF(a != 0 || b != 0) }
+	default:
+		return nil, fmt.Errorf("unsupported operator %s", n.Op)
+	}
+	return func(c *Candidate, f Fitness) float64 { return op(x(c, f), y(c, f)) }, nil
+}
+
+func boolF(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func ifElse(cond bool, a, b float64) float64 {
+	if cond {
+		return a
+	}
+	return b
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
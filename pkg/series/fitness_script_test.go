@@ -0,0 +1,51 @@
+package series
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestParseFitnessScript(t *testing.T) {
+	c, err := ParseCandidateLatex(`1 + \sum_{n=1}^{\infty} \frac{1}{n!}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := Fitness{Combined: 100, CorrectDigits: 12, Error: -1e-12}
+	tests := []struct {
+		src  string
+		want float64
+	}{
+		{`combined`, 100},
+		{`combined - 0.5*nodes`, 100 - 0.5*float64(c.NodeCount())},
+		{`digits + ifelse(start == 1 && offset, 10, 0)`, 22},
+		{`ifelse(error > 0, 1, -1) * (digits >= 12)`, -1},
+		{`max(log10(combined), -abs(error)) + !(digits < 10)`, 3},
+		{`sqrt(-1)`, 100}, // NaN keeps the computed fitness
+	}
+	for _, tt := range tests {
+		hook, err := ParseFitnessScript(tt.src)
+		if err != nil {
+			t.Errorf("ParseFitnessScript(%q): %v", tt.src, err)
+			continue
+		}
+		if got := hook(c, f); math.Abs(got.Combined-tt.want) > 1e-12 || got.CorrectDigits != f.CorrectDigits {
+			t.Errorf("%q: Combined = %v, want %v", tt.src, got.Combined, tt.want)
+		}
+	}
+
+	for src, msg := range map[string]string{
+		`combined +`:      "fitness script",
+		`combned * 2`:     "unknown metric combned",
+		`floor(digits)`:   "unknown function floor",
+		`max(digits)`:     "max takes 2 arguments",
+		`"digits"`:        "unsupported literal",
+		`combined % 2`:    "unsupported operator %",
+		`digits[0]`:       "unsupported expression",
+		`math.Abs(error)`: "unsupported call",
+	} {
+		if _, err := ParseFitnessScript(src); err == nil || !strings.Contains(err.Error(), msg) {
+			t.Errorf("ParseFitnessScript(%q) = %v, want an error mentioning %q", src, err, msg)
+		}
+	}
+}
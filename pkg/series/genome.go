@@ -0,0 +1,63 @@
+package series
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/wildfunctions/genetic_series/pkg/expr"
+)
+
+// Genome is a candidate in compact serialized form: an unsigned-varint start
+// index followed by the numerator and denominator bytecode (see expr.Encode)
+// and, if the candidate has one, the offset's. A ratio's Over is not
+// stored, so streaming populations hold single series only. Each tree
+// carries its bytecode version, so genomes written by an older release
+// decode as they were.
+// Large populations are held as genomes and decoded to trees only while a
+// candidate is being evaluated or bred.
+type Genome []byte
+
+// EncodeCandidate serializes c into a Genome.
+func EncodeCandidate(c *Candidate) Genome {
+	g := make([]byte, 0, 4+2*c.NodeCount())
+	g = binary.AppendVarint(g, c.Start)
+	g = expr.AppendEncode(g, c.Numerator)
+	g = expr.AppendEncode(g, c.Denominator)
+	if c.Offset != nil {
+		g = expr.AppendEncode(g, c.Offset)
+	}
+	return g
+}
+
+// Decode materializes the candidate stored in g.
+func (g Genome) Decode() (*Candidate, error) {
+	start, n := binary.Varint(g)
+	if n <= 0 {
+		return nil, fmt.Errorf("genome: bad start index")
+	}
+	num, rest, err := expr.DecodePrefix(g[n:])
+	if err != nil {
+		return nil, fmt.Errorf("genome numerator: %w", err)
+	}
+	den, rest, err := expr.DecodePrefix(rest)
+	if err != nil {
+		return nil, fmt.Errorf("genome denominator: %w", err)
+	}
+	c := &Candidate{Numerator: num, Denominator: den, Start: start}
+	if len(rest) > 0 {
+		if c.Offset, err = expr.Decode(rest); err != nil {
+			return nil, fmt.Errorf("genome offset: %w", err)
+		}
+	}
+	return c, nil
+}
+
+// MustDecode is Decode for genomes produced by EncodeCandidate in this
+// process, where a failure indicates a bug rather than bad input.
+func (g Genome) MustDecode() *Candidate {
+	c, err := g.Decode()
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
@@ -0,0 +1,31 @@
+package series
+
+import (
+	"testing"
+
+	"github.com/wildfunctions/genetic_series/pkg/expr"
+)
+
+func TestGenome_RoundTrip(t *testing.T) {
+	for _, latex := range append(loadCorpus(t), `\ln(2) - \sum_{n=1}^{\infty} \frac{1}{n 2^{n}}`) {
+		c := mustParse(t, latex)
+		g := EncodeCandidate(c)
+		got, err := g.Decode()
+		if err != nil {
+			t.Fatalf("Decode(%q): %v", latex, err)
+		}
+		if got.Start != c.Start || !expr.Equal(got.Numerator, c.Numerator) || !expr.Equal(got.Denominator, c.Denominator) ||
+			(got.Offset == nil) != (c.Offset == nil) || c.Offset != nil && !expr.Equal(got.Offset, c.Offset) {
+			t.Errorf("round trip of %q: got %s", latex, got)
+		}
+	}
+}
+
+func TestGenome_DecodeRejectsCorrupt(t *testing.T) {
+	g := EncodeCandidate(mustParse(t, `\sum_{n=1}^{\infty} \frac{1}{n^{2}}`))
+	for i := 0; i < len(g); i++ {
+		if _, err := g[:i].Decode(); err == nil {
+			t.Errorf("truncated genome of %d/%d bytes decoded", i, len(g))
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package series
+
+import (
+	"math/big"
+
+	"github.com/wildfunctions/genetic_series/pkg/constants"
+	"github.com/wildfunctions/genetic_series/pkg/numeric"
+)
+
+// LadderSteps is how many times VerifyLadder doubles precision and terms
+// past the search's own settings (so 2× and 4×).
+const LadderSteps = 2
+
+// ladderSlack is how many digits a rung may lose to the one below it and
+// still count as agreeing: convergence estimates wobble by a fraction of a
+// digit, precision artifacts lose far more.
+const ladderSlack = 0.5
+
+// LadderRung is one re-evaluation in a precision ladder.
+type LadderRung struct {
+	Precision uint    `json:"precision"`
+	Terms     int64   `json:"terms"`  // terms actually summed (fewer than asked if a term failed)
+	Digits    float64 `json:"digits"` // correct digits against the target, at most MaxDigits
+}
+
+// VerifyLadder re-sums c at prec/maxTerms and then at 2×, 4×, ... of both
+// (LadderSteps doublings) and reports the rungs and whether they agree: a
+// genuine match keeps or gains digits as precision and terms grow, while
+// an artifact of rounding or of the term cutoff loses them. Summation is
+// SumBinarySplit for hypergeometric candidates, which it sums to each
+// rung's precision in however many terms that takes, and otherwise
+// PartialSumNum. Neither has a timeout, so this is meant for background
+// verification of a few candidates, not the search loop. Each rung compares
+// against the target generated at its own precision.
+func VerifyLadder(c *Candidate, maxTerms int64, prec uint, target constants.Target) ([]LadderRung, bool) {
+	rungs := make([]LadderRung, 0, LadderSteps+1)
+	for i := 0; i <= LadderSteps; i++ {
+		p, terms := prec<<i, maxTerms<<i
+		sum, n, ok := SumBinarySplit(c, p)
+		if !ok {
+			sum, n, ok = ladderSum(c, terms, p)
+		}
+		if !ok {
+			return rungs, false
+		}
+		// Capped like fitness: past MaxDigits, the target's own precision
+		// decides, not the candidate.
+		digits := min(countCorrectDigits(sum, target.At(p)), MaxDigits)
+		rungs = append(rungs, LadderRung{Precision: p, Terms: n, Digits: digits})
+	}
+	return rungs, LadderAgrees(rungs)
+}
+
+// LadderAgrees reports whether no rung loses more than a fraction of a
+// digit to the one below it.
+func LadderAgrees(rungs []LadderRung) bool {
+	if len(rungs) == 0 {
+		return false
+	}
+	for i := 1; i < len(rungs); i++ {
+		if rungs[i].Digits < rungs[i-1].Digits-ladderSlack {
+			return false
+		}
+	}
+	return true
+}
+
+// ladderSum is PartialSumNum with the big.Float backend, treating a panic
+// as a failed sum.
+func ladderSum(c *Candidate, terms int64, prec uint) (sum *big.Float, n int64, ok bool) {
+	defer func() {
+		if recover() != nil {
+			sum, n, ok = nil, 0, false
+		}
+	}()
+	return PartialSumNum[*big.Float](c, numeric.BigFloat{}, terms, prec)
+}
@@ -0,0 +1,34 @@
+package series
+
+import (
+	"testing"
+
+	"github.com/wildfunctions/genetic_series/pkg/constants"
+)
+
+func TestVerifyLadder(t *testing.T) {
+	e := constants.Get("e")
+	rungs, ok := VerifyLadder(mustParse(t, `\sum_{n=0}^{\infty} \frac{1}{n!}`), 256, testPrec, e)
+	if !ok || len(rungs) != LadderSteps+1 {
+		t.Fatalf("1/n! vs e: rungs %+v, ok %v; want verified", rungs, ok)
+	}
+	if rungs[2].Precision != 4*testPrec || rungs[1].Terms > 512 {
+		t.Errorf("rungs = %+v, want precision and terms doubling", rungs)
+	}
+
+	// The harmonic series matches its own 256-term partial sum only at
+	// that cutoff; more terms pull it away.
+	h := mustParse(t, `\sum_{n=1}^{\infty} \frac{1}{n}`)
+	sum, _, _ := ladderSum(h, 256, testPrec)
+	target, err := constants.ParseTarget(sum.Text('g', 200))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rungs, ok = VerifyLadder(h, 256, testPrec, target)
+	if ok {
+		t.Errorf("harmonic cutoff artifact verified: %+v", rungs)
+	}
+	if rungs[0].Digits < 40 {
+		t.Errorf("first rung %.1f digits, want a near-exact match", rungs[0].Digits)
+	}
+}
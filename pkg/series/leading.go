@@ -0,0 +1,132 @@
+package series
+
+import (
+	"math/big"
+
+	"github.com/wildfunctions/genetic_series/pkg/expr"
+)
+
+// maxZeroLeading bounds how many leading zero terms DropZeroLeading skips.
+const maxZeroLeading = 8
+
+// Reindex returns the same series written to start at Start+k: every n in
+// both trees becomes n-k, with n+j folded to n+(j-k), so
+// Sum_{n=0} 1/(n+1) reindexed by 1 is Sum_{n=1} 1/n. c is not modified.
+func Reindex(c *Candidate, k int64) *Candidate {
+	return &Candidate{
+		Numerator:   shiftIndex(c.Numerator, k),
+		Denominator: shiftIndex(c.Denominator, k),
+		Start:       c.Start + k,
+		Offset:      c.Offset,
+	}
+}
+
+// shiftIndex substitutes n-k for n in node.
+func shiftIndex(node expr.ExprNode, k int64) expr.ExprNode {
+	if j, ok := offsetOf(node); ok {
+		return offsetNode(j - k)
+	}
+	switch n := node.(type) {
+	case *expr.VarNode:
+		return offsetNode(-k)
+	case *expr.UnaryNode:
+		return &expr.UnaryNode{Op: n.Op, Child: shiftIndex(n.Child, k)}
+	case *expr.BinaryNode:
+		return &expr.BinaryNode{Op: n.Op, Left: shiftIndex(n.Left, k), Right: shiftIndex(n.Right, k)}
+	}
+	return node
+}
+
+// offsetNode returns n+j, written as n, n + j or n - |j|.
+func offsetNode(j int64) expr.ExprNode {
+	switch {
+	case j == 0:
+		return &expr.VarNode{}
+	case j > 0:
+		return &expr.BinaryNode{Op: expr.OpAdd, Left: &expr.VarNode{}, Right: &expr.ConstNode{Val: j}}
+	default:
+		return &expr.BinaryNode{Op: expr.OpSub, Left: &expr.VarNode{}, Right: &expr.ConstNode{Val: -j}}
+	}
+}
+
+// SplitLeading splits the first k terms off c:
+//
+//	Sum_{n=S} t(n) = t(S) + ... + t(S+k-1) + Sum_{n=S+k} t(n)
+//
+// It returns the split-off terms and the remaining series, or false if a
+// split-off term fails to evaluate (so the identity would not hold).
+func SplitLeading(c *Candidate, k int64, prec uint) ([]*big.Float, *Candidate, bool) {
+	terms := make([]*big.Float, 0, k)
+	for n := c.Start; n < c.Start+k; n++ {
+		t, ok := termAt(c, n, prec)
+		if !ok {
+			return nil, nil, false
+		}
+		terms = append(terms, t)
+	}
+	rest := c.Clone()
+	rest.Start += k
+	return terms, rest, true
+}
+
+// AbsorbLeading is the inverse of SplitLeading: it moves the start of c
+// back by k, returning the sum of the k absorbed terms t(S-k)..t(S-1),
+// which the caller must subtract from any constant offset it carries. It
+// returns false if Start-k would be negative or an absorbed term fails.
+func AbsorbLeading(c *Candidate, k int64, prec uint) (*big.Float, *Candidate, bool) {
+	if c.Start-k < 0 {
+		return nil, nil, false
+	}
+	wider := c.Clone()
+	wider.Start -= k
+	terms, _, ok := SplitLeading(wider, k, prec)
+	if !ok {
+		return nil, nil, false
+	}
+	head := new(big.Float).SetPrec(prec)
+	for _, t := range terms {
+		head.Add(head, t)
+	}
+	return head, wider, true
+}
+
+// DropZeroLeading skips leading terms that are exactly zero (a numerator
+// that vanishes at Start over a defined, non-zero denominator), so
+// Sum_{n=0} n/2^n becomes Sum_{n=1} n/2^n. It returns c itself if there is
+// nothing to skip.
+func DropZeroLeading(c *Candidate) *Candidate {
+	start := c.Start
+	for i := 0; i < maxZeroLeading; i++ {
+		t, ok := termAt(c, start, 64)
+		if !ok || t.Sign() != 0 {
+			break
+		}
+		start++
+	}
+	if start == c.Start {
+		return c
+	}
+	out := c.Clone()
+	out.Start = start
+	return out
+}
+
+// termAt evaluates the single term t(n) of c. Like EvaluateCandidate it
+// treats a math/big panic as a failed term.
+func termAt(c *Candidate, n int64, prec uint) (t *big.Float, ok bool) {
+	defer func() {
+		if recover() != nil {
+			t, ok = nil, false
+		}
+	}()
+	nf := new(big.Float).SetPrec(prec).SetInt64(n)
+	num, ok := c.Numerator.Eval(nf, prec)
+	if !ok {
+		return nil, false
+	}
+	den, ok := c.Denominator.Eval(nf, prec)
+	if !ok || den.Sign() == 0 {
+		return nil, false
+	}
+	return new(big.Float).SetPrec(prec).Quo(num, den), true
+}
@@ -0,0 +1,69 @@
+package series
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestReindexPreservesSum(t *testing.T) {
+	for _, tc := range []struct {
+		latex string
+		k     int64
+		want  string // reindexed candidate
+	}{
+		{`\sum_{n=0}^{\infty} \frac{1}{(n+1)^2}`, 1, `Sum_{n=1}^{inf} (1) / ((n)^(2))`},
+		{`\sum_{n=1}^{\infty} \frac{(-1)^n}{n}`, -1, `Sum_{n=0}^{inf} ((-1)^((n + 1))) / ((n + 1))`},
+		{`\sum_{n=2}^{\infty} \frac{n}{2^n}`, 2, `Sum_{n=4}^{inf} ((n - 2)) / ((2)^((n - 2)))`},
+	} {
+		c := mustParse(t, tc.latex)
+		r := Reindex(c, tc.k)
+		if got := r.String(); got != tc.want {
+			t.Errorf("Reindex(%s, %d) = %s, want %s", c, tc.k, got, tc.want)
+		}
+		a := EvaluateCandidate(c, 256, testPrec)
+		b := EvaluateCandidate(r, 256, testPrec)
+		if !a.OK || !b.OK || countCorrectDigits(b.PartialSum, a.PartialSum) < 30 {
+			t.Errorf("Reindex(%s, %d) sums to %v, want %v", c, tc.k, b.PartialSum, a.PartialSum)
+		}
+	}
+}
+
+func TestSplitAbsorbLeading(t *testing.T) {
+	c := mustParse(t, `\sum_{n=0}^{\infty} \frac{1}{n!}`)
+	terms, rest, ok := SplitLeading(c, 3, testPrec)
+	if !ok || len(terms) != 3 || rest.Start != 3 {
+		t.Fatalf("SplitLeading = %v, %v, %v", terms, rest, ok)
+	}
+	for i, want := range []float64{1, 1, 0.5} {
+		if got, _ := terms[i].Float64(); got != want {
+			t.Errorf("t(%d) = %v, want %v", i, got, want)
+		}
+	}
+
+	head, wider, ok := AbsorbLeading(rest, 3, testPrec)
+	if !ok || wider.Start != 0 || head.Cmp(big.NewFloat(2.5)) != 0 {
+		t.Errorf("AbsorbLeading = %v, %v, %v; want 2.5, start 0", head, wider, ok)
+	}
+	if _, _, ok := AbsorbLeading(c, 1, testPrec); ok {
+		t.Error("AbsorbLeading below start 0 succeeded")
+	}
+	// 1/n fails at n = 0, so nothing can be split off there.
+	if _, _, ok := SplitLeading(mustParse(t, `\sum_{n=0}^{\infty} \frac{1}{n}`), 1, testPrec); ok {
+		t.Error("SplitLeading over a failing term succeeded")
+	}
+}
+
+func TestDropZeroLeading(t *testing.T) {
+	c := mustParse(t, `\sum_{n=0}^{\infty} \frac{n(n-1)}{2^n}`)
+	if got := DropZeroLeading(c).Start; got != 2 {
+		t.Errorf("DropZeroLeading start = %d, want 2", got)
+	}
+	if c.Start != 0 {
+		t.Errorf("DropZeroLeading modified its argument")
+	}
+	// A zero numerator over a zero denominator is not a zero term.
+	z := mustParse(t, `\sum_{n=0}^{\infty} \frac{n}{n}`)
+	if got := DropZeroLeading(z); got != z {
+		t.Errorf("DropZeroLeading(n/n) = %s, want unchanged", got)
+	}
+}
@@ -14,42 +14,193 @@ import (
 //	\sum_{n=0}^{\infty} EXPR
 //	\frac{A}{B} \sum_{n=0}^{\infty} \frac{NUM}{DEN}           (outer coefficient)
 //	COEFF \sum_{n=0}^{\infty} \frac{C}{D} \frac{E}{F}         (multiple fracs)
+//	\frac{\sum_{n=0}^{\infty} EXPR}{B}, -(A \sum ...) / B     (sum inside an expression)
+//	3 + \sum_{n=1}^{\infty} EXPR, \ln 2 - \sum ...              (offset, Candidate.Offset)
+//	\frac{\sum_{n=0}^{\infty} EXPR}{\sum_{n=1}^{\infty} EXPR}   (ratio, Candidate.Over)
 //
-// The summation variable can be any single letter (k, i, m, ...); it is
-// normalized to n internally.
+// The sum may sit anywhere in a larger expression that multiplies or
+// divides it by factors without n and adds terms without n (see hoistSum);
+// the factors become part of the series and the terms its offset. Its body
+// runs to the end of the enclosing group, so in
+// \frac{\sum_{n=0}^{\infty} EXPR}{B} it is EXPR alone. The summation
+// variable can be any single letter (k, i, m, ...); it is normalized to n
+// internally, outside command names (see renameIndex), and a sum over
+// another letter may not also use n. In a ratio each side is hoisted on
+// its own, and both sums are over the same letter.
 func ParseCandidateLatex(s string) (*Candidate, error) {
+	c, _, err := ParseCandidateLatexWarnings(s)
+	return c, err
+}
+
+// ParseCandidateLatexWarnings is ParseCandidateLatex, also returning the
+// warnings the parse raised (see expr.LatexParser.Warnings). Their
+// positions are in s with each run of whitespace made one space.
+func ParseCandidateLatexWarnings(s string) (*Candidate, []expr.Warning, error) {
 	// Normalize whitespace so newlines don't trip up the parser.
 	s = strings.Join(strings.Fields(s), " ")
+	c, end, warnings, err := parseCandidateWarnings(s)
+	if err != nil {
+		return nil, nil, err
+	}
+	if end < len(s) {
+		return nil, nil, fmt.Errorf("unexpected trailing input at pos %d: %q", end, s[end:])
+	}
+	return c, warnings, nil
+}
 
+// ParseCandidateLatexPrefix parses the longest prefix of s that is a
+// formula ParseCandidateLatex accepts and returns it with the rest of s,
+// for formulas embedded in larger documents. Positions in s are kept, so
+// the rest is exactly the text after the formula.
+func ParseCandidateLatexPrefix(s string) (*Candidate, string, error) {
+	// Newlines and tabs become spaces one for one, so positions hold.
+	s0 := s
+	s = strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return ' '
+		}
+		return r
+	}, s)
+	c, end, err := expr.LongestPrefix(s, parseCandidate)
+	if err != nil {
+		return nil, s0, err
+	}
+	return c, s0[end:], nil
+}
+
+// parseCandidate parses a formula at the start of s, reporting how far it
+// got.
+func parseCandidate(s string) (*Candidate, int, error) {
+	c, end, _, err := parseCandidateWarnings(s)
+	return c, end, err
+}
+
+// parseCandidateWarnings is parseCandidate, also returning the parser's
+// warnings.
+func parseCandidateWarnings(s string) (*Candidate, int, []expr.Warning, error) {
 	// Find \sum_{ and extract the variable name.
 	sumIdx := strings.Index(s, `\sum_{`)
 	if sumIdx < 0 {
-		return nil, fmt.Errorf("expected \\sum_{n=... in formula")
+		return nil, 0, nil, fmt.Errorf("expected \\sum_{n=... in formula")
 	}
 	varPos := sumIdx + len(`\sum_{`)
 	if varPos+2 > len(s) || s[varPos+1] != '=' || !unicode.IsLetter(rune(s[varPos])) {
-		return nil, fmt.Errorf("expected \\sum_{VAR=... at pos %d", sumIdx)
+		return nil, 0, nil, fmt.Errorf("expected \\sum_{VAR=... at pos %d", sumIdx)
 	}
-	varName := s[varPos]
-	if varName != 'n' {
-		// Replace the variable letter with n everywhere in the body (after \sum).
-		body := strings.ReplaceAll(s[varPos+2:], string(varName), "n")
-		s = s[:varPos] + "n=" + body
-		sumIdx = strings.Index(s, `\sum_{`)
+	if varName := s[varPos]; varName != 'n' {
+		body, bad := renameIndex(s[varPos:], varName)
+		if bad >= 0 {
+			// The parse reaches the stray n, so a prefix parse can stop short of it.
+			return nil, varPos + bad, nil, fmt.Errorf("n at pos %d in a sum over %c", varPos+bad, varName)
+		}
+		s = s[:varPos] + body
 	}
 
-	// Parse optional leading coefficient.
-	var coeffNum, coeffDen expr.ExprNode
-	if prefix := strings.TrimSpace(s[:sumIdx]); prefix != "" {
-		coeff, err := expr.ParseExprLatex(prefix)
-		if err != nil {
-			return nil, fmt.Errorf("parsing coefficient: %w", err)
+	// Each sum parses as a placeholder node in the outer expression.
+	var sums []*Candidate
+	var markers []expr.ExprNode
+	p := expr.NewLatexParser(s)
+	p.Commands = map[string]func(*expr.LatexParser) (expr.ExprNode, error){
+		`\sum_{`: func(p *expr.LatexParser) (expr.ExprNode, error) {
+			if len(sums) == 2 {
+				return nil, fmt.Errorf("more than two \\sum, at pos %d", p.Pos())
+			}
+			sum, err := parseSum(p)
+			marker := &expr.ConstNode{}
+			sums, markers = append(sums, sum), append(markers, marker)
+			return marker, err
+		},
+	}
+	outer, err := p.ParseExpr()
+	if err != nil {
+		return nil, p.Pos(), nil, err
+	}
+	p.SkipSpaces()
+	if len(sums) == 0 {
+		return nil, p.Pos(), nil, fmt.Errorf("expected \\sum_{n=... at pos %d", sumIdx)
+	}
+	if len(sums) == 1 {
+		sum, err := hoistInto(sums[0], outer, markers[0])
+		return sum, p.Pos(), p.Warnings, err
+	}
+
+	// Two sums are a ratio, one in each part of a fraction.
+	d, ok := outer.(*expr.BinaryNode)
+	if !ok || d.Op != expr.OpDiv || !containsNode(d.Left, markers[0]) || !containsNode(d.Right, markers[1]) {
+		return nil, p.Pos(), nil, fmt.Errorf("two \\sum must be the numerator and denominator of a fraction")
+	}
+	sum, err := hoistInto(sums[0], d.Left, markers[0])
+	if err != nil {
+		return nil, p.Pos(), nil, err
+	}
+	if sum.Over, err = hoistInto(sums[1], d.Right, markers[1]); err != nil {
+		return nil, p.Pos(), nil, fmt.Errorf("divisor: %w", err)
+	}
+	return sum, p.Pos(), p.Warnings, nil
+}
+
+// hoistInto moves what outer does to the sum at marker into sum (see
+// hoistSum) and returns it.
+func hoistInto(sum *Candidate, outer, marker expr.ExprNode) (*Candidate, error) {
+	coeffNum, coeffDen, offset, err := hoistSum(outer, marker)
+	if err != nil {
+		return nil, err
+	}
+	sum.Numerator = maybeMul(coeffNum, sum.Numerator)
+	sum.Denominator = maybeMul(coeffDen, sum.Denominator)
+	sum.Offset = offset
+	return sum, nil
+}
+
+// renameIndex replaces the summation variable from with n in s, the text
+// from the sum's index on. s is scanned as tokens, so command names and
+// the names of \operatorname{...} are copied intact: an index l must not
+// turn \ln into \nn, nor m \binom into \binon. A lone n already in s
+// would be confused with the index, so renameIndex returns its offset in s,
+// or -1 if there is none. Every token keeps its length, so positions in s
+// hold.
+func renameIndex(s string, from byte) (string, int) {
+	isLetter := func(i int) bool { return i < len(s) && unicode.IsLetter(rune(s[i])) }
+	var b strings.Builder
+	b.Grow(len(s))
+	for i := 0; i < len(s); {
+		switch {
+		case s[i] == '\\':
+			j := i + 1
+			for isLetter(j) {
+				j++
+			}
+			if s[i:j] == `\operatorname` && strings.HasPrefix(s[j:], "{") {
+				if k := strings.IndexByte(s[j:], '}'); k >= 0 {
+					j += k + 1
+				}
+			}
+			if j == i+1 && j < len(s) { // a control symbol, such as \, or \{
+				j++
+			}
+			b.WriteString(s[i:j])
+			i = j
+		case isLetter(i):
+			j := i
+			for isLetter(j) {
+				j++
+			}
+			if j == i+1 && s[i] == 'n' {
+				return "", i
+			}
+			b.WriteString(strings.ReplaceAll(s[i:j], string(from), "n"))
+			i = j
+		default:
+			b.WriteByte(s[i])
+			i++
 		}
-		coeffNum, coeffDen = splitFraction(coeff)
 	}
+	return b.String(), -1
+}
 
-	// Parse \sum_{n=start}^{\infty}
-	p := expr.NewLatexParser(s[sumIdx:])
+// parseSum parses \sum_{n=start}^{\infty} BODY at p, with the body split
+// into numerator and denominator.
+func parseSum(p *expr.LatexParser) (*Candidate, error) {
 	if err := p.Consume(`\sum_{n=`); err != nil {
 		return nil, err
 	}
@@ -64,28 +215,121 @@ func ParseCandidateLatex(s string) (*Candidate, error) {
 
 	// Parse the body as a full expression — handles \frac{}{}, \frac{}{}\frac{}{},
 	// implicit multiplication, infix ops, etc.
-	if p.Pos() >= p.Len() {
+	if p.Pos() >= p.Len() || p.HasPrefix("}") || p.HasPrefix(")") {
 		return nil, fmt.Errorf("expected series body after \\sum")
 	}
 	body, err := p.ParseExpr()
 	if err != nil {
 		return nil, fmt.Errorf("parsing series body: %w", err)
 	}
-	p.SkipSpaces()
-	if p.Pos() < p.Len() {
-		return nil, fmt.Errorf("unexpected trailing input at pos %d: %q", p.Pos(), p.Remaining())
-	}
 
 	// Decompose body into numerator/denominator.
 	num, den := splitFraction(body)
+	return &Candidate{Numerator: num, Denominator: den, Start: start}, nil
+}
 
-	// Fold coefficient in.
-	if coeffNum != nil {
-		num = maybeMul(coeffNum, num)
-		den = maybeMul(coeffDen, den)
+// hoistSum returns the coefficient the sum (marker) is multiplied by in
+// outer, as a numerator and denominator, and the offset added to it (nil
+// for none), so that outer = num/den · S + off. Between the root and the
+// sum there may only be products with, quotients by and negations of
+// factors without n, and terms without n added or subtracted:
+//
+//	A · S, S · A  → A
+//	S / B         → 1/B
+//	-S            → -1
+//	S + A, S - A  → 1, offset ±A
+//	A - S         → -1, offset A
+//
+// Anything else around the sum, such as n outside it or the sum in a
+// denominator, does not fit a Candidate and is an error.
+func hoistSum(outer, marker expr.ExprNode) (num, den, off expr.ExprNode, err error) {
+	one := &expr.ConstNode{Val: 1}
+	if outer == marker {
+		return one, one, nil, nil
+	}
+	factor := func(f expr.ExprNode) error {
+		if expr.ContainsVar(f) {
+			return fmt.Errorf("n outside the sum, in %s", f.LaTeX())
+		}
+		return nil
+	}
+	neg := func(x expr.ExprNode) expr.ExprNode {
+		if x == nil {
+			return nil
+		}
+		return &expr.UnaryNode{Op: expr.OpNeg, Child: x}
 	}
+	switch n := outer.(type) {
+	case *expr.UnaryNode:
+		if n.Op == expr.OpNeg && containsNode(n.Child, marker) {
+			num, den, off, err := hoistSum(n.Child, marker)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			return neg(num), den, neg(off), nil
+		}
+	case *expr.BinaryNode:
+		inLeft := containsNode(n.Left, marker)
+		sumSide, f := n.Left, n.Right
+		if !inLeft {
+			sumSide, f = n.Right, n.Left
+		}
+		if n.Op == expr.OpDiv && !inLeft {
+			return nil, nil, nil, fmt.Errorf("the sum cannot be in a denominator")
+		}
+		if n.Op > expr.OpDiv {
+			break
+		}
+		if err := factor(f); err != nil {
+			return nil, nil, nil, err
+		}
+		num, den, off, err := hoistSum(sumSide, marker)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		fNum, fDen := splitFraction(f)
+		switch n.Op {
+		case expr.OpMul:
+			if off != nil {
+				off = &expr.BinaryNode{Op: expr.OpMul, Left: f, Right: off}
+			}
+			return maybeMul(fNum, num), maybeMul(fDen, den), off, nil
+		case expr.OpDiv:
+			if off != nil {
+				off = &expr.BinaryNode{Op: expr.OpDiv, Left: off, Right: f}
+			}
+			return maybeMul(num, fDen), maybeMul(den, fNum), off, nil
+		case expr.OpSub:
+			if !inLeft {
+				// A - (c·S + off) = -c·S + (A - off)
+				if off != nil {
+					f = &expr.BinaryNode{Op: expr.OpSub, Left: f, Right: off}
+				}
+				return neg(num), den, f, nil
+			}
+			f = neg(f)
+		}
+		if off != nil {
+			f = &expr.BinaryNode{Op: expr.OpAdd, Left: off, Right: f}
+		}
+		return num, den, f, nil
+	}
+	return nil, nil, nil, fmt.Errorf("the sum can only be multiplied or divided by factors without n, or have terms without n added")
+}
 
-	return &Candidate{Numerator: num, Denominator: den, Start: start}, nil
+// containsNode reports whether target is node or one of its descendants,
+// by identity.
+func containsNode(node, target expr.ExprNode) bool {
+	if node == target {
+		return true
+	}
+	switch n := node.(type) {
+	case *expr.UnaryNode:
+		return containsNode(n.Child, target)
+	case *expr.BinaryNode:
+		return containsNode(n.Left, target) || containsNode(n.Right, target)
+	}
+	return false
 }
 
 // splitFraction recursively decomposes an expression into (numerator, denominator).
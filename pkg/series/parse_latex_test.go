@@ -1,6 +1,7 @@
 package series
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/wildfunctions/genetic_series/pkg/expr"
@@ -75,6 +76,77 @@ func TestParseCandidateLatexRoundTrip(t *testing.T) {
 	}
 }
 
+func TestParseCandidateLatexNestedSum(t *testing.T) {
+	want := map[string]string{
+		`\frac{\sum_{n=0}^{\infty} \frac{1}{n!}}{2}`:          "Sum_{n=0}^{inf} (1) / ((2 * (n)!))",
+		`\frac{3 \sum_{k=1}^{\infty} \frac{(-1)^{k}}{k}}{4}`:  "Sum_{n=1}^{inf} ((3 * (-1)^(n))) / ((4 * n))",
+		`-\sum_{n=0}^{\infty} \frac{1}{2^{n}}`:                "Sum_{n=0}^{inf} ((-1)) / ((2)^(n))",
+		`\frac{\frac{1}{2} \sum_{n=0}^{\infty} n}{3} \cdot 5`: "Sum_{n=0}^{inf} ((5 * n)) / ((2 * 3))",
+		`\frac{1}{2} \sum_{n=1}^{\infty} \frac{1}{n^2}`:       "Sum_{n=1}^{inf} (1) / ((2 * (n)^(2)))",
+	}
+	for latex, s := range want {
+		c, err := ParseCandidateLatex(latex)
+		if err != nil {
+			t.Errorf("ParseCandidateLatex(%q): %v", latex, err)
+		} else if c.String() != s {
+			t.Errorf("ParseCandidateLatex(%q) = %s, want %s", latex, c, s)
+		}
+	}
+}
+
+func TestParseCandidateLatexOffset(t *testing.T) {
+	want := map[string]string{
+		`3 + \sum_{n=1}^{\infty} \frac{1}{n^2}`:             "3 + Sum_{n=1}^{inf} (1) / ((n)^(2))",
+		`\frac{\sum_{n=0}^{\infty} \frac{1}{n!}}{2} + 1`:    "1 + Sum_{n=0}^{inf} (1) / ((2 * (n)!))",
+		`1 - \sum_{n=1}^{\infty} \frac{1}{2^{n}}`:           "1 + Sum_{n=1}^{inf} ((-1)) / ((2)^(n))",
+		`\frac{1 + \sum_{n=0}^{\infty} \frac{1}{3^{n}}}{2}`: "(1 / 2) + Sum_{n=0}^{inf} (1) / ((2 * (3)^(n)))",
+	}
+	for latex, s := range want {
+		c, err := ParseCandidateLatex(latex)
+		if err != nil {
+			t.Errorf("ParseCandidateLatex(%q): %v", latex, err)
+			continue
+		}
+		if c.String() != s {
+			t.Errorf("ParseCandidateLatex(%q) = %s, want %s", latex, c, s)
+		}
+		back, err := ParseCandidateLatex(c.LaTeX())
+		if err != nil || back.String() != c.String() {
+			t.Errorf("LaTeX round trip of %q: %s (%v), want %s", latex, back, err, c)
+		}
+	}
+}
+
+func TestParseCandidateLatexIndexRenaming(t *testing.T) {
+	// The index letter also occurs in command names, which must survive
+	// its renaming to n.
+	want := map[string]string{
+		`\sum_{i=0}^{\infty} \frac{1}{i!}`:                                                    "Sum_{n=0}^{inf} (1) / ((n)!)",
+		`\sum_{l=1}^{\infty} \frac{\ln(l)}{l^2}`:                                              "Sum_{n=1}^{inf} (ln(n)) / ((n)^(2))",
+		`\sum_{m=0}^{\infty} \frac{\binom{2m}{m}}{4^{m}}`:                                     "Sum_{n=0}^{inf} (C((2 * n), n)) / ((4)^(n))",
+		`\sum_{i=1}^{\infty} \frac{\operatorname{fib}(i)}{\pi^{i}}`:                           "Sum_{n=1}^{inf} (fib(n)) / ((pi)^(n))",
+		`\sum_{m=1}^{\infty} \frac{\frac{1}{m} + \frac{\ln(m)}{m^2}}{\binom{2m}{m}}`:          "Sum_{n=1}^{inf} (((1 / n) + (ln(n) / (n)^(2)))) / (C((2 * n), n))",
+		`\frac{\sum_{l=1}^{\infty} \frac{\frac{1}{l} - \frac{1}{l + 1}}{\ln(l + 1)}}{\ln(2)}`: "Sum_{n=1}^{inf} (((1 / n) - (1 / (n + 1)))) / ((ln(2) * ln((n + 1))))",
+	}
+	for latex, s := range want {
+		c, err := ParseCandidateLatex(latex)
+		if err != nil {
+			t.Errorf("ParseCandidateLatex(%q): %v", latex, err)
+		} else if c.String() != s {
+			t.Errorf("ParseCandidateLatex(%q) = %s, want %s", latex, c, s)
+		}
+	}
+
+	// n is not free to be the renamed index when the body already has it.
+	if _, err := ParseCandidateLatex(`\sum_{k=0}^{\infty} \frac{1}{k + n}`); err == nil || !strings.Contains(err.Error(), "sum over k") {
+		t.Errorf("sum over k with n in its body: %v", err)
+	}
+	c, rest, err := ParseCandidateLatexPrefix(`\sum_{k=1}^{\infty} \frac{1}{k^2} for n terms`)
+	if err != nil || c.String() != "Sum_{n=1}^{inf} (1) / ((n)^(2))" || rest != "for n terms" {
+		t.Errorf("prefix before a stray n: %v, %q, %v", c, rest, err)
+	}
+}
+
 func TestParseCandidateLatexErrors(t *testing.T) {
 	tests := []struct {
 		name  string
@@ -83,6 +155,12 @@ func TestParseCandidateLatexErrors(t *testing.T) {
 		{"empty", ""},
 		{"missing sum", `\frac{1}{2}`},
 		{"bad start", `\sum_{n=abc}^{\infty} n`},
+		{"sum in a denominator", `\frac{1}{\sum_{n=0}^{\infty} \frac{1}{n!}}`},
+		{"n added to the sum", `\frac{\sum_{n=0}^{\infty} \frac{1}{n!}}{2} + n`},
+		{"sum inside a function", `\sqrt{\sum_{n=1}^{\infty} \frac{6}{n^2}}`},
+		{"n outside the sum", `\frac{\sum_{n=0}^{\infty} \frac{1}{n!}}{n}`},
+		{"two sums", `\sum_{n=0}^{\infty} \frac{1}{n!} \cdot \sum_{n=0}^{\infty} \frac{1}{n!}`},
+		{"empty body", `\frac{\sum_{n=0}^{\infty}}{2}`},
 	}
 
 	for _, tt := range tests {
@@ -94,3 +172,53 @@ func TestParseCandidateLatexErrors(t *testing.T) {
 		})
 	}
 }
+
+func TestBenchmarkCorpus(t *testing.T) {
+	// Keep testdata/corpus.txt usable: every entry must parse and evaluate.
+	for _, c := range parseCorpus(t) {
+		if r := EvaluateCandidate(c, 64, testPrec); !r.OK {
+			t.Errorf("corpus candidate %s failed to evaluate", c)
+		}
+	}
+}
+
+func TestParseCandidateLatexPrefix(t *testing.T) {
+	tests := []struct {
+		input, want, rest string
+	}{
+		{"\\sum_{n=0}^{\\infty} \\frac{1}{n!} = e, as Euler\nshowed.", "Sum_{n=0}^{inf} (1) / ((n)!)", "= e, as Euler\nshowed."},
+		{`\sum_{k=1}^{\infty} \frac{1}{k^2}.`, "Sum_{n=1}^{inf} (1) / ((n)^(2))", "."},
+		{`\frac{\sum_{n=0}^{\infty} \frac{1}{n!}}{2} + n`, "Sum_{n=0}^{inf} (1) / ((2 * (n)!))", "+ n"},
+	}
+	for _, tt := range tests {
+		c, rest, err := ParseCandidateLatexPrefix(tt.input)
+		if err != nil {
+			t.Errorf("ParseCandidateLatexPrefix(%q): %v", tt.input, err)
+			continue
+		}
+		if c.String() != tt.want || rest != tt.rest {
+			t.Errorf("ParseCandidateLatexPrefix(%q) = %s, %q; want %s, %q", tt.input, c, rest, tt.want, tt.rest)
+		}
+	}
+	if _, _, err := ParseCandidateLatexPrefix(`where \frac{1}{2} is`); err == nil {
+		t.Error("expected an error for text without a sum")
+	}
+}
+
+func TestParseCandidateLatexWarnings(t *testing.T) {
+	c, warnings, err := ParseCandidateLatexWarnings(`\frac{1}{2} + \sum_{n=0}^{\infty}  \frac{n 2 + \sqrt{2}}{n!}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(warnings) != 1 || warnings[0].Kind != expr.WarnImplicitMul || warnings[0].Pos != 42 {
+		t.Errorf("parse warnings = %v, want an implicit product at pos 42", warnings)
+	}
+	sw := c.SimplifyWarnings(128)
+	if len(sw) != 1 || sw[0].Kind != expr.WarnLossyFold || !strings.HasPrefix(sw[0].Msg, "numerator: ") {
+		t.Errorf("simplify warnings = %v, want √2 rounded and the offset kept", sw)
+	}
+
+	if _, warnings, err := ParseCandidateLatexWarnings(`\sum_{n=0}^{\infty} \frac{1}{n!}`); err != nil || len(warnings) != 0 {
+		t.Errorf("plain formula: warnings %v, err %v", warnings, err)
+	}
+}
@@ -0,0 +1,178 @@
+package series
+
+import (
+	"math/big"
+
+	"github.com/wildfunctions/genetic_series/pkg/expr"
+)
+
+// poly is a polynomial in n with integer coefficients, lowest degree first.
+// The zero polynomial is the empty slice.
+type poly []*big.Int
+
+func constPoly(c int64) poly {
+	return poly{big.NewInt(c)}.trim()
+}
+
+// linearPoly returns an + b.
+func linearPoly(a, b int64) poly {
+	return poly{big.NewInt(b), big.NewInt(a)}.trim()
+}
+
+func (p poly) trim() poly {
+	for len(p) > 0 && p[len(p)-1].Sign() == 0 {
+		p = p[:len(p)-1]
+	}
+	return p
+}
+
+func (p poly) degree() int {
+	if len(p) == 0 {
+		return 0
+	}
+	return len(p) - 1
+}
+
+// coeff returns the coefficient of n^i.
+func (p poly) coeff(i int) *big.Int {
+	if i < len(p) {
+		return p[i]
+	}
+	return new(big.Int)
+}
+
+func (p poly) add(q poly, sign int) poly {
+	n := len(p)
+	if len(q) > n {
+		n = len(q)
+	}
+	r := make(poly, n)
+	for i := range r {
+		r[i] = new(big.Int).Set(p.coeff(i))
+		if sign < 0 {
+			r[i].Sub(r[i], q.coeff(i))
+		} else {
+			r[i].Add(r[i], q.coeff(i))
+		}
+	}
+	return r.trim()
+}
+
+func (p poly) mul(q poly) poly {
+	if len(p) == 0 || len(q) == 0 {
+		return nil
+	}
+	r := make(poly, len(p)+len(q)-1)
+	for i := range r {
+		r[i] = new(big.Int)
+	}
+	t := new(big.Int)
+	for i, a := range p {
+		for j, b := range q {
+			r[i+j].Add(r[i+j], t.Mul(a, b))
+		}
+	}
+	return r.trim()
+}
+
+// shift1 returns p(n+1).
+func (p poly) shift1() poly {
+	// Horner: r = (...(c_d (n+1) + c_{d-1}) (n+1) + ...) + c_0
+	np1 := poly{big.NewInt(1), big.NewInt(1)}
+	var r poly
+	for i := len(p) - 1; i >= 0; i-- {
+		r = r.mul(np1).add(poly{p[i]}, 1)
+	}
+	return r
+}
+
+func (p poly) eval(x *big.Int) *big.Int {
+	v := new(big.Int)
+	for i := len(p) - 1; i >= 0; i-- {
+		v.Mul(v, x)
+		v.Add(v, p[i])
+	}
+	return v
+}
+
+func polysF64(ps []poly) [][]float64 {
+	out := make([][]float64, len(ps))
+	for i, p := range ps {
+		out[i] = make([]float64, len(p))
+		for j, c := range p {
+			out[i][j], _ = new(big.Float).SetInt(c).Float64()
+		}
+	}
+	return out
+}
+
+func evalPolyF64(p []float64, x float64) float64 {
+	v := 0.0
+	for i := len(p) - 1; i >= 0; i-- {
+		v = v*x + p[i]
+	}
+	return v
+}
+
+// polyOf converts a tree built from n, integer constants, +, -, *, unary
+// minus, and small constant powers into a polynomial.
+func polyOf(node expr.ExprNode) (poly, bool) {
+	return polyOfD(node, 0)
+}
+
+func polyOfD(node expr.ExprNode, depth int) (poly, bool) {
+	if depth > 50 {
+		return nil, false
+	}
+	switch nd := node.(type) {
+	case *expr.VarNode:
+		return linearPoly(1, 0), true
+	case *expr.ConstNode:
+		return constPoly(nd.Val), true
+	case *expr.UnaryNode:
+		if nd.Op != expr.OpNeg {
+			return nil, false
+		}
+		p, ok := polyOfD(nd.Child, depth+1)
+		if !ok {
+			return nil, false
+		}
+		return poly(nil).add(p, -1), true
+	case *expr.BinaryNode:
+		if nd.Op == expr.OpPow {
+			k, ok := constInt(nd.Right)
+			if !ok || k < 0 || k > maxRatioDegree {
+				return nil, false
+			}
+			base, ok := polyOfD(nd.Left, depth+1)
+			if !ok || base.degree()*int(k) > maxRatioDegree {
+				return nil, false
+			}
+			r := constPoly(1)
+			for i := int64(0); i < k; i++ {
+				r = r.mul(base)
+			}
+			return r, true
+		}
+		l, ok := polyOfD(nd.Left, depth+1)
+		if !ok {
+			return nil, false
+		}
+		r, ok := polyOfD(nd.Right, depth+1)
+		if !ok {
+			return nil, false
+		}
+		switch nd.Op {
+		case expr.OpAdd:
+			return l.add(r, 1), true
+		case expr.OpSub:
+			return l.add(r, -1), true
+		case expr.OpMul:
+			if l.degree()+r.degree() > maxRatioDegree {
+				return nil, false
+			}
+			return l.mul(r), true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,85 @@
+package series
+
+import (
+	"math/bits"
+
+	"github.com/wildfunctions/genetic_series/pkg/expr"
+)
+
+const (
+	precisionMargin = 32 // bits kept past the digits a truncated sum can show
+	precisionStep   = 32 // CandidatePrecision rounds up to a multiple of this
+	minPrecision    = 64
+	maxPrecisionX   = 4 // CandidatePrecision never exceeds this multiple of the base precision
+)
+
+// CandidatePrecision is the precision, in bits, to sum maxTerms terms of c
+// at in place of the one global prec. A sum whose last term is still large
+// cannot show more digits than that term leaves, so it needs only about
+// those bits and precisionMargin more, while large terms and long constants
+// cost bits to cancellation: it takes the lesser of prec and the truncated
+// sum's digits, then adds the bit length of c's longest constant, the
+// binary exponent of its largest probed term and log2(maxTerms) for
+// rounding that accumulates over the terms. Terms are probed, at faultPrec,
+// at Start and Start+2^k-1 up to the last term summed; a candidate whose
+// probes all fail gets prec. The result is rounded up to a multiple of
+// precisionStep and kept within [minPrecision, maxPrecisionX·prec]. A
+// ratio takes the more its two series need.
+func CandidatePrecision(c *Candidate, maxTerms int64, prec uint) uint {
+	if c.Over != nil {
+		return max(CandidatePrecision(c.Series(), maxTerms, prec), CandidatePrecision(c.Over, maxTerms, prec))
+	}
+	if maxTerms <= 0 {
+		return prec
+	}
+	growth, lastExp := 0, 0
+	probed, tail := false, false // tail: the last probed term is nonzero
+	last := c.Start + maxTerms - 1
+	for n, step := c.Start, int64(1); ; step *= 2 {
+		t, ok := termAt(c, n, faultPrec)
+		if ok && t.Sign() != 0 {
+			exp := t.MantExp(nil)
+			growth = max(growth, exp)
+			lastExp, probed, tail = exp, true, true
+		} else if ok {
+			probed, tail = true, false // an exact zero tells nothing of the tail
+		}
+		if n == last {
+			break
+		}
+		n = min(c.Start+step*2-1, last)
+	}
+	if !probed {
+		return prec
+	}
+
+	guard := uint(bits.Len64(uint64(maxTerms)))
+	need := prec
+	if tail {
+		// The tail after the last term is about maxTerms times it, at worst
+		// (algebraic decay); its bits are all the digits the sum can show.
+		if shown := -(lastExp + int(guard)); shown+precisionMargin < int(prec) {
+			need = uint(max(shown, 0) + precisionMargin)
+		}
+	}
+	need += constBits(c.Numerator) + constBits(c.Denominator) + uint(growth) + guard
+	if c.Offset != nil {
+		need += constBits(c.Offset)
+	}
+
+	need = (need + precisionStep - 1) / precisionStep * precisionStep
+	return min(max(need, minPrecision), maxPrecisionX*prec)
+}
+
+// constBits is the bit length of the largest constant in node's magnitude.
+func constBits(node expr.ExprNode) uint {
+	var b int
+	for _, i := range expr.ConstIndices(node) {
+		v := expr.NodeAt(node, i).(*expr.ConstNode).Val
+		if v < 0 {
+			v = -v
+		}
+		b = max(b, bits.Len64(uint64(v)))
+	}
+	return uint(b)
+}
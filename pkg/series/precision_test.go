@@ -0,0 +1,36 @@
+package series
+
+import "testing"
+
+func TestCandidatePrecision(t *testing.T) {
+	const terms, prec = 1024, 512
+	for _, tc := range []struct {
+		latex    string
+		min, max uint
+	}{
+		// The tail of 1/n² after 1024 terms is ~1e-3: a few digits to show.
+		{`\sum_{n=1}^{\infty} \frac{1}{n^{2}}`, minPrecision, 64},
+		// 1/2^n converges past 512 bits.
+		{`\sum_{n=0}^{\infty} \frac{1}{2^{n}}`, prec, prec + 64},
+		// Ten-digit constants cost their 30-odd bits each.
+		{`\sum_{n=0}^{\infty} \frac{1234567891}{1234567890 n!}`, prec + 60, prec + 96},
+		// 40^n/n! peaks near 2^53 before it converges.
+		{`\sum_{n=0}^{\infty} \frac{40^{n}}{n!}`, prec + 53, prec + 96},
+	} {
+		c := mustParse(t, tc.latex)
+		if got := CandidatePrecision(c, terms, prec); got < tc.min || got > tc.max || got%precisionStep != 0 {
+			t.Errorf("%s: precision %d, want a multiple of %d in [%d, %d]", tc.latex, got, precisionStep, tc.min, tc.max)
+		}
+	}
+
+	// Lowered precision still gives the sum to more digits than it shows.
+	c := mustParse(t, `\sum_{n=1}^{\infty} \frac{1}{n^{2}}`)
+	low := EvaluateCandidate(c, terms, CandidatePrecision(c, terms, prec))
+	high := EvaluateCandidate(c, terms, prec)
+	if !low.OK || !high.OK {
+		t.Fatalf("evaluation failed: %+v, %+v", low, high)
+	}
+	if d := countCorrectDigits(low.PartialSum, high.PartialSum); d < 15 {
+		t.Errorf("lowered-precision sum agrees with the %d-bit one to %.1f digits, want >= 15", prec, d)
+	}
+}
@@ -0,0 +1,50 @@
+package series
+
+import (
+	"runtime/debug"
+	"time"
+)
+
+// Provenance identifies the run that produced a result, so discoveries can
+// be traced back to the exact settings and build even months later.
+type Provenance struct {
+	RunID      string    `json:"run_id"`
+	ConfigHash string    `json:"config_hash"` // hash of the search settings, excluding seed and output options
+	Version    string    `json:"version"`     // BuildVersion() of the binary
+	Seed       int64     `json:"seed"`
+	Timestamp  time.Time `json:"timestamp"` // run start, UTC
+}
+
+// BuildVersion returns the module version the binary was built at, as go
+// install module@version records it. A build from a checkout has none and
+// gets "dev" followed by the VCS revision (with "-dirty" if it had local
+// edits); one without build info is just "dev".
+func BuildVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "dev"
+	}
+	if v := info.Main.Version; v != "" && v != "(devel)" {
+		return v
+	}
+	var rev string
+	var dirty bool
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			rev = s.Value
+		case "vcs.modified":
+			dirty = s.Value == "true"
+		}
+	}
+	if rev == "" {
+		return "dev"
+	}
+	if len(rev) > 12 {
+		rev = rev[:12]
+	}
+	if dirty {
+		rev += "-dirty"
+	}
+	return "dev+" + rev
+}
@@ -0,0 +1,84 @@
+package series
+
+import (
+	"math"
+	"math/big"
+
+	"github.com/wildfunctions/genetic_series/pkg/numeric"
+)
+
+// ratioStop is why the term-by-term traces (Explain, DigitCurve) of a
+// ratio are empty.
+const ratioStop = "a ratio of two series has no single sequence of terms"
+
+// evaluateRatio evaluates a ratio (c.Over set) with ev: the sum of
+// c.Series() divided by the sum of c.Over, each evaluated under opts. It
+// fails if either side does or the divisor sums to zero. The quotient has
+// as many terms as the shorter side and converges if both do, at the
+// slower rate.
+func evaluateRatio(ev Evaluator, c *Candidate, opts EvalOptions) EvalResult {
+	top := ev.Evaluate(c.Series(), opts)
+	if !top.OK {
+		return top
+	}
+	bottom := ev.Evaluate(c.Over, opts)
+	if !bottom.OK {
+		return bottom
+	}
+	q, ok := quoSums(top.PartialSum, bottom.PartialSum)
+	if !ok {
+		return EvalResult{OK: false}
+	}
+	return EvalResult{
+		PartialSum:      q,
+		TermsComputed:   min(top.TermsComputed, bottom.TermsComputed),
+		Converged:       top.Converged && bottom.Converged,
+		ConvergenceRate: max(top.ConvergenceRate, bottom.ConvergenceRate),
+		OK:              true,
+	}
+}
+
+// evaluateRatioF64 is evaluateRatio for EvaluateCandidateF64.
+func evaluateRatioF64(c *Candidate, maxTerms int64) EvalResultF64 {
+	top := EvaluateCandidateF64(c.Series(), maxTerms)
+	if !top.OK {
+		return top
+	}
+	bottom := EvaluateCandidateF64(c.Over, maxTerms)
+	if !bottom.OK || bottom.PartialSum == 0 {
+		return EvalResultF64{OK: false}
+	}
+	q := top.PartialSum / bottom.PartialSum
+	if math.IsInf(q, 0) || math.IsNaN(q) {
+		return EvalResultF64{OK: false}
+	}
+	return EvalResultF64{
+		PartialSum:    q,
+		TermsComputed: min(top.TermsComputed, bottom.TermsComputed),
+		Converged:     top.Converged && bottom.Converged,
+		OK:            true,
+	}
+}
+
+// partialSumRatio is PartialSumNum for a ratio.
+func partialSumRatio[T any](c *Candidate, b numeric.Backend[T], terms int64, prec uint) (*big.Float, int64, bool) {
+	top, n, ok := PartialSumNum(c.Series(), b, terms, prec)
+	if !ok {
+		return nil, 0, false
+	}
+	bottom, m, ok := PartialSumNum(c.Over, b, terms, prec)
+	if !ok {
+		return nil, 0, false
+	}
+	q, ok := quoSums(top, bottom)
+	return q, min(n, m), ok
+}
+
+// quoSums divides the sum top by the sum bottom at top's precision,
+// failing if bottom is zero.
+func quoSums(top, bottom *big.Float) (*big.Float, bool) {
+	if bottom.Sign() == 0 {
+		return nil, false
+	}
+	return new(big.Float).SetPrec(top.Prec()).Quo(top, bottom), true
+}
@@ -0,0 +1,70 @@
+package series
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/wildfunctions/genetic_series/pkg/constants"
+)
+
+// piRatio is π/2 as a ratio of two series, π = Σ 2^(n+1)(n!)²/(2n+1)!
+// over Σ 2^-n = 2, neither of which is π/2 alone.
+const piRatio = `\frac{\sum_{n=0}^{\infty} \frac{2^{n + 1} (n!)^{2}}{(2 n + 1)!}}{\sum_{n=0}^{\infty} \frac{1}{2^{n}}}`
+
+func TestRatio(t *testing.T) {
+	c := mustParse(t, piRatio)
+	if c.Over == nil {
+		t.Fatalf("%s parsed without a divisor: %s", piRatio, c)
+	}
+	back := mustParse(t, c.LaTeX())
+	if back.String() != c.String() {
+		t.Errorf("LaTeX round trip: %s, want %s", back, c)
+	}
+	if CanonicalKey(c) == CanonicalKey(c.Series()) || c.Series().Over != nil || c.Over == nil {
+		t.Errorf("ratio and dividend share key %s", CanonicalKey(c))
+	}
+
+	tg, err := constants.ParseTarget("pi/2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := EvaluateCandidate(c, 256, testPrec)
+	if !res.OK || !res.Converged || countCorrectDigits(res.PartialSum, tg.At(testPrec)) < 70 {
+		t.Errorf("big: %+v, want π/2", res)
+	}
+	// float64 stops about 20 terms in, where the trees outgrow it.
+	if r := EvaluateCandidateF64(c, 64); !r.OK || math.Abs(r.PartialSum-math.Pi/2) > 1e-5 {
+		t.Errorf("f64: %+v, want π/2", r)
+	}
+	if rungs, ok := VerifyLadder(c, 256, testPrec, tg); !ok || rungs[0].Digits < MaxDigits {
+		t.Errorf("ladder: %+v, %v", rungs, ok)
+	}
+	if sum, _, ok := SumBinarySplit(c, testPrec); !ok || countCorrectDigits(sum, tg.At(testPrec)) < 150 {
+		t.Errorf("binary splitting: %v, %v", sum, ok)
+	}
+	if tr := Explain(c, 16, testPrec, nil); len(tr.Steps) != 0 || !tr.Result.OK {
+		t.Errorf("explained %d terms of a ratio, result %+v", len(tr.Steps), tr.Result)
+	}
+
+	// A divisor that sums to zero fails the ratio.
+	zero := mustParse(t, `\frac{\sum_{n=1}^{\infty} \frac{1}{n^{2}}}{\sum_{n=1}^{\infty} \frac{0}{n}}`)
+	if res := EvaluateCandidate(zero, 64, testPrec); res.OK {
+		t.Errorf("zero divisor evaluated to %v", res.PartialSum)
+	}
+	if r := EvaluateCandidateF64(zero, 64); r.OK {
+		t.Errorf("zero divisor evaluated to %v in float64", r.PartialSum)
+	}
+
+	for _, bad := range []string{
+		`\sum_{n=1}^{\infty} \frac{1}{n^{2}} + \sum_{n=1}^{\infty} \frac{1}{n^{3}}`,
+		`\frac{1}{\sum_{n=1}^{\infty} \frac{1}{n^{2}}} \sum_{n=1}^{\infty} \frac{1}{n^{3}}`,
+		`\frac{\sum_{n=1}^{\infty} \frac{1}{n}}{\sum_{n=1}^{\infty} \frac{1}{n} + \sum_{n=1}^{\infty} \frac{1}{n}}`,
+	} {
+		if c, err := ParseCandidateLatex(bad); err == nil {
+			t.Errorf("%s parsed as %s", bad, c)
+		} else if !strings.Contains(err.Error(), "sum") {
+			t.Errorf("%s: %v", bad, err)
+		}
+	}
+}
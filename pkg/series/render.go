@@ -0,0 +1,44 @@
+package series
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/wildfunctions/genetic_series/pkg/expr"
+)
+
+// Typst returns the candidate in Typst math syntax (see expr.Typst), for
+// use between $ delimiters.
+func (c *Candidate) Typst() string {
+	s := fmt.Sprintf("sum_(n=%d)^infinity (%s)/(%s)", c.Start, expr.Typst(c.Numerator), expr.Typst(c.Denominator))
+	if c.Offset != nil {
+		s = expr.Typst(c.Offset) + " + " + s
+	}
+	if c.Over != nil {
+		s = "(" + s + ")/(" + c.Over.Typst() + ")"
+	}
+	return s
+}
+
+// katexEscapes are the rewrites that keep LaTeX math intact through a
+// Markdown renderer with KaTeX: | would split a table cell, < and > could
+// open HTML, and $ would end the math early. KaTeX reads the replacements
+// as the same symbols.
+var katexEscapes = strings.NewReplacer(
+	"|", `\vert `,
+	"<", `\lt `,
+	">", `\gt `,
+	"$", `\$`,
+)
+
+// MarkdownMath wraps LaTeX math, such as Candidate.LaTeX's, for Markdown
+// rendered with KaTeX (GitHub, markdown-it, Obsidian, Hugo): escaped as
+// katexEscapes says and on one line, inline between $ delimiters with no
+// space inside them, or as a $$ display block on lines of its own.
+func MarkdownMath(latex string, display bool) string {
+	s := katexEscapes.Replace(strings.Join(strings.Fields(latex), " "))
+	if display {
+		return "$$\n" + s + "\n$$"
+	}
+	return "$" + s + "$"
+}
@@ -0,0 +1,40 @@
+package series
+
+import (
+	"testing"
+
+	"github.com/wildfunctions/genetic_series/pkg/expr"
+)
+
+func TestCandidateTypst(t *testing.T) {
+	c := &Candidate{
+		Numerator:   &expr.ConstNode{Val: 1},
+		Denominator: &expr.UnaryNode{Op: expr.OpFactorial, Child: &expr.VarNode{}},
+		Start:       1,
+	}
+	if got, want := c.Typst(), "sum_(n=1)^infinity (1)/(n!)"; got != want {
+		t.Errorf("Typst() = %q, want %q", got, want)
+	}
+	c.Offset = &expr.ConstNode{Val: 1}
+	if got, want := c.Typst(), "1 + sum_(n=1)^infinity (1)/(n!)"; got != want {
+		t.Errorf("Typst() with offset = %q, want %q", got, want)
+	}
+}
+
+func TestMarkdownMath(t *testing.T) {
+	tests := []struct {
+		latex   string
+		display bool
+		want    string
+	}{
+		{`\frac{1}{n!}`, false, `$\frac{1}{n!}$`},
+		{` \left|n\right| `, false, `$\left\vert n\right\vert $`},
+		{"a < b\n> c", true, "$$\na \\lt  b \\gt  c\n$$"},
+		{`a$b`, false, `$a\$b$`},
+	}
+	for _, tt := range tests {
+		if got := MarkdownMath(tt.latex, tt.display); got != tt.want {
+			t.Errorf("MarkdownMath(%q, %v) = %q, want %q", tt.latex, tt.display, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package series
+
+import "github.com/wildfunctions/genetic_series/pkg/expr"
+
+// faultPrec is the precision terms are probed for faults at: enough to
+// tell an integer divisor or factorial argument from its neighbours.
+const faultPrec = 64
+
+// DomainFault is a term of a candidate that is undefined because of a
+// division by zero or a factorial of a negative integer (see expr.Fault).
+// A denominator that is zero itself is a zero divisor in the denominator
+// tree (see expr.ZeroDivisorFault).
+type DomainFault struct {
+	expr.Fault
+	N           int64 // the term's index
+	Denominator bool  // the fault is in the denominator tree, not the numerator
+}
+
+// FindDomainFault returns the first of c's first terms terms that is
+// undefined because of a domain error, or false if none is. Terms that
+// fail for other reasons are passed over.
+func FindDomainFault(c *Candidate, terms int64) (DomainFault, bool) {
+	for n := c.Start; n < c.Start+terms; n++ {
+		if f, ok := expr.FindFault(c.Numerator, n, faultPrec); ok {
+			return DomainFault{Fault: f, N: n}, true
+		}
+		if f, ok := expr.FindFault(c.Denominator, n, faultPrec); ok {
+			return DomainFault{Fault: f, N: n, Denominator: true}, true
+		}
+		if f, ok := expr.ZeroDivisorFault(c.Denominator, n, faultPrec); ok {
+			return DomainFault{Fault: f, N: n, Denominator: true}, true
+		}
+	}
+	return DomainFault{}, false
+}
+
+// maxRepairShift bounds how far a shift repair moves the start past the
+// faulty term.
+const maxRepairShift = 8
+
+// RepairKind is a transformation that removes a DomainFault.
+type RepairKind int
+
+const (
+	RepairShift  RepairKind = iota // start the sum after the faulty term
+	RepairOffset                   // add to the faulty subtree what makes it 1 (a divisor) or 0 (a factorial argument)
+	RepairAbs                      // take the absolute value of a negative factorial argument
+)
+
+var repairNames = []string{"shift", "offset", "abs"}
+
+func (k RepairKind) String() string {
+	if k < 0 || int(k) >= len(repairNames) {
+		return "repair(?)"
+	}
+	return repairNames[k]
+}
+
+// Repairs returns the repairs that apply to f: all three for a negative
+// factorial argument, shift and offset for a zero divisor.
+func Repairs(f DomainFault) []RepairKind {
+	if f.Kind == expr.FaultNegativeArg {
+		return []RepairKind{RepairShift, RepairOffset, RepairAbs}
+	}
+	return []RepairKind{RepairShift, RepairOffset}
+}
+
+// Repair returns c with repair kind applied to the fault f found in it.
+// Shifting starts the sum at the first term after f.N without a fault,
+// looking up to maxRepairShift terms on, so dropping f.N and the faulty
+// terms that follow it (a factorial of n-3 from 0 to 2); the other repairs
+// change the faulty subtree, and with it possibly every term. An offset
+// divisor n±j becomes n+1-Start, so that it is positive for every term
+// rather than zero one term further on. c is not modified.
+func Repair(c *Candidate, f DomainFault, kind RepairKind) *Candidate {
+	out := c.Clone()
+	if kind == RepairShift {
+		out.Start = f.N + 1
+		for out.Start < f.N+maxRepairShift {
+			if _, faulty := FindDomainFault(out, 1); !faulty {
+				break
+			}
+			out.Start++
+		}
+		return out
+	}
+	tree := &out.Numerator
+	if f.Denominator {
+		tree = &out.Denominator
+	}
+	sub := expr.NodeAt(*tree, f.Index)
+	var repl expr.ExprNode
+	switch {
+	case kind == RepairAbs:
+		repl = &expr.UnaryNode{Op: expr.OpAbs, Child: sub}
+	case f.Kind == expr.FaultNegativeArg:
+		repl = addConstant(sub, -f.Value)
+	default:
+		if _, ok := indexOffset(sub); ok {
+			repl = offsetNode(1 - c.Start)
+		} else {
+			repl = addConstant(sub, 1)
+		}
+	}
+	*tree = expr.ReplaceAt(*tree, f.Index, repl)
+	return out
+}
+
+// indexOffset reports whether node is n or n±j, and j.
+func indexOffset(node expr.ExprNode) (int64, bool) {
+	if _, ok := node.(*expr.VarNode); ok {
+		return 0, true
+	}
+	return offsetOf(node)
+}
+
+// addConstant returns node + k, folding k into node if it is n or n±j.
+func addConstant(node expr.ExprNode, k int64) expr.ExprNode {
+	if j, ok := indexOffset(node); ok {
+		return offsetNode(j + k)
+	}
+	return &expr.BinaryNode{Op: expr.OpAdd, Left: node, Right: &expr.ConstNode{Val: k}}
+}
@@ -0,0 +1,50 @@
+package series
+
+import (
+	"testing"
+
+	"github.com/wildfunctions/genetic_series/pkg/expr"
+)
+
+func TestFindDomainFaultAndRepair(t *testing.T) {
+	for _, tc := range []struct {
+		latex       string
+		kind        expr.FaultKind
+		n           int64
+		denominator bool
+		repaired    map[RepairKind]string // String() of the repaired candidate
+	}{
+		{`\sum_{n=0}^{\infty} \frac{1}{n}`, expr.FaultZeroDivisor, 0, true, map[RepairKind]string{
+			RepairShift:  "Sum_{n=1}^{inf} (1) / (n)",
+			RepairOffset: "Sum_{n=0}^{inf} (1) / ((n + 1))",
+		}},
+		{`\sum_{n=0}^{\infty} \frac{1}{(n - 3)!}`, expr.FaultNegativeArg, 0, true, map[RepairKind]string{
+			RepairShift:  "Sum_{n=3}^{inf} (1) / (((n - 3))!)",
+			RepairOffset: "Sum_{n=0}^{inf} (1) / ((n)!)",
+			RepairAbs:    "Sum_{n=0}^{inf} (1) / ((abs((n - 3)))!)",
+		}},
+		{`\sum_{n=1}^{\infty} \frac{n + \frac{1}{n - 2}}{2^{n}}`, expr.FaultZeroDivisor, 2, false, nil},
+	} {
+		c := mustParse(t, tc.latex)
+		f, ok := FindDomainFault(c, 16)
+		if !ok || f.Kind != tc.kind || f.N != tc.n || f.Denominator != tc.denominator {
+			t.Errorf("%s: fault %+v, %v; want %v at n = %d", tc.latex, f, ok, tc.kind, tc.n)
+			continue
+		}
+		for kind, want := range tc.repaired {
+			if got := Repair(c, f, kind).String(); got != want {
+				t.Errorf("%s: %v repair = %s, want %s", tc.latex, kind, got, want)
+			}
+		}
+	}
+
+	// Failures that are not domain faults, and series without any.
+	for _, latex := range []string{
+		`\sum_{n=0}^{\infty} \frac{1}{n!}`,
+		`\sum_{n=0}^{\infty} \frac{\ln(n)}{n + 1}`,
+	} {
+		if f, ok := FindDomainFault(mustParse(t, latex), 16); ok {
+			t.Errorf("%s: unexpected fault %+v", latex, f)
+		}
+	}
+}
@@ -0,0 +1,230 @@
+package series
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/wildfunctions/genetic_series/pkg/expr"
+)
+
+// ResumableSum is a long, high-precision partial sum (say 10^7 terms at
+// 100k digits) that can be interrupted and picked up later. Its fields are
+// its checkpoint: Save writes them to disk and LoadResumableSum reads them
+// back, and Run continues from Next.
+//
+// Terms are evaluated with BlockEvaluator, exactly as EvaluateCandidate
+// does but without the timeout or convergence tracking, and the sum
+// likewise ends at the first failing term. Order picks how they are
+// added; it is part of the checkpoint, since resuming in another order
+// would change the low bits of the sum, but Workers is not.
+//
+// Checkpoints outlive releases, and Candidate.String can print a series
+// differently in a later one, so from CheckpointVersion 2 the series is
+// also stored as its genome, and Formula is reprinted from that on load.
+type ResumableSum struct {
+	Version   int      `json:"version,omitempty"` // checkpoint format; absent in version 1
+	Formula   string   `json:"formula"`           // Candidate.String() of the series being summed
+	Genome    string   `json:"genome,omitempty"`  // EncodeCandidate of it in base64; none for a ratio or before version 2
+	Precision uint     `json:"precision"`
+	Next      int64    `json:"next"`             // next n to add
+	Terms     int64    `json:"terms"`            // terms added so far
+	Sum       string   `json:"sum"`              // exact partial sum, big.Float 'p' format
+	Failed    bool     `json:"failed,omitempty"` // a term failed to evaluate; the sum can't go further
+	Order     SumOrder `json:"order,omitempty"`
+	Workers   int      `json:"-"` // goroutines for SumChunked; <= 0 means one per CPU
+}
+
+// CheckpointVersion is the format Save writes. LoadResumableSum reads it
+// and every older one:
+//
+//	1  the series as Formula only
+//	2  Version, and the series as Genome too
+const CheckpointVersion = 2
+
+// NewResumableSum starts a sum of c at precision prec, with no terms added
+// yet: its value is c's offset. An undefined offset marks it Failed, as
+// does a ratio, which is not one sum to add terms to.
+func NewResumableSum(c *Candidate, prec uint) *ResumableSum {
+	sum, ok := c.OffsetValue(prec)
+	if !ok {
+		sum = new(big.Float).SetPrec(prec)
+	}
+	return &ResumableSum{
+		Version:   CheckpointVersion,
+		Formula:   c.String(),
+		Genome:    checkpointGenome(c),
+		Precision: prec,
+		Next:      c.Start,
+		Sum:       sum.Text('p', 0),
+		Failed:    !ok || c.Over != nil,
+	}
+}
+
+// LoadResumableSum reads a checkpoint written by Save, in this release or
+// an older one, and brings it up to CheckpointVersion (see migrate).
+func LoadResumableSum(path string) (*ResumableSum, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var r ResumableSum
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if err := r.migrate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if _, err := r.Value(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &r, nil
+}
+
+// migrate upgrades a checkpoint just read. A version 1 checkpoint has only
+// its Formula, which must then print as the series does now; Run adds the
+// genome. Otherwise the genome is decoded, its bytecode in whatever version
+// wrote it, and Formula and Genome rewritten as this release writes them.
+func (r *ResumableSum) migrate() error {
+	if r.Version > CheckpointVersion {
+		return fmt.Errorf("checkpoint version %d is newer than this release reads (%d)", r.Version, CheckpointVersion)
+	}
+	if r.Genome != "" {
+		b, err := base64.StdEncoding.DecodeString(r.Genome)
+		if err != nil {
+			return fmt.Errorf("checkpoint genome: %w", err)
+		}
+		c, err := Genome(b).Decode()
+		if err != nil {
+			return fmt.Errorf("checkpoint genome: %w", err)
+		}
+		r.Formula, r.Genome = c.String(), checkpointGenome(c)
+	}
+	r.Version = CheckpointVersion
+	return nil
+}
+
+// checkpointGenome is the Genome field for c: EncodeCandidate in base64,
+// or empty for a ratio, which a genome cannot hold.
+func checkpointGenome(c *Candidate) string {
+	if c.Over != nil {
+		return ""
+	}
+	return base64.StdEncoding.EncodeToString(EncodeCandidate(c))
+}
+
+// Save writes the checkpoint to path atomically: a crash mid-write leaves
+// the previous checkpoint intact.
+func (r *ResumableSum) Save(path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Value returns the partial sum so far.
+func (r *ResumableSum) Value() (*big.Float, error) {
+	v, _, err := big.ParseFloat(r.Sum, 0, r.Precision, big.ToNearestEven)
+	if err != nil {
+		return nil, fmt.Errorf("bad partial sum %q: %w", r.Sum, err)
+	}
+	return v, nil
+}
+
+// Run adds terms of c until n reaches c.Start+maxTerms, a term fails, or
+// stop is closed. Every interval (and once more when it returns) it updates
+// the checkpoint fields and calls save, if non-nil. c must be the series the
+// sum was started with. Run may be called again to extend the sum.
+func (r *ResumableSum) Run(c *Candidate, maxTerms int64, interval time.Duration, save func(*ResumableSum) error, stop <-chan struct{}) error {
+	if s := c.String(); s != r.Formula {
+		return fmt.Errorf("checkpoint is for %s, not %s", r.Formula, s)
+	}
+	if r.Genome == "" {
+		r.Genome = checkpointGenome(c)
+	}
+	sum, err := r.Value()
+	if err != nil {
+		return err
+	}
+	prec := r.Precision
+	numEval := expr.NewBlockEvaluator(c.Numerator, prec)
+	denEval := expr.NewBlockEvaluator(c.Denominator, prec)
+	nums := make([]*big.Float, maxEvalBlock)
+	dens := make([]*big.Float, maxEvalBlock)
+	term := new(big.Float).SetPrec(prec)
+
+	checkpoint := func() error {
+		r.Sum = sum.Text('p', 0)
+		if save == nil {
+			return nil
+		}
+		return save(r)
+	}
+
+	end := c.Start + maxTerms
+	lastSave := time.Now()
+	for r.Next < end && !r.Failed {
+		select {
+		case <-stop:
+			return checkpoint()
+		default:
+		}
+
+		if r.Order == SumChunked {
+			size := min(chunkRound*chunkTerms, end-r.Next)
+			part, k := sumChunks(c, r.Next, size, prec, r.Workers)
+			sum.Add(sum, part)
+			r.Failed = k < size
+			r.Next += k
+			r.Terms += k
+		} else {
+			size := min(int64(maxEvalBlock), end-r.Next)
+			k := numEval.EvalBlock(r.Next, nums[:size])
+			if k > 0 {
+				k = min(k, denEval.EvalBlock(r.Next, dens[:k]))
+			}
+			if int64(k) < size {
+				r.Failed = true
+			}
+			for j := 0; j < k; j++ {
+				if dens[j].Sign() == 0 {
+					r.Failed = true
+					k = j
+					break
+				}
+				term.Quo(nums[j], dens[j])
+				sum.Add(sum, term)
+			}
+			releaseBlock(nums[:size])
+			releaseBlock(dens[:size])
+			r.Next += int64(k)
+			r.Terms += int64(k)
+		}
+
+		if time.Since(lastSave) >= interval {
+			if err := checkpoint(); err != nil {
+				return err
+			}
+			lastSave = time.Now()
+		}
+	}
+	return checkpoint()
+}
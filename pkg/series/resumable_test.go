@@ -0,0 +1,134 @@
+package series
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/wildfunctions/genetic_series/pkg/expr"
+)
+
+func TestResumableSumResumesExactly(t *testing.T) {
+	c := mustParse(t, `\sum_{n=0}^{\infty} \frac{(-1)^n}{(2n+1) 3^n}`)
+	const terms = 1000
+	const prec = 2048
+
+	whole := NewResumableSum(c, prec)
+	if err := whole.Run(c, terms, 0, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// Stop at the first checkpoint, reload it from disk, and finish.
+	path := filepath.Join(t.TempDir(), "sum.ckpt")
+	stop := make(chan struct{})
+	part := NewResumableSum(c, prec)
+	save := func(r *ResumableSum) error {
+		if r.Terms < terms {
+			select {
+			case <-stop:
+			default:
+				close(stop)
+			}
+		}
+		return r.Save(path)
+	}
+	if err := part.Run(c, terms, 0, save, stop); err != nil {
+		t.Fatal(err)
+	}
+	if part.Terms == 0 || part.Terms >= terms {
+		t.Fatalf("stopped after %d terms, want a partial run", part.Terms)
+	}
+	resumed, err := LoadResumableSum(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *resumed != *part {
+		t.Fatalf("reloaded checkpoint %+v, saved %+v", resumed, part)
+	}
+	if err := resumed.Run(c, terms, 0, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if *resumed != *whole {
+		t.Errorf("resumed sum %+v, uninterrupted %+v", resumed, whole)
+	}
+	t.Logf("stopped at %d terms, resumed to %d", part.Terms, resumed.Terms)
+}
+
+func TestResumableSumRejectsOtherFormula(t *testing.T) {
+	r := NewResumableSum(mustParse(t, `\sum_{n=0}^{\infty} \frac{1}{n!}`), 256)
+	if err := r.Run(mustParse(t, `\sum_{n=1}^{\infty} \frac{1}{n^2}`), 10, 0, nil, nil); err == nil {
+		t.Error("Run accepted a checkpoint for a different series")
+	}
+}
+
+func TestResumableSumStopsAtFailedTerm(t *testing.T) {
+	// 1/(n-3) fails at n = 3.
+	c := mustParse(t, `\sum_{n=0}^{\infty} \frac{1}{n-3}`)
+	r := NewResumableSum(c, 256)
+	if err := r.Run(c, 10, 0, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !r.Failed || r.Terms != 3 || r.Next != 3 {
+		t.Errorf("got %+v, want failure after 3 terms", r)
+	}
+}
+
+func TestResumableSumLoadsOlderCheckpoints(t *testing.T) {
+	c := mustParse(t, `\sum_{n=0}^{\infty} \frac{1}{n!}`)
+	whole := NewResumableSum(c, 256)
+	if err := whole.Run(c, 40, 0, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	half := NewResumableSum(c, 256)
+	if err := half.Run(c, 20, 0, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	load := func(name, json string) (*ResumableSum, error) {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(json), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return LoadResumableSum(path)
+	}
+	resume := func(name string, r *ResumableSum) {
+		t.Helper()
+		if err := r.Run(c, 40, 0, nil, nil); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if *r != *whole {
+			t.Errorf("%s resumed to %+v, uninterrupted %+v", name, r, whole)
+		}
+	}
+
+	// Version 1: no version and no genome; Run adds the genome.
+	v1, err := load("v1.ckpt", `{"formula": "`+half.Formula+`", "precision": 256, "next": 20, "terms": 20, "sum": "`+half.Sum+`"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resume("version 1", v1)
+
+	// Version 2 written by a release that printed the series otherwise,
+	// with its genome in bytecode version 1: Formula is reprinted from the
+	// genome.
+	g := EncodeCandidate(c)
+	g[1] = 1 // bytecode version of the numerator, the start taking one byte
+	g[1+len(expr.Encode(c.Numerator))] = 1
+	old := `{"version": 2, "formula": "Sum 1/n! as it once printed", "genome": "` + base64.StdEncoding.EncodeToString(g) +
+		`", "precision": 256, "next": 20, "terms": 20, "sum": "` + half.Sum + `"}`
+	v2, err := load("v2.ckpt", old)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v2.Formula != c.String() {
+		t.Errorf("formula %q not reprinted as %q", v2.Formula, c.String())
+	}
+	resume("version 2", v2)
+
+	if _, err := load("v3.ckpt", strings.Replace(old, `"version": 2`, `"version": 3`, 1)); err == nil {
+		t.Error("loaded a checkpoint from a newer release")
+	}
+}
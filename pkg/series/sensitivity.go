@@ -0,0 +1,116 @@
+package series
+
+import (
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+
+	"github.com/wildfunctions/genetic_series/pkg/expr"
+)
+
+// loadBearingDrop is the digit loss under a ±1 change above which a
+// constant counts as load-bearing.
+const loadBearingDrop = 1.0
+
+// Perturbation is the outcome of evaluating a candidate with one constant
+// shifted by Delta.
+type Perturbation struct {
+	Delta  int64   `json:"delta"`
+	Digits float64 `json:"digits"`          // correct digits (0 if evaluation failed)
+	Error  string  `json:"error,omitempty"` // |partial sum - target|, empty if evaluation failed
+}
+
+// ConstSensitivity describes how the error responds to changing one
+// integer constant of a candidate.
+type ConstSensitivity struct {
+	Tree          string         `json:"tree"`  // "numerator" or "denominator"
+	Index         int            `json:"index"` // preorder index in that tree (see expr.NodeAt)
+	Value         int64          `json:"value"`
+	Perturbations []Perturbation `json:"perturbations"`
+	// Drop is the baseline digits minus the best of the ±1 perturbations:
+	// how much accuracy the constant's exact value buys.
+	Drop        float64 `json:"drop"`
+	LoadBearing bool    `json:"load_bearing"`
+}
+
+// SensitivityReport is the result of Sensitivity.
+type SensitivityReport struct {
+	Candidate string             `json:"candidate"`
+	Digits    float64            `json:"digits"` // baseline correct digits
+	Constants []ConstSensitivity `json:"constants"`
+}
+
+// Sensitivity evaluates c with each integer constant shifted by ±1..±k
+// and records how many correct digits against target remain. Evaluation
+// is the search's (EvaluateCandidate), so perturbations that fail or stop
+// converging score 0 digits. Constants are listed numerator first, in
+// preorder.
+func Sensitivity(c *Candidate, maxTerms int64, prec uint, target *big.Float, k int64) SensitivityReport {
+	rep := SensitivityReport{Candidate: c.String()}
+	base, _ := sensitivityDigits(c, maxTerms, prec, target)
+	rep.Digits = base
+
+	trees := []struct {
+		name string
+		tree *expr.ExprNode
+	}{{"numerator", &c.Numerator}, {"denominator", &c.Denominator}}
+	for _, t := range trees {
+		for _, i := range expr.ConstIndices(*t.tree) {
+			val := expr.NodeAt(*t.tree, i).(*expr.ConstNode).Val
+			cs := ConstSensitivity{Tree: t.name, Index: i, Value: val, Drop: base}
+			for d := int64(1); d <= k; d++ {
+				for _, delta := range []int64{-d, d} {
+					variant := c.Clone()
+					tree := &variant.Numerator
+					if t.name == "denominator" {
+						tree = &variant.Denominator
+					}
+					*tree = expr.ReplaceAt(*tree, i, &expr.ConstNode{Val: val + delta})
+					digits, errStr := sensitivityDigits(variant, maxTerms, prec, target)
+					cs.Perturbations = append(cs.Perturbations, Perturbation{Delta: delta, Digits: digits, Error: errStr})
+					if d == 1 {
+						cs.Drop = min(cs.Drop, base-digits)
+					}
+				}
+			}
+			cs.LoadBearing = cs.Drop >= loadBearingDrop
+			rep.Constants = append(rep.Constants, cs)
+		}
+	}
+	return rep
+}
+
+// sensitivityDigits returns the correct digits of c against target and the
+// absolute error as text, or 0 and "" if c fails fitness evaluation.
+func sensitivityDigits(c *Candidate, maxTerms int64, prec uint, target *big.Float) (float64, string) {
+	res := EvaluateCandidate(c, maxTerms, prec)
+	f := ComputeFitness(c, res, target, DefaultWeights())
+	if f == WorstFitness() {
+		return 0, ""
+	}
+	diff := new(big.Float).SetPrec(prec).Sub(res.PartialSum, target)
+	return f.CorrectDigits, diff.Abs(diff).Text('e', 3)
+}
+
+// WriteText writes the report as a table, one line per constant.
+func (r SensitivityReport) WriteText(w io.Writer) {
+	fmt.Fprintf(w, "Candidate: %s\n", r.Candidate)
+	fmt.Fprintf(w, "Baseline:  %.1f digits\n", r.Digits)
+	if len(r.Constants) == 0 {
+		fmt.Fprintln(w, "(no integer constants)")
+		return
+	}
+	for _, cs := range r.Constants {
+		mark := ""
+		if cs.LoadBearing {
+			mark = "  load-bearing"
+		}
+		var deltas []string
+		for _, p := range cs.Perturbations {
+			deltas = append(deltas, fmt.Sprintf("%+d: %.1f", p.Delta, p.Digits))
+		}
+		fmt.Fprintf(w, "  %-11s #%-3d %6d | drop %5.1f | %s%s\n",
+			cs.Tree, cs.Index, cs.Value, cs.Drop, strings.Join(deltas, ", "), mark)
+	}
+}
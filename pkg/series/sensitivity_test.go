@@ -0,0 +1,35 @@
+package series
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestSensitivity(t *testing.T) {
+	e, _ := new(big.Float).SetPrec(testPrec).SetString("2.71828182845904523536028747135266249775724709369995")
+	// (-1)^{2n} = 1, so the 2 only matters up to parity.
+	c := mustParse(t, `\sum_{n=0}^{\infty} \frac{(-1)^{2n}}{n!}`)
+	rep := Sensitivity(c, 64, testPrec, e, 2)
+
+	if rep.Digits < 30 {
+		t.Fatalf("baseline %.1f digits, want 30+", rep.Digits)
+	}
+	if len(rep.Constants) != 1 {
+		t.Fatalf("%d constants, want 1: %+v", len(rep.Constants), rep.Constants)
+	}
+	cs := rep.Constants[0]
+	if cs.Tree != "numerator" || cs.Value != 2 || len(cs.Perturbations) != 4 {
+		t.Fatalf("unexpected constant %+v", cs)
+	}
+	if !cs.LoadBearing || cs.Drop < rep.Digits-1 {
+		t.Errorf("odd exponent should ruin the sum: %+v", cs)
+	}
+	for _, p := range cs.Perturbations {
+		if p.Delta == 2 && p.Digits != rep.Digits {
+			t.Errorf("(-1)^{4n} gave %.1f digits, want baseline %.1f", p.Digits, rep.Digits)
+		}
+	}
+	if c.String() != rep.Candidate {
+		t.Errorf("candidate modified: %s", c)
+	}
+}
@@ -0,0 +1,125 @@
+package series
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"net/http"
+	"os"
+	"regexp"
+	"time"
+)
+
+// Sequence is an integer sequence target: candidates are scored by how
+// many of its initial terms their term sequence t(n) reproduces exactly,
+// rather than by the digits of their sum.
+type Sequence struct {
+	Name   string // OEIS ID or file the terms came from
+	Offset int64  // index of Terms[0]
+	Terms  []*big.Int
+}
+
+var oeisID = regexp.MustCompile(`^A\d{6}$`)
+
+// oeisURL is where LoadSequence fetches an OEIS b-file; a variable so
+// tests can point it at a local server.
+var oeisURL = func(id string) string {
+	return fmt.Sprintf("https://oeis.org/%s/b%s.txt", id, id[1:])
+}
+
+// LoadSequence reads a sequence from an OEIS ID (A000045, fetched from
+// oeis.org) or a b-file path.
+func LoadSequence(spec string) (*Sequence, error) {
+	if oeisID.MatchString(spec) {
+		return fetchOEIS(spec)
+	}
+	f, err := os.Open(spec)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	offset, terms, err := ReadBFile(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", spec, err)
+	}
+	return &Sequence{Name: spec, Offset: offset, Terms: terms}, nil
+}
+
+func fetchOEIS(id string) (*Sequence, error) {
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Get(oeisURL(id))
+	if err != nil {
+		return nil, fmt.Errorf("fetching %s: %w", id, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching %s: %s", id, resp.Status)
+	}
+	offset, terms, err := ReadBFile(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("%s b-file: %w", id, err)
+	}
+	return &Sequence{Name: id, Offset: offset, Terms: terms}, nil
+}
+
+// Len returns the number of terms compared against, at most maxTerms.
+func (s *Sequence) Len(maxTerms int64) int {
+	if maxTerms > 0 && int64(len(s.Terms)) > maxTerms {
+		return int(maxTerms)
+	}
+	return len(s.Terms)
+}
+
+// SequenceFitness scores c against the first maxTerms terms of seq: the
+// candidate's terms t(Start), t(Start+1), ..., with t(n) =
+// numerator(n)/denominator(n) evaluated exactly, are compared with
+// a(Offset), a(Offset+1), .... Any denominator is allowed, since an
+// integer sequence needs none to shrink.
+//
+// CorrectDigits is the number of leading terms matched, plus credit below
+// 1 for how close the first mismatch came (see closeness), so that search
+// has a gradient between whole matches. The
+// result's TermsComputed is the whole-term count. A candidate whose first
+// term is undefined gets the worst fitness.
+func SequenceFitness(c *Candidate, seq *Sequence, maxTerms int64, weights FitnessWeights) (Fitness, EvalResult) {
+	matched, credit := 0, 0.0
+	for i := 0; i < seq.Len(maxTerms); i++ {
+		t, ok := rationalTerm(c, c.Start+int64(i))
+		if !ok {
+			if i == 0 {
+				return WorstFitness(), EvalResult{}
+			}
+			break
+		}
+		want := new(big.Rat).SetInt(seq.Terms[i])
+		if t.Cmp(want) != 0 {
+			credit = closeness(t, want)
+			break
+		}
+		matched++
+	}
+
+	score := float64(matched) + credit
+	complexity := c.Complexity()
+	penaltyScale := math.Min(score, 5.0) / 5.0
+	return Fitness{
+		Combined:      weights.Accuracy*score - weights.Complexity*complexity*penaltyScale,
+		CorrectDigits: score,
+		Simplicity:    1.0 / math.Max(complexity, 1.0),
+	}, EvalResult{TermsComputed: int64(matched), Converged: true, OK: true}
+}
+
+// closeness maps the relative error e of got against want (relative to
+// |want|, or absolute if |want| < 1) to 1/(1+e) in (0, 1).
+func closeness(got, want *big.Rat) float64 {
+	diff := new(big.Rat).Sub(got, want)
+	scale := new(big.Rat).Abs(want)
+	if scale.Cmp(big.NewRat(1, 1)) < 0 {
+		scale.SetInt64(1)
+	}
+	rel, _ := diff.Abs(diff).Quo(diff, scale).Float64()
+	if rel == 0 { // underflow: as close as can be without matching
+		return math.Nextafter(1, 0)
+	}
+	return 1 / (1 + rel)
+}
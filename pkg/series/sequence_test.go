@@ -0,0 +1,89 @@
+package series
+
+import (
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// fibonacciBFile is A000045's b-file for n = 0..20.
+func fibonacciBFile() string {
+	var b strings.Builder
+	b.WriteString("# A000045 (Fibonacci numbers)\n\n")
+	x, y := big.NewInt(0), big.NewInt(1)
+	for n := 0; n <= 20; n++ {
+		fmt.Fprintf(&b, "%d %s\n", n, x)
+		x, y = y, new(big.Int).Add(x, y)
+	}
+	return b.String()
+}
+
+func TestReadBFile(t *testing.T) {
+	offset, terms, err := ReadBFile(strings.NewReader(fibonacciBFile()))
+	if err != nil || offset != 0 || len(terms) != 21 || terms[20].Int64() != 6765 {
+		t.Fatalf("ReadBFile = %d, %v, %v", offset, terms, err)
+	}
+	for _, bad := range []string{"", "# only comments\n", "1 1\n3 2\n", "1 x\n", "1\n"} {
+		if _, _, err := ReadBFile(strings.NewReader(bad)); err == nil {
+			t.Errorf("ReadBFile(%q) succeeded", bad)
+		}
+	}
+}
+
+func TestSequenceFitness(t *testing.T) {
+	_, terms, _ := ReadBFile(strings.NewReader(fibonacciBFile()))
+	seq := &Sequence{Name: "fib", Offset: 0, Terms: terms}
+	parse := func(s string) *Candidate {
+		c, err := ParseCandidateLatex(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+	w := DefaultWeights()
+
+	exact, _ := SequenceFitness(parse(`\sum_{n=0}^{\infty} \frac{F_{n}}{1}`), seq, 0, w)
+	if exact.CorrectDigits != 21 {
+		t.Errorf("F_n matched %.2f terms, want 21", exact.CorrectDigits)
+	}
+	if f, _ := SequenceFitness(parse(`\sum_{n=0}^{\infty} \frac{F_{n}}{1}`), seq, 8, w); f.CorrectDigits != 8 {
+		t.Errorf("maxTerms 8: matched %.2f", f.CorrectDigits)
+	}
+
+	// n matches F_0 and F_1, then gets half credit for 2 against F_2 = 1.
+	lin, res := SequenceFitness(parse(`\sum_{n=0}^{\infty} \frac{n}{1}`), seq, 0, w)
+	if lin.CorrectDigits != 2.5 || res.TermsComputed != 2 {
+		t.Errorf("n: %.3f digits, %d terms", lin.CorrectDigits, res.TermsComputed)
+	}
+	if lin.Combined >= exact.Combined {
+		t.Errorf("n scores %.2f, not below F_n's %.2f", lin.Combined, exact.Combined)
+	}
+
+	if f, _ := SequenceFitness(parse(`\sum_{n=0}^{\infty} \frac{1}{n}`), seq, 0, w); f.Combined != WorstFitness().Combined {
+		t.Errorf("undefined first term scored %+v", f)
+	}
+}
+
+func TestLoadSequenceFromOEIS(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/A000045/b000045.txt" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, fibonacciBFile())
+	}))
+	defer srv.Close()
+	defer func(f func(string) string) { oeisURL = f }(oeisURL)
+	oeisURL = func(id string) string { return srv.URL + "/" + id + "/b" + id[1:] + ".txt" }
+
+	seq, err := LoadSequence("A000045")
+	if err != nil || seq.Name != "A000045" || len(seq.Terms) != 21 {
+		t.Fatalf("LoadSequence = %+v, %v", seq, err)
+	}
+	if _, err := LoadSequence("A999999"); err == nil {
+		t.Error("missing sequence loaded")
+	}
+}
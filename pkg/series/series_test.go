@@ -1,6 +1,7 @@
 package series
 
 import (
+	"errors"
 	"math"
 	"math/big"
 	"testing"
@@ -51,6 +52,38 @@ func TestEvaluateCandidate_DivByZero(t *testing.T) {
 	}
 }
 
+// nanNode behaves like n but raises the panic math/big uses for undefined
+// operations such as ∞/∞.
+type nanNode struct{ expr.VarNode }
+
+func (nanNode) Eval(*big.Float, uint) (*big.Float, bool) {
+	panic(big.ErrNaN{})
+}
+
+func TestEvaluateCandidate_RecoversPanic(t *testing.T) {
+	c := &Candidate{
+		Numerator:   &expr.ConstNode{Val: 1},
+		Denominator: &nanNode{},
+		Start:       1,
+	}
+
+	result := EvaluateCandidate(c, 10, testPrec)
+	if result.OK {
+		t.Fatal("Expected OK=false for a panicking candidate")
+	}
+	var evalErr *EvalError
+	if !errors.As(result.Err, &evalErr) {
+		t.Fatalf("Err = %v, want *EvalError", result.Err)
+	}
+	var nan big.ErrNaN
+	if !errors.As(result.Err, &nan) {
+		t.Errorf("Err = %v does not unwrap to big.ErrNaN", result.Err)
+	}
+	if f := ComputeFitness(c, result, big.NewFloat(1), DefaultWeights()); f != WorstFitness() {
+		t.Errorf("fitness = %+v, want worst", f)
+	}
+}
+
 func TestFitness_KnownSeries(t *testing.T) {
 	// 1/n! candidate targeting e
 	c := &Candidate{
@@ -78,6 +111,70 @@ func TestFitness_KnownSeries(t *testing.T) {
 		fitness.Combined, fitness.CorrectDigits, fitness.Simplicity)
 }
 
+func TestComputeBestFitness(t *testing.T) {
+	c, err := ParseCandidateLatex(`\sum_{n=0}^{\infty} \frac{1}{n!}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := func(s string) *big.Float {
+		f, _ := new(big.Float).SetPrec(testPrec).SetString(s)
+		return f
+	}
+	targets := []*big.Float{at("3.14159265358979323846"), at("2.71828182845904523536"), at("0.69314718055994530942")}
+	result := EvaluateCandidate(c, 40, testPrec)
+	f, i := ComputeBestFitness(c, result, targets, DefaultWeights())
+	if i != 1 || f.CorrectDigits < 19 || f.CorrectDigits < MatchThreshold(c) {
+		t.Errorf("best of pi, e, ln 2 for 1/n! = %d with %.1f digits (threshold %.1f), want e", i, f.CorrectDigits, MatchThreshold(c))
+	}
+	r64 := EvaluateCandidateF64(c, 40)
+	f64s := []float64{math.Pi, math.E, math.Ln2}
+	if f, i := ComputeBestFitnessF64(c, r64, f64s, DefaultWeights()); i != 1 || f.CorrectDigits < 14 {
+		t.Errorf("float64 best = %d with %.1f digits, want e", i, f.CorrectDigits)
+	}
+	bad := &Candidate{Numerator: &expr.ConstNode{Val: 1}, Denominator: &expr.ConstNode{Val: 0}}
+	if f, i := ComputeBestFitness(bad, EvaluateCandidate(bad, 10, testPrec), targets, DefaultWeights()); i != -1 || f.Combined != WorstFitness().Combined {
+		t.Errorf("failed candidate matched target %d: %+v", i, f)
+	}
+	if bigger, _ := ParseCandidateLatex(`\sum_{n=0}^{\infty} \frac{3n + 7}{n!}`); MatchThreshold(bigger) <= MatchThreshold(c) {
+		t.Errorf("threshold %.1f for %s not above %.1f for %s", MatchThreshold(bigger), bigger, MatchThreshold(c), c)
+	}
+}
+
+func TestComputeFitnessWithin(t *testing.T) {
+	c, err := ParseCandidateLatex(`\sum_{n=0}^{\infty} \frac{1}{n!}`) // e ≈ 2.71828
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := func(x float64) *big.Float { return new(big.Float).SetPrec(testPrec).SetFloat64(x) }
+	result := EvaluateCandidate(c, 40, testPrec)
+	r64 := EvaluateCandidateF64(c, 40)
+	w := DefaultWeights()
+
+	in := ComputeFitnessWithin(c, result, at(2.7), at(2.72), w)
+	if want := math.Log10(2.71 / 0.01); math.Abs(in.CorrectDigits-want) > 1e-9 || in.Error != 0 {
+		t.Errorf("inside [2.7, 2.72]: %.4f digits, error %g; want %.4f, 0", in.CorrectDigits, in.Error, want)
+	}
+	// Just outside an interval of the same width scores a little less,
+	// and the error says which side.
+	out := ComputeFitnessWithin(c, result, at(2.698), at(2.718), w)
+	if out.CorrectDigits >= in.CorrectDigits || out.CorrectDigits < in.CorrectDigits-0.1 || out.Error <= 0 || out.Combined >= in.Combined {
+		t.Errorf("just above [2.698, 2.718]: %+v, inside: %+v", out, in)
+	}
+	if f := ComputeFitnessWithinF64(c, r64, 2.7, 2.72, w); math.Abs(f.CorrectDigits-in.CorrectDigits) > 1e-9 {
+		t.Errorf("float64 inside: %.4f digits, want %.4f", f.CorrectDigits, in.CorrectDigits)
+	}
+	if f := ComputeFitnessWithinF64(c, r64, 2.72, 2.74, w); f.Error >= 0 {
+		t.Errorf("float64 below [2.72, 2.74]: error %g, want negative", f.Error)
+	}
+
+	// A far outside interval is scored like the point target.
+	far := ComputeFitnessWithin(c, result, at(3.13), at(3.15), w)
+	point := ComputeFitness(c, result, at(3.14), w)
+	if math.Abs(far.CorrectDigits-point.CorrectDigits) > 0.05 {
+		t.Errorf("far from [3.13, 3.15]: %.3f digits, point target 3.14: %.3f", far.CorrectDigits, point.CorrectDigits)
+	}
+}
+
 func TestFitness_BadCandidate(t *testing.T) {
 	c := &Candidate{
 		Numerator:   &expr.ConstNode{Val: 1},
@@ -110,6 +207,25 @@ func TestCandidateClone(t *testing.T) {
 	}
 }
 
+func TestCandidateLaTeXMap(t *testing.T) {
+	c, err := ParseCandidateLatex(`\sum_{n=1}^{\infty} \frac{(-1)^{n}}{n^{2} + 1}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, num, den := c.LaTeXMap()
+	if s != c.LaTeX() || len(num) != c.Numerator.NodeCount() || len(den) != c.Denominator.NodeCount() {
+		t.Fatalf("LaTeXMap = %q, %d + %d spans", s, len(num), len(den))
+	}
+	for i, sp := range den {
+		if got, want := s[sp.Start:sp.End], expr.NodeAt(c.Denominator, i).LaTeX(); got != want {
+			t.Errorf("denominator node %d spans %q, want %q", i, got, want)
+		}
+	}
+	if got := s[num[0].Start:num[0].End]; got != c.Numerator.LaTeX() {
+		t.Errorf("numerator spans %q", got)
+	}
+}
+
 func TestCandidateComplexity(t *testing.T) {
 	c := &Candidate{
 		Numerator: &expr.BinaryNode{
@@ -0,0 +1,99 @@
+package seriestest
+
+import (
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/wildfunctions/genetic_series/pkg/expr"
+	"github.com/wildfunctions/genetic_series/pkg/series"
+)
+
+// Check runs each fixture as a subtest: its LaTeX must parse, canonicalize
+// to the recorded string and evaluate to the recorded terms and sum, its
+// float64 terms must agree with those to 1e-9 where defined and in range,
+// and the parsed candidate must keep Properties.
+func Check(t *testing.T, fs []Fixture) {
+	t.Helper()
+	for _, f := range fs {
+		t.Run(f.name(), func(t *testing.T) {
+			c, err := series.ParseCandidateLatex(f.LaTeX)
+			if err != nil {
+				t.Fatalf("%s: %v", f.LaTeX, err)
+			}
+			if got := series.CanonicalKey(c); got != f.Canonical {
+				t.Errorf("%s: canonical\n got %s\nwant %s", f.LaTeX, got, f.Canonical)
+			}
+			for n, want := range f.Terms {
+				got := term(c, int64(n))
+				if !matches(got, want) {
+					t.Errorf("%s: a(%d) = %s, want %s", f.LaTeX, n, format(got), want)
+				}
+				if got == nil {
+					continue
+				}
+				num, ok1 := c.Numerator.EvalF64(float64(n))
+				den, ok2 := c.Denominator.EvalF64(float64(n))
+				if v := num / den; ok1 && ok2 && math.Abs(v) < 1e300 && math.Abs(v) > 1e-300 && !near(big.NewFloat(v), got, 1e-9) {
+					t.Errorf("%s: a(%d) in float64 = %g, want %s", f.LaTeX, n, v, format(got))
+				}
+			}
+			if got := sum(c); !matches(got, f.Sum) {
+				t.Errorf("%s: sum of %d terms = %s, want %s", f.LaTeX, SumTerms, format(got), f.Sum)
+			}
+			Properties(t, c)
+		})
+	}
+}
+
+// Properties checks what must hold of any candidate, whatever its ops:
+//
+//   - Its LaTeX parses back to a candidate with the same canonical form.
+//   - Its genome decodes back to the same candidate.
+//   - Simplify keeps the numerator's and denominator's values at
+//     n = 0..NumTerms-1 to 1e-25 wherever both forms are defined.
+func Properties(t testing.TB, c *series.Candidate) {
+	t.Helper()
+	key := series.CanonicalKey(c)
+	if back, err := series.ParseCandidateLatex(c.LaTeX()); err != nil {
+		t.Errorf("%s: LaTeX %s does not parse: %v", c, c.LaTeX(), err)
+	} else if got := series.CanonicalKey(back); got != key {
+		t.Errorf("%s: LaTeX round trip\n got %s\nwant %s", c, got, key)
+	}
+	if back, err := series.EncodeCandidate(c).Decode(); err != nil {
+		t.Errorf("%s: genome does not decode: %v", c, err)
+	} else if back.String() != c.String() {
+		t.Errorf("%s: genome round trip gives %s", c, back)
+	}
+	for _, part := range []struct {
+		name string
+		node expr.ExprNode
+	}{{"numerator", c.Numerator}, {"denominator", c.Denominator}} {
+		simple := expr.Simplify(part.node)
+		for n := int64(0); n < NumTerms; n++ {
+			x := new(big.Float).SetPrec(prec).SetInt64(n)
+			v, ok := part.node.Eval(x, prec)
+			if !ok {
+				continue
+			}
+			if s, ok := simple.Eval(x, prec); ok && !near(s, v, tolerance) {
+				t.Errorf("%s: simplified %s %s is %s at n=%d, was %s", c, part.name, simple, format(s), n, format(v))
+			}
+		}
+	}
+}
+
+// near reports whether got is within a relative tol of want, or both are
+// zero to within the working precision.
+func near(got, want *big.Float, tol float64) bool {
+	if got.IsInf() || want.IsInf() {
+		return got.IsInf() && want.IsInf() && got.Sign() == want.Sign()
+	}
+	d := new(big.Float).SetPrec(prec).Sub(got, want)
+	if d.Sign() == 0 || d.MantExp(nil) < -int(prec)+16 {
+		return true
+	}
+	bound := new(big.Float).SetPrec(prec).Abs(want)
+	bound.Mul(bound, big.NewFloat(tol))
+	return d.Abs(d).Cmp(bound) <= 0
+}
@@ -0,0 +1,210 @@
+// Package seriestest checks the parser, simplifier and evaluators against
+// golden fixtures, and candidates against properties every correct
+// implementation keeps. Golden returns the built-in corpus of classical
+// formulas; authors of custom ops (see expr.RegisterOp) can write fixtures
+// for series using their ops with Compute and Write, and run them, and
+// Properties over their own trees, from their tests.
+package seriestest
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/wildfunctions/genetic_series/pkg/series"
+)
+
+const (
+	NumTerms = 6   // terms a fixture records, at n = 0..NumTerms-1
+	SumTerms = 100 // terms of the partial sum a fixture records, from Start
+
+	prec      = 256 // bits the values are computed at
+	digits    = 30  // significant digits the values are recorded to
+	tolerance = 1e-25
+)
+
+// undefined is how a fixture records a term or sum that fails to evaluate.
+const undefined = "undefined"
+
+// Fixture is the expected behaviour of one series.
+type Fixture struct {
+	LaTeX     string
+	Canonical string           // series.CanonicalKey of the parsed candidate
+	Terms     [NumTerms]string // a(n) = num(n)/den(n) at n = 0..5, whatever Start is
+	Sum       string           // offset plus the first SumTerms terms, by series.BigFloatEvaluator
+	Line      int              // of the latex: line in the file it was read from, for messages
+}
+
+//go:embed golden.txt
+var golden string
+
+// Golden returns the built-in fixtures.
+func Golden() []Fixture {
+	fs, err := Parse(strings.NewReader(golden))
+	if err != nil {
+		panic("seriestest: golden.txt: " + err.Error())
+	}
+	return fs
+}
+
+// Compute evaluates latex with the current parser, simplifier and
+// evaluator, for recording as a fixture once checked by hand.
+func Compute(latex string) (Fixture, error) {
+	c, err := series.ParseCandidateLatex(latex)
+	if err != nil {
+		return Fixture{}, err
+	}
+	f := Fixture{LaTeX: latex, Canonical: series.CanonicalKey(c)}
+	for n := range f.Terms {
+		f.Terms[n] = format(term(c, int64(n)))
+	}
+	f.Sum = format(sum(c))
+	return f, nil
+}
+
+func term(c *series.Candidate, n int64) *big.Float {
+	x := new(big.Float).SetPrec(prec).SetInt64(n)
+	num, ok1 := c.Numerator.Eval(x, prec)
+	den, ok2 := c.Denominator.Eval(x, prec)
+	if !ok1 || !ok2 || den.Sign() == 0 {
+		return nil
+	}
+	return new(big.Float).SetPrec(prec).Quo(num, den)
+}
+
+func sum(c *series.Candidate) *big.Float {
+	r := series.BigFloatEvaluator{}.Evaluate(c, series.EvalOptions{MaxTerms: SumTerms, Prec: prec})
+	if !r.OK {
+		return nil
+	}
+	return r.PartialSum
+}
+
+func format(v *big.Float) string {
+	if v == nil || v.IsInf() {
+		return undefined
+	}
+	return v.Text('g', digits)
+}
+
+// matches reports whether got matches the recorded want: both undefined, or
+// equal to a relative tolerance of 1e-25.
+func matches(got *big.Float, want string) bool {
+	if got == nil || got.IsInf() || want == undefined {
+		return format(got) == want
+	}
+	w, _, err := big.ParseFloat(want, 10, prec, big.ToNearestEven)
+	if err != nil {
+		return false
+	}
+	return near(got, w, tolerance)
+}
+
+// Load reads fixtures from a file in the format Write produces.
+func Load(path string) ([]Fixture, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Parse(f)
+}
+
+// Parse reads fixtures: blocks of "key: value" lines, one per fixture,
+// separated by blank lines, with # comments. Each block starts with a
+// latex: line and has canonical:, terms: (NumTerms values) and sum: lines.
+func Parse(r io.Reader) ([]Fixture, error) {
+	var (
+		fs   []Fixture
+		cur  *Fixture
+		seen map[string]bool
+	)
+	done := func() error {
+		if cur == nil {
+			return nil
+		}
+		for _, k := range []string{"canonical", "terms", "sum"} {
+			if !seen[k] {
+				return fmt.Errorf("line %d: fixture has no %s", cur.Line, k)
+			}
+		}
+		fs = append(fs, *cur)
+		cur = nil
+		return nil
+	}
+	sc := bufio.NewScanner(r)
+	for line := 1; sc.Scan(); line++ {
+		s := strings.TrimSpace(sc.Text())
+		if s == "" {
+			if err := done(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if strings.HasPrefix(s, "#") {
+			continue
+		}
+		key, val, ok := strings.Cut(s, ":")
+		if !ok {
+			return nil, fmt.Errorf("line %d: want key: value", line)
+		}
+		val = strings.TrimSpace(val)
+		if key == "latex" {
+			if err := done(); err != nil {
+				return nil, err
+			}
+			cur, seen = &Fixture{LaTeX: val, Line: line}, map[string]bool{}
+			continue
+		}
+		if cur == nil {
+			return nil, fmt.Errorf("line %d: %s before latex", line, key)
+		}
+		switch key {
+		case "canonical":
+			cur.Canonical = val
+		case "terms":
+			ts := strings.Fields(val)
+			if len(ts) != NumTerms {
+				return nil, fmt.Errorf("line %d: %d terms, want %d", line, len(ts), NumTerms)
+			}
+			copy(cur.Terms[:], ts)
+		case "sum":
+			cur.Sum = val
+		default:
+			return nil, fmt.Errorf("line %d: unknown key %q", line, key)
+		}
+		seen[key] = true
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if err := done(); err != nil {
+		return nil, err
+	}
+	return fs, nil
+}
+
+// Write writes fixtures in the format Parse reads.
+func Write(w io.Writer, fs []Fixture) error {
+	bw := bufio.NewWriter(w)
+	for i, f := range fs {
+		if i > 0 {
+			bw.WriteString("\n")
+		}
+		fmt.Fprintf(bw, "latex: %s\ncanonical: %s\nterms: %s\nsum: %s\n", f.LaTeX, f.Canonical, strings.Join(f.Terms[:], " "), f.Sum)
+	}
+	return bw.Flush()
+}
+
+// name is a subtest name for f.
+func (f Fixture) name() string {
+	if f.Line > 0 {
+		return "line" + strconv.Itoa(f.Line)
+	}
+	return f.LaTeX
+}
@@ -0,0 +1,71 @@
+package seriestest
+
+import (
+	"bufio"
+	"flag"
+	"math/rand"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/wildfunctions/genetic_series/pkg/pool"
+	"github.com/wildfunctions/genetic_series/pkg/series"
+)
+
+var update = flag.Bool("update", false, "rewrite golden.txt from its latex: lines with the current code")
+
+const goldenHeader = `# Golden fixtures for seriestest.Check: classical series and what the
+# parser, canonicalizer and evaluator make of them. The terms are a(n) at
+# n = 0..5 and the sum is of the first 100 terms, both at 256 bits. After a
+# deliberate change, regenerate with go test -update and review the diff.
+# sin, cos and ln are evaluated through float64 even in big.Float, so the
+# fixtures using them are only good to about 16 digits.
+
+`
+
+func TestGolden(t *testing.T) {
+	if *update {
+		rewriteGolden(t)
+	}
+	fs := Golden()
+	if len(fs) < 36 {
+		t.Errorf("only %d golden fixtures", len(fs))
+	}
+	Check(t, fs)
+}
+
+func TestProperties(t *testing.T) {
+	p, _ := pool.Get("kitchensink")
+	rng := rand.New(rand.NewSource(42))
+	for range 500 {
+		c := &series.Candidate{
+			Numerator:   p.RandomTree(rng, 4),
+			Denominator: p.RandomTree(rng, 4),
+			Start:       int64(rng.Intn(2)),
+		}
+		Properties(t, c)
+	}
+}
+
+func rewriteGolden(t *testing.T) {
+	var fs []Fixture
+	sc := bufio.NewScanner(strings.NewReader(golden))
+	for sc.Scan() {
+		if latex, ok := strings.CutPrefix(sc.Text(), "latex: "); ok {
+			f, err := Compute(latex)
+			if err != nil {
+				t.Fatalf("%s: %v", latex, err)
+			}
+			fs = append(fs, f)
+		}
+	}
+	var b strings.Builder
+	b.WriteString(goldenHeader)
+	if err := Write(&b, fs); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile("golden.txt", []byte(b.String()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	golden = b.String()
+}
@@ -0,0 +1,85 @@
+package series
+
+import (
+	"sort"
+
+	"github.com/wildfunctions/genetic_series/pkg/expr"
+)
+
+// FamilyThreshold is the Similarity at or above which Cluster puts two
+// candidates in the same family.
+const FamilyThreshold = 0.5
+
+// Fingerprint is the sorted multiset of constant-erased subtree shapes of
+// a candidate's numerator and denominator (see expr.ShapeFeatures).
+type Fingerprint []uint64
+
+// denSalt keeps numerator and denominator features apart, so n/1 and 1/n
+// don't look alike.
+const denSalt = 0x9e3779b97f4a7c15
+
+// NewFingerprint computes c's fingerprint.
+func NewFingerprint(c *Candidate) Fingerprint {
+	f := expr.ShapeFeatures(nil, c.Numerator)
+	num := len(f)
+	f = expr.ShapeFeatures(f, c.Denominator)
+	for i := num; i < len(f); i++ {
+		f[i] ^= denSalt
+	}
+	sort.Slice(f, func(i, j int) bool { return f[i] < f[j] })
+	return f
+}
+
+// Similarity is the multiset Jaccard index of two fingerprints: 1 for the
+// same shape (constants and start index may differ), falling toward 0 as
+// the trees share fewer subtrees.
+func (f Fingerprint) Similarity(g Fingerprint) float64 {
+	var common int
+	for i, j := 0, 0; i < len(f) && j < len(g); {
+		switch {
+		case f[i] == g[j]:
+			common++
+			i++
+			j++
+		case f[i] < g[j]:
+			i++
+		default:
+			j++
+		}
+	}
+	union := len(f) + len(g) - common
+	if union == 0 {
+		return 1
+	}
+	return float64(common) / float64(union)
+}
+
+// Similarity compares the structure of two candidates; see Fingerprint.
+func Similarity(a, b *Candidate) float64 {
+	return NewFingerprint(a).Similarity(NewFingerprint(b))
+}
+
+// Cluster groups candidates into families of near-duplicates. Candidates
+// should be ordered best first: each joins the first family whose leader
+// (its first member) is at least threshold similar, or else leads a new
+// one. Families are returned in order of their leaders, as index lists.
+func Cluster(cands []*Candidate, threshold float64) [][]int {
+	var families [][]int
+	var leaders []Fingerprint
+	for i, c := range cands {
+		fp := NewFingerprint(c)
+		placed := false
+		for k, lf := range leaders {
+			if fp.Similarity(lf) >= threshold {
+				families[k] = append(families[k], i)
+				placed = true
+				break
+			}
+		}
+		if !placed {
+			families = append(families, []int{i})
+			leaders = append(leaders, fp)
+		}
+	}
+	return families
+}
@@ -0,0 +1,57 @@
+package series
+
+import "testing"
+
+func TestSimilarity(t *testing.T) {
+	base := mustParse(t, `\sum_{n=0}^{\infty} \frac{(-1)^n}{2n+1}`)
+	cases := []struct {
+		latex string
+		min   float64
+		max   float64
+	}{
+		{`\sum_{n=1}^{\infty} \frac{(-1)^n}{4n+3}`, 1, 1},          // same shape, other constants
+		{`\sum_{n=0}^{\infty} \frac{(-1)^n}{(2n+1)^2}`, 0.5, 0.99}, // one extra node
+		{`\sum_{n=0}^{\infty} \frac{1}{n!}`, 0, 0.3},               // unrelated
+	}
+	for _, tc := range cases {
+		got := Similarity(base, mustParse(t, tc.latex))
+		if got < tc.min || got > tc.max {
+			t.Errorf("Similarity(%s, %s) = %.3f, want in [%.2f, %.2f]", base, tc.latex, got, tc.min, tc.max)
+		}
+	}
+
+	// Numerator and denominator are kept apart.
+	a := mustParse(t, `\sum_{n=1}^{\infty} \frac{n}{2}`)
+	b := mustParse(t, `\sum_{n=1}^{\infty} \frac{2}{n}`)
+	if s := Similarity(a, b); s >= FamilyThreshold {
+		t.Errorf("Similarity(n/2, 2/n) = %.3f, want below %.2f", s, FamilyThreshold)
+	}
+}
+
+func TestCluster(t *testing.T) {
+	var cands []*Candidate
+	for _, latex := range []string{
+		`\sum_{n=0}^{\infty} \frac{1}{n!}`,
+		`\sum_{n=0}^{\infty} \frac{(-1)^n}{2n+1}`,
+		`\sum_{n=0}^{\infty} \frac{2}{n!}`,
+		`\sum_{n=0}^{\infty} \frac{(-1)^n}{2n+5}`,
+		`\sum_{n=1}^{\infty} \frac{3}{n!}`,
+	} {
+		cands = append(cands, mustParse(t, latex))
+	}
+	got := Cluster(cands, FamilyThreshold)
+	want := [][]int{{0, 2, 4}, {1, 3}}
+	if len(got) != len(want) {
+		t.Fatalf("Cluster = %v, want %v", got, want)
+	}
+	for i := range want {
+		if len(got[i]) != len(want[i]) {
+			t.Fatalf("Cluster = %v, want %v", got, want)
+		}
+		for j := range want[i] {
+			if got[i][j] != want[i][j] {
+				t.Fatalf("Cluster = %v, want %v", got, want)
+			}
+		}
+	}
+}
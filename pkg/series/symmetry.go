@@ -0,0 +1,146 @@
+package series
+
+import (
+	"github.com/wildfunctions/genetic_series/pkg/expr"
+)
+
+// PairTerms returns c with consecutive terms added in pairs,
+//
+//	Sum_{n=S} t(n) = Sum_{n=0} [t(2n+S) + t(2n+S+1)],
+//
+// the two terms over a common denominator (or one shared by both). The
+// partial sums of the result are every other partial sum of c, so a
+// convergent c keeps its value; pairing the terms of a divergent one, such
+// as (-1)^n, can make it converge. (-1)^ of the substituted index is
+// folded by parity, so alternating series pair into ones without the sign.
+// c is not modified.
+func PairTerms(c *Candidate) *Candidate {
+	n1, d1 := substituteIndex(c.Numerator, 2, c.Start), substituteIndex(c.Denominator, 2, c.Start)
+	n2, d2 := substituteIndex(c.Numerator, 2, c.Start+1), substituteIndex(c.Denominator, 2, c.Start+1)
+
+	out := &Candidate{Start: 0, Offset: c.Offset}
+	if expr.Equal(d1, d2) {
+		out.Numerator = &expr.BinaryNode{Op: expr.OpAdd, Left: n1, Right: n2}
+		out.Denominator = d1
+	} else {
+		out.Numerator = &expr.BinaryNode{Op: expr.OpAdd,
+			Left:  &expr.BinaryNode{Op: expr.OpMul, Left: n1, Right: d2},
+			Right: &expr.BinaryNode{Op: expr.OpMul, Left: n2, Right: d1},
+		}
+		out.Denominator = &expr.BinaryNode{Op: expr.OpMul, Left: d1, Right: d2}
+	}
+	out.Numerator = expr.Simplify(out.Numerator)
+	if p, q, ok := affineOf(out.Numerator); ok { // such as (4n+3) - (4n+1)
+		out.Numerator = affineNode(p, q)
+	}
+	out.Denominator = expr.Simplify(out.Denominator)
+	return out
+}
+
+// SplitParity splits c into the sums of its terms at even and at odd n,
+//
+//	Sum_{n=S} t(n) = Sum_{n=⌈S/2⌉} t(2n) + Sum_{n=⌊S/2⌋} t(2n+1),
+//
+// which hold whenever c converges absolutely. The offset goes with the
+// even part. c is not modified.
+func SplitParity(c *Candidate) (even, odd *Candidate) {
+	even = &Candidate{
+		Numerator:   expr.Simplify(substituteIndex(c.Numerator, 2, 0)),
+		Denominator: expr.Simplify(substituteIndex(c.Denominator, 2, 0)),
+		Start:       (c.Start + 1) / 2,
+		Offset:      c.Offset,
+	}
+	odd = &Candidate{
+		Numerator:   expr.Simplify(substituteIndex(c.Numerator, 2, 1)),
+		Denominator: expr.Simplify(substituteIndex(c.Denominator, 2, 1)),
+		Start:       c.Start / 2,
+	}
+	return even, odd
+}
+
+// ReverseRange returns c with the order of its first k terms reversed:
+// the term at n is t(2S+k-1-n). The result agrees with c only as the
+// finite sum over n = S..S+k-1, such as the head SplitLeading splits off;
+// past it the terms are t at indices below S. c is not modified.
+func ReverseRange(c *Candidate, k int64) *Candidate {
+	b := 2*c.Start + k - 1
+	return &Candidate{
+		Numerator:   expr.Simplify(substituteIndex(c.Numerator, -1, b)),
+		Denominator: expr.Simplify(substituteIndex(c.Denominator, -1, b)),
+		Start:       c.Start,
+		Offset:      c.Offset,
+	}
+}
+
+// substituteIndex substitutes a·n+b for n in node, folding a subtree
+// p·n+q (see affineOf) to pa·n+(pb+q), and (-1)^(p·n+q), as AltSign or a
+// power of -1, by parity: to ±1 for even pa and to ±(-1)^n for odd pa.
+func substituteIndex(node expr.ExprNode, a, b int64) expr.ExprNode {
+	if p, q, ok := indexAffine(node); ok {
+		return affineNode(p*a, p*b+q)
+	}
+	switch n := node.(type) {
+	case *expr.UnaryNode:
+		if p, q, ok := indexAffine(n.Child); ok && n.Op == expr.OpAltSign {
+			return altSignOf(p*a, p*b+q)
+		}
+		return &expr.UnaryNode{Op: n.Op, Child: substituteIndex(n.Child, a, b)}
+	case *expr.BinaryNode:
+		if m, ok := n.Left.(*expr.ConstNode); ok && m.Val == -1 && n.Op == expr.OpPow {
+			if p, q, ok := indexAffine(n.Right); ok {
+				return altSignOf(p*a, p*b+q) // (-1)^(p·n+q), as the parser reads it
+			}
+		}
+		return &expr.BinaryNode{Op: n.Op, Left: substituteIndex(n.Left, a, b), Right: substituteIndex(n.Right, a, b)}
+	}
+	return node
+}
+
+// indexAffine reports whether node is p·n+q with p != 0, and p and q.
+func indexAffine(node expr.ExprNode) (p, q int64, ok bool) {
+	if !expr.ContainsVar(node) {
+		return 0, 0, false
+	}
+	p, q, ok = affineOf(node)
+	return p, q, ok && p != 0
+}
+
+// affineNode returns a·n+j, written as j, n+j (see offsetNode), j - n or
+// a·n ± |j|.
+func affineNode(a, j int64) expr.ExprNode {
+	switch a {
+	case 0:
+		return &expr.ConstNode{Val: j}
+	case 1:
+		return offsetNode(j)
+	case -1:
+		if j == 0 {
+			return &expr.UnaryNode{Op: expr.OpNeg, Child: &expr.VarNode{}}
+		}
+		return &expr.BinaryNode{Op: expr.OpSub, Left: &expr.ConstNode{Val: j}, Right: &expr.VarNode{}}
+	}
+	an := &expr.BinaryNode{Op: expr.OpMul, Left: &expr.ConstNode{Val: a}, Right: &expr.VarNode{}}
+	switch {
+	case j > 0:
+		return &expr.BinaryNode{Op: expr.OpAdd, Left: an, Right: &expr.ConstNode{Val: j}}
+	case j < 0:
+		return &expr.BinaryNode{Op: expr.OpSub, Left: an, Right: &expr.ConstNode{Val: -j}}
+	}
+	return an
+}
+
+// altSignOf returns (-1)^(a·n+j): ±1 for even a, ±(-1)^n for odd a.
+func altSignOf(a, j int64) expr.ExprNode {
+	neg := j%2 != 0
+	if a%2 == 0 {
+		if neg {
+			return &expr.ConstNode{Val: -1}
+		}
+		return &expr.ConstNode{Val: 1}
+	}
+	alt := &expr.UnaryNode{Op: expr.OpAltSign, Child: &expr.VarNode{}}
+	if neg {
+		return &expr.UnaryNode{Op: expr.OpNeg, Child: alt}
+	}
+	return alt
+}
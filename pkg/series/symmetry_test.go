@@ -0,0 +1,87 @@
+package series
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+)
+
+// headSum is the sum of c's terms t(S)..t(S+k-1), and its offset.
+func headSum(t *testing.T, c *Candidate, k int64) *big.Float {
+	t.Helper()
+	sum, ok := c.OffsetValue(testPrec)
+	if !ok {
+		t.Fatalf("%s: offset undefined", c)
+	}
+	for n := c.Start; n < c.Start+k; n++ {
+		v, ok := termAt(c, n, testPrec)
+		if !ok {
+			t.Fatalf("%s: t(%d) undefined", c, n)
+		}
+		sum.Add(sum, v)
+	}
+	return sum
+}
+
+func sameValue(a, b *big.Float) bool {
+	d := new(big.Float).Sub(a, b)
+	return d.Sign() == 0 || d.MantExp(nil) < b.MantExp(nil)-int(testPrec)+8
+}
+
+func TestPairTerms(t *testing.T) {
+	for _, latex := range []string{
+		`\sum_{n=0}^{\infty} \frac{(-1)^{n}}{2n+1}`,
+		`\sum_{n=1}^{\infty} \frac{(-1)^{n+1}}{n}`,
+		`2 + \sum_{n=3}^{\infty} \frac{n}{n!}`,
+	} {
+		c := mustParse(t, latex)
+		p := PairTerms(c)
+		if got, want := headSum(t, p, 20), headSum(t, c, 40); !sameValue(got, want) {
+			t.Errorf("%s paired: 20 terms sum to %s, want the first 40 of c, %s", c, got.Text('g', 30), want.Text('g', 30))
+		}
+		if p.Start != 0 {
+			t.Errorf("%s paired starts at %d, want 0", c, p.Start)
+		}
+		if strings.Contains(latex, "(-1)") && strings.Contains(p.String(), "(-1)") {
+			t.Errorf("%s paired keeps its sign: %s", c, p)
+		}
+	}
+}
+
+func TestPairTermsLeibniz(t *testing.T) {
+	p := PairTerms(mustParse(t, `\sum_{n=0}^{\infty} \frac{(-1)^{n}}{2n+1}`))
+	if got, want := p.String(), "Sum_{n=0}^{inf} (2) / ((((4 * n) + 1) * ((4 * n) + 3)))"; got != want {
+		t.Errorf("paired Leibniz series = %s, want %s", got, want)
+	}
+}
+
+func TestSplitParity(t *testing.T) {
+	for _, latex := range []string{
+		`\sum_{n=0}^{\infty} \frac{1}{n!}`,
+		`\frac{1}{2} + \sum_{n=3}^{\infty} \frac{(-1)^{n}}{n^{2}}`,
+	} {
+		c := mustParse(t, latex)
+		even, odd := SplitParity(c)
+		got := headSum(t, even, 15)
+		got.Add(got, headSum(t, odd, 15))
+		if want := headSum(t, c, 30); !sameValue(got, want) {
+			t.Errorf("%s: even + odd = %s, want %s", c, got.Text('g', 30), want.Text('g', 30))
+		}
+		if even.Offset == nil != (c.Offset == nil) || odd.Offset != nil {
+			t.Errorf("%s: offsets %v, %v; want c's on the even part", c, even.Offset, odd.Offset)
+		}
+	}
+}
+
+func TestReverseRange(t *testing.T) {
+	c := mustParse(t, `\sum_{n=2}^{\infty} \frac{(-1)^{n}}{n (n+1)}`)
+	r := ReverseRange(c, 7)
+	if got, want := headSum(t, r, 7), headSum(t, c, 7); !sameValue(got, want) {
+		t.Errorf("reversed head = %s, want %s", got.Text('g', 30), want.Text('g', 30))
+	}
+	first, _ := termAt(r, r.Start, testPrec)
+	last, _ := termAt(c, c.Start+6, testPrec)
+	if first.Cmp(last) != 0 {
+		t.Errorf("reversed first term %s, want c's seventh %s", first, last)
+	}
+}
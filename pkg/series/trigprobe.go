@@ -0,0 +1,325 @@
+package series
+
+import (
+	"math"
+	"math/cmplx"
+
+	"github.com/wildfunctions/genetic_series/pkg/expr"
+)
+
+// Degeneracy is what ProbeTrig finds of a candidate's terms.
+type Degeneracy int
+
+const (
+	NotDegenerate Degeneracy = iota
+	// DegenerateZero means every term is 0, as in Σ sin(πn)/n.
+	DegenerateZero
+	// DegenerateConstant means every term is the same nonzero value, as
+	// in Σ cos(2πn)/2, so the series diverges.
+	DegenerateConstant
+)
+
+func (d Degeneracy) String() string {
+	switch d {
+	case DegenerateZero:
+		return "zero"
+	case DegenerateConstant:
+		return "constant"
+	}
+	return "none"
+}
+
+// Bounds of the trig probe: the largest q of an angle pπ/q it detects,
+// the most indices it samples, and the least; its imaginary step; and how
+// close to pπ/q, in units of π and relative to the angle, an argument must
+// come to count as on it.
+const (
+	maxProbeDenominator = 12
+	maxProbeTerms       = 48
+	minProbeTerms       = 8
+	probeStep           = 1e-20
+	probeTolerance      = 1e-11
+)
+
+// ProbeTrig tells, in cheap float64 arithmetic and before any summing,
+// whether c's terms are identically zero or constant because every sin,
+// cos and tan in them has its argument at a rational angle pπ/q, q ≤ 12.
+// Random trees with trig ops are mostly such, from arguments like π·n or
+// 2π·n!, and each would otherwise cost a full evaluation to fail.
+//
+// Each argument is evaluated at n + ih in complex arithmetic (the complex
+// step), which gives its value and its slope in n at once and without
+// cancellation. A slope of pπ/q repeats the trig values every 2q indices
+// (q for even p), so the probe samples the lcm of those periods, at least
+// minProbeTerms and at most maxProbeTerms indices from Start. At each the
+// trig values are taken exactly at their angles, so sin(πn) is 0 rather
+// than 1e-16·n, and the term evaluated with them. It is a probe, not a
+// proof: the rest of the term, and arguments that are not affine in n,
+// are sampled only. A ratio is degenerate if either of its series is.
+// Candidates with no trig op, or with an argument off every such angle,
+// are NotDegenerate.
+func ProbeTrig(c *Candidate) Degeneracy {
+	if c.Over != nil {
+		if d := ProbeTrig(c.Series()); d != NotDegenerate {
+			return d
+		}
+		return ProbeTrig(c.Over)
+	}
+	if !containsTrig(c.Numerator) && !containsTrig(c.Denominator) {
+		return NotDegenerate
+	}
+
+	// The first term fixes the period from the slopes.
+	p := &trigProbe{period: 1}
+	first, ok := p.term(c, c.Start)
+	if !ok || p.period > maxProbeTerms {
+		return NotDegenerate
+	}
+	count := p.period * ((minProbeTerms + p.period - 1) / p.period)
+	for n := c.Start + 1; n < c.Start+count; n++ {
+		t, ok := p.term(c, n)
+		if !ok || t != first && math.Abs(t-first) > probeTolerance*math.Abs(first) {
+			return NotDegenerate
+		}
+	}
+	if first == 0 {
+		return DegenerateZero
+	}
+	return DegenerateConstant
+}
+
+// trigProbe is one ProbeTrig: period is the lcm of the periods of the trig
+// values implied by the argument slopes seen so far.
+type trigProbe struct {
+	period int64
+}
+
+// term is the term of c at n, with the trig values exact.
+func (p *trigProbe) term(c *Candidate, n int64) (float64, bool) {
+	z := complex(float64(n), probeStep)
+	num, ok := p.eval(c.Numerator, z)
+	if !ok {
+		return 0, false
+	}
+	den, ok := p.eval(c.Denominator, z)
+	if !ok || real(den) == 0 {
+		return 0, false
+	}
+	t := real(num) / real(den)
+	return t, !math.IsInf(t, 0) && !math.IsNaN(t)
+}
+
+// eval evaluates node at z. The ops smooth in n are extended to complex
+// arguments; any other node is evaluated in float64 at the real part and
+// taken as locally constant, which is right for the integer-valued ones
+// (factorials, floors, Fibonacci numbers) but fails if a trig op is below
+// it, since its exactness would be lost.
+func (p *trigProbe) eval(node expr.ExprNode, z complex128) (complex128, bool) {
+	switch n := node.(type) {
+	case *expr.VarNode:
+		return z, true
+	case *expr.UnaryNode:
+		switch n.Op {
+		case expr.OpSin, expr.OpCos, expr.OpTan:
+			a, ok := p.eval(n.Child, z)
+			if !ok {
+				return 0, false
+			}
+			return p.trig(n.Op, a)
+		case expr.OpNeg, expr.OpSqrt, expr.OpExp, expr.OpLn, expr.OpAbs, expr.OpSinh, expr.OpCosh, expr.OpTanh, expr.OpArctan:
+			a, ok := p.eval(n.Child, z)
+			if !ok {
+				return 0, false
+			}
+			return probeUnary(n.Op, a)
+		}
+	case *expr.BinaryNode:
+		switch n.Op {
+		case expr.OpAdd, expr.OpSub, expr.OpMul, expr.OpDiv, expr.OpPow:
+			a, ok := p.eval(n.Left, z)
+			if !ok {
+				return 0, false
+			}
+			b, ok := p.eval(n.Right, z)
+			if !ok {
+				return 0, false
+			}
+			return probeBinary(n.Op, a, b, n.Right)
+		}
+	}
+	if containsTrig(node) {
+		return 0, false
+	}
+	v, ok := node.EvalF64(real(z))
+	return complex(v, 0), ok
+}
+
+// trig is op at the argument a, which must be at a rational angle kπ/q:
+// the exact value there, with the imaginary part the complex step of it.
+func (p *trigProbe) trig(op expr.UnaryOp, a complex128) (complex128, bool) {
+	k, q, ok := rationalAngle(real(a))
+	if !ok {
+		return 0, false
+	}
+	slope := imag(a) / probeStep
+	sk, sq, ok := rationalAngle(slope)
+	if !ok {
+		return 0, false
+	}
+	period := 2 * sq
+	if sk%2 == 0 {
+		period = sq
+	}
+	p.period = lcm(p.period, period)
+	if p.period > maxProbeTerms {
+		return 0, false
+	}
+
+	s, c := sinPi(k, q), sinPi(2*k+q, 2*q) // cos x = sin(x + π/2)
+	d := imag(a)
+	switch op {
+	case expr.OpSin:
+		return complex(s, c*d), true
+	case expr.OpCos:
+		return complex(c, -s*d), true
+	}
+	if c == 0 {
+		return 0, false
+	}
+	return complex(s/c, d/(c*c)), true
+}
+
+// rationalAngle writes x as kπ/q with 1 ≤ q ≤ maxProbeDenominator, q least.
+func rationalAngle(x float64) (k, q int64, ok bool) {
+	r := x / math.Pi
+	if math.IsNaN(r) || math.Abs(r) > 1<<30 {
+		return 0, 0, false
+	}
+	for q = 1; q <= maxProbeDenominator; q++ {
+		rq := r * float64(q)
+		if k := math.Round(rq); math.Abs(rq-k) <= probeTolerance*max(1, math.Abs(rq)) {
+			return int64(k), q, true
+		}
+	}
+	return 0, 0, false
+}
+
+// sinPi is sin(kπ/q), exactly 0 or ±1 at the multiples of π/2.
+func sinPi(k, q int64) float64 {
+	k %= 2 * q
+	if k < 0 {
+		k += 2 * q
+	}
+	switch 2 * k {
+	case 0, 2 * q:
+		return 0
+	case q:
+		return 1
+	case 3 * q:
+		return -1
+	}
+	return math.Sin(math.Pi * float64(k) / float64(q))
+}
+
+// probeUnary is op at the complex a, failing where the real function does.
+func probeUnary(op expr.UnaryOp, a complex128) (complex128, bool) {
+	var r complex128
+	switch op {
+	case expr.OpNeg:
+		r = -a
+	case expr.OpSqrt:
+		if real(a) < 0 {
+			return 0, false
+		}
+		r = cmplx.Sqrt(a)
+	case expr.OpExp:
+		r = cmplx.Exp(a)
+	case expr.OpLn:
+		if real(a) <= 0 {
+			return 0, false
+		}
+		r = cmplx.Log(a)
+	case expr.OpAbs:
+		r = a
+		if real(a) < 0 {
+			r = -a
+		}
+	case expr.OpSinh:
+		r = cmplx.Sinh(a)
+	case expr.OpCosh:
+		r = cmplx.Cosh(a)
+	case expr.OpTanh:
+		r = cmplx.Tanh(a)
+	case expr.OpArctan:
+		r = cmplx.Atan(a)
+	}
+	return r, !cmplx.IsInf(r) && !cmplx.IsNaN(r)
+}
+
+// probeBinary is a op b at complex operands; right is b's node, so that an
+// integer exponent is raised by multiplication and keeps a negative base.
+func probeBinary(op expr.BinaryOp, a, b complex128, right expr.ExprNode) (complex128, bool) {
+	var r complex128
+	switch op {
+	case expr.OpAdd:
+		r = a + b
+	case expr.OpSub:
+		r = a - b
+	case expr.OpMul:
+		r = stepMul(a, b)
+	case expr.OpDiv:
+		if real(b) == 0 {
+			return 0, false
+		}
+		r = stepDiv(a, b)
+	case expr.OpPow:
+		if k, ok := right.(*expr.ConstNode); ok && k.Val >= -64 && k.Val <= 64 {
+			r = 1
+			for i := int64(0); i < k.Val; i++ {
+				r = stepMul(r, a)
+			}
+			for i := k.Val; i < 0; i++ {
+				if real(a) == 0 {
+					return 0, false
+				}
+				r = stepDiv(r, a)
+			}
+		} else {
+			if real(a) < 0 {
+				return 0, false
+			}
+			r = cmplx.Pow(a, b)
+		}
+	}
+	return r, !cmplx.IsInf(r) && !cmplx.IsNaN(r)
+}
+
+// stepMul and stepDiv are a·b and a/b to first order in the imaginary
+// parts. Full complex arithmetic would add their product, of order h², to
+// the real part, and turn the exact zero of a sin(πn) factor into 1e-40.
+func stepMul(a, b complex128) complex128 {
+	return complex(real(a)*real(b), real(a)*imag(b)+imag(a)*real(b))
+}
+
+func stepDiv(a, b complex128) complex128 {
+	return complex(real(a)/real(b), (imag(a)*real(b)-real(a)*imag(b))/(real(b)*real(b)))
+}
+
+// containsTrig reports whether node has a sin, cos or tan in it.
+func containsTrig(node expr.ExprNode) bool {
+	switch n := node.(type) {
+	case *expr.UnaryNode:
+		return n.Op == expr.OpSin || n.Op == expr.OpCos || n.Op == expr.OpTan || containsTrig(n.Child)
+	case *expr.BinaryNode:
+		return containsTrig(n.Left) || containsTrig(n.Right)
+	}
+	return false
+}
+
+func lcm(a, b int64) int64 {
+	x, y := a, b
+	for y != 0 {
+		x, y = y, x%y
+	}
+	return a / x * b
+}
@@ -0,0 +1,38 @@
+package series
+
+import "testing"
+
+func TestProbeTrig(t *testing.T) {
+	for _, tt := range []struct {
+		formula string
+		want    Degeneracy
+	}{
+		{`\sum_{n=1}^{\infty} \frac{\sin{(\pi n)}}{n^{2}}`, DegenerateZero},
+		{`\sum_{n=0}^{\infty} \frac{\cos{(2 \pi n)}}{2}`, DegenerateConstant},
+		{`\sum_{n=1}^{\infty} \frac{\sin{(2 \pi n!)} + \tan{(\pi n)}}{n}`, DegenerateZero},
+		{`\sum_{n=0}^{\infty} \frac{\cos{(\frac{2 \pi n}{3})} + \cos{(\frac{2 \pi (n + 1)}{3})} + \cos{(\frac{2 \pi (n + 2)}{3})}}{n + 1}`, NotDegenerate}, // 0 only up to rounding
+		{`\sum_{n=0}^{\infty} \frac{\sin{(\frac{\pi n}{2})}^{2} + \cos{(\frac{\pi n}{2})}^{2}}{1}`, DegenerateConstant},
+		{`\sum_{n=0}^{\infty} \frac{1 - \cos{(\pi n)}^{2}}{n + 1}`, DegenerateZero},
+		{`\sum_{n=1}^{\infty} \frac{\sin{(\sin{(\pi n)})}}{n}`, DegenerateZero},
+		{`\sum_{n=1}^{\infty} \frac{\frac{\sin{(\pi n)}}{n}}{\frac{1}{n!}}`, DegenerateZero},
+
+		// Periodic but not constant, off a rational angle, or no trig.
+		{`\sum_{n=0}^{\infty} \frac{\cos{(\pi n)}}{1}`, NotDegenerate},
+		{`\sum_{n=0}^{\infty} \frac{\sin{(\frac{\pi n}{6})}}{2^{n}}`, NotDegenerate},
+		{`\sum_{n=1}^{\infty} \frac{\sin{(n)}}{n^{2}}`, NotDegenerate},
+		{`\sum_{n=1}^{\infty} \frac{\sin{(\frac{\pi}{n})}}{n}`, NotDegenerate},
+		{`\sum_{n=1}^{\infty} \frac{\cos{(2 \pi n)}}{n^{2}}`, NotDegenerate},
+		{`\sum_{n=1}^{\infty} \frac{1}{1}`, NotDegenerate},
+		{`\sum_{n=1}^{\infty} \frac{1}{\sin{(\pi n)}}`, NotDegenerate}, // fails at every term
+	} {
+		if got := ProbeTrig(mustParse(t, tt.formula)); got != tt.want {
+			t.Errorf("%s: %v, want %v", tt.formula, got, tt.want)
+		}
+	}
+
+	// A ratio is degenerate if either of its series is.
+	c := mustParse(t, `\frac{\sum_{n=1}^{\infty} \frac{1}{n^{2}}}{\sum_{n=1}^{\infty} \frac{\sin{(\pi n)}}{n}}`)
+	if got := ProbeTrig(c); got != DegenerateZero {
+		t.Errorf("ratio over a zero series: %v", got)
+	}
+}
@@ -0,0 +1,188 @@
+// Package stats has the small set of nonparametric statistics used to
+// compare search configurations over repeated trials: results are few,
+// skewed and heavily tied, so nothing here assumes normality.
+package stats
+
+import (
+	"math"
+	"sort"
+)
+
+// Median returns the median of xs, or NaN if xs is empty. +Inf values sort
+// last, so a median over censored results (failures as +Inf) is finite as
+// long as at least half of them are.
+func Median(xs []float64) float64 {
+	if len(xs) == 0 {
+		return math.NaN()
+	}
+	s := append([]float64(nil), xs...)
+	sort.Float64s(s)
+	n := len(s)
+	if n%2 == 1 {
+		return s[n/2]
+	}
+	lo, hi := s[n/2-1], s[n/2]
+	if math.IsInf(hi, 1) {
+		return hi
+	}
+	return (lo + hi) / 2
+}
+
+// Wilson returns the 95% Wilson score interval for successes out of n,
+// which unlike the normal interval stays inside [0, 1] and is sensible at
+// 0 or n successes.
+func Wilson(successes, n int) (lo, hi float64) {
+	if n == 0 {
+		return 0, 1
+	}
+	const z = 1.959963984540054
+	p := float64(successes) / float64(n)
+	nf := float64(n)
+	d := 1 + z*z/nf
+	c := (p + z*z/(2*nf)) / d
+	h := z * math.Sqrt(p*(1-p)/nf+z*z/(4*nf*nf)) / d
+	return math.Max(0, c-h), math.Min(1, c+h)
+}
+
+// maxExactMannWhitney bounds the combined sample size for which
+// MannWhitney enumerates the exact rank-sum distribution.
+const maxExactMannWhitney = 60
+
+// MannWhitney is the two-sided Mann–Whitney U (Wilcoxon rank-sum) test of
+// whether values in a tend to differ from those in b. It returns U for a
+// (the number of pairs with a above b, ties counting half) and the p-value.
+// Ties get midranks; the p-value is exact, conditional on the ties, up to
+// maxExactMannWhitney values in all, and from the tie-corrected normal
+// approximation beyond. ±Inf are allowed, e.g. failures as +Inf.
+func MannWhitney(a, b []float64) (u, p float64) {
+	n1, n2 := len(a), len(b)
+	if n1 == 0 || n2 == 0 {
+		return 0, 1
+	}
+	type obs struct {
+		v     float64
+		fromA bool
+	}
+	all := make([]obs, 0, n1+n2)
+	for _, v := range a {
+		all = append(all, obs{v, true})
+	}
+	for _, v := range b {
+		all = append(all, obs{v, false})
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].v < all[j].v })
+
+	// Doubled midranks, so they are integers.
+	n := len(all)
+	ranks2 := make([]int, n)
+	tieTerm := 0.0 // Σ (t³ - t) over tie groups
+	for i := 0; i < n; {
+		j := i
+		for j < n && all[j].v == all[i].v {
+			j++
+		}
+		for k := i; k < j; k++ {
+			ranks2[k] = i + j + 1 // 2 × mean of ranks i+1..j
+		}
+		t := float64(j - i)
+		tieTerm += t*t*t - t
+		i = j
+	}
+	r2 := 0
+	for i, o := range all {
+		if o.fromA {
+			r2 += ranks2[i]
+		}
+	}
+	u = float64(r2)/2 - float64(n1*(n1+1))/2
+
+	if n <= maxExactMannWhitney {
+		return u, exactRankSumP(ranks2, n1, r2)
+	}
+	mean := float64(n1*n2) / 2
+	nf := float64(n)
+	sd := math.Sqrt(float64(n1*n2) / 12 * (nf + 1 - tieTerm/(nf*(nf-1))))
+	if sd == 0 {
+		return u, 1
+	}
+	z := (math.Abs(u-mean) - 0.5) / sd
+	return u, math.Min(1, math.Erfc(math.Max(z, 0)/math.Sqrt2))
+}
+
+// exactRankSumP is the two-sided p-value of a doubled rank sum of obs over
+// all ways of choosing k of the doubled ranks: the probability of a sum at
+// least as far from its mean.
+func exactRankSumP(ranks2 []int, k, obs int) float64 {
+	total := 0
+	for _, r := range ranks2 {
+		total += r
+	}
+	// count[j][s]: subsets of size j with doubled-rank sum s. The counts
+	// reach C(60, 30) ≈ 1.2e17, past exact float64 integers, but only their
+	// ratio matters.
+	count := make([][]float64, k+1)
+	for j := range count {
+		count[j] = make([]float64, total+1)
+	}
+	count[0][0] = 1
+	for _, r := range ranks2 {
+		for j := k; j >= 1; j-- {
+			for s := total; s >= r; s-- {
+				count[j][s] += count[j-1][s-r]
+			}
+		}
+	}
+	mean := float64(total) * float64(k) / float64(len(ranks2))
+	dev := math.Abs(float64(obs)-mean) - 1e-9
+	var tail, all float64
+	for s, c := range count[k] {
+		all += c
+		if math.Abs(float64(s)-mean) >= dev {
+			tail += c
+		}
+	}
+	return math.Min(1, tail/all)
+}
+
+// FisherExact is the two-sided Fisher exact test of whether success rates
+// differ between a (s1 of n1) and b (s2 of n2): the probability, given the
+// margins, of a table no more likely than the one observed.
+func FisherExact(s1, n1, s2, n2 int) float64 {
+	successes, n := s1+s2, n1+n2
+	prob := func(x int) float64 {
+		return math.Exp(lchoose(n1, x) + lchoose(n2, successes-x) - lchoose(n, successes))
+	}
+	pObs := prob(s1)
+	p := 0.0
+	for x := max(0, successes-n2); x <= min(successes, n1); x++ {
+		if px := prob(x); px <= pObs*(1+1e-7) {
+			p += px
+		}
+	}
+	return math.Min(1, p)
+}
+
+func lchoose(n, k int) float64 {
+	a, _ := math.Lgamma(float64(n + 1))
+	b, _ := math.Lgamma(float64(k + 1))
+	c, _ := math.Lgamma(float64(n - k + 1))
+	return a - b - c
+}
+
+// Holm adjusts p-values for testing them all at once (Holm–Bonferroni):
+// comparing each adjusted value against α controls the chance of any false
+// positive at α. The result is in the order of ps.
+func Holm(ps []float64) []float64 {
+	order := make([]int, len(ps))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return ps[order[a]] < ps[order[b]] })
+	adj := make([]float64, len(ps))
+	running := 0.0
+	for rank, i := range order {
+		running = math.Max(running, math.Min(1, float64(len(ps)-rank)*ps[i]))
+		adj[i] = running
+	}
+	return adj
+}
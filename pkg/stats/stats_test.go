@@ -0,0 +1,94 @@
+package stats
+
+import (
+	"math"
+	"testing"
+)
+
+func near(a, b float64) bool { return math.Abs(a-b) < 1e-4 }
+
+func TestMedian(t *testing.T) {
+	inf := math.Inf(1)
+	cases := []struct {
+		xs   []float64
+		want float64
+	}{
+		{[]float64{3, 1, 2}, 2},
+		{[]float64{4, 1, 3, 2}, 2.5},
+		{[]float64{1, inf, 2}, 2},
+		{[]float64{1, inf, inf, 2}, inf},
+	}
+	for _, tc := range cases {
+		if got := Median(tc.xs); got != tc.want {
+			t.Errorf("Median(%v) = %v, want %v", tc.xs, got, tc.want)
+		}
+	}
+	if !math.IsNaN(Median(nil)) {
+		t.Error("Median(nil) is not NaN")
+	}
+}
+
+func TestWilson(t *testing.T) {
+	if lo, hi := Wilson(0, 10); lo != 0 || !near(hi, 0.2775) {
+		t.Errorf("Wilson(0, 10) = %v, %v; want 0, 0.2775", lo, hi)
+	}
+	if lo, hi := Wilson(5, 10); !near(lo, 0.2366) || !near(hi, 0.7634) {
+		t.Errorf("Wilson(5, 10) = %v, %v; want 0.2366, 0.7634", lo, hi)
+	}
+}
+
+func TestMannWhitney(t *testing.T) {
+	// Complete separation of 5 and 5: exact p = 2 / C(10, 5).
+	u, p := MannWhitney([]float64{1, 2, 3, 4, 5}, []float64{6, 7, 8, 9, 10})
+	if u != 0 || !near(p, 2.0/252) {
+		t.Errorf("separated: U = %v, p = %v; want 0, %v", u, p, 2.0/252)
+	}
+	u, _ = MannWhitney([]float64{6, 7, 8, 9, 10}, []float64{1, 2, 3, 4, 5})
+	if u != 25 {
+		t.Errorf("reversed: U = %v, want 25", u)
+	}
+	if _, p := MannWhitney([]float64{1, 1, 1}, []float64{1, 1, 1}); p != 1 {
+		t.Errorf("all tied: p = %v, want 1", p)
+	}
+	// Failures as +Inf tie with each other and rank last.
+	inf := math.Inf(1)
+	u, p = MannWhitney([]float64{3, 4, inf}, []float64{inf, inf, inf})
+	if u != 1.5 || p > 0.5 {
+		t.Errorf("censored: U = %v, p = %v; want 1.5 and p < 0.5", u, p)
+	}
+	// Past the exact limit, the normal approximation.
+	var a, b []float64
+	for i := 0; i < 40; i++ {
+		a = append(a, float64(i))
+		b = append(b, float64(i+40))
+	}
+	if _, p := MannWhitney(a, b); p > 1e-10 {
+		t.Errorf("large separated: p = %v", p)
+	}
+	if _, p := MannWhitney(a, a); p < 0.9 {
+		t.Errorf("large identical: p = %v", p)
+	}
+}
+
+func TestFisherExact(t *testing.T) {
+	if p := FisherExact(4, 4, 0, 4); !near(p, 2.0/70) {
+		t.Errorf("4/4 vs 0/4: p = %v, want %v", p, 2.0/70)
+	}
+	if p := FisherExact(3, 4, 1, 4); !near(p, 0.4857) {
+		t.Errorf("3/4 vs 1/4: p = %v, want 0.4857", p)
+	}
+	if p := FisherExact(2, 5, 2, 5); !near(p, 1) {
+		t.Errorf("equal rates: p = %v, want 1", p)
+	}
+}
+
+func TestHolm(t *testing.T) {
+	got := Holm([]float64{0.01, 0.04, 0.03})
+	want := []float64{0.03, 0.06, 0.06}
+	for i := range want {
+		if !near(got[i], want[i]) {
+			t.Errorf("Holm = %v, want %v", got, want)
+			break
+		}
+	}
+}
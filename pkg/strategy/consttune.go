@@ -2,11 +2,12 @@ package strategy
 
 import (
 	"fmt"
-	"math/rand"
+	"math"
 	"sort"
 
 	"github.com/wildfunctions/genetic_series/pkg/expr"
 	"github.com/wildfunctions/genetic_series/pkg/pool"
+	"github.com/wildfunctions/genetic_series/pkg/random"
 	"github.com/wildfunctions/genetic_series/pkg/series"
 )
 
@@ -14,6 +15,7 @@ const (
 	constTuneEliteRate      = 0.10 // top 10% carried over (higher than tournament to preserve good combos)
 	constTuneTournamentSize = 5
 	constTuneWideRate       = 0.10 // fraction of non-elite that get wide exploration
+	guidedExploreRate       = 0.25 // fraction of guided steps that ignore the error's sign
 )
 
 func init() {
@@ -23,11 +25,36 @@ func init() {
 // ConstantTuneStrategy freezes the expression tree structure and only varies
 // integer constants, using hill-climbing with tournament selection.
 type ConstantTuneStrategy struct {
-	seed *series.Candidate
+	seed         *series.Candidate
+	skeletonRate float64     // see SetSkeletonRate
+	guide        *errorGuide // nil unless SetGuided
+	origins      []Origin    // see Origins
 }
 
 func (s *ConstantTuneStrategy) Name() string { return "consttune" }
 
+// Origins reports the perturbation behind each child of the last Evolve:
+// OpWide, or the kind of its first small step.
+func (s *ConstantTuneStrategy) Origins() []Origin { return s.origins }
+
+func (s *ConstantTuneStrategy) Elites(n int) int { return eliteCount(n, constTuneEliteRate) }
+
+// SetSkeletonRate splits the hill climb's small perturbations by role: a
+// fraction rate moves a structural integer or the start (skeletonMutate),
+// the rest a coefficient (coefficientMutate). At 0, the default, they
+// move any constant alike, as they always have.
+func (s *ConstantTuneStrategy) SetSkeletonRate(rate float64) { s.skeletonRate = rate }
+
+// SetGuided turns on error-guided perturbation: the hill climb steps a
+// constant in the direction that moves the parent's sum toward the target
+// (see Fitness.Error), rather than up or down at random.
+func (s *ConstantTuneStrategy) SetGuided(on bool) {
+	s.guide = nil
+	if on {
+		s.guide = &errorGuide{}
+	}
+}
+
 // SetSeedFormula parses a LaTeX formula and stores it as the seed candidate.
 func (s *ConstantTuneStrategy) SetSeedFormula(latex string) error {
 	c, err := series.ParseCandidateLatex(latex)
@@ -38,7 +65,7 @@ func (s *ConstantTuneStrategy) SetSeedFormula(latex string) error {
 	return nil
 }
 
-func (s *ConstantTuneStrategy) Initialize(_ pool.Pool, rng *rand.Rand, popSize int) []*series.Candidate {
+func (s *ConstantTuneStrategy) Initialize(_ pool.Pool, rng random.Rand, popSize int) []*series.Candidate {
 	pop := make([]*series.Candidate, popSize)
 
 	// Clone 0 is the unmodified original (baseline).
@@ -61,10 +88,37 @@ func (s *ConstantTuneStrategy) Evolve(
 	population []*series.Candidate,
 	fitnesses []series.Fitness,
 	_ pool.Pool,
-	rng *rand.Rand,
+	rng random.Rand,
 ) []*series.Candidate {
+	s.guide.observe(fitnesses)
+	next, origins := constTuneEvolve(population, fitnesses, rng, s.skeletonRate, s.guide, treeCodec)
+	s.origins = origins
+	return next
+}
+
+func (s *ConstantTuneStrategy) EvolveGenomes(
+	population []series.Genome,
+	fitnesses []series.Fitness,
+	_ pool.Pool,
+	rng random.Rand,
+) []series.Genome {
+	s.guide.observe(fitnesses)
+	next, origins := constTuneEvolve(population, fitnesses, rng, s.skeletonRate, s.guide, genomeCodec)
+	s.origins = origins
+	return next
+}
+
+func constTuneEvolve[G any](
+	population []G,
+	fitnesses []series.Fitness,
+	rng random.Rand,
+	skeletonRate float64,
+	guide *errorGuide,
+	cd codec[G],
+) ([]G, []Origin) {
 	n := len(population)
-	next := make([]*series.Candidate, 0, n)
+	next := make([]G, 0, n)
+	origins := make([]Origin, 0, n)
 
 	// Sort indices by fitness (descending).
 	indices := make([]int, n)
@@ -76,101 +130,210 @@ func (s *ConstantTuneStrategy) Evolve(
 	})
 
 	// Elitism: carry over top 10%.
-	eliteCount := int(float64(n) * constTuneEliteRate)
-	if eliteCount < 1 {
-		eliteCount = 1
-	}
-	for i := 0; i < eliteCount; i++ {
-		next = append(next, population[indices[i]].Clone())
+	elites := eliteCount(n, constTuneEliteRate)
+	for i := 0; i < elites; i++ {
+		next = append(next, cd.keep(population[indices[i]]))
+		origins = append(origins, Origin{Op: OpElite, Parent: fitnesses[indices[i]].Combined})
 	}
 
 	// Fill rest via tournament selection + const perturbation.
-	wideCount := int(float64(n-eliteCount) * constTuneWideRate)
+	wideCount := int(float64(n-elites) * constTuneWideRate)
 	nonEliteFilled := 0
 
 	for len(next) < n {
-		parent := constTuneSelect(population, fitnesses, rng)
-		child := parent.Clone()
+		pi := constTuneSelect(fitnesses, rng)
+		child := cd.load(population[pi])
+		origin := Origin{Parent: fitnesses[pi].Combined}
 
 		if nonEliteFilled < wideCount {
 			// Wide exploration: replace a random constant with a value in [-100, 100].
 			replaceRandomConst(child, rng, 100)
+			origin.Op = OpWide
 		} else {
-			// Normal hill-climb: 1-2 small perturbations.
+			// Normal hill-climb: 1-2 small perturbations, the child
+			// attributed to the first.
 			nPerturbs := rng.Intn(2) + 1
 			for j := 0; j < nPerturbs; j++ {
-				constPerturb(child.Numerator, rng)
+				if skeletonRate > 0 {
+					op := OpGuided
+					switch {
+					case rng.Float64() < skeletonRate:
+						skeletonMutate(child, rng)
+						op = MutSkeleton.String()
+					case guide != nil:
+						guide.perturb(fitnesses[pi].Error, true, rng, numeratorOf(child), denominatorOf(child))
+					default:
+						coefficientMutate(child, rng)
+						op = MutCoefficient.String()
+					}
+					if origin.Op == "" {
+						origin.Op = op
+					}
+					continue
+				}
+				if guide != nil {
+					origin.Op = OpGuided
+					guide.perturb(fitnesses[pi].Error, false, rng, numeratorOf(child))
+					if rng.Float64() < 0.5 {
+						guide.perturb(fitnesses[pi].Error, false, rng, denominatorOf(child))
+					}
+					continue
+				}
+				origin.Op = MutConstPerturb.String()
+				child.Numerator = constPerturb(child.Numerator, rng)
 				if rng.Float64() < 0.5 {
-					constPerturb(child.Denominator, rng)
+					child.Denominator = constPerturb(child.Denominator, rng)
 				}
 			}
 		}
 
 		// Simplify (constant folding may collapse sub-expressions).
-		child.Numerator = expr.SimplifyBigFloat(child.Numerator, 128)
-		child.Denominator = expr.SimplifyBigFloat(child.Denominator, 128)
+		child = simplifyCandidate(child)
 
-		next = append(next, child)
+		next = append(next, cd.store(child))
+		origins = append(origins, origin)
 		nonEliteFilled++
 	}
 
-	return next[:n]
+	return next[:n], origins[:n]
 }
 
-// constTuneSelect performs tournament selection for constant tuning.
-func constTuneSelect(pop []*series.Candidate, fitnesses []series.Fitness, rng *rand.Rand) *series.Candidate {
-	bestIdx := rng.Intn(len(pop))
+// constTuneSelect performs tournament selection for constant tuning,
+// returning the winner's index.
+func constTuneSelect(fitnesses []series.Fitness, rng random.Rand) int {
+	bestIdx := rng.Intn(len(fitnesses))
 	bestFit := fitnesses[bestIdx].Combined
 
 	for i := 1; i < constTuneTournamentSize; i++ {
-		idx := rng.Intn(len(pop))
+		idx := rng.Intn(len(fitnesses))
 		if fitnesses[idx].Combined > bestFit {
 			bestIdx = idx
 			bestFit = fitnesses[idx].Combined
 		}
 	}
 
-	return pop[bestIdx]
+	return bestIdx
 }
 
 // perturbConstWide perturbs a random constant in the candidate by ±1 to ±maxDelta.
-func perturbConstWide(c *series.Candidate, rng *rand.Rand, maxDelta int) {
+func perturbConstWide(c *series.Candidate, rng random.Rand, maxDelta int) {
 	// Pick numerator or denominator.
-	tree := c.Numerator
+	tree := &c.Numerator
 	if rng.Float64() < 0.5 {
-		tree = c.Denominator
+		tree = &c.Denominator
 	}
 
-	consts := collectConsts(tree)
+	consts := expr.ConstIndices(*tree)
 	if len(consts) == 0 {
 		return
 	}
-	target := consts[rng.Intn(len(consts))]
+	idx := consts[rng.Intn(len(consts))]
 	delta := int64(rng.Intn(maxDelta) + 1)
 	if rng.Float64() < 0.5 {
 		delta = -delta
 	}
-	target.Val += delta
-	if target.Val == 0 {
-		target.Val = 1
-	}
+	*tree = expr.ReplaceAt(*tree, idx, nonZeroConst(expr.NodeAt(*tree, idx).(*expr.ConstNode).Val+delta))
 }
 
 // replaceRandomConst replaces a random constant in the candidate with a new value in [-maxVal, maxVal].
-func replaceRandomConst(c *series.Candidate, rng *rand.Rand, maxVal int) {
-	tree := c.Numerator
+func replaceRandomConst(c *series.Candidate, rng random.Rand, maxVal int) {
+	tree := &c.Numerator
 	if rng.Float64() < 0.5 {
-		tree = c.Denominator
+		tree = &c.Denominator
 	}
 
-	consts := collectConsts(tree)
+	consts := expr.ConstIndices(*tree)
 	if len(consts) == 0 {
 		return
 	}
-	target := consts[rng.Intn(len(consts))]
+	idx := consts[rng.Intn(len(consts))]
 	newVal := int64(rng.Intn(2*maxVal+1)) - int64(maxVal)
-	if newVal == 0 {
-		newVal = 1
+	*tree = expr.ReplaceAt(*tree, idx, nonZeroConst(newVal))
+}
+
+// errorGuide steers consttune's small perturbations by the parent's signed
+// error. Raising a numerator constant raises the sum and raising a
+// denominator one lowers it (up to the sign flips of expr.ConstPolarities),
+// so a sum that overshoots steps its numerator constants down and its
+// denominator constants up. The trend of the best candidate's error sets
+// the step size: once it crosses the target, steps shrink to 1.
+type errorGuide struct {
+	last    float64 // best candidate's Error in the previous generation
+	maxStep int     // largest step size, 1 just after the best crossed the target
+}
+
+// observe records the trend of the best error in fitnesses. A nil guide
+// ignores it.
+func (g *errorGuide) observe(fitnesses []series.Fitness) {
+	if g == nil || len(fitnesses) == 0 {
+		return
+	}
+	best := 0
+	for i, f := range fitnesses {
+		if f.Combined > fitnesses[best].Combined {
+			best = i
+		}
+	}
+	cur := fitnesses[best].Error
+	g.maxStep = 3
+	if g.last*cur < 0 {
+		g.maxStep = 1
+	}
+	g.last = cur
+}
+
+// guidedTree is a tree perturb may change, with the sign of the sum's
+// response to the tree's value: +1 for a numerator, -1 for a denominator.
+type guidedTree struct {
+	root *expr.ExprNode
+	sign int
+}
+
+func numeratorOf(c *series.Candidate) guidedTree   { return guidedTree{&c.Numerator, 1} }
+func denominatorOf(c *series.Candidate) guidedTree { return guidedTree{&c.Denominator, -1} }
+
+// perturb moves one constant of trees, a coefficient if coeffOnly, by up
+// to maxStep toward the target given the parent's signed error relErr. A
+// fraction guidedExploreRate of steps, and every step when relErr is 0 or
+// not finite, go either way, so a wrong reading of a tree's monotonicity
+// cannot pin a constant.
+func (g *errorGuide) perturb(relErr float64, coeffOnly bool, rng random.Rand, trees ...guidedTree) {
+	type site struct {
+		root *expr.ExprNode
+		idx  int
+		dir  int // sign of the step that raises the sum
+	}
+	var sites []site
+	for _, t := range trees {
+		all := expr.ConstIndices(*t.root)
+		pol := expr.ConstPolarities(*t.root)
+		keep := all
+		if coeffOnly {
+			_, keep = expr.ConstIndicesByRole(*t.root)
+		}
+		for k, j := 0, 0; k < len(all) && j < len(keep); k++ {
+			if all[k] == keep[j] {
+				sites = append(sites, site{t.root, all[k], pol[k] * t.sign})
+				j++
+			}
+		}
+	}
+	if len(sites) == 0 {
+		return
+	}
+	s := sites[rng.Intn(len(sites))]
+	maxStep := g.maxStep
+	if maxStep < 1 {
+		maxStep = 3
+	}
+	delta := int64(rng.Intn(maxStep) + 1)
+	switch {
+	case relErr == 0 || math.IsNaN(relErr) || math.IsInf(relErr, 0) || rng.Float64() < guidedExploreRate:
+		if rng.Float64() < 0.5 {
+			delta = -delta
+		}
+	case (relErr > 0) == (s.dir > 0): // overshoot and raising raises the sum, or the reverse
+		delta = -delta
 	}
-	target.Val = newVal
+	*s.root = addToConst(*s.root, s.idx, delta)
 }
@@ -1,17 +1,21 @@
 package strategy
 
 import (
-	"math/rand"
-
 	"github.com/wildfunctions/genetic_series/pkg/expr"
+	"github.com/wildfunctions/genetic_series/pkg/random"
 	"github.com/wildfunctions/genetic_series/pkg/series"
 )
 
 // CrossoverCandidates performs subtree crossover between two candidates,
-// returning two new offspring. Both numerator and denominator trees are crossed.
-func CrossoverCandidates(a, b *series.Candidate, rng *rand.Rand) (*series.Candidate, *series.Candidate) {
+// returning two new offspring. Both numerator and denominator trees are
+// crossed, and of two ratios the divisors are crossed the same way; a and
+// b are not modified.
+func CrossoverCandidates(a, b *series.Candidate, rng random.Rand) (*series.Candidate, *series.Candidate) {
 	c1 := a.Clone()
 	c2 := b.Clone()
+	if a.Over != nil && b.Over != nil {
+		c1.Over, c2.Over = CrossoverCandidates(a.Over, b.Over, rng)
+	}
 
 	// Cross numerators
 	c1.Numerator, c2.Numerator = crossoverTrees(c1.Numerator, c2.Numerator, rng)
@@ -22,20 +26,13 @@ func CrossoverCandidates(a, b *series.Candidate, rng *rand.Rand) (*series.Candid
 	return c1, c2
 }
 
-// crossoverTrees swaps random subtrees between two expression trees.
-func crossoverTrees(a, b expr.ExprNode, rng *rand.Rand) (expr.ExprNode, expr.ExprNode) {
-	nodesA := collectNodes(a)
-	nodesB := collectNodes(b)
-
-	if len(nodesA) == 0 || len(nodesB) == 0 {
-		return a, b
-	}
-
-	idxA := rng.Intn(len(nodesA))
-	idxB := rng.Intn(len(nodesB))
-
-	// Swap the subtrees
-	*nodesA[idxA], *nodesB[idxB] = *nodesB[idxB], *nodesA[idxA]
+// crossoverTrees swaps random subtrees between two expression trees,
+// returning new trees and leaving a and b untouched.
+func crossoverTrees(a, b expr.ExprNode, rng random.Rand) (expr.ExprNode, expr.ExprNode) {
+	idxA := rng.Intn(a.NodeCount())
+	idxB := rng.Intn(b.NodeCount())
 
-	return a, b
+	subA := expr.NodeAt(a, idxA)
+	subB := expr.NodeAt(b, idxB)
+	return expr.ReplaceAt(a, idxA, subB), expr.ReplaceAt(b, idxB, subA)
 }
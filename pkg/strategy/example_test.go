@@ -0,0 +1,75 @@
+package strategy_test
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/wildfunctions/genetic_series/pkg/pool"
+	"github.com/wildfunctions/genetic_series/pkg/random"
+	"github.com/wildfunctions/genetic_series/pkg/series"
+	"github.com/wildfunctions/genetic_series/pkg/strategy"
+)
+
+// mutateAll keeps the fittest half of each generation and refills the
+// rest with mutated copies of it.
+type mutateAll struct {
+	seed *series.Candidate
+}
+
+func (s *mutateAll) Name() string { return "mutateall" }
+
+// Elites makes mutateAll a strategy.Elitist: the engine reuses the scores
+// of the half it carries over.
+func (s *mutateAll) Elites(n int) int { return n / 2 }
+
+// SetSeedFormula makes mutateAll a strategy.Seedable, so it accepts
+// engine.Config.SeedFormula.
+func (s *mutateAll) SetSeedFormula(latex string) error {
+	c, err := series.ParseCandidateLatex(latex)
+	s.seed = c
+	return err
+}
+
+func (s *mutateAll) Initialize(p pool.Pool, rng random.Rand, popSize int) []*series.Candidate {
+	pop := make([]*series.Candidate, popSize)
+	for i := range pop {
+		if s.seed != nil {
+			pop[i] = s.seed.Clone()
+			strategy.MutateCandidate(pop[i], p, rng)
+			continue
+		}
+		pop[i] = &series.Candidate{Numerator: p.RandomTree(rng, 3), Denominator: p.RandomTree(rng, 3)}
+	}
+	return pop
+}
+
+func (s *mutateAll) Evolve(population []*series.Candidate, fitnesses []series.Fitness, p pool.Pool, rng random.Rand) []*series.Candidate {
+	order := make([]int, len(population))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return fitnesses[order[a]].Combined > fitnesses[order[b]].Combined })
+
+	keep := s.Elites(len(population))
+	next := make([]*series.Candidate, len(population))
+	for i := range next {
+		next[i] = population[order[i%max(keep, 1)]].Clone()
+		if i >= keep {
+			strategy.MutateCandidate(next[i], p, rng) // on the clone: the engine keeps the parents
+		}
+	}
+	return next
+}
+
+func ExampleRegister() {
+	strategy.Register("mutateall", func() strategy.Strategy { return &mutateAll{} })
+
+	s, err := strategy.Get("mutateall") // or engine.Config{Strategy: "mutateall"}
+	if err != nil {
+		panic(err)
+	}
+	_, seedable := s.(strategy.Seedable)
+	_, elitist := s.(strategy.Elitist)
+	fmt.Println(s.Name(), seedable, elitist)
+	// Output: mutateall true true
+}
@@ -0,0 +1,36 @@
+package strategy
+
+import (
+	"github.com/wildfunctions/genetic_series/pkg/pool"
+	"github.com/wildfunctions/genetic_series/pkg/random"
+	"github.com/wildfunctions/genetic_series/pkg/series"
+)
+
+// GenomeStrategy is implemented by strategies that can evolve a population
+// held as compact genomes, decoding only the candidates they breed from.
+// Given the same RNG state it produces the same offspring as Evolve.
+type GenomeStrategy interface {
+	EvolveGenomes(population []series.Genome, fitnesses []series.Fitness, p pool.Pool, rng random.Rand) []series.Genome
+}
+
+// codec adapts an evolution loop to a population representation. load
+// returns a private, mutable copy of a member, store packs a finished child,
+// and keep carries a member over unchanged.
+type codec[G any] struct {
+	load  func(G) *series.Candidate
+	store func(*series.Candidate) G
+	keep  func(G) G
+}
+
+var treeCodec = codec[*series.Candidate]{
+	load:  (*series.Candidate).Clone,
+	store: func(c *series.Candidate) *series.Candidate { return c },
+	keep:  (*series.Candidate).Clone,
+}
+
+// Genomes are never mutated in place, so keeping one is free.
+var genomeCodec = codec[series.Genome]{
+	load:  series.Genome.MustDecode,
+	store: series.EncodeCandidate,
+	keep:  func(g series.Genome) series.Genome { return g },
+}
@@ -1,12 +1,12 @@
 package strategy
 
 import (
-	"math/rand"
 	"sort"
 
-	"github.com/wildfunctions/genetic_series/pkg/expr"
 	"github.com/wildfunctions/genetic_series/pkg/pool"
+	"github.com/wildfunctions/genetic_series/pkg/random"
 	"github.com/wildfunctions/genetic_series/pkg/series"
+	"github.com/wildfunctions/genetic_series/pkg/tabu"
 )
 
 const (
@@ -21,14 +21,46 @@ func init() {
 // HillClimbStrategy implements directed hill-climbing with population.
 // For each candidate: clone + directed mutation, keep whichever is better.
 // Periodically injects random candidates to escape local optima.
-type HillClimbStrategy struct{}
+type HillClimbStrategy struct {
+	failed     *tabu.List    // structures known to fail; children on it are re-bred
+	repairRate float64       // see SetRepairRate
+	mutation   mutationRates // see SetRestart and SetSizeFairRate
+	ratio      bool          // see SetRatio
+	origins    []Origin      // see Origins
+}
 
 func (s *HillClimbStrategy) Name() string { return "hillclimb" }
 
-func (s *HillClimbStrategy) Initialize(p pool.Pool, rng *rand.Rand, popSize int) []*series.Candidate {
+func (s *HillClimbStrategy) Elites(int) int { return 1 }
+
+func (s *HillClimbStrategy) SetTabu(failed *tabu.List) { s.failed = failed }
+
+// SetRepairRate makes children with a domain fault in their leading terms
+// be repaired with probability rate rather than bred as they are (see
+// repairChild).
+func (s *HillClimbStrategy) SetRepairRate(rate float64) { s.repairRate = rate }
+
+// SetRestart makes a fraction rate of mutations regenerate one tree of the
+// child, side, and keep the other (see restartTree).
+func (s *HillClimbStrategy) SetRestart(rate float64, side RestartSide) {
+	s.mutation.restart, s.mutation.restartSide = rate, side
+}
+
+// SetSizeFairRate makes a fraction rate of the other mutations size-fair
+// ones (MutSizeFair), which MutateCandidate never draws.
+func (s *HillClimbStrategy) SetSizeFairRate(rate float64) { s.mutation.sizeFair = rate }
+
+// SetRatio makes the population ratios of two series, every candidate
+// drawn with a random series to divide it by (see Pairing).
+func (s *HillClimbStrategy) SetRatio(on bool) { s.ratio = on }
+
+// Origins reports the mutation behind each child of the last Evolve.
+func (s *HillClimbStrategy) Origins() []Origin { return s.origins }
+
+func (s *HillClimbStrategy) Initialize(p pool.Pool, rng random.Rand, popSize int) []*series.Candidate {
 	pop := make([]*series.Candidate, popSize)
 	for i := range pop {
-		pop[i] = randomCandidate(p, rng, hillclimbMaxDepth)
+		pop[i] = randomCandidate(p, rng, hillclimbMaxDepth, s.ratio)
 	}
 	return pop
 }
@@ -37,23 +69,59 @@ func (s *HillClimbStrategy) Evolve(
 	population []*series.Candidate,
 	fitnesses []series.Fitness,
 	p pool.Pool,
-	rng *rand.Rand,
+	rng random.Rand,
 ) []*series.Candidate {
+	next, origins := hillClimbEvolve(population, fitnesses, p, rng, treeCodec, s.failed, s.repairRate, s.mutation, s.ratio)
+	s.origins = origins
+	return next
+}
+
+func (s *HillClimbStrategy) EvolveGenomes(
+	population []series.Genome,
+	fitnesses []series.Fitness,
+	p pool.Pool,
+	rng random.Rand,
+) []series.Genome {
+	next, origins := hillClimbEvolve(population, fitnesses, p, rng, genomeCodec, s.failed, s.repairRate, s.mutation, s.ratio)
+	s.origins = origins
+	return next
+}
+
+func hillClimbEvolve[G any](
+	population []G,
+	fitnesses []series.Fitness,
+	p pool.Pool,
+	rng random.Rand,
+	cd codec[G],
+	failed *tabu.List,
+	repairRate float64,
+	mr mutationRates,
+	ratio bool,
+) ([]G, []Origin) {
 	n := len(population)
-	next := make([]*series.Candidate, n)
+	next := make([]G, n)
+	origins := make([]Origin, n)
 
 	for i := 0; i < n; i++ {
-		// Clone and mutate
-		child := population[i].Clone()
-		MutateCandidate(child, p, rng)
-		child.Numerator = expr.SimplifyBigFloat(child.Numerator, 128)
-		child.Denominator = expr.SimplifyBigFloat(child.Denominator, 128)
+		// Clone and mutate, trying again if the child is known to fail
+		child, m := mutatedChild(cd.load(population[i]), p, rng, repairRate, mr)
+		origins[i] = Origin{Op: m.String(), Parent: fitnesses[i].Combined}
+		for r := 0; isTabu(failed, child); r++ {
+			if r == tabuRetries {
+				child = randomCandidate(p, rng, hillclimbMaxDepth, ratio)
+				origins[i] = randomOrigin
+				break
+			}
+			child, m = mutatedChild(cd.load(population[i]), p, rng, repairRate, mr)
+			origins[i].Op = m.String()
+		}
 
 		if !candidateOK(child) {
-			child = randomCandidate(p, rng, hillclimbMaxDepth)
+			child = randomCandidate(p, rng, hillclimbMaxDepth, ratio)
+			origins[i] = randomOrigin
 		}
 
-		next[i] = child
+		next[i] = cd.store(child)
 	}
 
 	// Sort by fitness to identify worst candidates for injection
@@ -76,12 +144,22 @@ func (s *HillClimbStrategy) Evolve(
 	}
 	for i := 0; i < injectionCount && i < n; i++ {
 		idx := ranked[i].idx
-		next[idx] = randomCandidate(p, rng, hillclimbMaxDepth)
+		next[idx] = cd.store(randomCandidate(p, rng, hillclimbMaxDepth, ratio))
+		origins[idx] = randomOrigin
 	}
 
 	// Elitism: keep the best from the old generation if it's better
 	bestIdx := ranked[len(ranked)-1].idx
-	next[bestIdx] = population[bestIdx].Clone()
+	next[bestIdx] = cd.keep(population[bestIdx])
+	origins[bestIdx] = Origin{Op: OpElite, Parent: fitnesses[bestIdx].Combined}
 
-	return next
+	return next, origins
+}
+
+// mutatedChild mutates (see mutationRates.mutate) and simplifies c, a
+// private copy of a parent, and repairs a domain fault in it at
+// repairRate (see repairChild). It returns the mutation applied too.
+func mutatedChild(c *series.Candidate, p pool.Pool, rng random.Rand, repairRate float64, mr mutationRates) (*series.Candidate, MutationType) {
+	m := mr.mutate(c, p, rng)
+	return repairChild(simplifyCandidate(c), repairRate, rng), m
 }
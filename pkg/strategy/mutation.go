@@ -1,10 +1,11 @@
 package strategy
 
 import (
-	"math/rand"
+	"fmt"
 
 	"github.com/wildfunctions/genetic_series/pkg/expr"
 	"github.com/wildfunctions/genetic_series/pkg/pool"
+	"github.com/wildfunctions/genetic_series/pkg/random"
 	"github.com/wildfunctions/genetic_series/pkg/series"
 )
 
@@ -12,32 +13,352 @@ import (
 type MutationType int
 
 const (
-	MutPoint         MutationType = iota // replace a random node with a new one
-	MutSubtree                           // replace a random subtree with a new random tree
-	MutHoist                             // replace tree with one of its subtrees
-	MutConstPerturb                      // adjust a constant value by ±1-3
-	MutGrow                              // wrap a leaf in a new operation
-	MutShrink                            // replace a node with one of its children
+	MutPoint        MutationType = iota // replace a random node with a new one
+	MutSubtree                          // replace a random subtree with a new random tree
+	MutHoist                            // replace tree with one of its subtrees
+	MutConstPerturb                     // adjust a constant value by ±1-3
+	MutGrow                             // wrap a leaf in a new operation
+	MutShrink                           // replace a node with one of its children
+	MutSizeFair                         // replace a random subtree with a new one of about its size (not in MutAny; see SizeFairRated)
+	MutStart                            // move the start index (see mutateStart)
+	MutOffset                           // add, adjust or drop the offset (see mutateOffset)
+	MutSkeleton                         // move a structural integer or the start by ±1 (see skeletonMutate)
+	MutCoefficient                      // adjust a coefficient constant by ±1-3 (see coefficientMutate)
+	MutPair                             // add consecutive terms in pairs, keeping the sum (see series.PairTerms)
+	MutParity                           // keep the even or the odd terms (see parityMutate)
+	MutNumRestart                       // replace the numerator with a new random tree, keeping the denominator
+	MutDenRestart                       // replace the denominator with a new random tree, keeping the numerator
+	MutAny                              // what runs use: a random choice of the above (MutateCandidate)
 )
 
+// treeMutations is how many MutationTypes act on a tree in the random
+// choice; mutateTree picks among them uniformly. MutSizeFair is not among
+// them, so seeded runs draw the same mutations as before; strategies
+// apply it at a rate of their own instead (see SizeFairRated).
+const treeMutations = 6
+
+// mutationNames are the names of the MutationTypes, in order.
+var mutationNames = []string{"point", "subtree", "hoist", "const", "grow", "shrink", "sizefair", "start", "offset", "skeleton", "coeff", "pair", "parity", "numrestart", "denrestart", "any"}
+
+func (m MutationType) String() string {
+	if m < 0 || int(m) >= len(mutationNames) {
+		return fmt.Sprintf("MutationType(%d)", int(m))
+	}
+	return mutationNames[m]
+}
+
+// MutationNames returns the names ParseMutation accepts.
+func MutationNames() []string {
+	return append([]string(nil), mutationNames...)
+}
+
+// ParseMutation returns the MutationType with the given name.
+func ParseMutation(name string) (MutationType, error) {
+	for i, n := range mutationNames {
+		if n == name {
+			return MutationType(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown mutation: %s (available: %v)", name, mutationNames)
+}
+
 const maxMutationDepth = 4
 
-// MutateCandidate applies a random mutation to a candidate (modifies in place).
-func MutateCandidate(c *series.Candidate, p pool.Pool, rng *rand.Rand) {
+// offsetMutationRate is how often MutateCandidate mutates the offset of a
+// candidate that has one. Candidates without an offset never draw for it,
+// so runs that seed none breed exactly as before offsets existed.
+const offsetMutationRate = 0.1
+
+// MutateCandidate applies a random mutation to a candidate. The candidate's
+// tree fields are replaced, never modified, so trees it shares with its
+// parent are unaffected. A ratio has one of its two series mutated.
+func MutateCandidate(c *series.Candidate, p pool.Pool, rng random.Rand) {
+	mutateCandidate(c, p, rng)
+}
+
+// mutateCandidate is MutateCandidate, returning the mutation it drew.
+func mutateCandidate(c *series.Candidate, p pool.Pool, rng random.Rand) (m MutationType) {
+	if c.Over != nil {
+		onSide(c, rng, func(s *series.Candidate) { m = mutateCandidate(s, p, rng) })
+		return m
+	}
+	if c.Offset != nil && rng.Float64() < offsetMutationRate {
+		mutateOffset(c, rng)
+		return MutOffset
+	}
 	r := rng.Float64()
 	switch {
 	case r < 0.1:
-		// Flip start index (0 ↔ 1)
-		c.Start = 1 - c.Start
+		mutateStart(c, rng)
+		return MutStart
 	case r < 0.55:
-		c.Numerator = mutateTree(c.Numerator, p, rng)
+		c.Numerator, m = mutateTree(c.Numerator, p, rng)
+	default:
+		c.Denominator, m = mutateTree(c.Denominator, p, rng)
+	}
+	return m
+}
+
+// maxMutatedStart caps the start index mutateStart moves to.
+const maxMutatedStart = 10
+
+// mutateStart changes where the sum starts. Half the time it splits off or
+// absorbs one or two leading terms (Start ± k, changing the sum by those
+// terms); otherwise it rewrites the same series from a neighbouring start
+// index (series.Reindex), which changes the trees but not the sum.
+func mutateStart(c *series.Candidate, rng random.Rand) {
+	k := int64(rng.Intn(2) + 1)
+	if rng.Float64() < 0.5 {
+		k = -k
+	}
+	if c.Start+k < 0 || c.Start+k > maxMutatedStart {
+		k = -k
+	}
+	if rng.Float64() < 0.5 {
+		c.Start += k
+		return
+	}
+	*c = *series.Reindex(c, k)
+}
+
+// mutateOffset changes the constant added to the sum: a candidate without
+// one gets a small integer offset; otherwise a quarter of the time the
+// offset is dropped, and the rest of the time one of its constants moves
+// by ±1-3 (see constPerturb), or ±1-3 is added if it has none.
+func mutateOffset(c *series.Candidate, rng random.Rand) {
+	delta := int64(rng.Intn(3) + 1)
+	if rng.Float64() < 0.5 {
+		delta = -delta
+	}
+	switch {
+	case c.Offset == nil:
+		c.Offset = &expr.ConstNode{Val: delta}
+	case rng.Float64() < 0.25:
+		c.Offset = nil
+	case len(expr.ConstIndices(c.Offset)) == 0:
+		c.Offset = &expr.BinaryNode{Op: expr.OpAdd, Left: c.Offset, Right: &expr.ConstNode{Val: delta}}
+	default:
+		c.Offset = constPerturb(c.Offset, rng)
+	}
+}
+
+// skeletonMutate moves one structural integer of c by ±1: a constant in an
+// exponent, a factorial-like argument or a binomial (see
+// expr.ConstIndicesByRole), or the start index, chosen uniformly among
+// them. These are what an error signature points at when the series has
+// the wrong shape, and a step of one is the natural move for them.
+func skeletonMutate(c *series.Candidate, rng random.Rand) {
+	num, _ := expr.ConstIndicesByRole(c.Numerator)
+	den, _ := expr.ConstIndicesByRole(c.Denominator)
+	delta := int64(1)
+	if rng.Float64() < 0.5 {
+		delta = -1
+	}
+	switch k := rng.Intn(len(num) + len(den) + 1); {
+	case k < len(num):
+		c.Numerator = addToConst(c.Numerator, num[k], delta)
+	case k < len(num)+len(den):
+		c.Denominator = addToConst(c.Denominator, den[k-len(num)], delta)
+	default:
+		if c.Start+delta < 0 || c.Start+delta > maxMutatedStart {
+			delta = -delta
+		}
+		c.Start += delta
+	}
+}
+
+// coefficientMutate moves one coefficient constant of c (one that only
+// scales the series; see expr.ConstIndicesByRole) by ±1-3, chosen
+// uniformly across both trees. c is unchanged if it has none.
+func coefficientMutate(c *series.Candidate, rng random.Rand) {
+	_, num := expr.ConstIndicesByRole(c.Numerator)
+	_, den := expr.ConstIndicesByRole(c.Denominator)
+	if len(num)+len(den) == 0 {
+		return
+	}
+	k := rng.Intn(len(num) + len(den))
+	delta := int64(rng.Intn(3) + 1)
+	if rng.Float64() < 0.5 {
+		delta = -delta
+	}
+	if k < len(num) {
+		c.Numerator = addToConst(c.Numerator, num[k], delta)
+	} else {
+		c.Denominator = addToConst(c.Denominator, den[k-len(num)], delta)
+	}
+}
+
+// addToConst returns root with delta added to the constant at preorder
+// index idx, a result of 0 becoming 1 (see nonZeroConst).
+func addToConst(root expr.ExprNode, idx int, delta int64) expr.ExprNode {
+	return expr.ReplaceAt(root, idx, nonZeroConst(expr.NodeAt(root, idx).(*expr.ConstNode).Val+delta))
+}
+
+// parityMutate replaces c with the sum of its terms at even n or at odd
+// n, equally likely (series.SplitParity). The half is a different series,
+// often with a simpler term, that keeps c's shape.
+func parityMutate(c *series.Candidate, rng random.Rand) {
+	even, odd := series.SplitParity(c)
+	if rng.Float64() < 0.5 {
+		*c = *even
+	} else {
+		*c = *odd
+	}
+}
+
+// RestartSide is the tree a restart regenerates; the other is kept.
+type RestartSide int
+
+const (
+	RestartDenominator RestartSide = iota // keep the numerator
+	RestartNumerator                      // keep the denominator
+	RestartEither                         // either, equally likely
+)
+
+var restartSideNames = []string{"den", "num", "either"}
+
+func (s RestartSide) String() string {
+	if s < 0 || int(s) >= len(restartSideNames) {
+		return fmt.Sprintf("RestartSide(%d)", int(s))
+	}
+	return restartSideNames[s]
+}
+
+// ParseRestartSide returns the RestartSide with the given name.
+func ParseRestartSide(name string) (RestartSide, error) {
+	for i, n := range restartSideNames {
+		if n == name {
+			return RestartSide(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown restart side: %s (available: %v)", name, restartSideNames)
+}
+
+// onSide applies f to one series of a ratio c, the dividend or the
+// divisor, equally likely, and keeps the other. f gets a series without
+// Over, so the series transforms it may apply (Reindex, SplitParity, ...)
+// see a plain sum; a divisor is copied first, as c shares it with its
+// parent. Candidates that are not ratios draw nothing extra.
+func onSide(c *series.Candidate, rng random.Rand, f func(*series.Candidate)) {
+	over := c.Over
+	if over == nil {
+		f(c)
+		return
+	}
+	if rng.Float64() < 0.5 {
+		s := over.Clone()
+		f(s)
+		c.Over = s
+		return
+	}
+	c.Over = nil
+	f(c)
+	c.Over = over
+}
+
+// restartTree replaces one tree of c with a new random one from p, as
+// deep as mutations grow them, and keeps the other: a seeded numerator
+// that is already right survives, where any tree mutation of the
+// candidate as a whole would as likely hit it.
+func restartTree(c *series.Candidate, side RestartSide, p pool.Pool, rng random.Rand) {
+	if side == RestartEither {
+		side = RestartSide(rng.Intn(2))
+	}
+	if side == RestartNumerator {
+		c.Numerator = p.RandomTree(rng, maxMutationDepth)
+	} else {
+		c.Denominator = p.RandomTree(rng, maxMutationDepth)
+	}
+}
+
+// mutationRates are a strategy's options that take a fraction of its
+// mutations away from MutateCandidate's draw: restarts (see Restartable)
+// and size-fair mutations (see SizeFairRated).
+type mutationRates struct {
+	restart     float64
+	restartSide RestartSide
+	sizeFair    float64
+}
+
+// mutate mutates c like MutateCandidate, except that at r.restart it
+// restarts r.restartSide instead and otherwise at r.sizeFair applies
+// MutSizeFair, and returns the mutation applied. At rate 0 neither draws
+// anything, so seeded runs breed as they did without the options.
+func (r mutationRates) mutate(c *series.Candidate, p pool.Pool, rng random.Rand) MutationType {
+	if r.restart > 0 && rng.Float64() < r.restart {
+		side := r.restartSide
+		onSide(c, rng, func(s *series.Candidate) {
+			if side == RestartEither {
+				side = RestartSide(rng.Intn(2))
+			}
+			restartTree(s, side, p, rng)
+		})
+		if side == RestartNumerator {
+			return MutNumRestart
+		}
+		return MutDenRestart
+	}
+	if r.sizeFair > 0 && rng.Float64() < r.sizeFair {
+		Mutate(c, MutSizeFair, p, rng)
+		return MutSizeFair
+	}
+	return mutateCandidate(c, p, rng)
+}
+
+// Mutate applies mutation m to c in place, like MutateCandidate but with
+// the operator fixed: tree mutations act on the numerator or the
+// denominator, equally likely, of one series of a ratio.
+func Mutate(c *series.Candidate, m MutationType, p pool.Pool, rng random.Rand) {
+	if m == MutAny {
+		MutateCandidate(c, p, rng)
+		return
+	}
+	onSide(c, rng, func(s *series.Candidate) { mutate(s, m, p, rng) })
+}
+
+// mutate is Mutate for a single series.
+func mutate(c *series.Candidate, m MutationType, p pool.Pool, rng random.Rand) {
+	switch {
+	case m == MutStart:
+		mutateStart(c, rng)
+	case m == MutOffset:
+		mutateOffset(c, rng)
+	case m == MutSkeleton:
+		skeletonMutate(c, rng)
+	case m == MutCoefficient:
+		coefficientMutate(c, rng)
+	case m == MutPair:
+		*c = *series.PairTerms(c)
+	case m == MutParity:
+		parityMutate(c, rng)
+	case m == MutNumRestart:
+		restartTree(c, RestartNumerator, p, rng)
+	case m == MutDenRestart:
+		restartTree(c, RestartDenominator, p, rng)
+	case rng.Float64() < 0.5:
+		c.Numerator = applyTreeMutation(m, c.Numerator, p, rng)
 	default:
-		c.Denominator = mutateTree(c.Denominator, p, rng)
+		c.Denominator = applyTreeMutation(m, c.Denominator, p, rng)
 	}
 }
 
-func mutateTree(root expr.ExprNode, p pool.Pool, rng *rand.Rand) expr.ExprNode {
-	mut := MutationType(rng.Intn(6))
+// Preview is one offspring of parent under mutation m as a strategy would
+// breed it: raw is the mutated copy, child the simplified candidate that
+// enters the population, and ok whether child passes the size limits
+// (strategies replace those that do not with a random candidate).
+func Preview(parent *series.Candidate, m MutationType, p pool.Pool, rng random.Rand) (raw, child *series.Candidate, ok bool) {
+	raw = parent.Clone()
+	Mutate(raw, m, p, rng)
+	child = simplifyCandidate(raw.Clone())
+	return raw, child, candidateOK(child)
+}
+
+func mutateTree(root expr.ExprNode, p pool.Pool, rng random.Rand) (expr.ExprNode, MutationType) {
+	m := MutationType(rng.Intn(treeMutations))
+	return applyTreeMutation(m, root, p, rng), m
+}
+
+func applyTreeMutation(mut MutationType, root expr.ExprNode, p pool.Pool, rng random.Rand) expr.ExprNode {
 	switch mut {
 	case MutPoint:
 		return pointMutate(root, p, rng)
@@ -51,146 +372,105 @@ func mutateTree(root expr.ExprNode, p pool.Pool, rng *rand.Rand) expr.ExprNode {
 		return growMutate(root, p, rng)
 	case MutShrink:
 		return shrinkMutate(root, rng)
+	case MutSizeFair:
+		return sizeFairMutate(root, p, rng)
 	default:
 		return root
 	}
 }
 
 // pointMutate replaces a random node's operation (keeping children).
-func pointMutate(root expr.ExprNode, p pool.Pool, rng *rand.Rand) expr.ExprNode {
-	nodes := collectNodes(root)
-	if len(nodes) == 0 {
-		return root
-	}
-	idx := rng.Intn(len(nodes))
-	target := nodes[idx]
+func pointMutate(root expr.ExprNode, p pool.Pool, rng random.Rand) expr.ExprNode {
+	idx := rng.Intn(root.NodeCount())
 
-	switch n := (*target).(type) {
-	case *expr.VarNode:
-		*target = p.RandomLeaf(rng)
-	case *expr.ConstNode:
-		*target = p.RandomLeaf(rng)
+	switch n := expr.NodeAt(root, idx).(type) {
+	case *expr.VarNode, *expr.ConstNode, *expr.SymbolicConstNode:
+		return expr.ReplaceAt(root, idx, p.RandomLeaf(rng))
 	case *expr.UnaryNode:
-		n.Op = p.RandomUnary(rng)
+		return expr.ReplaceAt(root, idx, &expr.UnaryNode{Op: p.RandomUnary(rng), Child: n.Child})
 	case *expr.BinaryNode:
-		n.Op = p.RandomBinary(rng)
+		return expr.ReplaceAt(root, idx, &expr.BinaryNode{Op: p.RandomBinary(rng), Left: n.Left, Right: n.Right})
 	}
 	return root
 }
 
 // subtreeMutate replaces a random subtree with a new random tree.
-func subtreeMutate(root expr.ExprNode, p pool.Pool, rng *rand.Rand) expr.ExprNode {
-	nodes := collectNodes(root)
-	if len(nodes) == 0 {
-		return p.RandomTree(rng, maxMutationDepth)
-	}
-	idx := rng.Intn(len(nodes))
-	*nodes[idx] = p.RandomTree(rng, maxMutationDepth)
-	return root
+func subtreeMutate(root expr.ExprNode, p pool.Pool, rng random.Rand) expr.ExprNode {
+	idx := rng.Intn(root.NodeCount())
+	return expr.ReplaceAt(root, idx, p.RandomTree(rng, maxMutationDepth))
+}
+
+// sizeFairMutate replaces a random subtree of s nodes with a new random
+// tree of 1 to 2s-1 nodes, uniformly, so the replacement is on average the
+// size of what it replaces and trees do not drift bigger as subtree
+// replacement does. The new tree is at most maxMutationDepth plies deep.
+func sizeFairMutate(root expr.ExprNode, p pool.Pool, rng random.Rand) expr.ExprNode {
+	idx := rng.Intn(root.NodeCount())
+	s := expr.NodeAt(root, idx).NodeCount()
+	return expr.ReplaceAt(root, idx, pool.RandomTreeOfSize(p, rng, 1+rng.Intn(2*s-1), maxMutationDepth))
 }
 
 // hoistMutate replaces the tree with one of its subtrees.
-func hoistMutate(root expr.ExprNode, rng *rand.Rand) expr.ExprNode {
-	nodes := collectNodes(root)
-	if len(nodes) <= 1 {
+func hoistMutate(root expr.ExprNode, rng random.Rand) expr.ExprNode {
+	count := root.NodeCount()
+	if count <= 1 {
 		return root
 	}
-	idx := rng.Intn(len(nodes))
-	return (*nodes[idx]).Clone()
+	return expr.NodeAt(root, rng.Intn(count))
 }
 
 // constPerturb adjusts a random constant by ±1 to ±3.
-func constPerturb(root expr.ExprNode, rng *rand.Rand) expr.ExprNode {
-	consts := collectConsts(root)
+func constPerturb(root expr.ExprNode, rng random.Rand) expr.ExprNode {
+	consts := expr.ConstIndices(root)
 	if len(consts) == 0 {
 		return root
 	}
-	target := consts[rng.Intn(len(consts))]
+	idx := consts[rng.Intn(len(consts))]
 	delta := int64(rng.Intn(3) + 1)
 	if rng.Float64() < 0.5 {
 		delta = -delta
 	}
-	target.Val += delta
-	if target.Val == 0 {
-		target.Val = 1 // avoid zero constants
-	}
-	return root
+	return addToConst(root, idx, delta)
 }
 
-// growMutate wraps a random leaf in a new unary or binary operation.
-func growMutate(root expr.ExprNode, p pool.Pool, rng *rand.Rand) expr.ExprNode {
-	nodes := collectNodes(root)
-	if len(nodes) == 0 {
-		return root
+// nonZeroConst returns a ConstNode for v, with 0 replaced by 1 to avoid
+// zero constants.
+func nonZeroConst(v int64) *expr.ConstNode {
+	if v == 0 {
+		v = 1
 	}
-	idx := rng.Intn(len(nodes))
-	old := *nodes[idx]
+	return &expr.ConstNode{Val: v}
+}
 
+// growMutate wraps a random node in a new unary or binary operation.
+func growMutate(root expr.ExprNode, p pool.Pool, rng random.Rand) expr.ExprNode {
+	idx := rng.Intn(root.NodeCount())
+	old := expr.NodeAt(root, idx)
+
+	var grown expr.ExprNode
 	if rng.Float64() < 0.5 {
-		*nodes[idx] = &expr.UnaryNode{Op: p.RandomUnary(rng), Child: old}
+		grown = &expr.UnaryNode{Op: p.RandomUnary(rng), Child: old}
 	} else {
 		if rng.Float64() < 0.5 {
-			*nodes[idx] = &expr.BinaryNode{Op: p.RandomBinary(rng), Left: old, Right: p.RandomLeaf(rng)}
+			grown = &expr.BinaryNode{Op: p.RandomBinary(rng), Left: old, Right: p.RandomLeaf(rng)}
 		} else {
-			*nodes[idx] = &expr.BinaryNode{Op: p.RandomBinary(rng), Left: p.RandomLeaf(rng), Right: old}
+			grown = &expr.BinaryNode{Op: p.RandomBinary(rng), Left: p.RandomLeaf(rng), Right: old}
 		}
 	}
-	return root
+	return expr.ReplaceAt(root, idx, grown)
 }
 
 // shrinkMutate replaces a non-leaf node with one of its children.
-func shrinkMutate(root expr.ExprNode, rng *rand.Rand) expr.ExprNode {
-	nodes := collectNodes(root)
-	if len(nodes) == 0 {
-		return root
-	}
-	idx := rng.Intn(len(nodes))
-	switch n := (*nodes[idx]).(type) {
+func shrinkMutate(root expr.ExprNode, rng random.Rand) expr.ExprNode {
+	idx := rng.Intn(root.NodeCount())
+	switch n := expr.NodeAt(root, idx).(type) {
 	case *expr.UnaryNode:
-		*nodes[idx] = n.Child
+		return expr.ReplaceAt(root, idx, n.Child)
 	case *expr.BinaryNode:
 		if rng.Float64() < 0.5 {
-			*nodes[idx] = n.Left
-		} else {
-			*nodes[idx] = n.Right
+			return expr.ReplaceAt(root, idx, n.Left)
 		}
+		return expr.ReplaceAt(root, idx, n.Right)
 	}
 	return root
 }
-
-// collectNodes returns pointers to all nodes in the tree (for in-place mutation).
-func collectNodes(root expr.ExprNode) []*expr.ExprNode {
-	var result []*expr.ExprNode
-	collectNodesHelper(&root, &result)
-	return result
-}
-
-func collectNodesHelper(node *expr.ExprNode, result *[]*expr.ExprNode) {
-	*result = append(*result, node)
-	switch n := (*node).(type) {
-	case *expr.UnaryNode:
-		collectNodesHelper(&n.Child, result)
-	case *expr.BinaryNode:
-		collectNodesHelper(&n.Left, result)
-		collectNodesHelper(&n.Right, result)
-	}
-}
-
-// collectConsts returns pointers to all ConstNodes in the tree.
-func collectConsts(root expr.ExprNode) []*expr.ConstNode {
-	var result []*expr.ConstNode
-	collectConstsHelper(root, &result)
-	return result
-}
-
-func collectConstsHelper(node expr.ExprNode, result *[]*expr.ConstNode) {
-	switch n := node.(type) {
-	case *expr.ConstNode:
-		*result = append(*result, n)
-	case *expr.UnaryNode:
-		collectConstsHelper(n.Child, result)
-	case *expr.BinaryNode:
-		collectConstsHelper(n.Left, result)
-		collectConstsHelper(n.Right, result)
-	}
-}
@@ -0,0 +1,33 @@
+package strategy
+
+import "math"
+
+// Origin is how a member of a bred population came to be: Op is the
+// operator that produced it, and Parent the Combined fitness of the
+// fitter of its parents, NaN for a member drawn at random.
+type Origin struct {
+	Op     string
+	Parent float64
+}
+
+// Operators outside the mutations, whose names (MutationType.String)
+// are the other values of Origin.Op.
+const (
+	OpElite     = "elite"     // carried over unchanged
+	OpRandom    = "random"    // drawn afresh: injected, or replacing a rejected child
+	OpCrossover = "crossover" // subtree crossover with no mutation after it
+	OpWide      = "wide"      // consttune's wide exploration: a constant redrawn from [-100, 100]
+	OpGuided    = "guided"    // consttune's perturbation steered by the parent's error
+)
+
+// Attributing is implemented by strategies that record the Origin of
+// every member of the population their last Evolve or EvolveGenomes
+// returned, index for index, so the engine can tell which operators pay
+// off (see engine.OperatorStats). The slice belongs to the strategy and
+// is replaced by the next call; it is nil before the first.
+type Attributing interface {
+	Origins() []Origin
+}
+
+// randomOrigin is the Origin of a member drawn at random.
+var randomOrigin = Origin{Op: OpRandom, Parent: math.NaN()}
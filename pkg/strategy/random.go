@@ -0,0 +1,66 @@
+package strategy
+
+import (
+	"github.com/wildfunctions/genetic_series/pkg/pool"
+	"github.com/wildfunctions/genetic_series/pkg/random"
+	"github.com/wildfunctions/genetic_series/pkg/series"
+)
+
+const randomMaxDepth = 4
+
+func init() {
+	Register("random", func() Strategy { return &RandomStrategy{} })
+}
+
+// RandomStrategy is random search: every generation is drawn afresh from
+// the pool, apart from the best member of the last, which is carried over
+// so the population's best never regresses. It is the baseline the
+// evolutionary strategies should beat, and the smallest complete Strategy.
+type RandomStrategy struct {
+	ratio   bool     // see SetRatio
+	origins []Origin // see Origins
+}
+
+func (s *RandomStrategy) Name() string { return "random" }
+
+func (s *RandomStrategy) Elites(int) int { return 1 }
+
+// SetRatio makes every candidate drawn a ratio of two random series (see
+// Pairing).
+func (s *RandomStrategy) SetRatio(on bool) { s.ratio = on }
+
+// Origins reports every member of the last Evolve as OpRandom, bar the
+// carried-over best.
+func (s *RandomStrategy) Origins() []Origin { return s.origins }
+
+func (s *RandomStrategy) Initialize(p pool.Pool, rng random.Rand, popSize int) []*series.Candidate {
+	pop := make([]*series.Candidate, popSize)
+	for i := range pop {
+		pop[i] = randomCandidate(p, rng, randomMaxDepth, s.ratio)
+	}
+	return pop
+}
+
+func (s *RandomStrategy) Evolve(
+	population []*series.Candidate,
+	fitnesses []series.Fitness,
+	p pool.Pool,
+	rng random.Rand,
+) []*series.Candidate {
+	next := s.Initialize(p, rng, len(population))
+	s.origins = make([]Origin, len(next))
+	for i := range s.origins {
+		s.origins[i] = randomOrigin
+	}
+	if len(next) > 0 {
+		best := 0
+		for i, f := range fitnesses {
+			if f.Combined > fitnesses[best].Combined {
+				best = i
+			}
+		}
+		next[0] = population[best].Clone()
+		s.origins[0] = Origin{Op: OpElite, Parent: fitnesses[best].Combined}
+	}
+	return next
+}
@@ -0,0 +1,40 @@
+package strategy
+
+import (
+	"github.com/wildfunctions/genetic_series/pkg/random"
+	"github.com/wildfunctions/genetic_series/pkg/series"
+)
+
+const (
+	repairProbeTerms = 16 // leading terms of a child checked for domain faults
+	repairAttempts   = 3  // repairs tried on one child before it is left as bred
+)
+
+// repairChild checks the leading terms of c, a simplified child, for a
+// division by zero or a negative factorial argument and, with probability
+// rate, repairs it (see series.Repair) with a repair chosen uniformly
+// among those that apply, simplifying the result. A repaired child may
+// fault further on, so it is checked again, up to repairAttempts repairs;
+// a shift that would move the start past maxMutatedStart is made an offset
+// instead. At
+// rate 0 it draws nothing from rng, so runs without repair breed exactly
+// as before.
+func repairChild(c *series.Candidate, rate float64, rng random.Rand) *series.Candidate {
+	if rate == 0 {
+		return c
+	}
+	f, ok := series.FindDomainFault(c, repairProbeTerms)
+	if !ok || rng.Float64() >= rate {
+		return c
+	}
+	for i := 0; ok && i < repairAttempts; i++ {
+		repairs := series.Repairs(f)
+		r := series.Repair(c, f, repairs[rng.Intn(len(repairs))])
+		if r.Start > maxMutatedStart {
+			r = series.Repair(c, f, series.RepairOffset)
+		}
+		c = simplifyCandidate(r)
+		f, ok = series.FindDomainFault(c, repairProbeTerms)
+	}
+	return c
+}
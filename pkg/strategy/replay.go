@@ -0,0 +1,85 @@
+package strategy
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/wildfunctions/genetic_series/pkg/pool"
+	"github.com/wildfunctions/genetic_series/pkg/random"
+	"github.com/wildfunctions/genetic_series/pkg/series"
+)
+
+func init() {
+	Register("replay", func() Strategy { return &ReplayStrategy{} })
+}
+
+// ReplayStrategy evaluates a fixed list of candidates read from a file, a
+// population's worth per generation, instead of breeding: for re-scoring
+// earlier results against another target or weights, or as a baseline of
+// hand-picked guesses. Once the list is exhausted it starts over. It needs
+// SetReplayFile before Initialize.
+type ReplayStrategy struct {
+	formulas []*series.Candidate
+	next     int // index of the next formula to replay
+}
+
+func (s *ReplayStrategy) Name() string { return "replay" }
+
+// SetReplayFile loads the candidates to replay from path, one LaTeX series
+// per line as for -seed-formula; blank lines and lines starting with % or
+// # are skipped.
+func (s *ReplayStrategy) SetReplayFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var formulas []*series.Candidate
+	sc := bufio.NewScanner(f)
+	for line := 1; sc.Scan(); line++ {
+		text := strings.TrimSpace(sc.Text())
+		if text == "" || strings.HasPrefix(text, "%") || strings.HasPrefix(text, "#") {
+			continue
+		}
+		c, err := series.ParseCandidateLatex(text)
+		if err != nil {
+			return fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		formulas = append(formulas, c)
+	}
+	if err := sc.Err(); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	if len(formulas) == 0 {
+		return fmt.Errorf("%s: no formulas", path)
+	}
+	s.formulas = formulas
+	return nil
+}
+
+func (s *ReplayStrategy) Initialize(p pool.Pool, rng random.Rand, popSize int) []*series.Candidate {
+	s.next = 0
+	return s.batch(popSize)
+}
+
+func (s *ReplayStrategy) Evolve(
+	population []*series.Candidate,
+	fitnesses []series.Fitness,
+	p pool.Pool,
+	rng random.Rand,
+) []*series.Candidate {
+	return s.batch(len(population))
+}
+
+// batch returns clones of the next n formulas.
+func (s *ReplayStrategy) batch(n int) []*series.Candidate {
+	pop := make([]*series.Candidate, n)
+	for i := range pop {
+		pop[i] = s.formulas[s.next].Clone()
+		s.next = (s.next + 1) % len(s.formulas)
+	}
+	return pop
+}
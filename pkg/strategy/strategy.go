@@ -1,18 +1,107 @@
+// Package strategy holds the evolutionary strategies that breed each
+// generation from the last. Strategies are registered by name and may be
+// added by programs that import the package; options reach them through
+// the interfaces below.
 package strategy
 
 import (
 	"fmt"
-	"math/rand"
 
+	"github.com/wildfunctions/genetic_series/pkg/expr"
 	"github.com/wildfunctions/genetic_series/pkg/pool"
+	"github.com/wildfunctions/genetic_series/pkg/random"
 	"github.com/wildfunctions/genetic_series/pkg/series"
 )
 
 // Strategy defines an evolutionary strategy for evolving candidate series.
+// The engine calls Initialize once per attempt, then alternates evaluating
+// the population and calling Evolve with the fitnesses, index for index.
+// All randomness must come from rng and all ops and leaves from p, so that
+// seeded runs repeat and op whitelists hold. A strategy must not mutate
+// the candidates it is given: the engine keeps them for the hall of fame
+// and dedup, so children are built from clones. The population returned
+// should keep its size; the engine evaluates whatever it gets.
+//
+// Optional behaviour is discovered by interface: GenomeStrategy for
+// streaming mode, Elitist to skip re-evaluating elites, and TabuAware,
+// Seedable, Replayable, SkeletonRated, Guidable, Repairing,
+// Restartable, SizeFairRated and Pairing for the
+// matching options. RandomStrategy and ReplayStrategy are minimal
+// implementations to start from.
 type Strategy interface {
+	// Name returns the name the strategy is registered under.
 	Name() string
-	Initialize(p pool.Pool, rng *rand.Rand, popSize int) []*series.Candidate
-	Evolve(population []*series.Candidate, fitnesses []series.Fitness, p pool.Pool, rng *rand.Rand) []*series.Candidate
+	// Initialize returns the first generation, popSize candidates.
+	Initialize(p pool.Pool, rng random.Rand, popSize int) []*series.Candidate
+	// Evolve returns the next generation bred from population, whose
+	// members scored fitnesses.
+	Evolve(population []*series.Candidate, fitnesses []series.Fitness, p pool.Pool, rng random.Rand) []*series.Candidate
+}
+
+// Elitist is implemented by strategies that carry their fittest members
+// into the next generation unchanged. Elites returns how many of a
+// population of n are carried over; the engine keeps the fitness and
+// result of that many of the fittest and reuses them, rather than
+// evaluating the elites again, when they come back. A carried member need
+// not be the same pointer, only the same series (see series.CanonicalKey).
+type Elitist interface {
+	Elites(n int) int
+}
+
+// The option interfaces below are how the engine configures a strategy
+// from engine.Config; it rejects an option set for a strategy that does
+// not implement the matching one. Each is called once, before Initialize.
+
+// Seedable is implemented by strategies that start from a given formula
+// (Config.SeedFormula). SetSeedFormula parses latex and reports whether it
+// is a usable series.
+type Seedable interface {
+	SetSeedFormula(latex string) error
+}
+
+// Replayable is implemented by strategies that read their candidates from
+// a file (Config.ReplayFile).
+type Replayable interface {
+	SetReplayFile(path string) error
+}
+
+// SkeletonRated is implemented by strategies that can aim perturbations
+// at structural constants (Config.SkeletonRate, in [0, 1]).
+type SkeletonRated interface {
+	SetSkeletonRate(rate float64)
+}
+
+// Guidable is implemented by strategies that can step constants toward
+// the target by the sign of a parent's error (Config.Guided).
+type Guidable interface {
+	SetGuided(on bool)
+}
+
+// Repairing is implemented by strategies that repair children with a
+// domain fault (Config.RepairRate, in [0, 1]).
+type Repairing interface {
+	SetRepairRate(rate float64)
+}
+
+// Restartable is implemented by strategies that can regenerate one tree of
+// a child while keeping the other (Config.RestartRate, in [0, 1], and
+// Config.RestartSide).
+type Restartable interface {
+	SetRestart(rate float64, side RestartSide)
+}
+
+// SizeFairRated is implemented by strategies that can make a fraction of
+// their mutations size-fair ones, MutSizeFair (Config.SizeFairRate, in
+// [0, 1]).
+type SizeFairRated interface {
+	SetSizeFairRate(rate float64)
+}
+
+// Pairing is implemented by strategies that can breed ratios of two
+// series (Config.Ratio): candidates whose series.Candidate.Over is set,
+// each mutation acting on one of the two.
+type Pairing interface {
+	SetRatio(on bool)
 }
 
 var registry = map[string]func() Strategy{}
@@ -45,18 +134,48 @@ const (
 	maxNodeCount = 25 // reject candidates with more total nodes than this
 )
 
-// candidateOK checks that a candidate isn't too deep or bloated.
+// eliteCount is the number of elites, at least one, kept at rate of n.
+func eliteCount(n int, rate float64) int {
+	return max(int(float64(n)*rate), 1)
+}
+
+// candidateOK checks that a candidate isn't too deep or bloated. Each
+// series of a ratio is held to the limits on its own.
 func candidateOK(c *series.Candidate) bool {
+	if c.Over != nil {
+		return candidateOK(c.Series()) && candidateOK(c.Over)
+	}
 	return c.Numerator.Depth() <= maxTreeDepth &&
 		c.Denominator.Depth() <= maxTreeDepth &&
 		c.NodeCount() <= maxNodeCount
 }
 
-// randomCandidate creates a random candidate with trees of given max depth.
-func randomCandidate(p pool.Pool, rng *rand.Rand, maxDepth int) *series.Candidate {
-	return &series.Candidate{
+// simplifyCandidate simplifies both trees (folding constant subtrees) and
+// the offset (without folding, which would round an offset such as ln 2)
+// and skips exactly-zero leading terms, returning the simplified
+// candidate. A ratio's divisor is simplified the same way.
+func simplifyCandidate(c *series.Candidate) *series.Candidate {
+	if c.Over != nil {
+		c.Over = simplifyCandidate(c.Over.Clone())
+	}
+	c.Numerator = expr.SimplifyBigFloat(c.Numerator, 128)
+	c.Denominator = expr.SimplifyBigFloat(c.Denominator, 128)
+	if c.Offset != nil {
+		c.Offset = expr.Simplify(c.Offset)
+	}
+	return series.DropZeroLeading(c)
+}
+
+// randomCandidate creates a random candidate with trees of given max depth,
+// and if ratio is set a random series, drawn the same way, to divide it by.
+func randomCandidate(p pool.Pool, rng random.Rand, maxDepth int, ratio bool) *series.Candidate {
+	c := &series.Candidate{
 		Numerator:   p.RandomTree(rng, maxDepth),
 		Denominator: p.RandomTree(rng, maxDepth),
 		Start:       int64(rng.Intn(2)), // 0 or 1
 	}
+	if ratio {
+		c.Over = randomCandidate(p, rng, maxDepth, false)
+	}
+	return c
 }
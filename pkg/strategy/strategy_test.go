@@ -1,12 +1,18 @@
 package strategy
 
 import (
+	"math"
 	"math/big"
 	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/wildfunctions/genetic_series/pkg/expr"
 	"github.com/wildfunctions/genetic_series/pkg/pool"
 	"github.com/wildfunctions/genetic_series/pkg/series"
+	"github.com/wildfunctions/genetic_series/pkg/tabu"
 )
 
 const testPrec = 512
@@ -101,6 +107,153 @@ func TestMutation_TreeRemainValid(t *testing.T) {
 	}
 }
 
+func TestMutateStart_StaysInRange(t *testing.T) {
+	rng := rand.New(rand.NewSource(7))
+	c := &series.Candidate{
+		Numerator:   &expr.ConstNode{Val: 1},
+		Denominator: &expr.UnaryNode{Op: expr.OpFactorial, Child: &expr.VarNode{}},
+	}
+	seen := map[int64]bool{}
+	for i := 0; i < 200; i++ {
+		mutateStart(c, rng)
+		if c.Start < 0 || c.Start > maxMutatedStart {
+			t.Fatalf("start = %d, want in [0, %d]", c.Start, maxMutatedStart)
+		}
+		seen[c.Start] = true
+	}
+	if len(seen) < 3 {
+		t.Errorf("only start indices %v reached", seen)
+	}
+}
+
+func TestMutate_KeepsNonIntegerOffset(t *testing.T) {
+	p, _ := pool.Get("conservative")
+	rng := rand.New(rand.NewSource(5))
+	for _, src := range []string{`\frac{1}{2}`, `\ln(2)`} {
+		offset, err := expr.ParseExprLatex(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, _ := offset.Eval(new(big.Float), testPrec)
+		for i := 0; i < 50; i++ {
+			c := &series.Candidate{
+				Numerator:   p.RandomTree(rng, 3),
+				Denominator: p.RandomTree(rng, 3),
+				Start:       1,
+				Offset:      offset,
+			}
+			if mutateCandidate(c, p, rng) == MutOffset {
+				continue
+			}
+			c = simplifyCandidate(c)
+			if got, ok := c.OffsetValue(testPrec); !ok || got.Cmp(want) != 0 {
+				t.Fatalf("offset %s became %s after mutating and simplifying", src, c.Offset)
+			}
+		}
+	}
+}
+
+func TestMutationAndCrossover_LeaveParentsIntact(t *testing.T) {
+	p, _ := pool.Get("kitchensink")
+	rng := rand.New(rand.NewSource(42))
+
+	for i := 0; i < 500; i++ {
+		a := randomCandidate(p, rng, 4, i%2 == 1)
+		b := randomCandidate(p, rng, 4, i%2 == 1)
+		wantA, wantB := a.String(), b.String()
+
+		child := a.Clone()
+		MutateCandidate(child, p, rng)
+		c1, c2 := CrossoverCandidates(a, b, rng)
+		perturbConstWide(c1, rng, 5)
+		replaceRandomConst(c2, rng, 100)
+
+		if a.String() != wantA || b.String() != wantB {
+			t.Fatalf("iteration %d: parent changed\n  a: %s -> %s\n  b: %s -> %s", i, wantA, a, wantB, b)
+		}
+	}
+}
+
+func TestSizeFairMutate_KeepsSize(t *testing.T) {
+	p, _ := pool.Get("conservative")
+	rng := rand.New(rand.NewSource(5))
+	root := p.RandomTree(rng, 4)
+	for root.NodeCount() < 7 {
+		root = p.RandomTree(rng, 4)
+	}
+	const rounds = 4000
+	total := 0
+	for i := 0; i < rounds; i++ {
+		total += sizeFairMutate(root, p, rng).NodeCount()
+	}
+	// Each replacement is on average the size of what it replaces; only
+	// subtrees over the depth cap's 15 nodes could pull the mean down.
+	if mean := float64(total) / rounds; mean < float64(root.NodeCount())-0.5 || mean > float64(root.NodeCount())+0.5 {
+		t.Errorf("mean size %.2f after size-fair mutation of a %d-node tree", mean, root.NodeCount())
+	}
+}
+
+func TestPreview_AppliesTheNamedOperator(t *testing.T) {
+	p, _ := pool.Get("conservative")
+	rng := rand.New(rand.NewSource(3))
+	parent, err := series.ParseCandidateLatex(`\sum_{n=0}^{\infty} \frac{3}{(2n+1)!}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range MutationNames() {
+		m, err := ParseMutation(name)
+		if err != nil || m.String() != name {
+			t.Fatalf("ParseMutation(%q) = %v, %v", name, m, err)
+		}
+		for i := 0; i < 20; i++ {
+			raw, child, _ := Preview(parent, m, p, rng)
+			if parent.String() != "Sum_{n=0}^{inf} (3) / ((((2 * n) + 1))!)" {
+				t.Fatalf("%s modified the parent: %s", name, parent)
+			}
+			switch m {
+			case MutStart:
+				if raw.Start == parent.Start {
+					t.Errorf("start mutation kept Start %d", raw.Start)
+				}
+			case MutOffset:
+				if raw.Offset == nil || raw.NodeCount() != parent.NodeCount()+1 {
+					t.Errorf("offset mutation of a candidate without one: %s", raw)
+				}
+			case MutSkeleton: // the coefficient 3 is not structural
+				if raw.Numerator.String() != parent.Numerator.String() {
+					t.Errorf("skeleton mutation changed the coefficient: %s", raw)
+				}
+			case MutCoefficient: // the factorial argument and Start are
+				if raw.Start != parent.Start || raw.Denominator.String() != parent.Denominator.String() {
+					t.Errorf("coefficient mutation changed the skeleton: %s", raw)
+				}
+			case MutPair, MutParity:
+				if raw.Start != 0 || !strings.Contains(raw.Denominator.String(), "(4 * n)") {
+					t.Errorf("%s mutation: %s", name, raw)
+				}
+			case MutNumRestart:
+				if raw.Denominator != parent.Denominator {
+					t.Errorf("numerator restart changed the denominator: %s", raw)
+				}
+			case MutDenRestart:
+				if raw.Numerator != parent.Numerator {
+					t.Errorf("denominator restart changed the numerator: %s", raw)
+				}
+			case MutConstPerturb: // one constant, or none if it moved to 0 and back to 1
+				if raw.Start != parent.Start || len(expr.Diff(parent.Numerator, raw.Numerator))+len(expr.Diff(parent.Denominator, raw.Denominator)) > 1 {
+					t.Errorf("const mutation: %s", raw)
+				}
+			}
+			if child == nil {
+				t.Fatalf("%s: nil child", name)
+			}
+		}
+	}
+	if _, err := ParseMutation("teleport"); err == nil {
+		t.Error("ParseMutation accepted an unknown name")
+	}
+}
+
 func TestCrossover_ProducesTwoCandidates(t *testing.T) {
 	p, _ := pool.Get("conservative")
 	rng := rand.New(rand.NewSource(42))
@@ -144,3 +297,364 @@ func TestStrategyRegistry(t *testing.T) {
 		}
 	}
 }
+
+func TestEvolveGenomes_MatchesEvolve(t *testing.T) {
+	p, _ := pool.Get("moderate")
+	target, _ := new(big.Float).SetPrec(testPrec).SetString("2.718281828459045")
+
+	for _, name := range []string{"hillclimb", "tournament"} {
+		s, _ := Get(name)
+		gs, ok := s.(GenomeStrategy)
+		if !ok {
+			t.Fatalf("%s does not implement GenomeStrategy", name)
+		}
+
+		pop := s.Initialize(p, rand.New(rand.NewSource(7)), 40)
+		genomes := make([]series.Genome, len(pop))
+		for i, c := range pop {
+			genomes[i] = series.EncodeCandidate(c)
+		}
+
+		treeRng := rand.New(rand.NewSource(11))
+		genomeRng := rand.New(rand.NewSource(11))
+		for gen := 0; gen < 5; gen++ {
+			fitnesses := evalPopulation(pop, target)
+			pop = s.Evolve(pop, fitnesses, p, treeRng)
+			genomes = gs.EvolveGenomes(genomes, fitnesses, p, genomeRng)
+
+			for i := range pop {
+				if got, want := genomes[i].MustDecode().String(), pop[i].String(); got != want {
+					t.Fatalf("%s gen %d member %d: genome %s, tree %s", name, gen, i, got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestEvolve_RecordsOrigins(t *testing.T) {
+	p, _ := pool.Get("moderate")
+	target, _ := new(big.Float).SetPrec(testPrec).SetString("2.718281828459045")
+
+	known := map[string]bool{OpElite: true, OpRandom: true, OpCrossover: true, OpWide: true, OpGuided: true}
+	for _, m := range MutationNames() {
+		known[m] = true
+	}
+	tune := &ConstantTuneStrategy{}
+	if err := tune.SetSeedFormula(`\sum_{n=0}^{\infty} \frac{1}{(n + 1)! + 2}`); err != nil {
+		t.Fatal(err)
+	}
+	hill, _ := Get("hillclimb")
+	tour, _ := Get("tournament")
+	rnd, _ := Get("random")
+
+	for _, s := range []Strategy{hill, tour, rnd, tune} {
+		rng := rand.New(rand.NewSource(9))
+		pop := s.Initialize(p, rng, 40)
+		a := s.(Attributing)
+		if a.Origins() != nil {
+			t.Errorf("%s: origins before the first Evolve", s.Name())
+		}
+		for gen := 0; gen < 3; gen++ {
+			fitnesses := evalPopulation(pop, target)
+			parents := map[string]bool{}
+			for _, c := range pop {
+				parents[c.String()] = true
+			}
+			pop = s.Evolve(pop, fitnesses, p, rng)
+			origins := a.Origins()
+			if len(origins) != len(pop) {
+				t.Fatalf("%s gen %d: %d origins for %d members", s.Name(), gen, len(origins), len(pop))
+			}
+			elites := 0
+			for i, o := range origins {
+				if !known[o.Op] {
+					t.Errorf("%s gen %d member %d: unknown operator %q", s.Name(), gen, i, o.Op)
+				}
+				if math.IsNaN(o.Parent) != (o.Op == OpRandom) {
+					t.Errorf("%s gen %d member %d: %s with parent fitness %v", s.Name(), gen, i, o.Op, o.Parent)
+				}
+				if o.Op == OpElite {
+					elites++
+					if !parents[pop[i].String()] {
+						t.Errorf("%s gen %d member %d: elite %s is not a parent", s.Name(), gen, i, pop[i])
+					}
+				}
+			}
+			if want := s.(Elitist).Elites(len(pop)); elites != want {
+				t.Errorf("%s gen %d: %d elites, want %d", s.Name(), gen, elites, want)
+			}
+		}
+	}
+}
+
+func TestEvolve_AvoidsTabu(t *testing.T) {
+	p, _ := pool.Get("conservative")
+	target, _ := new(big.Float).SetPrec(testPrec).SetString("2.718281828459045")
+
+	for _, name := range []string{"hillclimb", "tournament"} {
+		s, _ := Get(name)
+		pop := s.Initialize(p, rand.New(rand.NewSource(3)), 60)
+		fitnesses := evalPopulation(pop, target)
+
+		// Mark every child of an unconstrained generation as failed, then
+		// breed the same generation again with the tabu in place.
+		failed := tabu.New(1024, 10)
+		for _, c := range s.Evolve(pop, fitnesses, p, rand.New(rand.NewSource(5))) {
+			failed.Add(tabu.Key(c))
+		}
+		before := failed.Len()
+
+		s.(TabuAware).SetTabu(failed)
+		repeats := 0
+		for _, c := range s.Evolve(pop, fitnesses, p, rand.New(rand.NewSource(5))) {
+			if failed.Contains(tabu.Key(c)) {
+				repeats++
+			}
+		}
+		// Elites are carried over regardless; little else should repeat.
+		if repeats > before/4 {
+			t.Errorf("%s: %d of %d tabu structures bred again", name, repeats, before)
+		}
+	}
+}
+
+func TestEvolve_RepairsDomainFaults(t *testing.T) {
+	p, _ := pool.Get("conservative")
+	target, _ := new(big.Float).SetPrec(testPrec).SetString("2.718281828459045")
+
+	faulty := func(pop []*series.Candidate) int {
+		k := 0
+		for _, c := range pop {
+			if _, ok := series.FindDomainFault(c, repairProbeTerms); ok {
+				k++
+			}
+		}
+		return k
+	}
+	for _, name := range []string{"hillclimb", "tournament"} {
+		s, _ := Get(name)
+		pop := s.Initialize(p, rand.New(rand.NewSource(3)), 200)
+		fitnesses := evalPopulation(pop, target)
+
+		before := faulty(s.Evolve(pop, fitnesses, p, rand.New(rand.NewSource(5))))
+		s.(interface{ SetRepairRate(float64) }).SetRepairRate(1)
+		after := faulty(s.Evolve(pop, fitnesses, p, rand.New(rand.NewSource(5))))
+		if before == 0 || after > before/4 {
+			t.Errorf("%s: %d faulty children without repair, %d with", name, before, after)
+		}
+	}
+
+	c, _ := series.ParseCandidateLatex(`\sum_{n=0}^{\infty} \frac{1}{(n - 3)! \cdot (n - 5)}`)
+	if r := repairChild(c, 0, nil); r != c {
+		t.Errorf("rate 0 changed the child: %s", r)
+	}
+	for seed := int64(0); seed < 20; seed++ {
+		r := repairChild(c, 1, rand.New(rand.NewSource(seed)))
+		if f, ok := series.FindDomainFault(r, repairProbeTerms); ok && r.Start <= maxMutatedStart {
+			t.Errorf("seed %d: %s still faults: %+v", seed, r, f)
+		}
+	}
+}
+
+func TestEvolve_RestartKeepsTheOtherTree(t *testing.T) {
+	p, _ := pool.Get("conservative")
+	target, _ := new(big.Float).SetPrec(testPrec).SetString("2.718281828459045")
+	s := &HillClimbStrategy{}
+	pop := s.Initialize(p, rand.New(rand.NewSource(3)), 200)
+	fitnesses := evalPopulation(pop, target)
+
+	// Hill-climb children are bred from the parent at the same index; the
+	// injected and elite slots are a few percent.
+	keptNumerators := func(next []*series.Candidate) int {
+		k := 0
+		for i, c := range next {
+			if c.Numerator.String() == simplifyCandidate(pop[i].Clone()).Numerator.String() {
+				k++
+			}
+		}
+		return k
+	}
+	before := keptNumerators(s.Evolve(pop, fitnesses, p, rand.New(rand.NewSource(5))))
+	s.SetRestart(1, RestartDenominator)
+	after := keptNumerators(s.Evolve(pop, fitnesses, p, rand.New(rand.NewSource(5))))
+	if after < len(pop)*3/4 || after <= before {
+		t.Errorf("%d of %d numerators kept restarting denominators, %d mutating", after, len(pop), before)
+	}
+
+	if _, err := ParseRestartSide("both"); err == nil {
+		t.Error("ParseRestartSide accepted an unknown side")
+	}
+	c, _ := series.ParseCandidateLatex(`\sum_{n=0}^{\infty} \frac{1}{n!}`)
+	sides := map[bool]int{}
+	for seed := int64(0); seed < 40; seed++ {
+		r := c.Clone()
+		restartTree(r, RestartEither, p, rand.New(rand.NewSource(seed)))
+		if (r.Numerator == c.Numerator) == (r.Denominator == c.Denominator) {
+			t.Fatalf("seed %d: restart kept both or neither tree: %s", seed, r)
+		}
+		sides[r.Numerator == c.Numerator]++
+	}
+	if sides[true] == 0 || sides[false] == 0 {
+		t.Errorf("either side restarted only one tree: %v", sides)
+	}
+}
+
+func TestEvolve_SizeFairRate(t *testing.T) {
+	p, _ := pool.Get("conservative")
+	target, _ := new(big.Float).SetPrec(testPrec).SetString("2.718281828459045")
+	bred := map[string]bool{OpElite: true, OpRandom: true, OpCrossover: true}
+	for _, s := range []interface {
+		Strategy
+		Attributing
+		SizeFairRated
+	}{&HillClimbStrategy{}, &TournamentStrategy{}} {
+		pop := s.Initialize(p, rand.New(rand.NewSource(3)), 100)
+		fitnesses := evalPopulation(pop, target)
+		count := func() (sizeFair, other int) {
+			s.Evolve(pop, fitnesses, p, rand.New(rand.NewSource(5)))
+			for _, o := range s.Origins() {
+				switch {
+				case o.Op == MutSizeFair.String():
+					sizeFair++
+				case !bred[o.Op]:
+					other++
+				}
+			}
+			return sizeFair, other
+		}
+		if sf, _ := count(); sf != 0 {
+			t.Errorf("%s: %d size-fair mutations by default", s.Name(), sf)
+		}
+		s.SetSizeFairRate(1)
+		if sf, other := count(); sf == 0 || other != 0 {
+			t.Errorf("%s at rate 1: %d size-fair mutations, %d others", s.Name(), sf, other)
+		}
+	}
+}
+
+func TestEvolve_RatioMutatesOneSeries(t *testing.T) {
+	p, _ := pool.Get("conservative")
+	target, _ := new(big.Float).SetPrec(testPrec).SetString("3.141592653589793")
+	for _, s := range []interface {
+		Strategy
+		Pairing
+	}{&HillClimbStrategy{}, &TournamentStrategy{}, &RandomStrategy{}} {
+		s.SetRatio(true)
+		pop := s.Initialize(p, rand.New(rand.NewSource(3)), 100)
+		next := s.Evolve(pop, evalPopulation(pop, target), p, rand.New(rand.NewSource(5)))
+		for _, c := range next {
+			if c.Over == nil {
+				t.Fatalf("%s: bred %s, not a ratio", s.Name(), c)
+			}
+		}
+	}
+
+	// A mutation changes the dividend or the divisor, never both.
+	c, _ := series.ParseCandidateLatex(`\frac{\sum_{n=0}^{\infty} \frac{1}{n!}}{\sum_{n=1}^{\infty} \frac{1}{n^{2}}}`)
+	sides := map[bool]int{}
+	for seed := int64(0); seed < 40; seed++ {
+		r := c.Clone()
+		MutateCandidate(r, p, rand.New(rand.NewSource(seed)))
+		top, over := r.Series().String() != c.Series().String(), r.Over.String() != c.Over.String()
+		if top && over {
+			t.Fatalf("seed %d: mutated both series: %s", seed, r)
+		}
+		sides[over]++
+	}
+	if sides[true] == 0 || sides[false] == 0 {
+		t.Errorf("mutations hit one series only: %v", sides)
+	}
+}
+
+func TestConstTune_GuidedConvergesFaster(t *testing.T) {
+	want, _ := series.ParseCandidateLatex(`\sum_{n=0}^{\infty} \frac{9}{(n + 1)! + 7}`)
+	target := series.EvaluateCandidate(want, 256, testPrec).PartialSum
+
+	// Mean generations to 40 correct digits over a fixed set of seeds,
+	// counting a run that never gets there as 40.
+	meanGens := func(guided bool) float64 {
+		const runs, maxGens = 20, 40
+		total := 0
+		for seed := int64(1); seed <= runs; seed++ {
+			s := &ConstantTuneStrategy{}
+			if err := s.SetSeedFormula(`\sum_{n=0}^{\infty} \frac{1}{(n + 1)! + 2}`); err != nil {
+				t.Fatal(err)
+			}
+			s.SetGuided(guided)
+			rng := rand.New(rand.NewSource(seed))
+			pop := s.Initialize(nil, rng, 30)
+			gen := 0
+			for ; gen < maxGens; gen++ {
+				fitnesses := evalPopulation(pop, target)
+				solved := false
+				for _, f := range fitnesses {
+					solved = solved || f.CorrectDigits >= 40
+				}
+				if solved {
+					break
+				}
+				pop = s.Evolve(pop, fitnesses, nil, rng)
+			}
+			total += gen
+		}
+		return float64(total) / runs
+	}
+	plain, guided := meanGens(false), meanGens(true)
+	t.Logf("mean generations to 40 digits: %.1f unguided, %.1f guided", plain, guided)
+	if guided >= plain {
+		t.Errorf("guided consttune took %.1f generations on average, unguided %.1f", guided, plain)
+	}
+}
+
+func TestRandom_KeepsBest(t *testing.T) {
+	p, _ := pool.Get("conservative")
+	s, _ := Get("random")
+	rng := rand.New(rand.NewSource(42))
+	target, _ := new(big.Float).SetPrec(testPrec).SetString("2.718281828459045")
+
+	pop := s.Initialize(p, rng, 30)
+	best := -1e300
+	for gen := 0; gen < 5; gen++ {
+		fitnesses := evalPopulation(pop, target)
+		genBest := fitnesses[0].Combined
+		for _, f := range fitnesses[1:] {
+			genBest = max(genBest, f.Combined)
+		}
+		if genBest < best {
+			t.Fatalf("gen %d: best fell from %v to %v", gen, best, genBest)
+		}
+		best = genBest
+		if pop = s.Evolve(pop, fitnesses, p, rng); len(pop) != 30 {
+			t.Fatalf("population size %d, want 30", len(pop))
+		}
+	}
+}
+
+func TestReplay_CyclesThroughFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "replay.tex")
+	src := "% earlier finds\n\\sum_{n=0}^{\\infty} \\frac{1}{n!}\n\n\\sum_{n=1}^{\\infty} \\frac{1}{n^{2}}\n# last\n\\sum_{n=0}^{\\infty} \\frac{(-1)^{n}}{2n+1}\n"
+	if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s := &ReplayStrategy{}
+	if err := s.SetReplayFile(path); err != nil {
+		t.Fatal(err)
+	}
+	pop := s.Initialize(nil, nil, 2)
+	pop = append(pop, s.Evolve(pop, make([]series.Fitness, 2), nil, nil)...)
+	want := []int64{0, 1, 0, 0}
+	for i, c := range pop {
+		if c.Start != want[i] {
+			t.Errorf("candidate %d = %s, want start %d", i, c, want[i])
+		}
+	}
+	if pop[0].String() != pop[3].String() {
+		t.Errorf("replay did not wrap around: %s vs %s", pop[0], pop[3])
+	}
+
+	bad := filepath.Join(t.TempDir(), "bad.tex")
+	os.WriteFile(bad, []byte("\\sum_{n=0}^{\\infty} \\frac{1}{\n"), 0o644)
+	if err := s.SetReplayFile(bad); err == nil || !strings.Contains(err.Error(), ":1:") {
+		t.Errorf("SetReplayFile(bad) = %v, want an error at line 1", err)
+	}
+}
@@ -0,0 +1,23 @@
+package strategy
+
+import (
+	"github.com/wildfunctions/genetic_series/pkg/series"
+	"github.com/wildfunctions/genetic_series/pkg/tabu"
+)
+
+// tabuRetries is how many times hill climbing re-mutates a parent whose
+// child is on the failure tabu before replacing it with a random candidate.
+const tabuRetries = 3
+
+// TabuAware is implemented by strategies that avoid breeding structures
+// the engine has seen fail (see package tabu). The engine calls SetTabu
+// once, before the first generation; a nil list disables the check.
+type TabuAware interface {
+	SetTabu(failed *tabu.List)
+}
+
+// isTabu reports whether c is on the failure tabu. It costs nothing when
+// the tabu is disabled, so strategies behave exactly as without one.
+func isTabu(failed *tabu.List, c *series.Candidate) bool {
+	return failed != nil && failed.Contains(tabu.Key(c))
+}
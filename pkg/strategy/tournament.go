@@ -1,12 +1,12 @@
 package strategy
 
 import (
-	"math/rand"
 	"sort"
 
-	"github.com/wildfunctions/genetic_series/pkg/expr"
 	"github.com/wildfunctions/genetic_series/pkg/pool"
+	"github.com/wildfunctions/genetic_series/pkg/random"
 	"github.com/wildfunctions/genetic_series/pkg/series"
+	"github.com/wildfunctions/genetic_series/pkg/tabu"
 )
 
 const (
@@ -22,14 +22,47 @@ func init() {
 }
 
 // TournamentStrategy implements tournament selection with crossover and mutation.
-type TournamentStrategy struct{}
+type TournamentStrategy struct {
+	failed     *tabu.List    // structures known to fail; children on it are replaced
+	repairRate float64       // see SetRepairRate
+	mutation   mutationRates // see SetRestart and SetSizeFairRate
+	ratio      bool          // see SetRatio
+	origins    []Origin      // see Origins
+}
 
 func (s *TournamentStrategy) Name() string { return "tournament" }
 
-func (s *TournamentStrategy) Initialize(p pool.Pool, rng *rand.Rand, popSize int) []*series.Candidate {
+func (s *TournamentStrategy) Elites(n int) int { return eliteCount(n, eliteRate) }
+
+func (s *TournamentStrategy) SetTabu(failed *tabu.List) { s.failed = failed }
+
+// SetRepairRate makes children with a domain fault in their leading terms
+// be repaired with probability rate rather than bred as they are (see
+// repairChild).
+func (s *TournamentStrategy) SetRepairRate(rate float64) { s.repairRate = rate }
+
+// SetRestart makes a fraction rate of mutations regenerate one tree of the
+// child, side, and keep the other (see restartTree).
+func (s *TournamentStrategy) SetRestart(rate float64, side RestartSide) {
+	s.mutation.restart, s.mutation.restartSide = rate, side
+}
+
+// SetSizeFairRate makes a fraction rate of the other mutations size-fair
+// ones (MutSizeFair), which MutateCandidate never draws.
+func (s *TournamentStrategy) SetSizeFairRate(rate float64) { s.mutation.sizeFair = rate }
+
+// SetRatio makes the population ratios of two series, every candidate
+// drawn with a random series to divide it by (see Pairing).
+func (s *TournamentStrategy) SetRatio(on bool) { s.ratio = on }
+
+// Origins reports the crossover or mutation behind each child of the last
+// Evolve.
+func (s *TournamentStrategy) Origins() []Origin { return s.origins }
+
+func (s *TournamentStrategy) Initialize(p pool.Pool, rng random.Rand, popSize int) []*series.Candidate {
 	pop := make([]*series.Candidate, popSize)
 	for i := range pop {
-		pop[i] = randomCandidate(p, rng, tournamentMaxDepth)
+		pop[i] = randomCandidate(p, rng, tournamentMaxDepth, s.ratio)
 	}
 	return pop
 }
@@ -38,10 +71,38 @@ func (s *TournamentStrategy) Evolve(
 	population []*series.Candidate,
 	fitnesses []series.Fitness,
 	p pool.Pool,
-	rng *rand.Rand,
+	rng random.Rand,
 ) []*series.Candidate {
+	next, origins := tournamentEvolve(population, fitnesses, p, rng, treeCodec, s.failed, s.repairRate, s.mutation, s.ratio)
+	s.origins = origins
+	return next
+}
+
+func (s *TournamentStrategy) EvolveGenomes(
+	population []series.Genome,
+	fitnesses []series.Fitness,
+	p pool.Pool,
+	rng random.Rand,
+) []series.Genome {
+	next, origins := tournamentEvolve(population, fitnesses, p, rng, genomeCodec, s.failed, s.repairRate, s.mutation, s.ratio)
+	s.origins = origins
+	return next
+}
+
+func tournamentEvolve[G any](
+	population []G,
+	fitnesses []series.Fitness,
+	p pool.Pool,
+	rng random.Rand,
+	cd codec[G],
+	failed *tabu.List,
+	repairRate float64,
+	mr mutationRates,
+	ratio bool,
+) ([]G, []Origin) {
 	n := len(population)
-	next := make([]*series.Candidate, 0, n)
+	next := make([]G, 0, n)
+	origins := make([]Origin, 0, n)
 
 	// Sort indices by fitness (descending)
 	indices := make([]int, n)
@@ -53,45 +114,47 @@ func (s *TournamentStrategy) Evolve(
 	})
 
 	// Elitism: carry over top candidates
-	eliteCount := int(float64(n) * eliteRate)
-	if eliteCount < 1 {
-		eliteCount = 1
-	}
-	for i := 0; i < eliteCount; i++ {
-		next = append(next, population[indices[i]].Clone())
+	elites := eliteCount(n, eliteRate)
+	for i := 0; i < elites; i++ {
+		next = append(next, cd.keep(population[indices[i]]))
+		origins = append(origins, Origin{Op: OpElite, Parent: fitnesses[indices[i]].Combined})
 	}
 
 	// Fill rest via tournament selection + crossover + mutation
 	for len(next) < n {
-		p1 := tournamentSelect(population, fitnesses, rng)
-		p2 := tournamentSelect(population, fitnesses, rng)
+		i1 := tournamentSelect(fitnesses, rng)
+		i2 := tournamentSelect(fitnesses, rng)
+		parent := max(fitnesses[i1].Combined, fitnesses[i2].Combined)
 
-		c1, c2 := CrossoverCandidates(p1, p2, rng)
+		c1, c2 := CrossoverCandidates(cd.load(population[i1]), cd.load(population[i2]), rng)
 
-		// Mutation + simplification
+		// Mutation + simplification + repair
+		o1, o2 := Origin{Op: OpCrossover, Parent: parent}, Origin{Op: OpCrossover, Parent: parent}
 		if rng.Float64() < mutationRate {
-			MutateCandidate(c1, p, rng)
+			o1.Op = mr.mutate(c1, p, rng).String()
 		}
-		c1.Numerator = expr.SimplifyBigFloat(c1.Numerator, 128)
-		c1.Denominator = expr.SimplifyBigFloat(c1.Denominator, 128)
+		c1 = repairChild(simplifyCandidate(c1), repairRate, rng)
 
 		if rng.Float64() < mutationRate {
-			MutateCandidate(c2, p, rng)
+			o2.Op = mr.mutate(c2, p, rng).String()
 		}
-		c2.Numerator = expr.SimplifyBigFloat(c2.Numerator, 128)
-		c2.Denominator = expr.SimplifyBigFloat(c2.Denominator, 128)
+		c2 = repairChild(simplifyCandidate(c2), repairRate, rng)
 
-		// Reject overly deep trees
-		if candidateOK(c1) {
-			next = append(next, c1)
+		// Reject overly deep trees and structures known to fail
+		if candidateOK(c1) && !isTabu(failed, c1) {
+			next = append(next, cd.store(c1))
+			origins = append(origins, o1)
 		} else {
-			next = append(next, randomCandidate(p, rng, tournamentMaxDepth))
+			next = append(next, cd.store(randomCandidate(p, rng, tournamentMaxDepth, ratio)))
+			origins = append(origins, randomOrigin)
 		}
 		if len(next) < n {
-			if candidateOK(c2) {
-				next = append(next, c2)
+			if candidateOK(c2) && !isTabu(failed, c2) {
+				next = append(next, cd.store(c2))
+				origins = append(origins, o2)
 			} else {
-				next = append(next, randomCandidate(p, rng, tournamentMaxDepth))
+				next = append(next, cd.store(randomCandidate(p, rng, tournamentMaxDepth, ratio)))
+				origins = append(origins, randomOrigin)
 			}
 		}
 	}
@@ -102,25 +165,27 @@ func (s *TournamentStrategy) Evolve(
 		injectionCount = 1
 	}
 	for i := 0; i < injectionCount; i++ {
-		idx := eliteCount + rng.Intn(n-eliteCount)
-		next[idx] = randomCandidate(p, rng, tournamentMaxDepth)
+		idx := elites + rng.Intn(n-elites)
+		next[idx] = cd.store(randomCandidate(p, rng, tournamentMaxDepth, ratio))
+		origins[idx] = randomOrigin
 	}
 
-	return next[:n]
+	return next[:n], origins[:n]
 }
 
-func tournamentSelect(pop []*series.Candidate, fitnesses []series.Fitness, rng *rand.Rand) *series.Candidate {
-	bestIdx := rng.Intn(len(pop))
+// tournamentSelect returns the index of the fittest of tournamentSize
+// members drawn at random.
+func tournamentSelect(fitnesses []series.Fitness, rng random.Rand) int {
+	bestIdx := rng.Intn(len(fitnesses))
 	bestFit := fitnesses[bestIdx].Combined
 
 	for i := 1; i < tournamentSize; i++ {
-		idx := rng.Intn(len(pop))
+		idx := rng.Intn(len(fitnesses))
 		if fitnesses[idx].Combined > bestFit {
 			bestIdx = idx
 			bestFit = fitnesses[idx].Combined
 		}
 	}
 
-	return pop[bestIdx].Clone()
+	return bestIdx
 }
-
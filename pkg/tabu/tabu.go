@@ -0,0 +1,126 @@
+// Package tabu keeps a bounded, expiring set of candidate structures that
+// are known to fail (diverge, hit a domain error, or degenerate), so that
+// strategies can avoid breeding them again. It is safe for concurrent use.
+package tabu
+
+import (
+	"hash/fnv"
+	"sync"
+
+	"github.com/wildfunctions/genetic_series/pkg/series"
+)
+
+// List is a FIFO-bounded set of canonical candidate hashes, each expiring
+// a fixed number of generations after it was last added. A nil *List is
+// empty and ignores Add, so callers need not check whether it is enabled.
+type List struct {
+	mu    sync.Mutex
+	ttl   int
+	gen   int
+	added map[uint64]int // key -> generation it was last added
+	ring  []uint64       // insertion order, for eviction
+	next  int            // ring slot the next new key overwrites
+}
+
+// New returns a list holding up to size keys, each for ttl generations.
+func New(size, ttl int) *List {
+	if size < 1 {
+		size = 1
+	}
+	return &List{
+		ttl:   ttl,
+		added: make(map[uint64]int, size),
+		ring:  make([]uint64, 0, size),
+	}
+}
+
+// Key returns the hash of series.CanonicalKey(c), so that rewritings of one
+// series share a key.
+func Key(c *series.Candidate) uint64 {
+	return KeyOf(series.CanonicalKey(c))
+}
+
+// KeyOf hashes a precomputed canonical key.
+func KeyOf(canonical string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(canonical))
+	return h.Sum64()
+}
+
+// Add records key as failed in the current generation. When the list is
+// full the oldest key is evicted.
+func (l *List) Add(key uint64) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, ok := l.added[key]; ok {
+		l.added[key] = l.gen // refresh; keeps its ring slot
+		return
+	}
+	if len(l.ring) < cap(l.ring) {
+		l.ring = append(l.ring, key)
+	} else {
+		delete(l.added, l.ring[l.next])
+		l.ring[l.next] = key
+		l.next = (l.next + 1) % len(l.ring)
+	}
+	l.added[key] = l.gen
+}
+
+// Contains reports whether key was added within the last ttl generations.
+func (l *List) Contains(key uint64) bool {
+	if l == nil {
+		return false
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	g, ok := l.added[key]
+	return ok && l.gen-g < l.ttl
+}
+
+// Advance starts the next generation, expiring keys that are now ttl
+// generations old.
+func (l *List) Advance() {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	l.gen++
+	l.mu.Unlock()
+}
+
+// Len returns the number of keys held, including expired ones not yet
+// evicted.
+func (l *List) Len() int {
+	if l == nil {
+		return 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.added)
+}
+
+// Compact evicts the expired keys, which otherwise stay until a new key
+// needs their slot, and returns how many it evicted. The rest keep their
+// order of eviction.
+func (l *List) Compact() int {
+	if l == nil {
+		return 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	n := len(l.ring)
+	ring := make([]uint64, 0, cap(l.ring))
+	added := make(map[uint64]int, n) // a new map: deleting does not shrink one
+	for i := 0; i < n; i++ {
+		k := l.ring[(l.next+i)%n]
+		if g := l.added[k]; l.gen-g < l.ttl {
+			ring = append(ring, k)
+			added[k] = g
+		}
+	}
+	l.ring, l.added, l.next = ring, added, 0
+	return n - len(ring)
+}
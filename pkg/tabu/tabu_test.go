@@ -0,0 +1,117 @@
+package tabu
+
+import (
+	"testing"
+
+	"github.com/wildfunctions/genetic_series/pkg/expr"
+	"github.com/wildfunctions/genetic_series/pkg/series"
+)
+
+func TestAddContains(t *testing.T) {
+	l := New(8, 3)
+	l.Add(1)
+	if !l.Contains(1) {
+		t.Error("added key not contained")
+	}
+	if l.Contains(2) {
+		t.Error("unknown key contained")
+	}
+	l.Add(1)
+	if l.Len() != 1 {
+		t.Errorf("Len = %d, want 1", l.Len())
+	}
+}
+
+func TestExpiry(t *testing.T) {
+	l := New(8, 3)
+	l.Add(1)
+	l.Advance()
+	l.Add(2)
+	l.Advance()
+	l.Advance() // key 1 is now 3 generations old
+	if l.Contains(1) {
+		t.Error("key 1 still tabu after ttl generations")
+	}
+	if !l.Contains(2) {
+		t.Error("key 2 expired early")
+	}
+
+	l.Add(1) // re-adding refreshes
+	l.Advance()
+	l.Advance()
+	if !l.Contains(1) {
+		t.Error("refreshed key 1 expired early")
+	}
+}
+
+func TestEvictsOldest(t *testing.T) {
+	l := New(3, 100)
+	for k := uint64(1); k <= 5; k++ {
+		l.Add(k)
+	}
+	if l.Len() != 3 {
+		t.Errorf("Len = %d, want 3", l.Len())
+	}
+	for k := uint64(1); k <= 5; k++ {
+		if want := k > 2; l.Contains(k) != want {
+			t.Errorf("Contains(%d) = %v, want %v", k, !want, want)
+		}
+	}
+}
+
+func TestNilList(t *testing.T) {
+	var l *List
+	l.Add(1)
+	l.Advance()
+	if l.Contains(1) || l.Len() != 0 {
+		t.Error("nil list is not empty")
+	}
+}
+
+func TestKeyCanonical(t *testing.T) {
+	// 1/(n+1) from 0 and 1/n from 1 are the same series.
+	a := &series.Candidate{
+		Numerator:   &expr.ConstNode{Val: 1},
+		Denominator: &expr.BinaryNode{Op: expr.OpAdd, Left: &expr.VarNode{}, Right: &expr.ConstNode{Val: 1}},
+	}
+	b := &series.Candidate{
+		Numerator:   &expr.ConstNode{Val: 1},
+		Denominator: &expr.VarNode{},
+		Start:       1,
+	}
+	if Key(a) != Key(b) {
+		t.Error("equivalent candidates have different keys")
+	}
+	b.Start = 2
+	if Key(a) == Key(b) {
+		t.Error("different candidates share a key")
+	}
+}
+
+func TestCompact(t *testing.T) {
+	l := New(4, 2)
+	for k := uint64(1); k <= 4; k++ {
+		l.Add(k) // fills the ring, so the next new key evicts the oldest
+	}
+	l.Advance()
+	l.Add(5) // evicts 1
+	l.Add(3) // refreshed
+	l.Advance()
+	if got := l.Compact(); got != 2 {
+		t.Errorf("Compact evicted %d keys, want 2 (keys 2 and 4)", got)
+	}
+	if l.Len() != 2 || !l.Contains(3) || !l.Contains(5) {
+		t.Fatalf("after Compact: Len = %d, Contains(3) = %v, Contains(5) = %v", l.Len(), l.Contains(3), l.Contains(5))
+	}
+	for k := uint64(6); k <= 8; k++ {
+		l.Add(k)
+	}
+	// Full again: 3 went in first, so it goes first.
+	if l.Contains(3) || !l.Contains(5) || !l.Contains(8) {
+		t.Errorf("after refilling: Contains(3) = %v, Contains(5) = %v, Contains(8) = %v", l.Contains(3), l.Contains(5), l.Contains(8))
+	}
+	var nl *List
+	if nl.Compact() != 0 {
+		t.Error("nil list compacted keys")
+	}
+}